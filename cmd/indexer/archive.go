@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/forge"
+	"github.com/yourorg/agent/internal/indexarchive"
+	"github.com/yourorg/agent/internal/rag"
+)
+
+func cmdExportIndex() {
+	fs := flag.NewFlagSet("export-index", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	output := fs.String("o", "index.tar.gz", "Archive to write")
+	fs.Parse(os.Args[2:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+	unlock, err := acquireRefreshLock(absPath)
+	if err != nil {
+		log.Fatalf("Export skipped: %v", err)
+	}
+	defer unlock()
+
+	// The commit lets an import tell how far the checkout has moved on.
+	commit, _ := forge.Git{Dir: absPath}.Head()
+
+	var manifest *indexarchive.Manifest
+	err = writeFileWith(*output, func(w io.Writer) error {
+		var err error
+		manifest, err = indexarchive.Export(w, absPath, commit)
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	var size int64
+	for _, f := range manifest.Files {
+		size += f.Size
+	}
+	fmt.Printf("Wrote %s: %d files, %.1f MB of index", *output, len(manifest.Files), float64(size)/(1<<20))
+	if commit != "" {
+		fmt.Printf(" at %s", shortCommit(commit))
+	}
+	fmt.Println()
+}
+
+func cmdImportIndex() {
+	fs := flag.NewFlagSet("import-index", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	fs.Parse(os.Args[2:])
+
+	if fs.NArg() < 1 {
+		log.Fatal("Usage: indexer import-index [-path dir] <archive.tar.gz>")
+	}
+	absPath, _ := filepath.Abs(*projectPath)
+	unlock, err := acquireRefreshLock(absPath)
+	if err != nil {
+		log.Fatalf("Import skipped: %v", err)
+	}
+	defer unlock()
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to open archive: %v", err)
+	}
+	defer f.Close()
+	manifest, err := indexarchive.Import(f, absPath)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	fmt.Printf("Imported %d index files built %s\n", len(manifest.Files), manifest.CreatedAt.Local().Format("2006-01-02 15:04"))
+
+	// Vector databases record absolute paths; point them at this checkout.
+	if manifest.ProjectRoot != absPath {
+		for _, dbPath := range manifest.Databases(absPath) {
+			store, err := rag.NewSQLiteVectorStore(dbPath, 0)
+			if err != nil {
+				log.Fatalf("Failed to open %s: %v", dbPath, err)
+			}
+			n, err := store.Relocate(manifest.ProjectRoot, absPath)
+			store.Close()
+			if err != nil {
+				log.Fatalf("Failed to relocate %s: %v", dbPath, err)
+			}
+			fmt.Printf("Relocated %d files in %s from %s\n", n, dbPath, manifest.ProjectRoot)
+		}
+	}
+
+	if manifest.Commit == "" {
+		return
+	}
+	head, err := forge.Git{Dir: absPath}.Head()
+	if err == nil && head != manifest.Commit {
+		fmt.Printf("Note: the index was built at %s but HEAD is %s; catch up with:\n  indexer hook refresh -from %s\n",
+			shortCommit(manifest.Commit), shortCommit(head), manifest.Commit)
+	}
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/rag"
+)
+
+// symbolExcerptLines is how much of a file is quoted after a matched symbol.
+const symbolExcerptLines = 30
+
+func cmdAsk() {
+	fs := flag.NewFlagSet("ask", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	provider := fs.String("provider", "claude", "LLM provider (claude, gemini, openai, ollama, llamacpp)")
+	model := fs.String("model", "", "Model name (provider-specific)")
+	reasoningEffort := fs.String("reasoning-effort", "", "Reasoning effort for OpenAI reasoning models (minimal, low, medium, high)")
+	apiKey := fs.String("api-key", "", "API key (or use environment variable)")
+	topK := fs.Int("top-k", 8, "Semantic (RAG) results to retrieve")
+	maxSymbols := fs.Int("max-symbols", 5, "Structural (indexer) results to retrieve")
+	jsonOutput := fs.Bool("json", false, "Output the answer and its sources in JSON format")
+	fs.Parse(os.Args[2:])
+
+	if fs.NArg() < 1 {
+		log.Fatal("Usage: indexer ask \"<question>\"")
+	}
+
+	question := fs.Arg(0)
+	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+
+	if *apiKey == "" {
+		switch *provider {
+		case "claude":
+			*apiKey = os.Getenv("CLAUDE_API_KEY")
+		case "gemini":
+			*apiKey = os.Getenv("GEMINI_API_KEY")
+		case "openai":
+			*apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+	}
+
+	codingAgent, err := agent.NewCodingAgent(agent.AgentConfig{
+		ProjectPath: absPath,
+		LLMConfig: agent.LLMConfig{
+			Provider:        *provider,
+			APIKey:          *apiKey,
+			Model:           *model,
+			ReasoningEffort: *reasoningEffort,
+		},
+		SystemPrompts: cfg.SystemPrompts,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create agent: %v", err)
+	}
+
+	sources := append(semanticSources(absPath, question, *topK), structuralSources(absPath, question, *maxSymbols)...)
+	sources = dedupeSources(sources)
+	if len(sources) == 0 {
+		log.Fatal("No relevant code or docs found. Run 'indexer index' and 'indexer rag index' first.")
+	}
+
+	response, err := codingAgent.Ask(context.Background(), question, sources)
+	if err != nil {
+		log.Fatalf("Ask failed: %v", err)
+	}
+
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(struct {
+			Question string         `json:"question"`
+			Answer   string         `json:"answer"`
+			Sources  []agent.Source `json:"sources"`
+		}{question, response.Content, sources}, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Println(response.Content)
+	fmt.Println("\nSources:")
+	for i, src := range sources {
+		fmt.Printf("  [%d] %s\n", i+1, src.Citation())
+	}
+	fmt.Printf("\n[Tokens: %d | Model: %s]\n", response.TokensUsed, response.Model)
+}
+
+// semanticSources searches the RAG index (whole-project or sharded) over
+// code and documentation. A missing index yields no sources.
+func semanticSources(projectPath, question string, topK int) []agent.Source {
+	var (
+		results []*rag.SearchResult
+		err     error
+	)
+
+	if shards := rag.IndexedShards(projectPath); len(shards) > 0 {
+		sharded := newShardedRAGIndexer(projectPath)
+		defer sharded.Close()
+		if err := sharded.Open(shards); err != nil {
+			log.Printf("Warning: failed to open RAG shards: %v", err)
+			return nil
+		}
+		results, err = sharded.Search(question, topK, rag.SearchFilter{})
+	} else {
+		ragIndexer := newRAGIndexer(projectPath)
+		defer ragIndexer.Close()
+		if ragIndexer.Stats().TotalChunks == 0 {
+			return nil
+		}
+		results, err = ragIndexer.Search(question, topK, rag.SearchFilter{})
+	}
+	if err != nil {
+		log.Printf("Warning: semantic search failed: %v", err)
+		return nil
+	}
+
+	sources := make([]agent.Source, 0, len(results))
+	for _, r := range results {
+		kind := "code"
+		if r.Chunk.Language == "markdown" {
+			kind = "docs"
+		}
+		sources = append(sources, agent.Source{
+			Path:      relPath(projectPath, r.Chunk.FilePath),
+			StartLine: r.Chunk.StartLine,
+			EndLine:   r.Chunk.EndLine,
+			Kind:      kind,
+			Content:   r.Chunk.Content,
+		})
+	}
+	return sources
+}
+
+// structuralSources quotes the definitions of symbols whose names or doc
+// comments match the question.
+func structuralSources(projectPath, question string, max int) []agent.Source {
+	idx := indexer.NewIndexer()
+	idx.RegisterParser(indexer.NewGoParser())
+	idx.RegisterParser(indexer.NewPythonParser())
+
+	projIdx, err := idx.IndexProject(projectPath)
+	if err != nil {
+		log.Printf("Warning: failed to load index: %v", err)
+		return nil
+	}
+
+	engine := indexer.NewSearchEngine(projIdx)
+	results := engine.SearchDocumentation(question)
+	if len(results) > max {
+		results = results[:max]
+	}
+
+	var sources []agent.Source
+	for _, r := range results {
+		path := r.FilePath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectPath, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(data), "\n")
+		start := r.Line
+		if start < 1 || start > len(lines) {
+			continue
+		}
+		end := start + symbolExcerptLines - 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		sources = append(sources, agent.Source{
+			Path:      relPath(projectPath, path),
+			StartLine: start,
+			EndLine:   end,
+			Kind:      r.Type,
+			Content:   strings.Join(lines[start-1:end], "\n"),
+		})
+	}
+	return sources
+}
+
+// dedupeSources drops sources whose range lies inside an earlier source
+// from the same file.
+func dedupeSources(sources []agent.Source) []agent.Source {
+	var out []agent.Source
+	for _, src := range sources {
+		covered := false
+		for _, prev := range out {
+			if prev.Path == src.Path && prev.StartLine <= src.StartLine && src.EndLine <= prev.EndLine {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			out = append(out, src)
+		}
+	}
+	return out
+}
+
+func relPath(projectPath, path string) string {
+	if rel, err := filepath.Rel(projectPath, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourorg/agent/internal/rag"
+)
+
+func cmdRAGCompact() {
+	fs := flag.NewFlagSet("rag compact", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	depth := fs.Int("depth", 1, "Directory levels to break sizes down by")
+	top := fs.Int("top", 15, "Largest languages and directories to list (0 = all)")
+	fs.Parse(os.Args[3:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+	if p := cfg.RAG.Store.Provider; p != "" && p != "sqlite" {
+		log.Fatalf("rag compact works on SQLite indexes; the %s store is managed by its database", p)
+	}
+
+	var stores []string
+	if mainDB := filepath.Join(absPath, ".index", "rag_vectors.db"); fileExists(mainDB) {
+		stores = append(stores, mainDB)
+	}
+	for _, shard := range rag.IndexedShards(absPath) {
+		stores = append(stores, rag.ShardDBPath(absPath, shard))
+	}
+	if len(stores) == 0 {
+		log.Fatal("No RAG index found. Run 'indexer rag index' first.")
+	}
+
+	fmt.Printf("\n=== RAG Compact ===\n")
+	var (
+		usage []rag.FileUsage
+		total int64
+	)
+	for _, dbPath := range stores {
+		rel, _ := filepath.Rel(absPath, dbPath)
+		u, size, err := compactStore(dbPath)
+		if err != nil {
+			log.Fatalf("Failed to compact %s: %v", rel, err)
+		}
+		fmt.Printf("  %s: %s on disk\n", rel, formatSize(size))
+		usage = append(usage, u...)
+		total += size
+	}
+	fmt.Printf("\nTotal on disk: %s\n", formatSize(total))
+
+	byLanguage := make(map[string]int64)
+	byDir := make(map[string]int64)
+	for _, u := range usage {
+		lang := u.Language
+		if lang == "" {
+			lang = "(none)"
+		}
+		byLanguage[lang] += u.Bytes
+		byDir[usageDir(absPath, u.FilePath, *depth)] += u.Bytes
+	}
+	printUsage("By language", byLanguage, *top)
+	printUsage("By directory", byDir, *top)
+	fmt.Printf("\nSizes count chunk contents and vectors; keyword and vector indexes add to the total.\n")
+}
+
+// compactStore drops the chunks of deleted files from the index at dbPath
+// and compacts it, returning its usage by file and its size on disk.
+func compactStore(dbPath string) ([]rag.FileUsage, int64, error) {
+	store, err := rag.NewSQLiteVectorStore(dbPath, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer store.Close()
+
+	removed, err := store.RemoveMissingFiles()
+	if err != nil {
+		return nil, 0, err
+	}
+	if removed > 0 {
+		stats, err := store.IndexStats()
+		if err != nil {
+			return nil, 0, err
+		}
+		stats.TotalFiles = max(stats.TotalFiles-removed, 0)
+		stats.LastUpdated = time.Now().Format(time.RFC3339)
+		if err := store.SaveIndexStats(stats); err != nil {
+			return nil, 0, err
+		}
+	}
+	saved, err := store.Compact()
+	if err != nil {
+		return nil, 0, err
+	}
+	fmt.Printf("✓ %s: removed %d deleted files, reclaimed %s\n", filepath.Base(dbPath), removed, formatSize(saved))
+
+	usage, err := store.FileUsage()
+	if err != nil {
+		return nil, 0, err
+	}
+	size, err := store.DiskSize()
+	if err != nil {
+		return nil, 0, err
+	}
+	return usage, size, nil
+}
+
+// usageDir is the directory, depth levels below the project, that a file's
+// size is counted under; files nearer the root count under their own
+// directory.
+func usageDir(projectPath, file string, depth int) string {
+	rel, err := filepath.Rel(projectPath, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "(outside project)"
+	}
+	parts := strings.Split(filepath.ToSlash(filepath.Dir(rel)), "/")
+	if depth > 0 && len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/") + "/"
+}
+
+// printUsage lists sizes largest first, folding all but the top n.
+func printUsage(title string, sizes map[string]int64, n int) {
+	names := make([]string, 0, len(sizes))
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if sizes[names[i]] != sizes[names[j]] {
+			return sizes[names[i]] > sizes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	fmt.Printf("\n%s:\n", title)
+	var rest int64
+	for i, name := range names {
+		if n > 0 && i >= n {
+			rest += sizes[name]
+			continue
+		}
+		fmt.Printf("  %-40s %10s\n", name, formatSize(sizes[name]))
+	}
+	if rest > 0 {
+		fmt.Printf("  %-40s %10s\n", fmt.Sprintf("(%d more)", len(names)-n), formatSize(rest))
+	}
+}
+
+// formatSize formats a byte count in the largest fitting binary unit.
+func formatSize(n int64) string {
+	const unit = 1 << 10
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/configkeys"
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/searchfilter"
+	"github.com/yourorg/agent/internal/workspace"
+)
+
+// configLimit is the number of keys config search reports by default.
+const configLimit = 20
+
+// searchConfig ranks the configuration keys of the project, or of its
+// shards, against query.
+func searchConfig(absPath, query, shards string, filter searchfilter.Filter, limit int, jsonOutput bool) {
+	dirs := []string{"."}
+	if shards != "" {
+		dirs = resolveShards(absPath, shards)
+	}
+	var keys []configkeys.Key
+	for _, dir := range dirs {
+		found, err := configkeys.Load(filepath.Join(absPath, dir))
+		if err != nil {
+			log.Fatalf("Failed to load config files: %v", err)
+		}
+		for _, k := range found {
+			k.File = path.Join(filepath.ToSlash(dir), k.File)
+			if filter.MatchFile(absPath, k.File) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	if limit <= 0 {
+		limit = configLimit
+	}
+	results := configkeys.Search(keys, query, limit)
+
+	if jsonOutput {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Printf("Found %d configuration keys for '%s':\n\n", len(results), query)
+	fmt.Print(configkeys.Format(results))
+}
+
+// keyResults presents configuration keys as search results of kind
+// "config".
+func keyResults(keys []configkeys.Key) []indexer.SearchResult {
+	results := make([]indexer.SearchResult, len(keys))
+	for i, k := range keys {
+		results[i] = indexer.SearchResult{Name: k.Path, Type: "config", FilePath: k.File, Line: k.Line, Signature: k.Value}
+	}
+	return results
+}
+
+// contextKeys is the number of configuration keys fetch_context adds.
+const contextKeys = 10
+
+// relevantConfig returns the configuration keys of the selected roots
+// that a task names. Errors are logged and otherwise ignored, like
+// relevantDeps.
+func relevantConfig(projectPath string, roots []workspace.Root, task string) []configkeys.Result {
+	keys, err := configkeys.Load(projectPath)
+	if err != nil {
+		log.Printf("Warning: failed to read config files: %v", err)
+		return nil
+	}
+	var scoped []configkeys.Key
+	for _, k := range keys {
+		if workspace.Contains(roots, k.File) {
+			scoped = append(scoped, k)
+		}
+	}
+	return configkeys.Relevant(scoped, task, contextKeys)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/deadcode"
+	"github.com/yourorg/agent/internal/indexer"
+)
+
+func cmdDeadcode() {
+	fs := flag.NewFlagSet("deadcode", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	exportedOnly := fs.Bool("exported", false, "Only report exported symbols")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	failOnUnused := fs.Bool("fail", false, "Exit with status 1 if anything unused is found (for CI)")
+	fs.Parse(os.Args[2:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+
+	idx := indexer.NewIndexer()
+	idx.RegisterParser(indexer.NewGoParser())
+	idx.RegisterParser(indexer.NewPythonParser())
+	idx.SetCacheEnabled(true)
+	projIdx, err := idx.IndexProject(absPath)
+	if err != nil {
+		log.Fatalf("Failed to load index: %v", err)
+	}
+	searchEngine := indexer.NewSearchEngine(projIdx)
+
+	report, err := deadcode.Analyze(absPath, deadcode.Options{
+		ExportedOnly: *exportedOnly,
+		Callers: func(name string) int {
+			return len(searchEngine.SearchByCallGraph(name, "callers"))
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to analyze dead code: %v", err)
+	}
+
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(report.Format())
+	}
+	if *failOnUnused && len(report.Unused) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/deps"
+)
+
+func cmdDeps() {
+	fs := flag.NewFlagSet("deps", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	all := fs.Bool("all", false, "Include indirect (transitive) dependencies")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	fs.Parse(os.Args[2:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+
+	found, err := deps.Scan(absPath)
+	if err != nil {
+		log.Fatalf("Failed to read manifests: %v", err)
+	}
+	if fs.NArg() > 0 {
+		found = deps.Match(found, fs.Arg(0))
+	} else if !*all {
+		found = deps.Direct(found)
+	}
+
+	if *jsonOutput {
+		if found == nil {
+			found = []deps.Dependency{}
+		}
+		data, _ := json.MarshalIndent(found, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(found) == 0 {
+		fmt.Println("No dependencies found")
+		return
+	}
+	fmt.Print(deps.Format(found))
+	fmt.Printf("\nTotal: %d dependencies\n", len(found))
+}
+
+// relevantDeps returns the direct dependencies a task mentions. Manifest
+// errors are logged and otherwise ignored so context fetching never fails
+// on them.
+func relevantDeps(projectPath, task string) []deps.Dependency {
+	found, err := deps.Scan(projectPath)
+	if err != nil {
+		log.Printf("Warning: failed to read dependency manifests: %v", err)
+		return nil
+	}
+	return deps.Match(found, task)
+}
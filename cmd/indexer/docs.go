@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/docs"
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/searchfilter"
+	"github.com/yourorg/agent/internal/workspace"
+)
+
+// docsLimit is the number of sections docs search reports by default.
+const docsLimit = 10
+
+// searchDocs ranks the documentation sections of the project, or of its
+// shards, against query.
+func searchDocs(absPath, query, shards string, filter searchfilter.Filter, limit int, jsonOutput bool) {
+	dirs := []string{"."}
+	if shards != "" {
+		dirs = resolveShards(absPath, shards)
+	}
+	var sections []docs.Section
+	for _, dir := range dirs {
+		found, err := docs.Load(filepath.Join(absPath, dir))
+		if err != nil {
+			log.Fatalf("Failed to load docs: %v", err)
+		}
+		for _, s := range found {
+			s.File = path.Join(filepath.ToSlash(dir), s.File)
+			if filter.MatchFile(absPath, s.File) {
+				sections = append(sections, s)
+			}
+		}
+	}
+	if limit <= 0 {
+		limit = docsLimit
+	}
+	results := docs.Search(sections, query, limit)
+
+	if jsonOutput {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Printf("Found %d documentation sections for '%s':\n\n", len(results), query)
+	fmt.Print(docs.Format(results, 3))
+}
+
+// sectionResults presents documentation sections as search results of
+// kind "section".
+func sectionResults(sections []docs.Section) []indexer.SearchResult {
+	results := make([]indexer.SearchResult, len(sections))
+	for i, s := range sections {
+		results[i] = indexer.SearchResult{Name: s.Name(), Type: "section", FilePath: s.File, Line: s.Line}
+	}
+	return results
+}
+
+// contextDocs is the number of documentation sections fetch_context adds.
+const contextDocs = 5
+
+// relevantDocs returns the documentation sections of the selected roots
+// that best match a task. Errors are logged and otherwise ignored, like
+// relevantDeps.
+func relevantDocs(projectPath string, roots []workspace.Root, task string) []docs.Result {
+	sections, err := docs.Load(projectPath)
+	if err != nil {
+		log.Printf("Warning: failed to read documentation: %v", err)
+		return nil
+	}
+	var scoped []docs.Section
+	for _, s := range sections {
+		if workspace.Contains(roots, s.File) {
+			scoped = append(scoped, s)
+		}
+	}
+	return docs.Search(scoped, task, contextDocs)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/yourorg/agent/internal/batch"
+	"github.com/yourorg/agent/internal/rag"
+)
+
+const enrichSystemPrompt = `You summarize source code and documentation for a search index.
+Reply with one or two plain sentences describing what the excerpt does or explains and the key names it defines. No preamble, no markdown.`
+
+// maxEnrichChunkChars caps how much of a chunk is sent for summarization.
+const maxEnrichChunkChars = 12000
+
+// enrichState records a submitted batch so a later run can collect it;
+// batches may take up to 24 hours to finish.
+type enrichState struct {
+	Provider    string    `json:"provider"`
+	Model       string    `json:"model"`
+	BatchID     string    `json:"batch_id"`
+	Chunks      int       `json:"chunks"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+func enrichStatePath(projectPath string) string {
+	return filepath.Join(projectPath, ".index", "enrich_batch.json")
+}
+
+func cmdRAGEnrich() {
+	fs := flag.NewFlagSet("rag enrich", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	provider := fs.String("provider", "claude", "Batch provider (claude, openai)")
+	model := fs.String("model", "", "Model name (default: claude-haiku-4-5 or gpt-4o-mini)")
+	apiKey := fs.String("api-key", "", "API key (or use environment variable)")
+	maxChunks := fs.Int("max-chunks", 10000, "Maximum chunks to submit in one batch")
+	poll := fs.Duration("poll", 30*time.Second, "Interval between batch status checks")
+	noWait := fs.Bool("no-wait", false, "Submit the batch and exit; run again later to collect results")
+	fs.Parse(os.Args[3:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+	// Batch providers are always remote, so the privacy policy applies
+	// whatever embedder built the index.
+	privacy := rag.NewPrivacyFilter(absPath, cfg.RAG.Privacy)
+
+	ragIndexer := newRAGIndexer(absPath)
+	defer ragIndexer.Close()
+	store, err := ragIndexer.SummaryStore()
+	if err != nil {
+		log.Fatalf("Cannot enrich: %v", err)
+	}
+
+	ctx := context.Background()
+	statePath := enrichStatePath(absPath)
+	state, err := loadEnrichState(statePath)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", statePath, err)
+	}
+	if state != nil {
+		// Resume with the provider that owns the pending batch.
+		*provider, *model = state.Provider, state.Model
+	}
+
+	if *apiKey == "" {
+		switch *provider {
+		case "claude":
+			*apiKey = os.Getenv("CLAUDE_API_KEY")
+		case "openai":
+			*apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+	}
+
+	client, err := batch.New(batch.Config{Provider: *provider, APIKey: *apiKey, Model: *model})
+	if err != nil {
+		log.Fatalf("Failed to create batch client: %v", err)
+	}
+
+	if state != nil {
+		fmt.Printf("Resuming batch %s (%d chunks, submitted %s)\n", state.BatchID, state.Chunks, state.SubmittedAt.Format(time.RFC3339))
+	} else {
+		chunks, err := store.UnsummarizedChunks(*maxChunks)
+		if err != nil {
+			log.Fatalf("Failed to list chunks: %v", err)
+		}
+		chunks = slices.DeleteFunc(chunks, func(c *rag.Chunk) bool { return privacy.Excluded(c.FilePath) })
+		if len(chunks) == 0 {
+			fmt.Println("All indexed chunks already have summaries.")
+			return
+		}
+
+		batchID, err := client.Submit(ctx, enrichRequests(chunks, privacy))
+		if err != nil {
+			log.Fatalf("Failed to submit batch: %v", err)
+		}
+		state = &enrichState{
+			Provider:    *provider,
+			Model:       *model,
+			BatchID:     batchID,
+			Chunks:      len(chunks),
+			SubmittedAt: time.Now(),
+		}
+		if err := saveEnrichState(statePath, state); err != nil {
+			log.Fatalf("Failed to record batch %s: %v", batchID, err)
+		}
+		fmt.Printf("Submitted batch %s with %d chunks\n", batchID, len(chunks))
+	}
+
+	if *noWait {
+		status, err := client.Status(ctx, state.BatchID)
+		if err != nil {
+			log.Fatalf("Failed to check batch: %v", err)
+		}
+		if !status.Done {
+			fmt.Printf("Batch is %s (%d/%d done). Run 'indexer rag enrich' again to collect results.\n",
+				status.State, status.Succeeded+status.Failed, status.Total)
+			return
+		}
+	}
+
+	status, err := batch.Wait(ctx, client, state.BatchID, *poll, func(s *batch.Status) {
+		fmt.Printf("  %s: %d succeeded, %d failed of %d\n", s.State, s.Succeeded, s.Failed, s.Total)
+	})
+	if err != nil {
+		log.Fatalf("Failed waiting for batch: %v", err)
+	}
+
+	results, err := client.Results(ctx, state.BatchID)
+	if err != nil {
+		log.Fatalf("Failed to collect results: %v", err)
+	}
+
+	summaries := make(map[string]string)
+	tokens, failed := 0, 0
+	for _, res := range results {
+		tokens += res.TokensUsed
+		if res.Error != "" || res.Content == "" {
+			failed++
+			continue
+		}
+		summaries[res.ID] = res.Content
+	}
+	if err := store.SaveSummaries(summaries); err != nil {
+		log.Fatalf("Failed to save summaries: %v", err)
+	}
+	if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: failed to remove %s: %v", statePath, err)
+	}
+
+	fmt.Printf("\nBatch %s %s: saved %d summaries, %d failed, %d tokens\n", state.BatchID, status.State, len(summaries), failed, tokens)
+	if failed > 0 {
+		fmt.Println("Run 'indexer rag enrich' again to retry chunks without summaries.")
+	}
+}
+
+// enrichRequests builds one summarization request per chunk, keyed by the
+// chunk's content hash.
+func enrichRequests(chunks []*rag.Chunk, privacy *rag.PrivacyFilter) []batch.Request {
+	requests := make([]batch.Request, 0, len(chunks))
+	for _, c := range chunks {
+		content := privacy.Clean(c.FilePath, c.Content)
+		if len(content) > maxEnrichChunkChars {
+			content = content[:maxEnrichChunkChars]
+		}
+		prompt := fmt.Sprintf("File: %s (lines %d-%d, %s %s)\n\n%s", c.FilePath, c.StartLine, c.EndLine, c.Language, c.ChunkType, content)
+		requests = append(requests, batch.Request{
+			ID:        c.Hash,
+			System:    enrichSystemPrompt,
+			Prompt:    prompt,
+			MaxTokens: 200,
+		})
+	}
+	return requests
+}
+
+func loadEnrichState(path string) (*enrichState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state enrichState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func saveEnrichState(path string, state *enrichState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
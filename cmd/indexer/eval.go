@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/eval"
+)
+
+func cmdEval() {
+	if len(os.Args) < 3 || os.Args[2] != "agent" {
+		log.Fatal("Usage: indexer eval agent -suite <suite.json> [options]")
+	}
+
+	fs := flag.NewFlagSet("eval agent", flag.ExitOnError)
+	suitePath := fs.String("suite", "", "Benchmark suite file (JSON: cases with repo, task, expect_diff, test_command)")
+	targets := fs.String("targets", "claude", "Comma-separated provider or provider:model targets, e.g. claude,gemini:gemini-2.5-pro,ollama:qwen2.5-coder")
+	cases := fs.String("cases", "", "Comma-separated case names to run (default: all)")
+	keep := fs.Bool("keep", false, "Keep each run's work directory for inspection")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	fs.Parse(os.Args[3:])
+
+	if *suitePath == "" {
+		log.Fatal("Usage: indexer eval agent -suite <suite.json> [options]")
+	}
+	suite, err := eval.LoadSuite(*suitePath)
+	if err != nil {
+		log.Fatalf("Failed to load suite: %v", err)
+	}
+	selected := suite.Filter(splitList(*cases))
+	if len(selected) == 0 {
+		log.Fatal("No cases selected")
+	}
+
+	var evalTargets []eval.Target
+	for _, t := range splitList(*targets) {
+		target, err := eval.ParseTarget(t)
+		if err != nil {
+			log.Fatal(err)
+		}
+		evalTargets = append(evalTargets, target)
+	}
+
+	name := suite.Name
+	if name == "" {
+		name = filepath.Base(*suitePath)
+	}
+	if !*jsonOutput {
+		fmt.Printf("Running %d case(s) against %d target(s)\n\n", len(selected), len(evalTargets))
+	}
+
+	runner := eval.NewRunner(eval.RunnerConfig{
+		Targets: evalTargets,
+		NewAgent: func(path string, target eval.Target) (*agent.CodingAgent, error) {
+			cfg := loadConfig(path)
+			return agent.NewCodingAgent(agent.AgentConfig{
+				ProjectPath: path,
+				LLMConfig: agent.LLMConfig{
+					Provider: target.Provider,
+					APIKey:   apiKeyFromEnv(target.Provider),
+					Model:    target.Model,
+				},
+				SystemPrompts: cfg.SystemPrompts,
+			})
+		},
+		Pricing:      suite.Pricing,
+		KeepWorkDirs: *keep,
+		OnResult: func(res eval.Result) {
+			if *jsonOutput {
+				return
+			}
+			status := "PASS"
+			if !res.Passed {
+				status = "FAIL"
+			}
+			fmt.Printf("%s %s [%s]\n", status, res.Case, res.Target)
+		},
+	})
+
+	report, err := runner.Run(context.Background(), name, selected)
+	if err != nil {
+		log.Fatalf("Benchmark failed: %v", err)
+	}
+
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Println()
+	fmt.Print(report.Format())
+}
+
+// apiKeyFromEnv returns the API key for provider from its environment
+// variable.
+func apiKeyFromEnv(provider string) string {
+	switch provider {
+	case "claude":
+		return os.Getenv("CLAUDE_API_KEY")
+	case "gemini":
+		return os.Getenv("GEMINI_API_KEY")
+	case "openai":
+		return os.Getenv("OPENAI_API_KEY")
+	case "voyage":
+		return os.Getenv("VOYAGE_API_KEY")
+	case "cohere":
+		return os.Getenv("COHERE_API_KEY")
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/yourorg/agent/internal/ctags"
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/scip"
+)
+
+// defaultExportFiles are the conventional output names of each format.
+var defaultExportFiles = map[string]string{
+	"scip":  "index.scip",
+	"ctags": "tags",
+}
+
+func cmdExport() {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	format := fs.String("format", "scip", "Export format: scip, ctags")
+	output := fs.String("o", "", "Output file (default index.scip for scip, tags for ctags)")
+	fs.Parse(os.Args[2:])
+
+	if _, ok := defaultExportFiles[*format]; !ok {
+		log.Fatalf("Unknown export format %q (supported: scip, ctags)", *format)
+	}
+	if *output == "" {
+		*output = defaultExportFiles[*format]
+	}
+	absPath, _ := filepath.Abs(*projectPath)
+	defs := exportDefinitions(absPath)
+
+	var summary string
+	err := writeFileWith(*output, func(w io.Writer) error {
+		switch *format {
+		case "ctags":
+			tags := make([]ctags.Tag, len(defs))
+			for i, d := range defs {
+				tags[i] = ctags.Tag{Name: d.Name, Kind: d.Kind, File: d.File, Line: d.Line}
+			}
+			n, err := ctags.Write(w, absPath, tags)
+			summary = fmt.Sprintf("%d tags", n)
+			return err
+		default:
+			stats, err := scip.Export(w, absPath, defs, scip.Options{
+				ToolName:  "indexer",
+				Arguments: os.Args[1:],
+			})
+			summary = fmt.Sprintf("%d documents, %d symbols, %d occurrences", stats.Documents, stats.Symbols, stats.Occurrences)
+			return err
+		}
+	})
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	fmt.Printf("Wrote %s: %s\n", *output, summary)
+}
+
+// exportDefinitions returns the symbols of the project's structural index,
+// and of the languages it does not parse, with paths relative to the
+// project.
+func exportDefinitions(absPath string) []scip.Definition {
+	idx := indexer.NewIndexer()
+	idx.RegisterParser(indexer.NewGoParser())
+	idx.RegisterParser(indexer.NewPythonParser())
+	idx.SetCacheEnabled(true)
+	projIdx, err := idx.IndexProject(absPath)
+	if err != nil {
+		log.Fatalf("Failed to load index: %v", err)
+	}
+	searchEngine := indexer.NewSearchEngine(projIdx)
+
+	names := make([]string, 0, len(projIdx.SymbolTable))
+	for name := range projIdx.SymbolTable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var defs []scip.Definition
+	for _, name := range names {
+		details := searchEngine.GetSymbolDetails(name)
+		if details == nil || details.FilePath == "" || details.Line < 1 {
+			continue
+		}
+		file := details.FilePath
+		if filepath.IsAbs(file) {
+			if file, err = filepath.Rel(absPath, file); err != nil {
+				continue
+			}
+		}
+		defs = append(defs, scip.Definition{
+			Name:      name,
+			Kind:      details.Type,
+			File:      filepath.ToSlash(file),
+			Line:      details.Line,
+			Signature: details.Signature,
+			Doc:       details.Doc,
+		})
+	}
+	for _, s := range loadSymbols(absPath).Symbols() {
+		defs = append(defs, scip.Definition{
+			Name:      s.Name,
+			Kind:      s.Kind,
+			File:      s.File,
+			Line:      s.Line,
+			Signature: s.Signature,
+			Doc:       s.Doc,
+		})
+	}
+	return defs
+}
+
+// writeFileWith creates path and fills it with write, removing it again
+// if anything fails so no truncated export is left behind.
+func writeFileWith(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
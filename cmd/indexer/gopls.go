@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/config"
+	"github.com/yourorg/agent/internal/gopls"
+	"github.com/yourorg/agent/internal/lsp"
+	"github.com/yourorg/agent/internal/refs"
+)
+
+// startGopls starts gopls when the -gopls flag or gopls.enabled in
+// .indexer.json asks for it. It returns nil, after a warning, when gopls is
+// unavailable so callers fall back to the structural index.
+func startGopls(projectPath string, cfg config.GoplsConfig, flagSet bool) *gopls.Analyzer {
+	if !flagSet && !cfg.Enabled {
+		return nil
+	}
+	analyzer, err := gopls.NewAnalyzer(context.Background(), projectPath, cfg.Path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; falling back to the built-in parser\n", err)
+		return nil
+	}
+	return analyzer
+}
+
+func cmdRefs() {
+	fs := flag.NewFlagSet("refs", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	useGopls := fs.Bool("gopls", false, "Use gopls for type-accurate Go references")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	fs.Parse(os.Args[2:])
+
+	if fs.NArg() < 1 {
+		log.Fatal("Usage: indexer refs <symbol>")
+	}
+
+	symbolName := fs.Arg(0)
+	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+
+	var locations []string
+	if analyzer := startGopls(absPath, cfg.Gopls, *useGopls); analyzer != nil {
+		found, err := analyzer.References(context.Background(), symbolName)
+		analyzer.Close()
+		if err != nil {
+			log.Fatalf("gopls references failed: %v", err)
+		}
+		locations = formatLocations(analyzer, found)
+	} else {
+		// Without type information, uses are matched by name.
+		found, err := refs.Find(absPath, symbolName)
+		if err != nil {
+			log.Fatalf("Failed to find references: %v", err)
+		}
+		for _, r := range found {
+			locations = append(locations, r.String())
+		}
+	}
+
+	printLocations(fmt.Sprintf("References to '%s'", symbolName), locations, *jsonOutput)
+}
+
+func cmdImpls() {
+	fs := flag.NewFlagSet("impls", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	fs.Parse(os.Args[2:])
+
+	if fs.NArg() < 1 {
+		log.Fatal("Usage: indexer impls <interface-or-type>")
+	}
+
+	symbolName := fs.Arg(0)
+	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+
+	// The structural parser does not resolve interface satisfaction, so
+	// this command always needs gopls.
+	analyzer := startGopls(absPath, cfg.Gopls, true)
+	if analyzer == nil {
+		log.Fatal("impls requires gopls (install with: go install golang.org/x/tools/gopls@latest)")
+	}
+	locations, err := analyzer.Implementations(context.Background(), symbolName)
+	analyzer.Close()
+	if err != nil {
+		log.Fatalf("gopls implementations failed: %v", err)
+	}
+
+	printLocations(fmt.Sprintf("Implementations of '%s'", symbolName), formatLocations(analyzer, locations), *jsonOutput)
+}
+
+func formatLocations(analyzer *gopls.Analyzer, locations []lsp.Location) []string {
+	out := make([]string, 0, len(locations))
+	for _, loc := range locations {
+		out = append(out, analyzer.FormatLocation(loc))
+	}
+	return out
+}
+
+func printLocations(title string, locations []string, jsonOutput bool) {
+	if jsonOutput {
+		if locations == nil {
+			locations = []string{}
+		}
+		data, _ := json.MarshalIndent(locations, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("%s:\n\n", title)
+	for _, loc := range locations {
+		fmt.Printf("  - %s\n", loc)
+	}
+	fmt.Printf("\nTotal: %d locations\n", len(locations))
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourorg/agent/internal/forge"
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/rag"
+	"github.com/yourorg/agent/internal/review"
+	"github.com/yourorg/agent/internal/summary"
+)
+
+const preCommitHook = `#!/bin/sh
+# Installed by "indexer hook install". Set INDEXER_HOOK_MODE=warn to never block.
+exec %q hook pre-commit -path "$(git rev-parse --show-toplevel)" -mode "${INDEXER_HOOK_MODE:-%s}"
+`
+
+// refreshHook starts "hook refresh" in the background so git returns
+// immediately; output goes to .index/refresh.log.
+const refreshHook = `#!/bin/sh
+# Installed by "indexer hook install". Refreshes the indexes in the background.
+root="$(git rev-parse --show-toplevel)"
+mkdir -p "$root/.index"
+nohup %q hook refresh -path "$root" %s >>"$root/.index/refresh.log" 2>&1 &
+`
+
+// refreshHookArgs tells "hook refresh" which commits to diff for each hook.
+var refreshHookArgs = map[string]string{
+	"post-commit":   `-to HEAD`,
+	"post-merge":    `-from ORIG_HEAD -to HEAD`,
+	"post-checkout": `-from "$1" -to "$2"`,
+}
+
+const (
+	refreshLockWait  = 10 * time.Minute
+	refreshLockStale = 30 * time.Minute
+)
+
+func cmdHook() {
+	if len(os.Args) < 3 {
+		log.Fatal("Usage: indexer hook <subcommand> [options]\nSubcommands: pre-commit, refresh, install")
+	}
+
+	switch os.Args[2] {
+	case "pre-commit":
+		cmdHookPreCommit()
+	case "refresh":
+		cmdHookRefresh()
+	case "install":
+		cmdHookInstall()
+	default:
+		log.Fatalf("Unknown hook subcommand: %s\nAvailable: pre-commit, refresh, install", os.Args[2])
+	}
+}
+
+func cmdHookPreCommit() {
+	fs := flag.NewFlagSet("hook pre-commit", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	mode := fs.String("mode", "", "block (exit 1 on findings) or warn (default: hook.mode in .indexer.json, else block)")
+	jsonOutput := fs.Bool("json", false, "Output findings in JSON format")
+	fs.Parse(os.Args[3:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+
+	if *mode == "" {
+		*mode = cfg.Hook.Mode
+	}
+	if *mode == "" {
+		*mode = "block"
+	}
+	if *mode != "block" && *mode != "warn" {
+		log.Fatalf("Unknown -mode %q (want block or warn)", *mode)
+	}
+
+	git := forge.Git{Dir: absPath}
+	diff, err := git.StagedDiff()
+	if err != nil {
+		log.Fatalf("Failed to read staged diff: %v", err)
+	}
+
+	files := review.ParseDiff(diff)
+	if len(files) == 0 {
+		return
+	}
+	for i := range files {
+		if !strings.HasSuffix(files[i].Path, ".go") {
+			continue
+		}
+		// Missing content (e.g. a deleted file) just skips content-based checks.
+		files[i].Content, _ = git.StagedContent(files[i].Path)
+	}
+
+	idx := indexer.NewIndexer()
+	idx.RegisterParser(indexer.NewGoParser())
+	idx.RegisterParser(indexer.NewPythonParser())
+
+	projIdx, err := idx.IndexProject(absPath)
+	if err != nil {
+		log.Fatalf("Failed to load index: %v", err)
+	}
+
+	findings := review.NewReviewer(absPath, projIdx).Review(files)
+
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Println(string(data))
+	} else if len(findings) > 0 {
+		fmt.Fprintf(os.Stderr, "indexer: %d possible issue(s) in staged changes:\n\n", len(findings))
+		for _, f := range findings {
+			fmt.Fprintf(os.Stderr, "%s\n\n", f)
+		}
+	}
+
+	if len(findings) > 0 && *mode == "block" {
+		fmt.Fprintln(os.Stderr, "Commit blocked. Update the files above, or commit with --no-verify / INDEXER_HOOK_MODE=warn.")
+		os.Exit(1)
+	}
+}
+
+// cmdHookRefresh incrementally updates the structural index and any RAG
+// index after HEAD moves. It is run by the post-commit, post-merge and
+// post-checkout hooks.
+func cmdHookRefresh() {
+	fs := flag.NewFlagSet("hook refresh", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	from := fs.String("from", "", "Previous commit (default: the parent of -to)")
+	to := fs.String("to", "HEAD", "New commit")
+	noRAG := fs.Bool("no-rag", false, "Only refresh the structural index")
+	fs.Parse(os.Args[3:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+	loadConfig(absPath)
+
+	unlock, err := acquireRefreshLock(absPath)
+	if err != nil {
+		log.Fatalf("Refresh skipped: %v", err)
+	}
+	defer unlock()
+
+	start := time.Now()
+	fmt.Printf("[%s] refreshing indexes for %s\n", start.Format(time.RFC3339), *to)
+
+	// The structural indexer's cache re-parses only files that changed.
+	idx := indexer.NewIndexer()
+	idx.RegisterParser(indexer.NewGoParser())
+	idx.RegisterParser(indexer.NewPythonParser())
+	idx.SetCacheEnabled(true)
+	projIdx, err := idx.IndexProject(absPath)
+	if err != nil {
+		log.Fatalf("Structural refresh failed: %v", err)
+	}
+	fmt.Printf("Structural index: %d modules, %d symbols\n", len(projIdx.Modules), len(projIdx.SymbolTable))
+	if _, err := summary.Ensure(absPath, projIdx); err != nil {
+		log.Printf("Warning: failed to refresh project summary: %v", err)
+	}
+
+	if !*noRAG {
+		refreshRAG(absPath, *from, *to)
+	}
+	fmt.Printf("Done in %s\n", time.Since(start).Round(time.Millisecond))
+}
+
+// refreshRAG re-embeds the files changed between from and to in whichever
+// RAG index (single or sharded) already exists. It never builds a new one.
+func refreshRAG(projectPath, from, to string) {
+	git := forge.Git{Dir: projectPath}
+	changed, err := git.ChangedFiles(from, to)
+	if err != nil {
+		log.Printf("Warning: failed to list changed files: %v", err)
+		return
+	}
+	if len(changed) == 0 {
+		return
+	}
+	root, err := git.Toplevel()
+	if err != nil {
+		log.Printf("Warning: failed to locate repository root: %v", err)
+		return
+	}
+	paths := make([]string, len(changed))
+	for i, f := range changed {
+		paths[i] = filepath.Join(root, filepath.FromSlash(f))
+	}
+	refreshRAGFiles(projectPath, paths)
+}
+
+// refreshRAGFiles re-embeds the given absolute paths in whichever RAG
+// index already exists, and drops those that were deleted.
+func refreshRAGFiles(projectPath string, paths []string) {
+	var (
+		updated, removed int
+		err              error
+	)
+	if shards := rag.IndexedShards(projectPath); len(shards) > 0 {
+		sharded := newShardedRAGIndexer(projectPath)
+		defer sharded.Close()
+		if err := sharded.Open(shards); err != nil {
+			log.Printf("Warning: failed to open RAG shards: %v", err)
+			return
+		}
+		updated, removed, err = sharded.RefreshFiles(paths)
+	} else {
+		ragIndexer := newRAGIndexer(projectPath)
+		defer ragIndexer.Close()
+		if ragIndexer.Stats().TotalChunks == 0 {
+			return
+		}
+		updated, removed, err = ragIndexer.RefreshFiles(paths)
+	}
+	if err != nil {
+		log.Printf("Warning: RAG refresh failed: %v", err)
+	}
+	fmt.Printf("RAG index: %d files re-embedded, %d removed\n", updated, removed)
+}
+
+// acquireRefreshLock serializes refreshes triggered by back-to-back git
+// operations. A lock older than refreshLockStale is assumed abandoned.
+func acquireRefreshLock(projectPath string) (func(), error) {
+	dir := filepath.Join(projectPath, ".index")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	lockPath := filepath.Join(dir, "refresh.lock")
+
+	deadline := time.Now().Add(refreshLockWait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > refreshLockStale {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("another refresh still holds %s", lockPath)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func cmdHookInstall() {
+	fs := flag.NewFlagSet("hook install", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	mode := fs.String("mode", "block", "Default pre-commit hook mode: block or warn")
+	force := fs.Bool("force", false, "Overwrite existing hooks")
+	preCommit := fs.Bool("pre-commit", true, "Install the pre-commit review hook")
+	refresh := fs.Bool("refresh", true, "Install post-commit/post-merge/post-checkout hooks that refresh the indexes in the background")
+	fs.Parse(os.Args[3:])
+
+	if *mode != "block" && *mode != "warn" {
+		log.Fatalf("Unknown -mode %q (want block or warn)", *mode)
+	}
+
+	absPath, _ := filepath.Abs(*projectPath)
+	hooksDir, err := forge.Git{Dir: absPath}.HooksDir()
+	if err != nil {
+		log.Fatalf("Failed to locate git hooks directory: %v", err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("Failed to resolve indexer binary: %v", err)
+	}
+
+	hooks := make(map[string]string)
+	if *preCommit {
+		hooks["pre-commit"] = fmt.Sprintf(preCommitHook, exe, *mode)
+	}
+	if *refresh {
+		for name, args := range refreshHookArgs {
+			hooks[name] = fmt.Sprintf(refreshHook, exe, args)
+		}
+	}
+	if len(hooks) == 0 {
+		log.Fatal("Nothing to install: both -pre-commit and -refresh are disabled")
+	}
+
+	for name := range hooks {
+		hookPath := filepath.Join(hooksDir, name)
+		if _, err := os.Stat(hookPath); err == nil && !*force {
+			log.Fatalf("%s already exists; rerun with -force to replace it", hookPath)
+		}
+	}
+
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		log.Fatalf("Failed to create hooks directory: %v", err)
+	}
+	for _, name := range []string{"pre-commit", "post-commit", "post-merge", "post-checkout"} {
+		script, ok := hooks[name]
+		if !ok {
+			continue
+		}
+		hookPath := filepath.Join(hooksDir, name)
+		if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+			log.Fatalf("Failed to write hook: %v", err)
+		}
+		if name == "pre-commit" {
+			fmt.Printf("✓ Installed pre-commit hook at %s (mode: %s)\n", hookPath, *mode)
+		} else {
+			fmt.Printf("✓ Installed %s hook at %s (background index refresh)\n", name, hookPath)
+		}
+	}
+}
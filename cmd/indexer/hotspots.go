@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/hotspots"
+	"github.com/yourorg/agent/internal/indexer"
+)
+
+func cmdHotspots() {
+	fs := flag.NewFlagSet("hotspots", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	by := fs.String("by", hotspots.ByFunction, "Rank functions or files: function, file")
+	since := fs.String("since", hotspots.DefaultSince, "Count churn since this date (git log --since syntax)")
+	limit := fs.Int("limit", 20, "Number of hotspots to show (0 = all)")
+	complexityWeight := fs.Float64("w-complexity", 1, "Weight of cyclomatic complexity in the score")
+	churnWeight := fs.Float64("w-churn", 1, "Weight of git churn in the score")
+	fanInWeight := fs.Float64("w-fanin", 1, "Weight of call-graph fan-in in the score")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	fs.Parse(os.Args[2:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+
+	idx := indexer.NewIndexer()
+	idx.RegisterParser(indexer.NewGoParser())
+	idx.RegisterParser(indexer.NewPythonParser())
+	idx.SetCacheEnabled(true)
+	projIdx, err := idx.IndexProject(absPath)
+	if err != nil {
+		log.Fatalf("Failed to load index: %v", err)
+	}
+	searchEngine := indexer.NewSearchEngine(projIdx)
+
+	report, err := hotspots.Analyze(absPath, hotspots.Options{
+		By:    *by,
+		Since: *since,
+		Limit: *limit,
+		Weights: hotspots.Weights{
+			Complexity: *complexityWeight,
+			Churn:      *churnWeight,
+			FanIn:      *fanInWeight,
+		},
+		FanIn: func(name string) int {
+			return len(searchEngine.SearchByCallGraph(name, "callers"))
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to analyze hotspots: %v", err)
+	}
+
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Print(report.Format())
+}
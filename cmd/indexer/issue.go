@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yourorg/agent/internal/config"
+	"github.com/yourorg/agent/internal/forge"
+	"github.com/yourorg/agent/internal/tracker"
+)
+
+// fetchIssue resolves an -issue reference and loads the issue from its tracker.
+func fetchIssue(cfg *config.Config, projectPath, ref string) *tracker.Issue {
+	jiraURL := cfg.Issues.JiraURL
+	if jiraURL == "" {
+		jiraURL = os.Getenv("JIRA_URL")
+	}
+
+	defaultTracker := cfg.Issues.Tracker
+	if defaultTracker == "" {
+		switch {
+		case jiraURL != "":
+			defaultTracker = "jira"
+		case os.Getenv("LINEAR_API_KEY") != "":
+			defaultTracker = "linear"
+		}
+	}
+
+	issueRef, err := tracker.ParseRef(ref, defaultTracker)
+	if err != nil {
+		log.Fatalf("Invalid -issue: %v", err)
+	}
+
+	var fetcher tracker.Fetcher
+	switch issueRef.Tracker {
+	case "jira":
+		email := cfg.Issues.JiraEmail
+		if email == "" {
+			email = os.Getenv("JIRA_EMAIL")
+		}
+		fetcher, err = tracker.NewJiraClient(tracker.JiraConfig{
+			BaseURL: jiraURL,
+			Email:   email,
+			Token:   os.Getenv("JIRA_API_TOKEN"),
+		})
+	case "linear":
+		fetcher, err = tracker.NewLinearClient(os.Getenv("LINEAR_API_KEY"))
+	case "github":
+		repo := issueRef.Repo
+		if repo == "" {
+			repo = githubIssueRepo(cfg, projectPath)
+		}
+		fetcher, err = tracker.NewGitHubIssues(os.Getenv("GITHUB_TOKEN"), repo, cfg.GitHub.APIURL)
+	}
+	if err != nil {
+		log.Fatalf("Failed to create %s client: %v", issueRef.Tracker, err)
+	}
+
+	issue, err := fetcher.Fetch(context.Background(), issueRef.Key)
+	if err != nil {
+		log.Fatalf("Failed to fetch issue: %v", err)
+	}
+	return issue
+}
+
+// githubIssueRepo picks the repository for "#123"-style references.
+func githubIssueRepo(cfg *config.Config, projectPath string) string {
+	switch {
+	case cfg.Issues.GitHubRepo != "":
+		return cfg.Issues.GitHubRepo
+	case cfg.GitHub.Owner != "" && cfg.GitHub.Repo != "":
+		return cfg.GitHub.Owner + "/" + cfg.GitHub.Repo
+	}
+
+	remote := cfg.GitHub.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+	remoteURL, err := forge.Git{Dir: projectPath}.RemoteURL(remote)
+	if err != nil {
+		log.Fatalf("Cannot determine GitHub repository for issue: %v", err)
+	}
+	_, path, err := forge.ParseRemote(remoteURL)
+	if err != nil {
+		log.Fatalf("Cannot determine GitHub repository for issue: %v", err)
+	}
+	return path
+}
+
+// issueTask builds the agent prompt from an issue and optional extra instructions.
+func issueTask(issue *tracker.Issue, instructions string) string {
+	task := issue.Prompt()
+	if instructions != "" {
+		task = fmt.Sprintf("%s\n\nAdditional instructions:\n%s", task, instructions)
+	}
+	return task
+}
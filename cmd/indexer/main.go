@@ -3,16 +3,40 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/callgraph"
+	"github.com/yourorg/agent/internal/config"
+	"github.com/yourorg/agent/internal/configkeys"
+	"github.com/yourorg/agent/internal/cycles"
+	"github.com/yourorg/agent/internal/deps"
+	"github.com/yourorg/agent/internal/diagnostics"
+	"github.com/yourorg/agent/internal/docs"
+	"github.com/yourorg/agent/internal/fuzzy"
+	"github.com/yourorg/agent/internal/grpcapi"
 	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/localonly"
+	"github.com/yourorg/agent/internal/lsp"
+	"github.com/yourorg/agent/internal/metrics"
+	"github.com/yourorg/agent/internal/notify"
 	"github.com/yourorg/agent/internal/rag"
+	"github.com/yourorg/agent/internal/retrieval"
+	"github.com/yourorg/agent/internal/routes"
+	"github.com/yourorg/agent/internal/searchfilter"
+	"github.com/yourorg/agent/internal/sqlschema"
+	"github.com/yourorg/agent/internal/symbols"
+	"github.com/yourorg/agent/internal/textsearch"
+	"github.com/yourorg/agent/internal/tracing"
+	"github.com/yourorg/agent/internal/workspace"
 )
 
 const usage = `Memory Indexer & Coding Agent - Universal AI Coding Assistant
@@ -22,22 +46,127 @@ Usage:
 
 INDEXER COMMANDS:
   index <path>              Index a project and create searchable memory
-  search <query>            Search for symbols in the indexed project
+                            (-workers=8 parses TypeScript, JavaScript, Java, Rust, Ruby and Kotlin
+                            files 8 at a time; default one per CPU; they are kept in
+                            .index/structure.db and parsed again only when changed, -refresh parses all)
+                            (-scip=index.scip merges in a SCIP index from scip-go, scip-typescript, ...
+                            for languages not parsed here; later commands keep it until the file
+                            changes, when it is read again, or is removed)
+                            (-tags=tags merges in a tags file universal-ctags wrote at the project
+                            root, e.g. with ctags -R --fields=+nK, the same way)
+  search <query>            Search for symbols in the indexed project (-shards to fan out)
+                            (-type=fuzzy ranks near matches, e.g. ctxfetchr for ContextFetcher; symbol
+                            search falls back to it when nothing matches exactly)
+                            (-type=text greps file contents through a trigram index; -regex, -i, -limit)
+                            (-type=docs ranks README, ADR and docs/ sections; symbol search also
+                            matches section headings, -kind=section keeps only those)
+                            (-type=config ranks YAML/JSON/TOML/.env keys, e.g. "redis url"; symbol
+                            search also matches key paths, -kind=config keeps only those)
+                            (-type=schema shows the tables a query names with their columns and
+                            migrations; symbol search also matches tables, columns and indexes)
+                            (-kind=func,type -lang=go -in=internal/... restrict the results)
   structure <path>          Show project structure tree
-  callgraph <function>      Show call graph for a function
+  callgraph <function>      Show call graph for a function (-gopls for type-accurate Go results)
+                            (-depth n follows callers/callees transitively; -format tree|edges|json)
+                            (-types type-checks the Go module: same-named functions and methods in
+                            different packages stay apart, and interface calls reach implementations)
   imports <module>          Show import relationships for a module
-  info <symbol>             Get detailed information about a symbol
+                            (-cycles reports import cycles with their shortest path; -json)
+  hotspots                  Rank functions (-by=file: files) by complexity, git churn (-since) and
+                            call-graph fan-in, to point the agent at the riskiest code first (-json)
+  deadcode                  List functions, methods and types nothing in the project refers to
+                            (-exported, -json; -fail exits 1 if any are found, for CI)
+  export                    Write the symbol table and references as a SCIP index for Sourcegraph
+                            and other code-intel tools (-format=scip, -o index.scip), or as a
+                            universal-ctags tags file for vim/emacs (-format=ctags, -o tags)
+  export-index              Pack the structural, RAG and text indexes into one archive for CI
+                            caches or sharing (-o index.tar.gz)
+  import-index <archive>    Unpack an index archive into the project, relocating its paths
+  deps [query]              List dependencies from go.mod, package.json/package-lock.json and
+                            requirements*.txt (-all adds indirect ones; a query filters by name)
+  vulns [id...]             Run govulncheck / npm audit / pip-audit and map findings to the
+                            project symbols and modules that reach them
+  info <symbol>             Get detailed information about a symbol (-gopls)
+  refs <symbol>             Show references to a symbol (-gopls)
+  impls <symbol>            Show implementations of a Go interface or type (requires gopls)
+  routes [path]             List HTTP routes and their handlers (Gin, Echo, chi, gorilla, net/http,
+                            Flask, FastAPI, Django); a path such as /patients keeps those serving it
+  tests <symbol>            List the Go and pytest tests exercising a symbol (-file a.go,b.py for
+                            everything in some files) and the commands running them (-commands, -json)
   fetch_context <task>      Get relevant context for a task/prompt
+                            (-export repomap|files|mentions for Aider, Claude Code, etc.;
+                            -roots api,ml scopes a monorepo workspace to some of its roots)
+  lsp                       Serve the index over the Language Server Protocol (stdio; -gopls for .go files)
+  serve                     Serve the indexer and agent over gRPC (-addr, -metrics-addr)
+  hook install              Install git hooks: pre-commit review, plus post-commit/post-merge/
+                            post-checkout hooks that refresh the indexes in the background
+  hook refresh              Incrementally refresh the structural and RAG indexes (-from, -to)
+  hook pre-commit           Review staged changes against the index (-mode=block|warn)
+  watch                     Refresh the structural and RAG indexes as files change, until
+                            interrupted (-debounce, -no-rag, -metrics-addr)
 
 AGENT COMMANDS:
+  ask <question>            Answer a question from indexed code and docs, with file:line citations
   agent plan <task>         Generate task breakdown for a coding task
   agent chat <message>      Chat with AI using project context
+                            (-session file.json|file.md keeps a multi-turn conversation to continue or
+                            share; -export writes it as Markdown or JSON, with or without a message)
   agent explain <symbol>    Get AI explanation of a code symbol
+  agent run <task>          Plan and execute a task (-create-pr / -create-mr publish it, -ci for pipelines)
+                            (-timeout bounds the whole run, -command-timeout each shell command,
+                            -minimal-env keeps credentials out of shell commands)
+                            (after each edit the agent is told which tests cover the changed files;
+                            -related-tests=false turns this off)
+                            (destructive commands such as rm -r outside the project, dd, mkfs, force
+                            pushes, curl | sh and DROP TABLE are refused unless -allow-dangerous is
+                            given; both cases are logged to .index/audit.log)
+                            (-issue ABC-123 / #12 / URL uses a Jira, Linear or GitHub issue as the task)
+                            Prompts are Go templates; override one per project with
+                            .indexer/prompts/<name>.tmpl (plan, plan_system, chat, chat_system, explain,
+                            explain_system, ask_system, action, action_system), or add instructions to
+                            the system prompts with system_prompts.all/plan/chat/explain/ask/action
+                            in .indexer.json
+  memory [list|add|remove|clear|edit]
+                            View or edit the conventions and learnings the agent remembers for this
+                            project (.index/memory.md); they are added to planning and task prompts
+  summary                   Regenerate .index/PROJECT_SUMMARY.md (modules, entry points, build/test
+                            commands; -llm adds module responsibilities). It is refreshed automatically
+                            when the project changes and prefixed to every agent prompt
+
+EVAL COMMANDS:
+  eval agent -suite <file>  Run scripted tasks against fixture repos for each -targets provider[:model]
+                            and report pass rate, tokens and cost (cases pass on expect_diff and/or
+                            test_command, otherwise when every planned task completes)
 
 RAG COMMANDS:
   rag index <path>          Build semantic RAG index for a project
-  rag search <query>        Perform semantic search
+                            (-shards=a,b or -shards=all to index top-level dirs separately)
+                            (-batch-size, -concurrency, -rpm tune embedding throughput)
+                            (-workers=8 chunks files in parallel and keeps 8 embedding requests in
+                            flight across files, with bounded memory)
+                            (-bulk embeds through the OpenAI/Voyage batch API, ~50% cheaper; the
+                            embedder is set by rag.embedder.provider/model in .indexer.json)
+                            (-embedder=openai embeds with text-embedding-3-small, no local Ollama
+                            needed; -embedding-model=text-embedding-3-large, -dimensions=256 shrinks
+                            the index; rag search and status take the same flags)
+  rag update [path]         Re-embed only files added or changed since the last index (by content
+                            hash) and drop chunks of deleted files (-shards as for rag index)
+  rag search <query>        Perform semantic search (-shards to fan out over shards)
+                            (-lang=python, -type=function,method, -in=api/ and -symbol=Handle
+                            narrow the search before ranking)
+  rag delete <path>...      Remove files from the RAG index without a rebuild: a directory, a file,
+                            or a glob relative to the project where * also matches / (e.g. '*_test.go')
   rag status                Show RAG index statistics
+  rag enrich                Summarize indexed chunks through the Anthropic/OpenAI batch APIs (~50% cheaper)
+                            (-no-wait submits and exits; run again to collect results)
+  rag reembed               Re-embed the index (and shards) after changing the embedding model
+                            (alias rag reindex; -model=<new> migrates to another model)
+  rag compact               Drop chunks of deleted files, vacuum the index and report its size by
+                            language and directory (-depth=2 for finer directories)
+                            (-provider, -model; defaults to rag.embedder; -bulk uses the batch API)
+  rag download-model [name] Fetch a sentence-transformers model (default all-MiniLM-L6-v2) for the
+                            pure-Go local embedder (-embedder=local or rag.embedder.provider "local"),
+                            which indexes without Ollama or an API key
 
 Options:
   -path string              Path to project (default ".")
@@ -45,9 +174,29 @@ Options:
   -depth int                Tree depth for structure (default 3)
   -refresh                  Force refresh index (ignore cache)
   -max-results int          Maximum results for fetch_context (default 10)
-  -provider string          LLM provider: claude, gemini, openai, ollama (default "claude")
+  -provider string          LLM provider: claude, gemini, openai, ollama, llamacpp (default "claude")
   -model string             Model name (provider-specific)
+  -reasoning-effort string  OpenAI reasoning models (o3, o4-mini, gpt-5): minimal, low, medium, high
   -api-key string           API key (or use env: CLAUDE_API_KEY, GEMINI_API_KEY, OPENAI_API_KEY)
+  -vulns                    agent plan/run: add dependency vulnerability findings to the context
+                            (automatic when the task mentions a CVE/GHSA/GO- ID or a vulnerability)
+  -local-only               Only use local models (ollama, llamacpp) and services; any remote API
+                            call fails (also AGENT_LOCAL_ONLY=1 or local_only.enabled in .indexer.json)
+
+Environment:
+  HTTP_PROXY, HTTPS_PROXY, NO_PROXY
+                            Proxy for LLM, embedding, forge, tracker and notification requests; override
+                            with proxy.url / proxy.no_proxy or per provider with proxy.providers.<name>
+                            ("direct" for none) in .indexer.json. The same clients trust extra CAs and
+                            present client certificates from tls.ca_file / tls.cert_file / tls.key_file
+                            (or tls.providers.<name>); tls.insecure_skip_verify disables verification
+  OPENAI_API_KEY, VOYAGE_API_KEY, COHERE_API_KEY
+                            API key of the openai, voyage or cohere embedder (rag.embedder.provider;
+                            voyage-code-3 is tuned for code, cohere defaults to embed-english-v3.0)
+  PGVECTOR_URL              PostgreSQL connection string of a shared index when rag.store.provider is
+                            "pgvector" (or rag.store.url); rag.store.index names the project's index
+  AGENT_LLM_RECORD=<file>   Record every LLM request and response to a JSON Lines file
+  AGENT_LLM_REPLAY=<file>   Serve LLM responses from a recording instead of calling the provider
 
 Examples:
   # Index a project
@@ -59,6 +208,9 @@ Examples:
   # Use Gemini for task planning
   indexer agent plan "add payment processing" -provider=gemini -api-key=$GEMINI_API_KEY
 
+  # Plan with an OpenAI reasoning model via the Responses API
+  indexer agent plan "split the billing service" -provider=openai -model=o3 -reasoning-effort=high
+
   # Chat with context
   indexer agent chat "how does authentication work?" -path=/path/to/project
 
@@ -67,6 +219,7 @@ Examples:
 `
 
 func main() {
+	enableLocalOnly()
 	if len(os.Args) < 2 {
 		fmt.Print(usage)
 		os.Exit(1)
@@ -85,14 +238,52 @@ func main() {
 		cmdCallGraph()
 	case "imports":
 		cmdImports()
+	case "hotspots":
+		cmdHotspots()
+	case "deadcode":
+		cmdDeadcode()
+	case "export":
+		cmdExport()
+	case "export-index":
+		cmdExportIndex()
+	case "import-index":
+		cmdImportIndex()
+	case "deps":
+		cmdDeps()
+	case "vulns":
+		cmdVulns()
 	case "info":
 		cmdInfo()
+	case "refs":
+		cmdRefs()
+	case "routes":
+		cmdRoutes()
+	case "tests":
+		cmdTests()
+	case "impls":
+		cmdImpls()
 	case "fetch_context":
 		cmdFetchContext()
 	case "agent":
 		cmdAgent()
 	case "rag":
 		cmdRAG()
+	case "lsp":
+		cmdLSP()
+	case "serve":
+		cmdServe()
+	case "hook", "hooks":
+		cmdHook()
+	case "watch":
+		cmdWatch()
+	case "ask":
+		cmdAsk()
+	case "eval":
+		cmdEval()
+	case "memory":
+		cmdMemory()
+	case "summary":
+		cmdSummary()
 	case "help", "-h", "--help":
 		fmt.Print(usage)
 	default:
@@ -107,6 +298,9 @@ func cmdIndex() {
 	projectPath := fs.String("path", ".", "Path to the project to index")
 	jsonOutput := fs.Bool("json", false, "Output in JSON format")
 	refresh := fs.Bool("refresh", false, "Force refresh (ignore cache)")
+	workers := fs.Int("workers", 0, "Files of other languages parsed in parallel (default one per CPU)")
+	scipFiles := fs.String("scip", "", "Comma-separated SCIP index files to merge in, e.g. from scip-go or scip-typescript")
+	tagsFiles := fs.String("tags", "", "Comma-separated tags files to merge in, from universal-ctags run at the project root")
 	fs.Parse(os.Args[2:])
 
 	// Get path from args or flag
@@ -141,6 +335,27 @@ func cmdIndex() {
 		log.Fatalf("Indexing failed: %v", err)
 	}
 
+	// The text index is kept alongside for search -type=text.
+	openText := textsearch.Open
+	if *refresh {
+		openText = textsearch.Rebuild
+	}
+	textIdx, err := openText(absPath)
+	if err != nil {
+		log.Fatalf("Text indexing failed: %v", err)
+	}
+
+	// Other languages are saved for later commands, with the index files
+	// merged in.
+	var imports []symbolImport
+	for _, file := range splitList(*scipFiles) {
+		imports = append(imports, symbolImport{path: file, format: symbols.FormatSCIP})
+	}
+	for _, file := range splitList(*tagsFiles) {
+		imports = append(imports, symbolImport{path: file, format: symbols.FormatCtags})
+	}
+	other := indexSymbols(absPath, *workers, *refresh, imports)
+
 	if *jsonOutput {
 		data, _ := json.MarshalIndent(projIdx, "", "  ")
 		fmt.Println(string(data))
@@ -148,15 +363,25 @@ func cmdIndex() {
 		summ := indexer.NewSummarizer()
 		overview := summ.GenerateProjectOverview(projIdx)
 		fmt.Println(overview)
-		fmt.Printf("\n✓ Indexed %d modules, %d symbols\n", len(projIdx.Modules), len(projIdx.SymbolTable))
+		fmt.Printf("\n✓ Indexed %d modules, %d symbols, %d files for text search\n", len(projIdx.Modules), len(projIdx.SymbolTable), textIdx.Files())
+		if other.Len() > 0 {
+			fmt.Printf("✓ Indexed %d symbols in %d %s files\n", other.Len(), other.NumFiles(), strings.Join(other.Languages(), ", "))
+		}
 	}
 }
 
 func cmdSearch() {
 	fs := flag.NewFlagSet("search", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the indexed project")
-	searchType := fs.String("type", "symbol", "Search type: symbol, doc")
+	searchType := fs.String("type", "symbol", "Search type: symbol, fuzzy, doc, docs, config, schema, text")
 	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	shards := fs.String("shards", "", "Comma-separated top-level directories to search (or \"all\")")
+	regex := fs.Bool("regex", false, "Treat a text query as a regular expression")
+	ignoreCase := fs.Bool("i", false, "Case-insensitive text search")
+	limit := fs.Int("limit", 0, "Maximum number of text matches (0 = no limit) or documentation sections (0 = 10)")
+	kinds := fs.String("kind", "", "Comma-separated symbol kinds to keep: func, method, type, struct, interface, class, var, const, section, config, table, column, index")
+	langs := fs.String("lang", "", "Comma-separated languages to keep, e.g. go,python")
+	in := fs.String("in", "", "Comma-separated paths to keep, relative to the project: a directory, dir/... for a subtree, or a glob")
 	fs.Parse(os.Args[2:])
 
 	if fs.NArg() < 1 {
@@ -166,25 +391,108 @@ func cmdSearch() {
 	query := fs.Arg(0)
 	absPath, _ := filepath.Abs(*projectPath)
 
+	filter, err := searchfilter.Parse(*kinds, *langs, *in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *searchType == "text" {
+		if len(filter.Kinds) > 0 {
+			log.Fatal("-kind does not apply to text search")
+		}
+		opts := textsearch.Options{Regex: *regex, IgnoreCase: *ignoreCase, MaxResults: *limit}
+		if *shards != "" {
+			opts.Dirs = resolveShards(absPath, *shards)
+		}
+		if !filter.Empty() {
+			opts.Include = func(file string) bool { return filter.MatchFile(absPath, file) }
+		}
+		searchText(absPath, query, opts, *jsonOutput)
+		return
+	}
+
+	if *searchType == "docs" {
+		if len(filter.Kinds) > 0 {
+			log.Fatal("-kind does not apply to docs search")
+		}
+		searchDocs(absPath, query, *shards, filter, *limit, *jsonOutput)
+		return
+	}
+
+	if *searchType == "config" {
+		if len(filter.Kinds) > 0 {
+			log.Fatal("-kind does not apply to config search")
+		}
+		searchConfig(absPath, query, *shards, filter, *limit, *jsonOutput)
+		return
+	}
+
+	if *searchType == "schema" {
+		searchSchema(absPath, query, *jsonOutput)
+		return
+	}
+
 	idx := indexer.NewIndexer()
 	idx.RegisterParser(indexer.NewGoParser())
 	idx.RegisterParser(indexer.NewPythonParser())
 
-	projIdx, err := idx.IndexProject(absPath)
-	if err != nil {
-		log.Fatalf("Failed to load index: %v", err)
+	// Each shard is indexed on its own and searched in turn; results are concatenated.
+	roots := []string{absPath}
+	if *shards != "" {
+		roots = nil
+		for _, shard := range resolveShards(absPath, *shards) {
+			roots = append(roots, filepath.Join(absPath, shard))
+		}
 	}
 
-	searchEngine := indexer.NewSearchEngine(projIdx)
-
 	var results []indexer.SearchResult
-	switch *searchType {
-	case "symbol":
-		results = searchEngine.SearchSymbol(query)
-	case "doc":
-		results = searchEngine.SearchDocumentation(query)
-	default:
-		results = searchEngine.SearchSymbol(query)
+	for _, root := range roots {
+		projIdx, err := idx.IndexProject(root)
+		if err != nil {
+			log.Fatalf("Failed to load index for %s: %v", root, err)
+		}
+
+		searchEngine := indexer.NewSearchEngine(projIdx)
+		other := loadSymbols(root)
+		var found []indexer.SearchResult
+		switch *searchType {
+		case "symbol":
+			found = searchEngine.SearchSymbol(query)
+			// Languages the structural index does not parse.
+			found = append(found, symbolResults(other.Find(query))...)
+			// Documentation sections are symbols named by their heading.
+			if sections, err := docs.Load(root); err == nil {
+				found = append(found, sectionResults(docs.ByTitle(sections, query))...)
+			}
+			// So are the keys of configuration files.
+			if keys, err := configkeys.Load(root); err == nil {
+				found = append(found, keyResults(configkeys.ByPath(keys, query))...)
+			}
+			// And the tables and columns of SQL files and migrations.
+			if objs, err := sqlschema.Load(root); err == nil {
+				found = append(found, schemaResults(sqlschema.ByName(objs, query))...)
+			}
+			if len(found) == 0 {
+				// Nothing by that name: offer the closest symbols.
+				found = fuzzySymbols(projIdx, searchEngine, other, query, fuzzyLimit)
+			}
+		case "fuzzy":
+			found = fuzzySymbols(projIdx, searchEngine, other, query, fuzzyLimit)
+		case "doc":
+			found = searchEngine.SearchDocumentation(query)
+		default:
+			found = searchEngine.SearchSymbol(query)
+		}
+		for _, r := range found {
+			// Paths of a shard's results are relative to the shard.
+			file := r.FilePath
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(root, file)
+			}
+			if filter.Match(absPath, r.Type, file) {
+				results = append(results, r)
+			}
+		}
 	}
 
 	if *jsonOutput {
@@ -198,6 +506,58 @@ func cmdSearch() {
 	}
 }
 
+// fuzzyLimit is the number of fuzzy matches search reports.
+const fuzzyLimit = 20
+
+// fuzzySymbols ranks the project's symbols, in the structural index and
+// other, against query by fuzzy match quality, best first.
+func fuzzySymbols(projIdx *indexer.ProjectIndex, searchEngine *indexer.SearchEngine, other *symbols.Index, query string, limit int) []indexer.SearchResult {
+	names := make([]string, 0, len(projIdx.SymbolTable))
+	for name := range projIdx.SymbolTable {
+		names = append(names, name)
+	}
+	names = append(names, other.Names()...)
+	var results []indexer.SearchResult
+	for _, m := range fuzzy.Rank(query, names, limit) {
+		if details := searchEngine.GetSymbolDetails(m.Name); details != nil {
+			results = append(results, *details)
+		} else if found := other.Lookup(m.Name); len(found) > 0 {
+			results = append(results, symbolResults(found[:1])...)
+		} else {
+			results = append(results, indexer.SearchResult{Name: m.Name})
+		}
+	}
+	return results
+}
+
+// searchText greps the project's files through its trigram index.
+func searchText(projectPath, query string, opts textsearch.Options, jsonOutput bool) {
+	textIdx, err := textsearch.Open(projectPath)
+	if err != nil {
+		log.Fatalf("Failed to load text index: %v", err)
+	}
+	matches, err := textIdx.Search(query, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	printTextMatches(query, matches, jsonOutput)
+}
+
+func printTextMatches(query string, matches []textsearch.Match, jsonOutput bool) {
+	if jsonOutput {
+		if matches == nil {
+			matches = []textsearch.Match{}
+		}
+		data, _ := json.MarshalIndent(matches, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Printf("Found %d matches for '%s':\n\n", len(matches), query)
+	for _, m := range matches {
+		fmt.Println(m)
+	}
+}
+
 func cmdStructure() {
 	fs := flag.NewFlagSet("structure", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the project")
@@ -228,42 +588,91 @@ func cmdCallGraph() {
 	fs := flag.NewFlagSet("callgraph", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the project")
 	direction := fs.String("dir", "both", "Direction: callers, callees, both")
+	depth := fs.Int("depth", 1, "Levels of callers/callees to follow (1 = immediate neighbors)")
+	format := fs.String("format", "tree", "Output format: tree, edges, json")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format (same as -format=json)")
+	useGopls := fs.Bool("gopls", false, "Use gopls for a type-accurate Go call graph")
+	useTypes := fs.Bool("types", false, "Type-check the Go module and resolve calls by package and receiver type, including interface calls")
 	fs.Parse(os.Args[2:])
 
 	if fs.NArg() < 1 {
-		log.Fatal("Usage: indexer callgraph <function>")
+		log.Fatal("Usage: indexer callgraph [-depth n] [-format tree|edges|json] [-types] <function>")
+	}
+	if *jsonOutput {
+		*format = "json"
 	}
 
 	functionName := fs.Arg(0)
 	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+	opts := callgraph.Options{Direction: *direction, Depth: *depth}
+
+	var (
+		graph *callgraph.Graph
+		err   error
+	)
+	var goGraph *callgraph.GoTypes
+	if *useTypes {
+		if goGraph, err = callgraph.LoadGo(absPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; falling back to the built-in parser\n", err)
+		}
+	}
+	if goGraph != nil {
+		// Report the qualified name when a short one is unambiguous.
+		if names := goGraph.Resolve(functionName); len(names) == 1 {
+			functionName = names[0]
+		}
+		graph, err = callgraph.Build(functionName, goGraph.Neighbors, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else if analyzer := startGopls(absPath, cfg.Gopls, *useGopls); analyzer != nil {
+		graph, err = callgraph.Build(functionName, func(name, dir string) ([]string, error) {
+			// gopls entries are "name (path:line)"; look up the next hop by name.
+			name, _, _ = strings.Cut(name, " (")
+			return analyzer.CallGraph(context.Background(), name, dir)
+		}, opts)
+		analyzer.Close()
+		if err != nil {
+			log.Fatalf("gopls call hierarchy failed: %v", err)
+		}
+	} else {
+		idx := indexer.NewIndexer()
+		idx.RegisterParser(indexer.NewGoParser())
+		idx.RegisterParser(indexer.NewPythonParser())
 
-	idx := indexer.NewIndexer()
-	idx.RegisterParser(indexer.NewGoParser())
-	idx.RegisterParser(indexer.NewPythonParser())
+		projIdx, err := idx.IndexProject(absPath)
+		if err != nil {
+			log.Fatalf("Failed to load index: %v", err)
+		}
 
-	projIdx, err := idx.IndexProject(absPath)
-	if err != nil {
-		log.Fatalf("Failed to load index: %v", err)
+		searchEngine := indexer.NewSearchEngine(projIdx)
+		other := loadSymbols(absPath)
+		graph, err = callgraph.Build(functionName, func(name, dir string) ([]string, error) {
+			return append(searchEngine.SearchByCallGraph(name, dir), other.Neighbors(name, dir)...), nil
+		}, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	searchEngine := indexer.NewSearchEngine(projIdx)
-	results := searchEngine.SearchByCallGraph(functionName, *direction)
-
-	fmt.Printf("Call graph for '%s' (%s):\n\n", functionName, *direction)
-	for _, fn := range results {
-		fmt.Printf("  - %s\n", fn)
+	out, err := graph.Format(*format)
+	if err != nil {
+		log.Fatal(err)
 	}
-	fmt.Printf("\nTotal: %d functions\n", len(results))
+	fmt.Print(out)
 }
 
 func cmdImports() {
 	fs := flag.NewFlagSet("imports", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the project")
 	direction := fs.String("dir", "both", "Direction: imports, imported_by, both")
+	findCycles := fs.Bool("cycles", false, "Report import cycles among the project's modules (only those through <module>, if given)")
+	jsonOutput := fs.Bool("json", false, "Output cycles in JSON format")
 	fs.Parse(os.Args[2:])
 
-	if fs.NArg() < 1 {
-		log.Fatal("Usage: indexer imports <module>")
+	if fs.NArg() < 1 && !*findCycles {
+		log.Fatal("Usage: indexer imports <module>\n       indexer imports -cycles [module]")
 	}
 
 	moduleName := fs.Arg(0)
@@ -279,7 +688,12 @@ func cmdImports() {
 	}
 
 	searchEngine := indexer.NewSearchEngine(projIdx)
+	if *findCycles {
+		printImportCycles(projIdx, searchEngine, moduleName, *jsonOutput)
+		return
+	}
 	results := searchEngine.SearchImports(moduleName, *direction)
+	results = append(results, loadSymbols(absPath).Imports(moduleName, *direction)...)
 
 	fmt.Printf("Import graph for '%s' (%s):\n\n", moduleName, *direction)
 	for _, mod := range results {
@@ -288,10 +702,42 @@ func cmdImports() {
 	fmt.Printf("\nTotal: %d modules\n", len(results))
 }
 
+// printImportCycles reports the cycles in the project's module import
+// graph, or only those through module when it is set.
+func printImportCycles(projIdx *indexer.ProjectIndex, searchEngine *indexer.SearchEngine, module string, jsonOutput bool) {
+	modules := make([]string, 0, len(projIdx.Modules))
+	for name := range projIdx.Modules {
+		modules = append(modules, name)
+	}
+	found := cycles.Find(modules, func(name string) []string {
+		return searchEngine.SearchImports(name, "imports")
+	})
+	if module != "" {
+		var through []cycles.Cycle
+		for _, c := range found {
+			if slices.Contains(c.Members, module) {
+				through = append(through, c)
+			}
+		}
+		found = through
+	}
+
+	if jsonOutput {
+		if found == nil {
+			found = []cycles.Cycle{}
+		}
+		data, _ := json.MarshalIndent(found, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Print(cycles.Format(found))
+}
+
 func cmdInfo() {
 	fs := flag.NewFlagSet("info", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the project")
 	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	useGopls := fs.Bool("gopls", false, "Use gopls for type-accurate Go signatures and docs")
 	fs.Parse(os.Args[2:])
 
 	if fs.NArg() < 1 {
@@ -300,18 +746,36 @@ func cmdInfo() {
 
 	symbolName := fs.Arg(0)
 	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+
+	var result *indexer.SearchResult
+	if analyzer := startGopls(absPath, cfg.Gopls, *useGopls); analyzer != nil {
+		var err error
+		result, err = analyzer.Details(context.Background(), symbolName)
+		analyzer.Close()
+		if err != nil {
+			log.Printf("Warning: gopls lookup failed: %v", err)
+		}
+	}
 
-	idx := indexer.NewIndexer()
-	idx.RegisterParser(indexer.NewGoParser())
-	idx.RegisterParser(indexer.NewPythonParser())
+	if result == nil {
+		idx := indexer.NewIndexer()
+		idx.RegisterParser(indexer.NewGoParser())
+		idx.RegisterParser(indexer.NewPythonParser())
 
-	projIdx, err := idx.IndexProject(absPath)
-	if err != nil {
-		log.Fatalf("Failed to load index: %v", err)
-	}
+		projIdx, err := idx.IndexProject(absPath)
+		if err != nil {
+			log.Fatalf("Failed to load index: %v", err)
+		}
 
-	searchEngine := indexer.NewSearchEngine(projIdx)
-	result := searchEngine.GetSymbolDetails(symbolName)
+		searchEngine := indexer.NewSearchEngine(projIdx)
+		result = searchEngine.GetSymbolDetails(symbolName)
+	}
+	if result == nil {
+		if found := loadSymbols(absPath).Lookup(symbolName); len(found) > 0 {
+			result = &symbolResults(found[:1])[0]
+		}
+	}
 
 	if result == nil {
 		fmt.Printf("Symbol '%s' not found\n", symbolName)
@@ -332,6 +796,9 @@ func cmdFetchContext() {
 	maxResults := fs.Int("max-results", 10, "Maximum number of results")
 	jsonOutput := fs.Bool("json", false, "Output in JSON format")
 	refresh := fs.Bool("refresh", false, "Force refresh (ignore cache)")
+	export := fs.String("export", "", "Export a context pack for other agents: repomap (Aider), files, or mentions (Claude Code @paths)")
+	maxDecls := fs.Int("max-decls", 30, "Maximum declarations listed per file in -export repomap")
+	rootNames := fs.String("roots", "", "Comma-separated workspace roots to fetch from (default: all configured roots)")
 	fs.Parse(os.Args[2:])
 
 	if fs.NArg() < 1 {
@@ -341,31 +808,94 @@ func cmdFetchContext() {
 	task := fs.Arg(0)
 	absPath, _ := filepath.Abs(*projectPath)
 
-	fmt.Printf("Fetching context for: %s\n", task)
-
-	idx := indexer.NewIndexer()
-	idx.RegisterParser(indexer.NewGoParser())
-	idx.RegisterParser(indexer.NewPythonParser())
+	roots, err := loadConfig(absPath).Workspace.Resolve(absPath)
+	if err != nil {
+		log.Fatalf("Invalid workspace config: %v", err)
+	}
+	if roots, err = workspace.Select(roots, *rootNames); err != nil {
+		log.Fatal(err)
+	}
 
-	// Set cache based on refresh flag
-	idx.SetCacheEnabled(!*refresh)
+	if *export != "" {
+		// Keep stdout clean so the pack can be piped straight into another tool.
+		fmt.Fprintf(os.Stderr, "Fetching context for: %s\n", task)
+	} else {
+		fmt.Printf("Fetching context for: %s\n", task)
+	}
 
-	projIdx, err := idx.IndexProject(absPath)
+	// Each workspace root is indexed with its own languages; a project
+	// without roots is a single root.
+	contexts, err := workspace.FetchContext(roots, task, *maxResults, func(r workspace.Root) (*indexer.ProjectIndex, error) {
+		idx := r.NewIndexer()
+		idx.SetCacheEnabled(!*refresh)
+		return idx.IndexProject(r.Dir)
+	})
 	if err != nil {
 		log.Fatalf("Failed to load index: %v", err)
 	}
 
-	// Create context fetcher
-	fetcher := indexer.NewContextFetcher(projIdx)
+	if *export != "" {
+		for i, c := range contexts {
+			pack, err := retrieval.Export(roots[i].Dir, c.Context.RelevantModules, retrieval.ExportFormat(*export), *maxDecls)
+			if err != nil {
+				log.Fatalf("Failed to export context of %s: %v", c.Root, err)
+			}
+			fmt.Print(pack)
+		}
+		return
+	}
 
-	// Fetch context
-	ctx := fetcher.FetchContext(task, *maxResults)
+	dependencies := relevantDeps(absPath, task)
+	documentation := relevantDocs(absPath, roots, task)
+	configuration := relevantConfig(absPath, roots, task)
+	endpoints := relevantRoutes(absPath, roots, task)
+	schema := relevantSchema(absPath, roots, task)
+	code := relevantSymbols(absPath, roots, task)
 
 	if *jsonOutput {
-		data, _ := json.MarshalIndent(ctx, "", "  ")
+		var data []byte
+		if len(contexts) == 1 {
+			data, _ = json.MarshalIndent(struct {
+				*indexer.ProjectContext
+				Dependencies  []deps.Dependency   `json:"dependencies,omitempty"`
+				Documentation []docs.Result       `json:"documentation,omitempty"`
+				Configuration []configkeys.Result `json:"configuration,omitempty"`
+				Schema        *schemaContext      `json:"schema,omitempty"`
+				Routes        []routes.Route      `json:"routes,omitempty"`
+				Symbols       []symbols.Result    `json:"symbols,omitempty"`
+			}{contexts[0].Context, dependencies, documentation, configuration, schema, endpoints, code}, "", "  ")
+		} else {
+			data, _ = json.MarshalIndent(struct {
+				Roots         []workspace.Context `json:"roots"`
+				Dependencies  []deps.Dependency   `json:"dependencies,omitempty"`
+				Documentation []docs.Result       `json:"documentation,omitempty"`
+				Configuration []configkeys.Result `json:"configuration,omitempty"`
+				Schema        *schemaContext      `json:"schema,omitempty"`
+				Routes        []routes.Route      `json:"routes,omitempty"`
+				Symbols       []symbols.Result    `json:"symbols,omitempty"`
+			}{contexts, dependencies, documentation, configuration, schema, endpoints, code}, "", "  ")
+		}
 		fmt.Println(string(data))
 	} else {
-		fmt.Println(indexer.FormatContext(ctx))
+		fmt.Println(workspace.Format(contexts))
+		if len(code) > 0 {
+			fmt.Printf("\nRelevant symbols:\n%s", symbols.Format(code))
+		}
+		if len(endpoints) > 0 {
+			fmt.Printf("\nRelevant routes:\n%s", routes.Format(endpoints))
+		}
+		if len(dependencies) > 0 {
+			fmt.Printf("\nRelevant dependencies:\n%s", deps.Format(dependencies))
+		}
+		if len(documentation) > 0 {
+			fmt.Printf("\nRelevant documentation:\n%s", docs.Format(documentation, 3))
+		}
+		if len(configuration) > 0 {
+			fmt.Printf("\nRelevant configuration:\n%s", configkeys.Format(configuration))
+		}
+		if schema != nil {
+			fmt.Printf("\nRelevant schema:\n%s", schema)
+		}
 	}
 }
 
@@ -393,10 +923,13 @@ func cmdAgent() {
 func cmdAgentPlan() {
 	fs := flag.NewFlagSet("agent plan", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the project")
-	provider := fs.String("provider", "claude", "LLM provider (claude, gemini, openai, ollama)")
+	provider := fs.String("provider", "claude", "LLM provider (claude, gemini, openai, ollama, llamacpp)")
 	model := fs.String("model", "", "Model name (provider-specific)")
+	reasoningEffort := fs.String("reasoning-effort", "", "Reasoning effort for OpenAI reasoning models (minimal, low, medium, high)")
 	apiKey := fs.String("api-key", "", "API key (or use environment variable)")
 	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	withDiagnostics := fs.Bool("diagnostics", false, "Include go vet / tsc / ruff findings in the planning context")
+	withVulns := fs.Bool("vulns", false, "Include govulncheck / npm audit / pip-audit findings in the planning context (automatic for vulnerability tasks)")
 	fs.Parse(os.Args[3:])
 
 	if fs.NArg() < 1 {
@@ -405,6 +938,7 @@ func cmdAgentPlan() {
 
 	task := fs.Arg(0)
 	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
 
 	// Get API key from environment if not provided
 	if *apiKey == "" {
@@ -422,16 +956,20 @@ func cmdAgentPlan() {
 	agentConfig := agent.AgentConfig{
 		ProjectPath: absPath,
 		LLMConfig: agent.LLMConfig{
-			Provider: *provider,
-			APIKey:   *apiKey,
-			Model:    *model,
+			Provider:        *provider,
+			APIKey:          *apiKey,
+			Model:           *model,
+			ReasoningEffort: *reasoningEffort,
 		},
+		SystemPrompts: cfg.SystemPrompts,
 	}
 
 	codingAgent, err := agent.NewCodingAgent(agentConfig)
 	if err != nil {
 		log.Fatalf("Failed to create agent: %v", err)
 	}
+	enableDiagnostics(codingAgent, absPath, cfg.Diagnostics, *withDiagnostics)
+	enableVulnerabilities(codingAgent, absPath, task, *withVulns)
 
 	// Generate task breakdown
 	fmt.Printf("\n=== Coding Agent: Task Planner ===\n")
@@ -457,18 +995,45 @@ func cmdAgentPlan() {
 func cmdAgentChat() {
 	fs := flag.NewFlagSet("agent chat", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the project")
-	provider := fs.String("provider", "claude", "LLM provider (claude, gemini, openai, ollama)")
+	provider := fs.String("provider", "claude", "LLM provider (claude, gemini, openai, ollama, llamacpp)")
 	model := fs.String("model", "", "Model name (provider-specific)")
+	reasoningEffort := fs.String("reasoning-effort", "", "Reasoning effort for OpenAI reasoning models (minimal, low, medium, high)")
 	apiKey := fs.String("api-key", "", "API key (or use environment variable)")
 	noContext := fs.Bool("no-context", false, "Don't include project context")
+	sessionPath := fs.String("session", "", "Session file (.json or .md) to continue and save the conversation to")
+	exportPath := fs.String("export", "", "Also write the session to this file (.md for Markdown, otherwise JSON)")
 	fs.Parse(os.Args[3:])
 
+	if fs.NArg() < 1 && (*sessionPath == "" || *exportPath == "") {
+		log.Fatal("Usage: indexer agent chat [-session file] [-export file] \"<message>\"")
+	}
+
+	absPath, _ := filepath.Abs(*projectPath)
+
+	var session *agent.ChatSession
+	if *sessionPath != "" {
+		if _, err := os.Stat(*sessionPath); err == nil {
+			loaded, err := agent.LoadChatSession(*sessionPath)
+			if err != nil {
+				log.Fatalf("Failed to load session: %v", err)
+			}
+			session = loaded
+		} else {
+			session = agent.NewChatSession(absPath)
+		}
+	} else if *exportPath != "" {
+		session = agent.NewChatSession(absPath)
+	}
+
+	// With only a session and -export, convert the session without chatting.
 	if fs.NArg() < 1 {
-		log.Fatal("Usage: indexer agent chat \"<message>\"")
+		saveChatSession(session, *exportPath)
+		fmt.Printf("Exported %d messages to %s\n", len(session.Turns), *exportPath)
+		return
 	}
 
 	message := fs.Arg(0)
-	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
 
 	// Get API key from environment if not provided
 	if *apiKey == "" {
@@ -486,10 +1051,12 @@ func cmdAgentChat() {
 	agentConfig := agent.AgentConfig{
 		ProjectPath: absPath,
 		LLMConfig: agent.LLMConfig{
-			Provider: *provider,
-			APIKey:   *apiKey,
-			Model:    *model,
+			Provider:        *provider,
+			APIKey:          *apiKey,
+			Model:           *model,
+			ReasoningEffort: *reasoningEffort,
 		},
+		SystemPrompts: cfg.SystemPrompts,
 	}
 
 	codingAgent, err := agent.NewCodingAgent(agentConfig)
@@ -499,22 +1066,40 @@ func cmdAgentChat() {
 
 	// Chat
 	fmt.Printf("\n=== Coding Agent: Chat ===\n")
-	fmt.Printf("Provider: %s\n\n", *provider)
+	fmt.Printf("Provider: %s\n", *provider)
+	if session != nil && len(session.Turns) > 0 {
+		fmt.Printf("Continuing session: %d previous messages\n", len(session.Turns))
+	}
+	fmt.Println()
 
-	response, err := codingAgent.Chat(context.Background(), message, !*noContext)
+	response, err := codingAgent.ContinueChat(context.Background(), session, message, !*noContext)
 	if err != nil {
 		log.Fatalf("Chat failed: %v", err)
 	}
 
 	fmt.Println(response.Content)
 	fmt.Printf("\n[Tokens: %d | Model: %s]\n", response.TokensUsed, response.Model)
+
+	if *sessionPath != "" {
+		saveChatSession(session, *sessionPath)
+	}
+	if *exportPath != "" {
+		saveChatSession(session, *exportPath)
+	}
+}
+
+func saveChatSession(session *agent.ChatSession, path string) {
+	if err := session.Save(path); err != nil {
+		log.Fatalf("Failed to save session: %v", err)
+	}
 }
 
 func cmdAgentExplain() {
 	fs := flag.NewFlagSet("agent explain", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the project")
-	provider := fs.String("provider", "claude", "LLM provider (claude, gemini, openai, ollama)")
+	provider := fs.String("provider", "claude", "LLM provider (claude, gemini, openai, ollama, llamacpp)")
 	model := fs.String("model", "", "Model name (provider-specific)")
+	reasoningEffort := fs.String("reasoning-effort", "", "Reasoning effort for OpenAI reasoning models (minimal, low, medium, high)")
 	apiKey := fs.String("api-key", "", "API key (or use environment variable)")
 	fs.Parse(os.Args[3:])
 
@@ -524,6 +1109,7 @@ func cmdAgentExplain() {
 
 	symbolName := fs.Arg(0)
 	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
 
 	// Get API key from environment if not provided
 	if *apiKey == "" {
@@ -541,10 +1127,12 @@ func cmdAgentExplain() {
 	agentConfig := agent.AgentConfig{
 		ProjectPath: absPath,
 		LLMConfig: agent.LLMConfig{
-			Provider: *provider,
-			APIKey:   *apiKey,
-			Model:    *model,
+			Provider:        *provider,
+			APIKey:          *apiKey,
+			Model:           *model,
+			ReasoningEffort: *reasoningEffort,
 		},
+		SystemPrompts: cfg.SystemPrompts,
 	}
 
 	codingAgent, err := agent.NewCodingAgent(agentConfig)
@@ -569,20 +1157,72 @@ func cmdAgentExplain() {
 func cmdAgentRun() {
 	fs := flag.NewFlagSet("agent run", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the project")
-	provider := fs.String("provider", "claude", "LLM provider (claude, gemini, openai, ollama)")
+	provider := fs.String("provider", "claude", "LLM provider (claude, gemini, openai, ollama, llamacpp)")
 	model := fs.String("model", "", "Model name (provider-specific)")
+	reasoningEffort := fs.String("reasoning-effort", "", "Reasoning effort for OpenAI reasoning models (minimal, low, medium, high)")
 	apiKey := fs.String("api-key", "", "API key (or use environment variable)")
 	dryRun := fs.Bool("dry-run", false, "If true, do not modify files or run commands")
 	maxIterations := fs.Int("max-iterations", 20, "Max action iterations per task")
 	maxContext := fs.Int("max-context", 8, "Max context results per task")
+	timeout := fs.Duration("timeout", 0, "Wall-clock limit for the whole run, e.g. 30m; unfinished tasks are left pending (0 = none)")
+	commandTimeout := fs.Duration("command-timeout", 0, "Default timeout for each run_command action (default 5m)")
+	allowDangerous := fs.Bool("allow-dangerous", false, "Let this run execute destructive commands (rm -r outside the project, dd, mkfs, git push --force, curl | sh, DROP TABLE); they are logged to .index/audit.log")
+	minimalEnv := fs.Bool("minimal-env", false, "Run commands with a minimal environment (PATH, HOME, locale) plus command_env.allow/set from .indexer.json")
+	createPR := fs.Bool("create-pr", false, "Commit the run's changes to a new branch, push it, and open a GitHub pull request")
+	createMR := fs.Bool("create-mr", false, "Commit the run's changes to a new branch, push it, and open a GitLab merge request")
+	ci := fs.Bool("ci", false, "CI mode: no interactive actions, edits only with -dry-run or on a new branch, exit code 2 if any task fails")
+	reportPath := fs.String("report", "", "Write a machine-readable report (plan, actions, command output, diffs, token usage and final status) to this file")
+	reportFormat := fs.String("report-format", "", "Report format: json, junit or sarif (default: from -report extension)")
+	withDiagnostics := fs.Bool("diagnostics", false, "Include go vet / tsc / ruff findings in planning and task context")
+	withVulns := fs.Bool("vulns", false, "Include govulncheck / npm audit / pip-audit findings in the planning context (automatic for vulnerability tasks)")
+	relatedTests := fs.Bool("related-tests", true, "After each edit, point the agent at the tests covering the changed files")
+	otlpEndpoint := fs.String("otlp-endpoint", "", "Export OpenTelemetry traces to this OTLP collector (default: tracing.otlp_endpoint or OTEL_EXPORTER_OTLP_ENDPOINT)")
+	issueRef := fs.String("issue", "", "Use an issue as the task: ABC-123, jira:ABC-123, linear:ENG-42, #12, owner/repo#12 or an issue URL")
 	fs.Parse(os.Args[3:])
 
-	if fs.NArg() < 1 {
-		log.Fatal("Usage: indexer agent run \"<task description>\"")
+	if fs.NArg() < 1 && *issueRef == "" {
+		log.Fatal("Usage: indexer agent run \"<task description>\" | -issue <ref> [\"<extra instructions>\"]")
 	}
 
 	task := fs.Arg(0)
 	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+
+	flushTraces := setupTracing(cfg.Tracing, *otlpEndpoint)
+	defer flushTraces()
+
+	var contextQueries []string
+	if *issueRef != "" {
+		issue := fetchIssue(cfg, absPath, *issueRef)
+		fmt.Printf("Issue %s: %s\n", issue.Key, issue.Title)
+		task = issueTask(issue, task)
+		contextQueries = issue.Queries(5)
+	}
+
+	if (*createPR || *createMR) && *dryRun {
+		log.Fatal("-create-pr/-create-mr cannot be combined with -dry-run")
+	}
+	if *createPR && *createMR {
+		log.Fatal("-create-pr and -create-mr are mutually exclusive")
+	}
+	if *createPR || *createMR {
+		if err := localonly.Check("-create-pr/-create-mr"); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *ci && !*dryRun && !*createPR && !*createMR {
+		log.Fatal("-ci requires -dry-run, -create-pr or -create-mr so edits never land on the checked-out branch")
+	}
+
+	format := agent.ReportFormat(*reportFormat)
+	if format == "" {
+		format = agent.ReportFormatForPath(*reportPath)
+	}
+	switch format {
+	case agent.ReportJSON, agent.ReportJUnit, agent.ReportSARIF:
+	default:
+		log.Fatalf("Unknown -report-format %q (want json, junit or sarif)", format)
+	}
 
 	if *apiKey == "" {
 		switch *provider {
@@ -598,30 +1238,69 @@ func cmdAgentRun() {
 	agentConfig := agent.AgentConfig{
 		ProjectPath: absPath,
 		LLMConfig: agent.LLMConfig{
-			Provider: *provider,
-			APIKey:   *apiKey,
-			Model:    *model,
+			Provider:        *provider,
+			APIKey:          *apiKey,
+			Model:           *model,
+			ReasoningEffort: *reasoningEffort,
 		},
+		SystemPrompts: cfg.SystemPrompts,
 	}
 
 	codingAgent, err := agent.NewCodingAgent(agentConfig)
 	if err != nil {
 		log.Fatalf("Failed to create agent: %v", err)
 	}
+	enableDiagnostics(codingAgent, absPath, cfg.Diagnostics, *withDiagnostics)
+	enableVulnerabilities(codingAgent, absPath, task, *withVulns)
+	enableTestMap(codingAgent, absPath, *relatedTests)
 
 	fmt.Printf("\n=== Coding Agent: Autonomous Run ===\n")
 	fmt.Printf("Provider: %s | Dry-run: %v\n", *provider, *dryRun)
 	fmt.Printf("Task: %s\n\n", task)
 
+	var branch *runBranch
+	switch {
+	case *createPR:
+		branch = startRunBranch(absPath, cfg.GitHub.Remote, cfg.GitHub.BaseBranch, cfg.GitHub.BranchPrefix, task)
+	case *createMR:
+		branch = startRunBranch(absPath, cfg.GitLab.Remote, cfg.GitLab.BaseBranch, cfg.GitLab.BranchPrefix, task)
+	}
+
+	commandEnv := cfg.CommandEnv
+	if *minimalEnv {
+		commandEnv.Minimal = true
+	}
+
+	start := time.Now()
 	result, err := codingAgent.Run(context.Background(), task, agent.RunOptions{
 		DryRun:            *dryRun,
 		MaxIterations:     *maxIterations,
 		MaxContextResults: *maxContext,
+		NonInteractive:    *ci,
+		ContextQueries:    contextQueries,
+		Timeout:           *timeout,
+		ActionTimeouts:    actionTimeouts(*commandTimeout),
+		CommandEnv:        commandEnv,
+		Paths:             cfg.Paths,
+		AllowDangerous:    *allowDangerous,
 	})
 	if err != nil {
+		summary := notify.NewSummary(task, nil)
+		summary.Project, summary.Provider, summary.Model = absPath, *provider, *model
+		summary.Duration = time.Since(start)
+		summary.Error = err.Error()
+		notifyRun(cfg.Notify, summary)
+		flushTraces()
 		log.Fatalf("Agent run failed: %v", err)
 	}
 
+	if *reportPath != "" {
+		if err := writeRunReport(*reportPath, format, result); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+		fmt.Printf("Report written to %s\n", *reportPath)
+	}
+
 	// Print updated plan with statuses
 	tm := agent.NewTaskManager()
 	fmt.Println(tm.FormatAsChecklist(result.Plan))
@@ -655,23 +1334,406 @@ func cmdAgentRun() {
 			fmt.Printf("  failure: %s\n", exec.FailureMsg)
 		}
 	}
+
+	if *dryRun {
+		fmt.Println()
+		fmt.Print(tm.FormatDiffs(result.Diffs))
+	}
+	if result.TimedOut {
+		fmt.Printf("\nRun timed out after %s; unfinished tasks were left pending.\n", *timeout)
+	}
+
+	var changeURL string
+	switch {
+	case *createPR:
+		changeURL = openGitHubPullRequest(cfg.GitHub, branch, task, result)
+	case *createMR:
+		changeURL = openGitLabMergeRequest(cfg.GitLab, branch, task, result)
+	}
+
+	summary := notify.NewSummary(task, result)
+	summary.Project, summary.Provider, summary.Model = absPath, result.Provider, result.Model
+	summary.Duration = time.Since(start)
+	summary.URL = changeURL
+	notifyRun(cfg.Notify, summary)
+
+	if *ci && !result.Succeeded() {
+		flushTraces()
+		os.Exit(exitTasksFailed)
+	}
+}
+
+// actionTimeouts builds the executor's per-action-type overrides from flags.
+func actionTimeouts(command time.Duration) map[agent.ActionType]time.Duration {
+	if command <= 0 {
+		return nil
+	}
+	return map[agent.ActionType]time.Duration{agent.ActionRunCommand: command}
+}
+
+// exitTasksFailed is the `agent run -ci` exit code when the run finished but
+// at least one task failed or did not complete. Errors that stop the run
+// itself exit with 1.
+const exitTasksFailed = 2
+
+func writeRunReport(path string, format agent.ReportFormat, result *agent.RunResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := agent.WriteReport(f, format, result); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func cmdLSP() {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	explain := fs.Bool("explain", false, "Add AI explanations to hover results")
+	provider := fs.String("provider", "claude", "LLM provider for -explain (claude, gemini, openai, ollama, llamacpp)")
+	model := fs.String("model", "", "Model name (provider-specific)")
+	apiKey := fs.String("api-key", "", "API key (or use environment variable)")
+	useGopls := fs.Bool("gopls", false, "Answer Go definition/references/implementation requests with gopls")
+	fs.Parse(os.Args[2:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+	appCfg := loadConfig(absPath)
+
+	// stdout carries the protocol; keep logs on stderr.
+	log.SetOutput(os.Stderr)
+
+	idx := indexer.NewIndexer()
+	idx.RegisterParser(indexer.NewGoParser())
+	idx.RegisterParser(indexer.NewPythonParser())
+
+	cfg := lsp.Config{
+		ProjectRoot: absPath,
+		Indexer:     idx,
+	}
+
+	if analyzer := startGopls(absPath, appCfg.Gopls, *useGopls); analyzer != nil {
+		defer analyzer.Close()
+		cfg.Gopls = analyzer.Client()
+	}
+
+	if *explain {
+		if *apiKey == "" {
+			switch *provider {
+			case "claude":
+				*apiKey = os.Getenv("CLAUDE_API_KEY")
+			case "gemini":
+				*apiKey = os.Getenv("GEMINI_API_KEY")
+			case "openai":
+				*apiKey = os.Getenv("OPENAI_API_KEY")
+			}
+		}
+
+		codingAgent, err := agent.NewCodingAgent(agent.AgentConfig{
+			ProjectPath: absPath,
+			LLMConfig: agent.LLMConfig{
+				Provider: *provider,
+				APIKey:   *apiKey,
+				Model:    *model,
+			},
+			SystemPrompts: appCfg.SystemPrompts,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create agent: %v", err)
+		}
+		cfg.Explainer = agentExplainer{codingAgent}
+	}
+
+	server := lsp.NewServer(cfg)
+	if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("LSP server failed: %v", err)
+	}
+}
+
+func cmdServe() {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":50051", "gRPC listen address")
+	metricsAddr := fs.String("metrics-addr", "", "Address to serve Prometheus /metrics on (disabled if empty)")
+	maxProjects := fs.Int("max-projects", 8, "Maximum number of project indexes kept in memory")
+	otlpEndpoint := fs.String("otlp-endpoint", "", "Export OpenTelemetry traces to this OTLP collector (default: OTEL_EXPORTER_OTLP_ENDPOINT)")
+	fs.Parse(os.Args[2:])
+
+	metrics.Serve(*metricsAddr)
+	defer setupTracing(tracing.Config{}, *otlpEndpoint)()
+
+	server := grpcapi.NewServer(*maxProjects)
+	fmt.Printf("Serving gRPC API on %s\n", *addr)
+	if err := server.ListenAndServe(*addr); err != nil {
+		log.Fatalf("gRPC server failed: %v", err)
+	}
+}
+
+// agentExplainer adapts CodingAgent.ExplainCode to lsp.Explainer.
+type agentExplainer struct {
+	agent *agent.CodingAgent
+}
+
+func (e agentExplainer) Explain(ctx context.Context, symbol string) (string, error) {
+	resp, err := e.agent.ExplainCode(ctx, symbol)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
 }
 
 // RAG Commands
 func newRAGIndexer(projectPath string) *rag.RAGIndexer {
-	embedder := rag.NewOllamaEmbedder("nomic-embed-text")
-	dbPath := filepath.Join(projectPath, ".index", "rag_vectors.db")
-	vectorStore, err := rag.NewSQLiteVectorStore(dbPath, embedder.Dimension())
+	cfg := loadConfig(projectPath)
+	embedder := newEmbedder(cfg)
+	vectorStore, err := rag.NewVectorStore(cfg.RAG.Store, projectPath, embedder.Dimension())
+	if err != nil {
+		log.Fatalf("Failed to open vector store: %v", err)
+	}
+
+	idx := rag.NewRAGIndexer(embedder, vectorStore)
+	idx.SetPrivacyPolicy(projectPath, cfg.RAG.Privacy)
+	if err := idx.SetChunkerConfig(chunkerConfig(cfg)); err != nil {
+		log.Fatal(err)
+	}
+	return idx
+}
+
+func newShardedRAGIndexer(projectPath string) *rag.ShardedIndexer {
+	cfg := loadConfig(projectPath)
+	if cfg.RAG.Store.Provider == "pgvector" {
+		log.Fatal("Shards are kept in SQLite; index a pgvector store without -shards")
+	}
+	quant, err := rag.ParseQuantization(cfg.RAG.Store.Quantization)
+	if err != nil {
+		log.Fatalf("Invalid rag.store.quantization: %v", err)
+	}
+	embedder := newEmbedder(cfg)
+	sharded := rag.NewShardedIndexer(projectPath, embedder, func(shard string) (rag.VectorStore, error) {
+		store, err := rag.NewSQLiteVectorStore(rag.ShardDBPath(projectPath, shard), embedder.Dimension())
+		if err != nil {
+			return nil, err
+		}
+		if err := store.SetQuantization(quant); err != nil {
+			store.Close()
+			return nil, err
+		}
+		return store, nil
+	})
+	sharded.SetPrivacyPolicy(cfg.RAG.Privacy)
+	if err := sharded.SetChunkerConfig(chunkerConfig(cfg)); err != nil {
+		log.Fatal(err)
+	}
+	return sharded
+}
+
+// newEmbedder creates the embedder selected by the embedder flags or
+// rag.embedder in .indexer.json, reading its API key from the environment.
+func newEmbedder(cfg *config.Config) rag.Embedder {
+	embedderCfg := overrideEmbedder(cfg.RAG.Embedder, embedderOverride.provider, embedderOverride.model, embedderOverride.dimensions)
+	embedderCfg.APIKey = apiKeyFromEnv(embedderCfg.Provider)
+	embedder, err := rag.NewEmbedder(embedderCfg)
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+	return embedder
+}
+
+// embedderOverride holds the -embedder, -embedding-model and -dimensions
+// flags of the rag commands, which take precedence over rag.embedder.
+var embedderOverride struct {
+	provider   string
+	model      string
+	dimensions int
+}
+
+// embedderFlags registers the embedder flags on a rag command.
+func embedderFlags(fs *flag.FlagSet) {
+	fs.StringVar(&embedderOverride.provider, "embedder", "", "Embedding provider: ollama, local, openai, voyage or cohere (default rag.embedder.provider)")
+	fs.StringVar(&embedderOverride.model, "embedding-model", "", "Embedding model, e.g. text-embedding-3-large (default rag.embedder.model)")
+	fs.IntVar(&embedderOverride.dimensions, "dimensions", 0, "Embedding dimensions: shortens text-embedding-3 embeddings, or sizes a model the indexer does not know")
+}
+
+// chunkerOverride holds the -max-chunk-size, -window-lines and
+// -window-overlap flags of rag index and update, which take precedence
+// over rag.chunking.
+var chunkerOverride rag.ChunkerConfig
+
+func chunkerFlags(fs *flag.FlagSet) {
+	fs.IntVar(&chunkerOverride.MaxChunkSize, "max-chunk-size", 0, "Characters above which chunks are split, about 4 per token (default 4000, or rag.chunking.max_chunk_size)")
+	fs.IntVar(&chunkerOverride.WindowLines, "window-lines", 0, "Lines per chunk of files chunked by sliding window (default 50, or rag.chunking.window_lines)")
+	fs.IntVar(&chunkerOverride.WindowOverlap, "window-overlap", 0, "Lines shared by consecutive windows (default 10, or rag.chunking.window_overlap)")
+}
+
+// chunkerConfig applies the chunker flags to rag.chunking.
+func chunkerConfig(cfg *config.Config) rag.ChunkerConfig {
+	chunking := cfg.RAG.Chunking
+	if chunkerOverride.MaxChunkSize > 0 {
+		chunking.MaxChunkSize = chunkerOverride.MaxChunkSize
+	}
+	if chunkerOverride.WindowLines > 0 {
+		chunking.WindowLines = chunkerOverride.WindowLines
+	}
+	if chunkerOverride.WindowOverlap > 0 {
+		chunking.WindowOverlap = chunkerOverride.WindowOverlap
+	}
+	return chunking
+}
+
+// overrideEmbedder applies a provider, model and dimensions given on the
+// command line to the configured embedder.
+func overrideEmbedder(cfg rag.EmbedderConfig, provider, model string, dimensions int) rag.EmbedderConfig {
+	if provider != "" && provider != cfg.Provider {
+		// Another provider's model and endpoint do not carry over.
+		cfg = rag.EmbedderConfig{Provider: provider}
+	}
+	if model != "" {
+		cfg.Model, cfg.Dimensions = model, 0
+	}
+	if dimensions > 0 {
+		cfg.Dimensions = dimensions
+	}
+	return cfg
+}
+
+// enableLocalOnly handles the global -local-only flag, accepted anywhere on
+// the command line, and the AGENT_LOCAL_ONLY environment variable.
+func enableLocalOnly() {
+	args := os.Args[:1]
+	for _, arg := range os.Args[1:] {
+		if arg == "-local-only" || arg == "--local-only" {
+			localonly.Enable()
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+	localonly.EnableFromEnv()
+}
+
+// loadConfig reads the project's .indexer.json and registers its provider rate limits.
+func loadConfig(projectPath string) *config.Config {
+	cfg, err := config.Load(projectPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.ApplyRateLimits()
+	cfg.ApplyModels()
+	cfg.ApplyLocalOnly()
+	if err := cfg.ApplyProxy(); err != nil {
+		log.Fatalf("Invalid proxy config: %v", err)
+	}
+	if err := cfg.ApplyTLS(); err != nil {
+		log.Fatalf("Invalid TLS config: %v", err)
+	}
+	return cfg
+}
+
+// enableDiagnostics attaches a diagnostics collector to the agent when the
+// -diagnostics flag or diagnostics.enabled in .indexer.json asks for it.
+func enableDiagnostics(codingAgent *agent.CodingAgent, projectPath string, cfg config.DiagnosticsConfig, flagSet bool) {
+	if !flagSet && !cfg.Enabled {
+		return
+	}
+	collector := diagnostics.NewCollector(diagnostics.CollectorConfig{
+		ProjectRoot: projectPath,
+		Tools:       cfg.Tools,
+	})
+	if tools := collector.Tools(); len(tools) > 0 {
+		fmt.Printf("Diagnostics: %s\n", strings.Join(tools, ", "))
+	} else {
+		fmt.Println("Diagnostics: no applicable tools found")
+	}
+	codingAgent.SetDiagnostics(collector)
+}
+
+// setupTracing exports spans when an OTLP endpoint is given on the command
+// line, in .indexer.json, or through the standard OTEL_EXPORTER_OTLP_*
+// variables. The returned function flushes pending spans.
+func setupTracing(cfg tracing.Config, endpoint string) func() {
+	if endpoint != "" {
+		cfg.Endpoint = endpoint
+	}
+	if cfg.Endpoint == "" && os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func() {}
+	}
+	for _, target := range []string{cfg.Endpoint, os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")} {
+		if target == "" {
+			continue
+		}
+		if err := localonly.CheckURL("OTLP trace export", target); err != nil {
+			log.Fatalf("Failed to set up tracing: %v", err)
+		}
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Printf("Warning: failed to flush traces: %v", err)
+		}
+	}
+}
+
+// loadEmbedOptions merges .indexer.json settings with command-line overrides.
+func loadEmbedOptions(projectPath string, batchSize, concurrency, rpm int) rag.EmbedOptions {
+	cfg := loadConfig(projectPath)
+
+	opts := rag.EmbedOptions{
+		BatchSize:         cfg.RAG.BatchSize,
+		Concurrency:       cfg.RAG.Concurrency,
+		RequestsPerMinute: cfg.RAG.RequestsPerMinute,
+		Bulk:              cfg.RAG.Bulk,
+	}
+	if batchSize > 0 {
+		opts.BatchSize = batchSize
+	}
+	if concurrency > 0 {
+		opts.Concurrency = concurrency
+	}
+	if rpm > 0 {
+		opts.RequestsPerMinute = rpm
+	}
+	return opts
+}
+
+// resolveShards expands a -shards flag value; "all" selects every top-level directory.
+func resolveShards(projectPath, value string) []string {
+	if value != "all" {
+		return splitList(value)
+	}
+	shards, err := rag.ListShards(projectPath)
 	if err != nil {
-		log.Fatalf("Failed to create SQLite vector store: %v", err)
+		log.Fatalf("Failed to list shards: %v", err)
+	}
+	return shards
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
+}
 
-	return rag.NewRAGIndexer(embedder, vectorStore)
+func printShardStats(sharded *rag.ShardedIndexer) {
+	stats := sharded.Stats()
+	fmt.Printf("\nShard Statistics:\n")
+	for _, name := range sharded.Shards() {
+		fmt.Printf("  %-24s %6d chunks\n", name, stats[name].TotalChunks)
+	}
 }
 
 func cmdRAG() {
 	if len(os.Args) < 3 {
-		log.Fatal("Usage: indexer rag <subcommand> [options]\nSubcommands: index, search, status")
+		log.Fatal("Usage: indexer rag <subcommand> [options]\nSubcommands: index, update, search, delete, status, enrich, reembed, compact, download-model")
 	}
 
 	subcommand := os.Args[2]
@@ -679,26 +1741,67 @@ func cmdRAG() {
 	switch subcommand {
 	case "index":
 		cmdRAGIndex()
+	case "update":
+		cmdRAGUpdate()
 	case "search":
 		cmdRAGSearch()
+	case "delete":
+		cmdRAGDelete()
 	case "status":
 		cmdRAGStatus()
+	case "enrich":
+		cmdRAGEnrich()
+	case "reembed", "reindex":
+		cmdRAGReembed()
+	case "compact":
+		cmdRAGCompact()
+	case "download-model":
+		cmdRAGDownloadModel()
 	default:
-		log.Fatalf("Unknown rag subcommand: %s\nAvailable: index, search, status", subcommand)
+		log.Fatalf("Unknown rag subcommand: %s\nAvailable: index, update, search, delete, status, enrich, reembed, compact, download-model", subcommand)
 	}
 }
 
 func cmdRAGIndex() {
 	fs := flag.NewFlagSet("rag index", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the project to index")
+	shards := fs.String("shards", "", "Comma-separated top-level directories to index as separate shards (or \"all\")")
+	batchSize := fs.Int("batch-size", 0, "Chunks per embedding request (default 10, or rag.batch_size in .indexer.json)")
+	concurrency := fs.Int("concurrency", 0, "Concurrent embedding requests (default 1, or rag.concurrency)")
+	workers := fs.Int("workers", 0, "Embedding workers fed by parallel chunking; overrides -concurrency")
+	rpm := fs.Int("rpm", 0, "Maximum embedding requests per minute (default unlimited, or rag.requests_per_minute)")
+	bulk := fs.Bool("bulk", false, "Embed through the OpenAI/Voyage batch API: about half the cost, but can take hours (or rag.bulk)")
+	embedderFlags(fs)
+	chunkerFlags(fs)
 	fs.Parse(os.Args[3:])
 
 	absPath, _ := filepath.Abs(*projectPath)
+	if *workers > 0 {
+		*concurrency = *workers
+	}
+	embedOpts := loadEmbedOptions(absPath, *batchSize, *concurrency, *rpm)
+	if *bulk {
+		embedOpts.Bulk = true
+	}
 
 	fmt.Printf("\n=== RAG Indexer ===\n")
 	fmt.Printf("Building semantic index for: %s\n\n", absPath)
 
+	if *shards != "" {
+		sharded := newShardedRAGIndexer(absPath)
+		defer sharded.Close()
+		sharded.SetEmbedOptions(embedOpts)
+
+		names := resolveShards(absPath, *shards)
+		if err := sharded.IndexShards(names); err != nil {
+			log.Fatalf("Failed to index shards: %v", err)
+		}
+		printShardStats(sharded)
+		return
+	}
+
 	indexer := newRAGIndexer(absPath)
+	indexer.SetEmbedOptions(embedOpts)
 
 	err := indexer.IndexProject(absPath)
 	if err != nil {
@@ -713,11 +1816,65 @@ func cmdRAGIndex() {
 	fmt.Printf("  Dims:     %d\n", stats.Dimensions)
 }
 
+func cmdRAGUpdate() {
+	fs := flag.NewFlagSet("rag update", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project to update")
+	shards := fs.String("shards", "", "Comma-separated shards to update (or \"all\" for every indexed shard)")
+	batchSize := fs.Int("batch-size", 0, "Chunks per embedding request (default 10, or rag.batch_size in .indexer.json)")
+	concurrency := fs.Int("concurrency", 0, "Concurrent embedding requests (default 1, or rag.concurrency)")
+	rpm := fs.Int("rpm", 0, "Maximum embedding requests per minute (default unlimited, or rag.requests_per_minute)")
+	embedderFlags(fs)
+	chunkerFlags(fs)
+	fs.Parse(os.Args[3:])
+	if fs.NArg() > 0 {
+		*projectPath = fs.Arg(0)
+	}
+
+	absPath, _ := filepath.Abs(*projectPath)
+	embedOpts := loadEmbedOptions(absPath, *batchSize, *concurrency, *rpm)
+
+	var (
+		stats rag.UpdateStats
+		err   error
+	)
+	if *shards != "" {
+		sharded := newShardedRAGIndexer(absPath)
+		defer sharded.Close()
+		sharded.SetEmbedOptions(embedOpts)
+
+		stats, err = sharded.UpdateShards(resolveShards(absPath, *shards))
+	} else {
+		indexer := newRAGIndexer(absPath)
+		defer indexer.Close()
+		indexer.SetEmbedOptions(embedOpts)
+		stats, err = indexer.Update(absPath)
+	}
+	var (
+		mismatch     *rag.EmbedderMismatchError
+		dimsMismatch *rag.DimensionMismatchError
+	)
+	if errors.As(err, &mismatch) || errors.As(err, &dimsMismatch) {
+		log.Fatalf("Update failed: %v\nPass the -embedder/-embedding-model the index was built with, or run 'indexer rag reembed' first.", err)
+	}
+	if err != nil {
+		log.Fatalf("Update failed: %v", err)
+	}
+
+	fmt.Printf("✓ %d added, %d modified, %d removed, %d unchanged files (%d chunks embedded)\n",
+		stats.Added, stats.Modified, stats.Removed, stats.Unchanged, stats.Chunks)
+}
+
 func cmdRAGSearch() {
 	fs := flag.NewFlagSet("rag search", flag.ExitOnError)
 	topK := fs.Int("top-k", 10, "Number of results to return")
 	jsonOutput := fs.Bool("json", false, "Output in JSON format")
 	projectPath := fs.String("path", ".", "Path to the project to search")
+	shards := fs.String("shards", "", "Comma-separated shards to search (or \"all\" for every indexed shard)")
+	langs := fs.String("lang", "", "Comma-separated languages to keep, e.g. go,python")
+	chunkTypes := fs.String("type", "", "Comma-separated chunk types to keep, e.g. function,method")
+	in := fs.String("in", "", "Path to keep, relative to the project: a directory, a file, or a glob where * also matches /")
+	symbol := fs.String("symbol", "", "Keep chunks whose symbol name starts with this prefix")
+	embedderFlags(fs)
 	fs.Parse(os.Args[3:])
 
 	if fs.NArg() < 1 {
@@ -726,17 +1883,56 @@ func cmdRAGSearch() {
 
 	query := fs.Arg(0)
 	absPath, _ := filepath.Abs(*projectPath)
+	loadConfig(absPath)
 
-	indexer := newRAGIndexer(absPath)
-
-	if indexer.Stats().TotalChunks == 0 {
-		log.Fatal("RAG index is empty. Please run 'indexer rag index <path>' first.")
+	languages, err := searchfilter.Parse("", *langs, "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	filter := rag.SearchFilter{
+		Languages:    languages.Languages,
+		ChunkTypes:   splitList(*chunkTypes),
+		Path:         rag.PathGlob(absPath, *in),
+		SymbolPrefix: *symbol,
 	}
 
-	fmt.Printf("\n=== RAG Search ===\n")
-	fmt.Printf("Query: %s\n\n", query)
+	var results []*rag.SearchResult
+
+	if *shards != "" {
+		sharded := newShardedRAGIndexer(absPath)
+		defer sharded.Close()
+
+		names := rag.IndexedShards(absPath)
+		if *shards != "all" {
+			names = splitList(*shards)
+		}
+		if len(names) == 0 {
+			log.Fatal("No indexed shards found. Please run 'indexer rag index -shards=all' first.")
+		}
+		if err := sharded.Open(names); err != nil {
+			log.Fatalf("Failed to open shards: %v", err)
+		}
+
+		fmt.Printf("\n=== RAG Search (%d shards) ===\n", len(names))
+		fmt.Printf("Query: %s\n\n", query)
+
+		results, err = sharded.Search(query, *topK, filter)
+	} else {
+		indexer := newRAGIndexer(absPath)
+
+		if indexer.Stats().TotalChunks == 0 {
+			log.Fatal("RAG index is empty. Please run 'indexer rag index <path>' first.")
+		}
+
+		fmt.Printf("\n=== RAG Search ===\n")
+		fmt.Printf("Query: %s\n\n", query)
 
-	results, err := indexer.Search(query, *topK)
+		results, err = indexer.Search(query, *topK, filter)
+	}
+	var mismatch *rag.EmbedderMismatchError
+	if errors.As(err, &mismatch) {
+		log.Fatalf("Search failed: %v\nPass the -embedder/-embedding-model the index was built with, or run 'indexer rag reembed' to migrate it to the configured embedder.", err)
+	}
 	if err != nil {
 		log.Fatalf("Search failed: %v", err)
 	}
@@ -752,6 +1948,16 @@ func cmdRAGSearch() {
 		fmt.Printf("%d. [Score: %.3f] %s\n", i+1, result.Score, result.Chunk.FilePath)
 		fmt.Printf("   Lines %d-%d: %s\n", result.Chunk.StartLine, result.Chunk.EndLine, result.Chunk.SymbolName)
 		fmt.Printf("   Type: %s | Language: %s\n", result.Chunk.ChunkType, result.Chunk.Language)
+		if dups := result.Chunk.Duplicates; len(dups) > 0 {
+			also := make([]string, len(dups))
+			for j, d := range dups {
+				also[j] = fmt.Sprintf("%s:%d-%d", d.FilePath, d.StartLine, d.EndLine)
+			}
+			fmt.Printf("   Also in: %s\n", strings.Join(also, ", "))
+		}
+		if result.Chunk.Summary != "" {
+			fmt.Printf("   Summary: %s\n", result.Chunk.Summary)
+		}
 
 		// Show snippet
 		lines := strings.Split(result.Chunk.Content, "\n")
@@ -766,13 +1972,60 @@ func cmdRAGSearch() {
 	}
 }
 
+func cmdRAGDelete() {
+	fs := flag.NewFlagSet("rag delete", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	embedderFlags(fs)
+	fs.Parse(os.Args[3:])
+
+	if fs.NArg() < 1 {
+		log.Fatal("Usage: indexer rag delete <dir|file|glob>...")
+	}
+
+	absPath, _ := filepath.Abs(*projectPath)
+	loadConfig(absPath)
+
+	var remove func(pattern string) (int, error)
+	if names := rag.IndexedShards(absPath); len(names) > 0 {
+		sharded := newShardedRAGIndexer(absPath)
+		defer sharded.Close()
+		if err := sharded.Open(names); err != nil {
+			log.Fatalf("Failed to open shards: %v", err)
+		}
+		remove = sharded.RemoveWhere
+	} else {
+		indexer := newRAGIndexer(absPath)
+		defer indexer.Close()
+		remove = indexer.RemoveWhere
+	}
+
+	for _, arg := range fs.Args() {
+		removed, err := remove(rag.PathGlob(absPath, arg))
+		if err != nil {
+			log.Fatalf("Delete failed: %v", err)
+		}
+		fmt.Printf("✓ %s: removed %d files\n", arg, removed)
+	}
+}
+
 func cmdRAGStatus() {
 	fs := flag.NewFlagSet("rag status", flag.ExitOnError)
 	projectPath := fs.String("path", ".", "Path to the project")
+	embedderFlags(fs)
 	fs.Parse(os.Args[3:])
 
 	absPath, _ := filepath.Abs(*projectPath)
 
+	if names := rag.IndexedShards(absPath); len(names) > 0 {
+		sharded := newShardedRAGIndexer(absPath)
+		defer sharded.Close()
+		if err := sharded.Open(names); err != nil {
+			log.Fatalf("Failed to open shards: %v", err)
+		}
+		fmt.Printf("\n=== RAG Shards ===\n")
+		printShardStats(sharded)
+	}
+
 	indexer := newRAGIndexer(absPath)
 	stats := indexer.Stats()
 
@@ -781,6 +2034,27 @@ func cmdRAGStatus() {
 	fmt.Printf("Total Chunks:    %d\n", stats.TotalChunks)
 	fmt.Printf("Embedding Model: %s\n", stats.EmbeddingModel)
 	fmt.Printf("Dimensions:      %d\n", stats.Dimensions)
+	if models, err := indexer.EmbeddingModels(); err == nil {
+		// Only worth showing when some chunks were embedded by another model.
+		mixed := len(models) > 1
+		names := make([]string, 0, len(models))
+		for model := range models {
+			mixed = mixed || (model != "" && model != stats.EmbeddingModel)
+			names = append(names, model)
+		}
+		if mixed {
+			slices.Sort(names)
+			counts := make([]string, len(names))
+			for i, model := range names {
+				label := model
+				if label == "" {
+					label = "unrecorded"
+				}
+				counts[i] = fmt.Sprintf("%s (%d)", label, models[model])
+			}
+			fmt.Printf("Chunk Models:    %s\n", strings.Join(counts, ", "))
+		}
+	}
 
 	if stats.LastUpdated != "" {
 		fmt.Printf("Last Updated:    %s\n", stats.LastUpdated)
@@ -788,6 +2062,8 @@ func cmdRAGStatus() {
 
 	if stats.TotalChunks == 0 {
 		fmt.Printf("\n⚠ Index is empty. Run 'indexer rag index <path>' to build the index.\n")
+	} else if err := indexer.CheckEmbedder(); err != nil {
+		fmt.Printf("\n⚠ %v\n  Run 'indexer rag reembed' to migrate it.\n", err)
 	} else {
 		fmt.Printf("\n✓ Index is ready\n")
 	}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yourorg/agent/internal/memory"
+)
+
+func cmdMemory() {
+	subcommand := "list"
+	args := os.Args[2:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		subcommand, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("memory "+subcommand, flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	category := fs.String("category", "", "Category for memory add (default \"general\")")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	fs.Parse(args)
+
+	absPath, _ := filepath.Abs(*projectPath)
+	store, err := memory.Load(absPath)
+	if err != nil {
+		log.Fatalf("Failed to load memory: %v", err)
+	}
+
+	switch subcommand {
+	case "list":
+		entries := store.Entries()
+		if *jsonOutput {
+			if entries == nil {
+				entries = []memory.Entry{}
+			}
+			data, _ := json.MarshalIndent(entries, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Println("No memory entries. The agent adds them during runs, or use: indexer memory add \"<note>\"")
+			return
+		}
+		for i, e := range entries {
+			fmt.Printf("%3d. [%s] %s\n", i+1, e.Category, e.Text)
+		}
+
+	case "add":
+		if fs.NArg() < 1 {
+			log.Fatal("Usage: indexer memory add [-category name] \"<note>\"")
+		}
+		if !store.Add(*category, strings.Join(fs.Args(), " ")) {
+			fmt.Println("Already remembered.")
+			return
+		}
+		saveMemory(store)
+		fmt.Printf("Added to %s\n", store.Path())
+
+	case "remove", "rm":
+		if fs.NArg() < 1 {
+			log.Fatal("Usage: indexer memory remove <number>")
+		}
+		n, err := strconv.Atoi(fs.Arg(0))
+		if err != nil {
+			log.Fatalf("Invalid entry number %q (see indexer memory list)", fs.Arg(0))
+		}
+		e, err := store.Remove(n - 1)
+		if err != nil {
+			log.Fatal(err)
+		}
+		saveMemory(store)
+		fmt.Printf("Removed: [%s] %s\n", e.Category, e.Text)
+
+	case "clear":
+		store.Clear()
+		saveMemory(store)
+		fmt.Println("Memory cleared.")
+
+	case "edit":
+		if _, err := os.Stat(store.Path()); os.IsNotExist(err) {
+			saveMemory(store)
+		}
+		editor := os.Getenv("VISUAL")
+		if editor == "" {
+			editor = os.Getenv("EDITOR")
+		}
+		if editor == "" {
+			editor = "vi"
+		}
+		cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", store.Path())
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			log.Fatalf("Editor failed: %v", err)
+		}
+
+	case "path":
+		fmt.Println(store.Path())
+
+	default:
+		log.Fatalf("Unknown memory subcommand: %s\nAvailable: list, add, remove, clear, edit, path", subcommand)
+	}
+}
+
+func saveMemory(store *memory.Store) {
+	if err := store.Save(); err != nil {
+		log.Fatalf("Failed to save memory: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/yourorg/agent/internal/config"
+	"github.com/yourorg/agent/internal/notify"
+)
+
+// newNotifier builds the notifiers configured in .indexer.json or the
+// environment. It returns nil when none are configured.
+func newNotifier(cfg config.NotifyConfig) notify.Notifier {
+	webhookURL := cfg.WebhookURL
+	if webhookURL == "" {
+		webhookURL = os.Getenv("AGENT_WEBHOOK_URL")
+	}
+	slackURL := cfg.SlackWebhookURL
+	if slackURL == "" {
+		slackURL = os.Getenv("SLACK_WEBHOOK_URL")
+	}
+
+	var notifiers notify.Multi
+	if webhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(webhookURL, cfg.WebhookHeaders))
+	}
+	if slackURL != "" {
+		notifiers = append(notifiers, notify.NewSlackNotifier(slackURL, cfg.SlackChannel))
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return notifiers
+}
+
+// notifyRun posts a run summary to the configured notifiers. Delivery
+// failures are logged but never fail the run.
+func notifyRun(cfg config.NotifyConfig, summary notify.Summary) {
+	notifier := newNotifier(cfg)
+	if notifier == nil {
+		return
+	}
+	if cfg.OnFailureOnly && summary.Success {
+		return
+	}
+
+	if price, ok := cfg.Pricing[summary.Model]; ok {
+		summary.CostUSD = float64(summary.TokensUsed) / 1e6 * price
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := notifier.Notify(ctx, summary); err != nil {
+		log.Printf("Warning: failed to send run notification: %v", err)
+	}
+}
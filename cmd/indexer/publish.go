@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/config"
+	"github.com/yourorg/agent/internal/forge"
+)
+
+// runBranch is the branch an agent run commits to before it is published.
+type runBranch struct {
+	git    forge.Git
+	remote string
+	name   string
+	base   string
+}
+
+// startRunBranch checks out a fresh branch for the run. The working tree must
+// be clean so unrelated local changes don't end up in the published branch.
+func startRunBranch(projectPath, remote, base, prefix, task string) *runBranch {
+	git := forge.Git{Dir: projectPath}
+
+	clean, err := git.IsClean()
+	if err != nil {
+		log.Fatalf("Failed to inspect git status: %v", err)
+	}
+	if !clean {
+		log.Fatal("Working tree has uncommitted changes; commit or stash them before publishing a run")
+	}
+
+	if base == "" {
+		if base, err = git.CurrentBranch(); err != nil {
+			log.Fatalf("Failed to determine current branch: %v", err)
+		}
+	}
+	if remote == "" {
+		remote = "origin"
+	}
+
+	name := forge.BranchName(prefix, task)
+	if err := git.CreateBranch(name); err != nil {
+		log.Fatalf("Failed to create branch: %v", err)
+	}
+	fmt.Printf("Working on branch %s (base %s)\n", name, base)
+
+	return &runBranch{git: git, remote: remote, name: name, base: base}
+}
+
+// push commits the run's changes and pushes the branch. It reports false
+// when the run changed nothing.
+func (b *runBranch) push(task string) bool {
+	committed, err := b.git.CommitAll("agent: " + task)
+	if err != nil {
+		log.Fatalf("Failed to commit changes: %v", err)
+	}
+	if !committed {
+		fmt.Println("Agent run made no changes; nothing to publish")
+		return false
+	}
+	if err := b.git.Push(b.remote, b.name); err != nil {
+		log.Fatalf("Failed to push branch: %v", err)
+	}
+	return true
+}
+
+// repoPath returns the host and repository path of the branch's remote.
+func (b *runBranch) repoPath() (string, string) {
+	remoteURL, err := b.git.RemoteURL(b.remote)
+	if err != nil {
+		log.Fatalf("Failed to read remote URL: %v", err)
+	}
+	host, path, err := forge.ParseRemote(remoteURL)
+	if err != nil {
+		log.Fatalf("Failed to parse remote URL: %v", err)
+	}
+	return host, path
+}
+
+// changeRequestBody renders the plan checklist and, unless withLog is false,
+// the execution log for a PR/MR description.
+func changeRequestBody(result *agent.RunResult, withLog bool) string {
+	tm := agent.NewTaskManager()
+
+	var b strings.Builder
+	b.WriteString("Opened automatically by `indexer agent run`.\n\n")
+	b.WriteString("## Plan\n\n")
+	b.WriteString(tm.FormatAsChecklist(result.Plan))
+	if withLog {
+		b.WriteString("\n")
+		b.WriteString(executionSummary(result))
+	}
+	return b.String()
+}
+
+// executionSummary renders the execution log as a markdown section.
+func executionSummary(result *agent.RunResult) string {
+	tm := agent.NewTaskManager()
+	return "## Execution log\n\n```\n" + tm.FormatExecutionLog(result.Executions) + "```\n"
+}
+
+func changeRequestTitle(task string) string {
+	title := strings.TrimSpace(strings.SplitN(task, "\n", 2)[0])
+	if len(title) > 72 {
+		title = title[:69] + "..."
+	}
+	return title
+}
+
+// openGitHubPullRequest pushes the run branch and opens a pull request for
+// it, returning the PR URL ("" when the run changed nothing).
+func openGitHubPullRequest(cfg config.GitHubConfig, branch *runBranch, task string, result *agent.RunResult) string {
+	if !branch.push(task) {
+		return ""
+	}
+
+	owner, repo := cfg.Owner, cfg.Repo
+	if owner == "" || repo == "" {
+		_, path := branch.repoPath()
+		if parts := strings.Split(path, "/"); len(parts) == 2 {
+			owner, repo = parts[0], parts[1]
+		}
+	}
+
+	client, err := forge.NewGitHubClient(forge.GitHubConfig{
+		Token:   os.Getenv("GITHUB_TOKEN"),
+		Owner:   owner,
+		Repo:    repo,
+		BaseURL: cfg.APIURL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create GitHub client: %v", err)
+	}
+
+	url, err := client.Open(context.Background(), forge.ChangeRequest{
+		Title: changeRequestTitle(task),
+		Body:  changeRequestBody(result, true),
+		Head:  branch.name,
+		Base:  branch.base,
+		Draft: cfg.Draft,
+	})
+	if err != nil {
+		log.Fatalf("Failed to open pull request: %v", err)
+	}
+	fmt.Printf("\n✓ Opened pull request: %s\n", url)
+	return url
+}
+
+// openGitLabMergeRequest pushes the run branch and opens a merge request for
+// it, returning the MR URL ("" when the run changed nothing).
+func openGitLabMergeRequest(cfg config.GitLabConfig, branch *runBranch, task string, result *agent.RunResult) string {
+	if !branch.push(task) {
+		return ""
+	}
+
+	projectURL := cfg.ProjectURL
+	if projectURL == "" {
+		host, path := branch.repoPath()
+		projectURL = "https://" + host + "/" + path
+	}
+
+	client, err := forge.NewGitLabClient(forge.GitLabConfig{
+		Token:      os.Getenv("GITLAB_TOKEN"),
+		ProjectURL: projectURL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create GitLab client: %v", err)
+	}
+
+	ctx := context.Background()
+	mr, err := client.CreateMergeRequest(ctx, forge.ChangeRequest{
+		Title: changeRequestTitle(task),
+		Body:  changeRequestBody(result, !cfg.PostNotes),
+		Head:  branch.name,
+		Base:  branch.base,
+		Draft: cfg.Draft,
+	})
+	if err != nil {
+		log.Fatalf("Failed to open merge request: %v", err)
+	}
+	fmt.Printf("\n✓ Opened merge request: %s\n", mr.WebURL)
+
+	if cfg.PostNotes {
+		if err := client.AddNote(ctx, mr.IID, executionSummary(result)); err != nil {
+			log.Printf("Warning: failed to post execution summary note: %v", err)
+		}
+	}
+	return mr.WebURL
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/rag"
+)
+
+func cmdRAGReembed() {
+	fs := flag.NewFlagSet("rag reembed", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	provider := fs.String("provider", "", "Embedding provider to migrate to: ollama, local, openai, voyage, cohere (default rag.embedder.provider)")
+	model := fs.String("model", "", "Embedding model to migrate to (default rag.embedder.model)")
+	dimensions := fs.Int("dimensions", 0, "Embedding dimensions: shortens text-embedding-3 embeddings, or sizes a model the indexer does not know")
+	bulk := fs.Bool("bulk", false, "Embed through the OpenAI/Voyage batch API: about half the cost, but can take hours (or rag.bulk)")
+	batchSize := fs.Int("batch-size", 0, "Chunks per embedding request (default 10, or rag.batch_size in .indexer.json)")
+	concurrency := fs.Int("concurrency", 0, "Concurrent embedding requests (default 1, or rag.concurrency)")
+	rpm := fs.Int("rpm", 0, "Maximum embedding requests per minute (default unlimited, or rag.requests_per_minute)")
+	fs.Parse(os.Args[3:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+	if p := cfg.RAG.Store.Provider; p != "" && p != "sqlite" {
+		log.Fatalf("rag reembed migrates SQLite indexes; rebuild the %s index with 'indexer rag index' and the new embedder", p)
+	}
+
+	embedderCfg := overrideEmbedder(cfg.RAG.Embedder, *provider, *model, *dimensions)
+	embedderCfg.APIKey = apiKeyFromEnv(embedderCfg.Provider)
+	embedder, err := rag.NewEmbedder(embedderCfg)
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+
+	quant, err := rag.ParseQuantization(cfg.RAG.Store.Quantization)
+	if err != nil {
+		log.Fatalf("Invalid rag.store.quantization: %v", err)
+	}
+	opts := loadEmbedOptions(absPath, *batchSize, *concurrency, *rpm)
+	if *bulk {
+		opts.Bulk = true
+	}
+
+	var stores []string
+	if mainDB := filepath.Join(absPath, ".index", "rag_vectors.db"); fileExists(mainDB) {
+		stores = append(stores, mainDB)
+	}
+	for _, shard := range rag.IndexedShards(absPath) {
+		stores = append(stores, rag.ShardDBPath(absPath, shard))
+	}
+	if len(stores) == 0 {
+		log.Fatal("No RAG index found. Run 'indexer rag index' to build one with the new embedder.")
+	}
+
+	fmt.Printf("\n=== RAG Re-embed ===\n")
+	fmt.Printf("Migrating to %s (%d dims)\n", embedder.Model(), embedder.Dimension())
+	for _, dbPath := range stores {
+		rel, _ := filepath.Rel(absPath, dbPath)
+		fmt.Printf("\n--- %s ---\n", rel)
+		n, err := reembedStore(dbPath, embedder, quant, opts, absPath, cfg.RAG.Privacy)
+		if err != nil {
+			log.Fatalf("Failed to re-embed %s: %v (the existing index is unchanged)", rel, err)
+		}
+		if n >= 0 {
+			fmt.Printf("✓ Re-embedded %d chunks\n", n)
+		}
+	}
+
+	if embedderCfg.Provider != cfg.RAG.Embedder.Provider || embedderCfg.Model != cfg.RAG.Embedder.Model {
+		fmt.Printf("\nSet rag.embedder in .indexer.json so searches use the new model:\n")
+		fmt.Printf("  \"embedder\": {\"provider\": %q, \"model\": %q}\n", providerOrDefault(embedderCfg.Provider), embedder.Model())
+	}
+}
+
+// reembedStore re-embeds the index at dbPath into a new database and
+// replaces the old one only once every chunk is stored, so a failure
+// leaves it intact. It returns -1 if the index already uses embedder.
+func reembedStore(dbPath string, embedder rag.Embedder, quant rag.Quantization, opts rag.EmbedOptions, projectPath string, privacy rag.PrivacyPolicy) (int, error) {
+	src, err := rag.NewSQLiteVectorStore(dbPath, 0)
+	if err != nil {
+		return 0, err
+	}
+	meta, err := src.EmbeddingMetadata()
+	if err != nil {
+		src.Close()
+		return 0, err
+	}
+	models, err := src.EmbeddingModels()
+	if err != nil {
+		src.Close()
+		return 0, err
+	}
+	stale := 0
+	for model, n := range models {
+		if model != "" && model != embedder.Model() {
+			stale += n
+		}
+	}
+	if meta.Model == embedder.Model() && meta.Dimensions == embedder.Dimension() && stale == 0 {
+		src.Close()
+		fmt.Printf("Already embedded with %s\n", embedder.Model())
+		return -1, nil
+	}
+	if meta.Model != "" {
+		fmt.Printf("Re-embedding from %s (%d dims)\n", meta.Model, meta.Dimensions)
+	}
+	if stale > 0 {
+		fmt.Printf("%d chunks were embedded with another model\n", stale)
+	}
+
+	tmpPath := dbPath + ".reembed"
+	_ = os.Remove(tmpPath)
+	dst, err := rag.NewSQLiteVectorStore(tmpPath, embedder.Dimension())
+	if err != nil {
+		src.Close()
+		return 0, err
+	}
+	if err := dst.SetQuantization(quant); err != nil {
+		src.Close()
+		dst.Close()
+		return 0, err
+	}
+
+	idx := rag.NewRAGIndexer(embedder, dst)
+	idx.SetEmbedOptions(opts)
+	idx.SetPrivacyPolicy(projectPath, privacy)
+	n, err := idx.Reembed(src)
+	src.Close()
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, err
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		return 0, fmt.Errorf("replace index: %w", err)
+	}
+	return n, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func providerOrDefault(provider string) string {
+	if provider == "" {
+		return "ollama"
+	}
+	return provider
+}
+
+func cmdRAGDownloadModel() {
+	fs := flag.NewFlagSet("rag download-model", flag.ExitOnError)
+	fs.Parse(os.Args[3:])
+
+	name := fs.Arg(0)
+	if name == "" {
+		name = rag.DefaultLocalModel
+	}
+	dir, err := rag.DownloadLocalModel(context.Background(), name, func(file string) {
+		fmt.Printf("Downloading %s...\n", file)
+	})
+	if err != nil {
+		log.Fatalf("Failed to download model: %v", err)
+	}
+	if _, err := rag.NewLocalEmbedder(rag.EmbedderConfig{Model: dir}); err != nil {
+		log.Fatalf("Downloaded model cannot be used: %v", err)
+	}
+	fmt.Printf("\n✓ Saved %s to %s\n", name, dir)
+	fmt.Printf("Index with it using -embedder=local -embedding-model=%s, or set in .indexer.json:\n", name)
+	fmt.Printf("  \"embedder\": {\"provider\": \"local\", \"model\": %q}\n", name)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/routes"
+	"github.com/yourorg/agent/internal/workspace"
+)
+
+func cmdRoutes() {
+	fs := flag.NewFlagSet("routes", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	method := fs.String("method", "", "Only routes serving this HTTP method")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	fs.Parse(os.Args[2:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+	all, err := routes.Load(absPath)
+	if err != nil {
+		log.Fatalf("Failed to extract routes: %v", err)
+	}
+	found := routes.Match(all, fs.Arg(0), *method)
+
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(found, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	if len(found) == 0 {
+		fmt.Printf("No routes found (%d routes in the project)\n", len(all))
+		return
+	}
+	fmt.Print(routes.Format(found))
+}
+
+// relevantRoutes returns the routes of the selected roots serving the URL
+// paths a task mentions. Errors are logged and otherwise ignored, like
+// relevantDeps.
+func relevantRoutes(projectPath string, roots []workspace.Root, task string) []routes.Route {
+	all, err := routes.Load(projectPath)
+	if err != nil {
+		log.Printf("Warning: failed to extract routes: %v", err)
+		return nil
+	}
+	var found []routes.Route
+	for _, r := range routes.ForTask(all, task) {
+		if workspace.Contains(roots, r.File) {
+			found = append(found, r)
+		}
+	}
+	return found
+}
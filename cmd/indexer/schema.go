@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/sqlschema"
+	"github.com/yourorg/agent/internal/workspace"
+)
+
+// searchSchema shows the tables query names, replayed from the project's
+// SQL files and migrations.
+func searchSchema(absPath, query string, jsonOutput bool) {
+	objs, err := sqlschema.Load(absPath)
+	if err != nil {
+		log.Fatalf("Failed to load SQL files: %v", err)
+	}
+	tables := sqlschema.ForTask(sqlschema.Tables(objs), query)
+
+	if jsonOutput {
+		data, _ := json.MarshalIndent(tables, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Printf("Found %d tables for '%s':\n\n", len(tables), query)
+	fmt.Print(sqlschema.Format(tables))
+}
+
+// schemaResults presents SQL schema objects as search results of their
+// kind: table, column, index or view.
+func schemaResults(objs []sqlschema.Object) []indexer.SearchResult {
+	results := make([]indexer.SearchResult, len(objs))
+	for i, o := range objs {
+		name := o.Name
+		if o.Table != "" {
+			name = o.Table + "." + o.Name
+		}
+		results[i] = indexer.SearchResult{Name: name, Type: o.Kind, FilePath: o.File, Line: o.Line, Signature: o.Type}
+	}
+	return results
+}
+
+// schemaContext is the part of the schema fetch_context reports: the
+// tables a task names and the migration new ones follow.
+type schemaContext struct {
+	Tables []*sqlschema.Table `json:"tables"`
+	Latest *sqlschema.Object  `json:"latest_migration,omitempty"`
+}
+
+func (s *schemaContext) String() string {
+	out := sqlschema.Format(s.Tables)
+	if s.Latest != nil {
+		out += fmt.Sprintf("latest migration: %s (%s)\n", s.Latest.Migration, s.Latest.File)
+	}
+	return out
+}
+
+// relevantSchema returns the tables of the selected roots a task names,
+// or nil when it names none. Errors are logged and otherwise ignored,
+// like relevantDeps.
+func relevantSchema(projectPath string, roots []workspace.Root, task string) *schemaContext {
+	objs, err := sqlschema.Load(projectPath)
+	if err != nil {
+		log.Printf("Warning: failed to read SQL files: %v", err)
+		return nil
+	}
+	var scoped []sqlschema.Object
+	for _, o := range objs {
+		if workspace.Contains(roots, o.File) {
+			scoped = append(scoped, o)
+		}
+	}
+	tables := sqlschema.ForTask(sqlschema.Tables(scoped), task)
+	if len(tables) == 0 {
+		return nil
+	}
+	s := &schemaContext{Tables: tables}
+	if latest, ok := sqlschema.Latest(scoped); ok {
+		s.Latest = &latest
+	}
+	return s
+}
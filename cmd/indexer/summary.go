@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/summary"
+)
+
+// cmdSummary regenerates .index/PROJECT_SUMMARY.md. With -llm the module
+// responsibilities section is written by the model.
+func cmdSummary() {
+	fs := flag.NewFlagSet("summary", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	useLLM := fs.Bool("llm", false, "Have the LLM describe module responsibilities")
+	provider := fs.String("provider", "claude", "LLM provider for -llm (claude, gemini, openai, ollama, llamacpp)")
+	model := fs.String("model", "", "Model name (provider-specific)")
+	apiKey := fs.String("api-key", "", "API key (or use environment variable)")
+	quiet := fs.Bool("quiet", false, "Only write the file; don't print it")
+	fs.Parse(os.Args[2:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+	cfg := loadConfig(absPath)
+
+	var content string
+	if *useLLM {
+		if *apiKey == "" {
+			*apiKey = apiKeyFromEnv(*provider)
+		}
+		codingAgent, err := agent.NewCodingAgent(agent.AgentConfig{
+			ProjectPath: absPath,
+			LLMConfig: agent.LLMConfig{
+				Provider: *provider,
+				APIKey:   *apiKey,
+				Model:    *model,
+			},
+			SystemPrompts: cfg.SystemPrompts,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create agent: %v", err)
+		}
+		content, err = codingAgent.SummarizeProject(context.Background(), true)
+		if err != nil {
+			log.Fatalf("Failed to summarize project: %v", err)
+		}
+	} else {
+		idx := indexer.NewIndexer()
+		idx.RegisterParser(indexer.NewGoParser())
+		idx.RegisterParser(indexer.NewPythonParser())
+		idx.SetCacheEnabled(true)
+		projIdx, err := idx.IndexProject(absPath)
+		if err != nil {
+			log.Fatalf("Failed to index project: %v", err)
+		}
+		s, err := summary.Build(absPath, projIdx)
+		if err != nil {
+			log.Fatalf("Failed to build summary: %v", err)
+		}
+		existing, err := summary.Load(absPath)
+		if err != nil {
+			log.Fatalf("Failed to read summary: %v", err)
+		}
+		s.Responsibilities = summary.Responsibilities(existing)
+		if err := s.Save(absPath); err != nil {
+			log.Fatalf("Failed to save summary: %v", err)
+		}
+		content = s.Markdown()
+	}
+
+	if !*quiet {
+		fmt.Println(content)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", summary.Path(absPath))
+}
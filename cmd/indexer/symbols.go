@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/symbols"
+	"github.com/yourorg/agent/internal/workspace"
+)
+
+// loadSymbols indexes the languages the structural indexer has no parser
+// for. Errors are logged and give an empty index, like relevantDeps.
+func loadSymbols(projectPath string) *symbols.Index {
+	return indexSymbols(projectPath, 0, false, nil)
+}
+
+// symbolImport is an index file another tool wrote, such as a SCIP
+// index or a tags file, to merge into the symbols of other languages.
+type symbolImport struct {
+	path, format string
+}
+
+// indexSymbols is loadSymbols parsing workers files at once, or one per
+// CPU for 0, and merging in imports. refresh parses every file again
+// instead of reusing those saved in .index/structure.db, and forgets the
+// index files imported before.
+func indexSymbols(projectPath string, workers int, refresh bool, imports []symbolImport) *symbols.Index {
+	x := symbols.NewIndexer()
+	for _, p := range symbols.Parsers() {
+		x.RegisterParser(p)
+	}
+	x.SetConcurrency(workers)
+	if refresh {
+		if err := os.Remove(symbols.Path(projectPath)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Warning: failed to remove the saved symbols: %v", err)
+		}
+	}
+	for _, imp := range imports {
+		if err := x.Import(imp.path, imp.format); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	idx, err := x.IndexProject(projectPath)
+	if err != nil {
+		log.Printf("Warning: failed to index other languages: %v", err)
+		return new(symbols.Index)
+	}
+	return idx
+}
+
+func symbolResults(syms []symbols.Symbol) []indexer.SearchResult {
+	results := make([]indexer.SearchResult, len(syms))
+	for i, s := range syms {
+		results[i] = indexer.SearchResult{Name: s.Name, Type: s.Kind, FilePath: s.File, Line: s.Line, Signature: s.Signature, Doc: s.Doc}
+	}
+	return results
+}
+
+// contextSymbols is the number of symbols of other languages
+// fetch_context adds.
+const contextSymbols = 10
+
+// relevantSymbols returns the symbols of other languages in the selected
+// roots that a task names.
+func relevantSymbols(projectPath string, roots []workspace.Root, task string) []symbols.Result {
+	scoped := loadSymbols(projectPath).SymbolsIn(func(file string) bool {
+		return workspace.Contains(roots, file)
+	})
+	return symbols.Relevant(scoped, task, contextSymbols)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/testmap"
+)
+
+func cmdTests() {
+	fs := flag.NewFlagSet("tests", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	files := fs.String("file", "", "Comma-separated files: the tests covering anything declared in them")
+	run := fs.Bool("commands", false, "Print only the commands running the tests")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	fs.Parse(os.Args[2:])
+
+	if fs.NArg() < 1 && *files == "" {
+		log.Fatal("Usage: indexer tests <symbol> | -file <file,...> [-commands] [-json]")
+	}
+
+	absPath, _ := filepath.Abs(*projectPath)
+	m, err := testmap.Build(absPath)
+	if err != nil {
+		log.Fatalf("Failed to map tests: %v", err)
+	}
+	var covs []testmap.Coverage
+	if *files != "" {
+		var list []string
+		for _, f := range strings.Split(*files, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				if abs, err := filepath.Abs(f); err == nil {
+					if rel, err := filepath.Rel(absPath, abs); err == nil {
+						f = rel
+					}
+				}
+				list = append(list, filepath.ToSlash(f))
+			}
+		}
+		covs = m.TestsForFiles(list)
+	} else {
+		covs = m.TestsFor(fs.Arg(0))
+	}
+
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(covs, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	if len(covs) == 0 {
+		fmt.Printf("No tests found (%d tests in the project)\n", len(m.Tests))
+		return
+	}
+	if *run {
+		fmt.Println(strings.Join(testmap.Commands(covs), "\n"))
+		return
+	}
+	fmt.Print(testmap.Format(covs))
+	fmt.Printf("\nRun with:\n  %s\n", strings.Join(testmap.Commands(covs), "\n  "))
+}
+
+// enableTestMap points the agent at the tests covering each change it
+// makes. Failures are logged and leave it disabled.
+func enableTestMap(codingAgent *agent.CodingAgent, projectPath string, enabled bool) {
+	if !enabled {
+		return
+	}
+	m, err := testmap.Build(projectPath)
+	if err != nil {
+		log.Printf("Warning: failed to map tests: %v", err)
+		return
+	}
+	if len(m.Tests) > 0 {
+		fmt.Printf("Tests: %d mapped to the code they cover\n", len(m.Tests))
+	}
+	codingAgent.SetTestMap(m)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/vuln"
+)
+
+func cmdVulns() {
+	fs := flag.NewFlagSet("vulns", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	tools := fs.String("tools", "", "Comma-separated scanners to run (govulncheck, npm audit, pip-audit; default: all that apply)")
+	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	fs.Parse(os.Args[2:])
+
+	absPath, _ := filepath.Abs(*projectPath)
+
+	scanner := vuln.NewScanner(vuln.ScannerConfig{ProjectRoot: absPath, Tools: splitList(*tools)})
+	if len(scanner.Tools()) == 0 {
+		log.Fatal("No vulnerability scanners apply (install govulncheck, npm or pip-audit)")
+	}
+	if !*jsonOutput {
+		fmt.Printf("Scanning with: %s\n\n", strings.Join(scanner.Tools(), ", "))
+	}
+
+	findings, errs := scanner.Scan(context.Background())
+	for _, err := range errs {
+		log.Printf("Warning: %v", err)
+	}
+	if fs.NArg() > 0 {
+		findings = vuln.Filter(findings, fs.Args())
+	}
+
+	if len(findings) > 0 {
+		idx := indexer.NewIndexer()
+		idx.RegisterParser(indexer.NewGoParser())
+		idx.RegisterParser(indexer.NewPythonParser())
+
+		projIdx, err := idx.IndexProject(absPath)
+		if err != nil {
+			log.Fatalf("Failed to load index: %v", err)
+		}
+		vuln.MapSymbols(findings, indexer.NewSearchEngine(projIdx))
+	}
+
+	if *jsonOutput {
+		if findings == nil {
+			findings = []vuln.Finding{}
+		}
+		data, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(findings) == 0 {
+		fmt.Println("No vulnerabilities found")
+		return
+	}
+	fmt.Print(vuln.Format(findings, 0))
+	fmt.Printf("\nTotal: %d findings\n", len(findings))
+}
+
+// enableVulnerabilities attaches a vulnerability scanner to the agent when
+// the -vulns flag is set or the task is about fixing a vulnerability.
+func enableVulnerabilities(codingAgent *agent.CodingAgent, projectPath, task string, flagSet bool) {
+	if !flagSet && !vuln.IsVulnerabilityTask(task) {
+		return
+	}
+	scanner := vuln.NewScanner(vuln.ScannerConfig{ProjectRoot: projectPath})
+	if tools := scanner.Tools(); len(tools) > 0 {
+		fmt.Printf("Vulnerability scanners: %s\n", strings.Join(tools, ", "))
+	} else {
+		fmt.Println("Vulnerability scanners: none available")
+		return
+	}
+	codingAgent.SetVulnerabilities(scanner)
+}
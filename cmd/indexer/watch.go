@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/metrics"
+	"github.com/yourorg/agent/internal/summary"
+	"github.com/yourorg/agent/internal/watch"
+)
+
+// cmdWatch keeps the structural and RAG indexes up to date as files change,
+// until interrupted.
+//
+// The watch loop lives here rather than behind an Indexer.Watch method:
+// internal/indexer has no watcher of its own yet, and moving this loop there
+// is left as a follow-up so that library users can watch without the CLI.
+func cmdWatch() {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	projectPath := fs.String("path", ".", "Path to the project")
+	noRAG := fs.Bool("no-rag", false, "Only refresh the structural index")
+	debounce := fs.Duration("debounce", watch.DefaultDebounce, "How long changes must settle before the indexes are refreshed")
+	metricsAddr := fs.String("metrics-addr", "", "Address to serve Prometheus /metrics on (disabled if empty)")
+	fs.Parse(os.Args[2:])
+
+	metrics.Serve(*metricsAddr)
+
+	absPath, _ := filepath.Abs(*projectPath)
+	loadConfig(absPath)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w, err := watch.New(watch.Config{Root: absPath, Debounce: *debounce})
+	if err != nil {
+		log.Fatalf("Failed to watch %s: %v", absPath, err)
+	}
+	defer w.Close()
+
+	// One indexer for the whole session: its cache keeps the parsed files
+	// in memory, so each refresh re-parses only what changed.
+	idx := indexer.NewIndexer()
+	idx.RegisterParser(indexer.NewGoParser())
+	idx.RegisterParser(indexer.NewPythonParser())
+	idx.SetCacheEnabled(true)
+	refreshStructure := func() {
+		start := time.Now()
+		projIdx, err := idx.IndexProject(absPath)
+		if err != nil {
+			log.Printf("Warning: structural refresh failed: %v", err)
+			return
+		}
+		metrics.ObserveSince(metrics.IndexDuration, start, "structural")
+		fmt.Printf("Structural index: %d modules, %d symbols\n", len(projIdx.Modules), len(projIdx.SymbolTable))
+		if _, err := summary.Ensure(absPath, projIdx); err != nil {
+			log.Printf("Warning: failed to refresh project summary: %v", err)
+		}
+	}
+
+	fmt.Printf("\n=== Watching %s ===\n", absPath)
+	refreshStructure()
+	fmt.Printf("Waiting for changes (Ctrl-C to stop)...\n")
+
+	err = w.Run(ctx, func(changed []string) {
+		// Share the lock with the git hooks' background refresh.
+		unlock, err := acquireRefreshLock(absPath)
+		if err != nil {
+			log.Printf("Warning: refresh skipped: %v", err)
+			return
+		}
+		defer unlock()
+
+		start := time.Now()
+		fmt.Printf("\n[%s] %d file(s) changed\n", start.Format(time.RFC3339), len(changed))
+		refreshStructure()
+		if !*noRAG {
+			refreshRAGFiles(absPath, changed)
+		}
+		fmt.Printf("Done in %s\n", time.Since(start).Round(time.Millisecond))
+	})
+	if err != nil {
+		log.Fatalf("Watch failed: %v", err)
+	}
+}
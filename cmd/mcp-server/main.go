@@ -4,17 +4,34 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/cache"
+	"github.com/yourorg/agent/internal/callgraph"
+	"github.com/yourorg/agent/internal/config"
+	"github.com/yourorg/agent/internal/configkeys"
+	"github.com/yourorg/agent/internal/docs"
+	"github.com/yourorg/agent/internal/fuzzy"
 	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/localonly"
+	"github.com/yourorg/agent/internal/metrics"
+	"github.com/yourorg/agent/internal/models"
 	"github.com/yourorg/agent/internal/rag"
+	"github.com/yourorg/agent/internal/refs"
 	"github.com/yourorg/agent/internal/retrieval"
+	"github.com/yourorg/agent/internal/routes"
+	"github.com/yourorg/agent/internal/searchfilter"
+	"github.com/yourorg/agent/internal/sqlschema"
+	"github.com/yourorg/agent/internal/symbols"
+	"github.com/yourorg/agent/internal/tracing"
+	"github.com/yourorg/agent/internal/workspace"
 )
 
 // MCP Server for Code Indexer
@@ -22,23 +39,41 @@ import (
 
 type MCPServer struct {
 	indexer       *indexer.Indexer
-	cache         map[string]*indexer.ProjectIndex
-	ragIndexers   map[string]*rag.RAGIndexer
+	cache         *cache.LRU[string, *indexer.ProjectIndex]
+	ragIndexers   *cache.LRU[string, *ragEntry]
 	queryAnalyzer *retrieval.QueryAnalyzer
-	useHybrid     bool // Enable hybrid search
+	useHybrid     bool              // Enable hybrid search
+	chunking      rag.ChunkerConfig // default chunk sizes; rag.chunking in a project's config overrides them
+	sessions      *cache.LRU[string, *agentSession]
 }
 
-func NewMCPServer() *MCPServer {
+// defaultMaxProjects bounds how many projects keep a warm index in memory.
+const defaultMaxProjects = 8
+
+func NewMCPServer(maxProjects, maxSessions int) *MCPServer {
 	idx := indexer.NewIndexer()
 	idx.RegisterParser(indexer.NewGoParser())
 	idx.RegisterParser(indexer.NewPythonParser())
 
+	if maxProjects <= 0 {
+		maxProjects = defaultMaxProjects
+	}
+	if maxSessions <= 0 {
+		maxSessions = defaultMaxSessions
+	}
+
 	return &MCPServer{
-		indexer:       idx,
-		cache:         make(map[string]*indexer.ProjectIndex),
-		ragIndexers:   make(map[string]*rag.RAGIndexer),
+		indexer: idx,
+		cache:   cache.NewLRU[string, *indexer.ProjectIndex](maxProjects, nil),
+		ragIndexers: cache.NewLRU(maxProjects, func(path string, e *ragEntry) {
+			log.Printf("Evicting RAG indexer for %s", path)
+			e.evict()
+		}),
 		queryAnalyzer: retrieval.NewQueryAnalyzer(),
 		useHybrid:     true, // Enable hybrid search by default
+		sessions: cache.NewLRU(maxSessions, func(id string, _ *agentSession) {
+			log.Printf("Evicting session %s", id)
+		}),
 	}
 }
 
@@ -124,6 +159,27 @@ func (s *MCPServer) GetTools() []Tool {
 						"description": "Maximum number of results to return (default: 10)",
 						"default":     10,
 					},
+					"model": map[string]interface{}{
+						"type":        "string",
+						"description": "Model the context is for (e.g. claude-sonnet-4-5, gpt-4o); limits the result to half its context window instead of 50k tokens",
+					},
+					"roots": map[string]interface{}{
+						"type":        "string",
+						"description": "Comma-separated workspace roots to fetch from, for monorepos with workspace.roots in .indexer.json (default: all roots)",
+					},
+					"rerank": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Have a small LLM reorder the top semantic matches before merging them (default: false)",
+						"default":     false,
+					},
+					"rerank_provider": map[string]interface{}{
+						"type":        "string",
+						"description": "LLM provider for reranking: claude, openai, gemini, ollama or llamacpp (default: claude)",
+					},
+					"rerank_model": map[string]interface{}{
+						"type":        "string",
+						"description": "Model for reranking (default: the provider's small model, e.g. claude-haiku-4-5)",
+					},
 				},
 				"required": []string{"project_path", "task"},
 			},
@@ -140,7 +196,19 @@ func (s *MCPServer) GetTools() []Tool {
 					},
 					"query": map[string]interface{}{
 						"type":        "string",
-						"description": "Symbol name to search for",
+						"description": "Symbol name to search for; close misspellings and abbreviations match too",
+					},
+					"kind": map[string]interface{}{
+						"type":        "string",
+						"description": "Comma-separated symbol kinds to keep: func, method, type, struct, interface, class, var, const",
+					},
+					"language": map[string]interface{}{
+						"type":        "string",
+						"description": "Comma-separated languages to keep, e.g. 'go,python'",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Comma-separated paths to keep, relative to the project: a directory, 'dir/...' for a subtree, or a glob",
 					},
 				},
 				"required": []string{"project_path", "query"},
@@ -167,7 +235,7 @@ func (s *MCPServer) GetTools() []Tool {
 		},
 		{
 			Name:        "get_call_graph",
-			Description: "Get the call graph for a function (who calls it and what it calls)",
+			Description: "Get the call graph for a function (who calls it and what it calls), optionally followed transitively to show the full blast radius of a change",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -185,10 +253,66 @@ func (s *MCPServer) GetTools() []Tool {
 						"enum":        []string{"callers", "callees", "both"},
 						"default":     "both",
 					},
+					"depth": map[string]interface{}{
+						"type":        "integer",
+						"description": "Levels of callers/callees to follow (default: 1, immediate neighbors only)",
+						"default":     1,
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"description": "Output format: 'tree', 'edges' (caller -> callee list) or 'json' (default: 'tree')",
+						"enum":        []string{"tree", "edges", "json"},
+						"default":     "tree",
+					},
 				},
 				"required": []string{"project_path", "function_name"},
 			},
 		},
+		{
+			Name:        "find_references",
+			Description: "Find every place a symbol is used (call sites, type references) with file:line:column and the source line",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"project_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Absolute path to the project directory",
+					},
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the function, method or type, optionally qualified (e.g. 'Type.Method')",
+					},
+					"max_results": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of references to return (default: 100)",
+						"default":     100,
+					},
+				},
+				"required": []string{"project_path", "symbol"},
+			},
+		},
+		{
+			Name:        "find_routes",
+			Description: "List the HTTP routes of a project (Gin, Echo, chi, gorilla/mux, net/http, Flask, FastAPI, Django) with the handler and file:line serving each, optionally only those serving a URL path",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"project_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Absolute path to the project directory",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "URL path to look up, e.g. '/patients' or '/patients/{id}'; also matches routes under it and under a prefix like /api/v1",
+					},
+					"method": map[string]interface{}{
+						"type":        "string",
+						"description": "HTTP method to keep, e.g. GET",
+					},
+				},
+				"required": []string{"project_path"},
+			},
+		},
 		{
 			Name:        "run_agent_task",
 			Description: "Plan and execute a coding task (same behavior as `indexer agent run`). Returns checklist and execution log.",
@@ -205,7 +329,7 @@ func (s *MCPServer) GetTools() []Tool {
 					},
 					"provider": map[string]interface{}{
 						"type":        "string",
-						"description": "LLM provider (claude, gemini, openai, ollama)",
+						"description": "LLM provider (claude, gemini, openai, ollama, llamacpp)",
 						"default":     "claude",
 					},
 					"model": map[string]interface{}{
@@ -218,7 +342,7 @@ func (s *MCPServer) GetTools() []Tool {
 					},
 					"dry_run": map[string]interface{}{
 						"type":        "boolean",
-						"description": "If true, don't modify files or run commands",
+						"description": "If true, don't modify files or run commands; the result includes a unified diff of the changes",
 						"default":     true,
 					},
 					"max_iterations": map[string]interface{}{
@@ -231,10 +355,91 @@ func (s *MCPServer) GetTools() []Tool {
 						"description": "Max context results per task",
 						"default":     8,
 					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "Wall-clock limit for the whole run; unfinished tasks are left pending (0 = none)",
+						"default":     0,
+					},
 				},
 				"required": []string{"project_path", "task"},
 			},
 		},
+		{
+			Name:        "start_session",
+			Description: "Start a multi-turn conversation with the coding agent. The agent, its conversation history and a warm project index stay alive across continue_session calls until end_session.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"project_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Absolute path to the project directory",
+					},
+					"provider": map[string]interface{}{
+						"type":        "string",
+						"description": "LLM provider (claude, gemini, openai, ollama, llamacpp)",
+						"default":     "claude",
+					},
+					"model": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional model name for provider",
+					},
+					"api_key": map[string]interface{}{
+						"type":        "string",
+						"description": "API key (falls back to environment variable)",
+					},
+					"include_context": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Retrieve project context for each message (default: true)",
+						"default":     true,
+					},
+					"resume_from": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional session file (.json or .md, as written by end_session or `indexer agent chat -session`) to continue",
+					},
+				},
+				"required": []string{"project_path"},
+			},
+		},
+		{
+			Name:        "continue_session",
+			Description: "Send the next message in a session started with start_session and get the agent's reply",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"session_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Session ID returned by start_session",
+					},
+					"message": map[string]interface{}{
+						"type":        "string",
+						"description": "Message to send",
+					},
+					"include_context": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Override the session's include_context for this message",
+					},
+				},
+				"required": []string{"session_id", "message"},
+			},
+		},
+		{
+			Name:        "end_session",
+			Description: "End a session and release its agent, optionally saving the transcript",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"session_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Session ID returned by start_session",
+					},
+					"export_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional file to save the transcript to (.md for Markdown, otherwise JSON)",
+					},
+				},
+				"required": []string{"session_id"},
+			},
+		},
 	}
 }
 
@@ -249,62 +454,153 @@ func (s *MCPServer) ExecuteTool(toolName string, arguments map[string]interface{
 		return s.getProjectStructure(arguments)
 	case "get_call_graph":
 		return s.getCallGraph(arguments)
+	case "find_references":
+		return s.findReferences(arguments)
+	case "find_routes":
+		return s.findRoutes(arguments)
 	case "run_agent_task":
 		return s.runAgentTask(arguments)
+	case "start_session":
+		return s.startSession(arguments)
+	case "continue_session":
+		return s.continueSession(arguments)
+	case "end_session":
+		return s.endSession(arguments)
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", toolName)
 	}
 }
 
 func (s *MCPServer) getProjectIndex(projectPath string) (*indexer.ProjectIndex, error) {
-	if idx, ok := s.cache[projectPath]; ok {
+	if idx, ok := s.cache.Get(projectPath); ok {
+		metrics.CacheHit("project_index", true)
 		return idx, nil
 	}
+	metrics.CacheHit("project_index", false)
 
+	start := time.Now()
 	idx, err := s.indexer.IndexProject(projectPath)
 	if err != nil {
 		return nil, err
 	}
+	metrics.ObserveSince(metrics.IndexDuration, start, "structural")
 
-	s.cache[projectPath] = idx
+	s.cache.Add(projectPath, idx)
 	return idx, nil
 }
 
-func (s *MCPServer) getOrCreateRAGIndexer(projectPath string) (*rag.RAGIndexer, error) {
-	if idx, ok := s.ragIndexers[projectPath]; ok {
-		return idx, nil
+// getOrCreateRAGIndexer returns the project's RAG indexer and a release
+// func the caller must call when done with it; the indexer stays open
+// until then even if it is evicted meanwhile.
+func (s *MCPServer) getOrCreateRAGIndexer(projectPath string) (*rag.RAGIndexer, func(), error) {
+	for {
+		e, hit, err := s.ragIndexers.GetOrAdd(projectPath, func() (*ragEntry, error) {
+			idx, err := s.newRAGIndexer(projectPath)
+			if err != nil {
+				return nil, err
+			}
+			return &ragEntry{path: projectPath, indexer: idx}, nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		metrics.CacheHit("rag_indexer", hit)
+		if e.acquire() {
+			return e.indexer, e.release, nil
+		}
+		// Evicted between lookup and use; look it up again.
 	}
+}
 
-	embedder := rag.NewOllamaEmbedder("nomic-embed-text")
-	dbPath := filepath.Join(projectPath, ".index", "rag_vectors.db")
-	store, err := rag.NewSQLiteVectorStore(dbPath, embedder.Dimension())
+// newRAGIndexer opens a RAG indexer for the project with its configured
+// embedder, store and options.
+func (s *MCPServer) newRAGIndexer(projectPath string) (*rag.RAGIndexer, error) {
+	cfg, err := config.Load(projectPath)
+	if err != nil {
+		log.Printf("Ignoring invalid project config: %v", err)
+	}
+	var embedderCfg rag.EmbedderConfig
+	if cfg != nil {
+		if err := cfg.CheckLocalEmbedder(); err != nil {
+			return nil, err
+		}
+		embedderCfg = cfg.RAG.Embedder
+	}
+	switch embedderCfg.Provider {
+	case "openai":
+		embedderCfg.APIKey = os.Getenv("OPENAI_API_KEY")
+	case "voyage":
+		embedderCfg.APIKey = os.Getenv("VOYAGE_API_KEY")
+	case "cohere":
+		embedderCfg.APIKey = os.Getenv("COHERE_API_KEY")
+	}
+	embedder, err := rag.NewEmbedder(embedderCfg)
+	if err != nil {
+		return nil, fmt.Errorf("create embedder: %w", err)
+	}
+	var storeCfg rag.StoreConfig
+	if cfg != nil {
+		storeCfg = cfg.RAG.Store
+	}
+	store, err := rag.NewVectorStore(storeCfg, projectPath, embedder.Dimension())
 	if err != nil {
-		return nil, fmt.Errorf("create sqlite vector store: %w", err)
+		return nil, fmt.Errorf("open vector store: %w", err)
 	}
 	idx := rag.NewRAGIndexer(embedder, store)
-	s.ragIndexers[projectPath] = idx
+	if cfg != nil {
+		idx.SetEmbedOptions(rag.EmbedOptions{
+			BatchSize:         cfg.RAG.BatchSize,
+			Concurrency:       cfg.RAG.Concurrency,
+			RequestsPerMinute: cfg.RAG.RequestsPerMinute,
+		})
+		idx.SetPrivacyPolicy(projectPath, cfg.RAG.Privacy)
+	}
+	if err := idx.SetChunkerConfig(s.chunkerConfig(cfg)); err != nil {
+		log.Printf("Ignoring chunk sizes: %v", err)
+	}
 	return idx, nil
 }
 
+// chunkerConfig returns the server's chunk sizes with those set in the
+// project's rag.chunking applied; cfg may be nil.
+func (s *MCPServer) chunkerConfig(cfg *config.Config) rag.ChunkerConfig {
+	chunking := s.chunking
+	if cfg == nil {
+		return chunking
+	}
+	if cfg.RAG.Chunking.MaxChunkSize > 0 {
+		chunking.MaxChunkSize = cfg.RAG.Chunking.MaxChunkSize
+	}
+	if cfg.RAG.Chunking.WindowLines > 0 {
+		chunking.WindowLines = cfg.RAG.Chunking.WindowLines
+	}
+	if cfg.RAG.Chunking.WindowOverlap > 0 {
+		chunking.WindowOverlap = cfg.RAG.Chunking.WindowOverlap
+	}
+	return chunking
+}
+
 // ensureRAGIndexed ensures RAG index exists for project (auto-index if needed)
-func (s *MCPServer) ensureRAGIndexed(projectPath string) error {
-	ragIndexer, err := s.getOrCreateRAGIndexer(projectPath)
+// and returns its indexer with the release func from getOrCreateRAGIndexer.
+func (s *MCPServer) ensureRAGIndexed(projectPath string) (*rag.RAGIndexer, func(), error) {
+	ragIndexer, release, err := s.getOrCreateRAGIndexer(projectPath)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	if ragIndexer.Stats().TotalChunks > 0 {
-		return nil
+		return ragIndexer, release, nil
 	}
 
 	// Auto-index the project
 	log.Printf("Auto-indexing project for RAG: %s", projectPath)
 	if err := ragIndexer.IndexProject(projectPath); err != nil {
-		return fmt.Errorf("failed to RAG index project: %w", err)
+		release()
+		return nil, nil, fmt.Errorf("failed to RAG index project: %w", err)
 	}
 
 	log.Printf("RAG indexing complete: %d chunks", ragIndexer.Stats().TotalChunks)
-	return nil
+	return ragIndexer, release, nil
 }
 
 func (s *MCPServer) getProjectContext(args map[string]interface{}) (*CallToolResult, error) {
@@ -315,8 +611,17 @@ func (s *MCPServer) getProjectContext(args map[string]interface{}) (*CallToolRes
 		maxResults = int(mr)
 	}
 
+	tokenBudget := defaultContextTokens
+	if model := getStringArg(args, "model", ""); model != "" {
+		tokenBudget = models.Lookup("", model).PromptBudget() / 2
+	}
+
 	log.Printf("getProjectContext called: project=%s, task=%s, useHybrid=%v", projectPath, task, s.useHybrid)
 
+	if cfg, err := config.Load(projectPath); err == nil && len(cfg.Workspace.Roots) > 0 {
+		return s.getWorkspaceContext(projectPath, cfg.Workspace, args, task, maxResults, tokenBudget)
+	}
+
 	// Get structural index
 	idx, err := s.getProjectIndex(projectPath)
 	if err != nil {
@@ -330,7 +635,7 @@ func (s *MCPServer) getProjectContext(args map[string]interface{}) (*CallToolRes
 
 	if s.useHybrid {
 		// Always try hybrid search - run both and merge
-		if err := s.ensureRAGIndexed(projectPath); err != nil {
+		if ragIndexer, release, err := s.ensureRAGIndexed(projectPath); err != nil {
 			// RAG not available, fall back to structural only
 			log.Printf("RAG not available, using structural only: %v", err)
 			fetcher := indexer.NewContextFetcher(idx)
@@ -338,9 +643,15 @@ func (s *MCPServer) getProjectContext(args map[string]interface{}) (*CallToolRes
 			formatted = indexer.FormatContext(ctx)
 		} else {
 			// Hybrid search: run both and merge
-			ragIndexer, _ := s.getOrCreateRAGIndexer(projectPath)
+			defer release()
+			var reranker retrieval.Reranker
+			if getBoolArg(args, "rerank", false) {
+				if reranker, err = newReranker(projectPath, args); err != nil {
+					log.Printf("Reranking disabled: %v", err)
+				}
+			}
 			log.Printf("Hybrid context search: project=%s query=\"%s\"", projectPath, task)
-			formatted = s.hybridSearch(idx, ragIndexer, task, maxResults)
+			formatted = s.hybridSearch(idx, ragIndexer, reranker, task, maxResults, tokenBudget)
 		}
 	} else {
 		// Hybrid disabled, use structural only
@@ -348,27 +659,283 @@ func (s *MCPServer) getProjectContext(args map[string]interface{}) (*CallToolRes
 		ctx := fetcher.FetchContext(task, maxResults)
 		formatted = indexer.FormatContext(ctx)
 	}
+	formatted += relevantSymbols(projectPath, nil, task) + relevantRoutes(projectPath, nil, task) + relevantDocs(projectPath, nil, task) +
+		relevantConfig(projectPath, nil, task) + relevantSchema(projectPath, nil, task)
 
 	return &CallToolResult{
-		Content: []ContentBlock{{Type: "text", Text: formatted}},
+		Content: []ContentBlock{{Type: "text", Text: models.Truncate(formatted, tokenBudget)}},
 	}, nil
 }
 
-// hybridSearch combines structural and semantic search
-func (s *MCPServer) hybridSearch(idx *indexer.ProjectIndex, ragIndexer *rag.RAGIndexer, query string, maxResults int) string {
+// getWorkspaceContext fetches context from the selected roots of a
+// monorepo workspace, each indexed with its own languages. Results are
+// structural only: the RAG index spans the whole project.
+func (s *MCPServer) getWorkspaceContext(projectPath string, ws workspace.Config, args map[string]interface{}, task string, maxResults, tokenBudget int) (*CallToolResult, error) {
+	roots, err := ws.Resolve(projectPath)
+	if err == nil {
+		roots, err = workspace.Select(roots, getStringArg(args, "roots", ""))
+	}
+	if err != nil {
+		return &CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Error: %v", err)}},
+			IsError: true,
+		}, nil
+	}
+
+	contexts, err := workspace.FetchContext(roots, task, maxResults, s.getRootIndex)
+	if err != nil {
+		return &CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Error indexing project: %v", err)}},
+			IsError: true,
+		}, nil
+	}
+	formatted := workspace.Format(contexts) + relevantSymbols(projectPath, roots, task) + relevantRoutes(projectPath, roots, task) +
+		relevantDocs(projectPath, roots, task) + relevantConfig(projectPath, roots, task) + relevantSchema(projectPath, roots, task)
+	return &CallToolResult{
+		Content: []ContentBlock{{Type: "text", Text: models.Truncate(formatted, tokenBudget)}},
+	}, nil
+}
+
+// loadSymbols indexes the languages the structural indexer has no parser
+// for. Errors are logged and give an empty index.
+func loadSymbols(projectPath string) *symbols.Index {
+	idx, err := symbols.Load(projectPath)
+	if err != nil {
+		log.Printf("Failed to index other languages: %v", err)
+		return new(symbols.Index)
+	}
+	return idx
+}
+
+func symbolResults(syms []symbols.Symbol) []indexer.SearchResult {
+	results := make([]indexer.SearchResult, len(syms))
+	for i, s := range syms {
+		results[i] = indexer.SearchResult{Name: s.Name, Type: s.Kind, FilePath: s.File, Line: s.Line, Signature: s.Signature, Doc: s.Doc}
+	}
+	return results
+}
+
+// contextSymbols is the number of symbols of other languages
+// get_project_context adds.
+const contextSymbols = 10
+
+// relevantSymbols renders the symbols of other languages task names, from
+// the given workspace roots or the whole project when roots is nil.
+func relevantSymbols(projectPath string, roots []workspace.Root, task string) string {
+	scoped := loadSymbols(projectPath).SymbolsIn(func(file string) bool {
+		return roots == nil || workspace.Contains(roots, file)
+	})
+	results := symbols.Relevant(scoped, task, contextSymbols)
+	if len(results) == 0 {
+		return ""
+	}
+	return "\n\n## Relevant symbols\n\n" + symbols.Format(results)
+}
+
+// relevantRoutes renders the routes serving the URL paths task mentions,
+// from the given workspace roots or the whole project when roots is nil.
+func relevantRoutes(projectPath string, roots []workspace.Root, task string) string {
+	all, err := routes.Load(projectPath)
+	if err != nil {
+		log.Printf("Failed to extract routes: %v", err)
+		return ""
+	}
+	var found []routes.Route
+	for _, r := range routes.ForTask(all, task) {
+		if roots == nil || workspace.Contains(roots, r.File) {
+			found = append(found, r)
+		}
+	}
+	if len(found) == 0 {
+		return ""
+	}
+	return "\n\n## Relevant routes\n\n" + routes.Format(found)
+}
+
+// contextDocs is the number of documentation sections get_project_context
+// adds.
+const contextDocs = 5
+
+// relevantDocs renders the documentation sections that best match task,
+// from the given workspace roots or the whole project when roots is nil.
+func relevantDocs(projectPath string, roots []workspace.Root, task string) string {
+	sections, err := docs.Load(projectPath)
+	if err != nil {
+		log.Printf("Failed to read documentation: %v", err)
+		return ""
+	}
+	if roots != nil {
+		scoped := sections[:0]
+		for _, s := range sections {
+			if workspace.Contains(roots, s.File) {
+				scoped = append(scoped, s)
+			}
+		}
+		sections = scoped
+	}
+	results := docs.Search(sections, task, contextDocs)
+	if len(results) == 0 {
+		return ""
+	}
+	return "\n\n## Relevant documentation\n\n" + docs.Format(results, 3)
+}
+
+// contextKeys is the number of configuration keys get_project_context
+// adds.
+const contextKeys = 10
+
+// relevantConfig renders the configuration keys task names, from the
+// given workspace roots or the whole project when roots is nil.
+func relevantConfig(projectPath string, roots []workspace.Root, task string) string {
+	keys, err := configkeys.Load(projectPath)
+	if err != nil {
+		log.Printf("Failed to read config files: %v", err)
+		return ""
+	}
+	if roots != nil {
+		scoped := keys[:0]
+		for _, k := range keys {
+			if workspace.Contains(roots, k.File) {
+				scoped = append(scoped, k)
+			}
+		}
+		keys = scoped
+	}
+	results := configkeys.Relevant(keys, task, contextKeys)
+	if len(results) == 0 {
+		return ""
+	}
+	return "\n\n## Relevant configuration\n\n" + configkeys.Format(results)
+}
+
+// relevantSchema renders the tables task names, with their columns and
+// migrations, from the given workspace roots or the whole project when
+// roots is nil.
+func relevantSchema(projectPath string, roots []workspace.Root, task string) string {
+	objs, err := sqlschema.Load(projectPath)
+	if err != nil {
+		log.Printf("Failed to read SQL files: %v", err)
+		return ""
+	}
+	if roots != nil {
+		scoped := objs[:0]
+		for _, o := range objs {
+			if workspace.Contains(roots, o.File) {
+				scoped = append(scoped, o)
+			}
+		}
+		objs = scoped
+	}
+	tables := sqlschema.ForTask(sqlschema.Tables(objs), task)
+	if len(tables) == 0 {
+		return ""
+	}
+	out := "\n\n## Relevant schema\n\n" + sqlschema.Format(tables)
+	if latest, ok := sqlschema.Latest(objs); ok {
+		out += fmt.Sprintf("latest migration: %s (%s)\n", latest.Migration, latest.File)
+	}
+	return out
+}
+
+// getRootIndex is getProjectIndex for a workspace root, whose indexer
+// only runs the root's parsers.
+func (s *MCPServer) getRootIndex(root workspace.Root) (*indexer.ProjectIndex, error) {
+	if idx, ok := s.cache.Get(root.Dir); ok {
+		metrics.CacheHit("project_index", true)
+		return idx, nil
+	}
+	metrics.CacheHit("project_index", false)
+
+	start := time.Now()
+	idx, err := root.NewIndexer().IndexProject(root.Dir)
+	if err != nil {
+		return nil, err
+	}
+	metrics.ObserveSince(metrics.IndexDuration, start, "structural")
+
+	s.cache.Add(root.Dir, idx)
+	return idx, nil
+}
+
+// defaultContextTokens bounds get_project_context results when the caller
+// does not name its model.
+const defaultContextTokens = 50000
+
+// rerankModels are the small models reranking uses by provider when no
+// model is named. Other providers use their default model.
+var rerankModels = map[string]string{
+	"claude": "claude-haiku-4-5",
+	"openai": "gpt-4o-mini",
+	"gemini": "gemini-2.0-flash",
+}
+
+// rerankTimeout bounds the reranking call; on timeout the results keep
+// their search order.
+const rerankTimeout = 30 * time.Second
+
+// newReranker builds the LLM reranker a get_project_context call asks for,
+// applying the project's privacy policy to what it sends.
+func newReranker(projectPath string, args map[string]interface{}) (retrieval.Reranker, error) {
+	provider := getStringArg(args, "rerank_provider", "claude")
+	model := getStringArg(args, "rerank_model", rerankModels[provider])
+
+	var privacy *rag.PrivacyFilter
+	if cfg, err := config.Load(projectPath); err == nil {
+		privacy = rag.NewPrivacyFilter(projectPath, cfg.RAG.Privacy)
+	}
+
+	var apiKey string
+	switch provider {
+	case "claude":
+		apiKey = os.Getenv("CLAUDE_API_KEY")
+	case "gemini":
+		apiKey = os.Getenv("GEMINI_API_KEY")
+	case "openai":
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	client, err := agent.NewLLMClient(agent.LLMConfig{Provider: provider, Model: model, APIKey: apiKey})
+	if err != nil {
+		return nil, err
+	}
+	return retrieval.NewLLMReranker(client, privacy, retrieval.DefaultRerankCandidates), nil
+}
+
+// hybridSearch combines structural and semantic search, reranking the
+// semantic results first when reranker is not nil.
+func (s *MCPServer) hybridSearch(idx *indexer.ProjectIndex, ragIndexer *rag.RAGIndexer, reranker retrieval.Reranker, query string, maxResults, tokenBudget int) string {
+	defer metrics.ObserveSince(metrics.SearchLatency, time.Now(), "hybrid")
+
 	// Get structural results
 	fetcher := indexer.NewContextFetcher(idx)
 	structuralCtx := fetcher.FetchContext(query, maxResults)
 	log.Printf("Hybrid structural results: %d modules", len(structuralCtx.RelevantModules))
 
-	// Get semantic results from RAG
-	ragResults, err := ragIndexer.Search(query, maxResults)
+	// Get semantic results from RAG, with more candidates to rerank
+	limit := maxResults
+	if reranker != nil {
+		limit = max(limit, retrieval.DefaultRerankCandidates)
+	}
+	ragResults, err := ragIndexer.Search(query, limit, rag.SearchFilter{})
 	if err != nil {
 		log.Printf("RAG search failed: %v", err)
 		return indexer.FormatContext(structuralCtx)
 	}
 	log.Printf("Hybrid RAG results: %d chunks", len(ragResults))
 
+	if reranker != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), rerankTimeout)
+		reranked, err := reranker.Rerank(ctx, query, ragResults)
+		cancel()
+		if err != nil {
+			log.Printf("Reranking failed, keeping search order: %v", err)
+		} else {
+			ragResults = reranked
+		}
+	}
+	if len(ragResults) > maxResults {
+		ragResults = ragResults[:maxResults]
+	}
+
 	// Extract file paths from structural context
 	structuralFiles := make([]string, 0)
 	for _, mod := range structuralCtx.RelevantModules {
@@ -376,7 +943,7 @@ func (s *MCPServer) hybridSearch(idx *indexer.ProjectIndex, ragIndexer *rag.RAGI
 	}
 
 	// Merge results
-	merger := retrieval.NewResultMerger(50000) // 50k token budget
+	merger := retrieval.NewResultMerger(tokenBudget)
 	hybridResult := merger.Merge(ragResults, structuralFiles)
 
 	// Format hybrid results
@@ -419,6 +986,13 @@ func (s *MCPServer) hybridSearch(idx *indexer.ProjectIndex, ragIndexer *rag.RAGI
 func (s *MCPServer) searchCode(args map[string]interface{}) (*CallToolResult, error) {
 	projectPath := args["project_path"].(string)
 	query := args["query"].(string)
+	filter, err := searchfilter.Parse(getStringArg(args, "kind", ""), getStringArg(args, "language", ""), getStringArg(args, "path", ""))
+	if err != nil {
+		return &CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Error: %v", err)}},
+			IsError: true,
+		}, nil
+	}
 
 	idx, err := s.getProjectIndex(projectPath)
 	if err != nil {
@@ -429,18 +1003,54 @@ func (s *MCPServer) searchCode(args map[string]interface{}) (*CallToolResult, er
 	}
 
 	// Always run structural search
+	searchStart := time.Now()
 	search := indexer.NewSearchEngine(idx)
-	structuralResults := search.SearchSymbol(query)
+	other := loadSymbols(projectPath)
+	structuralResults := append(search.SearchSymbol(query), symbolResults(other.Find(query))...)
+	if len(structuralResults) == 0 {
+		// Nothing by that name: offer the closest symbols.
+		names := make([]string, 0, len(idx.SymbolTable))
+		for name := range idx.SymbolTable {
+			names = append(names, name)
+		}
+		names = append(names, other.Names()...)
+		for _, m := range fuzzy.Rank(query, names, 10) {
+			if details := search.GetSymbolDetails(m.Name); details != nil {
+				structuralResults = append(structuralResults, *details)
+			} else if found := other.Lookup(m.Name); len(found) > 0 {
+				structuralResults = append(structuralResults, symbolResults(found[:1])...)
+			}
+		}
+	}
+	if !filter.Empty() {
+		kept := structuralResults[:0]
+		for _, r := range structuralResults {
+			if filter.Match(projectPath, r.Type, r.FilePath) {
+				kept = append(kept, r)
+			}
+		}
+		structuralResults = kept
+	}
+	metrics.ObserveSince(metrics.SearchLatency, searchStart, "structural")
 
 	var text strings.Builder
 	text.WriteString(fmt.Sprintf("=== Search Results for '%s' ===\n\n", query))
 
 	if s.useHybrid {
 		// Try to add RAG results
-		if err := s.ensureRAGIndexed(projectPath); err == nil {
+		if ragIndexer, release, err := s.ensureRAGIndexed(projectPath); err == nil {
 			// RAG available, get semantic results
-			ragIndexer, _ := s.getOrCreateRAGIndexer(projectPath)
-			ragResults, err := ragIndexer.Search(query, 10)
+			defer release()
+			ragResults, err := ragIndexer.Search(query, 10, ragFilter(projectPath, filter))
+			if !filter.Empty() {
+				kept := ragResults[:0]
+				for _, r := range ragResults {
+					if filter.Match(projectPath, r.Chunk.ChunkType, r.Chunk.FilePath) {
+						kept = append(kept, r)
+					}
+				}
+				ragResults = kept
+			}
 			if err == nil && len(ragResults) > 0 {
 				// Show combined results
 				text.WriteString(fmt.Sprintf("Structural: %d results | Semantic: %d results\n\n", len(structuralResults), len(ragResults)))
@@ -463,6 +1073,9 @@ func (s *MCPServer) searchCode(args map[string]interface{}) (*CallToolResult, er
 					text.WriteString(fmt.Sprintf("  [Score: %.3f] %s:%d-%d %s\n",
 						result.Score, result.Chunk.FilePath, result.Chunk.StartLine,
 						result.Chunk.EndLine, result.Chunk.SymbolName))
+					for _, d := range result.Chunk.Duplicates {
+						text.WriteString(fmt.Sprintf("      also %s:%d-%d\n", d.FilePath, d.StartLine, d.EndLine))
+					}
 				}
 
 				return &CallToolResult{
@@ -483,6 +1096,18 @@ func (s *MCPServer) searchCode(args map[string]interface{}) (*CallToolResult, er
 	}, nil
 }
 
+// ragFilter pushes the parts of a search_code filter that the vector store
+// can apply down to it: the languages, and a single path. Kinds, which
+// stand for several chunk types, and globs, where the store's * also
+// matches "/", are checked on the results instead.
+func ragFilter(projectPath string, f searchfilter.Filter) rag.SearchFilter {
+	rf := rag.SearchFilter{Languages: f.Languages}
+	if len(f.Paths) == 1 {
+		rf.Path = rag.PathGlob(projectPath, strings.TrimSuffix(f.Paths[0], "..."))
+	}
+	return rf
+}
+
 func (s *MCPServer) getProjectStructure(args map[string]interface{}) (*CallToolResult, error) {
 	projectPath := args["project_path"].(string)
 	depth := 3
@@ -523,19 +1148,74 @@ func (s *MCPServer) getCallGraph(args map[string]interface{}) (*CallToolResult,
 	}
 
 	search := indexer.NewSearchEngine(idx)
-	results := search.SearchByCallGraph(functionName, direction)
+	other := loadSymbols(projectPath)
+	graph, err := callgraph.Build(functionName, func(name, dir string) ([]string, error) {
+		return append(search.SearchByCallGraph(name, dir), other.Neighbors(name, dir)...), nil
+	}, callgraph.Options{Direction: direction, Depth: getIntArg(args, "depth", 1)})
+	if err != nil {
+		return &CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Error: %v", err)}},
+			IsError: true,
+		}, nil
+	}
+	text, err := graph.Format(getStringArg(args, "format", "tree"))
+	if err != nil {
+		return &CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Error: %v", err)}},
+			IsError: true,
+		}, nil
+	}
 
-	text := fmt.Sprintf("Call graph for '%s' (%s):\n\n", functionName, direction)
-	for _, fn := range results {
-		text += fmt.Sprintf("  - %s\n", fn)
+	return &CallToolResult{
+		Content: []ContentBlock{{Type: "text", Text: text}},
+	}, nil
+}
+
+func (s *MCPServer) findReferences(args map[string]interface{}) (*CallToolResult, error) {
+	projectPath := args["project_path"].(string)
+	symbol := args["symbol"].(string)
+	maxResults := getIntArg(args, "max_results", 100)
+
+	found, err := refs.Find(projectPath, symbol)
+	if err != nil {
+		return &CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Error: %v", err)}},
+			IsError: true,
+		}, nil
+	}
+	text := refs.Format(symbol, found)
+	if maxResults > 0 && len(found) > maxResults {
+		text = refs.Format(symbol, found[:maxResults]) +
+			fmt.Sprintf("\n... %d more not shown (raise max_results)\n", len(found)-maxResults)
 	}
-	text += fmt.Sprintf("\nTotal: %d functions\n", len(results))
 
 	return &CallToolResult{
 		Content: []ContentBlock{{Type: "text", Text: text}},
 	}, nil
 }
 
+func (s *MCPServer) findRoutes(args map[string]interface{}) (*CallToolResult, error) {
+	projectPath := args["project_path"].(string)
+	path := getStringArg(args, "path", "")
+
+	all, err := routes.Load(projectPath)
+	if err != nil {
+		return &CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Error: %v", err)}},
+			IsError: true,
+		}, nil
+	}
+	found := routes.Match(all, path, getStringArg(args, "method", ""))
+	if len(found) == 0 {
+		return &CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("No routes found for %q (%d routes in the project)", path, len(all))}},
+		}, nil
+	}
+	return &CallToolResult{
+		Content: []ContentBlock{{Type: "text", Text: routes.Format(found)}},
+	}, nil
+}
+
 func (s *MCPServer) runAgentTask(args map[string]interface{}) (*CallToolResult, error) {
 	projectPath := args["project_path"].(string)
 	task := args["task"].(string)
@@ -545,6 +1225,21 @@ func (s *MCPServer) runAgentTask(args map[string]interface{}) (*CallToolResult,
 	dryRun := getBoolArg(args, "dry_run", true)
 	maxIterations := getIntArg(args, "max_iterations", 20)
 	maxContext := getIntArg(args, "max_context", 8)
+	timeout := time.Duration(getIntArg(args, "timeout_seconds", 0)) * time.Second
+
+	var (
+		commandEnv    agent.CommandEnv
+		paths         agent.PathPolicy
+		systemPrompts agent.SystemPrompts
+	)
+	if cfg, err := config.Load(projectPath); err != nil {
+		log.Printf("Ignoring invalid project config: %v", err)
+	} else {
+		if err := cfg.CheckLocalLLM(provider, ""); err != nil {
+			return nil, err
+		}
+		commandEnv, paths, systemPrompts = cfg.CommandEnv, cfg.Paths, cfg.SystemPrompts
+	}
 
 	if apiKey == "" {
 		switch provider {
@@ -564,6 +1259,7 @@ func (s *MCPServer) runAgentTask(args map[string]interface{}) (*CallToolResult,
 			Model:    model,
 			APIKey:   apiKey,
 		},
+		SystemPrompts: systemPrompts,
 	}
 
 	codingAgent, err := agent.NewCodingAgent(agentConfig)
@@ -575,6 +1271,9 @@ func (s *MCPServer) runAgentTask(args map[string]interface{}) (*CallToolResult,
 		DryRun:            dryRun,
 		MaxIterations:     maxIterations,
 		MaxContextResults: maxContext,
+		Timeout:           timeout,
+		CommandEnv:        commandEnv,
+		Paths:             paths,
 	})
 	if err != nil {
 		return nil, err
@@ -582,7 +1281,13 @@ func (s *MCPServer) runAgentTask(args map[string]interface{}) (*CallToolResult,
 
 	tm := agent.NewTaskManager()
 	checklist := tm.FormatAsChecklist(runResult.Plan)
-	execSummary := formatExecutionLog(runResult.Executions)
+	execSummary := tm.FormatExecutionLog(runResult.Executions)
+	if dryRun {
+		execSummary += "\n" + tm.FormatDiffs(runResult.Diffs)
+	}
+	if runResult.TimedOut {
+		execSummary += fmt.Sprintf("\nRun timed out after %s; unfinished tasks were left pending.\n", timeout)
+	}
 
 	return &CallToolResult{
 		Content: []ContentBlock{
@@ -621,40 +1326,49 @@ func getIntArg(args map[string]interface{}, key string, def int) int {
 	return def
 }
 
-func formatExecutionLog(exec []agent.TaskExecution) string {
-	var b strings.Builder
-	b.WriteString("Execution log:\n")
-	for _, e := range exec {
-		status := "pending"
-		switch {
-		case e.Completed:
-			status = "done"
-		case e.Failed:
-			status = "failed"
-		}
-		b.WriteString(fmt.Sprintf("- %s [%s]\n", e.Task.Description, status))
-		for i, act := range e.Actions {
-			res := e.Results[i]
-			out := strings.TrimSpace(res.Output)
-			if len(out) > 160 {
-				out = out[:160] + "..."
-			}
-			b.WriteString(fmt.Sprintf("  • %s %s -> %t\n", act.Type, act.Path, res.Success))
-			if out != "" {
-				b.WriteString("    " + out + "\n")
-			}
-			if res.Error != "" {
-				b.WriteString("    error: " + res.Error + "\n")
-			}
-		}
-		if e.FailureMsg != "" {
-			b.WriteString("  failure: " + e.FailureMsg + "\n")
-		}
+// applyServerConfig applies the process-wide settings of the config in dir:
+// rate limits, model limits, local-only mode, proxies and TLS. They are
+// shared by every project the server handles, so they are set once here,
+// before any client is built, rather than from each project's config. A
+// project's own local_only setting is still enforced for that project, by
+// Config.CheckLocalEmbedder and CheckLocalLLM.
+func applyServerConfig(dir string) {
+	if dir == "" {
+		return
+	}
+	cfg, err := config.Load(dir)
+	if err != nil {
+		log.Printf("Ignoring invalid server config: %v", err)
+		return
+	}
+	cfg.ApplyRateLimits()
+	cfg.ApplyModels()
+	cfg.ApplyLocalOnly()
+	if err := cfg.ApplyProxy(); err != nil {
+		log.Printf("Ignoring invalid proxy config: %v", err)
+	}
+	if err := cfg.ApplyTLS(); err != nil {
+		log.Printf("Ignoring invalid TLS config: %v", err)
 	}
-	return b.String()
 }
 
 func main() {
+	metricsAddr := flag.String("metrics-addr", os.Getenv("MCP_METRICS_ADDR"), "Address to serve Prometheus /metrics on (e.g. :9090); disabled if empty")
+	maxProjects := flag.Int("max-projects", defaultMaxProjects, "Maximum number of projects whose indexes are kept in memory")
+	maxSessions := flag.Int("max-sessions", defaultMaxSessions, "Maximum number of agent sessions kept alive; the least recently used is ended first")
+	otlpEndpoint := flag.String("otlp-endpoint", os.Getenv("MCP_OTLP_ENDPOINT"), "Export OpenTelemetry traces to this OTLP collector; disabled if empty (OTEL_EXPORTER_OTLP_ENDPOINT also works)")
+	maxChunkSize := flag.Int("max-chunk-size", 0, "Characters above which RAG chunks are split, about 4 per token (default 4000); rag.chunking in a project's .indexer.json takes precedence")
+	windowLines := flag.Int("window-lines", 0, "Lines per RAG chunk of files chunked by sliding window (default 50)")
+	windowOverlap := flag.Int("window-overlap", 0, "Lines shared by consecutive sliding windows (default 10)")
+	localOnly := flag.Bool("local-only", false, "Only allow local models (ollama, llamacpp) and services; remote API calls fail ("+localonly.EnvVar+"=1 also works)")
+	configDir := flag.String("config", "", "Directory whose .indexer.json sets the server-wide rate limits, models, local-only mode, proxy and TLS; projects' own rate limits, models, proxy and TLS are ignored")
+	flag.Parse()
+
+	if *localOnly {
+		localonly.Enable()
+	}
+	localonly.EnableFromEnv()
+
 	logFile, err := os.OpenFile("/tmp/mcp-server.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err == nil {
 		log.SetOutput(logFile)
@@ -662,8 +1376,20 @@ func main() {
 	}
 
 	log.Println("MCP Server starting...")
+	applyServerConfig(*configDir)
+	metrics.Serve(*metricsAddr)
+
+	if *otlpEndpoint != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+		shutdown, err := tracing.Setup(context.Background(), tracing.Config{Endpoint: *otlpEndpoint, ServiceName: "code-agent-mcp"})
+		if err != nil {
+			log.Printf("Tracing disabled: %v", err)
+		} else {
+			defer shutdown(context.Background())
+		}
+	}
 
-	server := NewMCPServer()
+	server := NewMCPServer(*maxProjects, *maxSessions)
+	server.chunking = rag.ChunkerConfig{MaxChunkSize: *maxChunkSize, WindowLines: *windowLines, WindowOverlap: *windowOverlap}
 
 	scanner := bufio.NewScanner(os.Stdin)
 	encoder := json.NewEncoder(os.Stdout)
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"sync"
+
+	"github.com/yourorg/agent/internal/rag"
+)
+
+// ragEntry is a cached RAG indexer shared by concurrent tool calls. It is
+// reference counted so that eviction closes the indexer only once the last
+// call using it has released it.
+type ragEntry struct {
+	path    string
+	indexer *rag.RAGIndexer
+
+	mu      sync.Mutex
+	refs    int
+	evicted bool
+}
+
+// acquire takes a reference, reporting false if the entry was already
+// evicted and must not be used.
+func (e *ragEntry) acquire() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.evicted {
+		return false
+	}
+	e.refs++
+	return true
+}
+
+// release drops a reference, closing an evicted indexer when it was the
+// last one.
+func (e *ragEntry) release() {
+	e.mu.Lock()
+	e.refs--
+	closeNow := e.evicted && e.refs == 0
+	e.mu.Unlock()
+	if closeNow {
+		e.close()
+	}
+}
+
+// evict marks the entry evicted, closing the indexer unless calls still
+// hold it; the last release closes it then.
+func (e *ragEntry) evict() {
+	e.mu.Lock()
+	e.evicted = true
+	closeNow := e.refs == 0
+	e.mu.Unlock()
+	if closeNow {
+		e.close()
+	}
+}
+
+func (e *ragEntry) close() {
+	if err := e.indexer.Close(); err != nil {
+		log.Printf("Failed to close RAG indexer for %s: %v", e.path, err)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/config"
+	"github.com/yourorg/agent/internal/indexer"
+)
+
+// defaultMaxSessions bounds how many agent sessions are kept alive; the
+// least recently used one is ended when another starts.
+const defaultMaxSessions = 16
+
+// agentSession is a conversation with a CodingAgent kept alive across tool
+// calls. The agent's indexer caches parsed files, so each turn only
+// re-parses what changed.
+type agentSession struct {
+	id             string
+	agent          *agent.CodingAgent
+	chat           *agent.ChatSession
+	includeContext bool
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func sessionError(format string, args ...interface{}) *CallToolResult {
+	return &CallToolResult{
+		Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf(format, args...)}},
+		IsError: true,
+	}
+}
+
+func (s *MCPServer) startSession(args map[string]interface{}) (*CallToolResult, error) {
+	projectPath := getStringArg(args, "project_path", "")
+	if projectPath == "" {
+		return sessionError("project_path is required"), nil
+	}
+	provider := getStringArg(args, "provider", "claude")
+	model := getStringArg(args, "model", "")
+	apiKey := getStringArg(args, "api_key", "")
+
+	var systemPrompts agent.SystemPrompts
+	if cfg, err := config.Load(projectPath); err != nil {
+		log.Printf("Ignoring invalid project config: %v", err)
+	} else {
+		if err := cfg.CheckLocalLLM(provider, ""); err != nil {
+			return sessionError("%v", err), nil
+		}
+		systemPrompts = cfg.SystemPrompts
+	}
+
+	if apiKey == "" {
+		switch provider {
+		case "claude":
+			apiKey = os.Getenv("CLAUDE_API_KEY")
+		case "gemini":
+			apiKey = os.Getenv("GEMINI_API_KEY")
+		case "openai":
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+	}
+
+	idx := indexer.NewIndexer()
+	idx.RegisterParser(indexer.NewGoParser())
+	idx.RegisterParser(indexer.NewPythonParser())
+	idx.SetCacheEnabled(true)
+
+	codingAgent, err := agent.NewCodingAgent(agent.AgentConfig{
+		ProjectPath: projectPath,
+		LLMConfig: agent.LLMConfig{
+			Provider: provider,
+			Model:    model,
+			APIKey:   apiKey,
+		},
+		SystemPrompts: systemPrompts,
+		Indexer:       idx,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	session := &agentSession{
+		agent:          codingAgent,
+		chat:           agent.NewChatSession(projectPath),
+		includeContext: getBoolArg(args, "include_context", true),
+	}
+	if path := getStringArg(args, "resume_from", ""); path != "" {
+		if session.chat, err = agent.LoadChatSession(path); err != nil {
+			return sessionError("Error loading session: %v", err), nil
+		}
+	}
+
+	// Warm the index now so the first message doesn't pay for it.
+	if session.includeContext {
+		if _, err := idx.IndexProject(projectPath); err != nil {
+			return sessionError("Error indexing project: %v", err), nil
+		}
+	}
+
+	if session.id, err = newSessionID(); err != nil {
+		return nil, err
+	}
+	s.sessions.Add(session.id, session)
+	log.Printf("Started session %s for %s", session.id, projectPath)
+
+	text := fmt.Sprintf("Session started.\nsession_id: %s\nproject: %s\nprovider: %s\n", session.id, projectPath, provider)
+	if n := len(session.chat.Turns); n > 0 {
+		text += fmt.Sprintf("Resumed with %d previous messages.\n", n)
+	}
+	text += "Send messages with continue_session and close it with end_session."
+	return &CallToolResult{
+		Content: []ContentBlock{{Type: "text", Text: text}},
+	}, nil
+}
+
+func (s *MCPServer) continueSession(args map[string]interface{}) (*CallToolResult, error) {
+	id := getStringArg(args, "session_id", "")
+	message := getStringArg(args, "message", "")
+	if strings.TrimSpace(message) == "" {
+		return sessionError("message is required"), nil
+	}
+	session, ok := s.sessions.Get(id)
+	if !ok {
+		return sessionError("Unknown or expired session %q; start a new one with start_session", id), nil
+	}
+
+	includeContext := getBoolArg(args, "include_context", session.includeContext)
+	resp, err := session.agent.ContinueChat(context.Background(), session.chat, message, includeContext)
+	if err != nil {
+		return sessionError("Chat failed: %v", err), nil
+	}
+
+	text := fmt.Sprintf("%s\n\n[Tokens: %d (session total %d) | Model: %s | Messages: %d]",
+		resp.Content, resp.TokensUsed, session.chat.TokensUsed, resp.Model, len(session.chat.Turns))
+	return &CallToolResult{
+		Content: []ContentBlock{{Type: "text", Text: text}},
+	}, nil
+}
+
+func (s *MCPServer) endSession(args map[string]interface{}) (*CallToolResult, error) {
+	id := getStringArg(args, "session_id", "")
+	session, ok := s.sessions.Peek(id)
+	if !ok {
+		return sessionError("Unknown or expired session %q", id), nil
+	}
+
+	text := fmt.Sprintf("Session %s ended: %d messages, %d tokens.", id, len(session.chat.Turns), session.chat.TokensUsed)
+	if path := getStringArg(args, "export_path", ""); path != "" {
+		if err := session.chat.Save(path); err != nil {
+			return sessionError("Error exporting session: %v", err), nil
+		}
+		text += fmt.Sprintf("\nTranscript saved to %s.", path)
+	}
+	s.sessions.Remove(id)
+	log.Printf("Ended session %s", id)
+
+	return &CallToolResult{
+		Content: []ContentBlock{{Type: "text", Text: text}},
+	}, nil
+}
@@ -13,6 +13,7 @@ const (
 	ActionRunCommand ActionType = "run_command"
 	ActionSearch     ActionType = "search"
 	ActionAskUser    ActionType = "ask_user"
+	ActionRemember   ActionType = "remember"
 	ActionComplete   ActionType = "complete"
 	ActionFail       ActionType = "fail"
 )
@@ -36,6 +37,8 @@ type Action struct {
 	Timeout  int        `json:"timeout,omitempty"` // seconds
 	Summary  string     `json:"summary,omitempty"`
 	Question string     `json:"question,omitempty"`
+	Note     string     `json:"note,omitempty"`     // remember: the convention or learning
+	Category string     `json:"category,omitempty"` // remember: e.g. "testing", "style"
 }
 
 // ActionResult captures the outcome of executing an action.
@@ -44,6 +47,7 @@ type ActionResult struct {
 	Output       string        `json:"output,omitempty"`
 	Error        string        `json:"error,omitempty"`
 	FilesChanged []string      `json:"files_changed,omitempty"`
+	Diff         string        `json:"diff,omitempty"` // the change a file action made (or would make in a dry run)
 	Duration     time.Duration `json:"duration,omitempty"`
 }
 
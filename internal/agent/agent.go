@@ -3,8 +3,18 @@ package agent
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/yourorg/agent/internal/diagnostics"
 	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/memory"
+	"github.com/yourorg/agent/internal/summary"
+	"github.com/yourorg/agent/internal/testmap"
+	"github.com/yourorg/agent/internal/tracing"
+	"github.com/yourorg/agent/internal/vuln"
 )
 
 // CodingAgent is the main agent that orchestrates task planning and execution
@@ -13,37 +23,240 @@ type CodingAgent struct {
 	indexer     *indexer.Indexer
 	taskManager *TaskManager
 	projectPath string
+	diagnostics *diagnostics.Collector
+	vulns       *vuln.Scanner
+	tests       *testmap.Map
+	prompts     *Prompts
+	memory      *memory.Store
+	summary     *string // project summary preamble, once loaded
 }
 
 // AgentConfig holds configuration for creating a coding agent
 type AgentConfig struct {
 	ProjectPath string
 	LLMConfig   LLMConfig
+	// LLMClient, if set, is used instead of a client built from LLMConfig,
+	// e.g. a ReplayClient in tests.
+	LLMClient LLMClient
+	// PromptDir holds <prompt>.tmpl overrides of the built-in prompt
+	// templates (see DefaultPrompts). Defaults to DefaultPromptDir in the
+	// project.
+	PromptDir string
+	// SystemPrompts adds per-command instructions to the system prompts.
+	SystemPrompts SystemPrompts
+	// Indexer, if set, is used instead of a new indexer, e.g. one with its
+	// cache enabled shared by a long-lived agent.
+	Indexer *indexer.Indexer
 }
 
 // NewCodingAgent creates a new coding agent
 func NewCodingAgent(config AgentConfig) (*CodingAgent, error) {
 	// Create LLM client
-	llmClient, err := NewLLMClient(config.LLMConfig)
+	llmClient := config.LLMClient
+	if llmClient == nil {
+		var err error
+		llmClient, err = NewLLMClient(config.LLMConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create LLM client: %w", err)
+		}
+	}
+
+	promptDir := config.PromptDir
+	if promptDir == "" {
+		promptDir = filepath.Join(config.ProjectPath, DefaultPromptDir)
+	}
+	prompts, err := LoadPrompts(promptDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create LLM client: %w", err)
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+
+	mem, err := memory.Load(config.ProjectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project memory: %w", err)
 	}
 
 	// Create indexer
-	idx := indexer.NewIndexer()
-	idx.RegisterParser(indexer.NewGoParser())
-	idx.RegisterParser(indexer.NewPythonParser())
+	idx := config.Indexer
+	if idx == nil {
+		idx = indexer.NewIndexer()
+		idx.RegisterParser(indexer.NewGoParser())
+		idx.RegisterParser(indexer.NewPythonParser())
+	}
 
 	return &CodingAgent{
 		llmClient:   llmClient,
 		indexer:     idx,
 		taskManager: NewTaskManager(),
 		projectPath: config.ProjectPath,
+		prompts:     prompts.WithSystemPrompts(config.SystemPrompts),
+		memory:      mem,
 	}, nil
 }
 
+// Memory returns the project's remembered conventions and learnings.
+func (a *CodingAgent) Memory() *memory.Store {
+	return a.memory
+}
+
+// maxSummaryBytes bounds the project summary preamble in prompts.
+const maxSummaryBytes = 4000
+
+// projectSummary returns the project summary preamble, refreshing
+// .index/PROJECT_SUMMARY.md from projIdx when the project has changed. With
+// a nil projIdx an existing summary is used as is. It is read once per
+// agent.
+func (a *CodingAgent) projectSummary(projIdx *indexer.ProjectIndex) string {
+	if a.summary != nil {
+		return *a.summary
+	}
+	content, err := summary.Ensure(a.projectPath, projIdx)
+	if err != nil {
+		fmt.Printf("Warning: project summary unavailable: %v\n", err)
+	}
+	preamble := summary.Preamble(content, maxSummaryBytes)
+	if projIdx != nil || preamble != "" {
+		a.summary = &preamble
+	}
+	return preamble
+}
+
+// SummarizeProject rebuilds .index/PROJECT_SUMMARY.md. With useLLM the
+// model writes the module responsibilities section; otherwise an existing
+// one is kept.
+func (a *CodingAgent) SummarizeProject(ctx context.Context, useLLM bool) (string, error) {
+	projIdx, err := a.indexer.IndexProject(a.projectPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to index project: %w", err)
+	}
+	s, err := summary.Build(a.projectPath, projIdx)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := summary.Load(a.projectPath)
+	if err != nil {
+		return "", err
+	}
+	s.Responsibilities = summary.Responsibilities(existing)
+	if useLLM {
+		prompt, err := a.prompts.Render(PromptSummarize, PromptData{Context: summary.Preamble(s.Markdown(), 0)})
+		if err != nil {
+			return "", err
+		}
+		response, err := a.llmClient.Chat(ctx, []Message{{Role: "user", Content: prompt}})
+		if err != nil {
+			return "", fmt.Errorf("failed to get LLM response: %w", err)
+		}
+		s.Responsibilities = response.Content
+	}
+
+	if err := s.Save(a.projectPath); err != nil {
+		return "", err
+	}
+	a.summary = nil
+	return s.Markdown(), nil
+}
+
+// maxMemoryEntries bounds how many remembered notes go into a prompt.
+const maxMemoryEntries = 50
+
+// memoryContext formats the project's remembered notes, or returns "" when
+// there are none.
+func (a *CodingAgent) memoryContext() string {
+	notes := a.memory.Format(maxMemoryEntries)
+	if notes == "" {
+		return ""
+	}
+	return "\n\nPROJECT MEMORY (conventions and learnings from earlier work; follow them):\n" + notes
+}
+
+// SetDiagnostics enables attaching compiler/linter diagnostics to the
+// context used for planning and task execution. Pass nil to disable.
+func (a *CodingAgent) SetDiagnostics(c *diagnostics.Collector) {
+	a.diagnostics = c
+}
+
+// diagnosticsContext formats current diagnostics for the given files, or
+// returns "" when diagnostics are disabled or clean.
+func (a *CodingAgent) diagnosticsContext(ctx context.Context, files []string) string {
+	if a.diagnostics == nil {
+		return ""
+	}
+	diags := diagnostics.Format(a.diagnostics.Collect(ctx), files, 30)
+	if diags == "" {
+		return ""
+	}
+	return "\n\nCURRENT DIAGNOSTICS (existing compiler/linter findings):\n" + diags
+}
+
+// SetTestMap enables pointing the agent at the tests covering the files
+// each action changes, so it runs them before completing a task. Pass nil
+// to disable.
+func (a *CodingAgent) SetTestMap(m *testmap.Map) {
+	a.tests = m
+}
+
+// maxRelatedTests bounds the tests suggested after a change.
+const maxRelatedTests = 20
+
+// relatedTests returns a history note with the commands running the tests
+// that cover files, or "" when test mapping is disabled or none do.
+func (a *CodingAgent) relatedTests(files []string) string {
+	if a.tests == nil {
+		return ""
+	}
+	var rel []string
+	for _, f := range files {
+		if r, err := relPath(a.projectPath, f); err == nil {
+			rel = append(rel, r)
+		}
+	}
+	covs := a.tests.TestsForFiles(rel)
+	if len(covs) == 0 {
+		return ""
+	}
+	if len(covs) > maxRelatedTests {
+		covs = covs[:maxRelatedTests]
+	}
+	return fmt.Sprintf("tests covering %s (run them with run_command before completing): %s",
+		strings.Join(rel, ", "), strings.Join(testmap.Commands(covs), "; "))
+}
+
+// SetVulnerabilities enables attaching dependency vulnerability findings,
+// mapped to the project's symbols, to the planning context. Pass nil to
+// disable.
+func (a *CodingAgent) SetVulnerabilities(s *vuln.Scanner) {
+	a.vulns = s
+}
+
+// vulnerabilityContext formats the findings relevant to prompt: those it
+// names by ID, or all of them when it names none.
+func (a *CodingAgent) vulnerabilityContext(ctx context.Context, prompt string, projIdx *indexer.ProjectIndex) string {
+	if a.vulns == nil {
+		return ""
+	}
+	findings, _ := a.vulns.Scan(ctx)
+	if mentioned := vuln.Filter(findings, vuln.MentionedIDs(prompt)); len(mentioned) > 0 {
+		findings = mentioned
+	}
+	if len(findings) == 0 {
+		return ""
+	}
+	findings = append([]vuln.Finding(nil), findings...)
+	vuln.MapSymbols(findings, indexer.NewSearchEngine(projIdx))
+	return "\n\nVULNERABILITY FINDINGS (from dependency scanners):\n" + vuln.Format(findings, 10)
+}
+
 // PlanTask takes a user prompt and generates a task breakdown
-func (a *CodingAgent) PlanTask(ctx context.Context, userPrompt string) (*TaskBreakdown, error) {
+func (a *CodingAgent) PlanTask(ctx context.Context, userPrompt string) (breakdown *TaskBreakdown, err error) {
+	ctx, span := tracing.Start(ctx, "agent.plan")
+	defer func() {
+		if breakdown != nil {
+			span.SetAttributes(attribute.Int("plan.tasks", len(breakdown.Tasks)))
+		}
+		tracing.End(span, err)
+	}()
+
 	// Step 1: Index the project (or use cache)
 	fmt.Println("Indexing project...")
 	projIdx, err := a.indexer.IndexProject(a.projectPath)
@@ -58,9 +271,20 @@ func (a *CodingAgent) PlanTask(ctx context.Context, userPrompt string) (*TaskBre
 
 	// Format context for LLM
 	contextStr := indexer.FormatContext(projectContext)
+	contextStr += a.memoryContext()
+	contextStr += a.diagnosticsContext(ctx, projectContext.RelevantModules)
+	contextStr += a.vulnerabilityContext(ctx, userPrompt, projIdx)
 
 	// Step 3: Generate task breakdown prompt
-	taskPrompt := a.taskManager.GenerateTaskPrompt(userPrompt, contextStr)
+	data := PromptData{Task: userPrompt, Context: contextStr, Summary: a.projectSummary(projIdx)}
+	systemPrompt, err := a.prompts.RenderSystem(PromptPlanSystem, data)
+	if err != nil {
+		return nil, err
+	}
+	taskPrompt, err := a.fitPrompt(PromptPlan, &data, []Message{{Role: "system", Content: systemPrompt}})
+	if err != nil {
+		return nil, err
+	}
 
 	// Step 4: Send to LLM
 	fmt.Printf("Generating task breakdown using %s (%s)...\n",
@@ -69,7 +293,7 @@ func (a *CodingAgent) PlanTask(ctx context.Context, userPrompt string) (*TaskBre
 	messages := []Message{
 		{
 			Role:    "system",
-			Content: "You are an expert coding assistant that helps break down development tasks into actionable steps.",
+			Content: systemPrompt,
 		},
 		{
 			Role:    "user",
@@ -84,7 +308,7 @@ func (a *CodingAgent) PlanTask(ctx context.Context, userPrompt string) (*TaskBre
 
 	// Step 5: Parse tasks from LLM response
 	fmt.Println("Parsing task breakdown...")
-	breakdown, err := a.taskManager.ParseTasksFromLLM(response.Content)
+	breakdown, err = a.taskManager.ParseTasksFromLLM(response.Content)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse tasks: %w", err)
 	}
@@ -97,12 +321,15 @@ func (a *CodingAgent) PlanTask(ctx context.Context, userPrompt string) (*TaskBre
 
 // Chat sends a message to the LLM with project context
 func (a *CodingAgent) Chat(ctx context.Context, userMessage string, includeContext bool) (*LLMResponse, error) {
-	messages := []Message{
-		{
-			Role:    "user",
-			Content: userMessage,
-		},
-	}
+	return a.ContinueChat(ctx, nil, userMessage, includeContext)
+}
+
+// ContinueChat sends userMessage as the next turn of session, with the
+// earlier turns as conversation history, and records the exchange in it.
+// A nil session makes a one-off request.
+func (a *CodingAgent) ContinueChat(ctx context.Context, session *ChatSession, userMessage string, includeContext bool) (*LLMResponse, error) {
+	data := PromptData{Task: userMessage}
+	var contextRefs []string
 
 	// If context is requested, fetch and prepend it
 	if includeContext {
@@ -113,14 +340,40 @@ func (a *CodingAgent) Chat(ctx context.Context, userMessage string, includeConte
 
 		contextFetcher := indexer.NewContextFetcher(projIdx)
 		projectContext := contextFetcher.FetchContext(userMessage, 10)
-		contextStr := indexer.FormatContext(projectContext)
+		data.Context = indexer.FormatContext(projectContext)
+		data.Summary = a.projectSummary(projIdx)
+		if projectContext != nil {
+			contextRefs = projectContext.RelevantModules
+		}
+	}
 
-		// Prepend context to the message
-		messages[0].Content = fmt.Sprintf("PROJECT CONTEXT:\n%s\n\nUSER QUESTION:\n%s",
-			contextStr, userMessage)
+	systemPrompt, err := a.prompts.RenderSystem(PromptChatSystem, data)
+	if err != nil {
+		return nil, err
 	}
 
-	return a.llmClient.Chat(ctx, messages)
+	var messages []Message
+	if systemPrompt != "" {
+		messages = append(messages, Message{Role: "system", Content: systemPrompt})
+	}
+	if session != nil {
+		// The oldest turns give way first, keeping at most half the window
+		// for history; the retrieved context fits in the rest.
+		messages = append(messages, fitHistory(session.messages(), a.promptBudget()/2)...)
+	}
+	content, err := a.fitPrompt(PromptChat, &data, messages)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, Message{Role: "user", Content: content})
+	resp, err := a.llmClient.Chat(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	if session != nil {
+		session.record(userMessage, contextRefs, resp)
+	}
+	return resp, nil
 }
 
 // GetProjectSummary returns a summary of the indexed project
@@ -158,24 +411,20 @@ func (a *CodingAgent) ExplainCode(ctx context.Context, symbolName string) (*LLMR
 	}
 
 	// Use the first result
-	result := results[0]
-
-	prompt := fmt.Sprintf(`Please explain this code:
-
-Symbol: %s
-Type: %s
-Location: %s:%d
-Signature: %s
-Documentation: %s
-
-Provide a clear explanation of what this code does, its purpose, and how it's used.`,
-		result.Name, result.Type, result.FilePath, result.Line,
-		result.Signature, result.Doc)
+	data := PromptData{Task: symbolName, Symbol: results[0], Summary: a.projectSummary(nil)}
+	systemPrompt, err := a.prompts.RenderSystem(PromptExplainSystem, data)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := a.prompts.Render(PromptExplain, data)
+	if err != nil {
+		return nil, err
+	}
 
 	messages := []Message{
 		{
 			Role:    "system",
-			Content: "You are an expert code reviewer and educator.",
+			Content: systemPrompt,
 		},
 		{
 			Role:    "user",
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourorg/agent/internal/models"
+)
+
+// Source is a retrieved excerpt that an answer may cite.
+type Source struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Kind      string `json:"kind,omitempty"` // e.g. "code", "docs"
+	Content   string `json:"content"`
+}
+
+// Citation returns the "path:start-end" form answers use to cite a source.
+func (s Source) Citation() string {
+	if s.EndLine > s.StartLine {
+		return fmt.Sprintf("%s:%d-%d", s.Path, s.StartLine, s.EndLine)
+	}
+	return fmt.Sprintf("%s:%d", s.Path, s.StartLine)
+}
+
+// Ask answers a question grounded in the given sources. Unlike Chat it never
+// plans or edits; the model is instructed to answer only from the sources
+// and to cite them as file:line ranges.
+func (a *CodingAgent) Ask(ctx context.Context, question string, sources []Source) (*LLMResponse, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no sources retrieved for question")
+	}
+
+	systemPrompt, err := a.prompts.RenderSystem(PromptAskSystem, PromptData{Task: question})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sources are ranked, so the least relevant give way when they do not
+	// all fit the model's context window; a lone source is truncated.
+	budget := a.promptBudget() - messageTokens([]Message{{Content: systemPrompt}}) - perMessageTokens
+	prompt := askPrompt(question, sources)
+	for len(sources) > 1 && models.EstimateTokens(prompt) > budget {
+		sources = sources[:len(sources)-1]
+		prompt = askPrompt(question, sources)
+	}
+	if over := models.EstimateTokens(prompt) - budget; over > 0 {
+		src := sources[0]
+		src.Content = models.Truncate(src.Content, models.EstimateTokens(src.Content)-over)
+		prompt = askPrompt(question, []Source{src})
+	}
+
+	messages := []Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: prompt},
+	}
+
+	resp, err := a.llmClient.Chat(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LLM response: %w", err)
+	}
+	return resp, nil
+}
+
+func askPrompt(question string, sources []Source) string {
+	var b strings.Builder
+	b.WriteString("SOURCES:\n")
+	for i, src := range sources {
+		fmt.Fprintf(&b, "\n[%d] %s", i+1, src.Citation())
+		if src.Kind != "" {
+			fmt.Fprintf(&b, " (%s)", src.Kind)
+		}
+		fmt.Fprintf(&b, "\n```\n%s\n```\n", strings.TrimRight(src.Content, "\n"))
+	}
+	fmt.Fprintf(&b, "\nQUESTION:\n%s\n", question)
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/yourorg/agent/internal/models"
+)
+
+// perMessageTokens approximates the framing each message adds to a request.
+const perMessageTokens = 4
+
+// promptBudget returns how many tokens the messages of one request to the
+// agent's model may use.
+func (a *CodingAgent) promptBudget() int {
+	return models.Lookup(a.llmClient.GetProvider(), a.llmClient.GetModel()).PromptBudget()
+}
+
+func messageTokens(messages []Message) int {
+	n := 0
+	for _, m := range messages {
+		n += models.EstimateTokens(m.Content) + perMessageTokens
+	}
+	return n
+}
+
+// fitPrompt renders the named prompt so that, with the fixed messages sent
+// alongside it, it fits the model's context window. The oldest steps of
+// data.History give way until they use at most half the budget, then
+// data.Context is truncated, then more history is dropped. data is updated
+// to match what was rendered.
+func (a *CodingAgent) fitPrompt(name string, data *PromptData, fixed []Message) (string, error) {
+	budget := a.promptBudget() - messageTokens(fixed) - perMessageTokens
+	prompt, err := a.prompts.Render(name, *data)
+	if err != nil || models.EstimateTokens(prompt) <= budget {
+		return prompt, err
+	}
+
+	history, dropped := data.History, 0
+	dropHistory := func(limit int) error {
+		for dropped < len(history) && models.EstimateTokens(prompt) > budget && historyTokens(data.History) > limit {
+			dropped++
+			data.History = append([]string{fmt.Sprintf("(%d earlier steps omitted to fit the context window)", dropped)}, history[dropped:]...)
+			if prompt, err = a.prompts.Render(name, *data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := dropHistory(budget / 2); err != nil {
+		return "", err
+	}
+	if over := models.EstimateTokens(prompt) - budget; over > 0 && data.Context != "" {
+		data.Context = models.Truncate(data.Context, models.EstimateTokens(data.Context)-over)
+		if prompt, err = a.prompts.Render(name, *data); err != nil {
+			return "", err
+		}
+	}
+	if err := dropHistory(0); err != nil {
+		return "", err
+	}
+	return prompt, nil
+}
+
+func historyTokens(history []string) int {
+	n := 0
+	for _, step := range history {
+		n += models.EstimateTokens(step)
+	}
+	return n
+}
+
+// fitHistory drops the oldest exchanges of a conversation until it fits in
+// budget tokens. Messages are dropped in user/assistant pairs so the
+// history still starts with a user message.
+func fitHistory(history []Message, budget int) []Message {
+	for len(history) > 0 && messageTokens(history) > budget {
+		n := 2
+		if len(history) < n || history[0].Role != "user" {
+			n = 1
+		}
+		history = history[n:]
+	}
+	return history
+}
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/yourorg/agent/internal/proxy"
+	"github.com/yourorg/agent/internal/ratelimit"
 )
 
 // ClaudeClient implements LLMClient for Anthropic's Claude API
@@ -37,7 +40,7 @@ func NewClaudeClient(config LLMConfig) (*ClaudeClient, error) {
 		apiKey:  config.APIKey,
 		model:   model,
 		baseURL: baseURL,
-		client:  &http.Client{},
+		client:  proxy.Client("claude", 0),
 	}, nil
 }
 
@@ -98,6 +101,10 @@ func (c *ClaudeClient) Chat(ctx context.Context, messages []Message) (*LLMRespon
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	if err := ratelimit.Wait(ctx, "claude"); err != nil {
+		return nil, fmt.Errorf("rate limit wait: %w", err)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/messages", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
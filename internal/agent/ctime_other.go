@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package agent
+
+import (
+	"os"
+	"time"
+)
+
+// changeTime returns the zero time: the platform's file info has no change
+// time, so the file cache relies on size and modification time.
+func changeTime(os.FileInfo) time.Time {
+	return time.Time{}
+}
@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FileDiff is the net change a run would make to one file.
+type FileDiff struct {
+	Path   string `json:"path"`
+	Status string `json:"status"` // "created", "modified" or "deleted"
+	Diff   string `json:"diff"`   // unified diff
+}
+
+// diffContext is the number of unchanged lines shown around each hunk.
+const diffContext = 3
+
+// maxDiffCells bounds the line-matching table. Larger changed regions are
+// shown as a single replacement hunk instead.
+const maxDiffCells = 4_000_000
+
+// UnifiedDiff returns a unified diff turning oldText into newText, or "" when
+// nothing changes. created and deleted show the missing side as /dev/null.
+func UnifiedDiff(path, oldText, newText string, created, deleted bool) string {
+	if oldText == newText && !created && !deleted {
+		return ""
+	}
+	oldLines, newLines := splitLines(oldText), splitLines(newText)
+
+	var b strings.Builder
+	from, to := "a/"+path, "b/"+path
+	if created {
+		from = "/dev/null"
+	}
+	if deleted {
+		to = "/dev/null"
+	}
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", from, to)
+
+	ops := diffLines(oldLines, newLines)
+	for _, h := range hunks(ops) {
+		oldStart, newStart := h.oldStart+1, h.newStart+1
+		if h.oldCount == 0 {
+			oldStart--
+		}
+		if h.newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldStart, h.oldCount), hunkRange(newStart, h.newCount))
+		for _, op := range ops[h.first:h.last] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.text)
+			b.WriteByte('\n')
+			if op.noNewline {
+				b.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+type diffLine struct {
+	text      string
+	noNewline bool // last line of a file that lacks a trailing newline
+}
+
+func splitLines(s string) []diffLine {
+	if s == "" {
+		return nil
+	}
+	parts := strings.SplitAfter(s, "\n")
+	if parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	lines := make([]diffLine, len(parts))
+	for i, p := range parts {
+		text, hadNewline := strings.CutSuffix(p, "\n")
+		lines[i] = diffLine{text: text, noNewline: !hadNewline}
+	}
+	return lines
+}
+
+// diffOp is one line of the edit script: ' ' kept, '-' removed, '+' added.
+type diffOp struct {
+	kind      byte
+	text      string
+	noNewline bool
+}
+
+// diffLines computes a line edit script from a longest common subsequence.
+// Common prefix and suffix are stripped first so typical localized edits
+// only match the changed region.
+func diffLines(a, b []diffLine) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	keep := func(l diffLine) { ops = append(ops, diffOp{' ', l.text, l.noNewline}) }
+	remove := func(l diffLine) { ops = append(ops, diffOp{'-', l.text, l.noNewline}) }
+	add := func(l diffLine) { ops = append(ops, diffOp{'+', l.text, l.noNewline}) }
+
+	for _, l := range a[:prefix] {
+		keep(l)
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA)*len(midB) > maxDiffCells {
+		for _, l := range midA {
+			remove(l)
+		}
+		for _, l := range midB {
+			add(l)
+		}
+	} else {
+		// lcs[i][j] is the LCS length of midA[i:] and midB[j:].
+		n, m := len(midA), len(midB)
+		lcs := make([][]int, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if midA[i] == midB[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n && j < m {
+			switch {
+			case midA[i] == midB[j]:
+				keep(midA[i])
+				i, j = i+1, j+1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				remove(midA[i])
+				i++
+			default:
+				add(midB[j])
+				j++
+			}
+		}
+		for ; i < n; i++ {
+			remove(midA[i])
+		}
+		for ; j < m; j++ {
+			add(midB[j])
+		}
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		keep(l)
+	}
+	return ops
+}
+
+type hunk struct {
+	first, last        int // range in the edit script
+	oldStart, newStart int // zero-based line numbers
+	oldCount, newCount int
+}
+
+// hunks groups changed lines with up to diffContext lines of context,
+// merging groups whose context would overlap.
+func hunks(ops []diffOp) []hunk {
+	var out []hunk
+	oldLine, newLine := 0, 0
+	lineAt := make([][2]int, len(ops))
+	for i, op := range ops {
+		lineAt[i] = [2]int{oldLine, newLine}
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		first := max(i-diffContext, 0)
+		if len(out) > 0 && first <= out[len(out)-1].last {
+			first = out[len(out)-1].first
+			out = out[:len(out)-1]
+		}
+		last := i
+		for last < len(ops) && ops[last].kind != ' ' {
+			last++
+		}
+		i = last
+		last = min(last+diffContext, len(ops))
+
+		h := hunk{first: first, last: last, oldStart: lineAt[first][0], newStart: lineAt[first][1]}
+		for _, op := range ops[first:last] {
+			if op.kind != '+' {
+				h.oldCount++
+			}
+			if op.kind != '-' {
+				h.newCount++
+			}
+		}
+		out = append(out, h)
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// CommandEnv controls the environment run_command actions see. The zero
+// value inherits the agent's full environment.
+type CommandEnv struct {
+	// Minimal starts commands from a small base environment (PATH, HOME,
+	// locale, terminal and temp dir) so cloud credentials and tokens in the
+	// agent's environment do not leak into generated commands.
+	Minimal bool `json:"minimal,omitempty"`
+	// Allow passes more variables through in minimal mode. A trailing "*"
+	// matches a prefix, e.g. "GO*".
+	Allow []string `json:"allow,omitempty"`
+	// Set injects variables, overriding inherited ones. Values may refer to
+	// the agent's environment as $VAR or ${VAR}.
+	Set map[string]string `json:"set,omitempty"`
+}
+
+// minimalEnv is the base environment kept in minimal mode.
+var minimalEnv = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL",
+	"LANG", "LC_ALL", "LC_CTYPE", "TERM", "TMPDIR", "TZ",
+}
+
+// environ returns the environment for a command, or nil to inherit the
+// parent's unchanged.
+func (c CommandEnv) environ() []string {
+	if !c.Minimal && len(c.Set) == 0 {
+		return nil
+	}
+
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		if !c.Minimal || allowed(name, minimalEnv) || allowed(name, c.Allow) {
+			vars[name] = value
+		}
+	}
+	for name, value := range c.Set {
+		vars[name] = os.ExpandEnv(value)
+	}
+
+	env := make([]string, 0, len(vars))
+	for name, value := range vars {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+func allowed(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
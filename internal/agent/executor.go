@@ -6,10 +6,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/yourorg/agent/internal/cache"
 	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/memory"
+	"github.com/yourorg/agent/internal/metrics"
+	"github.com/yourorg/agent/internal/secrets"
 )
 
 // Executor is responsible for carrying out actions produced by the agent brain.
@@ -17,7 +22,17 @@ type Executor struct {
 	projectRoot string
 	index       *indexer.ProjectIndex
 	dryRun      bool
-	blocklist   []string
+	interactive bool
+	// allowDangerous runs commands that guardCommand would refuse.
+	allowDangerous bool
+	paths          pathRules
+	timeouts       map[ActionType]time.Duration
+	env            []string // nil inherits the parent environment
+	files          *cache.LRU[string, cachedFile]
+	memory         *memory.Store
+	// staged holds the files the run changed by absolute path, so the net
+	// diff can be reported and, in a dry run, later actions see the changes.
+	staged map[string]*stagedFile
 }
 
 // ExecutorConfig configures an Executor instance.
@@ -25,21 +40,102 @@ type ExecutorConfig struct {
 	ProjectRoot string
 	Index       *indexer.ProjectIndex
 	DryRun      bool
-	Blocklist   []string
+	// Paths adds forbidden, read-only and protected patterns to
+	// DefaultPathPolicy.
+	Paths PathPolicy
+	// FileCacheSize bounds how many file contents are kept in memory between
+	// actions (default 64). Negative disables the cache.
+	FileCacheSize int
+	// NonInteractive rejects actions that need a human, such as ask_user.
+	NonInteractive bool
+	// ActionTimeouts overrides the default timeout per action type
+	// (run_command: 5 minutes). A run_command action's own timeout field
+	// still takes precedence. Zero means no limit for that type.
+	ActionTimeouts map[ActionType]time.Duration
+	// Env scrubs or extends the environment of run_command actions.
+	Env CommandEnv
+	// Memory receives notes from remember actions. Nil rejects them.
+	Memory *memory.Store
+	// AllowDangerous lets run_command run destructive commands (rm -r
+	// outside the project, dd, mkfs, force pushes, curl | sh, DROP TABLE)
+	// that are refused by default. Either way they are logged to
+	// AuditLogPath.
+	AllowDangerous bool
 }
 
+// cachedFile is a file's content together with the stat info it was read under.
+type cachedFile struct {
+	content    string
+	size       int64
+	modTime    time.Time
+	changeTime time.Time // ctime where the platform has one
+	readAt     time.Time
+}
+
+// racyWindow is the coarsest modification time granularity expected (FAT
+// stores 2 seconds). A file modified this close to when it was cached may
+// change again without its stat info changing, so it is not served from
+// the cache until it has been read again later, as git does for racily
+// clean index entries.
+const racyWindow = 2 * time.Second
+
+// fresh reports whether the cached content still matches a file with info.
+func (c cachedFile) fresh(info os.FileInfo) bool {
+	return c.size == info.Size() && c.modTime.Equal(info.ModTime()) &&
+		c.changeTime.Equal(changeTime(info)) && c.readAt.Sub(c.modTime) >= racyWindow
+}
+
+// stagedFile is a change made by the run: the file as it was before and as
+// the run leaves it (or, in a dry run, would leave it).
+type stagedFile struct {
+	path     string // as given by the action
+	original string
+	existed  bool
+	content  string
+	deleted  bool
+}
+
+const defaultFileCacheSize = 64
+
+// defaultActionTimeouts bound actions that can block on external work.
+var defaultActionTimeouts = map[ActionType]time.Duration{
+	ActionRunCommand: 5 * time.Minute,
+}
+
+// commandWaitDelay is how long a killed command's output pipes may stay
+// open (held by child processes) before Execute gives up on them.
+const commandWaitDelay = 5 * time.Second
+
 // NewExecutor creates a new executor with sensible defaults.
 func NewExecutor(cfg ExecutorConfig) *Executor {
-	blocked := cfg.Blocklist
-	if len(blocked) == 0 {
-		blocked = []string{".env", "id_rsa", "id_dsa", "secrets", "config.yml", "config.yaml"}
+	var files *cache.LRU[string, cachedFile]
+	switch {
+	case cfg.FileCacheSize == 0:
+		files = cache.NewLRU[string, cachedFile](defaultFileCacheSize, nil)
+	case cfg.FileCacheSize > 0:
+		files = cache.NewLRU[string, cachedFile](cfg.FileCacheSize, nil)
+	}
+
+	timeouts := make(map[ActionType]time.Duration, len(defaultActionTimeouts)+len(cfg.ActionTimeouts))
+	for t, d := range defaultActionTimeouts {
+		timeouts[t] = d
+	}
+	for t, d := range cfg.ActionTimeouts {
+		timeouts[t] = d
 	}
 
 	return &Executor{
-		projectRoot: cfg.ProjectRoot,
-		index:       cfg.Index,
-		dryRun:      cfg.DryRun,
-		blocklist:   blocked,
+		projectRoot:    cfg.ProjectRoot,
+		index:          cfg.Index,
+		dryRun:         cfg.DryRun,
+		interactive:    !cfg.NonInteractive,
+		allowDangerous: cfg.AllowDangerous,
+		paths:          compilePathPolicy(cfg.Paths),
+		timeouts:       timeouts,
+		env:            cfg.Env.environ(),
+		files:          files,
+		memory:         cfg.Memory,
+		staged:         make(map[string]*stagedFile),
 	}
 }
 
@@ -47,88 +143,127 @@ func NewExecutor(cfg ExecutorConfig) *Executor {
 func (e *Executor) Execute(ctx context.Context, action Action) ActionResult {
 	start := time.Now()
 
+	if err := ctx.Err(); err != nil {
+		return e.result(false, "", fmt.Errorf("action not started: %w", err), start)
+	}
+	timeout := e.timeouts[action.Type]
+	if action.Type == ActionRunCommand && action.Timeout > 0 {
+		timeout = time.Duration(action.Timeout) * time.Second
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	switch action.Type {
 	case ActionReadFile:
-		content, err := os.ReadFile(e.abs(action.Path))
+		if err := e.checkPath(action.Path, accessRead); err != nil {
+			return e.result(false, "", err, start)
+		}
+		content, err := e.currentContent(e.abs(action.Path))
 		if err != nil {
 			return e.result(false, "", err, start)
 		}
-		return e.result(true, string(content), nil, start)
+		return e.result(true, content, nil, start)
 
 	case ActionCreateFile:
-		if err := e.checkPath(action.Path); err != nil {
+		access := accessEdit
+		before, err := e.currentContent(e.abs(action.Path))
+		if err == nil {
+			access = accessReplace
+		}
+		if err := e.checkPath(action.Path, access); err != nil {
+			return e.result(false, "", err, start)
+		}
+		if err := checkRedacted(action.Path, before, action.Content); err != nil {
 			return e.result(false, "", err, start)
 		}
 		if e.dryRun {
-			return e.result(true, fmt.Sprintf("[dry-run] would create %s", action.Path), nil, start)
+			diff := e.stage(action.Path, action.Content, false)
+			return e.dryRunResult(fmt.Sprintf("[dry-run] would create %s", action.Path), diff, start)
 		}
 		if err := os.MkdirAll(filepath.Dir(e.abs(action.Path)), 0o755); err != nil {
 			return e.result(false, "", err, start)
 		}
-		if err := os.WriteFile(e.abs(action.Path), []byte(action.Content), 0o644); err != nil {
+		diff := e.stage(action.Path, action.Content, false)
+		if err := e.writeFile(e.abs(action.Path), action.Content); err != nil {
 			return e.result(false, "", err, start)
 		}
-		return e.result(true, fmt.Sprintf("created %s", action.Path), nil, start, action.Path)
+		return e.changedResult(fmt.Sprintf("created %s", action.Path), diff, start, action.Path)
 
 	case ActionEditFile:
-		if err := e.checkPath(action.Path); err != nil {
+		if err := e.checkPath(action.Path, accessEdit); err != nil {
 			return e.result(false, "", err, start)
 		}
 		if len(action.Edits) == 0 {
 			return e.result(false, "", fmt.Errorf("no edits provided"), start)
 		}
 		absPath := e.abs(action.Path)
-		data, err := os.ReadFile(absPath)
+		original, err := e.currentContent(absPath)
 		if err != nil {
 			return e.result(false, "", err, start)
 		}
-		content := string(data)
+		content := original
 		for _, edit := range action.Edits {
 			if !strings.Contains(content, edit.OldText) {
 				return e.result(false, "", fmt.Errorf("old_text not found in %s", action.Path), start)
 			}
 			content = strings.Replace(content, edit.OldText, edit.NewText, 1)
 		}
+		if err := checkRedacted(action.Path, original, content); err != nil {
+			return e.result(false, "", err, start)
+		}
 		if e.dryRun {
-			return e.result(true, fmt.Sprintf("[dry-run] would edit %s", action.Path), nil, start)
+			diff := e.stage(action.Path, content, false)
+			return e.dryRunResult(fmt.Sprintf("[dry-run] would edit %s", action.Path), diff, start)
 		}
-		if err := os.WriteFile(absPath, []byte(content), 0o644); err != nil {
+		diff := e.stage(action.Path, content, false)
+		if err := e.writeFile(absPath, content); err != nil {
 			return e.result(false, "", err, start)
 		}
-		return e.result(true, fmt.Sprintf("edited %s", action.Path), nil, start, action.Path)
+		return e.changedResult(fmt.Sprintf("edited %s", action.Path), diff, start, action.Path)
 
 	case ActionDeleteFile:
-		if err := e.checkPath(action.Path); err != nil {
+		if err := e.checkPath(action.Path, accessReplace); err != nil {
 			return e.result(false, "", err, start)
 		}
 		if e.dryRun {
-			return e.result(true, fmt.Sprintf("[dry-run] would delete %s", action.Path), nil, start)
+			if _, err := e.currentContent(e.abs(action.Path)); err != nil {
+				return e.result(false, "", err, start)
+			}
+			diff := e.stage(action.Path, "", true)
+			return e.dryRunResult(fmt.Sprintf("[dry-run] would delete %s", action.Path), diff, start)
 		}
-		if err := os.Remove(e.abs(action.Path)); err != nil {
+		diff := e.stage(action.Path, "", true)
+		err := os.Remove(e.abs(action.Path))
+		e.invalidate(e.abs(action.Path))
+		if err != nil {
 			return e.result(false, "", err, start)
 		}
-		return e.result(true, fmt.Sprintf("deleted %s", action.Path), nil, start, action.Path)
+		return e.changedResult(fmt.Sprintf("deleted %s", action.Path), diff, start, action.Path)
 
 	case ActionRunCommand:
 		workdir := action.Workdir
 		if workdir == "" {
 			workdir = e.projectRoot
 		}
-		timeout := time.Duration(action.Timeout) * time.Second
-		if timeout == 0 {
-			timeout = 5 * time.Minute
+		if err := e.guardCommand(action.Command, workdir); err != nil {
+			return e.result(false, "", err, start)
 		}
-		runCtx, cancel := context.WithTimeout(ctx, timeout)
-		defer cancel()
-
 		if e.dryRun {
 			return e.result(true, fmt.Sprintf("[dry-run] would run '%s' (cwd=%s)", action.Command, workdir), nil, start)
 		}
 
-		cmd := exec.CommandContext(runCtx, "bash", "-c", action.Command)
+		cmd := exec.CommandContext(ctx, "bash", "-c", action.Command)
 		cmd.Dir = workdir
+		cmd.Env = e.env
+		cmd.WaitDelay = commandWaitDelay
 		output, err := cmd.CombinedOutput()
 		if err != nil {
+			if ctx.Err() != nil {
+				err = fmt.Errorf("command interrupted after %s: %w", time.Since(start).Round(time.Second), ctx.Err())
+			}
 			return e.result(false, string(output), err, start)
 		}
 		return e.result(true, string(output), nil, start)
@@ -147,9 +282,33 @@ func (e *Executor) Execute(ctx context.Context, action Action) ActionResult {
 		return e.result(true, b.String(), nil, start)
 
 	case ActionAskUser:
+		if !e.interactive {
+			return e.result(false, action.Question, fmt.Errorf("interactive actions are disabled"), start)
+		}
 		// Ask_user is a no-op for automation; bubble up the question.
 		return e.result(false, action.Question, fmt.Errorf("user input required"), start)
 
+	case ActionRemember:
+		if e.memory == nil {
+			return e.result(false, "", fmt.Errorf("memory unavailable"), start)
+		}
+		if strings.TrimSpace(action.Note) == "" {
+			return e.result(false, "", fmt.Errorf("remember requires a note"), start)
+		}
+		if e.memory.Has(action.Note) {
+			return e.result(true, "already remembered", nil, start)
+		}
+		if e.dryRun {
+			return e.result(true, "[dry-run] would remember: "+action.Note, nil, start)
+		}
+		if !e.memory.Add(action.Category, action.Note) {
+			return e.result(true, "already remembered", nil, start)
+		}
+		if err := e.memory.Save(); err != nil {
+			return e.result(false, "", err, start)
+		}
+		return e.result(true, "remembered: "+action.Note, nil, start)
+
 	case ActionComplete:
 		return e.result(true, action.Summary, nil, start)
 
@@ -161,6 +320,133 @@ func (e *Executor) Execute(ctx context.Context, action Action) ActionResult {
 	}
 }
 
+// checkRedacted rejects writing content with more redaction placeholders
+// than the file had: the model saw a secret as [REDACTED:<rule>] and would
+// otherwise overwrite the real value with the placeholder.
+func checkRedacted(path, before, after string) error {
+	if strings.Count(after, secrets.RedactedPrefix) > strings.Count(before, secrets.RedactedPrefix) {
+		return fmt.Errorf("refusing to write a redacted secret placeholder to %s; leave lines with redacted values unchanged", path)
+	}
+	return nil
+}
+
+// currentContent returns a file's content as the run has left it so far,
+// including staged dry-run changes.
+func (e *Executor) currentContent(absPath string) (string, error) {
+	if sf, ok := e.staged[absPath]; ok && e.dryRun {
+		if sf.deleted {
+			return "", fmt.Errorf("open %s: %w", absPath, os.ErrNotExist)
+		}
+		return sf.content, nil
+	}
+	return e.readFile(absPath)
+}
+
+// stage records a change to path, before it is written outside a dry run,
+// and returns the diff of this step.
+func (e *Executor) stage(path, content string, deleted bool) string {
+	absPath := e.abs(path)
+	sf, ok := e.staged[absPath]
+	if !ok {
+		original, err := e.readFile(absPath)
+		sf = &stagedFile{path: path, original: original, existed: err == nil}
+		sf.content, sf.deleted = original, !sf.existed
+		e.staged[absPath] = sf
+	} else if !e.dryRun {
+		// Commands may have changed the file since the last action.
+		current, err := e.readFile(absPath)
+		sf.content, sf.deleted = current, err != nil
+	}
+
+	before, existedBefore := sf.content, !sf.deleted
+	sf.content, sf.deleted = content, deleted
+	return UnifiedDiff(path, before, content, !existedBefore, deleted)
+}
+
+// Diffs returns the net change of every file created, edited or deleted by
+// the run's actions (changes made by commands alone are not tracked), sorted
+// by path. Files that end up unchanged are omitted.
+func (e *Executor) Diffs() []FileDiff {
+	var diffs []FileDiff
+	for absPath, sf := range e.staged {
+		if !e.dryRun {
+			current, err := e.readFile(absPath)
+			sf.content, sf.deleted = current, err != nil
+		}
+		var status string
+		switch {
+		case !sf.existed && sf.deleted:
+			continue
+		case !sf.existed:
+			status = "created"
+		case sf.deleted:
+			status = "deleted"
+		case sf.content == sf.original:
+			continue
+		default:
+			status = "modified"
+		}
+		diffs = append(diffs, FileDiff{
+			Path:   sf.path,
+			Status: status,
+			Diff:   UnifiedDiff(sf.path, sf.original, sf.content, status == "created", status == "deleted"),
+		})
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+// readFile returns a file's content, serving it from the cache while the
+// file's size, modification and change times are unchanged. The stat check
+// keeps the cache correct when run_command modifies files behind the
+// executor's back; files the executor writes are dropped from the cache.
+func (e *Executor) readFile(absPath string) (string, error) {
+	if e.files == nil {
+		data, err := os.ReadFile(absPath)
+		return string(data), err
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		e.files.Remove(absPath)
+		return "", err
+	}
+
+	if cached, ok := e.files.Get(absPath); ok && cached.fresh(info) {
+		metrics.CacheHit("executor_files", true)
+		return cached.content, nil
+	}
+	metrics.CacheHit("executor_files", false)
+
+	readAt := time.Now()
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return "", err
+	}
+	e.files.Add(absPath, cachedFile{
+		content:    string(data),
+		size:       info.Size(),
+		modTime:    info.ModTime(),
+		changeTime: changeTime(info),
+		readAt:     readAt,
+	})
+	return string(data), nil
+}
+
+// writeFile writes content to absPath and drops it from the file cache,
+// whether or not the write succeeded.
+func (e *Executor) writeFile(absPath, content string) error {
+	err := os.WriteFile(absPath, []byte(content), 0o644)
+	e.invalidate(absPath)
+	return err
+}
+
+func (e *Executor) invalidate(absPath string) {
+	if e.files != nil {
+		e.files.Remove(absPath)
+	}
+}
+
 func (e *Executor) abs(path string) string {
 	if filepath.IsAbs(path) {
 		return path
@@ -168,17 +454,24 @@ func (e *Executor) abs(path string) string {
 	return filepath.Join(e.projectRoot, path)
 }
 
-func (e *Executor) checkPath(path string) error {
-	abs := e.abs(path)
-	if !strings.HasPrefix(abs, filepath.Clean(e.projectRoot)) {
-		return fmt.Errorf("path %s escapes project root", path)
-	}
-	for _, blocked := range e.blocklist {
-		if strings.Contains(abs, blocked) {
-			return fmt.Errorf("path %s is blocked", path)
-		}
+func (e *Executor) checkPath(path string, access pathAccess) error {
+	rel, err := relPath(e.projectRoot, path)
+	if err != nil {
+		return err
 	}
-	return nil
+	return e.paths.check(rel, access)
+}
+
+func (e *Executor) dryRunResult(output, diff string, start time.Time) ActionResult {
+	res := e.result(true, output, nil, start)
+	res.Diff = diff
+	return res
+}
+
+func (e *Executor) changedResult(output, diff string, start time.Time, path string) ActionResult {
+	res := e.result(true, output, nil, start, path)
+	res.Diff = diff
+	return res
 }
 
 func (e *Executor) result(success bool, output string, err error, start time.Time, changed ...string) ActionResult {
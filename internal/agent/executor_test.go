@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readAction(t *testing.T, e *Executor, path string) string {
+	t.Helper()
+	res := e.Execute(context.Background(), Action{Type: ActionReadFile, Path: path})
+	if !res.Success {
+		t.Fatalf("read %s: %s", path, res.Error)
+	}
+	return res.Output
+}
+
+func TestExecutorFileCache(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, e *Executor, path string)
+	}{
+		{
+			name: "edit action",
+			modify: func(t *testing.T, e *Executor, path string) {
+				res := e.Execute(context.Background(), Action{
+					Type:  ActionEditFile,
+					Path:  "a.txt",
+					Edits: []TextEdit{{OldText: "alpha", NewText: "omega"}},
+				})
+				if !res.Success {
+					t.Fatalf("edit: %s", res.Error)
+				}
+				// Keep the size and mtime so only the executor's own
+				// invalidation can notice the change.
+				if err := os.Chtimes(path, old, old); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "external write with same size and mtime",
+			modify: func(t *testing.T, e *Executor, path string) {
+				if err := os.WriteFile(path, []byte("omega\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(path, old, old); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			path := filepath.Join(root, "a.txt")
+			if err := os.WriteFile(path, []byte("alpha\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+			e := NewExecutor(ExecutorConfig{ProjectRoot: root})
+
+			if got := readAction(t, e, "a.txt"); got != "alpha\n" {
+				t.Fatalf("first read = %q", got)
+			}
+			tt.modify(t, e, path)
+			if got := readAction(t, e, "a.txt"); got != "omega\n" {
+				t.Errorf("read after change = %q, want %q", got, "omega\n")
+			}
+		})
+	}
+}
+
+func TestExecutorSkipsRacyCacheEntries(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(path, []byte("alpha\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewExecutor(ExecutorConfig{ProjectRoot: root})
+	readAction(t, e, "a.txt")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, ok := e.files.Get(path)
+	if !ok {
+		t.Fatal("file was not cached")
+	}
+	if cached.fresh(info) {
+		t.Error("entry read within the racy window counts as fresh")
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/yourorg/agent/internal/proxy"
+	"github.com/yourorg/agent/internal/ratelimit"
 )
 
 // GeminiClient implements LLMClient for Google's Gemini API
@@ -37,7 +40,7 @@ func NewGeminiClient(config LLMConfig) (*GeminiClient, error) {
 		apiKey:  config.APIKey,
 		model:   model,
 		baseURL: baseURL,
-		client:  &http.Client{},
+		client:  proxy.Client("gemini", 0),
 	}, nil
 }
 
@@ -105,6 +108,10 @@ func (g *GeminiClient) Chat(ctx context.Context, messages []Message) (*LLMRespon
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	if err := ratelimit.Wait(ctx, "gemini"); err != nil {
+		return nil, fmt.Errorf("rate limit wait: %w", err)
+	}
+
 	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", g.baseURL, g.model, g.apiKey)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
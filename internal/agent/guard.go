@@ -0,0 +1,210 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dangerousPatterns match commands that can destroy data beyond what a
+// coding task needs. run_command refuses them unless the run explicitly
+// allows dangerous commands. Matching is heuristic: it guards against
+// mistakes, not against a model determined to get around it.
+var dangerousPatterns = []struct {
+	reason  string
+	pattern *regexp.Regexp
+}{
+	{"dd writes raw data to a file or device", regexp.MustCompile(`(^|[\s;&|(])dd\s[^;&|]*\bof=`)},
+	{"mkfs formats a filesystem", regexp.MustCompile(`(^|[\s;&|(/])mkfs(\.\w+)?(\s|$)`)},
+	{"force push rewrites remote history", regexp.MustCompile(`\bgit\s+(-\S+\s+)*push\b[^;&|]*(\s--force(\s|$)|\s-[a-zA-Z]*f[a-zA-Z]*(\s|$)|\s\+\S)`)},
+	{"piping a download into a shell runs unreviewed code", regexp.MustCompile(`\b(curl|wget)\b[^;&]*\|\s*(sudo\s+)?(ba|z|da|k)?sh(\s|$)`)},
+	{"piping a download into a shell runs unreviewed code", regexp.MustCompile(`\b(ba|z|da|k)?sh\s+(-c\s+)?["']?(\$|<)\(\s*(curl|wget)\b`)},
+	{"DROP deletes a database object and its data", regexp.MustCompile(`(?i)\bdrop\s+(table|database|schema)\b`)},
+}
+
+// commandSeparators split a shell command into simple commands.
+var commandSeparators = regexp.MustCompile(`&&|\|\||[;&|\n]`)
+
+// dangerousCommand returns why command, run in workdir, is dangerous, or ""
+// if it is not.
+func dangerousCommand(projectRoot, workdir, command string) string {
+	for _, d := range dangerousPatterns {
+		if d.pattern.MatchString(command) {
+			return d.reason
+		}
+	}
+
+	// Follow cd so "cd / && rm -rf x" is resolved against the right place.
+	dir := workdir
+	for _, segment := range commandSeparators.Split(command, -1) {
+		args := commandArgs(segment)
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "cd":
+			if len(args) < 2 || unresolvable(args[1]) {
+				dir = ""
+			} else if dir != "" {
+				dir = resolve(dir, args[1])
+			}
+		case "rm":
+			if reason := dangerousRemove(projectRoot, dir, args[1:]); reason != "" {
+				return reason
+			}
+		case "xargs":
+			for i, a := range args {
+				if a == "rm" && recursiveRemove(args[i+1:]) {
+					return "rm -r through xargs deletes paths that cannot be checked"
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// commandArgs splits a simple command into words, dropping quotes, a
+// leading sudo and variable assignments.
+func commandArgs(segment string) []string {
+	var args []string
+	for _, f := range strings.Fields(segment) {
+		f = strings.Trim(f, `"'()`)
+		if f == "" {
+			continue
+		}
+		if len(args) == 0 && (f == "sudo" || f == "command" || f == "exec" || strings.Contains(f, "=")) {
+			continue
+		}
+		args = append(args, f)
+	}
+	return args
+}
+
+// dangerousRemove checks a recursive rm: every target must lie inside the
+// project, and not be the project root itself.
+func dangerousRemove(projectRoot, dir string, args []string) string {
+	if !recursiveRemove(args) {
+		return ""
+	}
+	for _, t := range removeTargets(args) {
+		if dir == "" || unresolvable(t) {
+			return fmt.Sprintf("rm -r of %s cannot be checked to stay inside the project", t)
+		}
+		rel, err := relPath(projectRoot, resolve(dir, t))
+		if err != nil {
+			return fmt.Sprintf("rm -r of %s deletes outside the project", t)
+		}
+		if rel == "." {
+			return fmt.Sprintf("rm -r of %s deletes the whole project", t)
+		}
+	}
+	return ""
+}
+
+// recursiveRemove reports whether rm's args include -r, -R or --recursive.
+func recursiveRemove(args []string) bool {
+	for _, a := range args {
+		if a == "--" {
+			return false
+		}
+		if a == "--recursive" || (strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.ContainsAny(a, "rR")) {
+			return true
+		}
+	}
+	return false
+}
+
+func removeTargets(args []string) []string {
+	var targets []string
+	for i, a := range args {
+		if a == "--" {
+			return append(targets, args[i+1:]...)
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			targets = append(targets, a)
+		}
+	}
+	return targets
+}
+
+// unresolvable reports whether a path depends on the shell (home directory,
+// variables, command substitution) and so cannot be checked statically.
+func unresolvable(path string) bool {
+	return strings.HasPrefix(path, "~") || strings.ContainsAny(path, "$`")
+}
+
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// AuditLogPath returns the file the dangerous commands of runs in the
+// project at projectPath are logged to, one JSON object per line.
+func AuditLogPath(projectPath string) string {
+	return filepath.Join(projectPath, ".index", "audit.log")
+}
+
+// AuditEntry records a dangerous command the executor refused or, with
+// the run's override, ran.
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Workdir string    `json:"workdir"`
+	Reason  string    `json:"reason"`
+	Allowed bool      `json:"allowed"`
+	DryRun  bool      `json:"dry_run,omitempty"`
+}
+
+// guardCommand refuses a dangerous command unless the run allows them, and
+// logs the decision to the audit log. An allowed command whose decision
+// cannot be logged is refused too.
+func (e *Executor) guardCommand(command, workdir string) error {
+	reason := dangerousCommand(e.projectRoot, e.abs(workdir), command)
+	if reason == "" {
+		return nil
+	}
+
+	err := e.audit(AuditEntry{
+		Time:    time.Now().UTC(),
+		Command: command,
+		Workdir: workdir,
+		Reason:  reason,
+		Allowed: e.allowDangerous,
+		DryRun:  e.dryRun,
+	})
+	if !e.allowDangerous {
+		return fmt.Errorf("refused dangerous command: %s; rerun with -allow-dangerous to permit it", reason)
+	}
+	if err != nil {
+		return fmt.Errorf("refused dangerous command: audit log: %w", err)
+	}
+	log.Printf("WARNING: running dangerous command allowed by -allow-dangerous (%s): %s", reason, command)
+	return nil
+}
+
+func (e *Executor) audit(entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	path := AuditLogPath(e.projectRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
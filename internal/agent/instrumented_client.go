@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/yourorg/agent/internal/metrics"
+	"github.com/yourorg/agent/internal/tracing"
+)
+
+// instrumentedClient records latency, token usage and errors for every chat request.
+type instrumentedClient struct {
+	LLMClient
+}
+
+func (c *instrumentedClient) Chat(ctx context.Context, messages []Message) (*LLMResponse, error) {
+	ctx, span := tracing.Start(ctx, "llm.chat",
+		attribute.String("gen_ai.system", c.GetProvider()),
+		attribute.String("gen_ai.request.model", c.GetModel()),
+		attribute.Int("llm.messages", len(messages)),
+	)
+
+	start := time.Now()
+	resp, err := c.LLMClient.Chat(ctx, messages)
+	metrics.ObserveSince(metrics.LLMLatency, start, c.GetProvider(), c.GetModel())
+	if err != nil {
+		metrics.LLMErrors.Inc(c.GetProvider(), c.GetModel())
+		tracing.End(span, err)
+		return nil, err
+	}
+	metrics.LLMTokens.Add(float64(resp.TokensUsed), c.GetProvider(), c.GetModel())
+	span.SetAttributes(
+		attribute.String("gen_ai.response.model", resp.Model),
+		attribute.Int("gen_ai.usage.total_tokens", resp.TokensUsed),
+	)
+	tracing.End(span, nil)
+	if usage, ok := ctx.Value(tokenUsageKey{}).(*atomic.Int64); ok {
+		usage.Add(int64(resp.TokensUsed))
+	}
+	return resp, nil
+}
+
+type tokenUsageKey struct{}
+
+// withTokenUsage returns a context whose LLM calls add their token counts to the returned counter.
+func withTokenUsage(ctx context.Context) (context.Context, *atomic.Int64) {
+	usage := new(atomic.Int64)
+	return context.WithValue(ctx, tokenUsageKey{}, usage), usage
+}
@@ -3,6 +3,10 @@ package agent
 import (
 	"context"
 	"fmt"
+	"os"
+
+	"github.com/yourorg/agent/internal/localonly"
+	"github.com/yourorg/agent/internal/secrets"
 )
 
 // Message represents a chat message
@@ -37,24 +41,100 @@ type LLMClient interface {
 
 // LLMConfig holds configuration for LLM clients
 type LLMConfig struct {
-	Provider string // "claude", "gemini", "openai", "ollama"
+	Provider string // "claude", "gemini", "openai", "ollama", "llamacpp"
 	APIKey   string
 	Model    string
-	BaseURL  string // For custom endpoints (e.g., Ollama)
+	BaseURL  string // For custom endpoints (e.g., Ollama, llama.cpp)
+
+	// ReasoningEffort ("minimal", "low", "medium", "high") is sent to OpenAI reasoning
+	// models (o-series, gpt-5) through the Responses API.
+	ReasoningEffort string
+
+	// AllowSecrets disables redacting API keys, tokens and passwords from
+	// prompts.
+	AllowSecrets bool
+
+	// RecordPath, if set, records every request and response to this file
+	// (see RecordingClient). Defaults to $AGENT_LLM_RECORD.
+	RecordPath string
+	// ReplayPath, if set, serves responses from a recording instead of
+	// calling the provider (see ReplayClient). Defaults to $AGENT_LLM_REPLAY.
+	ReplayPath string
 }
 
 // NewLLMClient creates a new LLM client based on the provider
 func NewLLMClient(config LLMConfig) (LLMClient, error) {
+	var (
+		client LLMClient
+		err    error
+	)
+
+	if config.RecordPath == "" {
+		config.RecordPath = os.Getenv(RecordEnvVar)
+	}
+	if config.ReplayPath == "" {
+		config.ReplayPath = os.Getenv(ReplayEnvVar)
+	}
+
+	if config.ReplayPath != "" {
+		client, err = NewReplayClient(config.ReplayPath)
+		if err != nil {
+			return nil, err
+		}
+		return wrapClient(client, config), nil
+	}
+
+	if err := checkLocalOnly(config); err != nil {
+		return nil, err
+	}
+
 	switch config.Provider {
 	case "claude":
-		return NewClaudeClient(config)
+		client, err = NewClaudeClient(config)
 	case "gemini":
-		return NewGeminiClient(config)
+		client, err = NewGeminiClient(config)
 	case "openai":
-		return NewOpenAIClient(config)
+		client, err = NewOpenAIClient(config)
 	case "ollama":
-		return NewOllamaClient(config)
+		client, err = NewOllamaClient(config)
+	case "llamacpp":
+		client, err = NewLlamaCppClient(config)
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", config.Provider)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Record what the provider receives, after redaction, so recordings
+	// never hold secrets.
+	if config.RecordPath != "" {
+		client, err = NewRecordingClient(client, config.RecordPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return wrapClient(client, config), nil
+}
+
+// wrapClient adds secret redaction and instrumentation to a client.
+func wrapClient(client LLMClient, config LLMConfig) LLMClient {
+	if !config.AllowSecrets {
+		client = &redactingClient{LLMClient: client, scanner: secrets.NewScanner()}
+	}
+	return &instrumentedClient{LLMClient: client}
+}
+
+// checkLocalOnly rejects hosted providers, and local providers pointed at a
+// remote server, in local-only mode.
+func checkLocalOnly(config LLMConfig) error {
+	switch config.Provider {
+	case "ollama", "llamacpp":
+		if config.BaseURL != "" {
+			return localonly.CheckURL(config.Provider+" provider", config.BaseURL)
+		}
+		return nil
+	default:
+		return localonly.Check(fmt.Sprintf("provider %q (use ollama or llamacpp)", config.Provider))
+	}
 }
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/yourorg/agent/internal/proxy"
+	"github.com/yourorg/agent/internal/ratelimit"
 )
 
 // OllamaClient implements LLMClient for local Ollama models
@@ -31,7 +34,7 @@ func NewOllamaClient(config LLMConfig) (*OllamaClient, error) {
 	return &OllamaClient{
 		model:   model,
 		baseURL: baseURL,
-		client:  &http.Client{},
+		client:  proxy.Client("ollama", 0),
 	}, nil
 }
 
@@ -77,6 +80,10 @@ func (o *OllamaClient) Chat(ctx context.Context, messages []Message) (*LLMRespon
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	if err := ratelimit.Wait(ctx, "ollama"); err != nil {
+		return nil, fmt.Errorf("rate limit wait: %w", err)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -7,14 +7,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+
+	"github.com/yourorg/agent/internal/proxy"
+	"github.com/yourorg/agent/internal/ratelimit"
 )
 
 // OpenAIClient implements LLMClient for OpenAI API
 type OpenAIClient struct {
-	apiKey  string
-	model   string
-	baseURL string
-	client  *http.Client
+	provider        string
+	apiKey          string
+	model           string
+	baseURL         string
+	reasoningEffort string
+	client          *http.Client
 }
 
 // NewOpenAIClient creates a new OpenAI API client
@@ -33,14 +39,66 @@ func NewOpenAIClient(config LLMConfig) (*OpenAIClient, error) {
 		baseURL = "https://api.openai.com/v1"
 	}
 
+	switch config.ReasoningEffort {
+	case "", "minimal", "low", "medium", "high":
+	default:
+		return nil, fmt.Errorf("invalid reasoning effort %q (want minimal, low, medium or high)", config.ReasoningEffort)
+	}
+
 	return &OpenAIClient{
-		apiKey:  config.APIKey,
-		model:   model,
-		baseURL: baseURL,
-		client:  &http.Client{},
+		provider:        "openai",
+		apiKey:          config.APIKey,
+		model:           model,
+		baseURL:         baseURL,
+		reasoningEffort: config.ReasoningEffort,
+		client:          proxy.Client("openai", 0),
 	}, nil
 }
 
+// NewLlamaCppClient creates a client for a llama.cpp server (llama-server),
+// which serves the OpenAI chat completions API locally. No API key is
+// needed unless the server was started with --api-key.
+func NewLlamaCppClient(config LLMConfig) (*OpenAIClient, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = "http://localhost:8080/v1"
+	}
+	if config.APIKey == "" {
+		config.APIKey = "no-key"
+	}
+	if config.Model == "" {
+		config.Model = "default" // llama-server serves whichever model it loaded
+	}
+	if config.ReasoningEffort != "" {
+		return nil, fmt.Errorf("reasoning effort is not supported by llama.cpp")
+	}
+	client, err := NewOpenAIClient(config)
+	if err != nil {
+		return nil, err
+	}
+	client.provider = "llamacpp"
+	client.client = proxy.Client("llamacpp", 0)
+	return client, nil
+}
+
+// usesResponsesAPI reports whether requests go to /responses rather than
+// /chat/completions. Reasoning models only expose reasoning effort (and
+// some are only served) through the Responses API.
+func (o *OpenAIClient) usesResponsesAPI() bool {
+	if o.provider != "openai" {
+		return false
+	}
+	return o.reasoningEffort != "" || isOpenAIReasoningModel(o.model)
+}
+
+func isOpenAIReasoningModel(model string) bool {
+	for _, prefix := range []string{"o1", "o3", "o4", "gpt-5"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 type openAIRequest struct {
 	Model    string          `json:"model"`
 	Messages []openAIMessage `json:"messages"`
@@ -73,6 +131,10 @@ type openAIResponse struct {
 
 // Chat sends a chat request to OpenAI API
 func (o *OpenAIClient) Chat(ctx context.Context, messages []Message) (*LLMResponse, error) {
+	if o.usesResponsesAPI() {
+		return o.respond(ctx, messages)
+	}
+
 	var openAIMessages []openAIMessage
 	for _, msg := range messages {
 		openAIMessages = append(openAIMessages, openAIMessage{
@@ -86,12 +148,142 @@ func (o *OpenAIClient) Chat(ctx context.Context, messages []Message) (*LLMRespon
 		Messages: openAIMessages,
 	}
 
-	jsonData, err := json.Marshal(reqBody)
+	body, err := o.post(ctx, "/chat/completions", reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var openAIResp openAIResponse
+	if err := json.Unmarshal(body, &openAIResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	var content string
+	var finishReason string
+	if len(openAIResp.Choices) > 0 {
+		content = openAIResp.Choices[0].Message.Content
+		finishReason = openAIResp.Choices[0].FinishReason
+	}
+
+	return &LLMResponse{
+		Content:      content,
+		Provider:     o.provider,
+		Model:        openAIResp.Model,
+		TokensUsed:   openAIResp.Usage.TotalTokens,
+		FinishReason: finishReason,
+	}, nil
+}
+
+// openAIResponsesRequest is the body of a Responses API call.
+type openAIResponsesRequest struct {
+	Model        string           `json:"model"`
+	Instructions string           `json:"instructions,omitempty"`
+	Input        []openAIMessage  `json:"input"`
+	Reasoning    *openAIReasoning `json:"reasoning,omitempty"`
+	Store        *bool            `json:"store,omitempty"`
+}
+
+type openAIReasoning struct {
+	Effort string `json:"effort,omitempty"`
+}
+
+type openAIResponsesResponse struct {
+	ID     string `json:"id"`
+	Model  string `json:"model"`
+	Status string `json:"status"` // "completed", "incomplete", ...
+	Output []struct {
+		Type    string `json:"type"` // "message", "reasoning", ...
+		Content []struct {
+			Type string `json:"type"` // "output_text", "refusal"
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"output"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// respond sends the conversation to the Responses API. System messages
+// become the request's instructions; reasoning tokens are billed as output
+// and included in TokensUsed.
+func (o *OpenAIClient) respond(ctx context.Context, messages []Message) (*LLMResponse, error) {
+	store := false
+	reqBody := openAIResponsesRequest{
+		Model: o.model,
+		Store: &store,
+	}
+	if o.reasoningEffort != "" {
+		reqBody.Reasoning = &openAIReasoning{Effort: o.reasoningEffort}
+	}
+
+	var instructions []string
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			instructions = append(instructions, msg.Content)
+			continue
+		}
+		reqBody.Input = append(reqBody.Input, openAIMessage{Role: msg.Role, Content: msg.Content})
+	}
+	reqBody.Instructions = strings.Join(instructions, "\n\n")
+
+	body, err := o.post(ctx, "/responses", reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var respBody openAIResponsesResponse
+	if err := json.Unmarshal(body, &respBody); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	var content strings.Builder
+	for _, item := range respBody.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, part := range item.Content {
+			if part.Type == "output_text" {
+				content.WriteString(part.Text)
+			}
+		}
+	}
+
+	// Map the Responses status onto chat/completions finish reasons.
+	finishReason := "stop"
+	if respBody.Status == "incomplete" {
+		finishReason = "length"
+		if respBody.IncompleteDetails != nil && respBody.IncompleteDetails.Reason == "content_filter" {
+			finishReason = "content_filter"
+		}
+	}
+
+	return &LLMResponse{
+		Content:      content.String(),
+		Provider:     o.provider,
+		Model:        respBody.Model,
+		TokensUsed:   respBody.Usage.TotalTokens,
+		FinishReason: finishReason,
+	}, nil
+}
+
+// post sends a JSON request to an OpenAI endpoint and returns the body of a
+// successful response.
+func (o *OpenAIClient) post(ctx context.Context, endpoint string, payload interface{}) ([]byte, error) {
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err := ratelimit.Wait(ctx, o.provider); err != nil {
+		return nil, fmt.Errorf("rate limit wait: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -113,30 +305,11 @@ func (o *OpenAIClient) Chat(ctx context.Context, messages []Message) (*LLMRespon
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
 	}
-
-	var openAIResp openAIResponse
-	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	var content string
-	var finishReason string
-	if len(openAIResp.Choices) > 0 {
-		content = openAIResp.Choices[0].Message.Content
-		finishReason = openAIResp.Choices[0].FinishReason
-	}
-
-	return &LLMResponse{
-		Content:      content,
-		Provider:     "openai",
-		Model:        openAIResp.Model,
-		TokensUsed:   openAIResp.Usage.TotalTokens,
-		FinishReason: finishReason,
-	}, nil
+	return body, nil
 }
 
 func (o *OpenAIClient) GetProvider() string {
-	return "openai"
+	return o.provider
 }
 
 func (o *OpenAIClient) GetModel() string {
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// PathPolicy restricts which files actions may touch. Patterns use
+// .gitignore syntax relative to the project root ("*.pem", "secrets/",
+// "/deploy/**/*.tf"), and a "!pattern" line re-allows paths matched by an
+// earlier one, including the defaults.
+type PathPolicy struct {
+	// Forbidden paths can be neither read nor modified.
+	Forbidden []string `json:"forbidden,omitempty"`
+	// ReadOnly paths can be read but not created, edited or deleted.
+	ReadOnly []string `json:"read_only,omitempty"`
+	// Protected paths can be read and edited in place, but not deleted or
+	// overwritten wholesale by create_file.
+	Protected []string `json:"protected,omitempty"`
+}
+
+// DefaultPathPolicy keeps credentials out of reach and the repository's
+// and agent's own metadata unmodified. Configured patterns are added to it.
+var DefaultPathPolicy = PathPolicy{
+	Forbidden: []string{
+		".env", ".env.*", "*.pem", "*.key", "*.p12", "*.pfx",
+		"id_rsa*", "id_dsa*", "id_ecdsa*", "id_ed25519*",
+		".netrc", ".npmrc", ".pypirc", "credentials.json", "secrets/",
+	},
+	ReadOnly: []string{".git/", ".index/", ".indexer.json"},
+}
+
+// pathAccess is the kind of access an action needs to a file.
+type pathAccess int
+
+const (
+	accessRead pathAccess = iota
+	accessEdit
+	accessReplace // create_file over an existing file, or delete_file
+)
+
+// pathRules is a compiled PathPolicy.
+type pathRules struct {
+	forbidden *ignore.GitIgnore
+	readOnly  *ignore.GitIgnore
+	protected *ignore.GitIgnore
+}
+
+func compilePathPolicy(policy PathPolicy) pathRules {
+	compile := func(defaults, extra []string) *ignore.GitIgnore {
+		lines := append(append([]string(nil), defaults...), extra...)
+		if len(lines) == 0 {
+			return nil
+		}
+		return ignore.CompileIgnoreLines(lines...)
+	}
+	return pathRules{
+		forbidden: compile(DefaultPathPolicy.Forbidden, policy.Forbidden),
+		readOnly:  compile(DefaultPathPolicy.ReadOnly, policy.ReadOnly),
+		protected: compile(DefaultPathPolicy.Protected, policy.Protected),
+	}
+}
+
+// check reports whether rel (slash-separated, project-relative) may be
+// accessed as requested.
+func (r pathRules) check(rel string, access pathAccess) error {
+	if matches(r.forbidden, rel) {
+		return fmt.Errorf("path %s is forbidden", rel)
+	}
+	if access >= accessEdit && matches(r.readOnly, rel) {
+		return fmt.Errorf("path %s is read-only", rel)
+	}
+	if access >= accessReplace && matches(r.protected, rel) {
+		return fmt.Errorf("path %s is protected: edit it in place instead", rel)
+	}
+	return nil
+}
+
+func matches(gi *ignore.GitIgnore, rel string) bool {
+	return gi != nil && gi.MatchesPath(rel)
+}
+
+// relPath resolves path against root and rejects paths outside it.
+func relPath(root, path string) (string, error) {
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(root, abs)
+	}
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(abs))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s escapes project root", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
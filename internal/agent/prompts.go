@@ -0,0 +1,261 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/yourorg/agent/internal/indexer"
+)
+
+// DefaultPromptDir is where a project keeps prompt overrides, relative to
+// its root. A file named <prompt>.tmpl there replaces the built-in template
+// of that name, e.g. .indexer/prompts/plan.tmpl.
+const DefaultPromptDir = ".indexer/prompts"
+
+// Prompt template names.
+const (
+	PromptPlanSystem    = "plan_system"    // system prompt for task planning
+	PromptPlan          = "plan"           // task breakdown request
+	PromptChatSystem    = "chat_system"    // system prompt for chat; empty by default
+	PromptChat          = "chat"           // chat message, with optional project context
+	PromptExplainSystem = "explain_system" // system prompt for explaining a symbol
+	PromptExplain       = "explain"        // request to explain a symbol
+	PromptAskSystem     = "ask_system"     // system prompt for grounded questions
+	PromptActionSystem  = "action_system"  // system prompt for the action loop
+	PromptAction        = "action"         // next-action decision for a task
+	PromptSummarize     = "summarize"      // module responsibilities for the project summary
+)
+
+// PromptData is the data every prompt template is executed with. Fields
+// that do not apply to a prompt are zero.
+type PromptData struct {
+	Task           string   // user request, chat message or task description
+	Context        string   // formatted project context
+	History        []string // summaries of the steps taken so far in the task
+	NonInteractive bool     // no human is available to answer ask_user
+	Symbol         indexer.SearchResult
+	Summary        string // project summary preamble (.index/PROJECT_SUMMARY.md)
+}
+
+// DefaultPrompts are the built-in prompt templates.
+var DefaultPrompts = map[string]string{
+	PromptPlanSystem: `You are an expert coding assistant that helps break down development tasks into actionable steps.`,
+
+	PromptPlan: `{{with .Summary}}PROJECT SUMMARY:
+{{.}}
+
+{{end}}You are a coding agent task planner. Given a user's request and project context, create a detailed task breakdown.
+
+USER REQUEST:
+{{.Task}}
+
+PROJECT CONTEXT:
+{{.Context}}
+
+Please create a detailed task breakdown in the following format:
+☐ Task 1 description
+☐ Task 2 description
+☐ Task 3 description
+...
+
+IMPORTANT:
+- Each task should be specific and actionable
+- Include file paths when relevant (e.g., "Check schemas/patient.py for field definitions")
+- Order tasks logically (investigation → implementation → testing)
+- Be concise but clear
+- Use checkbox format (☐) for pending tasks
+- Focus on the most critical tasks first
+
+Your task breakdown:`,
+
+	PromptChatSystem: ``,
+
+	PromptChat: `{{with .Summary}}PROJECT SUMMARY:
+{{.}}
+
+{{end}}{{if .Context}}PROJECT CONTEXT:
+{{.Context}}
+
+USER QUESTION:
+{{end}}{{.Task}}`,
+
+	PromptExplainSystem: `You are an expert code reviewer and educator.`,
+
+	PromptExplain: `{{with .Summary}}PROJECT SUMMARY:
+{{.}}
+
+{{end}}Please explain this code:
+
+Symbol: {{.Symbol.Name}}
+Type: {{.Symbol.Type}}
+Location: {{.Symbol.FilePath}}:{{.Symbol.Line}}
+Signature: {{.Symbol.Signature}}
+Documentation: {{.Symbol.Doc}}
+
+Provide a clear explanation of what this code does, its purpose, and how it's used.`,
+
+	PromptAskSystem: `You answer questions about a software project using ONLY the numbered sources provided.
+Rules:
+- Cite every claim with the source's location in square brackets, e.g. [internal/agent/agent.go:46-98].
+- If the sources do not contain the answer, say so plainly instead of guessing.
+- Do not propose plans, write patches, or suggest running commands unless the question asks how to do something.
+- Be concise.`,
+
+	PromptActionSystem: `You are executing a coding task. Pick and emit ONE action in JSON. Do not add commentary outside JSON.
+Secrets in files and output are shown as [REDACTED:<rule>]: never write that placeholder to a file, and leave the lines holding it unchanged.
+{{- if .NonInteractive}} No human is available: never emit ask_user; use fail if the task cannot proceed.{{end}}`,
+
+	PromptAction: `{{with .Summary}}PROJECT SUMMARY:
+{{.}}
+
+{{end}}CURRENT TASK:
+{{.Task}}
+
+PROJECT CONTEXT:
+{{.Context}}
+{{- if .History}}
+
+PREVIOUS STEPS:
+{{range .History}}- {{.}}
+{{end}}
+{{- end}}
+
+You can take exactly ONE of these actions:
+- read_file: { "type": "read_file", "path": "<relative path>" }
+- edit_file: { "type": "edit_file", "path": "<relative path>", "edits": [{ "old_text": "...", "new_text": "..." }] }
+- create_file: { "type": "create_file", "path": "<relative path>", "content": "full file content" }
+- delete_file: { "type": "delete_file", "path": "<relative path>" }
+- run_command: { "type": "run_command", "command": "<shell command>", "workdir": "<dir>", "timeout": 120 }
+- search: { "type": "search", "query": "<symbol or keyword>" }
+- ask_user: { "type": "ask_user", "question": "<clarifying question>" }
+- remember: { "type": "remember", "note": "<project convention or learning worth keeping for future tasks>", "category": "<e.g. testing, style, build>" }
+- complete: { "type": "complete", "summary": "what you accomplished" }
+- fail: { "type": "fail", "reason": "why you cannot proceed" }
+
+Use remember sparingly, for durable facts about this project (where tests live, required fixtures, error-handling style), not for task progress.
+
+Respond with a single JSON object describing the action.`,
+
+	PromptSummarize: `Below is an automatically generated summary of a software project.
+
+{{.Context}}
+
+Describe the responsibilities of the project's main modules and how they fit together, as a Markdown bullet list of at most 15 lines ("- path: what it does"). Mention only what the summary supports. Output only the list.`,
+}
+
+// SystemPrompts are per-command instructions added to the built-in (or
+// overridden) system prompts, e.g. "Always prefer minimal diffs" or "Follow
+// our error-handling conventions".
+type SystemPrompts struct {
+	All     string `json:"all,omitempty"` // added for every command
+	Plan    string `json:"plan,omitempty"`
+	Chat    string `json:"chat,omitempty"`
+	Explain string `json:"explain,omitempty"`
+	Ask     string `json:"ask,omitempty"`
+	Action  string `json:"action,omitempty"` // the run loop's action decisions
+}
+
+// extra returns the instructions for the named system prompt template.
+func (s SystemPrompts) extra(name string) []string {
+	var cmd string
+	switch name {
+	case PromptPlanSystem:
+		cmd = s.Plan
+	case PromptChatSystem:
+		cmd = s.Chat
+	case PromptExplainSystem:
+		cmd = s.Explain
+	case PromptAskSystem:
+		cmd = s.Ask
+	case PromptActionSystem:
+		cmd = s.Action
+	}
+	var parts []string
+	for _, p := range []string{s.All, cmd} {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// Prompts renders the agent's prompt templates.
+type Prompts struct {
+	templates map[string]*template.Template
+	system    SystemPrompts
+}
+
+// LoadPrompts parses the built-in templates, replacing each one that has a
+// <name>.tmpl file in dir. A missing dir yields the built-in set.
+func LoadPrompts(dir string) (*Prompts, error) {
+	p := &Prompts{templates: make(map[string]*template.Template, len(DefaultPrompts))}
+	for name, text := range DefaultPrompts {
+		if dir != "" {
+			data, err := os.ReadFile(filepath.Join(dir, name+".tmpl"))
+			if err == nil {
+				text = string(data)
+			} else if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("read prompt %s: %w", name, err)
+			}
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parse prompt %s: %w", name, err)
+		}
+		p.templates[name] = tmpl
+	}
+	return p, nil
+}
+
+// defaultPrompts is the built-in set, used when no Prompts are configured.
+var defaultPrompts = func() *Prompts {
+	p, err := LoadPrompts("")
+	if err != nil {
+		panic(err)
+	}
+	return p
+}()
+
+// Render executes the named template with data.
+func (p *Prompts) Render(name string, data PromptData) (string, error) {
+	if p == nil {
+		p = defaultPrompts
+	}
+	tmpl, ok := p.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt %q", name)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("render prompt %s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// RenderSystem renders the named system prompt template followed by the
+// configured instructions for it.
+func (p *Prompts) RenderSystem(name string, data PromptData) (string, error) {
+	prompt, err := p.Render(name, data)
+	if err != nil {
+		return "", err
+	}
+	if p == nil {
+		return prompt, nil
+	}
+	parts := p.system.extra(name)
+	if prompt = strings.TrimSpace(prompt); prompt != "" {
+		parts = append([]string{prompt}, parts...)
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// WithSystemPrompts returns a copy of p that adds s to its system prompts.
+func (p *Prompts) WithSystemPrompts(s SystemPrompts) *Prompts {
+	if p == nil {
+		p = defaultPrompts
+	}
+	return &Prompts{templates: p.templates, system: s}
+}
@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/yourorg/agent/internal/metrics"
+	"github.com/yourorg/agent/internal/secrets"
+)
+
+// redactingClient strips secrets from every message before it reaches the
+// provider. Prompts carry file contents, retrieved chunks and command
+// output, any of which may contain credentials.
+type redactingClient struct {
+	LLMClient
+	scanner *secrets.Scanner
+}
+
+func (c *redactingClient) Chat(ctx context.Context, messages []Message) (*LLMResponse, error) {
+	redacted := make([]Message, len(messages))
+	for i, m := range messages {
+		content, n := c.scanner.Redact(m.Content)
+		if n > 0 {
+			metrics.SecretsRedacted.Add(float64(n), "llm")
+		}
+		redacted[i] = Message{Role: m.Role, Content: content}
+	}
+	return c.LLMClient.Chat(ctx, redacted)
+}
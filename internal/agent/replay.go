@@ -0,0 +1,246 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Environment variables that turn on recording or replay for every LLM
+// client created by NewLLMClient, when LLMConfig leaves them unset.
+const (
+	RecordEnvVar = "AGENT_LLM_RECORD"
+	ReplayEnvVar = "AGENT_LLM_REPLAY"
+)
+
+// ErrNoRecording is returned by a replay client for a request that is not
+// in its recording.
+var ErrNoRecording = errors.New("no recorded response for request")
+
+// recordedCall is one line of a recording: a request and its outcome.
+type recordedCall struct {
+	Messages []recordedMessage `json:"messages"`
+	Response *recordedResponse `json:"response,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+type recordedMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type recordedResponse struct {
+	Content      string `json:"content"`
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`
+	TokensUsed   int    `json:"tokens_used"`
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+func toRecorded(messages []Message) []recordedMessage {
+	out := make([]recordedMessage, len(messages))
+	for i, m := range messages {
+		out[i] = recordedMessage{Role: m.Role, Content: m.Content}
+	}
+	return out
+}
+
+// requestKey identifies a request by the hash of its messages.
+func requestKey(messages []recordedMessage) string {
+	data, _ := json.Marshal(messages)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordingClient passes requests to another client and appends each
+// request and response to a JSON Lines file for later replay.
+type RecordingClient struct {
+	LLMClient
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecordingClient records client's traffic to path, replacing any
+// existing recording.
+func NewRecordingClient(client LLMClient, path string) (*RecordingClient, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create recording: %w", err)
+	}
+	return &RecordingClient{LLMClient: client, file: f}, nil
+}
+
+func (c *RecordingClient) Chat(ctx context.Context, messages []Message) (*LLMResponse, error) {
+	resp, err := c.LLMClient.Chat(ctx, messages)
+	// Cancellations say nothing about the provider; don't replay them.
+	if ctx.Err() != nil {
+		return resp, err
+	}
+
+	call := recordedCall{Messages: toRecorded(messages)}
+	if err != nil {
+		call.Error = err.Error()
+	} else {
+		call.Response = &recordedResponse{
+			Content:      resp.Content,
+			Provider:     resp.Provider,
+			Model:        resp.Model,
+			TokensUsed:   resp.TokensUsed,
+			FinishReason: resp.FinishReason,
+		}
+	}
+	if werr := c.write(call); werr != nil {
+		return nil, werr
+	}
+	return resp, err
+}
+
+func (c *RecordingClient) write(call recordedCall) error {
+	line, err := json.Marshal(call)
+	if err != nil {
+		return fmt.Errorf("encode recording: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write recording: %w", err)
+	}
+	return nil
+}
+
+// Close closes the recording file.
+func (c *RecordingClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.Close()
+}
+
+// ReplayClient serves responses from a recording made by RecordingClient,
+// so the planner and action loop can be tested without a provider or API
+// key.
+type ReplayClient struct {
+	mu       sync.Mutex
+	calls    []recordedCall
+	used     []bool
+	byKey    map[string][]int // indexes into calls, in recorded order
+	next     int
+	provider string
+	model    string
+	// Sequential serves responses in recorded order, ignoring request
+	// contents. Use it when prompts legitimately vary between runs (e.g.
+	// they embed temporary paths).
+	Sequential bool
+}
+
+// NewReplayClient loads the recording at path. By default each request is
+// matched to a recorded one with identical messages; repeated requests get
+// their recorded responses in order.
+func NewReplayClient(path string) (*ReplayClient, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open recording: %w", err)
+	}
+	defer f.Close()
+
+	c := &ReplayClient{byKey: make(map[string][]int), provider: "replay"}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var call recordedCall
+		if err := json.Unmarshal(sc.Bytes(), &call); err != nil {
+			return nil, fmt.Errorf("parse %s line %d: %w", path, line, err)
+		}
+		key := requestKey(call.Messages)
+		c.byKey[key] = append(c.byKey[key], len(c.calls))
+		c.calls = append(c.calls, call)
+		if call.Response != nil && c.model == "" {
+			c.provider, c.model = call.Response.Provider, call.Response.Model
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read recording: %w", err)
+	}
+	c.used = make([]bool, len(c.calls))
+	return c, nil
+}
+
+func (c *ReplayClient) Chat(ctx context.Context, messages []Message) (*LLMResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := -1
+	if c.Sequential {
+		for c.next < len(c.calls) && c.used[c.next] {
+			c.next++
+		}
+		if c.next < len(c.calls) {
+			i = c.next
+		}
+	} else {
+		for _, j := range c.byKey[requestKey(toRecorded(messages))] {
+			if !c.used[j] {
+				i = j
+				break
+			}
+		}
+	}
+	if i < 0 {
+		return nil, ErrNoRecording
+	}
+	c.used[i] = true
+
+	call := c.calls[i]
+	if call.Error != "" {
+		return nil, errors.New(call.Error)
+	}
+	if call.Response == nil {
+		return nil, fmt.Errorf("recorded call %d has no response", i+1)
+	}
+	r := call.Response
+	return &LLMResponse{
+		Content:      r.Content,
+		Provider:     r.Provider,
+		Model:        r.Model,
+		TokensUsed:   r.TokensUsed,
+		FinishReason: r.FinishReason,
+	}, nil
+}
+
+// Remaining returns the number of recorded responses not yet served.
+func (c *ReplayClient) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, u := range c.used {
+		if !u {
+			n++
+		}
+	}
+	return n
+}
+
+// GetProvider returns the provider the recording was made with.
+func (c *ReplayClient) GetProvider() string {
+	return c.provider
+}
+
+// GetModel returns the model the recording was made with.
+func (c *ReplayClient) GetModel() string {
+	return c.model
+}
+
+func (c *ReplayClient) SupportsStreaming() bool {
+	return false
+}
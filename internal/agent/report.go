@@ -0,0 +1,359 @@
+package agent
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportFormat selects the machine-readable format of a run report.
+type ReportFormat string
+
+const (
+	ReportJSON  ReportFormat = "json"
+	ReportJUnit ReportFormat = "junit"
+	ReportSARIF ReportFormat = "sarif"
+)
+
+// ReportFormatForPath guesses the report format from a file extension,
+// falling back to JSON.
+func ReportFormatForPath(path string) ReportFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		return ReportJUnit
+	case ".sarif":
+		return ReportSARIF
+	default:
+		return ReportJSON
+	}
+}
+
+// ReportSchemaVersion is the version of the JSON report's schema. It is
+// bumped only when a field is removed or changes meaning; new fields may be
+// added without a bump.
+const ReportSchemaVersion = 1
+
+// RunStatus summarizes how a run ended.
+type RunStatus string
+
+const (
+	RunSucceeded  RunStatus = "succeeded"  // every task completed
+	RunFailed     RunStatus = "failed"     // a task failed
+	RunTimedOut   RunStatus = "timed_out"  // the run's timeout expired first
+	RunIncomplete RunStatus = "incomplete" // a task ran out of iterations, or nothing was planned
+)
+
+// Status returns how the run ended. A failed task takes precedence over a
+// timeout.
+func (r *RunResult) Status() RunStatus {
+	for _, exec := range r.Executions {
+		if exec.Failed {
+			return RunFailed
+		}
+	}
+	switch {
+	case r.TimedOut:
+		return RunTimedOut
+	case !r.Succeeded():
+		return RunIncomplete
+	default:
+		return RunSucceeded
+	}
+}
+
+// Succeeded reports whether every planned task completed.
+func (r *RunResult) Succeeded() bool {
+	if len(r.Executions) == 0 || r.TimedOut {
+		return false
+	}
+	for _, exec := range r.Executions {
+		if !exec.Completed {
+			return false
+		}
+	}
+	return true
+}
+
+// FilesChanged returns the distinct files changed by the run, in the order
+// they were first touched.
+func (r *RunResult) FilesChanged() []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, exec := range r.Executions {
+		for _, res := range exec.Results {
+			for _, file := range res.FilesChanged {
+				if !seen[file] {
+					seen[file] = true
+					files = append(files, file)
+				}
+			}
+		}
+	}
+	return files
+}
+
+// WriteReport writes the run result to w in the given format.
+func WriteReport(w io.Writer, format ReportFormat, result *RunResult) error {
+	switch format {
+	case ReportJSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(jsonReport{
+			SchemaVersion: ReportSchemaVersion,
+			Success:       result.Succeeded(),
+			Status:        result.Status(),
+			FilesChanged:  result.FilesChanged(),
+			RunResult:     result,
+		})
+	case ReportJUnit:
+		return writeJUnit(w, result)
+	case ReportSARIF:
+		return writeSARIF(w, result)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+// jsonReport is the JSON report: the RunResult with its summary fields.
+type jsonReport struct {
+	SchemaVersion int       `json:"schema_version"`
+	Success       bool      `json:"success"`
+	Status        RunStatus `json:"status"`
+	FilesChanged  []string  `json:"files_changed"`
+	*RunResult
+}
+
+func executionDuration(exec TaskExecution) time.Duration {
+	var total time.Duration
+	for _, res := range exec.Results {
+		total += res.Duration
+	}
+	return total
+}
+
+func executionFailure(exec TaskExecution) string {
+	if exec.FailureMsg != "" {
+		return exec.FailureMsg
+	}
+	return "task did not complete"
+}
+
+type junitTestSuite struct {
+	XMLName    xml.Name        `xml:"testsuite"`
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       float64         `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnit(w io.Writer, result *RunResult) error {
+	suite := junitTestSuite{
+		Name: "agent run",
+		Properties: []junitProperty{
+			{Name: "status", Value: string(result.Status())},
+			{Name: "provider", Value: result.Provider},
+			{Name: "model", Value: result.Model},
+			{Name: "tokens_used", Value: strconv.Itoa(result.TokensUsed)},
+			{Name: "files_changed", Value: strings.Join(result.FilesChanged(), ",")},
+		},
+	}
+	if result.Plan != nil {
+		suite.Name = result.Plan.UserPrompt
+	}
+
+	for _, exec := range result.Executions {
+		elapsed := executionDuration(exec).Seconds()
+		tc := junitTestCase{
+			Name:      fmt.Sprintf("Task %d: %s", exec.Task.ID, exec.Task.Description),
+			ClassName: "agent.task",
+			Time:      elapsed,
+			SystemOut: formatExecutionActions(exec),
+		}
+		if !exec.Completed {
+			msg := executionFailure(exec)
+			tc.Failure = &junitFailure{Message: msg, Text: msg}
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Time += elapsed
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// formatExecutionActions lists a task's actions with their command
+// output and diffs, for JUnit's system-out.
+func formatExecutionActions(exec TaskExecution) string {
+	var b strings.Builder
+	for i, act := range exec.Actions {
+		res := exec.Results[i]
+		target := act.Path
+		if act.Type == ActionRunCommand {
+			target = act.Command
+		}
+		fmt.Fprintf(&b, "%s %s -> %t\n", act.Type, target, res.Success)
+		if res.Error != "" {
+			fmt.Fprintf(&b, "  error: %s\n", res.Error)
+		}
+		if act.Type == ActionRunCommand && strings.TrimSpace(res.Output) != "" {
+			b.WriteString(indentLines(strings.TrimRight(res.Output, "\n"), "  | "))
+		}
+		if res.Diff != "" {
+			b.WriteString(indentLines(strings.TrimRight(res.Diff, "\n"), "  "))
+		}
+	}
+	return b.String()
+}
+
+func indentLines(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix) + "\n"
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// writeSARIF reports failed tasks as errors and every file a task changed
+// as a note, so code-scanning UIs can annotate the touched lines.
+func writeSARIF(w io.Writer, result *RunResult) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name: "code-agent",
+			Rules: []sarifRule{
+				{ID: "task-failed", ShortDescription: sarifMessage{Text: "Agent task did not complete"}},
+				{ID: "file-changed", ShortDescription: sarifMessage{Text: "File changed by the agent"}},
+			},
+		}},
+		Results: []sarifResult{},
+	}
+
+	for _, exec := range result.Executions {
+		if !exec.Completed {
+			res := sarifResult{
+				RuleID:  "task-failed",
+				Level:   "error",
+				Message: sarifMessage{Text: fmt.Sprintf("Task %d (%s): %s", exec.Task.ID, exec.Task.Description, executionFailure(exec))},
+			}
+			if exec.Task.FilePath != "" {
+				loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(exec.Task.FilePath)},
+				}}
+				if exec.Task.Line > 0 {
+					loc.PhysicalLocation.Region = &sarifRegion{StartLine: exec.Task.Line}
+				}
+				res.Locations = []sarifLocation{loc}
+			}
+			run.Results = append(run.Results, res)
+		}
+
+		seen := make(map[string]bool)
+		for _, actRes := range exec.Results {
+			for _, file := range actRes.FilesChanged {
+				if seen[file] {
+					continue
+				}
+				seen[file] = true
+				run.Results = append(run.Results, sarifResult{
+					RuleID:  "file-changed",
+					Level:   "note",
+					Message: sarifMessage{Text: fmt.Sprintf("Changed by task %d: %s", exec.Task.ID, exec.Task.Description)},
+					Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(file)},
+					}}},
+				})
+			}
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
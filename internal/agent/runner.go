@@ -3,10 +3,15 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/tracing"
 )
 
 // RunOptions controls the autonomous execution loop.
@@ -14,25 +19,101 @@ type RunOptions struct {
 	DryRun            bool
 	MaxIterations     int
 	MaxContextResults int
+	// NonInteractive forbids actions that wait on a human (CI mode).
+	NonInteractive bool
+	// Timeout bounds the wall-clock time of the whole run, planning
+	// included (0 means no limit). When it expires the current task is
+	// interrupted and it and the remaining tasks are left pending.
+	Timeout time.Duration
+	// ActionTimeouts overrides the executor's default timeout per action
+	// type.
+	ActionTimeouts map[ActionType]time.Duration
+	// CommandEnv controls the environment of run_command actions.
+	CommandEnv CommandEnv
+	// Paths adds project-specific rules to DefaultPathPolicy.
+	Paths PathPolicy
+	// AllowDangerous lets run_command run commands the executor refuses by
+	// default; see ExecutorConfig.AllowDangerous.
+	AllowDangerous bool
+	// ContextQueries are extra retrieval queries (e.g. from an issue) whose
+	// context is shared by every task in the run.
+	ContextQueries []string
+	// OnEvent, if set, is called synchronously as the run progresses.
+	OnEvent func(RunEvent)
+}
+
+// RunEventType identifies a step in the agent loop.
+type RunEventType string
+
+const (
+	RunEventPlan         RunEventType = "plan"
+	RunEventTaskStarted  RunEventType = "task_started"
+	RunEventAction       RunEventType = "action"
+	RunEventTaskFinished RunEventType = "task_finished"
+	RunEventDone         RunEventType = "done"
+)
+
+// RunEvent reports progress of a Run to observers such as streaming APIs.
+type RunEvent struct {
+	Type      RunEventType   `json:"type"`
+	Plan      *TaskBreakdown `json:"plan,omitempty"`
+	Task      *Task          `json:"task,omitempty"`
+	Action    *Action        `json:"action,omitempty"`
+	Result    *ActionResult  `json:"result,omitempty"`
+	Execution *TaskExecution `json:"execution,omitempty"`
+	Diffs     []FileDiff     `json:"diffs,omitempty"`
+}
+
+func (o RunOptions) emit(event RunEvent) {
+	if o.OnEvent != nil {
+		o.OnEvent(event)
+	}
 }
 
 // RunResult is returned after running the full agent loop.
 type RunResult struct {
 	Plan       *TaskBreakdown  `json:"plan"`
 	Executions []TaskExecution `json:"executions"`
+	Provider   string          `json:"provider"`
+	Model      string          `json:"model"`
+	TokensUsed int             `json:"tokens_used"`
+	// Duration is the wall-clock time of the run, planning included.
+	Duration time.Duration `json:"duration"`
+	// TimedOut is set when RunOptions.Timeout expired before every task ran.
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Diffs is the net change the run's actions made (or, in a dry run,
+	// would make) to each file.
+	Diffs []FileDiff `json:"diffs,omitempty"`
 }
 
 // Run executes the full agent loop: plan → execute tasks → report.
-func (a *CodingAgent) Run(ctx context.Context, userPrompt string, opts RunOptions) (*RunResult, error) {
+func (a *CodingAgent) Run(ctx context.Context, userPrompt string, opts RunOptions) (result *RunResult, err error) {
+	ctx, span := tracing.Start(ctx, "agent.run",
+		attribute.String("agent.prompt", tracing.Truncate(userPrompt, 512)),
+		attribute.String("llm.provider", a.llmClient.GetProvider()),
+		attribute.String("llm.model", a.llmClient.GetModel()),
+		attribute.Bool("agent.dry_run", opts.DryRun),
+	)
+	defer func() { tracing.End(span, err) }()
+	start := time.Now()
+
 	if opts.MaxIterations <= 0 {
 		opts.MaxIterations = 25
 	}
 	if opts.MaxContextResults <= 0 {
 		opts.MaxContextResults = 8
 	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	ctx, usage := withTokenUsage(ctx)
 
 	// Build or load project index once for the session.
+	_, indexSpan := tracing.Start(ctx, "agent.index", attribute.String("project.path", a.projectPath))
 	projectIndex, err := a.indexer.IndexProject(a.projectPath)
+	tracing.End(indexSpan, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to index project: %w", err)
 	}
@@ -42,25 +123,66 @@ func (a *CodingAgent) Run(ctx context.Context, userPrompt string, opts RunOption
 	if err != nil {
 		return nil, err
 	}
+	opts.emit(RunEvent{Type: RunEventPlan, Plan: plan})
 
 	executor := NewExecutor(ExecutorConfig{
-		ProjectRoot: a.projectPath,
-		Index:       projectIndex,
-		DryRun:      opts.DryRun,
+		ProjectRoot:    a.projectPath,
+		Index:          projectIndex,
+		DryRun:         opts.DryRun,
+		NonInteractive: opts.NonInteractive,
+		ActionTimeouts: opts.ActionTimeouts,
+		Env:            opts.CommandEnv,
+		Paths:          opts.Paths,
+		Memory:         a.memory,
+		AllowDangerous: opts.AllowDangerous,
 	})
 
 	contextFetcher := indexer.NewContextFetcher(projectIndex)
 	var executions []TaskExecution
 
+	var sharedContext strings.Builder
+	for _, q := range opts.ContextQueries {
+		sharedContext.WriteString(indexer.FormatContext(contextFetcher.FetchContext(q, opts.MaxContextResults)))
+		sharedContext.WriteString("\n")
+	}
+
 	for i, task := range plan.Tasks {
+		if ctx.Err() != nil {
+			// Out of time: leave this and the remaining tasks pending.
+			break
+		}
 		_ = plan.UpdateTaskStatus(task.ID, TaskStatusInProgress)
+		opts.emit(RunEvent{Type: RunEventTaskStarted, Task: &plan.Tasks[i]})
 
+		taskCtx, taskSpan := tracing.Start(ctx, "agent.task",
+			attribute.Int("task.id", task.ID),
+			attribute.String("task.description", tracing.Truncate(task.Description, 512)),
+		)
+
+		_, retrieveSpan := tracing.Start(taskCtx, "agent.retrieve")
 		taskContext := contextFetcher.FetchContext(task.Description, opts.MaxContextResults)
 		contextString := indexer.FormatContext(taskContext)
+		if sharedContext.Len() > 0 {
+			contextString += "\n\nRELATED CONTEXT:\n" + sharedContext.String()
+		}
+		contextString += a.memoryContext()
+		contextString += a.diagnosticsContext(taskCtx, taskContext.RelevantModules)
+		retrieveSpan.SetAttributes(attribute.Int("retrieve.context_bytes", len(contextString)))
+		retrieveSpan.End()
 
-		execResult := a.executeTask(ctx, executor, task, contextString, opts.MaxIterations)
+		execResult := a.executeTask(taskCtx, executor, task, contextString, opts)
 		executions = append(executions, execResult)
 
+		taskSpan.SetAttributes(
+			attribute.Int("task.actions", len(execResult.Actions)),
+			attribute.Bool("task.completed", execResult.Completed),
+		)
+		var taskErr error
+		if execResult.Failed {
+			taskErr = fmt.Errorf("%s", execResult.FailureMsg)
+		}
+		tracing.End(taskSpan, taskErr)
+
 		switch {
 		case execResult.Completed:
 			_ = plan.UpdateTaskStatus(task.ID, TaskStatusCompleted)
@@ -71,31 +193,64 @@ func (a *CodingAgent) Run(ctx context.Context, userPrompt string, opts RunOption
 		}
 
 		plan.Tasks[i].Details = fmt.Sprintf("Ran %d action(s)", len(execResult.Actions))
+		opts.emit(RunEvent{Type: RunEventTaskFinished, Task: &plan.Tasks[i], Execution: &execResult})
 	}
 
 	plan.UpdateStats()
 
-	return &RunResult{
+	result = &RunResult{
 		Plan:       plan,
 		Executions: executions,
-	}, nil
+		Provider:   a.llmClient.GetProvider(),
+		Model:      a.llmClient.GetModel(),
+		TokensUsed: int(usage.Load()),
+		Duration:   time.Since(start),
+		TimedOut:   errors.Is(ctx.Err(), context.DeadlineExceeded),
+		Diffs:      executor.Diffs(),
+	}
+	opts.emit(RunEvent{Type: RunEventDone, Plan: plan, Diffs: result.Diffs})
+
+	return result, nil
 }
 
-func (a *CodingAgent) executeTask(ctx context.Context, executor *Executor, task Task, contextString string, maxIterations int) TaskExecution {
+func (a *CodingAgent) executeTask(ctx context.Context, executor *Executor, task Task, contextString string, opts RunOptions) TaskExecution {
 	var (
 		actions []Action
 		results []ActionResult
 	)
+	maxIterations := opts.MaxIterations
+
+	data := PromptData{
+		Task:           task.Description,
+		Context:        contextString,
+		NonInteractive: opts.NonInteractive,
+		Summary:        a.projectSummary(nil),
+	}
+	systemPrompt, err := a.prompts.RenderSystem(PromptActionSystem, data)
+	if err != nil {
+		return TaskExecution{Task: task, Failed: true, FailureMsg: err.Error()}
+	}
 
 	history := make([]string, 0, maxIterations)
 
 	for i := 0; i < maxIterations; i++ {
-		prompt := buildActionDecisionPrompt(task.Description, contextString, history)
+		data.History = history
+		prompt, err := a.fitPrompt(PromptAction, &data, []Message{{Role: "system", Content: systemPrompt}})
+		if err != nil {
+			return TaskExecution{Task: task, Actions: actions, Results: results, Failed: true, FailureMsg: err.Error()}
+		}
+
+		if ctx.Err() != nil {
+			return interruptedExecution(ctx, task, actions, results)
+		}
 
 		response, err := a.llmClient.Chat(ctx, []Message{
-			{Role: "system", Content: "You are executing a coding task. Pick and emit ONE action in JSON. Do not add commentary outside JSON."},
+			{Role: "system", Content: systemPrompt},
 			{Role: "user", Content: prompt},
 		})
+		if ctx.Err() != nil {
+			return interruptedExecution(ctx, task, actions, results)
+		}
 		if err != nil {
 			return TaskExecution{Task: task, Failed: true, FailureMsg: fmt.Sprintf("llm error: %v", err)}
 		}
@@ -106,11 +261,31 @@ func (a *CodingAgent) executeTask(ctx context.Context, executor *Executor, task
 		}
 
 		actions = append(actions, action)
+		_, actionSpan := tracing.Start(ctx, "agent.action",
+			attribute.String("action.type", string(action.Type)),
+			attribute.String("action.path", action.Path),
+		)
 		result := executor.Execute(ctx, action)
+		actionSpan.SetAttributes(
+			attribute.Bool("action.success", result.Success),
+			attribute.StringSlice("action.files_changed", result.FilesChanged),
+		)
+		var actionErr error
+		if result.Error != "" {
+			actionErr = fmt.Errorf("%s", result.Error)
+		}
+		tracing.End(actionSpan, actionErr)
 		results = append(results, result)
+		if a.diagnostics != nil && len(result.FilesChanged) > 0 {
+			a.diagnostics.Invalidate()
+		}
+		opts.emit(RunEvent{Type: RunEventAction, Task: &task, Action: &action, Result: &result})
 
 		// Append brief history for the next iteration
 		history = append(history, summarizeStep(action, result))
+		if note := a.relatedTests(result.FilesChanged); note != "" {
+			history = append(history, note)
+		}
 
 		if action.Type == ActionComplete || action.Type == ActionFail {
 			return TaskExecution{
@@ -124,6 +299,9 @@ func (a *CodingAgent) executeTask(ctx context.Context, executor *Executor, task
 		}
 
 		// If execution failed hard, surface it
+		if !result.Success && ctx.Err() != nil {
+			return interruptedExecution(ctx, task, actions, results)
+		}
 		if !result.Success {
 			return TaskExecution{
 				Task:       task,
@@ -144,39 +322,19 @@ func (a *CodingAgent) executeTask(ctx context.Context, executor *Executor, task
 	}
 }
 
-func buildActionDecisionPrompt(taskDesc, contextString string, history []string) string {
-	var b strings.Builder
-
-	b.WriteString("CURRENT TASK:\n")
-	b.WriteString(taskDesc)
-	b.WriteString("\n\nPROJECT CONTEXT:\n")
-	b.WriteString(contextString)
-
-	if len(history) > 0 {
-		b.WriteString("\n\nPREVIOUS STEPS:\n")
-		for _, h := range history {
-			b.WriteString("- ")
-			b.WriteString(h)
-			b.WriteString("\n")
-		}
+// interruptedExecution records a task stopped by the run's deadline or
+// cancellation. It is neither completed nor failed, so the task stays pending.
+func interruptedExecution(ctx context.Context, task Task, actions []Action, results []ActionResult) TaskExecution {
+	reason := "run cancelled"
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		reason = "run timed out"
+	}
+	return TaskExecution{
+		Task:       task,
+		Actions:    actions,
+		Results:    results,
+		FailureMsg: reason,
 	}
-
-	b.WriteString(`
-
-You can take exactly ONE of these actions:
-- read_file: { "type": "read_file", "path": "<relative path>" }
-- edit_file: { "type": "edit_file", "path": "<relative path>", "edits": [{ "old_text": "...", "new_text": "..." }] }
-- create_file: { "type": "create_file", "path": "<relative path>", "content": "full file content" }
-- delete_file: { "type": "delete_file", "path": "<relative path>" }
-- run_command: { "type": "run_command", "command": "<shell command>", "workdir": "<dir>", "timeout": 120 }
-- search: { "type": "search", "query": "<symbol or keyword>" }
-- ask_user: { "type": "ask_user", "question": "<clarifying question>" }
-- complete: { "type": "complete", "summary": "what you accomplished" }
-- fail: { "type": "fail", "reason": "why you cannot proceed" }
-
-Respond with a single JSON object describing the action.`)
-
-	return b.String()
 }
 
 func summarizeStep(action Action, result ActionResult) string {
@@ -0,0 +1,237 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChatTurn is one message of a chat session.
+type ChatTurn struct {
+	Role    string    `json:"role"` // "user" or "assistant"
+	Content string    `json:"content"`
+	Time    time.Time `json:"time"`
+	// Context lists the project modules retrieved for a user message.
+	Context []string `json:"context,omitempty"`
+	// Model and TokensUsed describe the response for an assistant message.
+	Model      string `json:"model,omitempty"`
+	TokensUsed int    `json:"tokens_used,omitempty"`
+}
+
+// ChatSession is a multi-turn conversation with the agent. It can be saved
+// as JSON or Markdown and loaded again to continue it later or share it.
+type ChatSession struct {
+	Project    string     `json:"project"`
+	Provider   string     `json:"provider,omitempty"`
+	Model      string     `json:"model,omitempty"`
+	Started    time.Time  `json:"started"`
+	TokensUsed int        `json:"tokens_used"`
+	Turns      []ChatTurn `json:"turns"`
+}
+
+// NewChatSession starts an empty session for the project at projectPath.
+func NewChatSession(projectPath string) *ChatSession {
+	return &ChatSession{Project: projectPath, Started: time.Now().UTC(), Turns: []ChatTurn{}}
+}
+
+// messages returns the conversation so far as LLM messages. Earlier turns
+// are sent without the context retrieved for them.
+func (s *ChatSession) messages() []Message {
+	var messages []Message
+	for _, t := range s.Turns {
+		messages = append(messages, Message{Role: t.Role, Content: t.Content})
+	}
+	return messages
+}
+
+func (s *ChatSession) record(userMessage string, context []string, resp *LLMResponse) {
+	now := time.Now().UTC()
+	s.Turns = append(s.Turns,
+		ChatTurn{Role: "user", Content: userMessage, Time: now, Context: context},
+		ChatTurn{Role: "assistant", Content: resp.Content, Time: now, Model: resp.Model, TokensUsed: resp.TokensUsed},
+	)
+	s.Provider, s.Model = resp.Provider, resp.Model
+	s.TokensUsed += resp.TokensUsed
+}
+
+// Save writes the session to path: Markdown for a .md file, JSON otherwise.
+func (s *ChatSession) Save(path string) error {
+	var data []byte
+	if isMarkdown(path) {
+		data = []byte(s.Markdown())
+	} else {
+		var err error
+		if data, err = json.MarshalIndent(s, "", "  "); err != nil {
+			return fmt.Errorf("encode session: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+	return nil
+}
+
+// LoadChatSession reads a session saved by Save, as Markdown for a .md file
+// or JSON otherwise.
+func LoadChatSession(path string) (*ChatSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+	if isMarkdown(path) {
+		s, err := ParseChatMarkdown(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		return s, nil
+	}
+	s := &ChatSession{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func isMarkdown(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// Markdown headings and metadata used by Markdown and ParseChatMarkdown.
+const (
+	sessionTitle     = "# Chat session"
+	userHeading      = "## User"
+	assistantHeading = "## Assistant"
+	turnMetaPrefix   = "<!-- turn: "
+)
+
+// Markdown renders the session for reading or sharing. Turn metadata is
+// kept in HTML comments so ParseChatMarkdown can restore the session.
+func (s *ChatSession) Markdown() string {
+	var b strings.Builder
+	b.WriteString(sessionTitle + "\n\n")
+	fmt.Fprintf(&b, "- Project: %s\n", s.Project)
+	if s.Provider != "" {
+		fmt.Fprintf(&b, "- Provider: %s\n", s.Provider)
+	}
+	if s.Model != "" {
+		fmt.Fprintf(&b, "- Model: %s\n", s.Model)
+	}
+	fmt.Fprintf(&b, "- Started: %s\n", s.Started.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Tokens: %d\n", s.TokensUsed)
+
+	for _, t := range s.Turns {
+		heading := userHeading
+		if t.Role == "assistant" {
+			heading = assistantHeading
+		}
+		meta := map[string]interface{}{"time": t.Time.Format(time.RFC3339)}
+		if len(t.Context) > 0 {
+			meta["context"] = t.Context
+		}
+		if t.Model != "" {
+			meta["model"] = t.Model
+		}
+		if t.TokensUsed > 0 {
+			meta["tokens_used"] = t.TokensUsed
+		}
+		metaJSON, _ := json.Marshal(meta)
+
+		fmt.Fprintf(&b, "\n%s\n%s%s -->\n\n", heading, turnMetaPrefix, metaJSON)
+		if len(t.Context) > 0 {
+			fmt.Fprintf(&b, "_Context: %s_\n\n", strings.Join(t.Context, ", "))
+		}
+		b.WriteString(strings.TrimSpace(t.Content) + "\n")
+	}
+	return b.String()
+}
+
+// ParseChatMarkdown restores a session rendered by Markdown. Hand-written
+// files with just "## User" and "## Assistant" sections are accepted too.
+func ParseChatMarkdown(text string) (*ChatSession, error) {
+	s := &ChatSession{Turns: []ChatTurn{}}
+	var current *ChatTurn
+	var body []string
+	titled := false
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		content := strings.TrimSpace(strings.Join(body, "\n"))
+		if len(current.Context) > 0 {
+			// Drop the rendered context line; it is restored from the metadata.
+			content = strings.TrimSpace(strings.TrimPrefix(content, "_Context: "+strings.Join(current.Context, ", ")+"_"))
+		}
+		current.Content = content
+		s.Turns = append(s.Turns, *current)
+		current, body = nil, nil
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(text))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == userHeading || line == assistantHeading:
+			flush()
+			current = &ChatTurn{Role: "user"}
+			if line == assistantHeading {
+				current.Role = "assistant"
+			}
+		case current != nil && len(body) == 0 && strings.HasPrefix(line, turnMetaPrefix):
+			var meta struct {
+				Time       time.Time `json:"time"`
+				Context    []string  `json:"context"`
+				Model      string    `json:"model"`
+				TokensUsed int       `json:"tokens_used"`
+			}
+			raw := strings.TrimSuffix(strings.TrimPrefix(line, turnMetaPrefix), " -->")
+			if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+				return nil, fmt.Errorf("invalid turn metadata %q: %w", line, err)
+			}
+			current.Time, current.Context = meta.Time, meta.Context
+			current.Model, current.TokensUsed = meta.Model, meta.TokensUsed
+		case current != nil:
+			body = append(body, line)
+		case line == sessionTitle:
+			titled = true
+		default:
+			parseSessionHeader(s, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	if len(s.Turns) == 0 && !titled {
+		return nil, fmt.Errorf("no %q or %q sections found", userHeading, assistantHeading)
+	}
+	return s, nil
+}
+
+func parseSessionHeader(s *ChatSession, line string) {
+	key, value, ok := strings.Cut(strings.TrimPrefix(line, "- "), ": ")
+	if !ok || !strings.HasPrefix(line, "- ") {
+		return
+	}
+	value = strings.TrimSpace(value)
+	switch key {
+	case "Project":
+		s.Project = value
+	case "Provider":
+		s.Provider = value
+	case "Model":
+		s.Model = value
+	case "Started":
+		s.Started, _ = time.Parse(time.RFC3339, value)
+	case "Tokens":
+		s.TokensUsed, _ = strconv.Atoi(value)
+	}
+}
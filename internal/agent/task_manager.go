@@ -195,6 +195,58 @@ func (tm *TaskManager) FormatAsChecklist(breakdown *TaskBreakdown) string {
 	return b.String()
 }
 
+// FormatExecutionLog renders the actions and results of each executed task.
+func (tm *TaskManager) FormatExecutionLog(executions []TaskExecution) string {
+	var b strings.Builder
+	b.WriteString("Execution log:\n")
+	for _, e := range executions {
+		status := "pending"
+		switch {
+		case e.Completed:
+			status = "done"
+		case e.Failed:
+			status = "failed"
+		}
+		b.WriteString(fmt.Sprintf("- %s [%s]\n", e.Task.Description, status))
+		for i, act := range e.Actions {
+			res := e.Results[i]
+			out := strings.TrimSpace(res.Output)
+			if len(out) > 160 {
+				out = out[:160] + "..."
+			}
+			b.WriteString(fmt.Sprintf("  • %s %s -> %t\n", act.Type, act.Path, res.Success))
+			if out != "" {
+				b.WriteString("    " + out + "\n")
+			}
+			if res.Error != "" {
+				b.WriteString("    error: " + res.Error + "\n")
+			}
+		}
+		if e.FailureMsg != "" {
+			b.WriteString("  failure: " + e.FailureMsg + "\n")
+		}
+	}
+	return b.String()
+}
+
+// FormatDiffs renders the changes a dry run would make, one unified diff per
+// file.
+func (tm *TaskManager) FormatDiffs(diffs []FileDiff) string {
+	if len(diffs) == 0 {
+		return "Dry-run changes: none\n"
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Dry-run changes (%d file(s)):\n", len(diffs)))
+	for _, d := range diffs {
+		b.WriteString(fmt.Sprintf("  %s %s\n", d.Status, d.Path))
+	}
+	for _, d := range diffs {
+		b.WriteString("\n")
+		b.WriteString(d.Diff)
+	}
+	return b.String()
+}
+
 // FormatAsJSON formats the task breakdown as JSON
 func (tm *TaskManager) FormatAsJSON(breakdown *TaskBreakdown) (string, error) {
 	data, err := json.MarshalIndent(breakdown, "", "  ")
@@ -204,29 +256,9 @@ func (tm *TaskManager) FormatAsJSON(breakdown *TaskBreakdown) (string, error) {
 	return string(data), nil
 }
 
-// GenerateTaskPrompt generates a prompt for the LLM to create a task breakdown
+// GenerateTaskPrompt generates a prompt for the LLM to create a task
+// breakdown, using the built-in plan template.
 func (tm *TaskManager) GenerateTaskPrompt(userPrompt, projectContext string) string {
-	return fmt.Sprintf(`You are a coding agent task planner. Given a user's request and project context, create a detailed task breakdown.
-
-USER REQUEST:
-%s
-
-PROJECT CONTEXT:
-%s
-
-Please create a detailed task breakdown in the following format:
-☐ Task 1 description
-☐ Task 2 description
-☐ Task 3 description
-...
-
-IMPORTANT:
-- Each task should be specific and actionable
-- Include file paths when relevant (e.g., "Check schemas/patient.py for field definitions")
-- Order tasks logically (investigation → implementation → testing)
-- Be concise but clear
-- Use checkbox format (☐) for pending tasks
-- Focus on the most critical tasks first
-
-Your task breakdown:`, userPrompt, projectContext)
+	prompt, _ := defaultPrompts.Render(PromptPlan, PromptData{Task: userPrompt, Context: projectContext})
+	return prompt
 }
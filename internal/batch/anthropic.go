@@ -0,0 +1,199 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/yourorg/agent/internal/proxy"
+)
+
+const anthropicVersion = "2023-06-01"
+
+// Anthropic uses the Message Batches API.
+type Anthropic struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
+}
+
+// NewAnthropic creates an Anthropic batch client.
+func NewAnthropic(cfg Config) *Anthropic {
+	model := cfg.Model
+	if model == "" {
+		model = "claude-haiku-4-5"
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com/v1"
+	}
+	return &Anthropic{
+		apiKey:  cfg.APIKey,
+		model:   model,
+		baseURL: baseURL,
+		client:  proxy.Client("claude", 5*time.Minute),
+	}
+}
+
+type anthropicBatchRequest struct {
+	CustomID string          `json:"custom_id"`
+	Params   anthropicParams `json:"params"`
+}
+
+type anthropicParams struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicBatch struct {
+	ID               string `json:"id"`
+	ProcessingStatus string `json:"processing_status"` // in_progress, canceling, ended
+	RequestCounts    struct {
+		Processing int `json:"processing"`
+		Succeeded  int `json:"succeeded"`
+		Errored    int `json:"errored"`
+		Canceled   int `json:"canceled"`
+		Expired    int `json:"expired"`
+	} `json:"request_counts"`
+	ResultsURL string `json:"results_url"`
+}
+
+type anthropicResultLine struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string `json:"type"` // succeeded, errored, canceled, expired
+		Message struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+			Usage struct {
+				InputTokens  int `json:"input_tokens"`
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+		} `json:"message"`
+		Error json.RawMessage `json:"error"`
+	} `json:"result"`
+}
+
+// Submit implements Provider.
+func (a *Anthropic) Submit(ctx context.Context, requests []Request) (string, error) {
+	body := struct {
+		Requests []anthropicBatchRequest `json:"requests"`
+	}{}
+	for _, r := range requests {
+		body.Requests = append(body.Requests, anthropicBatchRequest{
+			CustomID: r.ID,
+			Params: anthropicParams{
+				Model:     a.model,
+				MaxTokens: maxTokens(r),
+				System:    r.System,
+				Messages:  []anthropicMessage{{Role: "user", Content: r.Prompt}},
+			},
+		})
+	}
+
+	var batch anthropicBatch
+	if err := doJSON(ctx, a.client, "POST", a.baseURL+"/messages/batches", a.headers(), body, &batch); err != nil {
+		return "", fmt.Errorf("failed to create batch: %w", err)
+	}
+	return batch.ID, nil
+}
+
+// Status implements Provider.
+func (a *Anthropic) Status(ctx context.Context, id string) (*Status, error) {
+	batch, err := a.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	counts := batch.RequestCounts
+	failed := counts.Errored + counts.Canceled + counts.Expired
+	return &Status{
+		ID:        batch.ID,
+		State:     batch.ProcessingStatus,
+		Done:      batch.ProcessingStatus == "ended",
+		Total:     counts.Processing + counts.Succeeded + failed,
+		Succeeded: counts.Succeeded,
+		Failed:    failed,
+	}, nil
+}
+
+// Results implements Provider.
+func (a *Anthropic) Results(ctx context.Context, id string) ([]Result, error) {
+	batch, err := a.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.ResultsURL == "" {
+		return nil, fmt.Errorf("batch %s has no results yet (status %s)", id, batch.ProcessingStatus)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", batch.ResultsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range a.headers() {
+		req.Header.Set(k, v)
+	}
+	data, err := send(a.client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download results: %w", err)
+	}
+
+	var results []Result
+	err = decodeLines(data, func(line []byte) error {
+		var l anthropicResultLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("failed to parse result line: %w", err)
+		}
+		res := Result{ID: l.CustomID}
+		if l.Result.Type == "succeeded" {
+			for _, block := range l.Result.Message.Content {
+				if block.Type == "text" {
+					res.Content += block.Text
+				}
+			}
+			res.TokensUsed = l.Result.Message.Usage.InputTokens + l.Result.Message.Usage.OutputTokens
+		} else {
+			res.Error = l.Result.Type
+			if len(l.Result.Error) > 0 {
+				res.Error += ": " + string(l.Result.Error)
+			}
+		}
+		results = append(results, res)
+		return nil
+	})
+	return results, err
+}
+
+func (a *Anthropic) get(ctx context.Context, id string) (*anthropicBatch, error) {
+	var batch anthropicBatch
+	if err := doJSON(ctx, a.client, "GET", a.baseURL+"/messages/batches/"+id, a.headers(), nil, &batch); err != nil {
+		return nil, fmt.Errorf("failed to fetch batch %s: %w", id, err)
+	}
+	return &batch, nil
+}
+
+func (a *Anthropic) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         a.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+func maxTokens(r Request) int {
+	if r.MaxTokens > 0 {
+		return r.MaxTokens
+	}
+	return 1024
+}
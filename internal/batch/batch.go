@@ -0,0 +1,181 @@
+// Package batch submits large, non-interactive LLM jobs (chunk summaries,
+// doc generation, bulk embeddings) through the Anthropic, OpenAI and Voyage
+// batch APIs, which are billed at roughly half the price of synchronous
+// requests.
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/yourorg/agent/internal/localonly"
+)
+
+// Request is one prompt in a batch. ID must be unique within the batch and
+// match ^[a-zA-Z0-9_-]{1,64}$ (the stricter Anthropic rule).
+type Request struct {
+	ID        string
+	System    string
+	Prompt    string
+	MaxTokens int
+}
+
+// Result is the outcome of one Request.
+type Result struct {
+	ID         string
+	Content    string
+	TokensUsed int
+	Error      string // non-empty if this request failed
+}
+
+// Status reports a batch's progress.
+type Status struct {
+	ID        string
+	State     string // provider-specific, e.g. "in_progress", "ended", "completed"
+	Done      bool   // results (if any) can be collected
+	Total     int
+	Succeeded int
+	Failed    int
+}
+
+// Poller reports a batch's progress.
+type Poller interface {
+	// Status fetches the batch's current progress.
+	Status(ctx context.Context, id string) (*Status, error)
+}
+
+// Provider is a batch API.
+type Provider interface {
+	Poller
+	// Submit creates a batch and returns its ID.
+	Submit(ctx context.Context, requests []Request) (string, error)
+	// Results downloads the results of a finished batch.
+	Results(ctx context.Context, id string) ([]Result, error)
+}
+
+// Config selects and authenticates a provider.
+type Config struct {
+	Provider string // "claude" or "openai"; "openai" or "voyage" for embeddings
+	APIKey   string
+	Model    string
+	BaseURL  string // optional override
+	// Dimensions sets the embedding size of OpenAI text-embedding-3 and
+	// Voyage models that support several; 0 keeps the model's size.
+	Dimensions int
+}
+
+// New creates the batch client for cfg.Provider.
+func New(cfg Config) (Provider, error) {
+	if err := localonly.Check("batch API"); err != nil {
+		return nil, err
+	}
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("%s API key is required", cfg.Provider)
+	}
+	switch cfg.Provider {
+	case "claude", "anthropic":
+		return NewAnthropic(cfg), nil
+	case "openai":
+		return NewOpenAI(cfg), nil
+	default:
+		return nil, fmt.Errorf("batch API not supported for provider %q (use claude or openai)", cfg.Provider)
+	}
+}
+
+// Wait polls the batch every interval until it is done or ctx ends. progress,
+// if non-nil, is called after each poll.
+func Wait(ctx context.Context, p Poller, id string, interval time.Duration, progress func(*Status)) (*Status, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := p.Status(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(status)
+		}
+		if status.Done {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// doJSON sends a request with an optional JSON body and decodes a JSON
+// response into out (which may be nil).
+func doJSON(ctx context.Context, client *http.Client, method, url string, headers map[string]string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	data, err := send(client, req)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+func send(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(data))
+	}
+	return data, nil
+}
+
+// decodeLines decodes a JSONL document, calling fn for each line.
+func decodeLines(data []byte, fn func(line []byte) error) error {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
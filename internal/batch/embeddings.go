@@ -0,0 +1,229 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/yourorg/agent/internal/localonly"
+	"github.com/yourorg/agent/internal/proxy"
+)
+
+// MaxEmbeddingRequests is the most embedding requests submitted in one
+// batch (OpenAI's per-batch limit); larger jobs are split.
+const MaxEmbeddingRequests = 50000
+
+// EmbeddingRequest is one text to embed. ID must be unique within the batch.
+type EmbeddingRequest struct {
+	ID   string
+	Text string
+}
+
+// EmbeddingResult is the outcome of one EmbeddingRequest.
+type EmbeddingResult struct {
+	ID         string
+	Embedding  []float32
+	TokensUsed int
+	Error      string // non-empty if this request failed
+}
+
+// EmbeddingProvider is a batch API for embeddings.
+type EmbeddingProvider interface {
+	Poller
+	// Submit creates a batch and returns its ID.
+	Submit(ctx context.Context, requests []EmbeddingRequest) (string, error)
+	// Results downloads the embeddings of a finished batch.
+	Results(ctx context.Context, id string) ([]EmbeddingResult, error)
+}
+
+// NewEmbeddings creates the embedding batch client for cfg.Provider.
+func NewEmbeddings(cfg Config) (EmbeddingProvider, error) {
+	if err := localonly.Check("batch API"); err != nil {
+		return nil, err
+	}
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("%s API key is required", cfg.Provider)
+	}
+	switch cfg.Provider {
+	case "openai":
+		return NewOpenAIEmbeddings(cfg), nil
+	case "voyage":
+		return NewVoyageEmbeddings(cfg), nil
+	default:
+		return nil, fmt.Errorf("embedding batch API not supported for provider %q (use openai or voyage)", cfg.Provider)
+	}
+}
+
+// OpenAIEmbeddings uses the Batch API over /v1/embeddings.
+type OpenAIEmbeddings struct {
+	api        *OpenAI
+	dimensions int
+}
+
+// NewOpenAIEmbeddings creates an OpenAI embedding batch client.
+func NewOpenAIEmbeddings(cfg Config) *OpenAIEmbeddings {
+	if cfg.Model == "" {
+		cfg.Model = "text-embedding-3-small"
+	}
+	return &OpenAIEmbeddings{api: NewOpenAI(cfg), dimensions: cfg.Dimensions}
+}
+
+type openAIEmbeddingLine struct {
+	CustomID string               `json:"custom_id"`
+	Method   string               `json:"method,omitempty"`
+	URL      string               `json:"url,omitempty"`
+	Body     openAIEmbeddingInput `json:"body"`
+}
+
+type openAIEmbeddingInput struct {
+	Model      string `json:"model,omitempty"`
+	Input      string `json:"input"`
+	Dimensions int    `json:"dimensions,omitempty"`
+}
+
+type embeddingResultLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int `json:"status_code"`
+		Body       struct {
+			Data []struct {
+				Embedding []float32 `json:"embedding"`
+			} `json:"data"`
+			Usage struct {
+				TotalTokens int `json:"total_tokens"`
+			} `json:"usage"`
+		} `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Submit implements EmbeddingProvider.
+func (o *OpenAIEmbeddings) Submit(ctx context.Context, requests []EmbeddingRequest) (string, error) {
+	input, err := encodeEmbeddingLines(requests, func(r EmbeddingRequest) openAIEmbeddingLine {
+		return openAIEmbeddingLine{
+			CustomID: r.ID,
+			Method:   "POST",
+			URL:      "/v1/embeddings",
+			Body:     openAIEmbeddingInput{Model: o.api.model, Input: r.Text, Dimensions: o.dimensions},
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	return o.api.create(ctx, input, map[string]interface{}{
+		"endpoint":          "/v1/embeddings",
+		"completion_window": "24h",
+	})
+}
+
+// Status implements EmbeddingProvider.
+func (o *OpenAIEmbeddings) Status(ctx context.Context, id string) (*Status, error) {
+	return o.api.Status(ctx, id)
+}
+
+// Results implements EmbeddingProvider.
+func (o *OpenAIEmbeddings) Results(ctx context.Context, id string) ([]EmbeddingResult, error) {
+	return embeddingResults(ctx, o.api, id)
+}
+
+// VoyageEmbeddings uses Voyage AI's batch API, which mirrors OpenAI's file
+// and batch endpoints but takes the model in request_params.
+type VoyageEmbeddings struct {
+	api        *OpenAI
+	model      string
+	dimensions int
+}
+
+// NewVoyageEmbeddings creates a Voyage embedding batch client.
+func NewVoyageEmbeddings(cfg Config) *VoyageEmbeddings {
+	if cfg.Model == "" {
+		cfg.Model = "voyage-code-3"
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.voyageai.com/v1"
+	}
+	api := NewOpenAI(cfg)
+	api.client = proxy.Client("voyage", 5*time.Minute)
+	return &VoyageEmbeddings{api: api, model: cfg.Model, dimensions: cfg.Dimensions}
+}
+
+// Submit implements EmbeddingProvider. Texts are embedded as documents.
+func (v *VoyageEmbeddings) Submit(ctx context.Context, requests []EmbeddingRequest) (string, error) {
+	input, err := encodeEmbeddingLines(requests, func(r EmbeddingRequest) openAIEmbeddingLine {
+		return openAIEmbeddingLine{CustomID: r.ID, Body: openAIEmbeddingInput{Input: r.Text}}
+	})
+	if err != nil {
+		return "", err
+	}
+	params := map[string]interface{}{
+		"model":      v.model,
+		"input_type": "document",
+	}
+	if v.dimensions > 0 {
+		params["output_dimension"] = v.dimensions
+	}
+	return v.api.create(ctx, input, map[string]interface{}{
+		"endpoint":          "/v1/embeddings",
+		"completion_window": "12h",
+		"request_params":    params,
+	})
+}
+
+// Status implements EmbeddingProvider.
+func (v *VoyageEmbeddings) Status(ctx context.Context, id string) (*Status, error) {
+	return v.api.Status(ctx, id)
+}
+
+// Results implements EmbeddingProvider.
+func (v *VoyageEmbeddings) Results(ctx context.Context, id string) ([]EmbeddingResult, error) {
+	return embeddingResults(ctx, v.api, id)
+}
+
+func encodeEmbeddingLines(requests []EmbeddingRequest, line func(EmbeddingRequest) openAIEmbeddingLine) ([]byte, error) {
+	var input bytes.Buffer
+	enc := json.NewEncoder(&input)
+	for _, r := range requests {
+		if err := enc.Encode(line(r)); err != nil {
+			return nil, fmt.Errorf("failed to encode request %s: %w", r.ID, err)
+		}
+	}
+	return input.Bytes(), nil
+}
+
+// embeddingResults reads the output and error files of a finished
+// embedding batch.
+func embeddingResults(ctx context.Context, api *OpenAI, id string) ([]EmbeddingResult, error) {
+	var results []EmbeddingResult
+	err := api.resultLines(ctx, id, func(line []byte) error {
+		var l embeddingResultLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("failed to parse result line: %w", err)
+		}
+		res := EmbeddingResult{ID: l.CustomID}
+		switch {
+		case l.Error != nil:
+			res.Error = l.Error.Message
+		case l.Response == nil || l.Response.StatusCode != http.StatusOK:
+			res.Error = "request failed"
+			if l.Response != nil {
+				res.Error = fmt.Sprintf("request failed with status %d", l.Response.StatusCode)
+			}
+		case len(l.Response.Body.Data) == 0:
+			res.Error = "empty embedding returned"
+		default:
+			res.Embedding = l.Response.Body.Data[0].Embedding
+			res.TokensUsed = l.Response.Body.Usage.TotalTokens
+		}
+		results = append(results, res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
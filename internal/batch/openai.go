@@ -0,0 +1,278 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/yourorg/agent/internal/proxy"
+)
+
+// OpenAI uses the Batch API over /v1/chat/completions.
+type OpenAI struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
+}
+
+// NewOpenAI creates an OpenAI batch client.
+func NewOpenAI(cfg Config) *OpenAI {
+	model := cfg.Model
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	return &OpenAI{
+		apiKey:  cfg.APIKey,
+		model:   model,
+		baseURL: baseURL,
+		client:  proxy.Client("openai", 5*time.Minute),
+	}
+}
+
+type openAIBatchLine struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     openAIChatInput `json:"body"`
+}
+
+type openAIChatInput struct {
+	Model               string          `json:"model"`
+	Messages            []openAIMessage `json:"messages"`
+	MaxCompletionTokens int             `json:"max_completion_tokens,omitempty"`
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIBatch struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"` // validating, in_progress, finalizing, completed, failed, expired, cancelling, cancelled
+	OutputFileID  string `json:"output_file_id"`
+	ErrorFileID   string `json:"error_file_id"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+}
+
+type openAIResultLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int `json:"status_code"`
+		Body       struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+			Usage struct {
+				TotalTokens int `json:"total_tokens"`
+			} `json:"usage"`
+		} `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Submit implements Provider. The requests are uploaded as a JSONL file and
+// a batch is created over it with a 24h completion window.
+func (o *OpenAI) Submit(ctx context.Context, requests []Request) (string, error) {
+	var input bytes.Buffer
+	enc := json.NewEncoder(&input)
+	for _, r := range requests {
+		messages := []openAIMessage{{Role: "user", Content: r.Prompt}}
+		if r.System != "" {
+			messages = append([]openAIMessage{{Role: "system", Content: r.System}}, messages...)
+		}
+		line := openAIBatchLine{
+			CustomID: r.ID,
+			Method:   "POST",
+			URL:      "/v1/chat/completions",
+			Body: openAIChatInput{
+				Model:               o.model,
+				Messages:            messages,
+				MaxCompletionTokens: maxTokens(r),
+			},
+		}
+		if err := enc.Encode(line); err != nil {
+			return "", fmt.Errorf("failed to encode request %s: %w", r.ID, err)
+		}
+	}
+
+	return o.create(ctx, input.Bytes(), map[string]interface{}{
+		"endpoint":          "/v1/chat/completions",
+		"completion_window": "24h",
+	})
+}
+
+// create uploads the JSONL input and creates a batch over it with the given
+// parameters (endpoint, completion_window, ...).
+func (o *OpenAI) create(ctx context.Context, input []byte, params map[string]interface{}) (string, error) {
+	fileID, err := o.upload(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	body := map[string]interface{}{"input_file_id": fileID}
+	for k, v := range params {
+		body[k] = v
+	}
+	var batch openAIBatch
+	if err := doJSON(ctx, o.client, "POST", o.baseURL+"/batches", o.headers(), body, &batch); err != nil {
+		return "", fmt.Errorf("failed to create batch: %w", err)
+	}
+	return batch.ID, nil
+}
+
+// Status implements Provider.
+func (o *OpenAI) Status(ctx context.Context, id string) (*Status, error) {
+	batch, err := o.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	done := false
+	switch batch.Status {
+	case "completed", "failed", "expired", "cancelled":
+		done = true
+	}
+	return &Status{
+		ID:        batch.ID,
+		State:     batch.Status,
+		Done:      done,
+		Total:     batch.RequestCounts.Total,
+		Succeeded: batch.RequestCounts.Completed,
+		Failed:    batch.RequestCounts.Failed,
+	}, nil
+}
+
+// Results implements Provider. Failed requests are read from the batch's
+// error file.
+func (o *OpenAI) Results(ctx context.Context, id string) ([]Result, error) {
+	var results []Result
+	err := o.resultLines(ctx, id, func(line []byte) error {
+		var l openAIResultLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("failed to parse result line: %w", err)
+		}
+		res := Result{ID: l.CustomID}
+		switch {
+		case l.Error != nil:
+			res.Error = l.Error.Message
+		case l.Response == nil || l.Response.StatusCode != http.StatusOK:
+			res.Error = "request failed"
+			if l.Response != nil {
+				res.Error = fmt.Sprintf("request failed with status %d", l.Response.StatusCode)
+			}
+		default:
+			if len(l.Response.Body.Choices) > 0 {
+				res.Content = l.Response.Body.Choices[0].Message.Content
+			}
+			res.TokensUsed = l.Response.Body.Usage.TotalTokens
+		}
+		results = append(results, res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// resultLines downloads the output and error files of a finished batch and
+// calls fn for each JSONL line.
+func (o *OpenAI) resultLines(ctx context.Context, id string, fn func(line []byte) error) error {
+	batch, err := o.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if batch.OutputFileID == "" && batch.ErrorFileID == "" {
+		return fmt.Errorf("batch %s has no results yet (status %s)", id, batch.Status)
+	}
+
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+"/files/"+fileID+"/content", nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		for k, v := range o.headers() {
+			req.Header.Set(k, v)
+		}
+		data, err := send(o.client, req)
+		if err != nil {
+			return fmt.Errorf("failed to download file %s: %w", fileID, err)
+		}
+		if err := decodeLines(data, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upload stores the batch input as a file with purpose "batch".
+func (o *OpenAI) upload(ctx context.Context, data []byte) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.WriteField("purpose", "batch"); err != nil {
+		return "", err
+	}
+	part, err := w.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/files", &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	for k, v := range o.headers() {
+		req.Header.Set(k, v)
+	}
+
+	respData, err := send(o.client, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload batch input: %w", err)
+	}
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(respData, &file); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return file.ID, nil
+}
+
+func (o *OpenAI) get(ctx context.Context, id string) (*openAIBatch, error) {
+	var batch openAIBatch
+	if err := doJSON(ctx, o.client, "GET", o.baseURL+"/batches/"+id, o.headers(), nil, &batch); err != nil {
+		return nil, fmt.Errorf("failed to fetch batch %s: %w", id, err)
+	}
+	return &batch, nil
+}
+
+func (o *OpenAI) headers() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + o.apiKey}
+}
@@ -0,0 +1,213 @@
+// Package cache provides a bounded, concurrency-safe LRU cache.
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// LRU is a fixed-capacity cache that evicts the least recently used entry.
+// It is safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	mu       sync.RWMutex
+	capacity int
+	ll       *list.List
+	items    map[K]*list.Element
+	onEvict  func(K, V)
+	pending  map[K]*call[V] // GetOrAdd constructors in progress
+}
+
+// call is a GetOrAdd constructor run that other callers for the key wait on.
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// NewLRU creates a cache holding at most capacity entries. A capacity of
+// zero or less means unbounded. onEvict, if non-nil, is called for entries
+// removed by eviction, Remove or Purge, and for values replaced by Add.
+// Callers must not Add a value that is already cached under the same key,
+// since onEvict would then release a live value.
+func NewLRU[K comparable, V any](capacity int, onEvict func(K, V)) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[K]*list.Element),
+		onEvict:  onEvict,
+		pending:  make(map[K]*call[V]),
+	}
+}
+
+// Get returns the value for key and marks it as recently used.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*entry[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Peek returns the value for key without updating its recency.
+func (c *LRU[K, V]) Peek(key K) (V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if el, ok := c.items[key]; ok {
+		return el.Value.(*entry[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Add inserts or replaces the value for key, evicting the oldest entry if
+// the cache is full. A replaced value is passed to onEvict.
+func (c *LRU[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	evicted := c.add(key, value)
+	c.mu.Unlock()
+
+	c.notify(evicted)
+}
+
+// GetOrAdd returns the value for key, calling create to build it on a miss
+// and reporting whether it was cached. Concurrent callers for the same key
+// share a single create call, so a value is never built twice and
+// discarded without onEvict. Errors from create are not cached; if create
+// panics, the waiting callers get an error and the panic continues in the
+// caller that ran it.
+func (c *LRU[K, V]) GetOrAdd(key K, create func() (V, error)) (V, bool, error) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*entry[K, V]).value, true, nil
+	}
+	if cl, ok := c.pending[key]; ok {
+		c.mu.Unlock()
+		<-cl.done
+		return cl.value, false, cl.err
+	}
+	cl := &call[V]{done: make(chan struct{})}
+	c.pending[key] = cl
+	c.mu.Unlock()
+
+	completed := false
+	defer func() {
+		if !completed {
+			// create panicked: release the waiters before the panic
+			// goes on up this caller's stack.
+			cl.err = fmt.Errorf("cache: constructor for %v panicked", key)
+			c.mu.Lock()
+			delete(c.pending, key)
+			c.mu.Unlock()
+			close(cl.done)
+		}
+	}()
+	cl.value, cl.err = create()
+	completed = true
+
+	c.mu.Lock()
+	delete(c.pending, key)
+	var evicted []*entry[K, V]
+	if cl.err == nil {
+		evicted = c.add(key, cl.value)
+	}
+	c.mu.Unlock()
+	close(cl.done)
+
+	c.notify(evicted)
+	return cl.value, false, cl.err
+}
+
+// add inserts or replaces the value for key with the lock held, returning
+// the entries to notify.
+func (c *LRU[K, V]) add(key K, value V) []*entry[K, V] {
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		e := el.Value.(*entry[K, V])
+		old := &entry[K, V]{key: key, value: e.value}
+		e.value = value
+		return []*entry[K, V]{old}
+	}
+
+	c.items[key] = c.ll.PushFront(&entry[K, V]{key: key, value: value})
+
+	var evicted []*entry[K, V]
+	for c.capacity > 0 && c.ll.Len() > c.capacity {
+		evicted = append(evicted, c.removeElement(c.ll.Back()))
+	}
+	return evicted
+}
+
+// Remove deletes key from the cache, reporting whether it was present.
+func (c *LRU[K, V]) Remove(key K) bool {
+	c.mu.Lock()
+	el, ok := c.items[key]
+	var evicted []*entry[K, V]
+	if ok {
+		evicted = append(evicted, c.removeElement(el))
+	}
+	c.mu.Unlock()
+
+	c.notify(evicted)
+	return ok
+}
+
+// Keys returns the cached keys from most to least recently used.
+func (c *LRU[K, V]) Keys() []K {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]K, 0, c.ll.Len())
+	for el := c.ll.Front(); el != nil; el = el.Next() {
+		keys = append(keys, el.Value.(*entry[K, V]).key)
+	}
+	return keys
+}
+
+// Len returns the number of cached entries.
+func (c *LRU[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ll.Len()
+}
+
+// Purge removes all entries.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	var evicted []*entry[K, V]
+	for c.ll.Len() > 0 {
+		evicted = append(evicted, c.removeElement(c.ll.Back()))
+	}
+	c.mu.Unlock()
+
+	c.notify(evicted)
+}
+
+func (c *LRU[K, V]) removeElement(el *list.Element) *entry[K, V] {
+	c.ll.Remove(el)
+	e := el.Value.(*entry[K, V])
+	delete(c.items, e.key)
+	return e
+}
+
+// notify runs eviction callbacks outside the lock so they may call back into the cache.
+func (c *LRU[K, V]) notify(evicted []*entry[K, V]) {
+	if c.onEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		c.onEvict(e.key, e.value)
+	}
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	var evicted []string
+	c := NewLRU(2, func(k string, _ int) { evicted = append(evicted, k) })
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Get("a") // b is now the oldest
+	c.Add("c", 3)
+
+	if want := []string{"b"}; !slices.Equal(evicted, want) {
+		t.Fatalf("evicted %v, want %v", evicted, want)
+	}
+	if want := []string{"c", "a"}; !slices.Equal(c.Keys(), want) {
+		t.Errorf("Keys() = %v, want %v", c.Keys(), want)
+	}
+	// Peek does not refresh an entry.
+	c.Peek("a")
+	c.Add("d", 4)
+	if want := []string{"b", "a"}; !slices.Equal(evicted, want) {
+		t.Errorf("evicted %v, want %v", evicted, want)
+	}
+}
+
+func TestLRUNotifiesReplacedAndRemoved(t *testing.T) {
+	var evicted []int
+	c := NewLRU(0, func(_ string, v int) { evicted = append(evicted, v) })
+	c.Add("a", 1)
+	c.Add("a", 2)
+	c.Add("b", 3)
+	if !c.Remove("b") || c.Remove("b") {
+		t.Error("Remove should report only the first removal")
+	}
+	c.Purge()
+
+	if want := []int{1, 3, 2}; !slices.Equal(evicted, want) {
+		t.Errorf("evicted %v, want %v", evicted, want)
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d after Purge", c.Len())
+	}
+}
+
+func TestGetOrAddCreatesOnce(t *testing.T) {
+	c := NewLRU[string, int](0, nil)
+	var calls atomic.Int32
+	create := func() (int, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, _, err := c.GetOrAdd("k", create); err != nil || v != 42 {
+				t.Errorf("GetOrAdd = %d, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("create ran %d times, want 1", n)
+	}
+	if _, hit, _ := c.GetOrAdd("k", create); !hit {
+		t.Error("GetOrAdd missed a cached key")
+	}
+}
+
+func TestGetOrAddDoesNotCacheErrors(t *testing.T) {
+	c := NewLRU[string, int](0, nil)
+	boom := errors.New("boom")
+	if _, _, err := c.GetOrAdd("k", func() (int, error) { return 0, boom }); !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+	if c.Len() != 0 {
+		t.Fatal("a failed create was cached")
+	}
+	if v, hit, err := c.GetOrAdd("k", func() (int, error) { return 7, nil }); err != nil || hit || v != 7 {
+		t.Errorf("retry = %d, %v, %v; want 7, false, nil", v, hit, err)
+	}
+}
+
+func TestGetOrAddReleasesWaitersOnPanic(t *testing.T) {
+	c := NewLRU[string, int](0, nil)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		defer func() { recover() }()
+		c.GetOrAdd("k", func() (int, error) {
+			close(started)
+			<-release
+			panic("constructor failed")
+		})
+	}()
+	<-started
+
+	waiter := make(chan error, 1)
+	go func() {
+		_, _, err := c.GetOrAdd("k", func() (int, error) { return 1, nil })
+		waiter <- err
+	}()
+	time.Sleep(50 * time.Millisecond) // let the waiter join the pending call
+	close(release)
+
+	select {
+	case err := <-waiter:
+		if err == nil {
+			t.Error("waiter got no error from a panicked constructor")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter still blocked after the constructor panicked")
+	}
+	if v, _, err := c.GetOrAdd("k", func() (int, error) { return 2, nil }); err != nil || v != 2 {
+		t.Errorf("GetOrAdd after the panic = %d, %v; want 2, nil", v, err)
+	}
+}
@@ -0,0 +1,245 @@
+// Package callgraph expands a function's immediate callers and callees, as
+// reported by the structural index or gopls, into a transitive call graph
+// bounded by depth, so the full blast radius of a change can be seen.
+package callgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Directions accepted by Build, matching indexer.SearchEngine.SearchByCallGraph.
+const (
+	Callers = "callers"
+	Callees = "callees"
+	Both    = "both"
+)
+
+// DefaultMaxNodes bounds the size of a graph when Options.MaxNodes is unset.
+const DefaultMaxNodes = 500
+
+// Neighbors returns the functions calling (direction "callers") or called by
+// (direction "callees") the named function.
+type Neighbors func(name, direction string) ([]string, error)
+
+// Options controls a traversal.
+type Options struct {
+	Direction string // callers, callees or both (default both)
+	Depth     int    // levels to follow; 1 (the default) is immediate neighbors
+	MaxNodes  int    // stop expanding after this many nodes (default DefaultMaxNodes)
+}
+
+// Node is a function in a call tree. A function reached more than once is
+// expanded only at its shallowest occurrence; other occurrences are marked
+// Cycle (it calls back into one of its ancestors) or Repeated.
+type Node struct {
+	Name     string  `json:"name"`
+	Cycle    bool    `json:"cycle,omitempty"`
+	Repeated bool    `json:"repeated,omitempty"`
+	Children []*Node `json:"children,omitempty"`
+
+	parent *Node
+}
+
+// Edge is a call from one function to another, found Depth levels from
+// the root.
+type Edge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Depth int    `json:"depth"`
+}
+
+// Graph is the transitive call graph around Root. Callers is a tree of who
+// calls Root; Callees a tree of what Root calls.
+type Graph struct {
+	Root      string `json:"root"`
+	Direction string `json:"direction"`
+	Depth     int    `json:"depth"`
+	Callers   *Node  `json:"callers,omitempty"`
+	Callees   *Node  `json:"callees,omitempty"`
+	Edges     []Edge `json:"edges"`
+	// Truncated is set when MaxNodes stopped the traversal early.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Build traverses the call graph of root breadth-first.
+func Build(root string, neighbors Neighbors, opts Options) (*Graph, error) {
+	if opts.Direction == "" {
+		opts.Direction = Both
+	}
+	if opts.Direction != Callers && opts.Direction != Callees && opts.Direction != Both {
+		return nil, fmt.Errorf("unknown call graph direction %q (want callers, callees or both)", opts.Direction)
+	}
+	if opts.Depth < 1 {
+		opts.Depth = 1
+	}
+	if opts.MaxNodes <= 0 {
+		opts.MaxNodes = DefaultMaxNodes
+	}
+
+	g := &Graph{Root: root, Direction: opts.Direction, Depth: opts.Depth, Edges: []Edge{}}
+	var err error
+	if opts.Direction == Callers || opts.Direction == Both {
+		if g.Callers, err = g.walk(root, Callers, neighbors, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Direction == Callees || opts.Direction == Both {
+		if g.Callees, err = g.walk(root, Callees, neighbors, opts); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func (g *Graph) walk(root, direction string, neighbors Neighbors, opts Options) (*Node, error) {
+	top := &Node{Name: root}
+	expanded := map[string]bool{root: true}
+	level := []*Node{top}
+	nodes := 1
+
+	for depth := 1; depth <= opts.Depth && len(level) > 0; depth++ {
+		var next []*Node
+		for _, n := range level {
+			names, err := neighbors(n.Name, direction)
+			if err != nil {
+				return nil, fmt.Errorf("%s of %s: %w", direction, n.Name, err)
+			}
+			for _, name := range names {
+				if nodes >= opts.MaxNodes {
+					g.Truncated = true
+					return top, nil
+				}
+				nodes++
+
+				child := &Node{Name: name, parent: n}
+				n.Children = append(n.Children, child)
+				if direction == Callers {
+					g.Edges = append(g.Edges, Edge{From: name, To: n.Name, Depth: depth})
+				} else {
+					g.Edges = append(g.Edges, Edge{From: n.Name, To: name, Depth: depth})
+				}
+
+				switch {
+				case child.hasAncestor(name):
+					child.Cycle = true
+				case expanded[name]:
+					child.Repeated = true
+				default:
+					expanded[name] = true
+					next = append(next, child)
+				}
+			}
+		}
+		level = next
+	}
+	return top, nil
+}
+
+func (n *Node) hasAncestor(name string) bool {
+	for p := n.parent; p != nil; p = p.parent {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Functions returns the distinct functions in the graph other than the root.
+func (g *Graph) Functions() []string {
+	seen := map[string]bool{g.Root: true}
+	var names []string
+	for _, e := range g.Edges {
+		for _, name := range []string{e.From, e.To} {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatTree renders the graph as indented trees, one per direction.
+func (g *Graph) FormatTree() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Call graph for '%s' (%s, depth %d):\n", g.Root, g.Direction, g.Depth)
+	for _, section := range []struct {
+		title string
+		root  *Node
+	}{{"Callers", g.Callers}, {"Callees", g.Callees}} {
+		if section.root == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n%s\n", section.title, section.root.Name)
+		if len(section.root.Children) == 0 {
+			b.WriteString("  (none)\n")
+		}
+		writeChildren(&b, section.root, "")
+	}
+	b.WriteString(g.footer())
+	return b.String()
+}
+
+func writeChildren(b *strings.Builder, n *Node, indent string) {
+	for i, child := range n.Children {
+		branch, nextIndent := "├── ", indent+"│   "
+		if i == len(n.Children)-1 {
+			branch, nextIndent = "└── ", indent+"    "
+		}
+		b.WriteString(indent + branch + child.Name)
+		switch {
+		case child.Cycle:
+			b.WriteString(" (cycle)")
+		case child.Repeated:
+			b.WriteString(" (see above)")
+		}
+		b.WriteString("\n")
+		writeChildren(b, child, nextIndent)
+	}
+}
+
+// FormatEdges renders the graph as a "caller -> callee" edge list.
+func (g *Graph) FormatEdges() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Call graph for '%s' (%s, depth %d):\n\n", g.Root, g.Direction, g.Depth)
+	seen := make(map[Edge]bool)
+	for _, e := range g.Edges {
+		key := Edge{From: e.From, To: e.To}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		fmt.Fprintf(&b, "%s -> %s\n", e.From, e.To)
+	}
+	b.WriteString(g.footer())
+	return b.String()
+}
+
+// Format renders the graph as "tree" (the default), "edges" or "json".
+func (g *Graph) Format(format string) (string, error) {
+	switch format {
+	case "", "tree":
+		return g.FormatTree(), nil
+	case "edges":
+		return g.FormatEdges(), nil
+	case "json":
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+	return "", fmt.Errorf("unknown call graph format %q (want tree, edges or json)", format)
+}
+
+func (g *Graph) footer() string {
+	footer := fmt.Sprintf("\nTotal: %d functions\n", len(g.Functions()))
+	if g.Truncated {
+		footer += "(truncated: lower the depth to see a complete graph)\n"
+	}
+	return footer
+}
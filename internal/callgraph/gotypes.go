@@ -0,0 +1,221 @@
+package callgraph
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	xcallgraph "golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// GoTypes is the call graph of a Go module resolved with full type
+// information. Each call is attributed to the exact function or method
+// called, so pkg.Bar and other.Bar, or T.Close and U.Close, are distinct,
+// and a call through an interface reaches every implementation in the
+// module. Calls made by closures count as calls of the enclosing function.
+type GoTypes struct {
+	callers map[string][]string
+	callees map[string][]string
+	// aliases maps the shorter names a function may be asked for by
+	// ("rag.NewEmbedder", "NewEmbedder", "APIEmbedder.Embed", "Embed") to
+	// its qualified names.
+	aliases map[string][]string
+}
+
+// LoadGo type-checks the packages of the Go module in dir and builds their
+// call graph. Only functions of the module itself appear in it; calls into
+// dependencies and the standard library are left out.
+func LoadGo(dir string) (*GoTypes, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes |
+			packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule,
+		Dir: dir,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("load Go packages: %w", err)
+	}
+	var errs []string
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		for _, e := range p.Errors {
+			errs = append(errs, e.Error())
+		}
+	})
+	if len(errs) > 0 {
+		if len(errs) > 3 {
+			errs = append(errs[:3], fmt.Sprintf("and %d more", len(errs)-3))
+		}
+		return nil, fmt.Errorf("type-check Go packages: %s", strings.Join(errs, "; "))
+	}
+
+	local := make(map[string]bool, len(pkgs))
+	modulePath := ""
+	for _, p := range pkgs {
+		local[p.PkgPath] = true
+		if p.Module != nil && modulePath == "" {
+			modulePath = p.Module.Path
+		}
+	}
+
+	prog, _ := ssautil.AllPackages(pkgs, ssa.InstantiateGenerics)
+	prog.Build()
+	cg := cha.CallGraph(prog)
+
+	g := &GoTypes{
+		callers: make(map[string][]string),
+		callees: make(map[string][]string),
+		aliases: make(map[string][]string),
+	}
+	names := make(map[*ssa.Function]string)
+	name := func(fn *ssa.Function) string {
+		if fn.Origin() != nil {
+			fn = fn.Origin()
+		}
+		if n, ok := names[fn]; ok {
+			return n
+		}
+		n := ""
+		if fn.Pkg != nil && local[fn.Pkg.Pkg.Path()] {
+			n = g.register(fn, modulePath)
+		}
+		names[fn] = n
+		return n
+	}
+
+	edges := make(map[[2]string]bool)
+	for fn, node := range cg.Nodes {
+		if fn == nil || wrapper(fn) {
+			continue
+		}
+		from := name(outermost(fn))
+		if from == "" {
+			continue
+		}
+		for _, callee := range calleesThroughWrappers(node, make(map[*ssa.Function]bool)) {
+			if callee.Parent() != nil {
+				// A closure called back by another function (a callback
+				// passed to it) is not a call of the closure's definer.
+				continue
+			}
+			to := name(callee)
+			if to == "" || to == from || edges[[2]string{from, to}] {
+				continue
+			}
+			edges[[2]string{from, to}] = true
+			g.callees[from] = append(g.callees[from], to)
+			g.callers[to] = append(g.callers[to], from)
+		}
+	}
+	for _, m := range []map[string][]string{g.callers, g.callees, g.aliases} {
+		for k := range m {
+			sort.Strings(m[k])
+		}
+	}
+	return g, nil
+}
+
+// Resolve returns the qualified names of the functions name refers to.
+func (g *GoTypes) Resolve(name string) []string {
+	return g.aliases[name]
+}
+
+// Neighbors implements Neighbors. name may be qualified as the graph
+// reports it or shortened (see GoTypes.aliases); a shortened name that
+// matches several functions yields the neighbors of all of them.
+func (g *GoTypes) Neighbors(name, direction string) ([]string, error) {
+	edges := g.callees
+	if direction == Callers {
+		edges = g.callers
+	}
+	matches := g.aliases[name]
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("function %s not found in the Go call graph", name)
+	}
+	if len(matches) == 1 {
+		return edges[matches[0]], nil
+	}
+	seen := make(map[string]bool)
+	var out []string
+	for _, m := range matches {
+		for _, n := range edges[m] {
+			if !seen[n] {
+				seen[n] = true
+				out = append(out, n)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// register returns fn's qualified name, e.g. "internal/rag.NewEmbedder" or
+// "(*internal/rag.APIEmbedder).Embed" (import paths relative to the
+// module), and records the names it can be looked up by.
+func (g *GoTypes) register(fn *ssa.Function, modulePath string) string {
+	pkgPath := fn.Pkg.Pkg.Path()
+	rel := strings.TrimPrefix(strings.TrimPrefix(pkgPath, modulePath), "/")
+	if rel == "" {
+		rel = fn.Pkg.Pkg.Name()
+	}
+	qualified := strings.Replace(fn.RelString(nil), pkgPath, rel, 1)
+
+	short := []string{qualified, fn.Name()}
+	pkgName := fn.Pkg.Pkg.Name()
+	if recv := fn.Signature.Recv(); recv != nil {
+		typeName := strings.TrimPrefix(recv.Type().String(), "*")
+		typeName = typeName[strings.LastIndex(typeName, ".")+1:]
+		if i := strings.IndexByte(typeName, '['); i >= 0 {
+			typeName = typeName[:i]
+		}
+		short = append(short, typeName+"."+fn.Name(), pkgName+"."+typeName+"."+fn.Name())
+	} else {
+		short = append(short, pkgName+"."+fn.Name())
+	}
+	for _, alias := range short {
+		if !slices.Contains(g.aliases[alias], qualified) {
+			g.aliases[alias] = append(g.aliases[alias], qualified)
+		}
+	}
+	return qualified
+}
+
+// outermost returns the named function a closure is defined in.
+func outermost(fn *ssa.Function) *ssa.Function {
+	for fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	return fn
+}
+
+// wrapper reports whether fn was generated by SSA to adapt a call (bound
+// methods, interface thunks) rather than written in the source. Generic
+// instances are synthetic too but stand for their origin.
+func wrapper(fn *ssa.Function) bool {
+	return fn.Synthetic != "" && fn.Origin() == nil && fn.Parent() == nil
+}
+
+// calleesThroughWrappers returns the functions node calls, looking through
+// the synthetic wrappers SSA generates for method values and interface
+// thunks.
+func calleesThroughWrappers(node *xcallgraph.Node, visited map[*ssa.Function]bool) []*ssa.Function {
+	var out []*ssa.Function
+	for _, edge := range node.Out {
+		fn := edge.Callee.Func
+		if fn == nil || visited[fn] {
+			continue
+		}
+		visited[fn] = true
+		if wrapper(fn) {
+			out = append(out, calleesThroughWrappers(edge.Callee, visited)...)
+			continue
+		}
+		out = append(out, fn)
+	}
+	return out
+}
@@ -0,0 +1,241 @@
+// Package config loads optional per-project settings from .indexer.json.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/localonly"
+	"github.com/yourorg/agent/internal/models"
+	"github.com/yourorg/agent/internal/proxy"
+	"github.com/yourorg/agent/internal/rag"
+	"github.com/yourorg/agent/internal/ratelimit"
+	"github.com/yourorg/agent/internal/tracing"
+	"github.com/yourorg/agent/internal/workspace"
+)
+
+// FileName is the project-relative name of the configuration file.
+const FileName = ".indexer.json"
+
+// Config holds per-project settings. Every field is optional; zero values
+// mean "use the built-in default".
+type Config struct {
+	RAG         RAGConfig                  `json:"rag"`
+	RateLimits  map[string]ratelimit.Limit `json:"rate_limits,omitempty"` // keyed by provider
+	Models      map[string]models.Info     `json:"models,omitempty"`      // context window of custom or self-hosted models, keyed by name or prefix
+	GitHub      GitHubConfig               `json:"github"`
+	GitLab      GitLabConfig               `json:"gitlab"`
+	Hook        HookConfig                 `json:"hook"`
+	Issues      IssuesConfig               `json:"issues"`
+	Notify      NotifyConfig               `json:"notify"`
+	Tracing     tracing.Config             `json:"tracing"`
+	Diagnostics DiagnosticsConfig          `json:"diagnostics"`
+	Gopls       GoplsConfig                `json:"gopls"`
+	CommandEnv  agent.CommandEnv           `json:"command_env"` // environment of the agent's run_command actions
+	Paths       agent.PathPolicy           `json:"paths"`       // forbidden / read_only / protected globs, added to the defaults
+	LocalOnly   LocalOnlyConfig            `json:"local_only"`
+	Proxy       proxy.Config               `json:"proxy"`     // outbound HTTP proxy, globally or per provider
+	TLS         proxy.TLSConfig            `json:"tls"`       // custom CAs and client certificates, globally or per provider
+	Workspace   workspace.Config           `json:"workspace"` // named roots of a monorepo, indexed separately
+	// SystemPrompts adds per-command instructions ("always prefer minimal
+	// diffs") to the agent's system prompts.
+	SystemPrompts agent.SystemPrompts `json:"system_prompts"`
+}
+
+// ApplyRateLimits registers the configured per-provider limits with the
+// shared rate limiter used by LLM clients and embedders.
+func (c *Config) ApplyRateLimits() {
+	for provider, limit := range c.RateLimits {
+		ratelimit.Default.Configure(provider, limit)
+	}
+}
+
+// ApplyModels registers the limits of models configured in the project, so
+// prompts to them are budgeted to their context window.
+func (c *Config) ApplyModels() {
+	for name, info := range c.Models {
+		models.Register(name, info)
+	}
+}
+
+// RAGConfig selects the embedder and tunes semantic indexing throughput.
+type RAGConfig struct {
+	BatchSize         int                `json:"batch_size,omitempty"`          // chunks per embedding request
+	Concurrency       int                `json:"concurrency,omitempty"`         // concurrent embedding requests
+	RequestsPerMinute int                `json:"requests_per_minute,omitempty"` // 0 means unlimited
+	Privacy           rag.PrivacyPolicy  `json:"privacy"`                       // exclusions and PII stripping for remote embedding
+	Embedder          rag.EmbedderConfig `json:"embedder"`                      // ollama (default), local, openai, voyage or cohere
+	Bulk              bool               `json:"bulk,omitempty"`                // embed full indexes through the provider's batch API
+	Store             rag.StoreConfig    `json:"store"`                         // sqlite in .index (default), a shared pgvector database, or memory
+	Chunking          rag.ChunkerConfig  `json:"chunking"`                      // chunk size and sliding-window lines, for the embedding model's context window
+}
+
+// GitHubConfig controls pull requests opened by `agent run -create-pr`.
+// The token is always read from the GITHUB_TOKEN environment variable.
+type GitHubConfig struct {
+	Owner        string `json:"owner,omitempty"`         // defaults to the origin remote
+	Repo         string `json:"repo,omitempty"`          // defaults to the origin remote
+	APIURL       string `json:"api_url,omitempty"`       // for GitHub Enterprise
+	Remote       string `json:"remote,omitempty"`        // default "origin"
+	BaseBranch   string `json:"base_branch,omitempty"`   // default: branch checked out when the run starts
+	BranchPrefix string `json:"branch_prefix,omitempty"` // default "agent/"
+	Draft        bool   `json:"draft,omitempty"`
+}
+
+// GitLabConfig controls merge requests opened by `agent run -create-mr`.
+// The token is always read from the GITLAB_TOKEN environment variable.
+type GitLabConfig struct {
+	ProjectURL   string `json:"project_url,omitempty"`   // defaults to the origin remote
+	Remote       string `json:"remote,omitempty"`        // default "origin"
+	BaseBranch   string `json:"base_branch,omitempty"`   // default: branch checked out when the run starts
+	BranchPrefix string `json:"branch_prefix,omitempty"` // default "agent/"
+	Draft        bool   `json:"draft,omitempty"`
+	PostNotes    bool   `json:"post_notes,omitempty"` // post the execution log as an MR note instead of in the description
+}
+
+// HookConfig controls `indexer hook pre-commit`.
+type HookConfig struct {
+	Mode string `json:"mode,omitempty"` // "block" (default) or "warn"
+}
+
+// IssuesConfig controls `agent run -issue`. Credentials come from the
+// JIRA_API_TOKEN, LINEAR_API_KEY and GITHUB_TOKEN environment variables.
+type IssuesConfig struct {
+	Tracker    string `json:"tracker,omitempty"`     // tracker for bare ABC-123 keys: "jira" or "linear"
+	JiraURL    string `json:"jira_url,omitempty"`    // or JIRA_URL
+	JiraEmail  string `json:"jira_email,omitempty"`  // or JIRA_EMAIL; empty uses a bearer token
+	GitHubRepo string `json:"github_repo,omitempty"` // owner/name; defaults to the github section or origin remote
+}
+
+// NotifyConfig controls the summary posted when `agent run` finishes.
+// SLACK_WEBHOOK_URL and AGENT_WEBHOOK_URL are used when the URLs are unset.
+type NotifyConfig struct {
+	WebhookURL      string            `json:"webhook_url,omitempty"`
+	WebhookHeaders  map[string]string `json:"webhook_headers,omitempty"`
+	SlackWebhookURL string            `json:"slack_webhook_url,omitempty"`
+	SlackChannel    string            `json:"slack_channel,omitempty"`
+	OnFailureOnly   bool              `json:"on_failure_only,omitempty"`
+	// Pricing maps a model name to its blended price in USD per million
+	// tokens, used to estimate run cost.
+	Pricing map[string]float64 `json:"pricing,omitempty"`
+}
+
+// DiagnosticsConfig controls whether compiler/linter output is added to
+// agent context (see `agent plan|run -diagnostics`).
+type DiagnosticsConfig struct {
+	Enabled bool     `json:"enabled,omitempty"`
+	Tools   []string `json:"tools,omitempty"` // subset of "go vet", "tsc", "ruff"
+}
+
+// GoplsConfig makes Go symbol queries (callgraph, info, refs, impls, lsp)
+// use gopls for type-accurate answers.
+type GoplsConfig struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	Path    string `json:"path,omitempty"` // gopls binary (default: "gopls" on PATH)
+}
+
+// LocalOnlyConfig restricts the tools to local models and services (see
+// the -local-only flag). AllowHosts names extra hosts treated as local,
+// such as an Ollama server on the same isolated network.
+type LocalOnlyConfig struct {
+	Enabled    bool     `json:"enabled,omitempty"`
+	AllowHosts []string `json:"allow_hosts,omitempty"`
+}
+
+// ApplyLocalOnly enables local-only mode when the config asks for it, and
+// registers the allowed hosts when it is already on.
+func (c *Config) ApplyLocalOnly() {
+	if c.LocalOnly.Enabled || localonly.Enabled() {
+		localonly.Enable(c.LocalOnly.AllowHosts...)
+	}
+}
+
+// CheckLocalEmbedder fails when the config asks for local-only mode and its
+// embedder would send text to another machine. Unlike ApplyLocalOnly it
+// changes no process state, so a server handling several projects can hold
+// each one to its own setting.
+func (c *Config) CheckLocalEmbedder() error {
+	e := c.RAG.Embedder
+	switch e.Provider {
+	case "local":
+		return nil
+	case "", "ollama":
+		return c.checkLocalURL("ollama embedder", e.BaseURL)
+	}
+	return c.checkLocal(e.Provider + " embedder")
+}
+
+// CheckLocalLLM is CheckLocalEmbedder for an LLM provider, which must be
+// ollama or llamacpp on this machine or an allowed host. An empty baseURL
+// is the provider's default, on localhost.
+func (c *Config) CheckLocalLLM(provider, baseURL string) error {
+	switch provider {
+	case "ollama", "llamacpp":
+		return c.checkLocalURL(provider+" provider", baseURL)
+	}
+	return c.checkLocal(fmt.Sprintf("provider %q (use ollama or llamacpp)", provider))
+}
+
+// checkLocalURL fails in the config's local-only mode when target is not on
+// this machine or an allowed host. An empty target is a local default.
+func (c *Config) checkLocalURL(feature, target string) error {
+	if !c.LocalOnly.Enabled || target == "" {
+		return nil
+	}
+	host := target
+	if u, err := url.Parse(target); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	allowed := slices.ContainsFunc(c.LocalOnly.AllowHosts, func(h string) bool {
+		return strings.EqualFold(h, host)
+	})
+	if allowed || localonly.IsLocal(host) {
+		return nil
+	}
+	return fmt.Errorf("%s at %s: %w", feature, host, localonly.ErrRemote)
+}
+
+// checkLocal fails in the config's local-only mode.
+func (c *Config) checkLocal(feature string) error {
+	if !c.LocalOnly.Enabled {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", feature, localonly.ErrRemote)
+}
+
+// ApplyProxy routes the HTTP clients of LLM providers, embedders, forges,
+// trackers and notifiers through the configured proxies. Without a proxy
+// section HTTP_PROXY, HTTPS_PROXY and NO_PROXY apply.
+func (c *Config) ApplyProxy() error {
+	return proxy.Configure(c.Proxy)
+}
+
+// ApplyTLS loads the configured CA bundles and client certificates for the
+// same clients, e.g. to reach an internal LLM gateway with a private CA.
+func (c *Config) ApplyTLS() error {
+	return proxy.ConfigureTLS(c.TLS)
+}
+
+// Load reads <projectPath>/.indexer.json. A missing file yields an empty config.
+func Load(projectPath string) (*Config, error) {
+	path := filepath.Join(projectPath, FileName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,184 @@
+// Package configkeys indexes the keys of configuration files (YAML, JSON,
+// TOML and .env) as symbols, so "where is the redis url configured" finds
+// config.yaml:12 redis.url as well as the code reading it.
+package configkeys
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourorg/agent/internal/secrets"
+)
+
+// Key is one key of a configuration file.
+type Key struct {
+	// Path is the key's dotted path, with list items indexed:
+	// "redis.url", "services[0].image".
+	Path string `json:"path"`
+	// Value is a scalar value as written, shortened and with credentials
+	// redacted; empty for tables, objects and lists.
+	Value  string `json:"value,omitempty"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Format string `json:"format"` // yaml, json, toml or env
+}
+
+func (k Key) String() string {
+	if k.Value == "" {
+		return fmt.Sprintf("%s:%d  %s", k.File, k.Line, k.Path)
+	}
+	return fmt.Sprintf("%s:%d  %s = %s", k.File, k.Line, k.Path, k.Value)
+}
+
+// maxFileSize bounds the files Load reads; larger JSON and YAML files are
+// data, not configuration.
+const maxFileSize = 256 << 10
+
+// maxValueLen bounds Key.Value.
+const maxValueLen = 80
+
+// FileFormat returns the configuration format of a file by its name, or "".
+func FileFormat(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	if name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env") {
+		return "env"
+	}
+	if skipFiles[name] {
+		return ""
+	}
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	}
+	return ""
+}
+
+// skipFiles are generated files with configuration extensions.
+var skipFiles = map[string]bool{
+	"package-lock.json": true,
+	"composer.lock":     true,
+	"pnpm-lock.yaml":    true,
+	"poetry.lock":       true,
+	"cargo.lock":        true,
+}
+
+// Load parses the configuration files of the project. Key files are
+// slash-separated and relative to projectPath.
+func Load(projectPath string) ([]Key, error) {
+	var keys []Key
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != projectPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || FileFormat(p) == "" {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(projectPath, p)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, Parse(filepath.ToSlash(rel), src)...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load config files: %w", err)
+	}
+	return keys, nil
+}
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"venv":         true,
+	"__pycache__":  true,
+}
+
+// Parse returns the keys of the configuration file named file. Files that
+// fail to parse part way yield the keys before the error.
+func Parse(file string, src []byte) []Key {
+	format := FileFormat(file)
+	var keys []Key
+	emit := func(path, value string, line int) {
+		keys = append(keys, Key{Path: path, Value: displayValue(path, value), File: file, Line: line, Format: format})
+	}
+	switch format {
+	case "yaml":
+		parseYAML(string(src), emit)
+	case "json":
+		parseJSON(src, emit)
+	case "toml":
+		parseTOML(string(src), emit)
+	case "env":
+		parseEnv(string(src), emit)
+	}
+	return keys
+}
+
+// sensitiveWords mark keys whose values are never shown.
+var sensitiveWords = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "api-key", "credential", "private_key", "privatekey"}
+
+// displayValue unquotes, shortens and redacts a value for display.
+func displayValue(path, value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	if value == "" {
+		return ""
+	}
+	lower := strings.ToLower(path)
+	for _, w := range sensitiveWords {
+		if strings.Contains(lower, w) {
+			return "[REDACTED]"
+		}
+	}
+	value = secrets.Redact(value)
+	if len(value) > maxValueLen {
+		value = value[:maxValueLen] + "..."
+	}
+	return value
+}
+
+// join appends key to a dotted path.
+func join(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// parseEnv parses KEY=value lines, with an optional "export ".
+func parseEnv(src string, emit func(path, value string, line int)) {
+	for i, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		emit(strings.TrimSpace(key), value, i+1)
+	}
+}
@@ -0,0 +1,77 @@
+package configkeys
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// parseJSON walks a JSON document token by token to keep the line of
+// every key.
+func parseJSON(src []byte, emit func(path, value string, line int)) {
+	var newlines []int
+	for i, b := range src {
+		if b == '\n' {
+			newlines = append(newlines, i)
+		}
+	}
+	lineAt := func(offset int64) int {
+		return sort.SearchInts(newlines, int(offset)) + 1
+	}
+	// next is the offset of the token after offset, past the separator.
+	next := func(offset int64) int64 {
+		for offset < int64(len(src)) && bytes.IndexByte([]byte(" \t\r\n,"), src[offset]) >= 0 {
+			offset++
+		}
+		return offset
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	var value func(path string, line int) error
+	value = func(path string, line int) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			if path != "" {
+				emit(path, "", line)
+			}
+			for dec.More() {
+				tok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := tok.(string)
+				if err := value(join(path, key), lineAt(dec.InputOffset()-1)); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+			return err
+		case json.Delim('['):
+			if path != "" {
+				emit(path, "", line)
+			}
+			for i := 0; dec.More(); i++ {
+				if err := value(fmt.Sprintf("%s[%d]", path, i), lineAt(next(dec.InputOffset()))); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+			return err
+		}
+		// Scalars in lists are values, not keys.
+		if path != "" && path[len(path)-1] != ']' {
+			if tok == nil {
+				tok = "null"
+			}
+			emit(path, fmt.Sprint(tok), line)
+		}
+		return nil
+	}
+	value("", 1)
+}
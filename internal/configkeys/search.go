@@ -0,0 +1,154 @@
+package configkeys
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yourorg/agent/internal/docs"
+)
+
+// Result is a key matching a query.
+type Result struct {
+	Key
+	Score   float64 `json:"score"`
+	Matched int     `json:"matched"` // query words the key matched
+	Terms   int     `json:"terms"`   // query words searched for
+}
+
+// queryNoise are query words every configuration key would match.
+var queryNoise = map[string]bool{
+	"config": true, "configured": true, "configuration": true, "configure": true,
+	"set": true, "setting": true, "settings": true, "defined": true, "value": true,
+}
+
+// Search ranks keys by how many query words their path contains, best
+// first, and returns at most limit of them (all with limit <= 0). A word
+// matching a whole path segment word ("redis" in redis_url) counts more
+// than a prefix; words found only in the value count least.
+func Search(keys []Key, query string, limit int) []Result {
+	var terms []string
+	for _, t := range docs.Terms(query) {
+		if !queryNoise[t] {
+			terms = append(terms, t)
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []Result
+	for _, k := range keys {
+		words := pathWords(k.Path)
+		value := strings.ToLower(k.Value)
+		score, matched := 0.0, 0
+		for _, t := range terms {
+			best := 0.0
+			for i, w := range words {
+				s := 0.0
+				switch {
+				case w == t:
+					s = 2
+				case len(t) >= 3 && (strings.HasPrefix(w, t) || strings.HasPrefix(t, w) && len(w) >= 3):
+					s = 1
+				}
+				if s > 0 && i == len(words)-1 {
+					s += 0.5 // the key itself rather than an enclosing table
+				}
+				best = max(best, s)
+			}
+			if best == 0 && strings.Contains(value, t) {
+				best = 0.5
+			}
+			if best > 0 {
+				score += best
+				matched++
+			}
+		}
+		if matched == 0 {
+			continue
+		}
+		score *= float64(matched) / float64(len(terms))
+		results = append(results, Result{Key: k, Score: score, Matched: matched, Terms: len(terms)})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		// Shallower keys first: redis.url before services[0].env.redis.url.
+		return strings.Count(results[i].Path, ".") < strings.Count(results[j].Path, ".")
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Relevant is Search keeping only keys that match every query word, or
+// at least two, for adding to a task's context unasked.
+func Relevant(keys []Key, query string, limit int) []Result {
+	var relevant []Result
+	for _, r := range Search(keys, query, 0) {
+		if r.Matched == r.Terms || r.Matched >= 2 {
+			relevant = append(relevant, r)
+		}
+		if limit > 0 && len(relevant) == limit {
+			break
+		}
+	}
+	return relevant
+}
+
+// ByPath returns the keys whose path contains name, ignoring case, the way
+// symbols are looked up by name.
+func ByPath(keys []Key, name string) []Key {
+	name = strings.ToLower(name)
+	var found []Key
+	for _, k := range keys {
+		if strings.Contains(strings.ToLower(k.Path), name) {
+			found = append(found, k)
+		}
+	}
+	return found
+}
+
+// pathWords splits a key path into lowercase words at separators and
+// camelCase boundaries: "db.redisURL[0]" gives db, redis, url, 0.
+func pathWords(path string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(path)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// Format renders results one per line, as "file:line  path = value".
+func Format(results []Result) string {
+	var b strings.Builder
+	for _, r := range results {
+		b.WriteString(r.Key.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
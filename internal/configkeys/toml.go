@@ -0,0 +1,119 @@
+package configkeys
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseTOML reads the tables and key/value pairs of a TOML file line by
+// line. Values spanning lines (multi-line strings and arrays) are skipped
+// past.
+func parseTOML(src string, emit func(path, value string, line int)) {
+	var (
+		table  string
+		arrays = make(map[string]int) // [[array]] tables seen, by path
+		until  string                 // closing delimiter of a multi-line value
+		depth  int                    // open brackets of a multi-line array
+	)
+	for i, raw := range strings.Split(src, "\n") {
+		line := strings.TrimSpace(raw)
+		if until != "" {
+			if strings.Contains(line, until) {
+				until = ""
+			}
+			continue
+		}
+		if depth > 0 {
+			depth += bracketDepth(line)
+			continue
+		}
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]]") {
+			name := tomlKey(line[2 : len(line)-2])
+			table = fmt.Sprintf("%s[%d]", name, arrays[name])
+			arrays[name]++
+			emit(table, "", i+1)
+			continue
+		}
+		if line[0] == '[' && strings.HasSuffix(line, "]") {
+			table = tomlKey(line[1 : len(line)-1])
+			emit(table, "", i+1)
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		for _, delim := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(value, delim) && !strings.Contains(value[3:], delim) {
+				until, value = delim, ""
+			}
+		}
+		if strings.HasPrefix(value, "[") {
+			if depth = bracketDepth(value); depth > 0 {
+				value = ""
+			}
+		}
+		emit(join(table, tomlKey(key)), value, i+1)
+	}
+}
+
+// tomlKey normalizes a possibly dotted and quoted key: `a . "b.c"` becomes
+// a.b.c.
+func tomlKey(key string) string {
+	var parts []string
+	for _, part := range strings.Split(key, ".") {
+		parts = append(parts, strings.Trim(strings.TrimSpace(part), `"'`))
+	}
+	return strings.Join(parts, ".")
+}
+
+// bracketDepth returns the brackets line opens minus those it closes,
+// outside strings.
+func bracketDepth(line string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '#':
+			return depth
+		}
+	}
+	return depth
+}
+
+// stripTOMLComment removes a "# comment" outside strings.
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
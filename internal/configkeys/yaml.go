@@ -0,0 +1,129 @@
+package configkeys
+
+import (
+	"fmt"
+	"strings"
+)
+
+// yamlFrame is an open mapping or list item, with the indentation of the
+// line that opened it.
+type yamlFrame struct {
+	indent int
+	path   string
+	item   bool
+}
+
+// parseYAML reads the block-style mappings and lists of a YAML file line
+// by line. Flow collections ({a: 1}, [1, 2]) are kept as values, block
+// scalars (| and >) are skipped, and each "---" document starts over.
+func parseYAML(src string, emit func(path, value string, line int)) {
+	var (
+		stack []yamlFrame
+		items = make(map[string]int) // list items seen, by list path and indentation
+		block = -1                   // indentation of a block scalar's key
+	)
+	for i, raw := range strings.Split(src, "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		if block >= 0 {
+			if strings.TrimSpace(raw) == "" || indent > block {
+				continue
+			}
+			block = -1
+		}
+		line := strings.TrimSpace(stripYAMLComment(raw))
+		if line == "" || line[0] == '%' {
+			continue
+		}
+		if line == "---" || line == "..." || strings.HasPrefix(line, "--- ") {
+			stack = stack[:0]
+			clear(items)
+			continue
+		}
+
+		isItem := line == "-" || strings.HasPrefix(line, "- ")
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			// A list may sit at its key's indentation ("key:\n- a").
+			if top.indent < indent || (top.indent == indent && isItem && !top.item) {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		parent := ""
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1].path
+		}
+
+		for line == "-" || strings.HasPrefix(line, "- ") {
+			list := fmt.Sprintf("%s@%d", parent, indent)
+			parent = fmt.Sprintf("%s[%d]", parent, items[list])
+			items[list]++
+			stack = append(stack, yamlFrame{indent: indent, path: parent, item: true})
+			rest := strings.TrimLeft(line[1:], " ")
+			indent += len(line) - len(rest)
+			line = rest
+		}
+		if line == "" {
+			continue
+		}
+
+		key, value, ok := splitYAMLKey(line)
+		if !ok {
+			continue // a scalar list item or a continued value
+		}
+		path := join(parent, key)
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			block = indent
+			value = ""
+		}
+		emit(path, value, i+1)
+		stack = append(stack, yamlFrame{indent: indent, path: path})
+	}
+}
+
+// splitYAMLKey splits "key: value" at the first colon outside quotes that
+// is followed by a space or ends the line.
+func splitYAMLKey(line string) (key, value string, ok bool) {
+	if line[0] == '{' || line[0] == '[' || line[0] == '?' {
+		return "", "", false
+	}
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == ':' && (i+1 == len(line) || line[i+1] == ' ' || line[i+1] == '\t'):
+			key = strings.Trim(strings.TrimSpace(line[:i]), `"'`)
+			return key, strings.TrimSpace(line[i+1:]), key != ""
+		}
+	}
+	return "", "", false
+}
+
+// stripYAMLComment removes a "# comment" that starts the line or follows
+// whitespace, outside quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || line[i-1] == ' ' || line[i-1] == ':' {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,145 @@
+// Package ctags writes a symbol table as a tags file in the extended
+// format of universal-ctags, so vim, emacs and other editors can jump to
+// the definitions the indexer found, and reads the tags files ctags
+// writes (see Read).
+package ctags
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Tag is a definition.
+type Tag struct {
+	Name string // "Func", "Type", or "Type.Method"
+	Kind string // "function", "method", "type", "class", ...
+	File string // slash-separated, relative to the project
+	Line int    // 1-based
+
+	// Read also sets these; Write ignores them.
+	Language  string // as ctags names it, e.g. "Python"
+	Signature string // the line of the definition
+	Exported  bool   // not private, protected or file scoped
+}
+
+// entry is a line of the tags file.
+type entry struct {
+	name    string
+	file    string
+	address string
+	fields  []string
+}
+
+// Write writes tags as a sorted tags file, with paths relative to
+// projectPath as editors expect when the file sits at the project root.
+// Each tag is addressed by a search pattern for its line, so jumps
+// survive edits elsewhere in the file, falling back to the line number
+// when the file cannot be read or the line is blank. Methods get a second, qualified entry
+// ("Type.Method"), as with ctags --extras=+q.
+func Write(w io.Writer, projectPath string, tags []Tag) (int, error) {
+	lines := make(map[string][]string)
+	lineOf := func(file string, n int) (string, bool) {
+		l, ok := lines[file]
+		if !ok {
+			if src, err := os.ReadFile(filepath.Join(projectPath, filepath.FromSlash(file))); err == nil {
+				l = strings.Split(string(src), "\n")
+			}
+			lines[file] = l
+		}
+		if n < 1 || n > len(l) {
+			return "", false
+		}
+		return strings.TrimRight(l[n-1], "\r"), true
+	}
+
+	var entries []entry
+	for _, t := range tags {
+		address := fmt.Sprint(t.Line)
+		if text, ok := lineOf(t.File, t.Line); ok && strings.TrimSpace(text) != "" {
+			address = "/^" + escapePattern(text) + "$/"
+		}
+		fields := []string{"kind:" + kind(t.Kind), fmt.Sprintf("line:%d", t.Line)}
+		if lang := language(t.File); lang != "" {
+			fields = append(fields, "language:"+lang)
+		}
+
+		name := t.Name
+		if i := strings.LastIndex(t.Name, "."); i >= 0 {
+			name = t.Name[i+1:]
+			scope := "class"
+			if path.Ext(t.File) == ".go" {
+				scope = "struct"
+			}
+			fields = append(fields, scope+":"+t.Name[:i])
+			entries = append(entries, entry{name: t.Name, file: t.File, address: address, fields: fields})
+		}
+		entries = append(entries, entry{name: name, file: t.File, address: address, fields: fields})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if a.file != b.file {
+			return a.file < b.file
+		}
+		return a.address < b.address
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, "!_TAG_FILE_FORMAT\t2\t/extended format; --format=1 will not append ;\" to lines/\n")
+	fmt.Fprint(bw, "!_TAG_FILE_SORTED\t1\t/0=unsorted, 1=sorted, 2=foldcase/\n")
+	fmt.Fprint(bw, "!_TAG_PROGRAM_NAME\tindexer\t//\n")
+	for _, e := range entries {
+		fmt.Fprintf(bw, "%s\t%s\t%s;\"\t%s\n", e.name, e.file, e.address, strings.Join(e.fields, "\t"))
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, fmt.Errorf("write tags: %w", err)
+	}
+	return len(entries), nil
+}
+
+// escapePattern escapes a line for a tags search pattern, in which only
+// the delimiter and backslash are special.
+func escapePattern(line string) string {
+	return strings.NewReplacer(`\`, `\\`, `/`, `\/`).Replace(line)
+}
+
+// kind maps the index's kinds to the names universal-ctags uses.
+func kind(k string) string {
+	switch k = strings.ToLower(k); k {
+	case "function", "func":
+		return "func"
+	case "":
+		return "unknown"
+	}
+	return k
+}
+
+func language(file string) string {
+	switch path.Ext(file) {
+	case ".go":
+		return "Go"
+	case ".py":
+		return "Python"
+	case ".ts", ".tsx":
+		return "TypeScript"
+	case ".js", ".jsx":
+		return "JavaScript"
+	case ".java":
+		return "Java"
+	case ".rs":
+		return "Rust"
+	case ".rb":
+		return "Ruby"
+	case ".kt", ".kts":
+		return "Kotlin"
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package ctags
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// scopes are the fields that name the container of a tag.
+var scopes = map[string]bool{
+	"class": true, "struct": true, "union": true, "enum": true, "interface": true,
+	"namespace": true, "module": true, "trait": true, "object": true, "record": true,
+}
+
+// kinds maps the one-letter kinds most languages share, written when
+// ctags runs without --fields=+K, to names.
+var kinds = map[string]string{
+	"c": "class", "d": "macro", "e": "enumerator", "f": "function", "g": "enum",
+	"i": "interface", "m": "member", "n": "namespace", "p": "prototype",
+	"s": "struct", "t": "typedef", "u": "union", "v": "variable",
+}
+
+// Read reads a tags file, as universal-ctags or Write write it, with
+// paths relative to projectPath as when ctags runs at the project root.
+// Names are qualified by their scope field ("Type.Method"), and the
+// qualified entries of --extras=+q are left out. A tag addressed only by
+// a search pattern gets the line of the first match in its file.
+func Read(r io.Reader, projectPath string) ([]Tag, error) {
+	lines := make(map[string][]string)
+	linesOf := func(file string) []string {
+		l, ok := lines[file]
+		if !ok {
+			if src, err := os.ReadFile(filepath.Join(projectPath, filepath.FromSlash(file))); err == nil {
+				l = strings.Split(string(src), "\n")
+				for i := range l {
+					l[i] = strings.TrimRight(l[i], "\r")
+				}
+			}
+			lines[file] = l
+		}
+		return l
+	}
+
+	var tags []Tag
+	seen := make(map[Tag]bool)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		text := strings.TrimRight(sc.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "!_") {
+			continue
+		}
+		name, rest, ok1 := strings.Cut(text, "\t")
+		file, rest, ok2 := strings.Cut(rest, "\t")
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("read tags: line %d is not a tag", n)
+		}
+		address, fields := rest, ""
+		if i := strings.Index(rest, ";\"\t"); i >= 0 {
+			address, fields = rest[:i], rest[i+3:]
+		} else {
+			address = strings.TrimSuffix(rest, ";\"")
+		}
+		file, ok := relative(projectPath, file)
+		if !ok {
+			continue
+		}
+
+		t := Tag{Name: strings.ReplaceAll(name, "::", "."), File: file, Exported: true}
+		var scope, signature string
+		for _, f := range strings.Split(fields, "\t") {
+			key, value, found := strings.Cut(f, ":")
+			if !found {
+				key, value = "kind", f
+			}
+			value = unescapeField(value)
+			switch {
+			case key == "kind":
+				t.Kind = value
+			case key == "line":
+				t.Line, _ = strconv.Atoi(value)
+			case key == "language":
+				t.Language = value
+			case key == "signature":
+				signature = value
+			case key == "access":
+				t.Exported = value != "private" && value != "protected"
+			case key == "file":
+				t.Exported = false // file scoped, like static in C
+			case key == "scope":
+				// --fields=+Z: scope:class:Type.
+				if k, v, ok := strings.Cut(value, ":"); ok && scopes[k] {
+					scope = v
+				}
+			case scopes[key]:
+				scope = value
+			}
+		}
+		if k, ok := kinds[t.Kind]; ok {
+			t.Kind = k
+		}
+		if t.Kind == "func" {
+			t.Kind = "function"
+		}
+		if t.Kind == "file" || t.Kind == "local" || t.Kind == "parameter" {
+			continue
+		}
+
+		pattern, line := parseAddress(address)
+		if t.Line == 0 {
+			t.Line = line
+		}
+		src := linesOf(file)
+		if t.Line == 0 && pattern != nil {
+			for i, l := range src {
+				if pattern(l) {
+					t.Line = i + 1
+					break
+				}
+			}
+		}
+		if t.Line >= 1 && t.Line <= len(src) {
+			t.Signature = strings.TrimSpace(src[t.Line-1])
+		}
+		if t.Signature == "" && signature != "" {
+			t.Signature = name + signature
+		}
+
+		if scope != "" {
+			scope = strings.ReplaceAll(scope, "::", ".")
+			if strings.HasPrefix(t.Name, scope+".") {
+				continue // the qualified entry of --extras=+q
+			}
+			t.Name = scope + "." + t.Name
+			short := t.Name[len(scope)+1:]
+			switch {
+			case t.Kind == "function",
+				t.Kind == "member" && (signature != "" || strings.Contains(t.Signature, short+"(")):
+				t.Kind = "method"
+			case t.Kind == "member":
+				t.Kind = "field"
+			}
+		}
+		if t.Kind == "" {
+			t.Kind = "unknown"
+		}
+		if !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read tags: %w", err)
+	}
+	return tags, nil
+}
+
+// relative returns file slash-separated and relative to projectPath, and
+// false when it is outside the project.
+func relative(projectPath, file string) (string, bool) {
+	if filepath.IsAbs(file) {
+		rel, err := filepath.Rel(projectPath, file)
+		if err != nil {
+			return "", false
+		}
+		file = rel
+	}
+	file = filepath.ToSlash(filepath.Clean(file))
+	if file == ".." || strings.HasPrefix(file, "../") {
+		return "", false
+	}
+	return file, true
+}
+
+// parseAddress returns the line of a numeric address or, for a search
+// pattern such as /^func main() {$/, a function reporting whether a line
+// matches it.
+func parseAddress(address string) (match func(string) bool, line int) {
+	if n, err := strconv.Atoi(address); err == nil {
+		return nil, n
+	}
+	if len(address) < 2 || (address[0] != '/' && address[0] != '?') || address[len(address)-1] != address[0] {
+		return nil, 0
+	}
+	delim := address[:1]
+	p := address[1 : len(address)-1]
+	start := strings.HasPrefix(p, "^")
+	end := strings.HasSuffix(p, "$") && !strings.HasSuffix(p, `\$`)
+	p = strings.TrimPrefix(p, "^")
+	if end {
+		p = p[:len(p)-1]
+	}
+	p = strings.NewReplacer(`\\`, `\`, `\`+delim, delim).Replace(p)
+	switch {
+	case start && end:
+		return func(l string) bool { return l == p }, 0
+	case start:
+		return func(l string) bool { return strings.HasPrefix(l, p) }, 0
+	case end:
+		return func(l string) bool { return strings.HasSuffix(l, p) }, 0
+	}
+	return func(l string) bool { return strings.Contains(l, p) }, 0
+}
+
+// unescapeField undoes the escapes of universal-ctags in field values.
+func unescapeField(v string) string {
+	if !strings.Contains(v, `\`) {
+		return v
+	}
+	return strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\r`, "\r", `\n`, "\n").Replace(v)
+}
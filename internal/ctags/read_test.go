@@ -0,0 +1,93 @@
+package ctags
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	src := "class Store:\n    def get(self, key):\n        return key\n\n    def _load(self):\n        pass\n"
+	if err := os.WriteFile(filepath.Join(dir, "store.py"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		line string
+		want []Tag
+	}{
+		{"pattern", "Store\tstore.py\t/^class Store:$/;\"\tkind:class\tlanguage:Python",
+			[]Tag{{Name: "Store", Kind: "class", File: "store.py", Line: 1, Language: "Python", Signature: "class Store:", Exported: true}}},
+		{"one-letter kind and scope", "get\tstore.py\t/^    def get(self, key):$/;\"\tm\tclass:Store",
+			[]Tag{{Name: "Store.get", Kind: "method", File: "store.py", Line: 2, Signature: "def get(self, key):", Exported: true}}},
+		{"scope field and access", "_load\tstore.py\t/^    def _load(self):$/;\"\tkind:member\tscope:class:Store\taccess:private",
+			[]Tag{{Name: "Store._load", Kind: "method", File: "store.py", Line: 5, Signature: "def _load(self):", Exported: false}}},
+		{"line number", "get\t./store.py\t2;\"\tkind:function\tclass:Store",
+			[]Tag{{Name: "Store.get", Kind: "method", File: "store.py", Line: 2, Signature: "def get(self, key):", Exported: true}}},
+		{"line field wins", "_load\tstore.py\t/^    def/;\"\tkind:member\tline:5\tclass:Store",
+			[]Tag{{Name: "Store._load", Kind: "method", File: "store.py", Line: 5, Signature: "def _load(self):", Exported: true}}},
+		{"absolute path", "Store\t" + filepath.Join(dir, "store.py") + "\t/^class Store:$/;\"\tc",
+			[]Tag{{Name: "Store", Kind: "class", File: "store.py", Line: 1, Signature: "class Store:", Exported: true}}},
+		{"missing file", "main\tmain.c\t/^int main(void)$/;\"\tf\tsignature:(void)\tfile:",
+			[]Tag{{Name: "main", Kind: "function", File: "main.c", Signature: "main(void)"}}},
+		{"qualified extra", "Store.get\tstore.py\t/^    def get(self, key):$/;\"\tm\tclass:Store", nil},
+		{"outside the project", "x\t../other.py\t1;\"\tv", nil},
+		{"pseudo tag", "!_TAG_FILE_FORMAT\t2\t/extended format/", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.line+"\n"), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Read(strings.NewReader("not a tags file\n"), dir); err == nil {
+		t.Error("Read of a line without tabs succeeded")
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	dir := t.TempDir()
+	src := "package store\n\ntype Store struct{}\n\nfunc (s *Store) Get(key string) string { return key }\n\nfunc New() *Store { return &Store{} }\n"
+	if err := os.WriteFile(filepath.Join(dir, "store.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tags := []Tag{
+		{Name: "Store", Kind: "type", File: "store.go", Line: 3},
+		{Name: "Store.Get", Kind: "method", File: "store.go", Line: 5},
+		{Name: "New", Kind: "function", File: "store.go", Line: 7},
+	}
+	var buf bytes.Buffer
+	if _, err := Write(&buf, dir, tags); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(src, "\n")
+	want := make(map[string]Tag)
+	for _, tag := range tags {
+		tag.Language, tag.Signature, tag.Exported = "Go", lines[tag.Line-1], true
+		want[tag.Name] = tag
+	}
+	if len(got) != len(want) {
+		t.Fatalf("read %d tags, want %d: %+v", len(got), len(want), got)
+	}
+	for _, tag := range got {
+		if tag != want[tag.Name] {
+			t.Errorf("read %+v, want %+v", tag, want[tag.Name])
+		}
+	}
+}
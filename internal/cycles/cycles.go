@@ -0,0 +1,213 @@
+// Package cycles finds cycles in a directed graph such as the module
+// import graph, reporting for each tangle of mutually dependent nodes the
+// shortest cycle through it, which is usually the one to break.
+package cycles
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Edges returns the nodes name points to, e.g. the modules it imports.
+type Edges func(name string) []string
+
+// Cycle is a strongly connected component of the graph: a set of nodes
+// that all reach each other.
+type Cycle struct {
+	// Path is a shortest cycle in the component, starting and ending at the
+	// same node.
+	Path []string `json:"path"`
+	// Members are every node in the component, sorted.
+	Members []string `json:"members"`
+}
+
+// String renders the cycle's path as "a -> b -> a".
+func (c Cycle) String() string {
+	return strings.Join(c.Path, " -> ")
+}
+
+// Find returns the cycles among nodes, ordered by path length and then
+// name. Edges to nodes outside the list are ignored, so imports of
+// third-party packages cannot form cycles.
+func Find(nodes []string, edges Edges) []Cycle {
+	g := newGraph(nodes, edges)
+	var cycles []Cycle
+	for _, comp := range g.components() {
+		path := g.shortestCycle(comp)
+		if path == nil {
+			continue
+		}
+		members := make([]string, len(comp))
+		for i, n := range comp {
+			members[i] = g.names[n]
+		}
+		sort.Strings(members)
+		cycles = append(cycles, Cycle{Path: path, Members: members})
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		if len(cycles[i].Path) != len(cycles[j].Path) {
+			return len(cycles[i].Path) < len(cycles[j].Path)
+		}
+		return cycles[i].String() < cycles[j].String()
+	})
+	return cycles
+}
+
+// Format renders cycles as a numbered list.
+func Format(cycles []Cycle) string {
+	if len(cycles) == 0 {
+		return "No import cycles found.\n"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Import cycles (%d):\n\n", len(cycles))
+	for i, c := range cycles {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, c)
+		if len(c.Members) > len(c.Path)-1 {
+			fmt.Fprintf(&b, "   part of a cycle among %d modules: %s\n", len(c.Members), strings.Join(c.Members, ", "))
+		}
+	}
+	return b.String()
+}
+
+// graph is the node list indexed by position, with sorted adjacency.
+type graph struct {
+	names []string
+	adj   [][]int
+}
+
+func newGraph(nodes []string, edges Edges) *graph {
+	names := append([]string(nil), nodes...)
+	sort.Strings(names)
+	ids := make(map[string]int, len(names))
+	for i, n := range names {
+		ids[n] = i
+	}
+	g := &graph{names: names, adj: make([][]int, len(names))}
+	for i, n := range names {
+		seen := make(map[int]bool)
+		for _, to := range edges(n) {
+			if j, ok := ids[to]; ok && !seen[j] {
+				seen[j] = true
+				g.adj[i] = append(g.adj[i], j)
+			}
+		}
+		sort.Ints(g.adj[i])
+	}
+	return g
+}
+
+// components returns the strongly connected components with a cycle in
+// them (more than one node, or a node importing itself), using Tarjan's
+// algorithm.
+func (g *graph) components() [][]int {
+	n := len(g.names)
+	index := make([]int, n)
+	low := make([]int, n)
+	onStack := make([]bool, n)
+	for i := range index {
+		index[i] = -1
+	}
+	var (
+		stack []int
+		comps [][]int
+		next  int
+	)
+
+	var visit func(v int)
+	visit = func(v int) {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range g.adj[v] {
+			switch {
+			case index[w] < 0:
+				visit(w)
+				low[v] = min(low[v], low[w])
+			case onStack[w]:
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var comp []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			comp = append(comp, w)
+			if w == v {
+				break
+			}
+		}
+		if len(comp) > 1 || g.selfLoop(v) {
+			comps = append(comps, comp)
+		}
+	}
+	for v := range g.names {
+		if index[v] < 0 {
+			visit(v)
+		}
+	}
+	return comps
+}
+
+func (g *graph) selfLoop(v int) bool {
+	for _, w := range g.adj[v] {
+		if w == v {
+			return true
+		}
+	}
+	return false
+}
+
+// shortestCycle returns the shortest cycle within comp, found by a
+// breadth-first search back to each member.
+func (g *graph) shortestCycle(comp []int) []string {
+	in := make(map[int]bool, len(comp))
+	for _, v := range comp {
+		in[v] = true
+	}
+	sort.Ints(comp)
+
+	var best []int
+	for _, start := range comp {
+		parent := map[int]int{start: -1}
+		queue := []int{start}
+		var found []int
+	search:
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			for _, w := range g.adj[v] {
+				if !in[w] {
+					continue
+				}
+				if w == start {
+					for u := v; u != -1; u = parent[u] {
+						found = append([]int{u}, found...)
+					}
+					found = append(found, start)
+					break search
+				}
+				if _, seen := parent[w]; !seen {
+					parent[w] = v
+					queue = append(queue, w)
+				}
+			}
+		}
+		if found != nil && (best == nil || len(found) < len(best)) {
+			best = found
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	path := make([]string, len(best))
+	for i, v := range best {
+		path[i] = g.names[v]
+	}
+	return path
+}
@@ -0,0 +1,166 @@
+// Package deadcode finds the functions, methods and types of a project that
+// nothing else in it refers to: candidates for deletion, or for a CI gate
+// that keeps new ones out.
+//
+// References are matched by name, so a symbol sharing its name with one in
+// use is assumed live. This errs towards missing dead code rather than
+// reporting live code.
+package deadcode
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Kinds of symbol.
+const (
+	KindFunction = "function"
+	KindMethod   = "method"
+	KindType     = "type"
+	KindClass    = "class"
+)
+
+// Options controls an analysis.
+type Options struct {
+	// Callers returns the number of callers of a function or "Type.Method",
+	// e.g. from the structural index's call graph. A symbol with callers is
+	// live even without a reference by name. Nil relies on references
+	// alone.
+	Callers func(name string) int
+	// ExportedOnly limits the report to exported symbols: capitalized in
+	// Go, not starting with an underscore in Python.
+	ExportedOnly bool
+}
+
+// Symbol is a declaration nothing refers to.
+type Symbol struct {
+	Name     string `json:"name"` // "Func", "Type.Method" or "Type"
+	Kind     string `json:"kind"`
+	File     string `json:"file"` // slash-separated, relative to the project
+	Line     int    `json:"line"`
+	Exported bool   `json:"exported"`
+}
+
+// Report is the result of an analysis.
+type Report struct {
+	Project string   `json:"project"`
+	Scanned int      `json:"scanned"` // declarations considered
+	Unused  []Symbol `json:"unused"`
+}
+
+// decl is a declaration found while scanning.
+type decl struct {
+	Symbol
+	lang string
+	// ref is the name references are counted under: the function, method
+	// or type name without its receiver.
+	ref string
+}
+
+// Analyze scans the Go and Python files under projectPath and reports the
+// declarations outside test files that are never referenced. References
+// from test files count, so code used only by tests is not reported.
+// Entry points and methods that are called implicitly (main, init,
+// String, dunder methods, decorated Python functions) are never reported.
+func Analyze(projectPath string, opts Options) (*Report, error) {
+	var decls []decl
+	refs := map[string]map[string]int{"go": {}, "python": {}}
+
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != projectPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		var scan func(file string, src []byte, test bool, refs map[string]int) ([]decl, error)
+		switch filepath.Ext(p) {
+		case ".go":
+			scan = scanGo
+		case ".py":
+			scan = scanPython
+		default:
+			return nil
+		}
+		rel, err := filepath.Rel(projectPath, p)
+		if err != nil {
+			return err
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		lang := strings.TrimPrefix(filepath.Ext(p), ".")
+		if lang == "py" {
+			lang = "python"
+		}
+		found, err := scan(filepath.ToSlash(rel), src, isTestFile(d.Name()), refs[lang])
+		if err != nil {
+			// Unparseable files are skipped, as the indexer does.
+			return nil
+		}
+		decls = append(decls, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan project: %w", err)
+	}
+
+	report := &Report{Project: projectPath, Unused: []Symbol{}}
+	for _, d := range decls {
+		if opts.ExportedOnly && !d.Exported {
+			continue
+		}
+		report.Scanned++
+		if refs[d.lang][d.ref] > 0 {
+			continue
+		}
+		if opts.Callers != nil && opts.Callers(d.Name) > 0 {
+			continue
+		}
+		report.Unused = append(report.Unused, d.Symbol)
+	}
+	sort.Slice(report.Unused, func(i, j int) bool {
+		a, b := report.Unused[i], report.Unused[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return report, nil
+}
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"venv":         true,
+	"__pycache__":  true,
+	"testdata":     true,
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go") ||
+		(strings.HasSuffix(name, ".py") && (strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test.py")))
+}
+
+// Format renders the report as a list of locations.
+func (r *Report) Format() string {
+	var b strings.Builder
+	if len(r.Unused) == 0 {
+		fmt.Fprintf(&b, "No unused functions or types in %s (%d checked).\n", r.Project, r.Scanned)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Unused functions and types in %s (%d of %d):\n\n", r.Project, len(r.Unused), r.Scanned)
+	for _, s := range r.Unused {
+		fmt.Fprintf(&b, "  %s:%d  %-8s  %s\n", s.File, s.Line, s.Kind, s.Name)
+	}
+	return b.String()
+}
@@ -0,0 +1,169 @@
+package deadcode
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// implicitMethods are Go methods typically called through an interface
+// outside the project (fmt, encoding/json, net/http, sort, io, errors,
+// database/sql), where no reference by name appears.
+var implicitMethods = map[string]bool{
+	"String": true, "GoString": true, "Format": true, "Error": true,
+	"Unwrap": true, "Is": true, "As": true,
+	"MarshalJSON": true, "UnmarshalJSON": true, "MarshalText": true, "UnmarshalText": true,
+	"MarshalYAML": true, "UnmarshalYAML": true, "MarshalBinary": true, "UnmarshalBinary": true,
+	"ServeHTTP": true, "Read": true, "Write": true, "Close": true,
+	"Len": true, "Less": true, "Swap": true, "Scan": true, "Value": true,
+}
+
+// scanGo records a Go file's identifier references in refs and returns its
+// top-level functions, methods and types, unless it is a test file.
+func scanGo(file string, src []byte, test bool, refs map[string]int) ([]decl, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	// Declaring names, and the receiver types of methods, are not
+	// references.
+	skip := make(map[*ast.Ident]bool)
+	var decls []decl
+	add := func(id *ast.Ident, name, kind string) {
+		skip[id] = true
+		if test {
+			return
+		}
+		decls = append(decls, decl{
+			Symbol: Symbol{Name: name, Kind: kind, File: file, Line: fset.Position(id.Pos()).Line, Exported: id.IsExported()},
+			lang:   "go",
+			ref:    id.Name,
+		})
+	}
+
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				if d.Name.Name == "main" || d.Name.Name == "init" || d.Name.Name == "_" {
+					skip[d.Name] = true
+					continue
+				}
+				add(d.Name, d.Name.Name, KindFunction)
+				continue
+			}
+			ast.Inspect(d.Recv, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok {
+					skip[id] = true
+				}
+				return true
+			})
+			if implicitMethods[d.Name.Name] {
+				skip[d.Name] = true
+				continue
+			}
+			recv := receiverType(d.Recv.List[0].Type)
+			add(d.Name, recv+"."+d.Name.Name, KindMethod)
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name != "_" {
+					add(ts.Name, ts.Name.Name, KindType)
+				}
+			}
+		}
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && !skip[id] {
+			refs[id.Name]++
+		}
+		return true
+	})
+	return decls, nil
+}
+
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+var (
+	pythonIdent = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	pythonDecl  = regexp.MustCompile(`^(\s*)(?:async\s+)?(def|class)\s+([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// scanPython records the identifiers of a Python file in refs and returns
+// its functions, methods and classes, unless it is a test file. Strings
+// and comments count as references, which keeps names used through
+// getattr or registries alive.
+func scanPython(file string, src []byte, test bool, refs map[string]int) ([]decl, error) {
+	type class struct {
+		indent int
+		name   string
+	}
+	var (
+		classes   []class
+		decls     []decl
+		decorated bool
+	)
+
+	for i, line := range strings.Split(string(src), "\n") {
+		trimmed := strings.TrimSpace(line)
+		m := pythonDecl.FindStringSubmatch(line)
+		if m == nil {
+			for _, id := range pythonIdent.FindAllString(line, -1) {
+				refs[id]++
+			}
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				decorated = strings.HasPrefix(trimmed, "@")
+			}
+			continue
+		}
+
+		indent, kind, name := len(m[1]), m[2], m[3]
+		// Everything after the declared name (base classes, parameter
+		// defaults and annotations) is a reference.
+		for _, id := range pythonIdent.FindAllString(line[len(m[0]):], -1) {
+			refs[id]++
+		}
+		for len(classes) > 0 && classes[len(classes)-1].indent >= indent {
+			classes = classes[:len(classes)-1]
+		}
+
+		symbol := Symbol{Name: name, Kind: KindFunction, File: file, Line: i + 1, Exported: !strings.HasPrefix(name, "_")}
+		switch {
+		case kind == "class":
+			symbol.Kind = KindClass
+		case len(classes) > 0:
+			symbol.Kind = KindMethod
+			symbol.Name = classes[len(classes)-1].name + "." + name
+		}
+		// Decorated functions are registered with a framework (routes, CLI
+		// commands, fixtures), and dunder methods are called by Python.
+		implicit := decorated || (strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__"))
+		if !test && !implicit {
+			decls = append(decls, decl{Symbol: symbol, lang: "python", ref: name})
+		}
+		if kind == "class" {
+			classes = append(classes, class{indent: indent, name: name})
+		}
+		decorated = false
+	}
+	return decls, nil
+}
@@ -0,0 +1,197 @@
+// Package deps reads dependency manifests and lockfiles (go.mod,
+// package.json with package-lock.json, requirements.txt) so tasks that
+// mention a library can be tied to the packages and versions involved.
+package deps
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Dependency is one package required by a manifest.
+type Dependency struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`   // resolved (lockfile) or pinned version
+	Specifier string `json:"specifier,omitempty"` // constraint as written (npm or pip range)
+	Ecosystem string `json:"ecosystem"`           // "go", "npm", "pypi"
+	Manifest  string `json:"manifest"`            // project-relative path of the declaring file
+	Direct    bool   `json:"direct"`              // declared directly rather than pulled in transitively
+	Dev       bool   `json:"dev,omitempty"`       // development-only dependency
+}
+
+func (d Dependency) String() string {
+	version := d.Version
+	if version == "" {
+		version = d.Specifier
+	}
+	s := d.Name
+	if version != "" {
+		s += " " + version
+	}
+	var tags []string
+	if !d.Direct {
+		tags = append(tags, "indirect")
+	}
+	if d.Dev {
+		tags = append(tags, "dev")
+	}
+	if len(tags) > 0 {
+		s += " (" + strings.Join(tags, ", ") + ")"
+	}
+	return s
+}
+
+// parser reads one kind of manifest. dir is the manifest's directory, used
+// to find a sibling lockfile.
+type parser func(path, dir string) ([]Dependency, error)
+
+var parsers = map[string]parser{
+	"go.mod":       parseGoMod,
+	"package.json": parsePackageJSON,
+}
+
+// skipDirs are never searched for manifests.
+var skipDirs = map[string]bool{
+	".git": true, ".index": true, "node_modules": true, "vendor": true,
+	"venv": true, ".venv": true, "__pycache__": true, "dist": true, "build": true,
+}
+
+// Scan finds every manifest under root and returns the dependencies they
+// declare, sorted by manifest then name.
+func Scan(root string) ([]Dependency, error) {
+	var all []Dependency
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		parse, ok := parsers[d.Name()]
+		if !ok && isRequirementsFile(d.Name()) {
+			parse, ok = parseRequirements, true
+		}
+		if !ok {
+			return nil
+		}
+
+		found, err := parse(path, filepath.Dir(path))
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		rel, _ := filepath.Rel(root, path)
+		for i := range found {
+			found[i].Manifest = filepath.ToSlash(rel)
+		}
+		all = append(all, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Manifest != all[j].Manifest {
+			return all[i].Manifest < all[j].Manifest
+		}
+		return all[i].Name < all[j].Name
+	})
+	return all, nil
+}
+
+// Direct filters deps down to directly declared dependencies.
+func Direct(deps []Dependency) []Dependency {
+	var out []Dependency
+	for _, d := range deps {
+		if d.Direct {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// genericTokens are name components too common to identify a package.
+var genericTokens = map[string]bool{
+	"com": true, "org": true, "net": true, "io": true, "dev": true, "www": true,
+	"github": true, "gitlab": true, "golang": true, "google": true, "go": true,
+	"lib": true, "js": true, "py": true, "python": true, "types": true,
+	"core": true, "api": true, "sdk": true, "client": true, "utils": true,
+}
+
+// Match returns the direct dependencies whose name, or a distinctive part of
+// it, appears in text (e.g. "upgrade the sqlite driver" matches
+// modernc.org/sqlite).
+func Match(deps []Dependency, text string) []Dependency {
+	words := make(map[string]bool)
+	lower := strings.ToLower(text)
+	for _, w := range strings.FieldsFunc(lower, splitName) {
+		words[w] = true
+	}
+
+	var out []Dependency
+	for _, d := range deps {
+		if !d.Direct {
+			continue
+		}
+		name := strings.ToLower(d.Name)
+		if strings.Contains(lower, name) {
+			out = append(out, d)
+			continue
+		}
+		for _, tok := range strings.FieldsFunc(name, splitName) {
+			if len(tok) >= 3 && !genericTokens[tok] && !isVersionToken(tok) && words[tok] {
+				out = append(out, d)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func splitName(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// isVersionToken reports whether tok is a major-version path element like "v2".
+func isVersionToken(tok string) bool {
+	if len(tok) < 2 || tok[0] != 'v' {
+		return false
+	}
+	for _, r := range tok[1:] {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Format renders deps as an indented list grouped by manifest.
+func Format(deps []Dependency) string {
+	var b strings.Builder
+	manifest := ""
+	for _, d := range deps {
+		if d.Manifest != manifest {
+			manifest = d.Manifest
+			fmt.Fprintf(&b, "%s (%s):\n", manifest, d.Ecosystem)
+		}
+		fmt.Fprintf(&b, "  - %s\n", d)
+	}
+	return b.String()
+}
+
+func readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
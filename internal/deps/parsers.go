@@ -0,0 +1,159 @@
+package deps
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// parseGoMod reads require directives; "// indirect" marks transitive ones.
+func parseGoMod(path, _ string) ([]Dependency, error) {
+	content, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []Dependency
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inBlock:
+			continue
+		}
+
+		spec, comment, _ := strings.Cut(line, "//")
+		fields := strings.Fields(spec)
+		if len(fields) < 2 {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Name:      fields[0],
+			Version:   fields[1],
+			Ecosystem: "go",
+			Direct:    !strings.Contains(comment, "indirect"),
+		})
+	}
+	return deps, nil
+}
+
+// parsePackageJSON reads dependencies and devDependencies, resolving exact
+// versions from a sibling package-lock.json when present. Locked packages
+// not declared in package.json are reported as indirect.
+func parsePackageJSON(path, dir string) ([]Dependency, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+
+	locked := readPackageLock(filepath.Join(dir, "package-lock.json"))
+
+	var deps []Dependency
+	declared := make(map[string]bool)
+	add := func(specs map[string]string, dev bool) {
+		for name, spec := range specs {
+			declared[name] = true
+			d := Dependency{Name: name, Specifier: spec, Ecosystem: "npm", Direct: true, Dev: dev}
+			if v, ok := locked[name]; ok {
+				d.Version = v
+			}
+			deps = append(deps, d)
+		}
+	}
+	add(pkg.Dependencies, false)
+	add(pkg.DevDependencies, true)
+
+	for name, version := range locked {
+		if !declared[name] {
+			deps = append(deps, Dependency{Name: name, Version: version, Ecosystem: "npm"})
+		}
+	}
+	return deps, nil
+}
+
+// readPackageLock maps top-level installed packages to their versions
+// (lockfile v2/v3 "packages" layout). A missing or unreadable lockfile
+// yields an empty map.
+func readPackageLock(path string) map[string]string {
+	versions := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return versions
+	}
+	var lock struct {
+		Packages map[string]struct {
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	if json.Unmarshal(data, &lock) != nil {
+		return versions
+	}
+	for key, p := range lock.Packages {
+		name, ok := strings.CutPrefix(key, "node_modules/")
+		if !ok || strings.Contains(name, "/node_modules/") {
+			continue
+		}
+		versions[name] = p.Version
+	}
+	return versions
+}
+
+// requirementPattern matches "name[extras] <op> version" in requirements files.
+var requirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?\s*(.*)$`)
+
+func isRequirementsFile(name string) bool {
+	return strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt")
+}
+
+// parseRequirements reads a pip requirements file. Files named like
+// requirements-dev.txt are treated as development dependencies.
+func parseRequirements(path, _ string) ([]Dependency, error) {
+	content, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Base(path)
+	dev := strings.Contains(base, "dev") || strings.Contains(base, "test")
+
+	var deps []Dependency
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line, _, _ = strings.Cut(line, ";") // environment markers
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		m := requirementPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		d := Dependency{Name: m[1], Ecosystem: "pypi", Direct: true, Dev: dev}
+		spec := strings.TrimSpace(m[2])
+		if v, ok := strings.CutPrefix(spec, "=="); ok {
+			d.Version = strings.TrimSpace(v)
+		} else {
+			d.Specifier = spec
+		}
+		deps = append(deps, d)
+	}
+	return deps, nil
+}
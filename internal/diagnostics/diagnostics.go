@@ -0,0 +1,261 @@
+// Package diagnostics runs the project's compilers and linters and collects
+// their findings so the agent starts a task knowing what is already broken.
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Diagnostic is a single compiler or linter finding.
+type Diagnostic struct {
+	Tool    string `json:"tool"`
+	File    string `json:"file"` // project-relative, slash-separated
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: [%s] %s", d.File, d.Line, d.Tool, d.Message)
+}
+
+// Tool describes a diagnostics command and how to recognise projects it applies to.
+type Tool struct {
+	Name    string
+	Command []string
+	// Markers are project-root files whose presence enables the tool.
+	Markers []string
+	parse   func(line string) (Diagnostic, bool)
+}
+
+var (
+	colonPattern = regexp.MustCompile(`^(\S+?):(\d+)(?::(\d+))?:\s*(.+)$`)
+	tscPattern   = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\):\s*(.+)$`)
+)
+
+func parseColon(line string) (Diagnostic, bool) {
+	m := colonPattern.FindStringSubmatch(strings.TrimPrefix(line, "vet: "))
+	if m == nil {
+		return Diagnostic{}, false
+	}
+	ln, _ := strconv.Atoi(m[2])
+	col, _ := strconv.Atoi(m[3])
+	return Diagnostic{File: m[1], Line: ln, Column: col, Message: m[4]}, true
+}
+
+func parseTSC(line string) (Diagnostic, bool) {
+	m := tscPattern.FindStringSubmatch(line)
+	if m == nil {
+		return Diagnostic{}, false
+	}
+	ln, _ := strconv.Atoi(m[2])
+	col, _ := strconv.Atoi(m[3])
+	return Diagnostic{File: m[1], Line: ln, Column: col, Message: m[4]}, true
+}
+
+// DefaultTools are the built-in collectors.
+var DefaultTools = []Tool{
+	{Name: "go vet", Command: []string{"go", "vet", "./..."}, Markers: []string{"go.mod"}, parse: parseColon},
+	{Name: "tsc", Command: []string{"npx", "--no-install", "tsc", "--noEmit", "--pretty", "false"}, Markers: []string{"tsconfig.json"}, parse: parseTSC},
+	{Name: "ruff", Command: []string{"ruff", "check", "--output-format=concise", "--no-fix", "."}, Markers: []string{"pyproject.toml", "ruff.toml", ".ruff.toml", "setup.py", "requirements.txt"}, parse: parseColon},
+}
+
+// Collector runs the applicable tools for a project and caches their
+// results until Invalidate is called.
+type Collector struct {
+	projectRoot string
+	tools       []Tool
+	timeout     time.Duration
+
+	mu     sync.Mutex
+	cached []Diagnostic
+	valid  bool
+}
+
+// CollectorConfig configures a Collector.
+type CollectorConfig struct {
+	ProjectRoot string
+	// Tools restricts collection to these tool names (default: all that apply).
+	Tools []string
+	// Timeout bounds each tool run (default 2 minutes).
+	Timeout time.Duration
+}
+
+// NewCollector creates a collector for the project.
+func NewCollector(cfg CollectorConfig) *Collector {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+
+	var tools []Tool
+	for _, t := range DefaultTools {
+		if len(cfg.Tools) > 0 && !contains(cfg.Tools, t.Name) {
+			continue
+		}
+		if applies(cfg.ProjectRoot, t) {
+			tools = append(tools, t)
+		}
+	}
+
+	return &Collector{
+		projectRoot: cfg.ProjectRoot,
+		tools:       tools,
+		timeout:     timeout,
+	}
+}
+
+// Tools returns the names of the tools the collector will run.
+func (c *Collector) Tools() []string {
+	names := make([]string, len(c.tools))
+	for i, t := range c.tools {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// Invalidate drops cached results, e.g. after the agent edits files.
+func (c *Collector) Invalidate() {
+	c.mu.Lock()
+	c.valid = false
+	c.mu.Unlock()
+}
+
+// Collect returns the current diagnostics, running the tools if the cache
+// is stale. Tools that are missing or time out are skipped.
+func (c *Collector) Collect(ctx context.Context) []Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.valid {
+		return c.cached
+	}
+
+	var diags []Diagnostic
+	for _, t := range c.tools {
+		diags = append(diags, c.run(ctx, t)...)
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].File != diags[j].File {
+			return diags[i].File < diags[j].File
+		}
+		return diags[i].Line < diags[j].Line
+	})
+
+	c.cached, c.valid = diags, true
+	return diags
+}
+
+func (c *Collector) run(ctx context.Context, t Tool) []Diagnostic {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, t.Command[0], t.Command[1:]...)
+	cmd.Dir = c.projectRoot
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	// Linters exit non-zero when they find something; only the output matters.
+	_ = cmd.Run()
+
+	var diags []Diagnostic
+	for _, line := range strings.Split(out.String(), "\n") {
+		d, ok := t.parse(strings.TrimSpace(line))
+		if !ok {
+			continue
+		}
+		d.Tool = t.Name
+		d.File = c.rel(d.File)
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+func (c *Collector) rel(path string) string {
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(c.projectRoot, path); err == nil {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// Format renders the diagnostics that touch files (all diagnostics when
+// files is empty), at most limit of them, noting how many were omitted.
+func Format(diags []Diagnostic, files []string, limit int) string {
+	relevant := diags
+	if len(files) > 0 {
+		want := make(map[string]bool, len(files))
+		for _, f := range files {
+			want[filepath.ToSlash(filepath.Clean(f))] = true
+		}
+		relevant = nil
+		for _, d := range diags {
+			if want[d.File] || matchesSuffix(want, d.File) {
+				relevant = append(relevant, d)
+			}
+		}
+	}
+	if len(relevant) == 0 {
+		if len(diags) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("(%d existing diagnostic(s) in other files)\n", len(diags))
+	}
+
+	var b strings.Builder
+	shown := relevant
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+	for _, d := range shown {
+		b.WriteString(d.String())
+		b.WriteString("\n")
+	}
+	if omitted := len(diags) - len(shown); omitted > 0 {
+		fmt.Fprintf(&b, "(%d more diagnostic(s) not shown)\n", omitted)
+	}
+	return b.String()
+}
+
+// matchesSuffix allows module-relative paths from the index to match
+// project-relative diagnostic paths (and vice versa).
+func matchesSuffix(want map[string]bool, file string) bool {
+	for f := range want {
+		if strings.HasSuffix(file, "/"+f) || strings.HasSuffix(f, "/"+file) {
+			return true
+		}
+	}
+	return false
+}
+
+func applies(root string, t Tool) bool {
+	if _, err := exec.LookPath(t.Command[0]); err != nil {
+		return false
+	}
+	for _, marker := range t.Markers {
+		if _, err := os.Stat(filepath.Join(root, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
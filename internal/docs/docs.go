@@ -0,0 +1,303 @@
+// Package docs splits documentation (READMEs, ADRs, docs/) into sections
+// at its headings, so "how does X work" questions can be answered from
+// prose as well as code: sections are searchable by title like symbols,
+// and become RAG chunks with heading boundaries.
+package docs
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Section is the text under one heading, up to the next heading of any
+// level.
+type Section struct {
+	Title string `json:"title"`
+	// Trail is the headings enclosing the section, outermost first and
+	// ending with Title.
+	Trail []string `json:"trail"`
+	// Level is the heading level, 1 for top-level headings; 0 for the text
+	// before a file's first heading, titled after the file.
+	Level   int    `json:"level"`
+	File    string `json:"file"`
+	Line    int    `json:"line"` // 1-based line of the heading
+	EndLine int    `json:"end_line"`
+	Content string `json:"content"` // the heading and its body
+}
+
+// Name is the section's heading trail, "Install > Linux".
+func (s Section) Name() string {
+	return strings.Join(s.Trail, " > ")
+}
+
+// maxFileSize bounds the documentation files Load reads.
+const maxFileSize = 1 << 20
+
+// IsDocFile reports whether path is a documentation format docs parses.
+func IsDocFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".rst", ".adoc":
+		return true
+	}
+	return false
+}
+
+// Load parses every documentation file of the project. Section files are
+// slash-separated and relative to projectPath.
+func Load(projectPath string) ([]Section, error) {
+	var sections []Section
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != projectPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !IsDocFile(p) {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(projectPath, p)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, Parse(filepath.ToSlash(rel), string(src))...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load docs: %w", err)
+	}
+	return sections, nil
+}
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"venv":         true,
+	"__pycache__":  true,
+}
+
+// heading is a heading found by Parse and the line it starts on.
+type heading struct {
+	title       string
+	level, line int
+}
+
+// Parse splits the documentation in content into sections. Markdown ATX
+// ("## Title") and setext (underlined) headings, reStructuredText
+// underlined and overlined headings, and AsciiDoc "== Title" headings are
+// recognized; headings inside fenced code blocks are not.
+func Parse(file, content string) []Section {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	var headings []heading
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".rst":
+		headings = rstHeadings(lines)
+	case ".adoc":
+		headings = adocHeadings(lines)
+	default:
+		headings = markdownHeadings(lines)
+	}
+
+	var (
+		sections []Section
+		trail    []string
+		levels   []int
+	)
+	add := func(title string, level, start, end int) {
+		body := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(body) == "" {
+			return
+		}
+		sections = append(sections, Section{
+			Title:   title,
+			Trail:   append([]string(nil), trail...),
+			Level:   level,
+			File:    file,
+			Line:    start + 1,
+			EndLine: end,
+			Content: strings.TrimRight(body, "\n"),
+		})
+	}
+
+	first := len(lines)
+	if len(headings) > 0 {
+		first = headings[0].line
+	}
+	if start := frontMatterEnd(lines); start < first {
+		title := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		trail = []string{title}
+		add(title, 0, start, first)
+	}
+	for i, h := range headings {
+		for len(levels) > 0 && levels[len(levels)-1] >= h.level {
+			levels, trail = levels[:len(levels)-1], trail[:len(trail)-1]
+		}
+		if len(levels) == 0 {
+			trail = trail[:0]
+		}
+		levels, trail = append(levels, h.level), append(trail, h.title)
+		end := len(lines)
+		if i+1 < len(headings) {
+			end = headings[i+1].line
+		}
+		add(h.title, h.level, h.line, end)
+	}
+	return sections
+}
+
+// frontMatterEnd returns the first line after a Markdown file's YAML
+// front matter, or 0 without one.
+func frontMatterEnd(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if t := strings.TrimSpace(lines[i]); t == "---" || t == "..." {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func markdownHeadings(lines []string) []heading {
+	var headings []heading
+	fence := ""
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if i == 0 && trimmed == "---" {
+			if end := frontMatterEnd(lines); end > 0 {
+				i = end - 1
+				continue
+			}
+		}
+		if level, title, ok := atxHeading(trimmed); ok {
+			headings = append(headings, heading{title: title, level: level, line: i})
+			continue
+		}
+		// Setext: a paragraph line underlined with === (level 1) or
+		// --- (level 2). Indented lines and list items are not titles.
+		if i+1 < len(lines) && trimmed != "" && !strings.HasPrefix(lines[i], "    ") &&
+			!strings.HasPrefix(trimmed, "- ") && !strings.HasPrefix(trimmed, "* ") && !strings.HasPrefix(trimmed, "|") &&
+			(i == 0 || strings.TrimSpace(lines[i-1]) == "") {
+			under := strings.TrimSpace(lines[i+1])
+			if c, ok := repeated(under, "=-"); ok && len(under) >= 2 {
+				level := 1
+				if c == '-' {
+					level = 2
+				}
+				headings = append(headings, heading{title: trimmed, level: level, line: i})
+				i++
+			}
+		}
+	}
+	return headings
+}
+
+// atxHeading parses "## Title ##".
+func atxHeading(line string) (level int, title string, ok bool) {
+	rest := strings.TrimLeft(line, "#")
+	level = len(line) - len(rest)
+	if level < 1 || level > 6 || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return 0, "", false
+	}
+	title = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#"))
+	return level, title, title != ""
+}
+
+// rstAdornments are the characters reStructuredText section titles are
+// underlined with.
+const rstAdornments = "=-`:'\"~^_*+#<>."
+
+// rstHeadings finds underlined and overlined titles. Levels follow the
+// order adornment styles first appear in, as in reStructuredText.
+func rstHeadings(lines []string) []heading {
+	var (
+		headings []heading
+		styles   []string
+	)
+	level := func(style string) int {
+		for i, s := range styles {
+			if s == style {
+				return i + 1
+			}
+		}
+		styles = append(styles, style)
+		return len(styles)
+	}
+	for i := 0; i+1 < len(lines); i++ {
+		title := strings.TrimSpace(lines[i])
+		if title == "" {
+			continue
+		}
+		// Overline, title, underline.
+		if c, ok := repeated(title, rstAdornments); ok && len(title) >= 3 && i+2 < len(lines) {
+			text := strings.TrimSpace(lines[i+1])
+			if text != "" && strings.TrimSpace(lines[i+2]) == title {
+				headings = append(headings, heading{title: text, level: level("over" + string(c)), line: i})
+				i += 2
+				continue
+			}
+		}
+		under := strings.TrimRight(lines[i+1], " \t")
+		if c, ok := repeated(under, rstAdornments); ok && len(under) >= len(title) && len(under) >= 3 &&
+			!strings.HasPrefix(lines[i], " ") && (i == 0 || strings.TrimSpace(lines[i-1]) == "") {
+			headings = append(headings, heading{title: title, level: level(string(c)), line: i})
+			i++
+		}
+	}
+	return headings
+}
+
+// adocHeadings finds "= Title" through "====== Title" outside listing
+// blocks.
+func adocHeadings(lines []string) []heading {
+	var headings []heading
+	inBlock := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "----" || trimmed == "...." {
+			inBlock = !inBlock
+			continue
+		}
+		if inBlock {
+			continue
+		}
+		rest := strings.TrimLeft(line, "=")
+		level := len(line) - len(rest)
+		if level >= 1 && level <= 6 && strings.HasPrefix(rest, " ") && strings.TrimSpace(rest) != "" {
+			headings = append(headings, heading{title: strings.TrimSpace(rest), level: level, line: i})
+		}
+	}
+	return headings
+}
+
+// repeated reports whether s is one character from set, repeated.
+func repeated(s, set string) (byte, bool) {
+	if s == "" || !strings.Contains(set, s[:1]) {
+		return 0, false
+	}
+	return s[0], strings.Count(s, s[:1]) == len(s)
+}
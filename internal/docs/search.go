@@ -0,0 +1,143 @@
+package docs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Result is a section matching a query.
+type Result struct {
+	Section
+	Score float64 `json:"score"`
+}
+
+// stopWords are left out of queries; they match every section.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "be": true, "by": true,
+	"do": true, "does": true, "for": true, "from": true, "how": true, "i": true, "in": true,
+	"is": true, "it": true, "of": true, "on": true, "or": true, "the": true, "this": true,
+	"to": true, "what": true, "when": true, "where": true, "which": true, "why": true, "with": true,
+}
+
+// Search ranks sections by how well they match query, best first, and
+// returns at most limit of them (all with limit <= 0). A query word in a
+// section's title counts most, then in an enclosing heading, then in the
+// body.
+func Search(sections []Section, query string, limit int) []Result {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	phrase := strings.ToLower(strings.TrimSpace(query))
+
+	var results []Result
+	for _, s := range sections {
+		title := strings.ToLower(s.Title)
+		parents := strings.ToLower(strings.Join(s.Trail[:max(len(s.Trail)-1, 0)], " "))
+		body := strings.ToLower(s.Content)
+
+		score, matched := 0.0, 0
+		for _, t := range terms {
+			hit := false
+			if strings.Contains(title, t) {
+				score += 3
+				hit = true
+			} else if strings.Contains(parents, t) {
+				score += 1
+				hit = true
+			}
+			if n := strings.Count(body, t); n > 0 {
+				score += 0.5 * float64(min(n, 5))
+				hit = true
+			}
+			if hit {
+				matched++
+			}
+		}
+		if matched == 0 {
+			continue
+		}
+		// Sections matching every word beat those matching many times.
+		score *= float64(matched) / float64(len(terms))
+		if title == phrase {
+			score += 5
+		}
+		results = append(results, Result{Section: s, Score: score})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].File != results[j].File {
+			return results[i].File < results[j].File
+		}
+		return results[i].Line < results[j].Line
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// ByTitle returns the sections whose title contains name, ignoring case,
+// the way symbols are looked up by name.
+func ByTitle(sections []Section, name string) []Section {
+	name = strings.ToLower(name)
+	var found []Section
+	for _, s := range sections {
+		if strings.Contains(strings.ToLower(s.Title), name) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// Terms splits a query into the lowercase words Search matches, without
+// stop words and one-letter words.
+func Terms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-'
+	}) {
+		w = strings.Trim(w, "-")
+		if len(w) < 2 || stopWords[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		terms = append(terms, w)
+	}
+	return terms
+}
+
+// Format renders results as a list of "file:line  Heading > Trail" with
+// the first lines of each section's body, at most bodyLines of them.
+func Format(results []Result, bodyLines int) string {
+	var b strings.Builder
+	for _, r := range results {
+		fmt.Fprintf(&b, "%s:%d  %s\n", r.File, r.Line, r.Name())
+		if bodyLines <= 0 {
+			continue
+		}
+		n := 0
+		lines := strings.Split(r.Content, "\n")
+		if r.Level > 0 {
+			lines = lines[1:] // the heading
+		}
+		for _, line := range lines {
+			if n == bodyLines {
+				b.WriteString("    ...\n")
+				break
+			}
+			if strings.TrimSpace(line) == "" || strings.Trim(line, rstAdornments+" ") == "" {
+				continue
+			}
+			fmt.Fprintf(&b, "    %s\n", strings.TrimRight(line, " \t"))
+			n++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,301 @@
+package eval
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yourorg/agent/internal/agent"
+)
+
+// lineChanges counts the added and removed lines of one file's diff,
+// keyed by the line with surrounding whitespace trimmed.
+type lineChanges struct {
+	added   map[string]int
+	removed map[string]int
+}
+
+func (c *lineChanges) add(kind byte, line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	m := &c.added
+	if kind == '-' {
+		m = &c.removed
+	}
+	if *m == nil {
+		*m = make(map[string]int)
+	}
+	(*m)[line]++
+}
+
+func (c *lineChanges) equal(o *lineChanges) bool {
+	return equalCounts(c.added, o.added) && equalCounts(c.removed, o.removed)
+}
+
+func (c *lineChanges) counts() string {
+	return fmt.Sprintf("+%d/-%d", total(c.added), total(c.removed))
+}
+
+func equalCounts(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func total(m map[string]int) int {
+	n := 0
+	for _, v := range m {
+		n += v
+	}
+	return n
+}
+
+// checkDiff compares the changes made in workDir, relative to the fixture,
+// with the expected diff file.
+func checkDiff(fixture, workDir, expectPath string) error {
+	data, err := os.ReadFile(expectPath)
+	if err != nil {
+		return fmt.Errorf("read expected diff: %w", err)
+	}
+	expected, err := parseDiff(string(data))
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", expectPath, err)
+	}
+
+	diff, err := treeDiff(fixture, workDir)
+	if err != nil {
+		return fmt.Errorf("diff work dir: %w", err)
+	}
+	actual, err := parseDiff(diff)
+	if err != nil {
+		return fmt.Errorf("parse run diff: %w", err)
+	}
+
+	var problems []string
+	for _, path := range sortedKeys(expected) {
+		got, ok := actual[path]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s: expected change not made", path))
+		case !got.equal(expected[path]):
+			problems = append(problems, fmt.Sprintf("%s: changes differ (expected %s, got %s)",
+				path, expected[path].counts(), got.counts()))
+		}
+	}
+	for _, path := range sortedKeys(actual) {
+		if _, ok := expected[path]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: unexpected change", path))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("diff does not match expected: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]*lineChanges) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseDiff reads a unified diff (plain or git format) into per-file line
+// changes, keyed by the slash-separated path without a/ or b/ prefix.
+func parseDiff(text string) (map[string]*lineChanges, error) {
+	files := make(map[string]*lineChanges)
+	var (
+		oldPath, newPath string
+		current          *lineChanges
+		oldLeft, newLeft int
+	)
+
+	sc := bufio.NewScanner(strings.NewReader(text))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+
+		// Inside a hunk the counts decide what is content, so added lines
+		// that start with "++" are not mistaken for headers.
+		if oldLeft > 0 || newLeft > 0 {
+			if line == "" {
+				line = " "
+			}
+			switch line[0] {
+			case '+':
+				current.add('+', line[1:])
+				newLeft--
+			case '-':
+				current.add('-', line[1:])
+				oldLeft--
+			case '\\':
+			default:
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			oldPath = diffPath(line[4:])
+		case strings.HasPrefix(line, "+++ "):
+			newPath = diffPath(line[4:])
+			path := newPath
+			if path == "" {
+				path = oldPath
+			}
+			if path == "" {
+				return nil, fmt.Errorf("file header without a path")
+			}
+			if files[path] == nil {
+				files[path] = &lineChanges{}
+			}
+			current = files[path]
+		case strings.HasPrefix(line, "@@ "):
+			if current == nil {
+				return nil, fmt.Errorf("hunk before file header: %s", line)
+			}
+			var err error
+			oldLeft, newLeft, err = hunkCounts(line)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Files whose only changes are blank lines or whitespace do not count.
+	for path, c := range files {
+		if len(c.added) == 0 && len(c.removed) == 0 {
+			delete(files, path)
+		}
+	}
+	return files, sc.Err()
+}
+
+// diffPath strips the a/ or b/ prefix and any timestamp from a file header
+// path. /dev/null yields "".
+func diffPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+// hunkCounts parses the line counts of a "@@ -a,b +c,d @@" header.
+func hunkCounts(header string) (oldCount, newCount int, err error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return 0, 0, fmt.Errorf("malformed hunk header: %s", header)
+	}
+	count := func(r string) (int, error) {
+		_, n, ok := strings.Cut(r[1:], ",")
+		if !ok {
+			return 1, nil
+		}
+		return strconv.Atoi(n)
+	}
+	if oldCount, err = count(fields[1]); err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk header: %s", header)
+	}
+	if newCount, err = count(fields[2]); err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk header: %s", header)
+	}
+	return oldCount, newCount, nil
+}
+
+// treeDiff returns the unified diff turning the files under oldRoot into
+// those under newRoot. VCS metadata and indexes are skipped.
+func treeDiff(oldRoot, newRoot string) (string, error) {
+	oldFiles, err := listFiles(oldRoot)
+	if err != nil {
+		return "", err
+	}
+	newFiles, err := listFiles(newRoot)
+	if err != nil {
+		return "", err
+	}
+
+	paths := make(map[string]bool)
+	for p := range oldFiles {
+		paths[p] = true
+	}
+	for p := range newFiles {
+		paths[p] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	for _, p := range sorted {
+		_, inOld := oldFiles[p]
+		_, inNew := newFiles[p]
+		var oldText, newText string
+		if inOld {
+			data, err := os.ReadFile(filepath.Join(oldRoot, p))
+			if err != nil {
+				return "", err
+			}
+			oldText = string(data)
+		}
+		if inNew {
+			data, err := os.ReadFile(filepath.Join(newRoot, p))
+			if err != nil {
+				return "", err
+			}
+			newText = string(data)
+		}
+		b.WriteString(agent.UnifiedDiff(filepath.ToSlash(p), oldText, newText, !inOld, !inNew))
+	}
+	return b.String(), nil
+}
+
+// listFiles returns the regular files under root, relative to it.
+func listFiles(root string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (d.Name() == ".git" || d.Name() == ".index") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[rel] = true
+		return nil
+	})
+	return files, err
+}
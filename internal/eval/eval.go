@@ -0,0 +1,214 @@
+// Package eval benchmarks the coding agent: it runs a suite of scripted
+// tasks against fixture repositories for each provider/model under test and
+// reports pass rates, token usage and cost.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Suite is a set of benchmark cases, usually loaded from a JSON file.
+type Suite struct {
+	Name  string `json:"name,omitempty"`
+	Cases []Case `json:"cases"`
+	// Pricing maps a model name to its blended price in USD per million
+	// tokens, used to estimate cost.
+	Pricing map[string]float64 `json:"pricing,omitempty"`
+}
+
+// Case is one scripted task. A case passes when every success criterion it
+// sets holds after the run; with none set it passes when the agent
+// completes every planned task.
+type Case struct {
+	Name string `json:"name"`
+	// Repo is the fixture repository, relative to the suite file. Each run
+	// works on a fresh copy of it.
+	Repo string `json:"repo"`
+	Task string `json:"task"`
+	// ExpectDiff is a unified diff file, relative to the suite file. The
+	// run must add and remove the same lines in the same files; context,
+	// hunk positions, blank lines and surrounding whitespace are ignored.
+	ExpectDiff string `json:"expect_diff,omitempty"`
+	// TestCommand is a shell command run in the changed repository that
+	// must exit 0, e.g. "go test ./...".
+	TestCommand string `json:"test_command,omitempty"`
+	// TimeoutSeconds bounds the agent run (default 10 minutes).
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// MaxIterations bounds the actions per task (default 20).
+	MaxIterations int `json:"max_iterations,omitempty"`
+}
+
+// LoadSuite reads a suite from path and resolves its fixture and diff paths
+// against the file's directory.
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read suite: %w", err)
+	}
+	var suite Suite
+	if err := json.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range suite.Cases {
+		c := &suite.Cases[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case-%d", i+1)
+		}
+		if c.Repo == "" || c.Task == "" {
+			return nil, fmt.Errorf("case %s: repo and task are required", c.Name)
+		}
+		c.Repo = resolve(dir, c.Repo)
+		if c.ExpectDiff != "" {
+			c.ExpectDiff = resolve(dir, c.ExpectDiff)
+		}
+	}
+	return &suite, nil
+}
+
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// Filter returns the cases whose names are in names, or all of them when
+// names is empty.
+func (s *Suite) Filter(names []string) []Case {
+	if len(names) == 0 {
+		return s.Cases
+	}
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		want[n] = true
+	}
+	var cases []Case
+	for _, c := range s.Cases {
+		if want[c.Name] {
+			cases = append(cases, c)
+		}
+	}
+	return cases
+}
+
+// Target is a provider and model to benchmark.
+type Target struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"` // empty uses the provider's default
+}
+
+// ParseTarget parses "provider" or "provider:model".
+func ParseTarget(s string) (Target, error) {
+	provider, model, _ := strings.Cut(strings.TrimSpace(s), ":")
+	if provider == "" {
+		return Target{}, fmt.Errorf("invalid target %q: want provider or provider:model", s)
+	}
+	return Target{Provider: provider, Model: model}, nil
+}
+
+func (t Target) String() string {
+	if t.Model == "" {
+		return t.Provider
+	}
+	return t.Provider + ":" + t.Model
+}
+
+// Result is the outcome of one case for one target.
+type Result struct {
+	Case           string        `json:"case"`
+	Target         Target        `json:"target"`
+	Model          string        `json:"model"` // as reported by the client
+	Passed         bool          `json:"passed"`
+	Reason         string        `json:"reason,omitempty"` // why the case failed
+	TasksCompleted int           `json:"tasks_completed"`
+	TasksTotal     int           `json:"tasks_total"`
+	TokensUsed     int           `json:"tokens_used"`
+	CostUSD        float64       `json:"cost_usd,omitempty"`
+	Duration       time.Duration `json:"duration_ns"`
+	WorkDir        string        `json:"work_dir,omitempty"` // kept for inspection
+}
+
+// TargetSummary aggregates a target's results.
+type TargetSummary struct {
+	Target     Target        `json:"target"`
+	Cases      int           `json:"cases"`
+	Passed     int           `json:"passed"`
+	PassRate   float64       `json:"pass_rate"`
+	TokensUsed int           `json:"tokens_used"`
+	CostUSD    float64       `json:"cost_usd,omitempty"`
+	Duration   time.Duration `json:"duration_ns"`
+}
+
+// Report holds every result of a benchmark run and per-target summaries.
+type Report struct {
+	Suite   string          `json:"suite,omitempty"`
+	Results []Result        `json:"results"`
+	Targets []TargetSummary `json:"targets"`
+}
+
+// summarize fills r.Targets from r.Results, in the order targets were run.
+func (r *Report) summarize() {
+	r.Targets = nil
+	index := make(map[Target]int)
+	for _, res := range r.Results {
+		i, ok := index[res.Target]
+		if !ok {
+			i = len(r.Targets)
+			index[res.Target] = i
+			r.Targets = append(r.Targets, TargetSummary{Target: res.Target})
+		}
+		s := &r.Targets[i]
+		s.Cases++
+		if res.Passed {
+			s.Passed++
+		}
+		s.TokensUsed += res.TokensUsed
+		s.CostUSD += res.CostUSD
+		s.Duration += res.Duration
+	}
+	for i := range r.Targets {
+		s := &r.Targets[i]
+		s.PassRate = float64(s.Passed) / float64(s.Cases)
+	}
+}
+
+// Format renders the report as a results table followed by the per-target
+// summary.
+func (r *Report) Format() string {
+	var b strings.Builder
+	if r.Suite != "" {
+		fmt.Fprintf(&b, "Suite: %s\n\n", r.Suite)
+	}
+	for _, res := range r.Results {
+		status := "PASS"
+		if !res.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "%s  %-24s %-32s tasks %d/%d  tokens %d  %s\n",
+			status, res.Case, res.Target, res.TasksCompleted, res.TasksTotal,
+			res.TokensUsed, res.Duration.Round(time.Second))
+		if res.Reason != "" {
+			fmt.Fprintf(&b, "      %s\n", res.Reason)
+		}
+		if res.WorkDir != "" {
+			fmt.Fprintf(&b, "      work dir: %s\n", res.WorkDir)
+		}
+	}
+
+	b.WriteString("\nSummary:\n")
+	for _, s := range r.Targets {
+		fmt.Fprintf(&b, "  %-32s %d/%d passed (%.0f%%)  tokens %d", s.Target, s.Passed, s.Cases, s.PassRate*100, s.TokensUsed)
+		if s.CostUSD > 0 {
+			fmt.Fprintf(&b, "  ~$%.2f", s.CostUSD)
+		}
+		fmt.Fprintf(&b, "  %s\n", s.Duration.Round(time.Second))
+	}
+	return b.String()
+}
@@ -0,0 +1,207 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourorg/agent/internal/agent"
+)
+
+const (
+	defaultRunTimeout  = 10 * time.Minute
+	defaultIterations  = 20
+	testCommandTimeout = 10 * time.Minute
+)
+
+// RunnerConfig configures a benchmark run.
+type RunnerConfig struct {
+	Targets []Target
+	// NewAgent creates an agent for target working on the project at path.
+	NewAgent func(path string, target Target) (*agent.CodingAgent, error)
+	// Pricing maps a model name to its blended price in USD per million
+	// tokens, used to estimate cost.
+	Pricing map[string]float64
+	// KeepWorkDirs leaves each run's copy of the fixture on disk.
+	KeepWorkDirs bool
+	// OnResult, if set, is called as each case finishes.
+	OnResult func(Result)
+}
+
+// Runner runs suites of cases against targets.
+type Runner struct {
+	cfg RunnerConfig
+}
+
+// NewRunner creates a benchmark runner.
+func NewRunner(cfg RunnerConfig) *Runner {
+	return &Runner{cfg: cfg}
+}
+
+// Run runs every case against every target, one at a time.
+func (r *Runner) Run(ctx context.Context, suiteName string, cases []Case) (*Report, error) {
+	if len(r.cfg.Targets) == 0 {
+		return nil, fmt.Errorf("no targets to benchmark")
+	}
+	report := &Report{Suite: suiteName}
+	for _, target := range r.cfg.Targets {
+		for _, c := range cases {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			res := r.runCase(ctx, c, target)
+			report.Results = append(report.Results, res)
+			if r.cfg.OnResult != nil {
+				r.cfg.OnResult(res)
+			}
+		}
+	}
+	report.summarize()
+	return report, nil
+}
+
+func (r *Runner) runCase(ctx context.Context, c Case, target Target) Result {
+	res := Result{Case: c.Name, Target: target, Model: target.Model}
+	fail := func(format string, args ...interface{}) Result {
+		res.Reason = fmt.Sprintf(format, args...)
+		return res
+	}
+
+	workDir, err := os.MkdirTemp("", "agent-eval-")
+	if err != nil {
+		return fail("create work dir: %v", err)
+	}
+	if r.cfg.KeepWorkDirs {
+		res.WorkDir = workDir
+	} else {
+		defer os.RemoveAll(workDir)
+	}
+	if err := copyTree(c.Repo, workDir); err != nil {
+		return fail("copy fixture: %v", err)
+	}
+
+	a, err := r.cfg.NewAgent(workDir, target)
+	if err != nil {
+		return fail("create agent: %v", err)
+	}
+
+	timeout := time.Duration(c.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultRunTimeout
+	}
+	iterations := c.MaxIterations
+	if iterations <= 0 {
+		iterations = defaultIterations
+	}
+
+	start := time.Now()
+	run, err := a.Run(ctx, c.Task, agent.RunOptions{
+		MaxIterations:     iterations,
+		MaxContextResults: 8,
+		NonInteractive:    true,
+		Timeout:           timeout,
+	})
+	res.Duration = time.Since(start)
+	if err != nil {
+		return fail("agent run: %v", err)
+	}
+
+	res.Model = run.Model
+	res.TokensUsed = run.TokensUsed
+	if price, ok := r.cfg.Pricing[run.Model]; ok {
+		res.CostUSD = float64(run.TokensUsed) / 1e6 * price
+	}
+	if run.Plan != nil {
+		res.TasksTotal = len(run.Plan.Tasks)
+	}
+	for _, exec := range run.Executions {
+		if exec.Completed {
+			res.TasksCompleted++
+		}
+	}
+
+	if c.ExpectDiff == "" && c.TestCommand == "" {
+		if !run.Succeeded() {
+			return fail("agent did not complete every task")
+		}
+		res.Passed = true
+		return res
+	}
+	if c.ExpectDiff != "" {
+		if err := checkDiff(c.Repo, workDir, c.ExpectDiff); err != nil {
+			return fail("%v", err)
+		}
+	}
+	if c.TestCommand != "" {
+		if err := runTests(ctx, workDir, c.TestCommand); err != nil {
+			return fail("%v", err)
+		}
+	}
+	res.Passed = true
+	return res
+}
+
+// runTests runs command in dir and reports its failure with the tail of its
+// output.
+func runTests(ctx context.Context, dir, command string) error {
+	ctx, cancel := context.WithTimeout(ctx, testCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	output := strings.TrimSpace(string(out))
+	if len(output) > 500 {
+		output = "..." + output[len(output)-500:]
+	}
+	return fmt.Errorf("test command %q failed: %v\n%s", command, err, output)
+}
+
+// copyTree copies the regular files, directories and symlinks under src into
+// dst, keeping file modes. A cached .index is left out so each run
+// starts from a fresh index.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			if d.Name() == ".index" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, data, info.Mode().Perm())
+		}
+		return nil
+	})
+}
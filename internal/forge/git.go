@@ -0,0 +1,222 @@
+// Package forge publishes agent runs to code hosting services: it creates a
+// branch for the run, commits and pushes its changes, and opens a pull or
+// merge request describing the work.
+package forge
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChangeRequest describes a pull/merge request to open.
+type ChangeRequest struct {
+	Title string
+	Body  string
+	Head  string // source branch
+	Base  string // target branch
+	Draft bool
+}
+
+// Git runs git commands inside a working tree.
+type Git struct {
+	Dir string
+}
+
+func (g Git) run(args ...string) (string, error) {
+	out, err := g.output(args...)
+	return strings.TrimSpace(out), err
+}
+
+// output runs git and returns its stdout untouched.
+func (g Git) output(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.Dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// CurrentBranch returns the checked-out branch name.
+func (g Git) CurrentBranch() (string, error) {
+	return g.run("rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// Head returns the commit hash HEAD points at.
+func (g Git) Head() (string, error) {
+	return g.run("rev-parse", "HEAD")
+}
+
+// excludeIndex is the pathspec leaving out the .index directory the
+// indexer writes into projects: vector databases, memory and audit logs
+// that must not be published with a run.
+const excludeIndex = ":(exclude).index"
+
+// IsClean reports whether the working tree has no uncommitted changes
+// outside .index.
+func (g Git) IsClean() (bool, error) {
+	out, err := g.run("status", "--porcelain", "--", ".", excludeIndex)
+	if err != nil {
+		return false, err
+	}
+	return out == "", nil
+}
+
+// StagedDiff returns the diff of the index against HEAD without context lines.
+func (g Git) StagedDiff() (string, error) {
+	return g.output("diff", "--cached", "--no-color", "--no-ext-diff", "-U0")
+}
+
+// StagedContent returns the staged version of path.
+func (g Git) StagedContent(path string) (string, error) {
+	return g.output("show", ":"+path)
+}
+
+// HooksDir returns the directory git runs hooks from.
+func (g Git) HooksDir() (string, error) {
+	return g.run("rev-parse", "--path-format=absolute", "--git-path", "hooks")
+}
+
+// Toplevel returns the absolute path of the working tree root.
+func (g Git) Toplevel() (string, error) {
+	return g.run("rev-parse", "--show-toplevel")
+}
+
+// ChangedFiles lists the repository-relative paths that differ between two
+// commits. With an empty from, it lists the files touched by the to commit
+// itself (including a root commit).
+func (g Git) ChangedFiles(from, to string) ([]string, error) {
+	var out string
+	var err error
+	if from == "" {
+		out, err = g.run("diff-tree", "--root", "--no-commit-id", "--name-only", "-r", to)
+	} else {
+		out, err = g.run("diff", "--name-only", from, to)
+	}
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// FileChurn is how often a file changed in the repository history.
+type FileChurn struct {
+	Commits int `json:"commits"`
+	Lines   int `json:"lines"` // lines added plus lines deleted
+}
+
+// Churn returns, for each file under Dir changed since the given date
+// (anything git log --since accepts; "" means all history), the number of
+// commits touching it and the lines they changed. Paths are slash-separated
+// and relative to Dir; renames are not followed.
+func (g Git) Churn(since string) (map[string]FileChurn, error) {
+	args := []string{"log", "--no-merges", "--no-renames", "--numstat", "--relative", "--format="}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	out, err := g.output(append(args, "--", ".")...)
+	if err != nil {
+		return nil, err
+	}
+
+	churn := make(map[string]FileChurn)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		c := churn[fields[2]]
+		c.Commits++
+		// Binary files report "-" for both counts.
+		if added, err := strconv.Atoi(fields[0]); err == nil {
+			c.Lines += added
+		}
+		if deleted, err := strconv.Atoi(fields[1]); err == nil {
+			c.Lines += deleted
+		}
+		churn[fields[2]] = c
+	}
+	return churn, nil
+}
+
+// CreateBranch creates and checks out a new branch at HEAD.
+func (g Git) CreateBranch(name string) error {
+	_, err := g.run("checkout", "-b", name)
+	return err
+}
+
+// CommitAll stages every change outside .index and commits it. It reports
+// false when there was nothing to commit.
+func (g Git) CommitAll(message string) (bool, error) {
+	if _, err := g.run("add", "-A", "--", ".", excludeIndex); err != nil {
+		return false, err
+	}
+	if _, err := g.run("diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	if _, err := g.run("commit", "-m", message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Push pushes branch to remote and sets its upstream.
+func (g Git) Push(remote, branch string) error {
+	_, err := g.run("push", "-u", remote, branch)
+	return err
+}
+
+// RemoteURL returns the fetch URL of remote.
+func (g Git) RemoteURL(remote string) (string, error) {
+	return g.run("remote", "get-url", remote)
+}
+
+var scpLikeRemote = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):(.+)$`)
+
+// ParseRemote splits a git remote URL (https or scp-like ssh) into host and
+// repository path, e.g. "github.com" and "org/repo".
+func ParseRemote(remote string) (host, path string, err error) {
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return "", "", fmt.Errorf("parse remote %q: %w", remote, err)
+		}
+		host, path = u.Hostname(), u.Path
+	} else if m := scpLikeRemote.FindStringSubmatch(remote); m != nil {
+		host, path = m[1], m[2]
+	} else {
+		return "", "", fmt.Errorf("unrecognized remote URL %q", remote)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if path == "" {
+		return "", "", fmt.Errorf("remote %q has no repository path", remote)
+	}
+	return host, path, nil
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// BranchName derives a branch name for a run from the task description.
+func BranchName(prefix, task string) string {
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(task), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	if slug == "" {
+		slug = "run"
+	}
+	if prefix == "" {
+		prefix = "agent/"
+	}
+	return fmt.Sprintf("%s%s-%s", prefix, slug, time.Now().Format("20060102-150405"))
+}
@@ -0,0 +1,114 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yourorg/agent/internal/proxy"
+)
+
+// GitHubClient opens pull requests through the GitHub REST API.
+type GitHubClient struct {
+	token   string
+	owner   string
+	repo    string
+	baseURL string
+	client  *http.Client
+}
+
+// GitHubConfig configures a GitHubClient.
+type GitHubConfig struct {
+	Token   string
+	Owner   string
+	Repo    string
+	BaseURL string // defaults to https://api.github.com (set for GitHub Enterprise)
+}
+
+// NewGitHubClient creates a GitHub API client.
+func NewGitHubClient(cfg GitHubConfig) (*GitHubClient, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("GitHub token is required (set GITHUB_TOKEN)")
+	}
+	if cfg.Owner == "" || cfg.Repo == "" {
+		return nil, fmt.Errorf("GitHub owner and repo are required")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.github.com"
+	}
+
+	return &GitHubClient{
+		token:   cfg.Token,
+		owner:   cfg.Owner,
+		repo:    cfg.Repo,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  proxy.Client("github", 0),
+	}, nil
+}
+
+type githubPullRequest struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body"`
+	Draft bool   `json:"draft"`
+}
+
+type githubPullResponse struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Open creates a pull request and returns its web URL.
+func (c *GitHubClient) Open(ctx context.Context, cr ChangeRequest) (string, error) {
+	reqBody := githubPullRequest{
+		Title: cr.Title,
+		Head:  cr.Head,
+		Base:  cr.Base,
+		Body:  cr.Body,
+		Draft: cr.Draft,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls", c.baseURL, c.owner, c.repo)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("GitHub API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var pr githubPullResponse
+	if err := json.Unmarshal(body, &pr); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return pr.HTMLURL, nil
+}
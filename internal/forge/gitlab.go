@@ -0,0 +1,144 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/yourorg/agent/internal/proxy"
+)
+
+// GitLabClient opens merge requests through the GitLab REST API (v4).
+type GitLabClient struct {
+	token   string
+	baseURL string
+	project string
+	client  *http.Client
+}
+
+// GitLabConfig configures a GitLabClient.
+type GitLabConfig struct {
+	Token      string
+	ProjectURL string // e.g. https://gitlab.com/group/project
+}
+
+// MergeRequest is the subset of a GitLab merge request the agent needs.
+type MergeRequest struct {
+	IID    int    `json:"iid"`
+	WebURL string `json:"web_url"`
+}
+
+// NewGitLabClient creates a GitLab API client for the project at ProjectURL.
+func NewGitLabClient(cfg GitLabConfig) (*GitLabClient, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("GitLab token is required (set GITLAB_TOKEN)")
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimRight(cfg.ProjectURL, "/"), ".git"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid GitLab project URL %q", cfg.ProjectURL)
+	}
+	project := strings.Trim(u.Path, "/")
+	if project == "" {
+		return nil, fmt.Errorf("GitLab project URL %q has no project path", cfg.ProjectURL)
+	}
+
+	return &GitLabClient{
+		token:   cfg.Token,
+		baseURL: u.Scheme + "://" + u.Host + "/api/v4",
+		project: project,
+		client:  proxy.Client("gitlab", 0),
+	}, nil
+}
+
+type gitlabMergeRequest struct {
+	SourceBranch       string `json:"source_branch"`
+	TargetBranch       string `json:"target_branch"`
+	Title              string `json:"title"`
+	Description        string `json:"description"`
+	RemoveSourceBranch bool   `json:"remove_source_branch"`
+}
+
+type gitlabNote struct {
+	Body string `json:"body"`
+}
+
+// Open creates a merge request and returns its web URL.
+func (c *GitLabClient) Open(ctx context.Context, cr ChangeRequest) (string, error) {
+	mr, err := c.CreateMergeRequest(ctx, cr)
+	if err != nil {
+		return "", err
+	}
+	return mr.WebURL, nil
+}
+
+// CreateMergeRequest creates a merge request. Draft requests get the
+// "Draft:" title prefix GitLab uses to mark them.
+func (c *GitLabClient) CreateMergeRequest(ctx context.Context, cr ChangeRequest) (*MergeRequest, error) {
+	title := cr.Title
+	if cr.Draft {
+		title = "Draft: " + title
+	}
+
+	var mr MergeRequest
+	err := c.post(ctx, "/merge_requests", gitlabMergeRequest{
+		SourceBranch:       cr.Head,
+		TargetBranch:       cr.Base,
+		Title:              title,
+		Description:        cr.Body,
+		RemoveSourceBranch: true,
+	}, &mr)
+	if err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// AddNote posts a comment on the merge request with the given IID.
+func (c *GitLabClient) AddNote(ctx context.Context, iid int, body string) error {
+	return c.post(ctx, fmt.Sprintf("/merge_requests/%d/notes", iid), gitlabNote{Body: body}, nil)
+}
+
+func (c *GitLabClient) post(ctx context.Context, path string, reqBody, out interface{}) error {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/projects/%s%s", c.baseURL, url.PathEscape(c.project), path)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("GitLab API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Package fuzzy ranks names against a loosely typed query, so "ctxfetchr"
+// finds ContextFetcher and "SearchEngnie" finds SearchEngine.
+//
+// A name matches when the query's characters appear in it in order,
+// ignoring case. Such matches score by how the characters land: at word
+// starts and in runs score higher, gaps cost. A query that is not a
+// subsequence still matches, below every subsequence match, when it is
+// within a few typos of the name.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Match is a name and how well it matched; higher is better.
+type Match struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+}
+
+const (
+	scoreChar        = 16
+	scoreBoundary    = 8
+	scoreConsecutive = 4
+	scoreFirst       = 20
+	scorePrefix      = 30
+	scoreExact       = 100
+	penaltyGap       = 1
+)
+
+// Rank returns the names matching query, best first, at most limit of
+// them (0 means no limit). Ties go to the shorter name, then
+// alphabetically.
+func Rank(query string, names []string, limit int) []Match {
+	var matches []Match
+	for _, name := range names {
+		if score, ok := Score(query, name); ok {
+			matches = append(matches, Match{Name: name, Score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Name) != len(b.Name) {
+			return len(a.Name) < len(b.Name)
+		}
+		return a.Name < b.Name
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Score reports whether name matches query and how well.
+func Score(query, name string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	n := []rune(name)
+	if len(q) == 0 {
+		return 0, false
+	}
+	lower := []rune(strings.ToLower(name))
+	if len(lower) != len(n) {
+		// Lowercasing changed the length; compare rune by rune instead.
+		lower = make([]rune, len(n))
+		for i, r := range n {
+			lower[i] = unicode.ToLower(r)
+		}
+	}
+
+	if score, ok := subsequence(q, n, lower); ok {
+		return score, true
+	}
+
+	// Typos: compare against the whole name and its last segment, so
+	// "Type.Mehtod" and "Mehtod" both find "Type.Method".
+	best := -1
+	for _, target := range []string{string(lower), lastSegment(string(lower))} {
+		d := distance(q, []rune(target))
+		if best < 0 || d < best {
+			best = d
+		}
+	}
+	if best <= maxTypos(len(q)) {
+		// Negative, below every subsequence match.
+		return -best, true
+	}
+	return 0, false
+}
+
+// maxTypos is the edit distance tolerated for a query of n characters.
+func maxTypos(n int) int {
+	switch {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// subsequence scores q as a subsequence of the name. It finds the first
+// place the match can end, then scans back from there for the shortest
+// window containing it, as fzf does.
+func subsequence(q, name, lower []rune) (int, bool) {
+	qi, end := 0, -1
+	for i, r := range lower {
+		if r == q[qi] {
+			qi++
+			if qi == len(q) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, false
+	}
+	qi, start := len(q)-1, end
+	for i := end; i >= 0; i-- {
+		if lower[i] == q[qi] {
+			start = i
+			if qi--; qi < 0 {
+				break
+			}
+		}
+	}
+
+	score, qi, prev := 0, 0, -2
+	for i := start; i <= end && qi < len(q); i++ {
+		if lower[i] != q[qi] {
+			score -= penaltyGap
+			continue
+		}
+		score += scoreChar
+		if boundary(name, i) {
+			score += scoreBoundary
+		}
+		if prev == i-1 {
+			score += scoreConsecutive
+		}
+		prev = i
+		qi++
+	}
+	if start == 0 {
+		score += scoreFirst
+	}
+	switch {
+	case len(q) == len(lower) && start == 0:
+		score += scoreExact
+	case start == 0 && end == len(q)-1:
+		score += scorePrefix
+	}
+	return max(score, 1), true
+}
+
+// boundary reports whether name[i] starts a word: the first character,
+// one after a separator, an upper-case letter after a lower-case one, or
+// a letter after a digit.
+func boundary(name []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := name[i-1], name[i]
+	switch {
+	case prev == '_' || prev == '.' || prev == '-' || prev == '/' || prev == ' ':
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return true
+	case unicode.IsDigit(prev) && unicode.IsLetter(cur):
+		return true
+	}
+	return false
+}
+
+func lastSegment(name string) string {
+	return name[strings.LastIndexAny(name, "./")+1:]
+}
+
+// distance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and transpositions of adjacent
+// characters each cost one.
+func distance(a, b []rune) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d = min(d, rows[i-2][j-2]+1)
+			}
+			rows[i][j] = d
+		}
+	}
+	return rows[len(a)][len(b)]
+}
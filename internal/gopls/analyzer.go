@@ -0,0 +1,308 @@
+// Package gopls answers Go symbol, reference and implementation queries
+// precisely by delegating to gopls over LSP. Callers fall back to the
+// structural indexer when gopls is not installed.
+package gopls
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/lsp"
+)
+
+// Analyzer wraps a running gopls process for one project.
+type Analyzer struct {
+	root   string
+	client *lsp.Client
+}
+
+// Available reports whether a gopls binary can be found.
+func Available(path string) bool {
+	_, err := exec.LookPath(binary(path))
+	return err == nil
+}
+
+// NewAnalyzer starts gopls for the project at root. path overrides the
+// binary name looked up on PATH.
+func NewAnalyzer(ctx context.Context, root, path string) (*Analyzer, error) {
+	bin, err := exec.LookPath(binary(path))
+	if err != nil {
+		return nil, fmt.Errorf("gopls not found: %w", err)
+	}
+	client, err := lsp.StartClient(ctx, []string{bin, "serve"}, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start gopls: %w", err)
+	}
+	return &Analyzer{root: root, client: client}, nil
+}
+
+// Client exposes the underlying LSP connection, e.g. for request forwarding.
+func (a *Analyzer) Client() *lsp.Client {
+	return a.client
+}
+
+// Close shuts gopls down.
+func (a *Analyzer) Close() error {
+	return a.client.Close()
+}
+
+// Symbols returns workspace symbols matching name exactly or as the last
+// component of a qualified name (e.g. "Server.Serve" for "Serve").
+func (a *Analyzer) Symbols(ctx context.Context, name string) ([]indexer.SearchResult, error) {
+	symbols, err := a.workspaceSymbols(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]indexer.SearchResult, 0, len(symbols))
+	for _, sym := range symbols {
+		results = append(results, indexer.SearchResult{
+			Name:     sym.Name,
+			Type:     kindName(sym.Kind),
+			FilePath: a.rel(sym.Location.URI),
+			Line:     sym.Location.Range.Start.Line + 1,
+		})
+	}
+	return results, nil
+}
+
+// Details returns the symbol's location plus its signature and doc comment
+// taken from gopls hover output.
+func (a *Analyzer) Details(ctx context.Context, name string) (*indexer.SearchResult, error) {
+	sym, err := a.find(ctx, name)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+
+	result := &indexer.SearchResult{
+		Name:     sym.Name,
+		Type:     kindName(sym.Kind),
+		FilePath: a.rel(sym.Location.URI),
+		Line:     sym.Location.Range.Start.Line + 1,
+	}
+
+	var hover *lsp.Hover
+	if err := a.client.Call(ctx, "textDocument/hover", positionParams(sym.Location), &hover); err != nil {
+		return nil, err
+	}
+	if hover != nil {
+		result.Signature, result.Doc = splitHover(hover.Contents.Value)
+	}
+	return result, nil
+}
+
+// References returns every use of the named symbol, excluding its declaration.
+func (a *Analyzer) References(ctx context.Context, name string) ([]lsp.Location, error) {
+	sym, err := a.find(ctx, name)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+
+	params := struct {
+		lsp.TextDocumentPositionParams
+		Context struct {
+			IncludeDeclaration bool `json:"includeDeclaration"`
+		} `json:"context"`
+	}{TextDocumentPositionParams: positionParams(sym.Location)}
+
+	var locations []lsp.Location
+	if err := a.client.Call(ctx, "textDocument/references", params, &locations); err != nil {
+		return nil, err
+	}
+	return a.sorted(locations), nil
+}
+
+// Implementations returns the types implementing the named interface, or
+// the interfaces implemented by the named type.
+func (a *Analyzer) Implementations(ctx context.Context, name string) ([]lsp.Location, error) {
+	sym, err := a.find(ctx, name)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+
+	var locations []lsp.Location
+	if err := a.client.Call(ctx, "textDocument/implementation", positionParams(sym.Location), &locations); err != nil {
+		return nil, err
+	}
+	return a.sorted(locations), nil
+}
+
+// CallGraph returns the function's callers, callees, or both, each as
+// "name (path:line)". direction matches indexer.SearchEngine.SearchByCallGraph.
+func (a *Analyzer) CallGraph(ctx context.Context, name, direction string) ([]string, error) {
+	sym, err := a.find(ctx, name)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+
+	var items []callHierarchyItem
+	if err := a.client.Call(ctx, "textDocument/prepareCallHierarchy", positionParams(sym.Location), &items); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	add := func(item callHierarchyItem) {
+		entry := fmt.Sprintf("%s (%s:%d)", item.Name, a.rel(item.URI), item.SelectionRange.Start.Line+1)
+		if !seen[entry] {
+			seen[entry] = true
+			names = append(names, entry)
+		}
+	}
+
+	for _, item := range items {
+		if direction == "callers" || direction == "both" {
+			var calls []struct {
+				From callHierarchyItem `json:"from"`
+			}
+			if err := a.client.Call(ctx, "callHierarchy/incomingCalls", map[string]interface{}{"item": item}, &calls); err != nil {
+				return nil, err
+			}
+			for _, c := range calls {
+				add(c.From)
+			}
+		}
+		if direction == "callees" || direction == "both" {
+			var calls []struct {
+				To callHierarchyItem `json:"to"`
+			}
+			if err := a.client.Call(ctx, "callHierarchy/outgoingCalls", map[string]interface{}{"item": item}, &calls); err != nil {
+				return nil, err
+			}
+			for _, c := range calls {
+				add(c.To)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// FormatLocation renders a location as "path:line:col" relative to the project.
+func (a *Analyzer) FormatLocation(loc lsp.Location) string {
+	return fmt.Sprintf("%s:%d:%d", a.rel(loc.URI), loc.Range.Start.Line+1, loc.Range.Start.Character+1)
+}
+
+type callHierarchyItem struct {
+	Name           string    `json:"name"`
+	Kind           int       `json:"kind"`
+	Detail         string    `json:"detail,omitempty"`
+	URI            string    `json:"uri"`
+	Range          lsp.Range `json:"range"`
+	SelectionRange lsp.Range `json:"selectionRange"`
+}
+
+func (a *Analyzer) workspaceSymbols(ctx context.Context, name string) ([]lsp.SymbolInformation, error) {
+	var symbols []lsp.SymbolInformation
+	if err := a.client.Call(ctx, "workspace/symbol", map[string]string{"query": name}, &symbols); err != nil {
+		return nil, err
+	}
+
+	var matches []lsp.SymbolInformation
+	for _, sym := range symbols {
+		if sym.Name == name || strings.HasSuffix(sym.Name, "."+name) {
+			matches = append(matches, sym)
+		}
+	}
+	return matches, nil
+}
+
+// find resolves a name to a single symbol, preferring an exact match and
+// then one declared inside the project. It returns nil if nothing matches.
+func (a *Analyzer) find(ctx context.Context, name string) (*lsp.SymbolInformation, error) {
+	symbols, err := a.workspaceSymbols(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	var best *lsp.SymbolInformation
+	for i := range symbols {
+		sym := &symbols[i]
+		if sym.Name == name {
+			return sym, nil
+		}
+		if best == nil && !strings.HasPrefix(a.rel(sym.Location.URI), "..") {
+			best = sym
+		}
+	}
+	return best, nil
+}
+
+func (a *Analyzer) sorted(locations []lsp.Location) []lsp.Location {
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].URI != locations[j].URI {
+			return locations[i].URI < locations[j].URI
+		}
+		return locations[i].Range.Start.Line < locations[j].Range.Start.Line
+	})
+	return locations
+}
+
+func (a *Analyzer) rel(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := filepath.FromSlash(u.Path)
+	if rel, err := filepath.Rel(a.root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func positionParams(loc lsp.Location) lsp.TextDocumentPositionParams {
+	return lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: loc.URI},
+		Position:     loc.Range.Start,
+	}
+}
+
+// splitHover separates the fenced Go signature gopls puts first in hover
+// markdown from the documentation that follows it.
+func splitHover(value string) (signature, doc string) {
+	const fence = "```"
+	if !strings.HasPrefix(value, fence) {
+		return "", strings.TrimSpace(value)
+	}
+	body := strings.TrimPrefix(value, fence)
+	body = strings.TrimPrefix(body, "go")
+	end := strings.Index(body, fence)
+	if end < 0 {
+		return strings.TrimSpace(body), ""
+	}
+	return strings.TrimSpace(body[:end]), strings.TrimSpace(body[end+len(fence):])
+}
+
+// kindName maps LSP SymbolKind values to the indexer's type names.
+func kindName(kind int) string {
+	switch kind {
+	case 5:
+		return "class"
+	case 6:
+		return "method"
+	case 8:
+		return "field"
+	case 11:
+		return "interface"
+	case 13:
+		return "variable"
+	case 14:
+		return "constant"
+	case 23:
+		return "struct"
+	default:
+		return "function"
+	}
+}
+
+func binary(path string) string {
+	if path != "" {
+		return path
+	}
+	return "gopls"
+}
@@ -0,0 +1,304 @@
+// Package grpcapi serves the indexer, retrieval and agent run loop over gRPC.
+//
+// Messages are google.protobuf.Struct values (see agent.proto), which keeps
+// the service usable from any gRPC client without code generation.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/cache"
+	"github.com/yourorg/agent/internal/indexer"
+)
+
+const serviceName = "codeagent.v1.AgentService"
+
+// Server implements AgentService.
+type Server struct {
+	indexer *indexer.Indexer
+	indexes *cache.LRU[string, *indexer.ProjectIndex]
+}
+
+// NewServer creates a server that keeps up to maxProjects indexes warm.
+func NewServer(maxProjects int) *Server {
+	idx := indexer.NewIndexer()
+	idx.RegisterParser(indexer.NewGoParser())
+	idx.RegisterParser(indexer.NewPythonParser())
+
+	return &Server{
+		indexer: idx,
+		indexes: cache.NewLRU[string, *indexer.ProjectIndex](maxProjects, nil),
+	}
+}
+
+// Register adds the service to a gRPC server.
+func (s *Server) Register(g *grpc.Server) {
+	g.RegisterService(&serviceDesc, s)
+}
+
+// ListenAndServe serves the API on addr until the listener fails.
+func (s *Server) ListenAndServe(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", addr, err)
+	}
+	g := grpc.NewServer()
+	s.Register(g)
+	return g.Serve(lis)
+}
+
+// Index builds or refreshes the structural index.
+func (s *Server) Index(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	args := req.AsMap()
+	projectPath, err := requireString(args, "project_path")
+	if err != nil {
+		return nil, err
+	}
+	if getBool(args, "refresh", false) {
+		s.indexes.Remove(projectPath)
+	}
+
+	idx, err := s.projectIndex(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	return toStruct(map[string]interface{}{
+		"modules": len(idx.Modules),
+		"symbols": len(idx.SymbolTable),
+	})
+}
+
+// Search finds symbols or documentation matching the query.
+func (s *Server) Search(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	args := req.AsMap()
+	projectPath, err := requireString(args, "project_path")
+	if err != nil {
+		return nil, err
+	}
+	query, err := requireString(args, "query")
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.projectIndex(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := indexer.NewSearchEngine(idx)
+	var results []indexer.SearchResult
+	switch getString(args, "type", "symbol") {
+	case "doc":
+		results = engine.SearchDocumentation(query)
+	default:
+		results = engine.SearchSymbol(query)
+	}
+	return toStruct(map[string]interface{}{"results": results})
+}
+
+// FetchContext returns formatted project context for a task.
+func (s *Server) FetchContext(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	args := req.AsMap()
+	projectPath, err := requireString(args, "project_path")
+	if err != nil {
+		return nil, err
+	}
+	task, err := requireString(args, "task")
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.projectIndex(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher := indexer.NewContextFetcher(idx)
+	projectContext := fetcher.FetchContext(task, getInt(args, "max_results", 10))
+	return toStruct(map[string]interface{}{
+		"context": indexer.FormatContext(projectContext),
+	})
+}
+
+// RunAgent runs the agent loop and streams each RunEvent to the client.
+func (s *Server) RunAgent(req *structpb.Struct, stream grpc.ServerStream) error {
+	args := req.AsMap()
+	projectPath, err := requireString(args, "project_path")
+	if err != nil {
+		return err
+	}
+	task, err := requireString(args, "task")
+	if err != nil {
+		return err
+	}
+
+	provider := getString(args, "provider", "claude")
+	apiKey := getString(args, "api_key", "")
+	if apiKey == "" {
+		apiKey = apiKeyFromEnv(provider)
+	}
+
+	codingAgent, err := agent.NewCodingAgent(agent.AgentConfig{
+		ProjectPath: projectPath,
+		LLMConfig: agent.LLMConfig{
+			Provider: provider,
+			APIKey:   apiKey,
+			Model:    getString(args, "model", ""),
+		},
+	})
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "create agent: %v", err)
+	}
+
+	var sendErr error
+	_, err = codingAgent.Run(stream.Context(), task, agent.RunOptions{
+		DryRun:            getBool(args, "dry_run", true),
+		MaxIterations:     getInt(args, "max_iterations", 20),
+		MaxContextResults: getInt(args, "max_context", 8),
+		Timeout:           time.Duration(getInt(args, "timeout_seconds", 0)) * time.Second,
+		OnEvent: func(event agent.RunEvent) {
+			if sendErr != nil {
+				return
+			}
+			msg, err := toStruct(event)
+			if err != nil {
+				sendErr = err
+				return
+			}
+			sendErr = stream.SendMsg(msg)
+		},
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "agent run: %v", err)
+	}
+	return sendErr
+}
+
+func (s *Server) projectIndex(projectPath string) (*indexer.ProjectIndex, error) {
+	if idx, ok := s.indexes.Get(projectPath); ok {
+		return idx, nil
+	}
+	idx, err := s.indexer.IndexProject(projectPath)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "index project: %v", err)
+	}
+	s.indexes.Add(projectPath, idx)
+	return idx, nil
+}
+
+// Service descriptor (hand-written in place of protoc-gen-go-grpc output).
+
+type agentService interface {
+	Index(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Search(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	FetchContext(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	RunAgent(*structpb.Struct, grpc.ServerStream) error
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*agentService)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Index", Handler: unaryHandler("Index", agentService.Index)},
+		{MethodName: "Search", Handler: unaryHandler("Search", agentService.Search)},
+		{MethodName: "FetchContext", Handler: unaryHandler("FetchContext", agentService.FetchContext)},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RunAgent",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(structpb.Struct)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(agentService).RunAgent(req, stream)
+			},
+		},
+	},
+	Metadata: "agent.proto",
+}
+
+type unaryMethod func(agentService, context.Context, *structpb.Struct) (*structpb.Struct, error)
+
+func unaryHandler(name string, method unaryMethod) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := new(structpb.Struct)
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return method(srv.(agentService), ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/" + name}
+		return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return method(srv.(agentService), ctx, req.(*structpb.Struct))
+		})
+	}
+}
+
+// Helper functions
+
+// toStruct converts any JSON-serializable value into a protobuf Struct.
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encode response: %v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, status.Errorf(codes.Internal, "encode response: %v", err)
+	}
+	return structpb.NewStruct(m)
+}
+
+func requireString(args map[string]interface{}, key string) (string, error) {
+	if v, ok := args[key].(string); ok && v != "" {
+		return v, nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "%s is required", key)
+}
+
+func getString(args map[string]interface{}, key, def string) string {
+	if v, ok := args[key].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func getBool(args map[string]interface{}, key string, def bool) bool {
+	if v, ok := args[key].(bool); ok {
+		return v
+	}
+	return def
+}
+
+func getInt(args map[string]interface{}, key string, def int) int {
+	if v, ok := args[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+func apiKeyFromEnv(provider string) string {
+	switch provider {
+	case "claude":
+		return os.Getenv("CLAUDE_API_KEY")
+	case "gemini":
+		return os.Getenv("GEMINI_API_KEY")
+	case "openai":
+		return os.Getenv("OPENAI_API_KEY")
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+package hotspots
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// goFunctions measures the cyclomatic complexity of each function and
+// method in a Go file: one plus the number of branch points. Methods are
+// named "Type.Method".
+func goFunctions(file string, src []byte) ([]function, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var functions []function
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			name = receiverName(fn.Recv.List[0].Type) + "." + name
+		}
+		functions = append(functions, function{
+			name:       name,
+			file:       file,
+			line:       fset.Position(fn.Pos()).Line,
+			complexity: goComplexity(fn.Body),
+		})
+	}
+	return functions, nil
+}
+
+func goComplexity(body *ast.BlockStmt) int {
+	complexity := 1
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil { // not default
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil { // not default
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr: // generic receiver T[P]
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// pythonBranches are the statements that add a path through a Python
+// function.
+var pythonBranches = []string{"if ", "elif ", "for ", "async for ", "while ", "except", "case "}
+
+// pythonFunctions estimates the cyclomatic complexity of each Python function
+// from its source lines, using indentation to find where it ends. Nested
+// functions count towards the function containing them; methods are named
+// "Class.method".
+func pythonFunctions(file string, src []byte) ([]function, error) {
+	lines := strings.Split(string(src), "\n")
+
+	type class struct {
+		indent int
+		name   string
+	}
+	var classes []class
+	var functions []function
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := indentOf(lines[i])
+		for len(classes) > 0 && classes[len(classes)-1].indent >= indent {
+			classes = classes[:len(classes)-1]
+		}
+
+		if strings.HasPrefix(trimmed, "class ") {
+			classes = append(classes, class{indent: indent, name: pythonName(trimmed[len("class "):])})
+			continue
+		}
+		def := strings.TrimPrefix(trimmed, "async ")
+		if !strings.HasPrefix(def, "def ") {
+			continue
+		}
+
+		name := pythonName(def[len("def "):])
+		if len(classes) > 0 {
+			name = classes[len(classes)-1].name + "." + name
+		}
+		complexity := 1
+		end := i + 1
+		for ; end < len(lines); end++ {
+			body := strings.TrimSpace(lines[end])
+			if body == "" || strings.HasPrefix(body, "#") {
+				continue
+			}
+			if indentOf(lines[end]) <= indent {
+				break
+			}
+			complexity += pythonLineBranches(body)
+		}
+		functions = append(functions, function{name: name, file: file, line: i + 1, complexity: complexity})
+		i = end - 1
+	}
+	return functions, nil
+}
+
+func pythonLineBranches(line string) int {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+	n := strings.Count(line, " and ") + strings.Count(line, " or ")
+	for _, kw := range pythonBranches {
+		if strings.HasPrefix(line, kw) {
+			n++
+			break
+		}
+	}
+	return n
+}
+
+func pythonName(s string) string {
+	if i := strings.IndexAny(s, "(:"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
@@ -0,0 +1,274 @@
+// Package hotspots ranks a project's functions and files by risk: code that
+// is complex, changes often and is called from many places is where bugs
+// are most likely and most costly.
+package hotspots
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourorg/agent/internal/forge"
+)
+
+// Levels of detail for a report.
+const (
+	ByFunction = "function"
+	ByFile     = "file"
+)
+
+// DefaultSince limits churn to recent history when Options.Since is unset.
+const DefaultSince = "1 year ago"
+
+// Weights sets how much each metric contributes to the combined score.
+// Zero weights use the defaults (equal weighting).
+type Weights struct {
+	Complexity float64 `json:"complexity"`
+	Churn      float64 `json:"churn"`
+	FanIn      float64 `json:"fan_in"`
+}
+
+// Options controls an analysis.
+type Options struct {
+	By      string // ByFunction (default) or ByFile
+	Since   string // churn window, as accepted by git log --since (default DefaultSince)
+	Limit   int    // number of hotspots to return; 0 means all
+	Weights Weights
+	// FanIn returns the number of distinct callers of a function, e.g. from
+	// the structural index's call graph. Nil leaves fan-in at zero.
+	FanIn func(name string) int
+}
+
+// Hotspot is a ranked function or file.
+type Hotspot struct {
+	Name       string  `json:"name,omitempty"` // function name; empty for files
+	File       string  `json:"file"`
+	Line       int     `json:"line,omitempty"`
+	Complexity int     `json:"complexity"`
+	Commits    int     `json:"commits"`
+	LinesChurn int     `json:"lines_churned"`
+	FanIn      int     `json:"fan_in"`
+	Score      float64 `json:"score"`
+}
+
+// Report is the result of an analysis, most risky first.
+type Report struct {
+	Project  string    `json:"project"`
+	By       string    `json:"by"`
+	Since    string    `json:"since"`
+	Weights  Weights   `json:"weights"`
+	Hotspots []Hotspot `json:"hotspots"`
+	// Warning explains a missing metric, e.g. churn outside a git repository.
+	Warning string `json:"warning,omitempty"`
+}
+
+// Analyze measures every Go and Python function under projectPath and ranks
+// them, or their files, by a weighted combination of cyclomatic complexity,
+// git churn and fan-in. Test files are left out. Churn is measured per file,
+// so functions share their file's churn.
+func Analyze(projectPath string, opts Options) (*Report, error) {
+	if opts.By == "" {
+		opts.By = ByFunction
+	}
+	if opts.By != ByFunction && opts.By != ByFile {
+		return nil, fmt.Errorf("unknown hotspot level %q (want function or file)", opts.By)
+	}
+	if opts.Since == "" {
+		opts.Since = DefaultSince
+	}
+	if opts.Weights == (Weights{}) {
+		opts.Weights = Weights{Complexity: 1, Churn: 1, FanIn: 1}
+	}
+
+	report := &Report{Project: projectPath, By: opts.By, Since: opts.Since, Weights: opts.Weights}
+
+	functions, err := scanFunctions(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	churn, err := forge.Git{Dir: projectPath}.Churn(opts.Since)
+	if err != nil {
+		report.Warning = fmt.Sprintf("churn unavailable: %v", err)
+	}
+
+	var hotspots []Hotspot
+	for _, fn := range functions {
+		h := Hotspot{
+			Name:       fn.name,
+			File:       fn.file,
+			Line:       fn.line,
+			Complexity: fn.complexity,
+			Commits:    churn[fn.file].Commits,
+			LinesChurn: churn[fn.file].Lines,
+		}
+		if opts.FanIn != nil {
+			// Call graphs key functions by their bare name.
+			bare := fn.name[strings.LastIndex(fn.name, ".")+1:]
+			h.FanIn = opts.FanIn(bare)
+		}
+		hotspots = append(hotspots, h)
+	}
+	if opts.By == ByFile {
+		hotspots = byFile(hotspots)
+	}
+
+	score(hotspots, opts.Weights)
+	sort.SliceStable(hotspots, func(i, j int) bool {
+		if hotspots[i].Score != hotspots[j].Score {
+			return hotspots[i].Score > hotspots[j].Score
+		}
+		if hotspots[i].File != hotspots[j].File {
+			return hotspots[i].File < hotspots[j].File
+		}
+		return hotspots[i].Line < hotspots[j].Line
+	})
+	if opts.Limit > 0 && len(hotspots) > opts.Limit {
+		hotspots = hotspots[:opts.Limit]
+	}
+	if hotspots == nil {
+		hotspots = []Hotspot{}
+	}
+	report.Hotspots = hotspots
+	return report, nil
+}
+
+// byFile rolls function hotspots up into one per file: complexity and
+// fan-in are summed, churn is the file's own.
+func byFile(functions []Hotspot) []Hotspot {
+	index := make(map[string]int)
+	var files []Hotspot
+	for _, fn := range functions {
+		i, ok := index[fn.File]
+		if !ok {
+			i = len(files)
+			index[fn.File] = i
+			files = append(files, Hotspot{File: fn.File, Commits: fn.Commits, LinesChurn: fn.LinesChurn})
+		}
+		files[i].Complexity += fn.Complexity
+		files[i].FanIn += fn.FanIn
+	}
+	return files
+}
+
+// score sets each hotspot's score in [0, 100]. Metrics are log-scaled and
+// normalized against the largest value so one outlier does not flatten
+// every other score.
+func score(hotspots []Hotspot, w Weights) {
+	var maxComplexity, maxCommits, maxFanIn int
+	for _, h := range hotspots {
+		maxComplexity = max(maxComplexity, h.Complexity)
+		maxCommits = max(maxCommits, h.Commits)
+		maxFanIn = max(maxFanIn, h.FanIn)
+	}
+	total := w.Complexity + w.Churn + w.FanIn
+	for i := range hotspots {
+		h := &hotspots[i]
+		s := w.Complexity*normalize(h.Complexity, maxComplexity) +
+			w.Churn*normalize(h.Commits, maxCommits) +
+			w.FanIn*normalize(h.FanIn, maxFanIn)
+		h.Score = math.Round(s/total*1000) / 10
+	}
+}
+
+func normalize(v, maxV int) float64 {
+	if maxV <= 0 {
+		return 0
+	}
+	return math.Log1p(float64(v)) / math.Log1p(float64(maxV))
+}
+
+// function is a measured function or method.
+type function struct {
+	name       string
+	file       string // slash-separated, relative to the project
+	line       int
+	complexity int
+}
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"venv":         true,
+	"__pycache__":  true,
+	"testdata":     true,
+}
+
+func scanFunctions(root string) ([]function, error) {
+	var functions []function
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isTestFile(d.Name()) {
+			return nil
+		}
+
+		var measure func(string, []byte) ([]function, error)
+		switch filepath.Ext(p) {
+		case ".go":
+			measure = goFunctions
+		case ".py":
+			measure = pythonFunctions
+		default:
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		found, err := measure(filepath.ToSlash(rel), src)
+		if err != nil {
+			// Unparseable files are skipped, as the indexer does.
+			return nil
+		}
+		functions = append(functions, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan project: %w", err)
+	}
+	return functions, nil
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go") ||
+		(strings.HasSuffix(name, ".py") && (strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test.py")))
+}
+
+// Format renders the report as a table.
+func (r *Report) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hotspots in %s (by %s, churn since %s):\n\n", r.Project, r.By, r.Since)
+	if r.Warning != "" {
+		fmt.Fprintf(&b, "Warning: %s\n\n", r.Warning)
+	}
+	if len(r.Hotspots) == 0 {
+		b.WriteString("No Go or Python functions found.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "%5s  %10s  %7s  %6s  %s\n", "SCORE", "COMPLEXITY", "COMMITS", "FAN-IN", "LOCATION")
+	for _, h := range r.Hotspots {
+		location := h.File
+		if h.Name != "" {
+			location = fmt.Sprintf("%s (%s:%d)", h.Name, h.File, h.Line)
+		}
+		fmt.Fprintf(&b, "%5.1f  %10d  %7d  %6d  %s\n", h.Score, h.Complexity, h.Commits, h.FanIn, location)
+	}
+	return b.String()
+}
@@ -0,0 +1,290 @@
+// Package indexarchive packs a project's indexes (the structural cache,
+// RAG vector databases, shards and text index under .index) into a single
+// gzipped tar, and unpacks one into another checkout, so CI can publish a
+// warm index and developers can start from a prebuilt one.
+//
+// Files that belong to the local checkout rather than the index (the
+// audit log, agent memory, refresh logs, pending batches) are left out.
+package indexarchive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FormatVersion is bumped whenever the archive layout changes.
+const FormatVersion = 1
+
+const (
+	indexDir     = ".index"
+	manifestName = "manifest.json"
+)
+
+// excluded are the files under .index that are local state, not index.
+var excluded = map[string]bool{
+	"audit.log":         true,
+	"memory.md":         true,
+	"refresh.log":       true,
+	"refresh.lock":      true,
+	"enrich_batch.json": true,
+}
+
+// Manifest describes an archive. It is the archive's first entry.
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	// ProjectRoot is the absolute path the index was built in; paths
+	// recorded under it are relocated on import.
+	ProjectRoot string `json:"project_root"`
+	// Commit is the git commit the index was built at, if known.
+	Commit string `json:"commit,omitempty"`
+	Files  []File `json:"files"`
+}
+
+// File is an entry of the archive, relative to .index.
+type File struct {
+	Path   string `json:"path"` // slash-separated
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Export writes the indexes of the project at projectPath to w. commit
+// is recorded in the manifest and may be empty.
+func Export(w io.Writer, projectPath, commit string) (*Manifest, error) {
+	root, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve project path: %w", err)
+	}
+	dir := filepath.Join(root, indexDir)
+
+	m := &Manifest{FormatVersion: FormatVersion, CreatedAt: time.Now().UTC(), ProjectRoot: root, Commit: commit}
+	var paths []string
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Temporary files and staging directories are work in progress.
+		if strings.HasSuffix(d.Name(), ".tmp") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || excluded[d.Name()] {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		sum, size, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		m.Files = append(m.Files, File{Path: rel, Size: size, SHA256: sum})
+		paths = append(paths, p)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no index in %s; run indexer index and indexer rag index first", root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", dir, err)
+	}
+	if len(m.Files) == 0 {
+		return nil, fmt.Errorf("no index in %s; run indexer index and indexer rag index first", root)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := writeEntry(tw, manifestName, int64(len(manifest)), strings.NewReader(string(manifest))); err != nil {
+		return nil, err
+	}
+	for i, f := range m.Files {
+		if err := copyEntry(tw, indexDir+"/"+f.Path, paths[i], f.Size); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("finish archive: %w", err)
+	}
+	return m, nil
+}
+
+// Import unpacks an archive written by Export into the project at
+// projectPath, replacing the index files it contains and keeping the
+// others. Every file is checked against the manifest before any is put in
+// place.
+func Import(r io.Reader, projectPath string) (*Manifest, error) {
+	root, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve project path: %w", err)
+	}
+	dir := filepath.Join(root, indexDir)
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return nil, errors.New("not an index archive: missing manifest")
+	}
+	var m Manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	if m.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("archive format %d is not supported (want %d)", m.FormatVersion, FormatVersion)
+	}
+	want := make(map[string]File, len(m.Files))
+	for _, f := range m.Files {
+		want[f.Path] = f
+	}
+
+	// Unpack next to the index, then move files into place once all of
+	// them have been verified.
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create %s: %w", dir, err)
+	}
+	staging, err := os.MkdirTemp(dir, "import-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	seen := make(map[string]bool)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+		rel, ok := strings.CutPrefix(hdr.Name, indexDir+"/")
+		f, listed := want[rel]
+		if !ok || !listed || hdr.Typeflag != tar.TypeReg || !safePath(rel) {
+			return nil, fmt.Errorf("unexpected archive entry %q", hdr.Name)
+		}
+		if err := extract(tr, filepath.Join(staging, filepath.FromSlash(rel)), f); err != nil {
+			return nil, err
+		}
+		seen[rel] = true
+	}
+	for _, f := range m.Files {
+		if !seen[f.Path] {
+			return nil, fmt.Errorf("archive is missing %s", f.Path)
+		}
+	}
+
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	for _, f := range m.Files {
+		dst := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, fmt.Errorf("create %s: %w", filepath.Dir(dst), err)
+		}
+		if err := os.Rename(filepath.Join(staging, filepath.FromSlash(f.Path)), dst); err != nil {
+			return nil, fmt.Errorf("install %s: %w", f.Path, err)
+		}
+	}
+	return &m, nil
+}
+
+// Databases returns the paths of the RAG vector databases among the
+// manifest's files, under projectPath.
+func (m *Manifest) Databases(projectPath string) []string {
+	var out []string
+	for _, f := range m.Files {
+		if path.Base(f.Path) == "rag_vectors.db" {
+			out = append(out, filepath.Join(projectPath, indexDir, filepath.FromSlash(f.Path)))
+		}
+	}
+	return out
+}
+
+// safePath rejects entries that would land outside .index.
+func safePath(rel string) bool {
+	return rel != "" && !path.IsAbs(rel) && path.Clean(rel) == rel && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+func extract(r io.Reader, dst string, f File) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(dst), err)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("extract %s: %w", f.Path, err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), io.LimitReader(r, f.Size+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("extract %s: %w", f.Path, err)
+	}
+	if n != f.Size || hex.EncodeToString(h.Sum(nil)) != f.SHA256 {
+		return fmt.Errorf("extract %s: checksum mismatch, the archive is corrupt", f.Path)
+	}
+	return nil
+}
+
+func hashFile(p string) (string, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{Name: name, Mode: 0o644, Size: size, ModTime: time.Now(), Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// copyEntry adds the file at src, which must still be size bytes long.
+func copyEntry(tw *tar.Writer, name, src string, size int64) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", src, err)
+	}
+	defer f.Close()
+	return writeEntry(tw, name, size, io.LimitReader(f, size))
+}
@@ -0,0 +1,130 @@
+// Package localonly enforces local-only mode for air-gapped and
+// compliance-sensitive environments: only local models (Ollama, llama.cpp)
+// and the local embedder may be used, and any attempt to reach a remote API
+// fails with ErrRemote instead of sending data.
+package localonly
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrRemote is returned for any remote call made in local-only mode.
+var ErrRemote = errors.New("remote calls are disabled in local-only mode")
+
+// EnvVar enables local-only mode when set to a true value.
+const EnvVar = "AGENT_LOCAL_ONLY"
+
+var (
+	mu         sync.RWMutex
+	enabled    bool
+	allowHosts = make(map[string]bool)
+)
+
+// Enable turns on local-only mode for the rest of the process. Hosts in
+// allow (e.g. an Ollama server on the same isolated network) are treated as
+// local. Every request through http.DefaultTransport, which all of the
+// project's HTTP clients use, is checked from then on.
+func Enable(allow ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, h := range allow {
+		allowHosts[strings.ToLower(h)] = true
+	}
+	if enabled {
+		return
+	}
+	enabled = true
+	http.DefaultTransport = &guard{next: http.DefaultTransport}
+}
+
+// EnableFromEnv enables local-only mode if EnvVar is set, reporting whether
+// it did.
+func EnableFromEnv() bool {
+	switch strings.ToLower(os.Getenv(EnvVar)) {
+	case "1", "true", "yes", "on":
+		Enable()
+		return true
+	}
+	return false
+}
+
+// Enabled reports whether local-only mode is on.
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return enabled
+}
+
+// Check fails in local-only mode for a feature that always calls a remote
+// service.
+func Check(feature string) error {
+	if !Enabled() {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", feature, ErrRemote)
+}
+
+// CheckURL fails in local-only mode when target (a URL or host:port) is not
+// on this machine or an allowed host.
+func CheckURL(feature, target string) error {
+	if !Enabled() {
+		return nil
+	}
+	host := target
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return fmt.Errorf("%s: %w", feature, err)
+		}
+		host = u.Hostname()
+	} else if h, _, err := net.SplitHostPort(target); err == nil {
+		host = h
+	}
+	if IsLocal(host) {
+		return nil
+	}
+	return fmt.Errorf("%s at %s: %w", feature, host, ErrRemote)
+}
+
+// IsLocal reports whether host is a loopback name or address, or allowed.
+func IsLocal(host string) bool {
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	return allowHosts[host]
+}
+
+// Wrap guards a transport that does not go through http.DefaultTransport
+// (e.g. one with its own proxy settings): once local-only mode is enabled,
+// its requests to non-local hosts fail like those of the default transport.
+func Wrap(rt http.RoundTripper) http.RoundTripper {
+	return &guard{next: rt}
+}
+
+// guard rejects requests to non-local hosts.
+type guard struct {
+	next http.RoundTripper
+}
+
+func (g *guard) RoundTrip(req *http.Request) (*http.Response, error) {
+	if Enabled() && !IsLocal(req.URL.Hostname()) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Host, ErrRemote)
+	}
+	return g.next.RoundTrip(req)
+}
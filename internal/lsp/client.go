@@ -0,0 +1,241 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// shutdownTimeout bounds how long Close waits for the server to acknowledge
+// shutdown before it is asked to exit.
+const shutdownTimeout = 5 * time.Second
+
+// Client talks to an external language server over stdio. It is used to
+// delegate precise queries (e.g. to gopls) that the structural index can
+// only approximate.
+type Client struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan clientResult
+	done    chan struct{}
+	err     error
+}
+
+type clientResult struct {
+	result json.RawMessage
+	err    *responseError
+}
+
+type clientMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+// StartClient launches a language server, performs the initialize
+// handshake with rootPath as the workspace, and returns a ready client.
+func StartClient(ctx context.Context, command []string, rootPath string) (*Client, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = rootPath
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command[0], err)
+	}
+
+	c := &Client{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int]chan clientResult),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop(bufio.NewReader(stdout))
+
+	rootURI := pathToURI(rootPath)
+	initParams := map[string]interface{}{
+		"processId": nil,
+		"rootUri":   rootURI,
+		"workspaceFolders": []map[string]string{
+			{"uri": rootURI, "name": "workspace"},
+		},
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"hover":         map[string]interface{}{"contentFormat": []string{"markdown", "plaintext"}},
+				"callHierarchy": map[string]interface{}{},
+			},
+			"workspace": map[string]interface{}{"workspaceFolders": true},
+		},
+	}
+	if err := c.Call(ctx, "initialize", initParams, nil); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+	if err := c.Notify("initialized", map[string]interface{}{}); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Call sends a request and decodes its result into result (which may be nil).
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan clientResult, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	rawID := json.RawMessage(fmt.Sprintf("%d", id))
+	if err := c.send(clientMessage{JSONRPC: "2.0", ID: &rawID, Method: method, Params: mustMarshal(params)}); err != nil {
+		c.forget(id)
+		return err
+	}
+
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return fmt.Errorf("%s: %s (code %d)", method, res.err.Message, res.err.Code)
+		}
+		if result != nil && len(res.result) > 0 {
+			if err := json.Unmarshal(res.result, result); err != nil {
+				return fmt.Errorf("%s: decode result: %w", method, err)
+			}
+		}
+		return nil
+	case <-c.done:
+		return c.closedErr()
+	case <-ctx.Done():
+		c.forget(id)
+		return ctx.Err()
+	}
+}
+
+// Notify sends a notification.
+func (c *Client) Notify(method string, params interface{}) error {
+	return c.send(clientMessage{JSONRPC: "2.0", Method: method, Params: mustMarshal(params)})
+}
+
+// Close asks the server to shut down and waits for it to exit.
+func (c *Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	_ = c.Call(ctx, "shutdown", nil, nil)
+	_ = c.Notify("exit", nil)
+	c.stdin.Close()
+	return c.cmd.Wait()
+}
+
+func (c *Client) send(msg clientMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.stdin, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err = c.stdin.Write(data)
+	return err
+}
+
+func (c *Client) readLoop(r *bufio.Reader) {
+	defer close(c.done)
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			c.mu.Lock()
+			c.err = fmt.Errorf("language server connection closed: %w", err)
+			c.mu.Unlock()
+			return
+		}
+
+		var msg clientMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			log.Printf("lsp client: invalid message: %v", err)
+			continue
+		}
+
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			c.replyToServer(msg)
+		case msg.Method != "":
+			// Server notifications (progress, logs, diagnostics) are not needed.
+		case msg.ID != nil:
+			var id int
+			if err := json.Unmarshal(*msg.ID, &id); err != nil {
+				continue
+			}
+			c.mu.Lock()
+			ch, ok := c.pending[id]
+			delete(c.pending, id)
+			c.mu.Unlock()
+			if ok {
+				ch <- clientResult{result: msg.Result, err: msg.Error}
+			}
+		}
+	}
+}
+
+// replyToServer answers server-to-client requests with empty results so the
+// server never blocks waiting on us.
+func (c *Client) replyToServer(msg clientMessage) {
+	var result interface{}
+	if msg.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		result = make([]interface{}, len(params.Items))
+	}
+	if err := c.send(clientMessage{JSONRPC: "2.0", ID: msg.ID, Result: mustMarshal(result)}); err != nil {
+		log.Printf("lsp client: reply to %s: %v", msg.Method, err)
+	}
+}
+
+func (c *Client) forget(id int) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *Client) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	return fmt.Errorf("language server connection closed")
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("lsp: marshal %T: %v", v, err))
+	}
+	return data
+}
@@ -0,0 +1,542 @@
+// Package lsp serves a subset of the Language Server Protocol on top of the
+// structural ProjectIndex so editors can query the index directly.
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yourorg/agent/internal/indexer"
+	"github.com/yourorg/agent/internal/refs"
+)
+
+// Explainer produces an AI explanation of a symbol for hover requests.
+// *agent.CodingAgent satisfies it through a small adapter in the CLI.
+type Explainer interface {
+	Explain(ctx context.Context, symbol string) (string, error)
+}
+
+// Server answers LSP requests using a ProjectIndex.
+type Server struct {
+	root      string
+	indexer   *indexer.Indexer
+	explainer Explainer
+	gopls     *Client
+
+	mu    sync.Mutex
+	index *indexer.ProjectIndex
+	docs  map[string]string // open document contents keyed by URI
+
+	out   *bufio.Writer
+	outMu sync.Mutex
+}
+
+// Config configures a Server.
+type Config struct {
+	ProjectRoot string
+	Indexer     *indexer.Indexer
+	Explainer   Explainer // optional; enables AI explanations in hover
+	Gopls       *Client   // optional; answers .go definition/references/implementation precisely
+}
+
+// NewServer creates a server for the project at cfg.ProjectRoot.
+func NewServer(cfg Config) *Server {
+	return &Server{
+		root:      cfg.ProjectRoot,
+		indexer:   cfg.Indexer,
+		explainer: cfg.Explainer,
+		gopls:     cfg.Gopls,
+		docs:      make(map[string]string),
+	}
+}
+
+// Serve reads framed JSON-RPC messages from r and writes responses to w
+// until the client sends "exit" or r is closed.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = bufio.NewWriter(w)
+	in := bufio.NewReader(r)
+
+	for {
+		body, err := readMessage(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			log.Printf("lsp: invalid message: %v", err)
+			continue
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+		s.handle(req)
+	}
+}
+
+func (s *Server) handle(req request) {
+	result, rpcErr := s.dispatch(req)
+
+	// Notifications carry no ID and get no response.
+	if req.ID == nil {
+		if rpcErr != nil {
+			log.Printf("lsp: %s: %s", req.Method, rpcErr.Message)
+		}
+		return
+	}
+
+	s.write(response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr})
+}
+
+func (s *Server) dispatch(req request) (interface{}, *responseError) {
+	if s.forwardsToGopls(req) {
+		return s.forward(req)
+	}
+
+	switch req.Method {
+	case "initialize":
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:        1,
+				DefinitionProvider:      true,
+				ReferencesProvider:      true,
+				ImplementationProvider:  s.gopls != nil,
+				HoverProvider:           true,
+				WorkspaceSymbolProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "code-indexer", Version: "1.0.0"},
+		}, nil
+
+	case "initialized":
+		// Warm the index so the first query is fast.
+		go func() {
+			if _, err := s.projectIndex(); err != nil {
+				log.Printf("lsp: indexing failed: %v", err)
+			}
+		}()
+		return nil, nil
+
+	case "shutdown":
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.notifyGopls(req, p.TextDocument.URI)
+		s.mu.Lock()
+		s.docs[p.TextDocument.URI] = p.TextDocument.Text
+		s.mu.Unlock()
+		return nil, nil
+
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.notifyGopls(req, p.TextDocument.URI)
+		if n := len(p.ContentChanges); n > 0 {
+			s.mu.Lock()
+			s.docs[p.TextDocument.URI] = p.ContentChanges[n-1].Text
+			s.mu.Unlock()
+		}
+		return nil, nil
+
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.notifyGopls(req, p.TextDocument.URI)
+		s.mu.Lock()
+		delete(s.docs, p.TextDocument.URI)
+		s.mu.Unlock()
+		return nil, nil
+
+	case "textDocument/didSave":
+		// Saved files change the index; rebuild lazily on the next query.
+		s.mu.Lock()
+		s.index = nil
+		s.mu.Unlock()
+		return nil, nil
+
+	case "workspace/symbol":
+		var p workspaceSymbolParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.workspaceSymbol(p.Query)
+
+	case "textDocument/definition":
+		var p TextDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.definition(p)
+
+	case "textDocument/references":
+		var p ReferenceParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.references(p)
+
+	case "textDocument/hover":
+		var p TextDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.hover(p)
+
+	default:
+		if strings.HasPrefix(req.Method, "$/") {
+			return nil, nil
+		}
+		return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+// forwardsToGopls reports whether a position request on a Go file should be
+// answered by gopls instead of the structural index.
+func (s *Server) forwardsToGopls(req request) bool {
+	if s.gopls == nil {
+		return false
+	}
+	switch req.Method {
+	case "textDocument/definition", "textDocument/references", "textDocument/implementation":
+	default:
+		return false
+	}
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(req.Params, &p); err != nil {
+		return false
+	}
+	return isGoURI(p.TextDocument.URI)
+}
+
+func (s *Server) forward(req request) (interface{}, *responseError) {
+	var result json.RawMessage
+	if err := s.gopls.Call(context.Background(), req.Method, req.Params, &result); err != nil {
+		return nil, &responseError{Code: codeInternalError, Message: err.Error()}
+	}
+	return result, nil
+}
+
+// notifyGopls keeps gopls' view of open Go buffers in sync with the editor.
+func (s *Server) notifyGopls(req request, uri string) {
+	if s.gopls == nil || !isGoURI(uri) {
+		return
+	}
+	if err := s.gopls.Notify(req.Method, req.Params); err != nil {
+		log.Printf("lsp: forward %s to gopls: %v", req.Method, err)
+	}
+}
+
+func (s *Server) workspaceSymbol(query string) (interface{}, *responseError) {
+	engine, rpcErr := s.searchEngine()
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	symbols := []SymbolInformation{}
+	for _, r := range engine.SearchSymbol(query) {
+		symbols = append(symbols, SymbolInformation{
+			Name:     r.Name,
+			Kind:     symbolKind(r.Type),
+			Location: s.location(r),
+		})
+	}
+	return symbols, nil
+}
+
+func (s *Server) definition(p TextDocumentPositionParams) (interface{}, *responseError) {
+	word := s.wordAt(p.TextDocument.URI, p.Position)
+	if word == "" {
+		return nil, nil
+	}
+
+	engine, rpcErr := s.searchEngine()
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	locations := []Location{}
+	for _, r := range engine.SearchSymbol(word) {
+		if symbolMatches(r.Name, word) {
+			locations = append(locations, s.location(r))
+		}
+	}
+	return locations, nil
+}
+
+// references returns every use of the identifier under the cursor,
+// matched by name across the project (see package refs), and its
+// definitions when the client asks for them.
+func (s *Server) references(p ReferenceParams) (interface{}, *responseError) {
+	word := s.wordAt(p.TextDocument.URI, p.Position)
+	if word == "" {
+		return nil, nil
+	}
+
+	found, err := refs.Find(s.root, word)
+	if err != nil {
+		return nil, &responseError{Code: codeInternalError, Message: fmt.Sprintf("find references: %v", err)}
+	}
+
+	locations := []Location{}
+	if p.Context.IncludeDeclaration {
+		engine, rpcErr := s.searchEngine()
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		for _, r := range engine.SearchSymbol(word) {
+			if symbolMatches(r.Name, word) {
+				locations = append(locations, s.location(r))
+			}
+		}
+	}
+
+	files := make(map[string][]string)
+	for _, r := range found {
+		path := filepath.Join(s.root, filepath.FromSlash(r.File))
+		lines, ok := files[path]
+		if !ok {
+			if data, err := os.ReadFile(path); err == nil {
+				lines = strings.Split(string(data), "\n")
+			}
+			files[path] = lines
+		}
+		// Columns are byte offsets; positions count characters, as in
+		// wordAt.
+		char := r.Column - 1
+		if r.Line-1 < len(lines) && char <= len(lines[r.Line-1]) {
+			char = utf8.RuneCountInString(lines[r.Line-1][:char])
+		}
+		start := Position{Line: r.Line - 1, Character: char}
+		end := Position{Line: start.Line, Character: char + utf8.RuneCountInString(word)}
+		locations = append(locations, Location{URI: pathToURI(path), Range: Range{Start: start, End: end}})
+	}
+	return locations, nil
+}
+
+func (s *Server) hover(p TextDocumentPositionParams) (interface{}, *responseError) {
+	word := s.wordAt(p.TextDocument.URI, p.Position)
+	if word == "" {
+		return nil, nil
+	}
+
+	engine, rpcErr := s.searchEngine()
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	details := engine.GetSymbolDetails(word)
+	if details == nil {
+		return nil, nil
+	}
+
+	var b strings.Builder
+	if details.Signature != "" {
+		b.WriteString("```\n")
+		b.WriteString(details.Signature)
+		b.WriteString("\n```\n\n")
+	}
+	if details.Doc != "" {
+		b.WriteString(details.Doc)
+		b.WriteString("\n\n")
+	}
+	fmt.Fprintf(&b, "*%s* — %s:%d", details.Type, details.FilePath, details.Line)
+
+	if s.explainer != nil {
+		explanation, err := s.explainer.Explain(context.Background(), details.Name)
+		if err != nil {
+			log.Printf("lsp: explain %s: %v", details.Name, err)
+		} else if explanation != "" {
+			b.WriteString("\n\n---\n\n")
+			b.WriteString(explanation)
+		}
+	}
+
+	return Hover{Contents: MarkupContent{Kind: "markdown", Value: b.String()}}, nil
+}
+
+func (s *Server) projectIndex() (*indexer.ProjectIndex, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.index != nil {
+		return s.index, nil
+	}
+	idx, err := s.indexer.IndexProject(s.root)
+	if err != nil {
+		return nil, err
+	}
+	s.index = idx
+	return idx, nil
+}
+
+func (s *Server) searchEngine() (*indexer.SearchEngine, *responseError) {
+	idx, err := s.projectIndex()
+	if err != nil {
+		return nil, &responseError{Code: codeInternalError, Message: fmt.Sprintf("index project: %v", err)}
+	}
+	return indexer.NewSearchEngine(idx), nil
+}
+
+// location converts a 1-based indexed position into an LSP location.
+func (s *Server) location(r indexer.SearchResult) Location {
+	path := r.FilePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.root, path)
+	}
+	line := r.Line - 1
+	if line < 0 {
+		line = 0
+	}
+	pos := Position{Line: line}
+	return Location{URI: pathToURI(path), Range: Range{Start: pos, End: pos}}
+}
+
+// wordAt returns the identifier under the cursor, reading the document from
+// disk when the client has not opened it.
+func (s *Server) wordAt(uri string, pos Position) string {
+	s.mu.Lock()
+	text, ok := s.docs[uri]
+	s.mu.Unlock()
+
+	if !ok {
+		data, err := os.ReadFile(uriToPath(uri))
+		if err != nil {
+			return ""
+		}
+		text = string(data)
+	}
+
+	lines := strings.Split(text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return ""
+	}
+	line := []rune(lines[pos.Line])
+	if pos.Character < 0 || pos.Character > len(line) {
+		return ""
+	}
+
+	isIdent := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	start, end := pos.Character, pos.Character
+	for start > 0 && isIdent(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdent(line[end]) {
+		end++
+	}
+	return string(line[start:end])
+}
+
+func (s *Server) write(resp response) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("lsp: encode response: %v", err)
+		return
+	}
+
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(data))
+	s.out.Write(data)
+	s.out.Flush()
+}
+
+// readMessage reads one Content-Length framed message body.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length: %w", err)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// Helper functions
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+// symbolMatches accepts exact names and qualified names ending in .word.
+func symbolMatches(name, word string) bool {
+	return name == word || strings.HasSuffix(name, "."+word)
+}
+
+func symbolKind(kind string) int {
+	switch strings.ToLower(kind) {
+	case "class":
+		return symbolKindClass
+	case "method":
+		return symbolKindMethod
+	case "field":
+		return symbolKindField
+	case "interface":
+		return symbolKindInterface
+	case "struct", "type":
+		return symbolKindStruct
+	case "const", "constant":
+		return symbolKindConstant
+	case "var", "variable":
+		return symbolKindVariable
+	default:
+		return symbolKindFunction
+	}
+}
+
+func isGoURI(uri string) bool {
+	return strings.HasSuffix(uri, ".go")
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
@@ -0,0 +1,221 @@
+// Package memory keeps the agent's per-project notes: conventions and
+// learnings ("tests live in tests/, use the db fixture") recorded during
+// runs or by hand, and fed back into later prompts.
+//
+// Notes are stored as Markdown in .index/memory.md so they can be read and
+// edited directly: "## category" headings followed by "- note" bullets.
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultCategory holds notes recorded without a category.
+const DefaultCategory = "general"
+
+// Path returns the memory file of the project at projectPath.
+func Path(projectPath string) string {
+	return filepath.Join(projectPath, ".index", "memory.md")
+}
+
+// Entry is one remembered note.
+type Entry struct {
+	Category string `json:"category"`
+	Text     string `json:"text"`
+}
+
+// Store is a project's memory file loaded into memory.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	entries []Entry
+}
+
+// Load reads the memory of the project at projectPath. A missing file
+// yields an empty store.
+func Load(projectPath string) (*Store, error) {
+	s := &Store{path: Path(projectPath)}
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open memory: %w", err)
+	}
+	defer f.Close()
+
+	category := DefaultCategory
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case strings.HasPrefix(line, "## "):
+			category = strings.TrimSpace(line[3:])
+		case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "):
+			if text := strings.TrimSpace(line[2:]); text != "" {
+				s.entries = append(s.entries, Entry{Category: category, Text: text})
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read memory: %w", err)
+	}
+	return s, nil
+}
+
+// Path returns the file the store is saved to.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Entries returns the notes in file order.
+func (s *Store) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry(nil), s.entries...)
+}
+
+// Add records a note, reporting false if an identical one (ignoring case)
+// already exists. Notes and categories are kept to a single line, so they
+// read back from the file as they were added.
+func (s *Store) Add(category, text string) bool {
+	text = cleanText(text)
+	category = cleanCategory(category)
+	if text == "" {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.has(text) {
+		return false
+	}
+	s.entries = append(s.entries, Entry{Category: category, Text: text})
+	return true
+}
+
+// Has reports whether a note identical to text, as Add compares them, is
+// already recorded.
+func (s *Store) Has(text string) bool {
+	text = cleanText(text)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.has(text)
+}
+
+// cleanText folds a note onto one line.
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// cleanCategory folds a category onto one line and strips the "#"s around
+// it, which would otherwise change the heading it is saved under.
+func cleanCategory(category string) string {
+	category = strings.TrimSpace(strings.Trim(cleanText(category), "#"))
+	if category == "" {
+		return DefaultCategory
+	}
+	return category
+}
+
+func (s *Store) has(text string) bool {
+	for _, e := range s.entries {
+		if strings.EqualFold(e.Text, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove deletes the note at index i (0-based, in Entries order).
+func (s *Store) Remove(i int) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i < 0 || i >= len(s.entries) {
+		return Entry{}, fmt.Errorf("no memory entry %d", i+1)
+	}
+	e := s.entries[i]
+	s.entries = append(s.entries[:i], s.entries[i+1:]...)
+	return e, nil
+}
+
+// Clear deletes every note.
+func (s *Store) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+}
+
+// Save writes the store back to its file, grouping notes by category in
+// order of first appearance.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# Project memory\n\nConventions and learnings recorded by the agent. Edit freely: one \"- note\" per line under \"## category\" headings.\n")
+	for _, category := range s.categories() {
+		fmt.Fprintf(&b, "\n## %s\n\n", category)
+		for _, e := range s.entries {
+			if e.Category == category {
+				fmt.Fprintf(&b, "- %s\n", e.Text)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("save memory: %w", err)
+	}
+	if err := os.WriteFile(s.path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("save memory: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) categories() []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, e := range s.entries {
+		if !seen[e.Category] {
+			seen[e.Category] = true
+			categories = append(categories, e.Category)
+		}
+	}
+	return categories
+}
+
+// Format renders up to limit notes (0 means all), grouped by category, for
+// inclusion in a prompt. It returns "" when there are none.
+func (s *Store) Format(limit int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) == 0 {
+		return ""
+	}
+	keep := s.entries
+	if limit > 0 && len(keep) > limit {
+		// Keep the most recent notes; later ones tend to refine earlier ones.
+		keep = keep[len(keep)-limit:]
+	}
+
+	var b strings.Builder
+	for _, category := range s.categories() {
+		header := false
+		for _, e := range keep {
+			if e.Category != category {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(&b, "[%s]\n", category)
+				header = true
+			}
+			fmt.Fprintf(&b, "- %s\n", e.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAddKeepsFileStructure(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adds := []struct{ category, text string }{
+		{"testing", "tests live in tests/"},
+		{"build\n## injected", "run make\n- before pushing"},
+		{"## style ##", "wrap errors with %w"},
+		{"", "  spaced   out\tnote "},
+	}
+	for _, a := range adds {
+		if !s.Add(a.category, a.text) {
+			t.Fatalf("Add(%q, %q) = false", a.category, a.text)
+		}
+	}
+	if s.Add("other", "TESTS LIVE IN   tests/") {
+		t.Error("Add accepted a duplicate differing in case and spacing")
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Category: "testing", Text: "tests live in tests/"},
+		{Category: "build ## injected", Text: "run make - before pushing"},
+		{Category: "style", Text: "wrap errors with %w"},
+		{Category: DefaultCategory, Text: "spaced out note"},
+	}
+	if got := loaded.Entries(); !slices.Equal(got, want) {
+		t.Errorf("entries after reload:\n got %q\nwant %q", got, want)
+	}
+	for _, e := range want {
+		if !loaded.Has(e.Text) {
+			t.Errorf("Has(%q) = false after reload", e.Text)
+		}
+	}
+	if !loaded.Has("run make\n- before pushing") {
+		t.Error("Has does not fold a multi-line note as Add does")
+	}
+}
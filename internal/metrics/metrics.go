@@ -0,0 +1,84 @@
+// Package metrics exposes operational counters and histograms in the
+// Prometheus text format without pulling in the client library.
+package metrics
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// Metrics shared by the indexer, retrieval and agent packages.
+var (
+	IndexDuration = NewHistogramVec(
+		"indexer_index_duration_seconds",
+		"Time spent building a structural or RAG index.",
+		nil, "kind")
+
+	SearchLatency = NewHistogramVec(
+		"indexer_search_latency_seconds",
+		"Latency of structural, semantic, keyword and hybrid searches.",
+		nil, "kind")
+
+	LLMLatency = NewHistogramVec(
+		"agent_llm_request_duration_seconds",
+		"Latency of LLM chat requests.",
+		nil, "provider", "model")
+
+	LLMTokens = NewCounterVec(
+		"agent_llm_tokens_total",
+		"Tokens consumed by LLM chat requests.",
+		"provider", "model")
+
+	LLMErrors = NewCounterVec(
+		"agent_llm_errors_total",
+		"Failed LLM chat requests.",
+		"provider", "model")
+
+	EmbedderErrors = NewCounterVec(
+		"rag_embedder_errors_total",
+		"Failed embedding requests.",
+		"model")
+
+	SecretsRedacted = NewCounterVec(
+		"agent_secrets_redacted_total",
+		"Secrets redacted from content before it was sent to an LLM or embedder.",
+		"destination")
+
+	CacheRequests = NewCounterVec(
+		"indexer_cache_requests_total",
+		"Cache lookups partitioned by cache name and result (hit or miss).",
+		"cache", "result")
+)
+
+// ObserveSince records the time elapsed since start on h.
+func ObserveSince(h *HistogramVec, start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// CacheHit records a cache lookup result for the named cache.
+func CacheHit(cache string, hit bool) {
+	if hit {
+		CacheRequests.Inc(cache, "hit")
+	} else {
+		CacheRequests.Inc(cache, "miss")
+	}
+}
+
+// Serve starts an HTTP server exposing /metrics on addr in the background.
+// An empty addr disables the endpoint.
+func Serve(addr string) {
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	go func() {
+		log.Printf("Serving metrics on %s/metrics", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Metrics server stopped: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,154 @@
+// Package models records the context window and output limits of known
+// LLMs, so prompts can be budgeted to fit the model instead of failing with
+// "context length exceeded".
+package models
+
+import (
+	"strings"
+	"sync"
+)
+
+// Info describes a model's limits, in tokens.
+type Info struct {
+	ContextWindow   int `json:"context_window"`    // input plus output
+	MaxOutputTokens int `json:"max_output_tokens"` // longest completion
+}
+
+// ReservedOutputTokens is the most of the context window held back for the
+// completion; models that can write more rarely need to.
+const ReservedOutputTokens = 8192
+
+// PromptBudget returns how many tokens a request's messages may use: the
+// context window minus room for the completion and a 10% margin for the
+// inaccuracy of EstimateTokens.
+func (i Info) PromptBudget() int {
+	reserve := min(i.MaxOutputTokens, ReservedOutputTokens)
+	return (i.ContextWindow - reserve) * 9 / 10
+}
+
+// known maps model name prefixes to their limits. The longest matching
+// prefix wins, so dated snapshots ("claude-sonnet-4-5-20250929") and
+// variants ("llama3.1:8b") resolve to their family.
+var known = map[string]Info{
+	// Anthropic
+	"claude-opus-4":     {200000, 32000},
+	"claude-sonnet-4":   {200000, 64000},
+	"claude-haiku-4":    {200000, 64000},
+	"claude-3-7-sonnet": {200000, 64000},
+	"claude-3-5-sonnet": {200000, 8192},
+	"claude-3-5-haiku":  {200000, 8192},
+	"claude-3":          {200000, 4096},
+
+	// OpenAI
+	"gpt-5":         {400000, 128000},
+	"gpt-4.1":       {1047576, 32768},
+	"gpt-4o":        {128000, 16384},
+	"gpt-4-turbo":   {128000, 4096},
+	"gpt-4":         {8192, 8192},
+	"gpt-3.5-turbo": {16385, 4096},
+	"o1-mini":       {128000, 65536},
+	"o1":            {200000, 100000},
+	"o3":            {200000, 100000},
+	"o4-mini":       {200000, 100000},
+
+	// Google
+	"gemini-2.5":     {1048576, 65536},
+	"gemini-2.0":     {1048576, 8192},
+	"gemini-1.5-pro": {2097152, 8192},
+	"gemini-1.5":     {1048576, 8192},
+
+	// Local models, at their trained context length. Ollama and llama.cpp
+	// servers may be configured with less; register the real size in
+	// .indexer.json under "models" when they are.
+	"llama3.1":          {131072, 4096},
+	"llama3.2":          {131072, 4096},
+	"llama3.3":          {131072, 4096},
+	"llama3":            {8192, 4096},
+	"qwen2.5-coder":     {32768, 8192},
+	"qwen3":             {40960, 8192},
+	"mistral":           {32768, 4096},
+	"codellama":         {16384, 4096},
+	"deepseek-coder-v2": {163840, 8192},
+	"deepseek-r1":       {131072, 8192},
+}
+
+// providerDefaults apply to models not in the registry.
+var providerDefaults = map[string]Info{
+	"claude":   {200000, 8192},
+	"openai":   {128000, 16384},
+	"gemini":   {1048576, 8192},
+	"ollama":   {8192, 2048},
+	"llamacpp": {8192, 2048},
+}
+
+// fallback applies when neither the model nor its provider is known.
+var fallback = Info{ContextWindow: 8192, MaxOutputTokens: 2048}
+
+var (
+	mu     sync.RWMutex
+	custom = map[string]Info{}
+)
+
+// Register adds or overrides the limits of model (an exact name or a
+// prefix), e.g. a fine-tuned or self-hosted model.
+func Register(model string, info Info) {
+	mu.Lock()
+	defer mu.Unlock()
+	custom[strings.ToLower(model)] = info
+}
+
+// Lookup returns the limits of model, falling back to provider's defaults
+// (provider may be empty).
+func Lookup(provider, model string) Info {
+	name := strings.ToLower(model)
+	// Strip a provider path such as "openrouter/anthropic/claude-sonnet-4".
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	mu.RLock()
+	info, ok := longestPrefix(custom, name)
+	mu.RUnlock()
+	if ok {
+		return info
+	}
+	if info, ok := longestPrefix(known, name); ok {
+		return info
+	}
+	if info, ok := providerDefaults[provider]; ok {
+		return info
+	}
+	return fallback
+}
+
+func longestPrefix(table map[string]Info, name string) (Info, bool) {
+	best, found := "", false
+	for prefix := range table {
+		if strings.HasPrefix(name, prefix) && len(prefix) >= len(best) {
+			best, found = prefix, true
+		}
+	}
+	return table[best], found
+}
+
+// EstimateTokens approximates the number of tokens in text at about four
+// characters per token.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// Truncate shortens text to about maxTokens, cutting at a line boundary
+// where possible, and marks the cut.
+func Truncate(text string, maxTokens int) string {
+	if EstimateTokens(text) <= maxTokens {
+		return text
+	}
+	if maxTokens <= 0 {
+		return ""
+	}
+	keep := maxTokens * 4
+	if i := strings.LastIndexByte(text[:keep], '\n'); i > keep/2 {
+		keep = i
+	}
+	return text[:keep] + "\n[... truncated to fit the model's context window]\n"
+}
@@ -0,0 +1,132 @@
+// Package notify posts agent run summaries to webhooks and Slack.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourorg/agent/internal/agent"
+)
+
+// Summary describes a finished agent run.
+type Summary struct {
+	Task         string        `json:"task"`
+	Project      string        `json:"project"`
+	Provider     string        `json:"provider"`
+	Model        string        `json:"model,omitempty"`
+	Success      bool          `json:"success"`
+	Completed    int           `json:"tasks_completed"`
+	Failed       int           `json:"tasks_failed"`
+	Total        int           `json:"tasks_total"`
+	FilesChanged []string      `json:"files_changed"`
+	TokensUsed   int           `json:"tokens_used"`
+	CostUSD      float64       `json:"cost_usd,omitempty"`
+	Duration     time.Duration `json:"duration_ns"`
+	URL          string        `json:"url,omitempty"` // pull/merge request opened for the run
+	Error        string        `json:"error,omitempty"`
+}
+
+// NewSummary builds a summary from a run result. result may be nil when
+// the run failed before producing one.
+func NewSummary(task string, result *agent.RunResult) Summary {
+	s := Summary{Task: task, FilesChanged: []string{}}
+	if result == nil {
+		return s
+	}
+
+	s.Success = result.Succeeded()
+	s.TokensUsed = result.TokensUsed
+	s.FilesChanged = append(s.FilesChanged, result.FilesChanged()...)
+	for _, exec := range result.Executions {
+		s.Total++
+		switch {
+		case exec.Completed:
+			s.Completed++
+		case exec.Failed:
+			s.Failed++
+		}
+	}
+	return s
+}
+
+// Title is a one-line headline for the run.
+func (s Summary) Title() string {
+	status := "✅ Agent run succeeded"
+	switch {
+	case s.Error != "":
+		status = "❌ Agent run errored"
+	case !s.Success:
+		status = "⚠️ Agent run finished with failures"
+	}
+	return fmt.Sprintf("%s: %s", status, firstLine(s.Task))
+}
+
+// Text renders the summary as plain text (also valid Slack mrkdwn).
+func (s Summary) Text() string {
+	var b strings.Builder
+	b.WriteString(s.Title())
+	b.WriteString("\n")
+	if s.Project != "" {
+		fmt.Fprintf(&b, "Project: %s\n", s.Project)
+	}
+	if s.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", s.Error)
+	}
+	fmt.Fprintf(&b, "Tasks: %d/%d completed, %d failed\n", s.Completed, s.Total, s.Failed)
+	fmt.Fprintf(&b, "Files changed: %d", len(s.FilesChanged))
+	if n := len(s.FilesChanged); n > 0 {
+		shown := s.FilesChanged
+		if n > 10 {
+			shown = shown[:10]
+		}
+		fmt.Fprintf(&b, " (%s", strings.Join(shown, ", "))
+		if n > 10 {
+			fmt.Fprintf(&b, ", +%d more", n-10)
+		}
+		b.WriteString(")")
+	}
+	b.WriteString("\n")
+	model := s.Provider
+	if s.Model != "" {
+		model += "/" + s.Model
+	}
+	fmt.Fprintf(&b, "LLM: %s, %d tokens", model, s.TokensUsed)
+	if s.CostUSD > 0 {
+		fmt.Fprintf(&b, " (~$%.2f)", s.CostUSD)
+	}
+	fmt.Fprintf(&b, ", %s\n", s.Duration.Round(time.Second))
+	if s.URL != "" {
+		fmt.Fprintf(&b, "%s\n", s.URL)
+	}
+	return b.String()
+}
+
+func firstLine(s string) string {
+	line := strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+	if len(line) > 100 {
+		line = line[:97] + "..."
+	}
+	return line
+}
+
+// Notifier delivers run summaries.
+type Notifier interface {
+	Notify(ctx context.Context, s Summary) error
+}
+
+// Multi fans a summary out to several notifiers and joins their errors.
+type Multi []Notifier
+
+// Notify implements Notifier.
+func (m Multi) Notify(ctx context.Context, s Summary) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/yourorg/agent/internal/proxy"
+)
+
+// WebhookNotifier POSTs the summary as JSON to an arbitrary URL.
+type WebhookNotifier struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookNotifier creates a notifier for url. headers are sent with every
+// request (e.g. an Authorization header).
+func NewWebhookNotifier(url string, headers map[string]string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:     url,
+		headers: headers,
+		client:  proxy.Client("webhook", 30*time.Second),
+	}
+}
+
+type webhookPayload struct {
+	Event string `json:"event"`
+	Text  string `json:"text"`
+	Summary
+}
+
+// Notify implements Notifier.
+func (w *WebhookNotifier) Notify(ctx context.Context, s Summary) error {
+	return postJSON(ctx, w.client, w.url, w.headers, webhookPayload{
+		Event:   "agent_run.finished",
+		Text:    s.Text(),
+		Summary: s,
+	})
+}
+
+// SlackNotifier posts the summary to a Slack incoming webhook.
+type SlackNotifier struct {
+	webhookURL string
+	channel    string
+	client     *http.Client
+}
+
+// NewSlackNotifier creates a notifier for a Slack incoming webhook. channel
+// overrides the webhook's default channel when non-empty.
+func NewSlackNotifier(webhookURL, channel string) *SlackNotifier {
+	return &SlackNotifier{
+		webhookURL: webhookURL,
+		channel:    channel,
+		client:     proxy.Client("slack", 30*time.Second),
+	}
+}
+
+type slackMessage struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Notify implements Notifier.
+func (n *SlackNotifier) Notify(ctx context.Context, s Summary) error {
+	return postJSON(ctx, n.client, n.webhookURL, nil, slackMessage{
+		Channel: n.channel,
+		Text:    s.Text(),
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("notification to %s failed with status %d: %s", req.URL.Host, resp.StatusCode, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// Package proxy builds the transports of outbound HTTP clients. By default
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY apply to every client; .indexer.json
+// can set a proxy, custom CAs and client certificates for all services or
+// for individual providers.
+package proxy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/yourorg/agent/internal/localonly"
+)
+
+// Direct, as a provider's proxy URL, sends its requests without a proxy.
+const Direct = "direct"
+
+// Config sets proxies in place of, or on top of, the environment.
+type Config struct {
+	URL       string            `json:"url,omitempty"`       // proxy for every service; overrides HTTP(S)_PROXY
+	NoProxy   string            `json:"no_proxy,omitempty"`  // hosts to reach directly; overrides NO_PROXY
+	Providers map[string]string `json:"providers,omitempty"` // per-service proxy URL (claude, openai, ollama, github, ...) or "direct"
+}
+
+// Registry holds the proxy and TLS configuration shared by every client.
+type Registry struct {
+	mu           sync.Mutex
+	cfg          Config
+	tls          *tls.Config
+	tlsProviders map[string]*tls.Config
+	transports   map[string]http.RoundTripper // keyed by service
+}
+
+// NewRegistry creates a registry that uses the environment's proxies.
+func NewRegistry() *Registry {
+	return &Registry{transports: make(map[string]http.RoundTripper)}
+}
+
+// Default is the process-wide registry used by Client and Transport.
+var Default = NewRegistry()
+
+// Configure validates cfg and applies it to requests made from then on.
+func (r *Registry) Configure(cfg Config) error {
+	if err := validate("proxy.url", cfg.URL); err != nil {
+		return err
+	}
+	providers := make(map[string]string, len(cfg.Providers))
+	for service, u := range cfg.Providers {
+		if u != Direct {
+			if err := validate("proxy.providers."+service, u); err != nil {
+				return err
+			}
+		}
+		providers[strings.ToLower(service)] = u
+	}
+	cfg.Providers = providers
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cfg = cfg
+	r.transports = make(map[string]http.RoundTripper)
+	return nil
+}
+
+func validate(field, raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("%s: unsupported proxy scheme %q (want http, https or socks5)", field, u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%s: proxy URL %q has no host", field, raw)
+	}
+	return nil
+}
+
+// transport returns the round tripper for service: http.DefaultTransport
+// when nothing is configured, so the environment and local-only mode apply
+// as usual, otherwise a transport with the configured proxy and TLS
+// settings.
+func (r *Registry) transport(service string) http.RoundTripper {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	proxyURL, ok := r.cfg.Providers[service]
+	if !ok {
+		proxyURL = r.cfg.URL
+	}
+	tlsConfig := r.tlsFor(service)
+	if proxyURL == "" && r.cfg.NoProxy == "" && tlsConfig == nil {
+		return http.DefaultTransport
+	}
+	if t, ok := r.transports[service]; ok {
+		return t
+	}
+
+	t := baseTransport.Clone()
+	if tlsConfig != nil {
+		t.TLSClientConfig = tlsConfig.Clone()
+	}
+	switch {
+	case proxyURL == "" && r.cfg.NoProxy == "":
+		// Keep the environment's proxies.
+	case proxyURL == Direct:
+		t.Proxy = nil
+	default:
+		env := httpproxy.FromEnvironment()
+		if proxyURL != "" {
+			env.HTTPProxy, env.HTTPSProxy = proxyURL, proxyURL
+		}
+		if r.cfg.NoProxy != "" {
+			env.NoProxy = r.cfg.NoProxy
+		}
+		proxyFunc := env.ProxyFunc()
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+	rt := localonly.Wrap(t)
+	r.transports[service] = rt
+	return rt
+}
+
+// baseTransport is a copy of the standard transport made before local-only
+// mode can replace http.DefaultTransport.
+var baseTransport = func() *http.Transport {
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		return t.Clone()
+	}
+	return &http.Transport{Proxy: http.ProxyFromEnvironment}
+}()
+
+// serviceTransport looks up the service's transport on each request, so
+// clients created before Configure still pick up the configuration.
+type serviceTransport struct {
+	registry *Registry
+	service  string
+}
+
+func (t *serviceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.registry.transport(t.service).RoundTrip(req)
+}
+
+// Configure applies cfg to the default registry.
+func Configure(cfg Config) error {
+	return Default.Configure(cfg)
+}
+
+// Transport returns a round tripper for requests to service (a provider
+// name such as "claude" or "github") that honors the proxy configuration.
+func Transport(service string) http.RoundTripper {
+	return &serviceTransport{registry: Default, service: strings.ToLower(service)}
+}
+
+// Client returns an HTTP client for service with the given timeout (0 means
+// none).
+func Client(service string, timeout time.Duration) *http.Client {
+	return &http.Client{Transport: Transport(service), Timeout: timeout}
+}
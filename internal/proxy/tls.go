@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// TLSSettings customizes certificate verification and client
+// authentication, e.g. for an internal LLM gateway with a private CA.
+type TLSSettings struct {
+	CAFile   string `json:"ca_file,omitempty"`   // PEM bundle trusted in addition to the system roots
+	CertFile string `json:"cert_file,omitempty"` // client certificate (PEM) for mutual TLS
+	KeyFile  string `json:"key_file,omitempty"`  // client certificate's private key (PEM)
+	// InsecureSkipVerify disables certificate verification. Only for
+	// testing; a warning is logged whenever it is used.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+func (s TLSSettings) empty() bool {
+	return s == TLSSettings{}
+}
+
+// TLSConfig sets TLS settings for every service, or per service.
+type TLSConfig struct {
+	TLSSettings
+	// Providers replaces the settings for individual services (claude,
+	// openai, ollama, github, ...).
+	Providers map[string]TLSSettings `json:"providers,omitempty"`
+}
+
+// build loads the certificates s refers to.
+func (s TLSSettings) build(field string) (*tls.Config, error) {
+	if s.empty() {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if s.CAFile != "" {
+		pem, err := os.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s.ca_file: %w", field, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s.ca_file: no PEM certificates in %s", field, s.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if (s.CertFile == "") != (s.KeyFile == "") {
+		return nil, fmt.Errorf("%s: cert_file and key_file must be set together", field)
+	}
+	if s.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: load client certificate: %w", field, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	cfg.InsecureSkipVerify = s.InsecureSkipVerify
+	return cfg, nil
+}
+
+// ConfigureTLS loads the certificates in cfg and applies them to requests
+// made from then on.
+func (r *Registry) ConfigureTLS(cfg TLSConfig) error {
+	global, err := cfg.TLSSettings.build("tls")
+	if err != nil {
+		return err
+	}
+	providers := make(map[string]*tls.Config, len(cfg.Providers))
+	var insecure []string
+	for service, settings := range cfg.Providers {
+		service = strings.ToLower(service)
+		if providers[service], err = settings.build("tls.providers." + service); err != nil {
+			return err
+		}
+		if settings.InsecureSkipVerify {
+			insecure = append(insecure, service)
+		}
+	}
+
+	if cfg.InsecureSkipVerify {
+		log.Printf("WARNING: TLS certificate verification is disabled for all services (tls.insecure_skip_verify); connections can be intercepted")
+	}
+	if len(insecure) > 0 {
+		sort.Strings(insecure)
+		log.Printf("WARNING: TLS certificate verification is disabled for %s; connections can be intercepted", strings.Join(insecure, ", "))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tls = global
+	r.tlsProviders = providers
+	r.transports = make(map[string]http.RoundTripper)
+	return nil
+}
+
+// tlsFor returns the TLS configuration for service, or nil for the default.
+// Callers hold r.mu.
+func (r *Registry) tlsFor(service string) *tls.Config {
+	if cfg, ok := r.tlsProviders[service]; ok {
+		return cfg
+	}
+	return r.tls
+}
+
+// ConfigureTLS applies cfg to the default registry.
+func ConfigureTLS(cfg TLSConfig) error {
+	return Default.ConfigureTLS(cfg)
+}
@@ -0,0 +1,177 @@
+package rag
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters: k1 dampens repeated terms, b normalises for chunk
+// length. These are the usual defaults, also SQLite FTS5's.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// keywordTerms splits text into the lowercase terms keyword search
+// matches. Identifiers are kept whole, so an exact name scores highest,
+// and also split at underscores and case changes, so "parseHTTPHeader"
+// is found by "http header" too. One-letter terms are dropped.
+func keywordTerms(text string) []string {
+	var terms []string
+	word := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+	for _, ident := range strings.FieldsFunc(text, func(r rune) bool { return !word(r) }) {
+		ident = strings.Trim(ident, "_")
+		if len([]rune(ident)) < 2 {
+			continue
+		}
+		terms = append(terms, strings.ToLower(ident))
+		parts := identifierParts(ident)
+		if len(parts) < 2 {
+			continue
+		}
+		for _, p := range parts {
+			if len([]rune(p)) >= 2 {
+				terms = append(terms, strings.ToLower(p))
+			}
+		}
+	}
+	return terms
+}
+
+// identifierParts splits an identifier at underscores and case changes:
+// "parseHTTPHeader" gives parse, HTTP, Header. Digits stay with the
+// letters before them.
+func identifierParts(ident string) []string {
+	var parts []string
+	for _, piece := range strings.Split(ident, "_") {
+		runes := []rune(piece)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			next := rune(0)
+			if i+1 < len(runes) {
+				next = runes[i+1]
+			}
+			if unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				unicode.IsUpper(prev) && unicode.IsLower(next)) {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+	return parts
+}
+
+// keywordText is the text of a chunk that keyword search indexes.
+func keywordText(chunk *Chunk) string {
+	return chunk.SymbolName + "\n" + chunk.Content
+}
+
+// bm25Index is an inverted index over chunks scored with BM25, for stores
+// that keep everything in memory.
+type bm25Index struct {
+	postings map[string]map[string]int // term -> chunk ID -> occurrences
+	lengths  map[string]int            // chunk ID -> number of terms
+	terms    map[string][]string       // chunk ID -> distinct terms, for removal
+	total    int                       // sum of lengths
+}
+
+func newBM25Index() *bm25Index {
+	return &bm25Index{
+		postings: make(map[string]map[string]int),
+		lengths:  make(map[string]int),
+		terms:    make(map[string][]string),
+	}
+}
+
+// add indexes text as the chunk id, replacing what was indexed for it.
+func (x *bm25Index) add(id, text string) {
+	x.remove(id)
+	terms := keywordTerms(text)
+	var distinct []string
+	for _, t := range terms {
+		docs := x.postings[t]
+		if docs == nil {
+			docs = make(map[string]int)
+			x.postings[t] = docs
+		}
+		if docs[id] == 0 {
+			distinct = append(distinct, t)
+		}
+		docs[id]++
+	}
+	x.lengths[id] = len(terms)
+	x.terms[id] = distinct
+	x.total += len(terms)
+}
+
+// remove drops the chunk id from the index.
+func (x *bm25Index) remove(id string) {
+	n, ok := x.lengths[id]
+	if !ok {
+		return
+	}
+	for _, t := range x.terms[id] {
+		docs := x.postings[t]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(x.postings, t)
+		}
+	}
+	delete(x.lengths, id)
+	delete(x.terms, id)
+	x.total -= n
+}
+
+// scoredID is a chunk ID and its BM25 score.
+type scoredID struct {
+	id    string
+	score float64
+}
+
+// search returns the topK chunks scoring highest for query, best first.
+func (x *bm25Index) search(query string, topK int) []scoredID {
+	if len(x.lengths) == 0 {
+		return nil
+	}
+	n := float64(len(x.lengths))
+	avgLen := float64(x.total) / n
+	scores := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, t := range keywordTerms(query) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		docs := x.postings[t]
+		if len(docs) == 0 {
+			continue
+		}
+		df := float64(len(docs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range docs {
+			f := float64(tf)
+			norm := 1 - bm25B + bm25B*float64(x.lengths[id])/avgLen
+			scores[id] += idf * f * (bm25K1 + 1) / (f + bm25K1*norm)
+		}
+	}
+	results := make([]scoredID, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, scoredID{id, score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].id < results[j].id
+	})
+	if topK > 0 && topK < len(results) {
+		results = results[:topK]
+	}
+	return results
+}
@@ -7,6 +7,9 @@ import (
 	"go/token"
 	"path/filepath"
 	"strings"
+
+	"github.com/yourorg/agent/internal/docs"
+	"github.com/yourorg/agent/internal/sqlschema"
 )
 
 // Chunker splits code into searchable chunks
@@ -15,11 +18,55 @@ type Chunker interface {
 	Language() string
 }
 
+// ChunkerConfig sizes chunks. Raise MaxChunkSize for embedding models with
+// larger context windows, or lower it for small local models; a character
+// is roughly a quarter of a token. Zero fields take their defaults.
+type ChunkerConfig struct {
+	MaxChunkSize  int `json:"max_chunk_size,omitempty"` // characters above which chunks are split (default 4000)
+	WindowLines   int `json:"window_lines,omitempty"`   // lines per chunk of files chunked by sliding window (default 50)
+	WindowOverlap int `json:"window_overlap,omitempty"` // lines shared by consecutive windows and split parts (default 10)
+}
+
+// DefaultChunkerConfig returns the sizes used when none are configured.
+func DefaultChunkerConfig() ChunkerConfig {
+	return ChunkerConfig{
+		MaxChunkSize:  4000,
+		WindowLines:   50,
+		WindowOverlap: 10,
+	}
+}
+
+// minChunkSize is the smallest MaxChunkSize accepted, a few lines of code.
+const minChunkSize = 200
+
+// withDefaults fills the unset fields of cfg and checks the result.
+func (cfg ChunkerConfig) withDefaults() (ChunkerConfig, error) {
+	defaults := DefaultChunkerConfig()
+	if cfg.MaxChunkSize <= 0 {
+		cfg.MaxChunkSize = defaults.MaxChunkSize
+	}
+	if cfg.WindowLines <= 0 {
+		cfg.WindowLines = defaults.WindowLines
+	}
+	if cfg.WindowOverlap <= 0 {
+		cfg.WindowOverlap = min(defaults.WindowOverlap, cfg.WindowLines/5)
+	}
+	if cfg.MaxChunkSize < minChunkSize {
+		return cfg, fmt.Errorf("max chunk size %d is below %d characters", cfg.MaxChunkSize, minChunkSize)
+	}
+	if cfg.WindowOverlap >= cfg.WindowLines {
+		return cfg, fmt.Errorf("window overlap of %d lines must be less than the window's %d", cfg.WindowOverlap, cfg.WindowLines)
+	}
+	return cfg, nil
+}
+
 // GoChunker implements AST-based chunking for Go files
-type GoChunker struct{}
+type GoChunker struct {
+	cfg ChunkerConfig
+}
 
-func NewGoChunker() *GoChunker {
-	return &GoChunker{}
+func NewGoChunker(cfg ChunkerConfig) *GoChunker {
+	return &GoChunker{cfg: cfg}
 }
 
 func (c *GoChunker) Language() string {
@@ -31,7 +78,7 @@ func (c *GoChunker) ChunkFile(filePath string, content string) ([]*Chunk, error)
 	node, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
 	if err != nil {
 		// Fallback to generic chunking if parsing fails
-		return c.genericChunk(filePath, content), nil
+		return c.cfg.genericSlidingChunks(filePath, content, "go"), nil
 	}
 
 	var chunks []*Chunk
@@ -58,7 +105,7 @@ func (c *GoChunker) ChunkFile(filePath string, content string) ([]*Chunk, error)
 				}
 			}
 
-			subChunks := splitLargeChunk(filePath, funcContent, chunkType, symbolName, "go", start, end)
+			subChunks := c.cfg.splitLargeChunk(filePath, funcContent, chunkType, symbolName, "go", start, end)
 			chunks = append(chunks, subChunks...)
 
 		case *ast.GenDecl:
@@ -78,7 +125,7 @@ func (c *GoChunker) ChunkFile(filePath string, content string) ([]*Chunk, error)
 							chunkType = "interface"
 						}
 
-						subChunks := splitLargeChunk(filePath, typeContent, chunkType, typeSpec.Name.Name, "go", start, end)
+						subChunks := c.cfg.splitLargeChunk(filePath, typeContent, chunkType, typeSpec.Name.Name, "go", start, end)
 						chunks = append(chunks, subChunks...)
 					}
 				}
@@ -89,53 +136,20 @@ func (c *GoChunker) ChunkFile(filePath string, content string) ([]*Chunk, error)
 
 	// If no chunks were extracted, fallback to generic
 	if len(chunks) == 0 {
-		return c.genericChunk(filePath, content), nil
+		return c.cfg.genericSlidingChunks(filePath, content, "go"), nil
 	}
 
 	return chunks, nil
 }
 
-// genericChunk uses sliding window for files that can't be parsed
-func (c *GoChunker) genericChunk(filePath string, content string) []*Chunk {
-	lines := strings.Split(content, "\n")
-	var chunks []*Chunk
-
-	// Sliding window: 50 lines per chunk, 10 line overlap
-	chunkSize := 50
-	overlap := 10
-	stride := chunkSize - overlap
-
-	for i := 0; i < len(lines); i += stride {
-		end := i + chunkSize
-		if end > len(lines) {
-			end = len(lines)
-		}
-
-		chunkLines := lines[i:end]
-		chunkContent := strings.Join(chunkLines, "\n")
-
-		if len(strings.TrimSpace(chunkContent)) < 50 {
-			// Skip very small chunks
-			continue
-		}
-
-		chunk := NewChunk(filePath, chunkContent, "block", "", "go", i+1, end)
-		chunks = append(chunks, chunk)
-
-		if end >= len(lines) {
-			break
-		}
-	}
-
-	return chunks
-}
-
 // PythonChunker implements lightweight chunking for Python files using indentation blocks.
 // It captures decorators with their def/class and handles nested defs by indentation.
-type PythonChunker struct{}
+type PythonChunker struct {
+	cfg ChunkerConfig
+}
 
-func NewPythonChunker() *PythonChunker {
-	return &PythonChunker{}
+func NewPythonChunker(cfg ChunkerConfig) *PythonChunker {
+	return &PythonChunker{cfg: cfg}
 }
 
 func (c *PythonChunker) Language() string {
@@ -206,25 +220,199 @@ func (c *PythonChunker) ChunkFile(filePath string, content string) ([]*Chunk, er
 		}
 
 		// Split large chunks to avoid exceeding embedding model context limit
-		subChunks := splitLargeChunk(filePath, chunkContent, chunkType, symbolName, "python", startIdx+1, endIdx)
+		subChunks := c.cfg.splitLargeChunk(filePath, chunkContent, chunkType, symbolName, "python", startIdx+1, endIdx)
 		chunks = append(chunks, subChunks...)
 		i = endIdx - 1 // continue after this block
 	}
 
 	if len(chunks) == 0 {
-		return genericSlidingChunks(filePath, content, "python"), nil
+		return c.cfg.genericSlidingChunks(filePath, content, "python"), nil
+	}
+	return chunks, nil
+}
+
+// MarkdownChunker splits documentation into one chunk per heading section,
+// so answers can cite the section a fact came from. Chunks are named after
+// their heading trail ("Install > Linux"). Sections too large to embed, and
+// plain text files, which have no headings, are split between paragraphs.
+type MarkdownChunker struct {
+	cfg ChunkerConfig
+}
+
+func NewMarkdownChunker(cfg ChunkerConfig) *MarkdownChunker {
+	return &MarkdownChunker{cfg: cfg}
+}
+
+func (c *MarkdownChunker) Language() string {
+	return "markdown"
+}
+
+func (c *MarkdownChunker) ChunkFile(filePath string, content string) ([]*Chunk, error) {
+	if strings.EqualFold(filepath.Ext(filePath), ".txt") {
+		name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+		return c.cfg.splitParagraphs(filePath, content, "paragraph", name, "text", 1), nil
+	}
+	var chunks []*Chunk
+	for _, s := range docs.Parse(filePath, content) {
+		if len(strings.TrimSpace(s.Content)) < 20 {
+			continue
+		}
+		chunks = append(chunks, c.cfg.splitParagraphs(filePath, s.Content, "section", s.Name(), "markdown", s.Line)...)
 	}
 	return chunks, nil
 }
 
-// splitLargeChunk splits oversized chunks into smaller pieces
-// Max chunk size is ~4000 characters (roughly 1000 tokens) to stay well below embedding model limits
-func splitLargeChunk(filePath, content, chunkType, symbolName, language string, start, end int) []*Chunk {
-	const maxChunkSize = 4000
-	const overlapLines = 10
+// splitParagraphs returns content starting at line start as one chunk, or
+// if it is larger than cfg.MaxChunkSize, as chunks of whole paragraphs. Blank
+// lines inside fenced code blocks do not end a paragraph, and paragraphs
+// too large on their own are split by splitLargeChunk.
+func (cfg ChunkerConfig) splitParagraphs(filePath, content, chunkType, name, language string, start int) []*Chunk {
+	lines := strings.Split(content, "\n")
+	if len(content) <= cfg.MaxChunkSize {
+		if len(strings.TrimSpace(content)) < 20 {
+			return nil
+		}
+		return []*Chunk{NewChunk(filePath, content, chunkType, name, language, start, start+len(lines)-1)}
+	}
+
+	// Paragraphs as [first, end) line indexes.
+	var paras [][2]int
+	first, fence := -1, ""
+	for i, line := range lines {
+		t := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(t, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+			fence = t[:3]
+			if first < 0 {
+				first = i
+			}
+			continue
+		}
+		if t == "" {
+			if first >= 0 {
+				paras = append(paras, [2]int{first, i})
+				first = -1
+			}
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	if first >= 0 {
+		paras = append(paras, [2]int{first, len(lines)})
+	}
+
+	var chunks []*Chunk
+	part := 1
+	emit := func(from, to int) {
+		text := strings.Join(lines[from:to], "\n")
+		if len(strings.TrimSpace(text)) < 20 {
+			return
+		}
+		partName := fmt.Sprintf("%s_part%d", name, part)
+		if len(text) > cfg.MaxChunkSize {
+			for _, c := range cfg.splitLargeChunk(filePath, text, chunkType, name, language, start+from, start+to-1) {
+				c.SymbolName = fmt.Sprintf("%s_part%d", name, part)
+				chunks = append(chunks, c)
+				part++
+			}
+			return
+		}
+		chunks = append(chunks, NewChunk(filePath, text, chunkType, partName, language, start+from, start+to-1))
+		part++
+	}
+	from, to, size := -1, 0, 0
+	for _, p := range paras {
+		n := len(strings.Join(lines[p[0]:p[1]], "\n"))
+		if from >= 0 && size+n > cfg.MaxChunkSize {
+			emit(from, to)
+			from, size = -1, 0
+		}
+		if from < 0 {
+			from = p[0]
+		}
+		to, size = p[1], size+n+2
+	}
+	if from >= 0 {
+		emit(from, to)
+	}
+	return chunks
+}
+
+// SQLChunker gives each schema statement of a SQL file or migration its
+// own chunk, named after what it does ("0007 add column patients.email"),
+// and groups the statements in between (inserts, grants).
+type SQLChunker struct {
+	cfg ChunkerConfig
+}
+
+func NewSQLChunker(cfg ChunkerConfig) *SQLChunker {
+	return &SQLChunker{cfg: cfg}
+}
+
+func (c *SQLChunker) Language() string {
+	return "sql"
+}
+
+func (c *SQLChunker) ChunkFile(filePath string, content string) ([]*Chunk, error) {
+	lines := strings.Split(content, "\n")
+	var chunks []*Chunk
+	add := func(start, end int, chunkType, name string) {
+		section := strings.Join(lines[start-1:min(end, len(lines))], "\n")
+		if strings.TrimSpace(section) != "" {
+			chunks = append(chunks, c.cfg.splitLargeChunk(filePath, section, chunkType, name, "sql", start, end)...)
+		}
+	}
+
+	next := 1  // first line not chunked yet; comments go with the statement after them
+	other := 0 // first line of the pending non-schema statements
+	for _, stmt := range sqlschema.Statements(filePath, content) {
+		if len(stmt.Objects) == 0 {
+			if other == 0 {
+				other = next
+			}
+			next = stmt.EndLine + 1
+			continue
+		}
+		start := next
+		if other > 0 {
+			add(other, stmt.Line-1, "block", "")
+			start, other = stmt.Line, 0
+		}
+		o := stmt.Objects[0]
+		if o.Op == "alter" && len(stmt.Objects) > 1 {
+			o = stmt.Objects[1] // the first action of ALTER TABLE
+		}
+		target := o.Name
+		if o.Table != "" {
+			target = o.Table + "." + o.Name
+		}
+		name := strings.TrimSpace(o.Migration + " " + o.Op + " " + o.Kind + " " + target)
+		add(start, stmt.EndLine, "statement", name)
+		next = stmt.EndLine + 1
+	}
+	if other > 0 {
+		add(other, len(lines), "block", "")
+	}
+	if len(chunks) == 0 {
+		return c.cfg.genericSlidingChunks(filePath, content, "sql"), nil
+	}
+	return chunks, nil
+}
 
+// splitLargeChunk splits chunks larger than cfg.MaxChunkSize, by default
+// roughly 1000 tokens to stay well below embedding model limits, into
+// overlapping pieces.
+func (cfg ChunkerConfig) splitLargeChunk(filePath, content, chunkType, symbolName, language string, start, end int) []*Chunk {
 	// If chunk is small enough, return as-is
-	if len(content) <= maxChunkSize {
+	if len(content) <= cfg.MaxChunkSize {
 		return []*Chunk{NewChunk(filePath, content, chunkType, symbolName, language, start, end)}
 	}
 
@@ -232,11 +420,9 @@ func splitLargeChunk(filePath, content, chunkType, symbolName, language string,
 	lines := strings.Split(content, "\n")
 	var chunks []*Chunk
 
-	// Calculate lines per chunk (~100 lines assuming ~40 chars per line)
-	linesPerChunk := maxChunkSize / 40
-	if linesPerChunk > 100 {
-		linesPerChunk = 100
-	}
+	// Calculate lines per chunk (~100 lines by default, assuming ~40 chars per line)
+	linesPerChunk := cfg.MaxChunkSize / 40
+	overlapLines := min(cfg.WindowOverlap, linesPerChunk/2)
 
 	partNum := 1
 	for i := 0; i < len(lines); i += (linesPerChunk - overlapLines) {
@@ -282,13 +468,14 @@ func leadingIndent(s string) int {
 	return len(s) - len(strings.TrimLeft(s, " \t"))
 }
 
-func genericSlidingChunks(filePath, content, lang string) []*Chunk {
+// genericSlidingChunks chunks content into windows of cfg.WindowLines
+// lines, for files that cannot be parsed.
+func (cfg ChunkerConfig) genericSlidingChunks(filePath, content, lang string) []*Chunk {
 	lines := strings.Split(content, "\n")
 	var chunks []*Chunk
 
-	chunkSize := 50
-	overlap := 10
-	stride := chunkSize - overlap
+	chunkSize := cfg.WindowLines
+	stride := chunkSize - cfg.WindowOverlap
 
 	for i := 0; i < len(lines); i += stride {
 		end := i + chunkSize
@@ -342,15 +529,43 @@ func exprToString(expr ast.Expr) string {
 }
 
 // ChunkerFactory creates appropriate chunker based on file extension
-func ChunkerFactory(filePath string) Chunker {
+func ChunkerFactory(filePath string, cfg ChunkerConfig) Chunker {
 	ext := filepath.Ext(filePath)
 	switch ext {
 	case ".go":
-		return NewGoChunker()
+		return NewGoChunker(cfg)
 	case ".py":
-		return NewPythonChunker()
-	default:
-		// TODO: Add JS/TS chunkers
-		return NewGoChunker() // Fallback for now
+		return NewPythonChunker(cfg)
+	case ".md", ".markdown", ".rst", ".adoc", ".txt":
+		return NewMarkdownChunker(cfg)
+	case ".sql":
+		return NewSQLChunker(cfg)
+	case ".js", ".jsx", ".mjs", ".cjs":
+		return NewJSChunker("javascript", cfg)
+	case ".ts", ".tsx", ".mts", ".cts":
+		return NewJSChunker("typescript", cfg)
 	}
+	if c := NewTreeSitterChunker(filePath, cfg); c != nil {
+		return c
+	}
+	return NewSlidingWindowChunker(strings.TrimPrefix(strings.ToLower(ext), "."), cfg)
+}
+
+// SlidingWindowChunker chunks files no other chunker understands into
+// overlapping windows of lines.
+type SlidingWindowChunker struct {
+	language string
+	cfg      ChunkerConfig
+}
+
+func NewSlidingWindowChunker(language string, cfg ChunkerConfig) *SlidingWindowChunker {
+	return &SlidingWindowChunker{language: language, cfg: cfg}
+}
+
+func (c *SlidingWindowChunker) Language() string {
+	return c.language
+}
+
+func (c *SlidingWindowChunker) ChunkFile(filePath string, content string) ([]*Chunk, error) {
+	return c.cfg.genericSlidingChunks(filePath, content, c.language), nil
 }
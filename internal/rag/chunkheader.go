@@ -0,0 +1,190 @@
+package rag
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxHeaderImports bounds the imports listed in a chunk header.
+const maxHeaderImports = 12
+
+// fileContext is what a file says about where its chunks belong: the
+// package or namespace it declares and what it imports.
+type fileContext struct {
+	pkg     string
+	imports []string
+}
+
+// importPatterns find a language's imports; the first group is the
+// imported module.
+var importPatterns = map[string][]*regexp.Regexp{
+	"python": {
+		regexp.MustCompile(`(?m)^\s*import\s+([\w.]+)`),
+		regexp.MustCompile(`(?m)^\s*from\s+([\w.]+)\s+import\b`),
+	},
+	"javascript": jsImportPatterns,
+	"typescript": jsImportPatterns,
+	"java":       jvmImportPatterns,
+	"kotlin":     jvmImportPatterns,
+	"scala":      jvmImportPatterns,
+	"csharp":     {regexp.MustCompile(`(?m)^\s*(?:global\s+)?using\s+(?:static\s+)?([\w.]+)\s*;`)},
+	"php":        {regexp.MustCompile(`(?m)^\s*use\s+(?:function\s+|const\s+)?\\?([\w\\]+)`)},
+	"rust":       {regexp.MustCompile(`(?m)^\s*(?:pub(?:\([^)]*\))?\s+)?use\s+([\w:]+?)(?:::)?[\s;{*]`)},
+	"c":          cIncludePatterns,
+	"cpp":        cIncludePatterns,
+	"ruby":       {regexp.MustCompile(`(?m)^\s*require(?:_relative)?\s*\(?\s*['"]([^'"]+)['"]`)},
+	"swift":      {regexp.MustCompile(`(?m)^\s*(?:@\w+\s+)*import\s+(?:(?:class|struct|enum|protocol|func|typealias)\s+)?([\w.]+)`)},
+}
+
+var (
+	jsImportPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^\s*(?:import|export)\b[^'";]*?['"]([^'"]+)['"]`),
+		regexp.MustCompile(`\brequire\(\s*['"]([^'"]+)['"]\s*\)`),
+	}
+	jvmImportPatterns = []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*import\s+(?:static\s+)?([\w.]+)`)}
+	cIncludePatterns  = []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*#\s*include\s*[<"]([^>"]+)[>"]`)}
+)
+
+// packagePatterns find the package or namespace a file declares.
+var packagePatterns = map[string]*regexp.Regexp{
+	"java":   regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)`),
+	"kotlin": regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)`),
+	"scala":  regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)`),
+	"csharp": regexp.MustCompile(`(?m)^\s*namespace\s+([\w.]+)`),
+	"php":    regexp.MustCompile(`(?m)^\s*namespace\s+([\w\\]+)`),
+	"cpp":    regexp.MustCompile(`(?m)^\s*namespace\s+([\w:]+)\s*\{`),
+}
+
+// parseFileContext extracts the package and imports of a file written in
+// language, as labelled by its chunker.
+func parseFileContext(language, content string) fileContext {
+	var fc fileContext
+	if language == "go" {
+		f, err := parser.ParseFile(token.NewFileSet(), "", content, parser.ImportsOnly)
+		if err != nil {
+			return fc
+		}
+		fc.pkg = f.Name.Name
+		// The standard library says least about a file, so it goes last.
+		var std []string
+		for _, imp := range f.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			if strings.Contains(strings.Split(path, "/")[0], ".") {
+				fc.imports = append(fc.imports, path)
+			} else {
+				std = append(std, path)
+			}
+		}
+		fc.imports = append(fc.imports, std...)
+		return fc
+	}
+	if re := packagePatterns[language]; re != nil {
+		if m := re.FindStringSubmatch(content); m != nil {
+			fc.pkg = m[1]
+		}
+	}
+	seen := make(map[string]bool)
+	for _, re := range importPatterns[language] {
+		for _, m := range re.FindAllStringSubmatch(content, -1) {
+			imp := strings.TrimRight(m[1], ".") // a Java wildcard import
+			if !seen[imp] {
+				seen[imp] = true
+				fc.imports = append(fc.imports, imp)
+			}
+		}
+	}
+	return fc
+}
+
+var partSuffix = regexp.MustCompile(`_part(\d+)$`)
+
+// chunkHeader describes where a chunk of the file at relPath sits, so its
+// vector carries the location as well as the code: a query for "the
+// payment handler in the billing service" matches billing/handler.go even
+// when the code never says "billing".
+func chunkHeader(relPath string, chunk *Chunk, fc fileContext) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "File: %s\n", filepath.ToSlash(relPath))
+	if fc.pkg != "" {
+		fmt.Fprintf(&b, "Package: %s\n", fc.pkg)
+	}
+	if name := chunk.SymbolName; name != "" {
+		part := ""
+		if m := partSuffix.FindStringSubmatchIndex(name); m != nil {
+			part = " (part " + name[m[2]:m[3]] + ")"
+			name = name[:m[0]]
+		}
+		switch {
+		case chunk.ChunkType == "section":
+			fmt.Fprintf(&b, "Section: %s%s\n", name, part)
+		case name == "":
+		case chunk.ChunkType == "method" && strings.Contains(name, "."):
+			i := strings.LastIndex(name, ".")
+			fmt.Fprintf(&b, "Symbol: method %s of %s%s\n", name[i+1:], strings.TrimPrefix(name[:i], "*"), part)
+		default:
+			fmt.Fprintf(&b, "Symbol: %s %s%s\n", chunk.ChunkType, name, part)
+		}
+	}
+	if len(fc.imports) > 0 {
+		imports := fc.imports
+		more := ""
+		if len(imports) > maxHeaderImports {
+			more = fmt.Sprintf(" and %d more", len(imports)-maxHeaderImports)
+			imports = imports[:maxHeaderImports]
+		}
+		fmt.Fprintf(&b, "Imports: %s%s\n", strings.Join(imports, ", "), more)
+	}
+	return b.String()
+}
+
+// embeddingText is the text embedded for a chunk: its header, if any,
+// followed by its content.
+func embeddingText(chunk *Chunk) string {
+	if chunk.Header == "" {
+		return chunk.Content
+	}
+	return chunk.Header + "\n" + chunk.Content
+}
+
+// relPath returns path relative to the project root, or path itself when
+// the root is unknown or does not contain it.
+func (r *RAGIndexer) relPath(path string) string {
+	if r.root == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(r.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+// addHeaders gives chunks read back from a store, which do not keep their
+// headers, new ones, reading each file once for its package and imports.
+// Files that no longer exist get headers without them.
+func (r *RAGIndexer) addHeaders(chunks []*Chunk) {
+	contexts := make(map[string]fileContext)
+	for _, chunk := range chunks {
+		fc, ok := contexts[chunk.FilePath]
+		if !ok {
+			path := chunk.FilePath
+			if !filepath.IsAbs(path) && r.root != "" {
+				path = filepath.Join(r.root, path)
+			}
+			if content, err := os.ReadFile(path); err == nil {
+				fc = parseFileContext(chunk.Language, string(content))
+			}
+			contexts[chunk.FilePath] = fc
+		}
+		chunk.Header = chunkHeader(r.relPath(chunk.FilePath), chunk, fc)
+	}
+}
@@ -2,11 +2,22 @@ package rag
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+
+	"github.com/yourorg/agent/internal/proxy"
+	"github.com/yourorg/agent/internal/ratelimit"
 )
 
 // Embedder generates vector embeddings for text
@@ -21,8 +32,19 @@ type Embedder interface {
 type OllamaEmbedder struct {
 	baseURL    string
 	model      string
-	dimensions int
+	dimensions int // 0 until probed, unless configured
+	probe      sync.Once
+	probeErr   error // why the probe failed; dimensions is then a guess
 	httpClient *http.Client
+	legacy     atomic.Bool // the server only has /api/embeddings
+}
+
+// ollamaDimensions lists the embedding size of common Ollama models, used
+// when the server cannot be reached to probe the model.
+var ollamaDimensions = map[string]int{
+	"nomic-embed-text":  768,
+	"mxbai-embed-large": 1024,
+	"all-minilm":        384,
 }
 
 type ollamaEmbedRequest struct {
@@ -34,89 +56,171 @@ type ollamaEmbedResponse struct {
 	Embedding []float32 `json:"embedding"`
 }
 
-// NewOllamaEmbedder creates a new Ollama embedder
+type ollamaBatchRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaBatchResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// NewOllamaEmbedder creates a new Ollama embedder. The model's dimensions
+// are found by embedding a probe text on first use, so any model works.
 func NewOllamaEmbedder(model string) *OllamaEmbedder {
 	if model == "" {
 		model = "nomic-embed-text" // Default model
 	}
 
-	dimensions := 768 // nomic-embed-text dimensions
-	if model == "mxbai-embed-large" {
-		dimensions = 1024
-	}
-
 	return &OllamaEmbedder{
 		baseURL:    "http://localhost:11434",
 		model:      model,
-		dimensions: dimensions,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: proxy.Client("ollama", 30*time.Second),
 	}
 }
 
+// Embed embeds a single text.
 func (e *OllamaEmbedder) Embed(text string) ([]float32, error) {
-	reqBody := ollamaEmbedRequest{
-		Model:  e.model,
-		Prompt: text,
+	embeddings, err := e.EmbedBatch([]string{text})
+	if err != nil {
+		return nil, err
 	}
+	return embeddings[0], nil
+}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+// EmbedBatch embeds texts in one /api/embed request. Ollama servers older
+// than 0.3.4 lack that endpoint; for those it falls back to one
+// /api/embeddings request per text.
+func (e *OllamaEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	if !e.legacy.Load() {
+		embeddings, err := e.embed(texts)
+		if !errors.Is(err, errNoBatchEndpoint) {
+			return embeddings, err
+		}
+		e.legacy.Store(true)
 	}
 
-	resp, err := e.httpClient.Post(
-		e.baseURL+"/api/embeddings",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("ollama request failed: %w", err)
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := e.embedOne(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed text %d: %w", i, err)
+		}
+		embeddings[i] = embedding
 	}
-	defer resp.Body.Close()
+	return embeddings, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
+// errNoBatchEndpoint reports an Ollama server without /api/embed.
+var errNoBatchEndpoint = errors.New("ollama has no /api/embed endpoint")
+
+func (e *OllamaEmbedder) embed(texts []string) ([][]float32, error) {
+	var result ollamaBatchResponse
+	status, err := e.post("/api/embed", ollamaBatchRequest{Model: e.model, Input: texts}, &result)
+	if status == http.StatusNotFound && err != nil && !strings.Contains(err.Error(), "model") {
+		return nil, errNoBatchEndpoint
 	}
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d texts", len(result.Embeddings), len(texts))
+	}
+	for i, embedding := range result.Embeddings {
+		if len(embedding) == 0 {
+			return nil, fmt.Errorf("empty embedding returned for text %d", i)
+		}
+	}
+	return result.Embeddings, nil
+}
 
+func (e *OllamaEmbedder) embedOne(text string) ([]float32, error) {
 	var result ollamaEmbedResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if _, err := e.post("/api/embeddings", ollamaEmbedRequest{Model: e.model, Prompt: text}, &result); err != nil {
+		return nil, err
 	}
-
 	if len(result.Embedding) == 0 {
 		return nil, fmt.Errorf("empty embedding returned")
 	}
-
 	return result.Embedding, nil
 }
 
-func (e *OllamaEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
-	embeddings := make([][]float32, len(texts))
+// post sends a JSON request to the Ollama server and decodes its reply
+// into out, returning the HTTP status.
+func (e *OllamaEmbedder) post(path string, body, out any) (int, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
 
-	// TODO: Implement true batch processing if Ollama supports it
-	// For now, process sequentially
-	for i, text := range texts {
-		embedding, err := e.Embed(text)
-		if err != nil {
-			return nil, fmt.Errorf("failed to embed text %d: %w", i, err)
+	if err := ratelimit.Wait(context.Background(), "ollama"); err != nil {
+		return 0, fmt.Errorf("rate limit wait: %w", err)
+	}
+
+	resp, err := e.httpClient.Post(e.baseURL+path, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return 0, fmt.Errorf("ollama is not running at %s (start it, or set rag.embedder.provider to \"local\" to embed without it): %w", e.baseURL, err)
 		}
-		embeddings[i] = embedding
+		return 0, fmt.Errorf("ollama request failed: %w", err)
 	}
+	defer resp.Body.Close()
 
-	return embeddings, nil
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.StatusCode, nil
 }
 
+// Dimension returns the size of the model's embeddings, probing the model
+// with a test embed the first time unless it was configured. When Ollama
+// cannot be reached it falls back to the known size of common models, or
+// 0, which vector stores take as the size they recorded or of the first
+// vectors inserted; ProbeErr then says why.
 func (e *OllamaEmbedder) Dimension() int {
+	e.probe.Do(func() {
+		if e.dimensions > 0 {
+			return
+		}
+		embedding, err := e.Embed("dimension probe")
+		if err != nil {
+			e.probeErr = fmt.Errorf("probe %s embedding size: %w", e.model, err)
+			e.dimensions = ollamaDimensions[e.model]
+			return
+		}
+		e.dimensions = len(embedding)
+	})
 	return e.dimensions
 }
 
+// ProbeErr returns why Dimension could not probe the model, or nil.
+func (e *OllamaEmbedder) ProbeErr() error {
+	e.Dimension()
+	return e.probeErr
+}
+
 func (e *OllamaEmbedder) Model() string {
 	return e.model
 }
 
+// Remote reports whether the Ollama server is on another machine.
+func (e *OllamaEmbedder) Remote() bool {
+	u, err := url.Parse(e.baseURL)
+	if err != nil {
+		return true
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
+
 // MockEmbedder for testing (returns random embeddings)
 type MockEmbedder struct {
 	dimensions int
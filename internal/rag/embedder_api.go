@@ -0,0 +1,319 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourorg/agent/internal/batch"
+	"github.com/yourorg/agent/internal/proxy"
+	"github.com/yourorg/agent/internal/ratelimit"
+)
+
+// EmbedderConfig selects the embedding provider.
+type EmbedderConfig struct {
+	Provider string `json:"provider,omitempty"` // ollama (default), local, openai, voyage or cohere
+	Model    string `json:"model,omitempty"`
+	BaseURL  string `json:"base_url,omitempty"`
+	// Dimensions is required for models whose size is not known here.
+	// OpenAI's text-embedding-3, Voyage's code-3/3.5/3-large and Cohere's
+	// embed-v4.0 models return embeddings of this size instead of their
+	// default.
+	Dimensions int    `json:"dimensions,omitempty"`
+	APIKey     string `json:"-"` // OPENAI_API_KEY, VOYAGE_API_KEY or COHERE_API_KEY
+}
+
+// NewEmbedder creates the embedder for cfg.Provider.
+func NewEmbedder(cfg EmbedderConfig) (Embedder, error) {
+	switch cfg.Provider {
+	case "", "ollama":
+		e := NewOllamaEmbedder(cfg.Model)
+		if cfg.BaseURL != "" {
+			e.baseURL = cfg.BaseURL
+		}
+		if cfg.Dimensions > 0 {
+			e.dimensions = cfg.Dimensions
+		}
+		return e, nil
+	case "openai", "voyage":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("%s API key is required", cfg.Provider)
+		}
+		return NewAPIEmbedder(cfg)
+	case "local":
+		return NewLocalEmbedder(cfg)
+	case "cohere":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("%s API key is required", cfg.Provider)
+		}
+		return NewCohereEmbedder(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider %q (use ollama, local, openai, voyage or cohere)", cfg.Provider)
+	}
+}
+
+// BulkEmbedder is implemented by embedders whose provider has an
+// asynchronous batch API. Bulk embedding costs about half as much but can
+// take minutes to hours, so it is only used to build a full index.
+type BulkEmbedder interface {
+	Embedder
+	// EmbedBulk embeds texts through the batch API. Texts the batch did not
+	// return an embedding for are left nil. progress, if non-nil, is called
+	// after each poll.
+	EmbedBulk(ctx context.Context, texts []string, progress func(*batch.Status)) ([][]float32, error)
+}
+
+// bulkPollInterval is how often a bulk embedding batch is polled.
+const bulkPollInterval = 30 * time.Second
+
+// embeddingDimensions lists the output size of known hosted models.
+var embeddingDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+	"voyage-code-3":          1024,
+	"voyage-code-2":          1536,
+	"voyage-3.5":             1024,
+	"voyage-3.5-lite":        1024,
+	"voyage-3-large":         1024,
+	"voyage-3":               1024,
+	"voyage-3-lite":          512,
+}
+
+// voyageFlexible lists the Voyage models that return 256, 512, 1024 or 2048
+// dimensions on request.
+var voyageFlexible = map[string]bool{
+	"voyage-code-3":   true,
+	"voyage-3.5":      true,
+	"voyage-3.5-lite": true,
+	"voyage-3-large":  true,
+}
+
+// APIEmbedder implements Embedder and BulkEmbedder over the OpenAI and
+// Voyage AI embedding APIs, which share a request format.
+type APIEmbedder struct {
+	provider   string
+	baseURL    string
+	model      string
+	apiKey     string
+	dimensions int
+	httpClient *http.Client
+}
+
+type apiEmbedRequest struct {
+	Model           string   `json:"model"`
+	Input           []string `json:"input"`
+	InputType       string   `json:"input_type,omitempty"`       // Voyage only
+	Dimensions      int      `json:"dimensions,omitempty"`       // OpenAI only
+	OutputDimension int      `json:"output_dimension,omitempty"` // Voyage only
+}
+
+type apiEmbedResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+}
+
+// NewAPIEmbedder creates an OpenAI or Voyage embedder. The model defaults
+// to text-embedding-3-small and voyage-code-3 respectively.
+func NewAPIEmbedder(cfg EmbedderConfig) (*APIEmbedder, error) {
+	e := &APIEmbedder{
+		provider:   cfg.Provider,
+		baseURL:    cfg.BaseURL,
+		model:      cfg.Model,
+		apiKey:     cfg.APIKey,
+		dimensions: cfg.Dimensions,
+		httpClient: proxy.Client(cfg.Provider, 60*time.Second),
+	}
+	switch cfg.Provider {
+	case "openai":
+		if e.model == "" {
+			e.model = "text-embedding-3-small"
+		}
+		if e.baseURL == "" {
+			e.baseURL = "https://api.openai.com/v1"
+		}
+	case "voyage":
+		if e.model == "" {
+			e.model = "voyage-code-3"
+		}
+		if e.baseURL == "" {
+			e.baseURL = "https://api.voyageai.com/v1"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported embedding API %q (use openai or voyage)", cfg.Provider)
+	}
+	native := embeddingDimensions[e.model]
+	if e.dimensions == 0 {
+		e.dimensions = native
+	}
+	if e.dimensions == 0 {
+		return nil, fmt.Errorf("unknown dimensions for embedding model %s; set rag.embedder.dimensions", e.model)
+	}
+	if native > 0 && e.dimensions != native {
+		switch {
+		case e.shortens() && e.dimensions > native:
+			return nil, fmt.Errorf("embedding model %s has at most %d dimensions", e.model, native)
+		case voyageFlexible[e.model] && !oneOf(e.dimensions, 256, 512, 1024, 2048):
+			return nil, fmt.Errorf("embedding model %s has 256, 512, 1024 or 2048 dimensions, not %d", e.model, e.dimensions)
+		case !e.shortens() && !voyageFlexible[e.model]:
+			return nil, fmt.Errorf("embedding model %s has %d dimensions, not %d", e.model, native, e.dimensions)
+		}
+	}
+	return e, nil
+}
+
+// NewOpenAIEmbedder creates an embedder over OpenAI's text-embedding-3
+// models. A dimensions below the model's size (1536 for small, 3072 for
+// large) shortens the embeddings, trading a little accuracy for a smaller
+// index; 0 keeps the full size.
+func NewOpenAIEmbedder(model, apiKey string, dimensions int) (*APIEmbedder, error) {
+	return NewAPIEmbedder(EmbedderConfig{Provider: "openai", Model: model, APIKey: apiKey, Dimensions: dimensions})
+}
+
+// shortens reports whether the model returns embeddings of a requested
+// size, as OpenAI's text-embedding-3 models do.
+func (e *APIEmbedder) shortens() bool {
+	return e.provider == "openai" && strings.HasPrefix(e.model, "text-embedding-3-")
+}
+
+// requestDimensions is the dimensions to ask the API for, or 0 for the
+// model's own size.
+func (e *APIEmbedder) requestDimensions() int {
+	if (e.shortens() || voyageFlexible[e.model]) && e.dimensions != embeddingDimensions[e.model] {
+		return e.dimensions
+	}
+	return 0
+}
+
+// Embed embeds a search query. Voyage embeds queries and documents
+// differently, so it is told which one this is.
+func (e *APIEmbedder) Embed(text string) ([]float32, error) {
+	embeddings, err := e.embed([]string{text}, "query")
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch embeds documents in a single request.
+func (e *APIEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	return e.embed(texts, "document")
+}
+
+func (e *APIEmbedder) embed(texts []string, inputType string) ([][]float32, error) {
+	reqBody := apiEmbedRequest{Model: e.model, Input: texts}
+	if e.provider == "voyage" {
+		reqBody.InputType = inputType
+		reqBody.OutputDimension = e.requestDimensions()
+	} else {
+		reqBody.Dimensions = e.requestDimensions()
+	}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	if err := ratelimit.Wait(context.Background(), e.provider); err != nil {
+		return nil, fmt.Errorf("rate limit wait: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", e.baseURL+"/embeddings", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", e.provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s returned status %d: %s", e.provider, resp.StatusCode, string(body))
+	}
+
+	var result apiEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index >= 0 && d.Index < len(embeddings) {
+			embeddings[d.Index] = d.Embedding
+		}
+	}
+	for i, embedding := range embeddings {
+		if len(embedding) == 0 {
+			return nil, fmt.Errorf("no embedding returned for text %d", i)
+		}
+	}
+	if err := checkDimensions(embeddings, e.dimensions, e.model); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// EmbedBulk implements BulkEmbedder: texts are submitted in batches of up
+// to batch.MaxEmbeddingRequests, polled until done, and downloaded.
+func (e *APIEmbedder) EmbedBulk(ctx context.Context, texts []string, progress func(*batch.Status)) ([][]float32, error) {
+	client, err := batch.NewEmbeddings(batch.Config{
+		Provider:   e.provider,
+		APIKey:     e.apiKey,
+		Model:      e.model,
+		BaseURL:    e.baseURL,
+		Dimensions: e.requestDimensions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for start := 0; start < len(texts); start += batch.MaxEmbeddingRequests {
+		end := min(start+batch.MaxEmbeddingRequests, len(texts))
+		requests := make([]batch.EmbeddingRequest, 0, end-start)
+		for i := start; i < end; i++ {
+			requests = append(requests, batch.EmbeddingRequest{ID: strconv.Itoa(i), Text: texts[i]})
+		}
+
+		id, err := client.Submit(ctx, requests)
+		if err != nil {
+			return nil, fmt.Errorf("submit embedding batch: %w", err)
+		}
+		if _, err := batch.Wait(ctx, client, id, bulkPollInterval, progress); err != nil {
+			return nil, fmt.Errorf("wait for embedding batch %s: %w", id, err)
+		}
+		results, err := client.Results(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("download embedding batch %s: %w", id, err)
+		}
+
+		for _, res := range results {
+			i, err := strconv.Atoi(res.ID)
+			if err != nil || i < start || i >= end || res.Error != "" || len(res.Embedding) != e.dimensions {
+				continue
+			}
+			embeddings[i] = res.Embedding
+		}
+	}
+	return embeddings, nil
+}
+
+func (e *APIEmbedder) Dimension() int {
+	return e.dimensions
+}
+
+func (e *APIEmbedder) Model() string {
+	return e.model
+}
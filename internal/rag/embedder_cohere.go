@@ -0,0 +1,180 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/yourorg/agent/internal/proxy"
+	"github.com/yourorg/agent/internal/ratelimit"
+)
+
+// cohereMaxTexts is the most texts Cohere embeds in one request.
+const cohereMaxTexts = 96
+
+// cohereDimensions lists the output size of Cohere's embedding models.
+var cohereDimensions = map[string]int{
+	"embed-v4.0":                    1536,
+	"embed-english-v3.0":            1024,
+	"embed-multilingual-v3.0":       1024,
+	"embed-english-light-v3.0":      384,
+	"embed-multilingual-light-v3.0": 384,
+}
+
+// CohereEmbedder implements Embedder over the Cohere v2 embed API.
+type CohereEmbedder struct {
+	baseURL    string
+	model      string
+	apiKey     string
+	dimensions int
+	httpClient *http.Client
+}
+
+type cohereEmbedRequest struct {
+	Model           string   `json:"model"`
+	Texts           []string `json:"texts"`
+	InputType       string   `json:"input_type"`
+	EmbeddingTypes  []string `json:"embedding_types"`
+	OutputDimension int      `json:"output_dimension,omitempty"`
+}
+
+type cohereEmbedResponse struct {
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+}
+
+// NewCohereEmbedder creates a Cohere embedder. The model defaults to
+// embed-english-v3.0; embed-v4.0 can be shortened to 256, 512 or 1024
+// dimensions.
+func NewCohereEmbedder(cfg EmbedderConfig) (*CohereEmbedder, error) {
+	e := &CohereEmbedder{
+		baseURL:    cfg.BaseURL,
+		model:      cfg.Model,
+		apiKey:     cfg.APIKey,
+		dimensions: cfg.Dimensions,
+		httpClient: proxy.Client("cohere", 60*time.Second),
+	}
+	if e.model == "" {
+		e.model = "embed-english-v3.0"
+	}
+	if e.baseURL == "" {
+		e.baseURL = "https://api.cohere.com"
+	}
+	native := cohereDimensions[e.model]
+	if e.dimensions == 0 {
+		e.dimensions = native
+	}
+	if e.dimensions == 0 {
+		return nil, fmt.Errorf("unknown dimensions for embedding model %s; set rag.embedder.dimensions", e.model)
+	}
+	if native > 0 && e.dimensions != native && !(e.model == "embed-v4.0" && oneOf(e.dimensions, 256, 512, 1024)) {
+		return nil, fmt.Errorf("embedding model %s has %d dimensions, not %d", e.model, native, e.dimensions)
+	}
+	return e, nil
+}
+
+// Embed embeds a search query.
+func (e *CohereEmbedder) Embed(text string) ([]float32, error) {
+	embeddings, err := e.embed([]string{text}, "search_query")
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch embeds documents, in as few requests as Cohere allows.
+func (e *CohereEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += cohereMaxTexts {
+		batch, err := e.embed(texts[start:min(start+cohereMaxTexts, len(texts))], "search_document")
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+func (e *CohereEmbedder) embed(texts []string, inputType string) ([][]float32, error) {
+	reqBody := cohereEmbedRequest{
+		Model:          e.model,
+		Texts:          texts,
+		InputType:      inputType,
+		EmbeddingTypes: []string{"float"},
+	}
+	if e.dimensions != cohereDimensions[e.model] {
+		reqBody.OutputDimension = e.dimensions
+	}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	if err := ratelimit.Wait(context.Background(), "cohere"); err != nil {
+		return nil, fmt.Errorf("rate limit wait: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", e.baseURL+"/v2/embed", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cohere request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("cohere returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result cohereEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Embeddings.Float) != len(texts) {
+		return nil, fmt.Errorf("cohere returned %d embeddings for %d texts", len(result.Embeddings.Float), len(texts))
+	}
+	if err := checkDimensions(result.Embeddings.Float, e.dimensions, e.model); err != nil {
+		return nil, err
+	}
+	return result.Embeddings.Float, nil
+}
+
+func (e *CohereEmbedder) Dimension() int {
+	return e.dimensions
+}
+
+func (e *CohereEmbedder) Model() string {
+	return e.model
+}
+
+// checkDimensions catches a configured dimensions that does not match the
+// model before its vectors reach the vector store, which sizes every
+// stored vector by it.
+func checkDimensions(embeddings [][]float32, dimensions int, model string) error {
+	for _, embedding := range embeddings {
+		if len(embedding) != dimensions {
+			return fmt.Errorf("embedding model %s returned %d dimensions, expected %d; fix rag.embedder.dimensions", model, len(embedding), dimensions)
+		}
+	}
+	return nil
+}
+
+func oneOf(n int, values ...int) bool {
+	for _, v := range values {
+		if n == v {
+			return true
+		}
+	}
+	return false
+}
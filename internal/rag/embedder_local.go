@@ -0,0 +1,504 @@
+package rag
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourorg/agent/internal/proxy"
+)
+
+// DefaultLocalModel is the model the local embedder uses by default: a
+// small (90MB, 384 dimensions) sentence-transformers model.
+const DefaultLocalModel = "all-MiniLM-L6-v2"
+
+// LocalEmbedder implements Embedder in pure Go, running a BERT-style
+// sentence-transformers model (all-MiniLM-L6-v2, all-MiniLM-L12-v2,
+// bge-small-en-v1.5, e5-small-v2 and the like) on the CPU, so indexing
+// needs no Ollama server or API key. The model directory holds the
+// HuggingFace config.json, vocab.txt and model.safetensors; see
+// DownloadLocalModel. Accents are not stripped before tokenizing, which
+// only affects non-ASCII text.
+type LocalEmbedder struct {
+	name      string
+	tokenizer *wordPiece
+	model     *bertModel
+	maxLen    int
+	clsPool   bool // pool the [CLS] vector instead of the token mean
+	prefixes  [2]string
+}
+
+// LocalModelDir returns the directory a named local model is kept in:
+// <user cache>/indexer/models/<name>, without any "org/" prefix. An
+// absolute or relative path to a directory is returned as is.
+func LocalModelDir(name string) string {
+	if name == "" {
+		name = DefaultLocalModel
+	}
+	if filepath.IsAbs(name) || strings.HasPrefix(name, ".") {
+		return name
+	}
+	if info, err := os.Stat(name); err == nil && info.IsDir() {
+		return name
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		cache = os.TempDir()
+	}
+	return filepath.Join(cache, "indexer", "models", filepath.Base(name))
+}
+
+// NewLocalEmbedder loads the model named by cfg.Model (DefaultLocalModel
+// if empty), or found at that path.
+func NewLocalEmbedder(cfg EmbedderConfig) (*LocalEmbedder, error) {
+	dir := LocalModelDir(cfg.Model)
+	if _, err := os.Stat(filepath.Join(dir, "model.safetensors")); errors.Is(err, fs.ErrNotExist) {
+		if dir == cfg.Model {
+			return nil, fmt.Errorf("no model.safetensors in %s", dir)
+		}
+		return nil, fmt.Errorf("local embedding model not found in %s; run 'indexer rag download-model %s'", dir, cmp.Or(cfg.Model, DefaultLocalModel))
+	}
+
+	var conf struct {
+		HiddenSize            int     `json:"hidden_size"`
+		NumHiddenLayers       int     `json:"num_hidden_layers"`
+		NumAttentionHeads     int     `json:"num_attention_heads"`
+		IntermediateSize      int     `json:"intermediate_size"`
+		MaxPositionEmbeddings int     `json:"max_position_embeddings"`
+		LayerNormEps          float64 `json:"layer_norm_eps"`
+		HiddenAct             string  `json:"hidden_act"`
+	}
+	if err := readJSON(filepath.Join(dir, "config.json"), &conf); err != nil {
+		return nil, fmt.Errorf("read model config: %w", err)
+	}
+	if conf.HiddenAct != "" && conf.HiddenAct != "gelu" {
+		return nil, fmt.Errorf("local embedder supports BERT models with gelu activation, not %s", conf.HiddenAct)
+	}
+	if conf.LayerNormEps == 0 {
+		conf.LayerNormEps = 1e-12
+	}
+
+	lowercase := true
+	var tokConf struct {
+		DoLowerCase *bool `json:"do_lower_case"`
+	}
+	if readJSON(filepath.Join(dir, "tokenizer_config.json"), &tokConf) == nil && tokConf.DoLowerCase != nil {
+		lowercase = *tokConf.DoLowerCase
+	}
+	tokenizer, err := loadWordPiece(filepath.Join(dir, "vocab.txt"), lowercase)
+	if err != nil {
+		return nil, fmt.Errorf("load vocabulary: %w", err)
+	}
+
+	weights, err := readSafetensors(filepath.Join(dir, "model.safetensors"))
+	if err != nil {
+		return nil, fmt.Errorf("load model weights: %w", err)
+	}
+	model, err := newBertModel(weights, conf.HiddenSize, conf.NumHiddenLayers, conf.NumAttentionHeads, float32(conf.LayerNormEps))
+	if err != nil {
+		return nil, fmt.Errorf("load model weights: %w", err)
+	}
+
+	e := &LocalEmbedder{
+		name:      filepath.Base(filepath.Clean(dir)),
+		tokenizer: tokenizer,
+		model:     model,
+		maxLen:    min(512, conf.MaxPositionEmbeddings),
+	}
+	var stConf struct {
+		MaxSeqLength int `json:"max_seq_length"`
+	}
+	if readJSON(filepath.Join(dir, "sentence_bert_config.json"), &stConf) == nil && stConf.MaxSeqLength > 0 {
+		e.maxLen = min(e.maxLen, stConf.MaxSeqLength)
+	}
+	var pooling struct {
+		CLS bool `json:"pooling_mode_cls_token"`
+	}
+	if readJSON(filepath.Join(dir, "1_Pooling", "config.json"), &pooling) == nil {
+		e.clsPool = pooling.CLS
+	}
+	if strings.HasPrefix(e.name, "e5-") {
+		e.prefixes = [2]string{"query: ", "passage: "}
+	}
+	return e, nil
+}
+
+// Embed embeds a search query.
+func (e *LocalEmbedder) Embed(text string) ([]float32, error) {
+	return e.embed(e.prefixes[0] + text), nil
+}
+
+// EmbedBatch embeds documents one at a time; each uses every CPU.
+func (e *LocalEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = e.embed(e.prefixes[1] + text)
+	}
+	return embeddings, nil
+}
+
+func (e *LocalEmbedder) embed(text string) []float32 {
+	hidden := e.model.forward(e.tokenizer.encode(text, e.maxLen))
+	h := e.model.hidden
+	out := make([]float32, h)
+	if e.clsPool {
+		copy(out, hidden[:h])
+	} else {
+		n := len(hidden) / h
+		for t := 0; t < n; t++ {
+			for j, v := range hidden[t*h : (t+1)*h] {
+				out[j] += v
+			}
+		}
+		for j := range out {
+			out[j] /= float32(n)
+		}
+	}
+	var norm float64
+	for _, v := range out {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for j := range out {
+			out[j] *= scale
+		}
+	}
+	return out
+}
+
+func (e *LocalEmbedder) Dimension() int {
+	return e.model.hidden
+}
+
+func (e *LocalEmbedder) Model() string {
+	return e.name
+}
+
+// Remote reports false: the model runs in this process.
+func (e *LocalEmbedder) Remote() bool {
+	return false
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// bertModel is a BERT encoder: token, position and type embeddings, then
+// layers of multi-head self-attention and a GELU feed-forward block, each
+// followed by a residual connection and layer normalization. Linear
+// weights are [out, in], as stored by PyTorch.
+type bertModel struct {
+	hidden, heads int
+	eps           float32
+	wordEmb       tensor
+	posEmb        tensor
+	typeEmb       tensor
+	embNorm       [2]tensor
+	layers        []bertLayer
+}
+
+type bertLayer struct {
+	query, key, value, attnOut [2]tensor // weight, bias
+	attnNorm                   [2]tensor
+	inter, out                 [2]tensor
+	outNorm                    [2]tensor
+}
+
+func newBertModel(w map[string]tensor, hidden, layers, heads int, eps float32) (*bertModel, error) {
+	var missing []string
+	get := func(name string) tensor {
+		for _, n := range []string{name, "bert." + name} {
+			if t, ok := w[n]; ok {
+				return t
+			}
+		}
+		// Older checkpoints name layer norm parameters gamma and beta.
+		old := strings.NewReplacer("LayerNorm.weight", "LayerNorm.gamma", "LayerNorm.bias", "LayerNorm.beta").Replace(name)
+		for _, n := range []string{old, "bert." + old} {
+			if t, ok := w[n]; ok {
+				return t
+			}
+		}
+		missing = append(missing, name)
+		return tensor{}
+	}
+	pair := func(prefix string) [2]tensor {
+		return [2]tensor{get(prefix + ".weight"), get(prefix + ".bias")}
+	}
+
+	m := &bertModel{
+		hidden:  hidden,
+		heads:   heads,
+		eps:     eps,
+		wordEmb: get("embeddings.word_embeddings.weight"),
+		posEmb:  get("embeddings.position_embeddings.weight"),
+		typeEmb: get("embeddings.token_type_embeddings.weight"),
+		embNorm: pair("embeddings.LayerNorm"),
+	}
+	for i := 0; i < layers; i++ {
+		p := fmt.Sprintf("encoder.layer.%d.", i)
+		m.layers = append(m.layers, bertLayer{
+			query:    pair(p + "attention.self.query"),
+			key:      pair(p + "attention.self.key"),
+			value:    pair(p + "attention.self.value"),
+			attnOut:  pair(p + "attention.output.dense"),
+			attnNorm: pair(p + "attention.output.LayerNorm"),
+			inter:    pair(p + "intermediate.dense"),
+			out:      pair(p + "output.dense"),
+			outNorm:  pair(p + "output.LayerNorm"),
+		})
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("not a BERT model: missing %s", strings.Join(missing[:min(3, len(missing))], ", "))
+	}
+	if hidden == 0 || heads == 0 || hidden%heads != 0 || len(m.wordEmb.shape) != 2 || m.wordEmb.shape[1] != hidden {
+		return nil, fmt.Errorf("model config does not match its weights")
+	}
+	return m, nil
+}
+
+// forward returns the final hidden states of ids, len(ids) rows of
+// m.hidden values.
+func (m *bertModel) forward(ids []int) []float32 {
+	n, h := len(ids), m.hidden
+	vocab := m.wordEmb.shape[0]
+	positions := m.posEmb.shape[0]
+	x := make([]float32, n*h)
+	for t, id := range ids {
+		if id >= vocab {
+			id = 0
+		}
+		row := x[t*h : (t+1)*h]
+		word := m.wordEmb.data[id*h : (id+1)*h]
+		pos := m.posEmb.data[min(t, positions-1)*h:]
+		for j := range row {
+			row[j] = word[j] + pos[j] + m.typeEmb.data[j]
+		}
+	}
+	layerNorm(x, h, m.embNorm, m.eps)
+
+	for _, l := range m.layers {
+		q := linear(x, n, l.query)
+		k := linear(x, n, l.key)
+		v := linear(x, n, l.value)
+		ctx := attention(q, k, v, n, h, m.heads)
+		attnOut := linear(ctx, n, l.attnOut)
+		for i := range attnOut {
+			attnOut[i] += x[i]
+		}
+		layerNorm(attnOut, h, l.attnNorm, m.eps)
+
+		inter := linear(attnOut, n, l.inter)
+		for i, v := range inter {
+			inter[i] = gelu(v)
+		}
+		x = linear(inter, n, l.out)
+		for i := range x {
+			x[i] += attnOut[i]
+		}
+		layerNorm(x, h, l.outNorm, m.eps)
+	}
+	return x
+}
+
+// linear computes x·Wᵀ + b for the n rows of x, spreading rows over the
+// CPUs.
+func linear(x []float32, n int, wb [2]tensor) []float32 {
+	w, b := wb[0], wb[1]
+	out, in := w.shape[0], w.shape[1]
+	y := make([]float32, n*out)
+	parallel(n, func(i int) {
+		row := x[i*in : (i+1)*in]
+		dst := y[i*out : (i+1)*out]
+		for o := range dst {
+			dst[o] = dot(row, w.data[o*in:(o+1)*in]) + b.data[o]
+		}
+	})
+	return y
+}
+
+// attention is scaled dot-product self-attention over all n tokens, per
+// head; q, k and v hold n rows of h values.
+func attention(q, k, v []float32, n, h, heads int) []float32 {
+	d := h / heads
+	scale := float32(1 / math.Sqrt(float64(d)))
+	ctx := make([]float32, n*h)
+	parallel(n*heads, func(job int) {
+		i, head := job/heads, job%heads
+		off := head * d
+		qi := q[i*h+off : i*h+off+d]
+		scores := make([]float32, n)
+		maxScore := float32(math.Inf(-1))
+		for j := 0; j < n; j++ {
+			scores[j] = dot(qi, k[j*h+off:j*h+off+d]) * scale
+			maxScore = max(maxScore, scores[j])
+		}
+		var sum float32
+		for j := range scores {
+			scores[j] = float32(math.Exp(float64(scores[j] - maxScore)))
+			sum += scores[j]
+		}
+		dst := ctx[i*h+off : i*h+off+d]
+		for j, s := range scores {
+			s /= sum
+			for c, vv := range v[j*h+off : j*h+off+d] {
+				dst[c] += s * vv
+			}
+		}
+	})
+	return ctx
+}
+
+func layerNorm(x []float32, h int, wb [2]tensor, eps float32) {
+	for start := 0; start < len(x); start += h {
+		row := x[start : start+h]
+		var mean float32
+		for _, v := range row {
+			mean += v
+		}
+		mean /= float32(h)
+		var variance float32
+		for _, v := range row {
+			variance += (v - mean) * (v - mean)
+		}
+		variance /= float32(h)
+		inv := float32(1 / math.Sqrt(float64(variance+eps)))
+		for j, v := range row {
+			row[j] = (v-mean)*inv*wb[0].data[j] + wb[1].data[j]
+		}
+	}
+}
+
+// gelu is the exact (erf) GELU that BERT uses.
+func gelu(x float32) float32 {
+	return 0.5 * x * (1 + float32(math.Erf(float64(x)/math.Sqrt2)))
+}
+
+func dot(a, b []float32) float32 {
+	var s float32
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// parallel runs fn(0..n-1) on up to GOMAXPROCS goroutines.
+func parallel(n int, fn func(int)) {
+	workers := min(runtime.GOMAXPROCS(0), n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	next := make(chan int, n)
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// localModelFiles are fetched by DownloadLocalModel; only the first three
+// are required.
+var localModelFiles = []string{
+	"config.json",
+	"vocab.txt",
+	"model.safetensors",
+	"tokenizer_config.json",
+	"sentence_bert_config.json",
+	"1_Pooling/config.json",
+}
+
+// DownloadLocalModel fetches a model from the HuggingFace Hub into
+// LocalModelDir(name). name is a repository ("BAAI/bge-small-en-v1.5") or
+// a sentence-transformers model ("all-MiniLM-L6-v2"). progress, if
+// non-nil, is called before each file.
+func DownloadLocalModel(ctx context.Context, name string, progress func(file string)) (string, error) {
+	if name == "" {
+		name = DefaultLocalModel
+	}
+	repo := name
+	if !strings.Contains(repo, "/") {
+		repo = "sentence-transformers/" + repo
+	}
+	dir := LocalModelDir(name)
+	client := proxy.Client("huggingface", 30*time.Minute)
+	for i, file := range localModelFiles {
+		if progress != nil {
+			progress(file)
+		}
+		err := downloadFile(ctx, client, "https://huggingface.co/"+repo+"/resolve/main/"+file, filepath.Join(dir, filepath.FromSlash(file)))
+		if errors.Is(err, fs.ErrNotExist) && i >= 3 {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("download %s: %w", file, err)
+		}
+	}
+	return dir, nil
+}
+
+// downloadFile writes url to path through a temporary file. A 404 is
+// reported as fs.ErrNotExist.
+func downloadFile(ctx context.Context, client *http.Client, url, path string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fs.ErrNotExist
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,138 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// SearchFilter restricts a search to chunks with matching metadata. Stores
+// apply it before ranking, so topK results still come back when the
+// filter is narrow. Zero fields do not filter.
+type SearchFilter struct {
+	Languages  []string // any of these languages, e.g. "python"
+	ChunkTypes []string // any of these chunk types, e.g. "function"
+	// Path is a glob over the chunk's file path as stored, in SQLite GLOB
+	// syntax: * also matches "/". Build one from a project-relative path
+	// with PathGlob.
+	Path         string
+	SymbolPrefix string // symbol names starting with this, case-sensitive
+}
+
+// Empty reports whether the filter keeps every chunk.
+func (f SearchFilter) Empty() bool {
+	return len(f.Languages) == 0 && len(f.ChunkTypes) == 0 && f.Path == "" && f.SymbolPrefix == ""
+}
+
+// PathGlob turns a path relative to projectRoot into a SearchFilter path:
+// a directory, or any path ending in "/", matches everything below it; a
+// file matches itself; a pattern containing *, ? or [ is a glob below the
+// root, where * also matches "/", so "*.py" finds Python files anywhere.
+func PathGlob(projectRoot, p string) string {
+	p = strings.TrimPrefix(filepath.ToSlash(p), "./")
+	if p == "" {
+		return ""
+	}
+	if strings.ContainsAny(p, "*?[") {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return escapeGlob(filepath.ToSlash(projectRoot)) + "/" + p
+	}
+	dir := strings.HasSuffix(p, "/")
+	abs := filepath.FromSlash(p)
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(projectRoot, abs)
+	}
+	if !dir {
+		if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+			return escapeGlob(abs)
+		}
+	}
+	return escapeGlob(abs) + string(filepath.Separator) + "*"
+}
+
+// escapeGlob quotes the glob metacharacters in s.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == '*' || r == '?' || r == '[' {
+			b.WriteByte('[')
+			b.WriteRune(r)
+			b.WriteByte(']')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// globRegexp translates a SQLite GLOB pattern into an anchored regular
+// expression accepted by both Go and PostgreSQL.
+func globRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteByte('^')
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		case '[':
+			j := i + 1
+			if j < len(glob) && glob[j] == '^' {
+				j++
+			}
+			if j < len(glob) && glob[j] == ']' {
+				j++ // a leading ']' is part of the set
+			}
+			end := strings.IndexByte(glob[j:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			b.WriteByte('[')
+			b.WriteString(strings.ReplaceAll(glob[i+1:j+end], `\`, `\\`))
+			b.WriteByte(']')
+			i = j + end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteByte('$')
+	return b.String()
+}
+
+// chunkMatcher applies a filter in Go, for stores without a query engine.
+type chunkMatcher struct {
+	filter SearchFilter
+	path   *regexp.Regexp
+}
+
+func newChunkMatcher(f SearchFilter) (*chunkMatcher, error) {
+	m := &chunkMatcher{filter: f}
+	if f.Path != "" {
+		re, err := regexp.Compile(globRegexp(f.Path))
+		if err != nil {
+			return nil, err
+		}
+		m.path = re
+	}
+	return m, nil
+}
+
+func (m *chunkMatcher) match(c *Chunk) bool {
+	f := m.filter
+	if len(f.Languages) > 0 && !slices.Contains(f.Languages, c.Language) {
+		return false
+	}
+	if len(f.ChunkTypes) > 0 && !slices.Contains(f.ChunkTypes, c.ChunkType) {
+		return false
+	}
+	if m.path != nil && !m.path.MatchString(c.FilePath) {
+		return false
+	}
+	return strings.HasPrefix(c.SymbolName, f.SymbolPrefix)
+}
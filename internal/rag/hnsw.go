@@ -0,0 +1,442 @@
+package rag
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+)
+
+// hnswVersion is bumped whenever the persisted graph format changes.
+const hnswVersion = 1
+
+// HNSW parameters: links per node on the upper layers (twice as many on
+// layer 0), and candidate list sizes while building and searching.
+const (
+	hnswM              = 16
+	hnswEfConstruction = 100
+	hnswEfSearch       = 64
+)
+
+// hnswIndex is a hierarchical navigable small world graph over normalised
+// vectors, for approximate nearest neighbour search by cosine similarity
+// (Malkov and Yashunin, 2016). Nodes are keyed by chunk ID. Removing a
+// node reconnects its neighbours, and its slot is reused by a later add.
+//
+// Searches may run concurrently with each other but not with add or
+// remove.
+type hnswIndex struct {
+	dims     int
+	keys     []string // "" for a free slot
+	vecs     []float32
+	links    [][][]int32 // per node, per layer
+	byKey    map[string]int32
+	free     []int32
+	entry    int32 // -1 when empty
+	maxLevel int
+	rng      *rand.Rand
+	visits   sync.Pool
+}
+
+func newHNSW(dims int) *hnswIndex {
+	return &hnswIndex{
+		dims:  dims,
+		byKey: make(map[string]int32),
+		entry: -1,
+		rng:   rand.New(rand.NewSource(1)),
+	}
+}
+
+// Len returns the number of vectors in the graph.
+func (h *hnswIndex) Len() int {
+	return len(h.byKey)
+}
+
+// Has reports whether key is in the graph.
+func (h *hnswIndex) Has(key string) bool {
+	_, ok := h.byKey[key]
+	return ok
+}
+
+// Keys returns the keys in the graph, in no particular order.
+func (h *hnswIndex) Keys() []string {
+	keys := make([]string, 0, len(h.byKey))
+	for key := range h.byKey {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (h *hnswIndex) vec(n int32) []float32 {
+	return h.vecs[int(n)*h.dims : int(n+1)*h.dims]
+}
+
+func (h *hnswIndex) sim(q []float32, n int32) float32 {
+	v := h.vec(n)
+	var dot float32
+	for i := range q {
+		dot += q[i] * v[i]
+	}
+	return dot
+}
+
+func maxLinks(level int) int {
+	if level == 0 {
+		return 2 * hnswM
+	}
+	return hnswM
+}
+
+// Add inserts vec under key, replacing any vector already stored for it.
+func (h *hnswIndex) Add(key string, vec []float32) error {
+	if len(vec) != h.dims {
+		return fmt.Errorf("vector has %d dimensions, index has %d", len(vec), h.dims)
+	}
+	if h.Has(key) {
+		h.Remove(key)
+	}
+	level := int(-math.Log(1-h.rng.Float64()) / math.Log(hnswM))
+
+	var n int32
+	if len(h.free) > 0 {
+		n = h.free[len(h.free)-1]
+		h.free = h.free[:len(h.free)-1]
+		h.keys[n] = key
+	} else {
+		n = int32(len(h.keys))
+		h.keys = append(h.keys, key)
+		h.vecs = append(h.vecs, make([]float32, h.dims)...)
+		h.links = append(h.links, nil)
+	}
+	q := h.vec(n)
+	copy(q, vec)
+	normalize(q)
+	h.links[n] = make([][]int32, level+1)
+	h.byKey[key] = n
+
+	if h.entry < 0 {
+		h.entry, h.maxLevel = n, level
+		return nil
+	}
+
+	ep := h.greedy(q, h.entry, h.maxLevel, level)
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(q, ep, hnswEfConstruction, l)
+		h.links[n][l] = h.selectNeighbors(q, candidates, maxLinks(l))
+		for _, nb := range h.links[n][l] {
+			h.connect(nb, n, l)
+		}
+		ep = candidates[0].node
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = n, level
+	}
+	return nil
+}
+
+// connect links from to to on level l, pruning from's links when it has
+// too many.
+func (h *hnswIndex) connect(from, to int32, l int) {
+	links := append(h.links[from][l], to)
+	if len(links) > maxLinks(l) {
+		q := h.vec(from)
+		candidates := make([]hnswCandidate, len(links))
+		for i, nb := range links {
+			candidates[i] = hnswCandidate{node: nb, sim: h.sim(q, nb)}
+		}
+		sortCandidates(candidates)
+		links = h.selectNeighbors(q, candidates, maxLinks(l))
+	}
+	h.links[from][l] = links
+}
+
+// Remove deletes key from the graph, reconnecting its neighbours to each
+// other.
+func (h *hnswIndex) Remove(key string) {
+	n, ok := h.byKey[key]
+	if !ok {
+		return
+	}
+	delete(h.byKey, key)
+	for l, links := range h.links[n] {
+		for _, nb := range links {
+			if nb == n || h.keys[nb] == "" || len(h.links[nb]) <= l {
+				continue
+			}
+			q := h.vec(nb)
+			seen := map[int32]bool{nb: true, n: true}
+			var candidates []hnswCandidate
+			for _, c := range append(append([]int32(nil), h.links[nb][l]...), links...) {
+				if seen[c] || h.keys[c] == "" {
+					continue
+				}
+				seen[c] = true
+				candidates = append(candidates, hnswCandidate{node: c, sim: h.sim(q, c)})
+			}
+			sortCandidates(candidates)
+			h.links[nb][l] = h.selectNeighbors(q, candidates, maxLinks(l))
+		}
+	}
+	h.keys[n] = ""
+	h.links[n] = nil
+	h.free = append(h.free, n)
+
+	if h.entry == n {
+		h.entry, h.maxLevel = -1, 0
+		for i, links := range h.links {
+			if h.keys[i] != "" && (h.entry < 0 || len(links)-1 > h.maxLevel) {
+				h.entry, h.maxLevel = int32(i), len(links)-1
+			}
+		}
+	}
+}
+
+// Search returns the keys of the k vectors most similar to vec, most
+// similar first, with their cosine similarity.
+func (h *hnswIndex) Search(vec []float32, k int) ([]string, []float32) {
+	if h.entry < 0 || k <= 0 || len(vec) != h.dims {
+		return nil, nil
+	}
+	q := append([]float32(nil), vec...)
+	normalize(q)
+	ep := h.greedy(q, h.entry, h.maxLevel, 0)
+	candidates := h.searchLayer(q, ep, max(hnswEfSearch, k), 0)
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	keys := make([]string, len(candidates))
+	sims := make([]float32, len(candidates))
+	for i, c := range candidates {
+		keys[i], sims[i] = h.keys[c.node], c.sim
+	}
+	return keys, sims
+}
+
+// greedy walks from ep to the node closest to q on each layer from top
+// down to above level.
+func (h *hnswIndex) greedy(q []float32, ep int32, top, level int) int32 {
+	best := h.sim(q, ep)
+	for l := top; l > level; l-- {
+		for changed := true; changed; {
+			changed = false
+			for _, nb := range h.links[ep][l] {
+				if h.keys[nb] == "" {
+					continue
+				}
+				if s := h.sim(q, nb); s > best {
+					ep, best, changed = nb, s, true
+				}
+			}
+		}
+	}
+	return ep
+}
+
+type hnswCandidate struct {
+	node int32
+	sim  float32
+}
+
+func sortCandidates(c []hnswCandidate) {
+	sort.Slice(c, func(i, j int) bool { return c[i].sim > c[j].sim })
+}
+
+// candidateQueue is a heap of candidates; with max set the most similar
+// is on top, otherwise the least similar.
+type candidateQueue struct {
+	items []hnswCandidate
+	max   bool
+}
+
+func (q *candidateQueue) Len() int { return len(q.items) }
+func (q *candidateQueue) Less(i, j int) bool {
+	if q.max {
+		return q.items[i].sim > q.items[j].sim
+	}
+	return q.items[i].sim < q.items[j].sim
+}
+func (q *candidateQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *candidateQueue) Push(x any)    { q.items = append(q.items, x.(hnswCandidate)) }
+func (q *candidateQueue) Pop() any {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
+
+// visitSet marks the nodes a search has reached.
+type visitSet struct {
+	seen    []bool
+	touched []int32
+}
+
+// searchLayer returns up to ef nodes of level l closest to q, found by a
+// best-first search from ep, most similar first.
+func (h *hnswIndex) searchLayer(q []float32, ep int32, ef, l int) []hnswCandidate {
+	visited, _ := h.visits.Get().(*visitSet)
+	if visited == nil {
+		visited = &visitSet{}
+	}
+	if len(visited.seen) < len(h.keys) {
+		visited.seen = make([]bool, len(h.keys))
+	}
+	defer func() {
+		for _, n := range visited.touched {
+			visited.seen[n] = false
+		}
+		visited.touched = visited.touched[:0]
+		h.visits.Put(visited)
+	}()
+	visit := func(n int32) bool {
+		if visited.seen[n] {
+			return false
+		}
+		visited.seen[n] = true
+		visited.touched = append(visited.touched, n)
+		return true
+	}
+
+	start := hnswCandidate{node: ep, sim: h.sim(q, ep)}
+	visit(ep)
+	frontier := &candidateQueue{items: []hnswCandidate{start}, max: true}
+	found := &candidateQueue{items: []hnswCandidate{start}}
+	for frontier.Len() > 0 {
+		c := heap.Pop(frontier).(hnswCandidate)
+		if found.Len() >= ef && c.sim < found.items[0].sim {
+			break
+		}
+		if len(h.links[c.node]) <= l {
+			continue
+		}
+		for _, nb := range h.links[c.node][l] {
+			if h.keys[nb] == "" || !visit(nb) {
+				continue
+			}
+			s := h.sim(q, nb)
+			if found.Len() < ef || s > found.items[0].sim {
+				heap.Push(frontier, hnswCandidate{node: nb, sim: s})
+				heap.Push(found, hnswCandidate{node: nb, sim: s})
+				if found.Len() > ef {
+					heap.Pop(found)
+				}
+			}
+		}
+	}
+	sortCandidates(found.items)
+	return found.items
+}
+
+// selectNeighbors picks up to m of candidates, sorted most similar to q
+// first, preferring ones that are closer to q than to any already picked
+// so links spread in different directions.
+func (h *hnswIndex) selectNeighbors(q []float32, candidates []hnswCandidate, m int) []int32 {
+	picked := make([]int32, 0, m)
+	for _, c := range candidates {
+		if len(picked) == m {
+			break
+		}
+		diverse := true
+		for _, p := range picked {
+			if h.sim(h.vec(c.node), p) > c.sim {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			picked = append(picked, c.node)
+		}
+	}
+	return picked
+}
+
+func normalize(v []float32) {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+}
+
+// persistedHNSW is the on-disk form of an hnswIndex. Model records the
+// embedding model of the vectors, so a graph left over from another model
+// is not reused.
+type persistedHNSW struct {
+	Version  int
+	Model    string
+	Dims     int
+	Keys     []string
+	Vectors  []byte
+	Links    [][][]int32
+	Entry    int32
+	MaxLevel int
+}
+
+// save writes the graph to a temporary file and renames it into place.
+func (h *hnswIndex) save(path, model string) error {
+	p := persistedHNSW{
+		Version:  hnswVersion,
+		Model:    model,
+		Dims:     h.dims,
+		Keys:     h.keys,
+		Vectors:  encodeEmbedding(h.vecs),
+		Links:    h.links,
+		Entry:    h.entry,
+		MaxLevel: h.maxLevel,
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(p); err != nil {
+		return fmt.Errorf("encode vector index: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write vector index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write vector index: %w", err)
+	}
+	return nil
+}
+
+// loadHNSW reads a graph saved for model and dims.
+func loadHNSW(path, model string, dims int) (*hnswIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p persistedHNSW
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&p); err != nil {
+		return nil, fmt.Errorf("decode vector index: %w", err)
+	}
+	if p.Version != hnswVersion || p.Model != model || p.Dims != dims {
+		return nil, errors.New("vector index is for another format or model")
+	}
+	vecs, err := decodeEmbedding(p.Vectors, len(p.Keys)*dims)
+	if err != nil {
+		return nil, fmt.Errorf("decode vector index: %w", err)
+	}
+	if len(p.Links) != len(p.Keys) {
+		return nil, errors.New("vector index is corrupt")
+	}
+	h := newHNSW(dims)
+	h.keys, h.vecs, h.links = p.Keys, vecs, p.Links
+	h.entry, h.maxLevel = p.Entry, p.MaxLevel
+	for i, key := range h.keys {
+		if key == "" {
+			h.free = append(h.free, int32(i))
+		} else {
+			h.byKey[key] = int32(i)
+		}
+	}
+	return h, nil
+}
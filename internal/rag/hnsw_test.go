@@ -0,0 +1,180 @@
+package rag
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func randomVectors(rng *rand.Rand, n, dims int) [][]float32 {
+	vecs := make([][]float32, n)
+	for i := range vecs {
+		v := make([]float32, dims)
+		for j := range v {
+			v[j] = rng.Float32()*2 - 1
+		}
+		vecs[i] = v
+	}
+	return vecs
+}
+
+// bruteForce returns the keys of the k vectors most similar to q.
+func bruteForce(keys []string, vecs [][]float32, q []float32, k int) []string {
+	type scored struct {
+		key string
+		sim float32
+	}
+	qn := append([]float32(nil), q...)
+	normalize(qn)
+	all := make([]scored, 0, len(keys))
+	for i, key := range keys {
+		if key == "" {
+			continue
+		}
+		v := append([]float32(nil), vecs[i]...)
+		normalize(v)
+		var dot float32
+		for j := range v {
+			dot += v[j] * qn[j]
+		}
+		all = append(all, scored{key, dot})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].sim > all[j].sim })
+	out := make([]string, 0, k)
+	for _, s := range all[:min(k, len(all))] {
+		out = append(out, s.key)
+	}
+	return out
+}
+
+func buildHNSW(t *testing.T, n, dims int) (*hnswIndex, []string, [][]float32) {
+	t.Helper()
+	rng := rand.New(rand.NewSource(42))
+	vecs := randomVectors(rng, n, dims)
+	keys := make([]string, n)
+	h := newHNSW(dims)
+	for i, v := range vecs {
+		keys[i] = fmt.Sprintf("chunk-%d", i)
+		if err := h.Add(keys[i], v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return h, keys, vecs
+}
+
+// recall returns the fraction of the exact top k that h finds.
+func recall(h *hnswIndex, keys []string, vecs, queries [][]float32, k int) float64 {
+	var found, total int
+	for _, q := range queries {
+		want := bruteForce(keys, vecs, q, k)
+		got, _ := h.Search(q, k)
+		for _, key := range want {
+			if slices.Contains(got, key) {
+				found++
+			}
+		}
+		total += len(want)
+	}
+	return float64(found) / float64(total)
+}
+
+func TestHNSWRecall(t *testing.T) {
+	const k = 10
+	h, keys, vecs := buildHNSW(t, 2000, 32)
+	queries := randomVectors(rand.New(rand.NewSource(7)), 50, 32)
+
+	if r := recall(h, keys, vecs, queries, k); r < 0.9 {
+		t.Errorf("recall@%d = %.2f, want at least 0.9", k, r)
+	}
+
+	// Remove every third vector: they must no longer be returned, and the
+	// reconnected graph must still find the rest.
+	for i := 0; i < len(keys); i += 3 {
+		h.Remove(keys[i])
+		keys[i] = ""
+	}
+	for _, q := range queries {
+		got, _ := h.Search(q, k)
+		for _, key := range got {
+			if !slices.Contains(keys, key) {
+				t.Fatalf("search returned removed key %s", key)
+			}
+		}
+	}
+	if r := recall(h, keys, vecs, queries, k); r < 0.9 {
+		t.Errorf("recall@%d after removals = %.2f, want at least 0.9", k, r)
+	}
+}
+
+func TestHNSWSearchOrder(t *testing.T) {
+	h, _, vecs := buildHNSW(t, 200, 8)
+	keys, sims := h.Search(vecs[5], 5)
+	if len(keys) != 5 || keys[0] != "chunk-5" {
+		t.Fatalf("Search(own vector) = %v, want chunk-5 first", keys)
+	}
+	for i := 1; i < len(sims); i++ {
+		if sims[i] > sims[i-1] {
+			t.Errorf("similarities not descending: %v", sims)
+		}
+	}
+	if keys, _ := h.Search(make([]float32, 3), 5); keys != nil {
+		t.Errorf("Search with wrong dimensions = %v, want nil", keys)
+	}
+}
+
+func TestHNSWPersistence(t *testing.T) {
+	h, keys, _ := buildHNSW(t, 500, 16)
+	h.Remove(keys[10])
+	path := filepath.Join(t.TempDir(), "vectors.hnsw")
+	if err := h.save(path, "model-a"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadHNSW(path, "model-a", 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != h.Len() || loaded.Has(keys[10]) {
+		t.Fatalf("loaded %d vectors (has removed key %v), want %d", loaded.Len(), loaded.Has(keys[10]), h.Len())
+	}
+	queries := randomVectors(rand.New(rand.NewSource(3)), 20, 16)
+	for _, q := range queries {
+		want, _ := h.Search(q, 10)
+		got, _ := loaded.Search(q, 10)
+		if !slices.Equal(got, want) {
+			t.Fatalf("loaded graph search = %v, want %v", got, want)
+		}
+	}
+
+	// The slot freed by the removal is reused after loading.
+	freed := loaded.free
+	if len(freed) != 1 {
+		t.Fatalf("loaded free slots = %v, want 1", freed)
+	}
+	slot := freed[0]
+	if err := loaded.Add("new", queries[0]); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.byKey["new"] != slot {
+		t.Errorf("new vector in slot %d, want freed slot %d", loaded.byKey["new"], slot)
+	}
+
+	tests := []struct {
+		name  string
+		model string
+		dims  int
+	}{
+		{"other model", "model-b", 16},
+		{"other dimensions", "model-a", 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadHNSW(path, tt.model, tt.dims); err == nil {
+				t.Errorf("loadHNSW(%s, %d) succeeded, want error", tt.model, tt.dims)
+			}
+		})
+	}
+}
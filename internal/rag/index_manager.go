@@ -1,26 +1,67 @@
 package rag
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	ignore "github.com/sabhiram/go-gitignore"
+
+	"github.com/yourorg/agent/internal/batch"
+	"github.com/yourorg/agent/internal/docs"
+	"github.com/yourorg/agent/internal/metrics"
+	"github.com/yourorg/agent/internal/ratelimit"
+	"github.com/yourorg/agent/internal/secrets"
 )
 
+// secretScanner redacts credentials from chunks before they are embedded.
+var secretScanner = secrets.NewScanner()
+
 // RAGIndexer manages the RAG indexing lifecycle
 type RAGIndexer struct {
 	embedder    Embedder
 	vectorStore VectorStore
 	stats       *IndexStats
+	opts        EmbedOptions
+	chunking    ChunkerConfig
+	limiter     *ratelimit.Limiter
+	embedSlots  chan struct{}
+	privacy     *PrivacyFilter
+	root        string // project root, for the paths in chunk headers
+
+	embedderChecked bool // the store's embedding model matches the embedder
+}
+
+// EmbedOptions controls embedding throughput.
+type EmbedOptions struct {
+	BatchSize         int // chunks per EmbedBatch call (default 10)
+	Concurrency       int // concurrent EmbedBatch calls (default 1)
+	RequestsPerMinute int // cap on EmbedBatch calls per minute; 0 means unlimited
+	// Bulk embeds a full project index through the provider's batch API
+	// when the embedder has one (see BulkEmbedder). Incremental updates are
+	// always embedded synchronously.
+	Bulk bool
+}
+
+// DefaultEmbedOptions returns the options used when none are configured.
+func DefaultEmbedOptions() EmbedOptions {
+	return EmbedOptions{
+		BatchSize:   10,
+		Concurrency: 1,
+	}
 }
 
 // NewRAGIndexer creates a new RAG indexer
 func NewRAGIndexer(embedder Embedder, vectorStore VectorStore) *RAGIndexer {
-	return &RAGIndexer{
+	r := &RAGIndexer{
 		embedder:    embedder,
 		vectorStore: vectorStore,
 		stats: &IndexStats{
@@ -28,19 +69,116 @@ func NewRAGIndexer(embedder Embedder, vectorStore VectorStore) *RAGIndexer {
 			Dimensions:     embedder.Dimension(),
 		},
 	}
+	r.SetEmbedOptions(DefaultEmbedOptions())
+	r.chunking = DefaultChunkerConfig()
+	return r
+}
+
+// SetEmbedOptions configures batch size, concurrency and rate limiting.
+// Zero values fall back to the defaults.
+func (r *RAGIndexer) SetEmbedOptions(opts EmbedOptions) {
+	defaults := DefaultEmbedOptions()
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaults.BatchSize
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaults.Concurrency
+	}
+
+	r.opts = opts
+	r.limiter = ratelimit.PerMinute(opts.RequestsPerMinute)
+	r.embedSlots = make(chan struct{}, opts.Concurrency)
+}
+
+// SetChunkerConfig sets the sizes of the chunks files are split into. Zero
+// fields fall back to the defaults. Files already indexed keep their
+// chunks until they change or the index is rebuilt.
+func (r *RAGIndexer) SetChunkerConfig(cfg ChunkerConfig) error {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return fmt.Errorf("invalid chunker config: %w", err)
+	}
+	r.chunking = cfg
+	return nil
+}
+
+// SetPrivacyPolicy applies policy to files under projectRoot when the
+// embedder is remote, or always with policy.ApplyToLocal.
+func (r *RAGIndexer) SetPrivacyPolicy(projectRoot string, policy PrivacyPolicy) {
+	r.root = projectRoot
+	if !policy.ApplyToLocal && !IsRemote(r.embedder) {
+		r.privacy = nil
+		return
+	}
+	r.privacy = NewPrivacyFilter(projectRoot, policy)
 }
 
 // IndexProject indexes all code files in a project
 func (r *RAGIndexer) IndexProject(projectPath string) error {
 	fmt.Printf("Indexing project: %s\n", projectPath)
-
-	var files []string
-	var totalChunks int
+	r.root = projectPath
+	defer metrics.ObserveSince(metrics.IndexDuration, time.Now(), "rag")
 
 	// Fresh index each run to avoid duplicates.
 	if err := r.vectorStore.Clear(); err != nil {
 		return fmt.Errorf("failed to clear vector store: %w", err)
 	}
+	if err := r.recordEmbedder(); err != nil {
+		return err
+	}
+
+	files, err := r.projectFiles(projectPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Found %d code and doc files\n", len(files))
+
+	if bulk, ok := r.embedder.(BulkEmbedder); ok && r.opts.Bulk {
+		n, err := r.indexBulk(bulk, files)
+		if err == nil {
+			r.stats.TotalFiles = len(files)
+			r.stats.TotalChunks = n
+			r.stats.LastUpdated = time.Now().Format(time.RFC3339)
+			fmt.Printf("\n✓ Indexed %d files, %d chunks\n", len(files), n)
+			return r.saveStats()
+		}
+		fmt.Printf("Warning: bulk embedding failed, embedding synchronously: %v\n", err)
+		if err := r.vectorStore.Clear(); err != nil {
+			return fmt.Errorf("failed to clear vector store: %w", err)
+		}
+		if err := r.recordEmbedder(); err != nil {
+			return err
+		}
+	}
+
+	totalChunks, err := r.indexFiles(files)
+	if err != nil {
+		return err
+	}
+
+	// Update stats
+	r.stats.TotalFiles = len(files)
+	r.stats.TotalChunks = totalChunks
+	r.stats.LastUpdated = time.Now().Format(time.RFC3339)
+
+	fmt.Printf("\n✓ Indexed %d files, %d chunks\n", len(files), totalChunks)
+
+	return r.saveStats()
+}
+
+// saveStats keeps the statistics in the vector store, for stores that can
+// hold them, so other processes report them.
+func (r *RAGIndexer) saveStats() error {
+	if store, ok := r.vectorStore.(StatsStore); ok {
+		return store.SaveIndexStats(*r.stats)
+	}
+	return nil
+}
+
+// projectFiles lists the code and doc files of a project that are not
+// ignored by .gitignore or the privacy policy.
+func (r *RAGIndexer) projectFiles(projectPath string) ([]string, error) {
+	var files []string
 
 	// Load .gitignore if it exists
 	var gitignore *ignore.GitIgnore
@@ -74,10 +212,13 @@ func (r *RAGIndexer) IndexProject(projectPath string) error {
 			return nil
 		}
 
-		// Skip non-directories that aren't code files
+		// Skip non-directories that aren't code or documentation files
 		if !d.IsDir() {
 			ext := filepath.Ext(path)
-			if !isCodeFile(ext) {
+			if !isCodeFile(ext) && !isDocFile(path) {
+				return nil
+			}
+			if r.privacy.Excluded(path) {
 				return nil
 			}
 			files = append(files, path)
@@ -87,82 +228,409 @@ func (r *RAGIndexer) IndexProject(projectPath string) error {
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to walk directory: %w", err)
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
 	}
+	return files, nil
+}
 
-	fmt.Printf("Found %d code files\n", len(files))
-
-	// Index each file
-	for i, filePath := range files {
-		if i%10 == 0 {
-			fmt.Printf("Progress: %d/%d files (%.1f%%)\n", i, len(files), float64(i)/float64(len(files))*100)
+// IndexFile indexes a single file and records its content hash.
+func (r *RAGIndexer) IndexFile(filePath string) ([]*Chunk, error) {
+	chunks, hash, err := r.prepareChunks(filePath)
+	if err != nil {
+		return nil, err
+	}
+	unseen, err := r.dedupe(collapseDuplicates(chunks))
+	if err != nil {
+		return nil, err
+	}
+	if len(unseen) > 0 {
+		if err := r.storeSync(unseen); err != nil {
+			return nil, err
 		}
+	}
+	if err := r.recordHashes(map[string]string{filePath: hash}); err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
 
-		chunks, err := r.IndexFile(filePath)
-		if err != nil {
-			fmt.Printf("Warning: failed to index %s: %v\n", filePath, err)
+// dedupe records the chunks whose content the store already holds as
+// duplicates, when it deduplicates, and returns the ones left to embed.
+func (r *RAGIndexer) dedupe(chunks []*Chunk) ([]*Chunk, error) {
+	store, ok := r.vectorStore.(DedupStore)
+	if !ok || len(chunks) == 0 {
+		return chunks, nil
+	}
+	unseen, err := store.AddDuplicates(chunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deduplicate chunks: %w", err)
+	}
+	return unseen, nil
+}
+
+// collapseDuplicates keeps the first of chunks with the same content,
+// recording where the others are as its duplicates.
+func collapseDuplicates(chunks []*Chunk) []*Chunk {
+	first := make(map[string]*Chunk, len(chunks))
+	unique := chunks[:0:0]
+	for _, chunk := range chunks {
+		if c, ok := first[chunk.ID]; ok {
+			c.addDuplicate(chunk.Location())
 			continue
 		}
+		first[chunk.ID] = chunk
+		unique = append(unique, chunk)
+	}
+	return unique
+}
 
-		totalChunks += len(chunks)
+// recordHashes saves file content hashes when the store keeps them.
+func (r *RAGIndexer) recordHashes(hashes map[string]string) error {
+	if store, ok := r.vectorStore.(FileHashStore); ok && len(hashes) > 0 {
+		return store.SetFileHashes(hashes)
 	}
+	return nil
+}
 
-	// Update stats
-	r.stats.TotalFiles = len(files)
-	r.stats.TotalChunks = totalChunks
-	r.stats.LastUpdated = time.Now().Format(time.RFC3339)
+// contentHash identifies a version of a file's content.
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
 
-	fmt.Printf("\n✓ Indexed %d files, %d chunks\n", len(files), totalChunks)
+// storeSync embeds chunks in batches, up to opts.Concurrency requests at a
+// time, and stores them.
+func (r *RAGIndexer) storeSync(chunks []*Chunk) error {
+	batches := r.batches(chunks)
+	embeddings := make([][][]float32, len(batches))
+	errs := make([]error, len(batches))
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []*Chunk) {
+			defer wg.Done()
+			embeddings[i], errs[i] = r.embedBatch(batch)
+		}(i, batch)
+	}
+	wg.Wait()
 
+	for i, batch := range batches {
+		if errs[i] != nil {
+			return errs[i]
+		}
+
+		// Store in vector store
+		if err := r.insert(batch, embeddings[i]); err != nil {
+			return fmt.Errorf("failed to store embeddings: %w", err)
+		}
+	}
 	return nil
 }
 
-// IndexFile indexes a single file
-func (r *RAGIndexer) IndexFile(filePath string) ([]*Chunk, error) {
+// prepareChunks reads and chunks a file, redacting secrets and applying the
+// privacy policy, and gives each chunk its header. It also returns the hash
+// of the content it read.
+func (r *RAGIndexer) prepareChunks(filePath string) ([]*Chunk, string, error) {
 	// Read file content
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
 	}
+	hash := contentHash(content)
 
 	// Chunk the file
-	chunker := ChunkerFactory(filePath)
+	chunker := ChunkerFactory(filePath, r.chunking)
 	chunks, err := chunker.ChunkFile(filePath, string(content))
 	if err != nil {
-		return nil, fmt.Errorf("failed to chunk file: %w", err)
+		return nil, "", fmt.Errorf("failed to chunk file: %w", err)
 	}
 
 	if len(chunks) == 0 {
-		return nil, nil
+		return nil, hash, nil
 	}
 
-	// Embed chunks in batches
-	batchSize := 10
+	// Keep credentials out of embedding requests and out of stored chunks,
+	// which are later quoted in prompts.
+	for _, chunk := range chunks {
+		var n int
+		chunk.Content, n = secretScanner.Redact(chunk.Content)
+		if n > 0 {
+			metrics.SecretsRedacted.Add(float64(n), "embedding")
+		}
+		chunk.Content = r.privacy.Clean(filePath, chunk.Content)
+	}
+	fc := parseFileContext(chunker.Language(), string(content))
+	for _, chunk := range chunks {
+		chunk.Header = chunkHeader(r.relPath(filePath), chunk, fc)
+	}
+	return chunks, hash, nil
+}
+
+// batches splits chunks into groups of opts.BatchSize.
+func (r *RAGIndexer) batches(chunks []*Chunk) [][]*Chunk {
+	batchSize := r.opts.BatchSize
+	var batches [][]*Chunk
 	for i := 0; i < len(chunks); i += batchSize {
 		end := i + batchSize
 		if end > len(chunks) {
 			end = len(chunks)
 		}
+		batches = append(batches, chunks[i:end])
+	}
+	return batches
+}
 
-		batch := chunks[i:end]
-		texts := make([]string, len(batch))
-		for j, chunk := range batch {
-			texts[j] = chunk.Content
+// indexBulk chunks every file, embeds all chunks through the provider's
+// batch API and stores them. Chunks the batch did not return are embedded
+// synchronously. It returns the number of chunks indexed.
+func (r *RAGIndexer) indexBulk(bulk BulkEmbedder, files []string) (int, error) {
+	var chunks []*Chunk
+	hashes := make(map[string]string, len(files))
+	for _, filePath := range files {
+		fileChunks, hash, err := r.prepareChunks(filePath)
+		if err != nil {
+			fmt.Printf("Warning: failed to index %s: %v\n", filePath, err)
+			continue
 		}
+		chunks = append(chunks, fileChunks...)
+		hashes[filePath] = hash
+	}
+	chunks = collapseDuplicates(chunks)
+	if err := r.storeBulk(bulk, chunks); err != nil {
+		return 0, err
+	}
+	if err := r.recordHashes(hashes); err != nil {
+		return 0, err
+	}
+	return len(chunks), nil
+}
 
-		// Generate embeddings
-		embeddings, err := r.embedder.EmbedBatch(texts)
+// storeBulk embeds chunks through the provider's batch API and stores them.
+// Chunks the batch did not return are embedded synchronously.
+func (r *RAGIndexer) storeBulk(bulk BulkEmbedder, chunks []*Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = embeddingText(chunk)
+	}
+	fmt.Printf("Submitting %d chunks to the %s batch API\n", len(chunks), bulk.Model())
+	embeddings, err := bulk.EmbedBulk(context.Background(), texts, func(s *batch.Status) {
+		fmt.Printf("  %s: %d succeeded, %d failed of %d\n", s.State, s.Succeeded, s.Failed, s.Total)
+	})
+	if err != nil {
+		metrics.EmbedderErrors.Inc(bulk.Model())
+		return err
+	}
+
+	var missing []*Chunk
+	var missingIdx []int
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			missing = append(missing, chunks[i])
+			missingIdx = append(missingIdx, i)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Printf("Embedding %d chunks missing from the batch synchronously\n", len(missing))
+		n := 0
+		for _, b := range r.batches(missing) {
+			batchEmbeddings, err := r.embedBatch(b)
+			if err != nil {
+				return err
+			}
+			for _, embedding := range batchEmbeddings {
+				embeddings[missingIdx[n]] = embedding
+				n++
+			}
+		}
+	}
+
+	for start := 0; start < len(chunks); start += r.opts.BatchSize {
+		end := min(start+r.opts.BatchSize, len(chunks))
+		if err := r.insert(chunks[start:end], embeddings[start:end]); err != nil {
+			return fmt.Errorf("failed to store embeddings: %w", err)
+		}
+	}
+	return nil
+}
+
+// embedBatch embeds one batch while holding an embedding slot and respecting the rate limit.
+func (r *RAGIndexer) embedBatch(batch []*Chunk) ([][]float32, error) {
+	r.embedSlots <- struct{}{}
+	defer func() { <-r.embedSlots }()
+
+	if err := r.limiter.Wait(context.Background()); err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, len(batch))
+	for j, chunk := range batch {
+		texts[j] = embeddingText(chunk)
+	}
+
+	// Generate embeddings
+	embeddings, err := r.embedder.EmbedBatch(texts)
+	if err != nil {
+		metrics.EmbedderErrors.Inc(r.embedder.Model())
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	return embeddings, nil
+}
+
+// RefreshFiles re-indexes the given absolute paths in place: changed files
+// are re-chunked and re-embedded, deleted files are dropped, and files that
+// are not code or docs are ignored. It returns how many files were updated
+// and removed.
+func (r *RAGIndexer) RefreshFiles(paths []string) (updated, removed int, err error) {
+	if err := r.CheckEmbedder(); err != nil {
+		return 0, 0, err
+	}
+	for _, path := range paths {
+		ext := filepath.Ext(path)
+		if !isCodeFile(ext) && !isDocFile(path) {
+			continue
+		}
+		if err := r.vectorStore.Delete(path); err != nil {
+			return updated, removed, err
+		}
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) || r.privacy.Excluded(path) {
+			removed++
+			continue
+		}
+		if _, err := r.IndexFile(path); err != nil {
+			return updated, removed, fmt.Errorf("failed to index %s: %w", path, err)
+		}
+		updated++
+	}
+	if updated+removed > 0 {
+		r.Stats()
+		r.stats.LastUpdated = time.Now().Format(time.RFC3339)
+		if err := r.saveStats(); err != nil {
+			return updated, removed, err
+		}
+	}
+	return updated, removed, nil
+}
+
+// UpdateStats counts the files an Update looked at and the chunks it
+// embedded.
+type UpdateStats struct {
+	Added     int
+	Modified  int
+	Removed   int
+	Unchanged int
+	Chunks    int
+}
+
+func (s *UpdateStats) add(o UpdateStats) {
+	s.Added += o.Added
+	s.Modified += o.Modified
+	s.Removed += o.Removed
+	s.Unchanged += o.Unchanged
+	s.Chunks += o.Chunks
+}
+
+// Update brings the index of projectPath up to date without rebuilding
+// it: new files and files whose content hash changed are re-chunked and
+// re-embedded, files that were deleted or are now ignored are dropped, and
+// the rest is left alone. An index built before hashes were recorded has
+// every file re-embedded once.
+func (r *RAGIndexer) Update(projectPath string) (UpdateStats, error) {
+	var stats UpdateStats
+	r.root = projectPath
+	store, ok := r.vectorStore.(FileHashStore)
+	if !ok {
+		return stats, fmt.Errorf("vector store does not record file hashes; rebuild the index instead")
+	}
+	if err := r.CheckEmbedder(); err != nil {
+		return stats, err
+	}
+	if r.vectorStore.Count() == 0 {
+		if err := r.recordEmbedder(); err != nil {
+			return stats, err
+		}
+	}
+
+	known, err := store.FileHashes()
+	if err != nil {
+		return stats, err
+	}
+	indexed := make(map[string]bool, len(known))
+	for path := range known {
+		indexed[path] = true
+	}
+	if src, ok := r.vectorStore.(ChunkSource); ok && len(known) == 0 {
+		chunks, err := src.Chunks()
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+			return stats, err
+		}
+		for _, chunk := range chunks {
+			indexed[chunk.FilePath] = true
 		}
+	}
 
-		// Store in vector store
-		if err := r.vectorStore.InsertBatch(batch, embeddings); err != nil {
-			return nil, fmt.Errorf("failed to store embeddings: %w", err)
+	files, err := r.projectFiles(projectPath)
+	if err != nil {
+		return stats, err
+	}
+	current := make(map[string]bool, len(files))
+	var changed []string
+	for _, path := range files {
+		current[path] = true
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("Warning: failed to read %s: %v\n", path, err)
+			continue
+		}
+		if hash, ok := known[path]; ok && hash == contentHash(content) {
+			stats.Unchanged++
+			continue
+		}
+		if err := r.vectorStore.Delete(path); err != nil {
+			return stats, err
+		}
+		if indexed[path] {
+			stats.Modified++
+		} else {
+			stats.Added++
+		}
+		changed = append(changed, path)
+	}
+	if len(changed) > 0 {
+		if stats.Chunks, err = r.indexFiles(changed); err != nil {
+			return stats, err
 		}
 	}
 
-	return chunks, nil
+	for path := range indexed {
+		if current[path] {
+			continue
+		}
+		if err := r.vectorStore.Delete(path); err != nil {
+			return stats, err
+		}
+		stats.Removed++
+	}
+
+	r.Stats()
+	r.stats.TotalFiles = len(files)
+	if stats.Added+stats.Modified+stats.Removed > 0 {
+		r.stats.LastUpdated = time.Now().Format(time.RFC3339)
+	}
+	return stats, r.saveStats()
+}
+
+// insert stores chunks with their embeddings, recording the model that
+// embedded them.
+func (r *RAGIndexer) insert(chunks []*Chunk, embeddings [][]float32) error {
+	model := r.embedder.Model()
+	for _, chunk := range chunks {
+		chunk.EmbeddingModel = model
+	}
+	return r.vectorStore.InsertBatch(chunks, embeddings)
 }
 
 // RemoveFile removes a file from the index
@@ -170,16 +638,42 @@ func (r *RAGIndexer) RemoveFile(filePath string) error {
 	return r.vectorStore.Delete(filePath)
 }
 
-// Search performs semantic search
-func (r *RAGIndexer) Search(query string, topK int) ([]*SearchResult, error) {
+// RemoveWhere removes every file matching pattern from the index, such as
+// a package dropped in a refactor, and returns how many it removed. Build
+// pattern from a project-relative path with PathGlob.
+func (r *RAGIndexer) RemoveWhere(pattern string) (int, error) {
+	removed, err := r.vectorStore.DeleteWhere(pattern)
+	if err != nil {
+		return removed, fmt.Errorf("remove %s: %w", pattern, err)
+	}
+	if removed > 0 {
+		r.Stats()
+		r.stats.TotalFiles = max(r.stats.TotalFiles-removed, 0)
+		r.stats.LastUpdated = time.Now().Format(time.RFC3339)
+		if err := r.saveStats(); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// Search performs semantic search over the chunks passing filter.
+func (r *RAGIndexer) Search(query string, topK int, filter SearchFilter) ([]*SearchResult, error) {
+	defer metrics.ObserveSince(metrics.SearchLatency, time.Now(), "semantic")
+
+	if err := r.CheckEmbedder(); err != nil {
+		return nil, err
+	}
+
 	// Embed the query
 	queryEmbedding, err := r.embedder.Embed(query)
 	if err != nil {
+		metrics.EmbedderErrors.Inc(r.embedder.Model())
 		return nil, fmt.Errorf("failed to embed query: %w", err)
 	}
 
 	// Search vector store
-	results, err := r.vectorStore.Search(queryEmbedding, topK)
+	results, err := r.vectorStore.Search(queryEmbedding, topK, filter)
 	if err != nil {
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
@@ -187,9 +681,33 @@ func (r *RAGIndexer) Search(query string, topK int) ([]*SearchResult, error) {
 	return results, nil
 }
 
-// Stats returns indexing statistics
+// KeywordSearch ranks chunks by BM25 over their contents, for exact
+// identifiers and error strings that semantic search misses. Its scores
+// are not comparable with Search's; fuse the two by rank.
+func (r *RAGIndexer) KeywordSearch(query string, topK int) ([]*SearchResult, error) {
+	defer metrics.ObserveSince(metrics.SearchLatency, time.Now(), "keyword")
+
+	store, ok := r.vectorStore.(KeywordSearcher)
+	if !ok {
+		return nil, fmt.Errorf("vector store does not support keyword search")
+	}
+	results, err := store.KeywordSearch(query, topK)
+	if err != nil {
+		return nil, fmt.Errorf("keyword search failed: %w", err)
+	}
+	return results, nil
+}
+
+// Stats returns the index statistics, reading the file count and last
+// update saved by whichever process last changed the index.
 func (r *RAGIndexer) Stats() *IndexStats {
 	r.stats.TotalChunks = r.vectorStore.Count()
+	if store, ok := r.vectorStore.(StatsStore); ok {
+		if saved, err := store.IndexStats(); err == nil && saved.LastUpdated != "" {
+			r.stats.TotalFiles = saved.TotalFiles
+			r.stats.LastUpdated = saved.LastUpdated
+		}
+	}
 	return r.stats
 }
 
@@ -198,6 +716,34 @@ func (r *RAGIndexer) Clear() error {
 	return r.vectorStore.Clear()
 }
 
+// SummaryStore returns the vector store's summary storage, or an error if
+// the store cannot hold summaries.
+func (r *RAGIndexer) SummaryStore() (SummaryStore, error) {
+	store, ok := r.vectorStore.(SummaryStore)
+	if !ok {
+		return nil, fmt.Errorf("vector store does not support chunk summaries")
+	}
+	return store, nil
+}
+
+// EmbeddingModels counts the indexed chunks by the model that embedded
+// them, or returns an error if the store does not record it.
+func (r *RAGIndexer) EmbeddingModels() (map[string]int, error) {
+	store, ok := r.vectorStore.(ChunkModelStore)
+	if !ok {
+		return nil, fmt.Errorf("vector store does not record chunk models")
+	}
+	return store.EmbeddingModels()
+}
+
+// Close releases resources held by the vector store, if it holds any.
+func (r *RAGIndexer) Close() error {
+	if closer, ok := r.vectorStore.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 // Helper functions
 
 func isCodeFile(ext string) bool {
@@ -208,19 +754,48 @@ func isCodeFile(ext string) bool {
 		".ts":    true,
 		".jsx":   true,
 		".tsx":   true,
+		".mjs":   true,
+		".cjs":   true,
+		".mts":   true,
+		".cts":   true,
 		".java":  true,
 		".c":     true,
 		".cpp":   true,
+		".cc":    true,
+		".cxx":   true,
 		".h":     true,
 		".hpp":   true,
+		".hh":    true,
+		".hxx":   true,
 		".rs":    true,
 		".rb":    true,
 		".php":   true,
 		".cs":    true,
 		".swift": true,
 		".kt":    true,
+		".kts":   true,
 		".scala": true,
+		".sql":   true,
 	}
 
 	return codeExts[strings.ToLower(ext)]
 }
+
+// isDocFile reports whether path is documentation worth embedding
+// (READMEs, design docs, plain text notes), so questions can be answered
+// from prose too. Plain text files that are really configuration or data
+// (requirements.txt, CMakeLists.txt) are left out.
+func isDocFile(path string) bool {
+	if strings.EqualFold(filepath.Ext(path), ".txt") {
+		name := strings.ToLower(filepath.Base(path))
+		return !strings.HasPrefix(name, "requirements") && !nonProseText[name]
+	}
+	return docs.IsDocFile(path)
+}
+
+var nonProseText = map[string]bool{
+	"cmakelists.txt":  true,
+	"robots.txt":      true,
+	"constraints.txt": true,
+	"license.txt":     true,
+}
@@ -0,0 +1,636 @@
+package rag
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// JSChunker splits JavaScript and TypeScript into functions, classes, React
+// components, exported consts and TypeScript types. Classes too large for
+// one chunk are split into their methods. Statements between declarations,
+// such as route registrations, are grouped into blocks; imports are left
+// out.
+type JSChunker struct {
+	language string
+	cfg      ChunkerConfig
+}
+
+// NewJSChunker creates a chunker labelling its chunks with language,
+// "javascript" or "typescript".
+func NewJSChunker(language string, cfg ChunkerConfig) *JSChunker {
+	return &JSChunker{language: language, cfg: cfg}
+}
+
+func (c *JSChunker) Language() string {
+	return c.language
+}
+
+var (
+	jsDecorators = regexp.MustCompile(`^(?:@[\w$.]+\s*(?:\([^)]*\))?\s*)+`)
+	jsFunction   = regexp.MustCompile(`^(export\s+)?(default\s+)?(?:declare\s+)?(?:async\s+)?function\b\s*\*?\s*([A-Za-z_$][\w$]*)?`)
+	jsClass      = regexp.MustCompile(`^(export\s+)?(default\s+)?(?:declare\s+)?(?:abstract\s+)?class\b\s*([A-Za-z_$][\w$]*)?`)
+	jsVariable   = regexp.MustCompile(`^(export\s+)?(?:declare\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)`)
+	jsTypeDecl   = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(interface|type|enum|const\s+enum|namespace|module)\s+([A-Za-z_$][\w$.]*)`)
+	jsExports    = regexp.MustCompile(`^(?:module\.)?exports\.([A-Za-z_$][\w$]*)\s*=`)
+	jsImport     = regexp.MustCompile(`^(?:import\b|export\s*(?:\*|\{|type\s*\{))`)
+	// jsFunctionValue matches what follows a declared name when the value
+	// is a function: "= async (a, b) =>", ": FC<Props> = function",
+	// "= memo(".
+	jsFunctionValue = regexp.MustCompile(`^\s*(?::[^=]*)?=\s*(?:async\s+)?(?:function\b|(?:<[^>]*>\s*)?(?:\([^)]*\)|[A-Za-z_$][\w$]*)\s*(?::[^=]*)?=>|(?:React\.)?(?:memo|forwardRef)\s*\()`)
+	jsMethod        = regexp.MustCompile(`^(?:(?:static|async|get|set|public|private|protected|readonly|override|abstract|declare)\s+)*\*?\s*(#?[A-Za-z_$][\w$]*)\s*\??\s*(?:<[^>]*>\s*)?\(`)
+	jsArrowField    = regexp.MustCompile(`^(?:(?:static|public|private|protected|readonly|override)\s+)*(#?[A-Za-z_$][\w$]*)\s*(?::[^=]*)?=\s*(?:async\s+)?(?:\([^)]*\)|[A-Za-z_$][\w$]*)\s*(?::[^=]*)?=>`)
+)
+
+func (c *JSChunker) ChunkFile(filePath string, content string) ([]*Chunk, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	jsx := ext != ".ts" && ext != ".mts" && ext != ".cts"
+	masked, ok := maskJS(content, jsx)
+	if !ok {
+		return c.cfg.genericSlidingChunks(filePath, content, c.language), nil
+	}
+	f := &jsFile{
+		path:     filePath,
+		src:      content,
+		lines:    strings.Split(content, "\n"),
+		masked:   masked,
+		language: c.language,
+		cfg:      c.cfg,
+		jsx:      ext == ".jsx" || ext == ".tsx",
+	}
+	for i, ch := range content {
+		if ch == '\n' {
+			f.lineStarts = append(f.lineStarts, i+1)
+		}
+	}
+
+	var other []jsSpan // pending statements that declare nothing
+	flush := func() {
+		if len(other) > 0 {
+			f.add(other[0].start, other[len(other)-1].end, "block", "")
+			other = nil
+		}
+	}
+	for _, s := range jsStatements(masked, 0, len(masked)) {
+		chunkType, name := f.classify(s)
+		switch chunkType {
+		case "":
+			other = append(other, s)
+		case "import":
+			flush()
+		case "class":
+			flush()
+			f.addClass(s, name)
+		default:
+			flush()
+			f.add(s.start, s.end, chunkType, name)
+		}
+	}
+	flush()
+
+	if len(f.chunks) == 0 {
+		return c.cfg.genericSlidingChunks(filePath, content, c.language), nil
+	}
+	return f.chunks, nil
+}
+
+// jsFile holds the state of chunking one file.
+type jsFile struct {
+	path       string
+	src        string
+	lines      []string
+	masked     string // src with strings, comments, regexps and JSX blanked
+	language   string
+	cfg        ChunkerConfig
+	jsx        bool // a .jsx or .tsx file
+	lineStarts []int
+	chunks     []*Chunk
+}
+
+// line returns the 1-based line of the byte at offset.
+func (f *jsFile) line(offset int) int {
+	lo, hi := 0, len(f.lineStarts)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if f.lineStarts[mid] <= offset {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo + 1
+}
+
+// classify returns the chunk type and name of a top-level statement: ""
+// for one that declares nothing, or "import" for imports and re-exports.
+func (f *jsFile) classify(s jsSpan) (string, string) {
+	text := f.masked[s.start:s.end]
+	text = text[len(jsDecorators.FindString(text)):]
+	if jsImport.MatchString(text) {
+		return "import", ""
+	}
+	if m := jsFunction.FindStringSubmatch(text); m != nil {
+		return f.functionType(m[3], s), nameOrDefault(m[3])
+	}
+	if m := jsClass.FindStringSubmatch(text); m != nil {
+		return "class", nameOrDefault(m[3])
+	}
+	if m := jsTypeDecl.FindStringSubmatch(text); m != nil {
+		kind := strings.Fields(m[1])
+		switch kind[len(kind)-1] {
+		case "module":
+			return "namespace", m[2]
+		default:
+			return kind[len(kind)-1], m[2]
+		}
+	}
+	if m := jsVariable.FindStringSubmatchIndex(text); m != nil {
+		name := text[m[4]:m[5]]
+		if jsFunctionValue.MatchString(text[m[1]:]) {
+			return f.functionType(name, s), name
+		}
+		if m[2] >= 0 {
+			return "const", name
+		}
+		return "", ""
+	}
+	if m := jsExports.FindStringSubmatchIndex(text); m != nil {
+		if jsFunctionValue.MatchString(text[m[3]:]) {
+			return "function", text[m[2]:m[3]]
+		}
+		return "", ""
+	}
+	if strings.HasPrefix(text, "export default") {
+		return "const", "default"
+	}
+	return "", ""
+}
+
+// functionType reports a function named like a component that renders JSX
+// as a React component.
+func (f *jsFile) functionType(name string, s jsSpan) string {
+	if name == "" || !unicode.IsUpper(rune(name[0])) {
+		return "function"
+	}
+	body := f.src[s.start:s.end]
+	if f.jsx || strings.Contains(body, "/>") || strings.Contains(body, "</") || strings.Contains(body, "createElement(") {
+		return "component"
+	}
+	return "function"
+}
+
+func nameOrDefault(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// addClass adds a class as one chunk, or when it is too large, a chunk per
+// method and chunks for the fields between them.
+func (f *jsFile) addClass(s jsSpan, name string) {
+	closeBrace := strings.LastIndexByte(f.masked[s.start:s.end], '}')
+	if s.end-s.start <= f.cfg.MaxChunkSize || closeBrace < 0 {
+		f.add(s.start, s.end, "class", name)
+		return
+	}
+	closeBrace += s.start
+	openBrace := matchingOpen(f.masked, closeBrace)
+	if openBrace < s.start {
+		f.add(s.start, s.end, "class", name)
+		return
+	}
+
+	fieldsStart := s.start // the class line goes with the first fields
+	fieldsEnd := -1
+	for _, m := range jsStatements(f.masked, openBrace+1, closeBrace) {
+		text := f.masked[m.start:m.end]
+		text = text[len(jsDecorators.FindString(text)):]
+		match := jsMethod.FindStringSubmatch(text)
+		if match == nil {
+			match = jsArrowField.FindStringSubmatch(text)
+		}
+		if match == nil {
+			if fieldsStart < 0 {
+				fieldsStart = m.start
+			}
+			fieldsEnd = m.end
+			continue
+		}
+		if fieldsStart >= 0 && fieldsEnd >= 0 {
+			f.add(fieldsStart, fieldsEnd, "class", name)
+		}
+		fieldsStart, fieldsEnd = -1, -1
+		f.add(m.start, m.end, "method", name+"."+match[1])
+	}
+	if fieldsStart >= 0 && fieldsEnd >= 0 {
+		f.add(fieldsStart, fieldsEnd, "class", name)
+	}
+}
+
+// add chunks the lines from start to end, with the comments right above
+// them.
+func (f *jsFile) add(start, end int, chunkType, name string) {
+	first, last := f.line(start), f.line(max(end-1, start))
+	for first > 1 {
+		prev := strings.TrimSpace(f.lines[first-2])
+		if !strings.HasPrefix(prev, "//") && !strings.HasPrefix(prev, "/*") && !strings.HasPrefix(prev, "*") {
+			break
+		}
+		first--
+	}
+	text := strings.Join(f.lines[first-1:last], "\n")
+	if len(strings.TrimSpace(text)) < 20 {
+		return
+	}
+	f.chunks = append(f.chunks, f.cfg.splitLargeChunk(f.path, text, chunkType, name, f.language, first, last)...)
+}
+
+// jsSpan is a statement, from its first character to just past its last.
+type jsSpan struct {
+	start, end int
+}
+
+// jsStatements splits masked source between lo and hi into statements.
+// A statement ends at a semicolon, at a closing brace ending its line, or
+// at a line break where automatic semicolon insertion would end it.
+// Decorators are kept with the declaration they decorate.
+func jsStatements(masked string, lo, hi int) []jsSpan {
+	var spans []jsSpan
+	decorated := -1 // start of pending decorators
+	i := lo
+	for {
+		for i < hi && isJSSpace(masked[i]) {
+			i++
+		}
+		if i >= hi {
+			break
+		}
+		start, depth, end := i, 0, -1
+		for ; i < hi && end < 0; i++ {
+			switch masked[i] {
+			case '{', '(', '[':
+				depth++
+			case '}', ')', ']':
+				if depth > 0 {
+					depth--
+				}
+				if masked[i] == '}' && depth == 0 && blockEndsStatement(masked, i+1, hi) {
+					end = i + 1
+				}
+			case ';':
+				if depth == 0 {
+					end = i + 1
+				}
+			case '\n':
+				if depth == 0 && lineEndsStatement(masked, start, i, hi) {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			end = hi
+		}
+		i = end
+		if masked[start] == '@' && jsDecorators.FindString(masked[start:end]) == strings.TrimRight(masked[start:end], " \t\n\r;") {
+			if decorated < 0 {
+				decorated = start
+			}
+			continue
+		}
+		if decorated >= 0 {
+			start, decorated = decorated, -1
+		}
+		spans = append(spans, jsSpan{start: start, end: end})
+	}
+	return spans
+}
+
+// blockEndsStatement reports whether a closing brace before i ends its
+// statement: nothing but a line break follows it, and the next line does
+// not continue the expression or statement.
+func blockEndsStatement(masked string, i, hi int) bool {
+	for i < hi && (masked[i] == ' ' || masked[i] == '\t' || masked[i] == '\r') {
+		i++
+	}
+	if i < hi && masked[i] != '\n' {
+		return false
+	}
+	return !nextLineContinues(masked, i, hi)
+}
+
+// lineEndsStatement reports whether the line break at i ends the statement
+// that began at start.
+func lineEndsStatement(masked string, start, i, hi int) bool {
+	last := strings.TrimRight(masked[start:i], " \t\r\n")
+	if last == "" || strings.ContainsRune(",=([{+-*/%&|^!~?:<>.", rune(last[len(last)-1])) {
+		return false
+	}
+	for _, word := range []string{"extends", "implements", "return", "export", "default", "async", "new", "typeof", "in", "of", "as"} {
+		if strings.HasSuffix(last, word) && (len(last) == len(word) || !isJSIdent(last[len(last)-len(word)-1])) {
+			return false
+		}
+	}
+	return !nextLineContinues(masked, i, hi)
+}
+
+// nextLineContinues reports whether the code after the line break at i
+// continues the statement before it.
+func nextLineContinues(masked string, i, hi int) bool {
+	for i < hi && isJSSpace(masked[i]) {
+		i++
+	}
+	if i >= hi {
+		return false
+	}
+	if strings.ContainsRune(".?:,)]=+-*/%&|^<>([`{", rune(masked[i])) {
+		return true
+	}
+	rest := masked[i:hi]
+	for _, word := range []string{"else", "catch", "finally", "extends", "implements", "as", "instanceof"} {
+		if strings.HasPrefix(rest, word) && (len(rest) == len(word) || !isJSIdent(rest[len(word)])) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchingOpen returns the offset of the '{' matching the '}' at close.
+func matchingOpen(masked string, close int) int {
+	depth := 0
+	for i := close; i >= 0; i-- {
+		switch masked[i] {
+		case '}':
+			depth++
+		case '{':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isJSSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isJSIdent(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// maskJS returns src with comments, string, template and regexp literals
+// and, when jsx is set, JSX elements replaced by spaces, keeping line
+// breaks, so braces and semicolons left in it are all code. It reports
+// false if a literal or element is not terminated.
+func maskJS(src string, jsx bool) (string, bool) {
+	m := &jsMasker{src: src, out: []byte(src), jsx: jsx}
+	end, ok := m.code(0, false)
+	return string(m.out), ok && end >= len(src)
+}
+
+type jsMasker struct {
+	src  string
+	out  []byte
+	jsx  bool
+	prev byte   // last significant character, 'a' after a word, 0 at the start
+	word string // the last word, when prev is 'a'
+}
+
+// blank replaces src[from:to] by spaces in the output.
+func (m *jsMasker) blank(from, to int) {
+	for i := from; i < to && i < len(m.out); i++ {
+		if m.out[i] != '\n' {
+			m.out[i] = ' '
+		}
+	}
+}
+
+// expressionStart reports whether a value, rather than an operator, may
+// come next, so '/' starts a regexp and '<' an element.
+func (m *jsMasker) expressionStart() bool {
+	if m.prev == 'a' {
+		switch m.word {
+		case "return", "typeof", "case", "do", "else", "in", "of", "new", "delete", "void", "throw", "yield", "await":
+			return true
+		}
+		return false
+	}
+	return m.prev == 0 || strings.IndexByte("(,=:[!&|?{};+-*%<>~^", m.prev) >= 0
+}
+
+// code scans code from i. When nested, it stops at the '}' closing the
+// expression it is in and returns its offset.
+func (m *jsMasker) code(i int, nested bool) (int, bool) {
+	src := m.src
+	depth := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			m.blank(i, i+end)
+			i += end
+			continue
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return i, false
+			}
+			m.blank(i, i+2+end+2)
+			i += 2 + end + 2
+			continue
+		case c == '"' || c == '\'':
+			end := skipJSString(src, i)
+			m.blank(i, end)
+			i, m.prev = end, '"'
+			continue
+		case c == '`':
+			end, ok := m.template(i + 1)
+			if !ok {
+				return i, false
+			}
+			m.blank(i, end)
+			i, m.prev = end, '"'
+			continue
+		case c == '/' && m.expressionStart():
+			if end, ok := skipJSRegexp(src, i); ok {
+				m.blank(i, end)
+				i, m.prev = end, '"'
+				continue
+			}
+		case c == '<' && m.jsx && m.expressionStart() && i+1 < len(src) && (src[i+1] == '>' || unicode.IsLetter(rune(src[i+1]))):
+			// A generic arrow function in a .tsx file, <T,>(x: T) => x,
+			// is not an element; '<' is then left as code.
+			if end, ok := m.element(i); ok {
+				m.blank(i, end)
+				i, m.prev = end, ')'
+				continue
+			}
+		case c == '{':
+			depth++
+		case c == '}':
+			if nested && depth == 0 {
+				return i, true
+			}
+			depth--
+		case isJSIdent(c):
+			start := i
+			for i < len(src) && isJSIdent(src[i]) {
+				i++
+			}
+			m.prev, m.word = 'a', src[start:i]
+			continue
+		}
+		if !isJSSpace(c) {
+			m.prev = c
+		}
+		i++
+	}
+	return i, !nested
+}
+
+// nested scans the expression of a template or JSX attribute from i and
+// returns the offset of its closing '}'.
+func (m *jsMasker) nested(i int) (int, bool) {
+	prev, word := m.prev, m.word
+	m.prev = '{'
+	end, ok := m.code(i, true)
+	m.prev, m.word = prev, word
+	return end, ok
+}
+
+// template skips a template literal from just after its opening backquote.
+func (m *jsMasker) template(i int) (int, bool) {
+	src := m.src
+	for i < len(src) {
+		switch {
+		case src[i] == '\\':
+			i += 2
+		case src[i] == '`':
+			return i + 1, true
+		case src[i] == '$' && i+1 < len(src) && src[i+1] == '{':
+			end, ok := m.nested(i + 2)
+			if !ok {
+				return i, false
+			}
+			i = end + 1
+		default:
+			i++
+		}
+	}
+	return i, false
+}
+
+// element skips a JSX element or fragment starting at its '<'.
+func (m *jsMasker) element(i int) (int, bool) {
+	src := m.src
+	i++
+	if i < len(src) && src[i] == '>' {
+		return m.children(i + 1)
+	}
+	for i < len(src) {
+		switch c := src[i]; {
+		case c == '/' && i+1 < len(src) && src[i+1] == '>':
+			return i + 2, true
+		case c == '>':
+			return m.children(i + 1)
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(src[i+1:], c)
+			if end < 0 {
+				return i, false
+			}
+			i += end + 2
+		case c == '{':
+			end, ok := m.nested(i + 1)
+			if !ok {
+				return i, false
+			}
+			i = end + 1
+		default:
+			i++
+		}
+	}
+	return i, false
+}
+
+// children skips the children of a JSX element and its closing tag.
+func (m *jsMasker) children(i int) (int, bool) {
+	src := m.src
+	for i < len(src) {
+		switch {
+		case src[i] == '<' && i+1 < len(src) && src[i+1] == '/':
+			end := strings.IndexByte(src[i:], '>')
+			if end < 0 {
+				return i, false
+			}
+			return i + end + 1, true
+		case src[i] == '<':
+			end, ok := m.element(i)
+			if !ok {
+				return i, false
+			}
+			i = end
+		case src[i] == '{':
+			end, ok := m.nested(i + 1)
+			if !ok {
+				return i, false
+			}
+			i = end + 1
+		default:
+			i++
+		}
+	}
+	return i, false
+}
+
+// skipJSString returns the offset just past the string literal at i, or
+// of the line break ending an unterminated one.
+func skipJSString(src string, i int) int {
+	quote := src[i]
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		case '\n':
+			return j
+		}
+	}
+	return len(src)
+}
+
+// skipJSRegexp returns the offset just past the regexp literal at i, with
+// its flags. It reports false if the line ends first, when the slash was
+// a division after all.
+func skipJSRegexp(src string, i int) (int, bool) {
+	inClass := false
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if inClass {
+				continue
+			}
+			j++
+			for j < len(src) && isJSIdent(src[j]) {
+				j++
+			}
+			return j, true
+		case '\n':
+			return i, false
+		}
+	}
+	return i, false
+}
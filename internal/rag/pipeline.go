@@ -0,0 +1,261 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// indexFiles chunks and embeds files in a pipeline: GOMAXPROCS goroutines
+// read and chunk files, their chunks are packed into batches of
+// opts.BatchSize across file boundaries, opts.Concurrency embedders embed
+// the batches, and a single writer stores them. Each stage waits once a
+// few batches queue up behind it, so memory stays bounded however large
+// the project. A file's hash is recorded once all its chunks are stored;
+// files that fail to read or embed are reported and skipped, so the next
+// update retries them. A failure to store stops the pipeline, so no more
+// files are embedded for nothing. It returns the number of chunks stored.
+func (r *RAGIndexer) indexFiles(files []string) (int, error) {
+	type chunkedFile struct {
+		path, hash string
+		chunks     []*Chunk
+	}
+	type embedded struct {
+		batch      []*Chunk
+		embeddings [][]float32
+		err        error
+	}
+	workers := min(runtime.GOMAXPROCS(0), max(len(files), 1))
+	paths := make(chan string)
+	chunked := make(chan chunkedFile, workers)
+	batches := make(chan []*Chunk, r.opts.Concurrency)
+	results := make(chan embedded, r.opts.Concurrency)
+	tracker := newFileTracker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		defer close(paths)
+		for _, path := range files {
+			select {
+			case paths <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var chunkers sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		chunkers.Add(1)
+		go func() {
+			defer chunkers.Done()
+			for path := range paths {
+				chunks, hash, err := r.prepareChunks(path)
+				if err != nil {
+					fmt.Printf("Warning: failed to index %s: %v\n", path, err)
+					tracker.skip()
+					continue
+				}
+				select {
+				case chunked <- chunkedFile{path: path, hash: hash, chunks: chunks}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		chunkers.Wait()
+		close(chunked)
+	}()
+
+	go func() {
+		defer close(batches)
+		var batch []*Chunk
+		for f := range chunked {
+			chunks, err := r.dedupe(collapseDuplicates(f.chunks))
+			if err != nil {
+				fmt.Printf("Warning: %v; embedding every chunk of %s\n", err, f.path)
+				chunks = f.chunks
+			}
+			tracker.add(f.path, f.hash, len(chunks))
+			for _, chunk := range chunks {
+				batch = append(batch, chunk)
+				if len(batch) == r.opts.BatchSize {
+					select {
+					case batches <- batch:
+					case <-ctx.Done():
+						return
+					}
+					batch = nil
+				}
+			}
+		}
+		if len(batch) > 0 {
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	var embedders sync.WaitGroup
+	for i := 0; i < r.opts.Concurrency; i++ {
+		embedders.Add(1)
+		go func() {
+			defer embedders.Done()
+			for batch := range batches {
+				if ctx.Err() != nil {
+					continue // stopped: don't pay for embeddings that won't be stored
+				}
+				embeddings, err := r.embedBatch(batch)
+				select {
+				case results <- embedded{batch: batch, embeddings: embeddings, err: err}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		embedders.Wait()
+		close(results)
+	}()
+
+	stored := 0
+	var storeErr error
+	for res := range results {
+		if storeErr != nil {
+			continue // drain so the other stages can finish
+		}
+		if res.err != nil {
+			fmt.Printf("Warning: failed to embed %d chunks of %s: %v\n", len(res.batch), describeFiles(res.batch), res.err)
+			tracker.fail(res.batch)
+		} else if err := r.insert(res.batch, res.embeddings); err != nil {
+			storeErr = fmt.Errorf("failed to store embeddings: %w", err)
+			cancel()
+			continue
+		} else {
+			stored += len(res.batch)
+			tracker.stored(res.batch)
+		}
+		if err := r.recordHashes(tracker.ready()); err != nil {
+			storeErr = err
+			cancel()
+		}
+		if done, ok := tracker.progress(10); ok {
+			fmt.Printf("Progress: %d/%d files (%.1f%%)\n", done, len(files), float64(done)/float64(len(files))*100)
+		}
+	}
+	if storeErr != nil {
+		return stored, storeErr
+	}
+	return stored, r.recordHashes(tracker.ready())
+}
+
+// describeFiles names the files chunks come from, for warnings.
+func describeFiles(chunks []*Chunk) string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, chunk := range chunks {
+		if !seen[chunk.FilePath] {
+			seen[chunk.FilePath] = true
+			files = append(files, chunk.FilePath)
+		}
+	}
+	if len(files) > 3 {
+		return fmt.Sprintf("%s, %s and %d more files", files[0], files[1], len(files)-2)
+	}
+	return fmt.Sprint(files)
+}
+
+// fileTracker follows each file's chunks through the pipeline, so a
+// file's hash is only recorded once all of them are stored.
+type fileTracker struct {
+	mu       sync.Mutex
+	pending  map[string]int
+	hashes   map[string]string
+	failed   map[string]bool
+	done     map[string]string // finished files whose hashes are not yet saved
+	finished int
+	reported int
+}
+
+func newFileTracker() *fileTracker {
+	return &fileTracker{
+		pending: make(map[string]int),
+		hashes:  make(map[string]string),
+		failed:  make(map[string]bool),
+		done:    make(map[string]string),
+	}
+}
+
+func (t *fileTracker) add(path, hash string, chunks int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hashes[path] = hash
+	t.pending[path] = chunks
+	if chunks == 0 {
+		t.finish(path)
+	}
+}
+
+// skip counts a file that never entered the pipeline.
+func (t *fileTracker) skip() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.finished++
+}
+
+func (t *fileTracker) stored(batch []*Chunk) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, chunk := range batch {
+		t.pending[chunk.FilePath]--
+		if t.pending[chunk.FilePath] == 0 {
+			t.finish(chunk.FilePath)
+		}
+	}
+}
+
+func (t *fileTracker) fail(batch []*Chunk) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, chunk := range batch {
+		t.failed[chunk.FilePath] = true
+		t.pending[chunk.FilePath]--
+		if t.pending[chunk.FilePath] == 0 {
+			t.finish(chunk.FilePath)
+		}
+	}
+}
+
+func (t *fileTracker) finish(path string) {
+	t.finished++
+	if !t.failed[path] {
+		t.done[path] = t.hashes[path]
+	}
+	delete(t.pending, path)
+}
+
+// ready returns the hashes of the files finished since the last call.
+func (t *fileTracker) ready() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	done := t.done
+	t.done = make(map[string]string)
+	return done
+}
+
+// progress returns the number of finished files each time it has grown
+// by at least every.
+func (t *fileTracker) progress(every int) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished-t.reported < every {
+		return 0, false
+	}
+	t.reported = t.finished
+	return t.finished, true
+}
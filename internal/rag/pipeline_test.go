@@ -0,0 +1,63 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// countingEmbedder counts the batches it is asked to embed.
+type countingEmbedder struct {
+	*MockEmbedder
+	batches atomic.Int32
+}
+
+func (e *countingEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	e.batches.Add(1)
+	return e.MockEmbedder.EmbedBatch(texts)
+}
+
+// failingStore refuses every insert.
+type failingStore struct {
+	*MemoryVectorStore
+}
+
+func (s failingStore) InsertBatch([]*Chunk, [][]float32) error {
+	return errors.New("disk full")
+}
+
+func TestIndexFilesStopsEmbeddingAfterStoreError(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for i := 0; i < 200; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("f%03d.go", i))
+		src := fmt.Sprintf("package p\n\nfunc F%d() int {\n\treturn %d\n}\n", i, i)
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	store, err := NewMemoryVectorStore("", 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedder := &countingEmbedder{MockEmbedder: NewMockEmbedder(8)}
+	r := NewRAGIndexer(embedder, failingStore{store})
+	r.SetEmbedOptions(EmbedOptions{BatchSize: 1, Concurrency: 1})
+
+	stored, err := r.indexFiles(files)
+	if err == nil {
+		t.Fatal("indexFiles succeeded with a failing store")
+	}
+	if stored != 0 {
+		t.Errorf("stored = %d, want 0", stored)
+	}
+	// The embedder may have a few batches in flight when the store fails,
+	// but must not go on to embed the rest of the project.
+	if n := embedder.batches.Load(); n > 10 {
+		t.Errorf("embedded %d batches after the store failed on the first", n)
+	}
+}
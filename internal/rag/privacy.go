@@ -0,0 +1,185 @@
+package rag
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// PrivacyPolicy limits what is sent to embedders that run off the machine.
+// It applies only to remote embedders unless ApplyToLocal is set.
+type PrivacyPolicy struct {
+	// Exclude lists .gitignore-style patterns, relative to the project root,
+	// for files that are never indexed (customer-data fixtures, proprietary
+	// directories).
+	Exclude []string `json:"exclude,omitempty"`
+	// StripPII removes comments containing email addresses, phone numbers
+	// or US social security numbers, and masks such values elsewhere.
+	StripPII bool `json:"strip_pii,omitempty"`
+	// ApplyToLocal enforces the policy for local embedders too.
+	ApplyToLocal bool `json:"apply_to_local,omitempty"`
+}
+
+// RemoteEmbedder is implemented by embedders that can report whether they
+// send text off the machine.
+type RemoteEmbedder interface {
+	Remote() bool
+}
+
+// IsRemote reports whether e sends text to a remote service. Embedders that
+// do not say are assumed to be remote.
+func IsRemote(e Embedder) bool {
+	if r, ok := e.(RemoteEmbedder); ok {
+		return r.Remote()
+	}
+	return true
+}
+
+// ProbingEmbedder is implemented by embedders that learn their embedding
+// size from the model, and so may not know it.
+type ProbingEmbedder interface {
+	ProbeErr() error
+}
+
+// probeErr returns why e could not learn its embedding size, or nil.
+func probeErr(e Embedder) error {
+	if p, ok := e.(ProbingEmbedder); ok {
+		return p.ProbeErr()
+	}
+	return nil
+}
+
+// PrivacyFilter is a PrivacyPolicy bound to a project root. A nil filter
+// allows everything.
+type PrivacyFilter struct {
+	root     string
+	exclude  *ignore.GitIgnore
+	stripPII bool
+}
+
+// NewPrivacyFilter binds policy to the project at root.
+func NewPrivacyFilter(root string, policy PrivacyPolicy) *PrivacyFilter {
+	f := &PrivacyFilter{root: root, stripPII: policy.StripPII}
+	if len(policy.Exclude) > 0 {
+		f.exclude = ignore.CompileIgnoreLines(policy.Exclude...)
+	}
+	return f
+}
+
+// Excluded reports whether the file at path must not be indexed.
+func (f *PrivacyFilter) Excluded(path string) bool {
+	if f == nil || f.exclude == nil {
+		return false
+	}
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	return f.exclude.MatchesPath(filepath.ToSlash(rel))
+}
+
+// Clean applies PII stripping to a chunk of the file at path.
+func (f *PrivacyFilter) Clean(path, content string) string {
+	if f == nil || !f.stripPII {
+		return content
+	}
+	return StripPII(content, filepath.Ext(path))
+}
+
+var piiPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	regexp.MustCompile(`(?:\+\d{1,3}[-. ])?\(?\b\d{3}\)?[-. ]\d{3}[-. ]\d{4}\b`),
+	regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+}
+
+// commentMarkers are the line-comment prefixes checked per file type.
+var commentMarkers = map[string][]string{
+	".py": {"#"}, ".rb": {"#"}, ".sh": {"#"}, ".yaml": {"#"}, ".yml": {"#"},
+	".sql": {"--"}, ".lua": {"--"},
+}
+
+var defaultCommentMarkers = []string{"//", "/*", "*"}
+
+// StripPII removes comments that contain PII from source code, keeping the
+// comment marker so line structure survives, and masks PII that appears
+// outside comments or in documentation as "[PII]".
+func StripPII(content, ext string) string {
+	if !hasPII(content) {
+		return content
+	}
+	if isDocFile(ext) {
+		return maskPII(content)
+	}
+
+	markers := commentMarkers[strings.ToLower(ext)]
+	if markers == nil {
+		markers = defaultCommentMarkers
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if !hasPII(line) {
+			continue
+		}
+		if at := commentStart(line, markers); at >= 0 {
+			marker := line[at:]
+			for _, m := range markers {
+				if strings.HasPrefix(marker, m) {
+					marker = m
+					break
+				}
+			}
+			lines[i] = line[:at] + marker + " [comment removed: contained PII]"
+			continue
+		}
+		lines[i] = maskPII(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commentStart returns the byte offset of the comment on line that holds
+// PII, or -1 when the PII is in code.
+func commentStart(line string, markers []string) int {
+	trimmed := strings.TrimLeft(line, " \t")
+	indent := len(line) - len(trimmed)
+	for _, m := range markers {
+		if strings.HasPrefix(trimmed, m) {
+			return indent
+		}
+	}
+	// A trailing comment: the marker must come before the first PII match
+	// and follow whitespace, so "http://" in a string is not taken for one.
+	first := len(line)
+	for _, p := range piiPatterns {
+		if loc := p.FindStringIndex(line); loc != nil && loc[0] < first {
+			first = loc[0]
+		}
+	}
+	for _, m := range markers {
+		if m == "*" {
+			continue
+		}
+		if at := strings.Index(line, " "+m+" "); at >= 0 && at < first {
+			return at + 1
+		}
+	}
+	return -1
+}
+
+func hasPII(s string) bool {
+	for _, p := range piiPatterns {
+		if p.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func maskPII(s string) string {
+	for _, p := range piiPatterns {
+		s = p.ReplaceAllString(s, "[PII]")
+	}
+	return s
+}
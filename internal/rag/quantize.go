@@ -0,0 +1,130 @@
+package rag
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Quantization is how a store encodes embedding vectors. Quantized vectors
+// are converted back to float32 when they are scored.
+type Quantization string
+
+const (
+	QuantizeNone    Quantization = ""        // float32, 4 bytes per dimension
+	QuantizeFloat16 Quantization = "float16" // 2 bytes per dimension, nearly lossless
+	QuantizeInt8    Quantization = "int8"    // 1 byte per dimension and a float32 scale
+)
+
+// ParseQuantization accepts float32 (or ""), float16 and int8.
+func ParseQuantization(s string) (Quantization, error) {
+	switch s {
+	case "", "float32", "none":
+		return QuantizeNone, nil
+	case "float16", "fp16", "half":
+		return QuantizeFloat16, nil
+	case "int8":
+		return QuantizeInt8, nil
+	}
+	return "", fmt.Errorf("unknown quantization %q (want float32, float16 or int8)", s)
+}
+
+// quantizedSize returns the bytes a vector of dims dimensions takes.
+// Stores tell the encodings apart by size, which is unambiguous above 4
+// dimensions.
+func quantizedSize(q Quantization, dims int) int {
+	switch q {
+	case QuantizeFloat16:
+		return 2 * dims
+	case QuantizeInt8:
+		return 4 + dims
+	}
+	return 4 * dims
+}
+
+// encodeQuantized encodes vec with q. int8 vectors are scaled so their
+// largest component maps to 127, with the scale stored first.
+func encodeQuantized(vec []float32, q Quantization) []byte {
+	switch q {
+	case QuantizeFloat16:
+		buf := make([]byte, 2*len(vec))
+		for i, v := range vec {
+			binary.LittleEndian.PutUint16(buf[2*i:], float16bits(v))
+		}
+		return buf
+	case QuantizeInt8:
+		var peak float32
+		for _, v := range vec {
+			peak = max(peak, float32(math.Abs(float64(v))))
+		}
+		scale := peak / 127
+		buf := make([]byte, 4+len(vec))
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(scale))
+		if scale == 0 {
+			return buf
+		}
+		for i, v := range vec {
+			buf[4+i] = byte(int8(math.Round(float64(v / scale))))
+		}
+		return buf
+	}
+	return encodeEmbedding(vec)
+}
+
+// decodeQuantized decodes a vector of dims dimensions in any of the
+// encodings, recognised by its size.
+func decodeQuantized(data []byte, dims int) ([]float32, error) {
+	if dims > 4 {
+		switch len(data) {
+		case quantizedSize(QuantizeFloat16, dims):
+			vec := make([]float32, dims)
+			for i := range vec {
+				vec[i] = float16(binary.LittleEndian.Uint16(data[2*i:]))
+			}
+			return vec, nil
+		case quantizedSize(QuantizeInt8, dims):
+			scale := math.Float32frombits(binary.LittleEndian.Uint32(data))
+			vec := make([]float32, dims)
+			for i := range vec {
+				vec[i] = float32(int8(data[4+i])) * scale
+			}
+			return vec, nil
+		}
+	}
+	return decodeEmbedding(data, dims)
+}
+
+// float16bits converts f to IEEE 754 half precision, rounding to nearest
+// even. It is the inverse of float16.
+func float16bits(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int(b>>23&0xff) - 127 + 15
+	frac := b & 0x7fffff
+	switch {
+	case b>>23&0xff == 0xff: // infinity or NaN
+		if frac != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp >= 0x1f:
+		return sign | 0x7c00
+	case exp <= 0: // subnormal or zero
+		if exp < -10 {
+			return sign
+		}
+		frac |= 0x800000
+		shift := uint(14 - exp)
+		frac += 1<<(shift-1) - 1 + frac>>shift&1
+		return sign | uint16(frac>>shift)
+	}
+	frac += 0xfff + frac>>13&1
+	if frac&0x800000 != 0 {
+		frac = 0
+		exp++
+		if exp >= 0x1f {
+			return sign | 0x7c00
+		}
+	}
+	return sign | uint16(exp)<<10 | uint16(frac>>13)
+}
@@ -0,0 +1,173 @@
+package rag
+
+import (
+	"fmt"
+	"time"
+)
+
+// EmbedderMismatchError reports an index whose vectors were produced by a
+// different embedding model than the indexer's. Their similarity scores
+// would be meaningless, so searching it is refused.
+type EmbedderMismatchError struct {
+	Index    EmbeddingMetadata
+	Embedder EmbeddingMetadata
+	// Chunks is how many chunks Index.Model embedded when the index
+	// mixes models, or 0 when the whole index was built with it.
+	Chunks int
+}
+
+func (e *EmbedderMismatchError) Error() string {
+	indexModel := e.Index.Model
+	if indexModel == "" {
+		indexModel = "an unrecorded model"
+	}
+	if e.Chunks > 0 {
+		return fmt.Sprintf("%d chunks of the index were embedded with %s but the embedder is %s; re-embed the index or switch the embedder back",
+			e.Chunks, indexModel, e.Embedder.Model)
+	}
+	return fmt.Sprintf("index was embedded with %s (%d dims) but the embedder is %s (%d dims); re-embed the index or switch the embedder back",
+		indexModel, e.Index.Dimensions, e.Embedder.Model, e.Embedder.Dimensions)
+}
+
+// embedderMetadata describes the indexer's embedder.
+func (r *RAGIndexer) embedderMetadata() EmbeddingMetadata {
+	return EmbeddingMetadata{Model: r.embedder.Model(), Dimensions: r.embedder.Dimension()}
+}
+
+// CheckEmbedder returns an *EmbedderMismatchError if the index was built
+// with another embedding model, or holds chunks embedded by one. An empty
+// index, or a store that does not record its model, always passes; an
+// index built before the model was recorded is checked by dimensions only.
+func (r *RAGIndexer) CheckEmbedder() error {
+	if r.embedderChecked {
+		return nil
+	}
+	store, ok := r.vectorStore.(MetadataStore)
+	if !ok {
+		return nil
+	}
+	meta, err := store.EmbeddingMetadata()
+	if err != nil {
+		return err
+	}
+	current := r.embedderMetadata()
+	if err := probeErr(r.embedder); err != nil && meta.Dimensions != 0 && meta.Dimensions != current.Dimensions {
+		return err // the embedder's size is a guess, not a mismatch
+	}
+	if meta.Dimensions != 0 && (meta.Dimensions != current.Dimensions || (meta.Model != "" && meta.Model != current.Model)) {
+		return &EmbedderMismatchError{Index: meta, Embedder: current}
+	}
+	if cms, ok := r.vectorStore.(ChunkModelStore); ok {
+		models, err := cms.EmbeddingModels()
+		if err != nil {
+			return err
+		}
+		for model, n := range models {
+			if model != "" && model != current.Model {
+				return &EmbedderMismatchError{Index: EmbeddingMetadata{Model: model, Dimensions: meta.Dimensions}, Embedder: current, Chunks: n}
+			}
+		}
+	}
+	r.embedderChecked = true
+	return nil
+}
+
+// recordEmbedder stores the embedder's model in the index metadata.
+func (r *RAGIndexer) recordEmbedder() error {
+	r.embedderChecked = true
+	if store, ok := r.vectorStore.(MetadataStore); ok {
+		return store.SetEmbeddingMetadata(r.embedderMetadata())
+	}
+	return nil
+}
+
+// Reembed replaces the index with the chunks of src embedded by the
+// indexer's embedder, keeping their summaries, e.g. to move to a new
+// embedding model without re-reading the project. The privacy policy is
+// applied again, since it may cover the new embedder but not the old, and
+// chunk headers are rebuilt from the files. File hashes carry over, so a
+// later Update still skips unchanged files. It returns the number of
+// chunks embedded.
+func (r *RAGIndexer) Reembed(src ChunkSource) (int, error) {
+	chunks, err := src.Chunks()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read chunks: %w", err)
+	}
+	hashes := make(map[string]string)
+	if hs, ok := src.(FileHashStore); ok {
+		if hashes, err = hs.FileHashes(); err != nil {
+			return 0, err
+		}
+	}
+	for path := range hashes {
+		if r.privacy.Excluded(path) {
+			delete(hashes, path)
+		}
+	}
+
+	var kept []*Chunk
+	summaries := make(map[string]string)
+	for _, chunk := range chunks {
+		if r.privacy.Excluded(chunk.FilePath) {
+			continue
+		}
+		chunk.Content = r.privacy.Clean(chunk.FilePath, chunk.Content)
+		if chunk.Summary != "" {
+			summaries[chunk.Hash] = chunk.Summary
+		}
+		kept = append(kept, chunk)
+	}
+	r.addHeaders(kept)
+
+	if err := r.vectorStore.Clear(); err != nil {
+		return 0, fmt.Errorf("failed to clear vector store: %w", err)
+	}
+	if err := r.recordEmbedder(); err != nil {
+		return 0, err
+	}
+
+	bulkDone := false
+	if bulk, ok := r.embedder.(BulkEmbedder); ok && r.opts.Bulk {
+		if err := r.storeBulk(bulk, kept); err != nil {
+			fmt.Printf("Warning: bulk embedding failed, embedding synchronously: %v\n", err)
+			if err := r.vectorStore.Clear(); err != nil {
+				return 0, fmt.Errorf("failed to clear vector store: %w", err)
+			}
+			if err := r.recordEmbedder(); err != nil {
+				return 0, err
+			}
+		} else {
+			bulkDone = true
+		}
+	}
+	if !bulkDone {
+		const group = 500
+		for start := 0; start < len(kept); start += group {
+			fmt.Printf("Progress: %d/%d chunks\n", start, len(kept))
+			if err := r.storeSync(kept[start:min(start+group, len(kept))]); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if len(summaries) > 0 {
+		if store, ok := r.vectorStore.(SummaryStore); ok {
+			if err := store.SaveSummaries(summaries); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := r.recordHashes(hashes); err != nil {
+		return 0, err
+	}
+	files := make(map[string]bool)
+	for _, chunk := range kept {
+		for _, loc := range chunk.Locations() {
+			files[loc.FilePath] = true
+		}
+	}
+	r.stats.TotalFiles = max(len(files), len(hashes))
+	r.stats.TotalChunks = len(kept)
+	r.stats.LastUpdated = time.Now().Format(time.RFC3339)
+	return len(kept), r.saveStats()
+}
@@ -0,0 +1,106 @@
+package rag
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// tensor is a float32 tensor read from a safetensors file.
+type tensor struct {
+	shape []int
+	data  []float32
+}
+
+// readSafetensors loads every tensor of a safetensors file (an 8-byte
+// header length, a JSON header, then the raw little-endian data),
+// converting F16 and BF16 weights to float32.
+func readSafetensors(path string) (map[string]tensor, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < 8 {
+		return nil, fmt.Errorf("%s: not a safetensors file", path)
+	}
+	n := binary.LittleEndian.Uint64(raw[:8])
+	if n > uint64(len(raw)-8) {
+		return nil, fmt.Errorf("%s: header length %d exceeds file size", path, n)
+	}
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(raw[8:8+n], &header); err != nil {
+		return nil, fmt.Errorf("%s: decode header: %w", path, err)
+	}
+	data := raw[8+n:]
+
+	tensors := make(map[string]tensor, len(header))
+	for name, msg := range header {
+		if name == "__metadata__" {
+			continue
+		}
+		var info struct {
+			Dtype       string `json:"dtype"`
+			Shape       []int  `json:"shape"`
+			DataOffsets [2]int `json:"data_offsets"`
+		}
+		if err := json.Unmarshal(msg, &info); err != nil {
+			return nil, fmt.Errorf("%s: tensor %s: %w", path, name, err)
+		}
+		start, end := info.DataOffsets[0], info.DataOffsets[1]
+		if start < 0 || end < start || end > len(data) {
+			return nil, fmt.Errorf("%s: tensor %s: data offsets out of range", path, name)
+		}
+		b := data[start:end]
+		var values []float32
+		switch info.Dtype {
+		case "F32":
+			values = make([]float32, len(b)/4)
+			for i := range values {
+				values[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+			}
+		case "F16":
+			values = make([]float32, len(b)/2)
+			for i := range values {
+				values[i] = float16(binary.LittleEndian.Uint16(b[2*i:]))
+			}
+		case "BF16":
+			values = make([]float32, len(b)/2)
+			for i := range values {
+				values[i] = math.Float32frombits(uint32(binary.LittleEndian.Uint16(b[2*i:])) << 16)
+			}
+		default:
+			continue // integer buffers such as position_ids are not weights
+		}
+		size := 1
+		for _, d := range info.Shape {
+			size *= d
+		}
+		if size != len(values) {
+			return nil, fmt.Errorf("%s: tensor %s: shape %v does not match its data", path, name, info.Shape)
+		}
+		tensors[name] = tensor{shape: info.Shape, data: values}
+	}
+	return tensors, nil
+}
+
+// float16 converts an IEEE 754 half-precision value.
+func float16(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+	switch {
+	case exp == 0 && frac == 0:
+		return math.Float32frombits(sign)
+	case exp == 0: // subnormal
+		f := float32(frac) / 1024 / (1 << 14)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0xff<<23 | frac<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | frac<<13)
+}
@@ -0,0 +1,325 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// ShardStoreFactory opens the vector store backing a single shard.
+type ShardStoreFactory func(shard string) (VectorStore, error)
+
+// ShardedIndexer splits a project into shards by top-level directory. Each
+// shard has its own vector store and is indexed independently; searches fan
+// out over every opened shard and merge the results by score.
+type ShardedIndexer struct {
+	projectPath string
+	embedder    Embedder
+	newStore    ShardStoreFactory
+	shards      map[string]*RAGIndexer
+	opts        EmbedOptions
+	chunking    ChunkerConfig
+	privacy     PrivacyPolicy
+}
+
+// NewShardedIndexer creates a sharded indexer for projectPath.
+func NewShardedIndexer(projectPath string, embedder Embedder, newStore ShardStoreFactory) *ShardedIndexer {
+	return &ShardedIndexer{
+		projectPath: projectPath,
+		embedder:    embedder,
+		newStore:    newStore,
+		shards:      make(map[string]*RAGIndexer),
+		opts:        DefaultEmbedOptions(),
+		chunking:    DefaultChunkerConfig(),
+	}
+}
+
+// SetEmbedOptions applies embedding options to every current and future shard.
+func (s *ShardedIndexer) SetEmbedOptions(opts EmbedOptions) {
+	s.opts = opts
+	for _, idx := range s.shards {
+		idx.SetEmbedOptions(opts)
+	}
+}
+
+// SetChunkerConfig applies chunk sizes to every current and future shard.
+func (s *ShardedIndexer) SetChunkerConfig(cfg ChunkerConfig) error {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return fmt.Errorf("invalid chunker config: %w", err)
+	}
+	s.chunking = cfg
+	for _, idx := range s.shards {
+		idx.chunking = cfg
+	}
+	return nil
+}
+
+// SetPrivacyPolicy applies policy to every current and future shard.
+func (s *ShardedIndexer) SetPrivacyPolicy(policy PrivacyPolicy) {
+	s.privacy = policy
+	for _, idx := range s.shards {
+		idx.SetPrivacyPolicy(s.projectPath, policy)
+	}
+}
+
+// ShardDBPath returns the default SQLite location for a shard's vectors.
+func ShardDBPath(projectPath, shard string) string {
+	return filepath.Join(projectPath, ".index", "shards", shard, "rag_vectors.db")
+}
+
+// ListShards returns the top-level directories of projectPath that are not
+// hidden or gitignored. Each one is a candidate shard.
+func ListShards(projectPath string) ([]string, error) {
+	entries, err := os.ReadDir(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("read project dir: %w", err)
+	}
+
+	var gitignore *ignore.GitIgnore
+	if gi, err := ignore.CompileIgnoreFile(filepath.Join(projectPath, ".gitignore")); err == nil {
+		gitignore = gi
+	}
+
+	var shards []string
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if gitignore != nil && gitignore.MatchesPath(e.Name()+"/") {
+			continue
+		}
+		shards = append(shards, e.Name())
+	}
+	return shards, nil
+}
+
+// IndexedShards returns the shards that already have a vector store on disk.
+func IndexedShards(projectPath string) []string {
+	entries, err := os.ReadDir(filepath.Join(projectPath, ".index", "shards"))
+	if err != nil {
+		return nil
+	}
+	var shards []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(ShardDBPath(projectPath, e.Name())); err == nil {
+			shards = append(shards, e.Name())
+		}
+	}
+	return shards
+}
+
+// Open opens the named shards without indexing them. It fails for a shard
+// that has no index yet, rather than creating an empty one.
+func (s *ShardedIndexer) Open(names []string) error {
+	for _, name := range names {
+		if _, err := os.Stat(ShardDBPath(s.projectPath, name)); os.IsNotExist(err) {
+			return fmt.Errorf("shard %q is not indexed (run rag index -shards %s)", name, name)
+		}
+		if _, err := s.shard(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IndexShards (re)builds the index of each named shard.
+func (s *ShardedIndexer) IndexShards(names []string) error {
+	for _, name := range names {
+		root := filepath.Join(s.projectPath, name)
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("shard %s is not a directory under %s", name, s.projectPath)
+		}
+
+		idx, err := s.shard(name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\n--- Shard: %s ---\n", name)
+		if err := idx.IndexProject(root); err != nil {
+			return fmt.Errorf("index shard %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// UpdateShards updates the index of each named shard in place, as
+// RAGIndexer.Update does, and returns the combined counts.
+func (s *ShardedIndexer) UpdateShards(names []string) (UpdateStats, error) {
+	var total UpdateStats
+	for _, name := range names {
+		root := filepath.Join(s.projectPath, name)
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return total, fmt.Errorf("shard %s is not a directory under %s", name, s.projectPath)
+		}
+		idx, err := s.shard(name)
+		if err != nil {
+			return total, err
+		}
+		stats, err := idx.Update(root)
+		total.add(stats)
+		if err != nil {
+			return total, fmt.Errorf("update shard %s: %w", name, err)
+		}
+	}
+	return total, nil
+}
+
+// RefreshFiles routes each absolute path to the opened shard named by its
+// top-level directory and refreshes it there. Paths outside opened shards
+// are skipped.
+func (s *ShardedIndexer) RefreshFiles(paths []string) (updated, removed int, err error) {
+	byShard := make(map[string][]string)
+	for _, path := range paths {
+		rel, err := filepath.Rel(s.projectPath, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		name, _, ok := strings.Cut(filepath.ToSlash(rel), "/")
+		if !ok {
+			continue
+		}
+		if _, opened := s.shards[name]; opened {
+			byShard[name] = append(byShard[name], path)
+		}
+	}
+
+	for name, files := range byShard {
+		u, r, err := s.shards[name].RefreshFiles(files)
+		updated += u
+		removed += r
+		if err != nil {
+			return updated, removed, fmt.Errorf("refresh shard %s: %w", name, err)
+		}
+	}
+	return updated, removed, nil
+}
+
+// RemoveWhere removes the files matching pattern from every opened shard
+// and returns how many it removed.
+func (s *ShardedIndexer) RemoveWhere(pattern string) (int, error) {
+	removed := 0
+	for _, name := range s.Shards() {
+		n, err := s.shards[name].RemoveWhere(pattern)
+		removed += n
+		if err != nil {
+			return removed, fmt.Errorf("shard %s: %w", name, err)
+		}
+	}
+	return removed, nil
+}
+
+// Search embeds the query once and searches every opened shard, returning
+// the overall topK results passing filter. Like RAGIndexer.Search it fails
+// with an *EmbedderMismatchError if a shard was built with another model.
+func (s *ShardedIndexer) Search(query string, topK int, filter SearchFilter) ([]*SearchResult, error) {
+	if len(s.shards) == 0 {
+		return nil, fmt.Errorf("no shards opened")
+	}
+	for _, name := range s.Shards() {
+		if err := s.shards[name].CheckEmbedder(); err != nil {
+			return nil, fmt.Errorf("shard %s: %w", name, err)
+		}
+	}
+
+	queryEmbedding, err := s.embedder.Embed(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	var merged []*SearchResult
+	for _, name := range s.Shards() {
+		results, err := s.shards[name].vectorStore.Search(queryEmbedding, topK, filter)
+		if err != nil {
+			return nil, fmt.Errorf("search shard %s: %w", name, err)
+		}
+		merged = append(merged, results...)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Score > merged[j].Score
+	})
+	if topK > 0 && topK < len(merged) {
+		merged = merged[:topK]
+	}
+	return merged, nil
+}
+
+// KeywordSearch runs a keyword search in every opened shard and merges the
+// results by score.
+func (s *ShardedIndexer) KeywordSearch(query string, topK int) ([]*SearchResult, error) {
+	if len(s.shards) == 0 {
+		return nil, fmt.Errorf("no shards opened")
+	}
+
+	var merged []*SearchResult
+	for _, name := range s.Shards() {
+		results, err := s.shards[name].KeywordSearch(query, topK)
+		if err != nil {
+			return nil, fmt.Errorf("search shard %s: %w", name, err)
+		}
+		merged = append(merged, results...)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Score > merged[j].Score
+	})
+	if topK > 0 && topK < len(merged) {
+		merged = merged[:topK]
+	}
+	return merged, nil
+}
+
+// Shards returns the names of the opened shards in sorted order.
+func (s *ShardedIndexer) Shards() []string {
+	names := make([]string, 0, len(s.shards))
+	for name := range s.shards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns per-shard statistics.
+func (s *ShardedIndexer) Stats() map[string]*IndexStats {
+	stats := make(map[string]*IndexStats, len(s.shards))
+	for name, idx := range s.shards {
+		stats[name] = idx.Stats()
+	}
+	return stats
+}
+
+// Close closes every opened shard.
+func (s *ShardedIndexer) Close() error {
+	var firstErr error
+	for _, idx := range s.shards {
+		if err := idx.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (s *ShardedIndexer) shard(name string) (*RAGIndexer, error) {
+	if idx, ok := s.shards[name]; ok {
+		return idx, nil
+	}
+	store, err := s.newStore(name)
+	if err != nil {
+		return nil, fmt.Errorf("open shard %s: %w", name, err)
+	}
+	idx := NewRAGIndexer(s.embedder, store)
+	idx.SetEmbedOptions(s.opts)
+	idx.chunking = s.chunking
+	idx.SetPrivacyPolicy(s.projectPath, s.privacy)
+	s.shards[name] = idx
+	return idx, nil
+}
@@ -0,0 +1,56 @@
+package rag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShardedSearchChecksEmbedder(t *testing.T) {
+	dir := t.TempDir()
+	stores := make(map[string]*MemoryVectorStore)
+	for _, name := range []string{"api", "web"} {
+		path := ShardDBPath(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		store, err := NewMemoryVectorStore("", 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores[name] = store
+	}
+	// web was built with another model.
+	if err := stores["web"].SetEmbeddingMetadata(EmbeddingMetadata{Model: "other-model", Dimensions: 8}); err != nil {
+		t.Fatal(err)
+	}
+
+	sharded := NewShardedIndexer(dir, NewMockEmbedder(8), func(name string) (VectorStore, error) {
+		return stores[name], nil
+	})
+	if err := sharded.Open([]string{"api", "web"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := sharded.Search("query", 5, SearchFilter{})
+	var mismatch *EmbedderMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Search error = %v, want an *EmbedderMismatchError", err)
+	}
+	if mismatch.Index.Model != "other-model" {
+		t.Errorf("mismatch index model = %q, want other-model", mismatch.Index.Model)
+	}
+}
+
+func TestShardedOpenRequiresIndex(t *testing.T) {
+	sharded := NewShardedIndexer(t.TempDir(), NewMockEmbedder(8), func(string) (VectorStore, error) {
+		t.Fatal("opened a store for a shard that was never indexed")
+		return nil, nil
+	})
+	if err := sharded.Open([]string{"missing"}); err == nil {
+		t.Fatal("Open succeeded for a shard without an index")
+	}
+}
@@ -0,0 +1,494 @@
+//go:build cgo
+
+package rag
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+	"github.com/smacker/go-tree-sitter/cpp"
+	"github.com/smacker/go-tree-sitter/csharp"
+	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/kotlin"
+	"github.com/smacker/go-tree-sitter/php"
+	"github.com/smacker/go-tree-sitter/ruby"
+	"github.com/smacker/go-tree-sitter/rust"
+	"github.com/smacker/go-tree-sitter/scala"
+	"github.com/smacker/go-tree-sitter/swift"
+)
+
+// tsGrammar describes how to chunk one language parsed with tree-sitter.
+// Node types are those of the language's grammar.
+type tsGrammar struct {
+	language string
+	grammar  func() *sitter.Language
+	// defs maps the node types of definitions to chunk types. Functions
+	// become methods inside containers.
+	defs map[string]string
+	// containers are the definitions that are split into their members
+	// when too large for one chunk.
+	containers map[string]bool
+	// transparent nodes, such as namespaces and preprocessor
+	// conditionals, are chunked as if their contents were at top level.
+	transparent map[string]bool
+	// skip lists imports, package clauses and the like, which are left
+	// out.
+	skip map[string]bool
+	// leading lists nodes, besides comments, that go with the definition
+	// after them, such as Rust attributes.
+	leading map[string]bool
+}
+
+func nodeTypes(types ...string) map[string]bool {
+	m := make(map[string]bool, len(types))
+	for _, t := range types {
+		m[t] = true
+	}
+	return m
+}
+
+var (
+	cGrammar = &tsGrammar{
+		language: "c",
+		grammar:  c.GetLanguage,
+		defs: map[string]string{
+			"function_definition":  "function",
+			"struct_specifier":     "struct",
+			"union_specifier":      "struct",
+			"enum_specifier":       "enum",
+			"type_definition":      "type",
+			"preproc_function_def": "macro",
+		},
+		transparent: nodeTypes("preproc_ifdef", "preproc_if", "preproc_else", "preproc_elif", "linkage_specification"),
+		skip:        nodeTypes("preproc_include"),
+	}
+	cppGrammar = &tsGrammar{
+		language: "cpp",
+		grammar:  cpp.GetLanguage,
+		defs: map[string]string{
+			"function_definition":  "function",
+			"class_specifier":      "class",
+			"struct_specifier":     "struct",
+			"union_specifier":      "struct",
+			"enum_specifier":       "enum",
+			"type_definition":      "type",
+			"alias_declaration":    "type",
+			"concept_definition":   "type",
+			"preproc_function_def": "macro",
+		},
+		containers:  nodeTypes("class_specifier", "struct_specifier"),
+		transparent: nodeTypes("namespace_definition", "preproc_ifdef", "preproc_if", "preproc_else", "preproc_elif", "linkage_specification"),
+		skip:        nodeTypes("preproc_include", "using_declaration"),
+	}
+	tsGrammars = map[string]*tsGrammar{
+		".c": cGrammar,
+		// Headers are parsed as C++, which is close to a superset of C
+		// and also handles C++ headers.
+		".h":   {language: "c", grammar: cpp.GetLanguage, defs: cppGrammar.defs, containers: cppGrammar.containers, transparent: cppGrammar.transparent, skip: cppGrammar.skip},
+		".cpp": cppGrammar,
+		".cc":  cppGrammar,
+		".cxx": cppGrammar,
+		".hpp": cppGrammar,
+		".hh":  cppGrammar,
+		".hxx": cppGrammar,
+		".java": {
+			language: "java",
+			grammar:  java.GetLanguage,
+			defs: map[string]string{
+				"class_declaration":           "class",
+				"record_declaration":          "class",
+				"interface_declaration":       "interface",
+				"annotation_type_declaration": "interface",
+				"enum_declaration":            "enum",
+				"method_declaration":          "method",
+				"constructor_declaration":     "method",
+			},
+			containers: nodeTypes("class_declaration", "record_declaration", "interface_declaration", "enum_declaration"),
+			skip:       nodeTypes("package_declaration", "import_declaration"),
+		},
+		".rs": {
+			language: "rust",
+			grammar:  rust.GetLanguage,
+			defs: map[string]string{
+				"function_item":    "function",
+				"struct_item":      "struct",
+				"union_item":       "struct",
+				"enum_item":        "enum",
+				"trait_item":       "interface",
+				"impl_item":        "impl",
+				"type_item":        "type",
+				"const_item":       "const",
+				"static_item":      "const",
+				"macro_definition": "macro",
+			},
+			containers:  nodeTypes("impl_item", "trait_item"),
+			transparent: nodeTypes("mod_item"),
+			skip:        nodeTypes("use_declaration", "extern_crate_declaration"),
+			leading:     nodeTypes("attribute_item"),
+		},
+		".rb": {
+			language: "ruby",
+			grammar:  ruby.GetLanguage,
+			defs: map[string]string{
+				"class":            "class",
+				"module":           "module",
+				"method":           "function",
+				"singleton_method": "method",
+			},
+			containers:  nodeTypes("class", "module"),
+			transparent: nodeTypes("singleton_class"),
+		},
+		".php": {
+			language: "php",
+			grammar:  php.GetLanguage,
+			defs: map[string]string{
+				"class_declaration":     "class",
+				"trait_declaration":     "class",
+				"interface_declaration": "interface",
+				"enum_declaration":      "enum",
+				"function_definition":   "function",
+				"method_declaration":    "method",
+			},
+			containers:  nodeTypes("class_declaration", "trait_declaration", "interface_declaration", "enum_declaration"),
+			transparent: nodeTypes("namespace_definition"),
+			skip:        nodeTypes("php_tag", "namespace_use_declaration"),
+		},
+		".cs": {
+			language: "csharp",
+			grammar:  csharp.GetLanguage,
+			defs: map[string]string{
+				"class_declaration":         "class",
+				"record_declaration":        "class",
+				"struct_declaration":        "struct",
+				"record_struct_declaration": "struct",
+				"interface_declaration":     "interface",
+				"enum_declaration":          "enum",
+				"delegate_declaration":      "type",
+				"method_declaration":        "method",
+				"constructor_declaration":   "method",
+				"operator_declaration":      "method",
+			},
+			containers:  nodeTypes("class_declaration", "record_declaration", "struct_declaration", "record_struct_declaration", "interface_declaration"),
+			transparent: nodeTypes("namespace_declaration"),
+			skip:        nodeTypes("using_directive", "extern_alias_directive", "file_scoped_namespace_declaration"),
+		},
+		".swift": {
+			language: "swift",
+			grammar:  swift.GetLanguage,
+			defs: map[string]string{
+				"class_declaration":     "class",
+				"protocol_declaration":  "interface",
+				"function_declaration":  "function",
+				"init_declaration":      "method",
+				"typealias_declaration": "type",
+			},
+			containers: nodeTypes("class_declaration", "protocol_declaration"),
+			skip:       nodeTypes("import_declaration"),
+		},
+		".kt":  kotlinGrammar,
+		".kts": kotlinGrammar,
+		".scala": {
+			language: "scala",
+			grammar:  scala.GetLanguage,
+			defs: map[string]string{
+				"class_definition":     "class",
+				"object_definition":    "class",
+				"trait_definition":     "interface",
+				"enum_definition":      "enum",
+				"function_definition":  "function",
+				"function_declaration": "method",
+				"type_definition":      "type",
+			},
+			containers: nodeTypes("class_definition", "object_definition", "trait_definition", "enum_definition"),
+			skip:       nodeTypes("package_clause", "import_declaration"),
+		},
+	}
+	kotlinGrammar = &tsGrammar{
+		language: "kotlin",
+		grammar:  kotlin.GetLanguage,
+		defs: map[string]string{
+			"class_declaration":    "class",
+			"object_declaration":   "class",
+			"companion_object":     "class",
+			"function_declaration": "function",
+			"type_alias":           "type",
+		},
+		containers: nodeTypes("class_declaration", "object_declaration", "companion_object"),
+		skip:       nodeTypes("package_header", "import_list"),
+	}
+)
+
+// TreeSitterChunker chunks languages without a dedicated chunker using
+// their tree-sitter grammar: each top-level definition becomes a chunk,
+// with the comments above it, and the statements between definitions are
+// grouped into blocks. Classes too large for one chunk are split into
+// their methods, as in JSChunker.
+type TreeSitterChunker struct {
+	g   *tsGrammar
+	cfg ChunkerConfig
+}
+
+// NewTreeSitterChunker returns a chunker for filePath, or nil when no
+// grammar is loaded for its extension.
+func NewTreeSitterChunker(filePath string, cfg ChunkerConfig) Chunker {
+	g := tsGrammars[strings.ToLower(filepath.Ext(filePath))]
+	if g == nil {
+		return nil
+	}
+	return &TreeSitterChunker{g: g, cfg: cfg}
+}
+
+func (c *TreeSitterChunker) Language() string {
+	return c.g.language
+}
+
+func (c *TreeSitterChunker) ChunkFile(filePath string, content string) ([]*Chunk, error) {
+	src := []byte(content)
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(c.g.grammar())
+	tree, err := parser.ParseCtx(context.Background(), nil, src)
+	if err != nil {
+		return c.cfg.genericSlidingChunks(filePath, content, c.g.language), nil
+	}
+	defer tree.Close()
+
+	f := &tsFile{g: c.g, cfg: c.cfg, path: filePath, src: src, lines: strings.Split(content, "\n")}
+	f.addChildren(tree.RootNode())
+	if len(f.chunks) == 0 {
+		return c.cfg.genericSlidingChunks(filePath, content, c.g.language), nil
+	}
+	return f.chunks, nil
+}
+
+// tsFile holds the state of chunking one file.
+type tsFile struct {
+	g      *tsGrammar
+	cfg    ChunkerConfig
+	path   string
+	src    []byte
+	lines  []string
+	chunks []*Chunk
+}
+
+// addChildren chunks the definitions among the children of n, grouping
+// the other statements into blocks.
+func (f *tsFile) addChildren(n *sitter.Node) {
+	if body := n.ChildByFieldName("body"); body != nil {
+		n = body
+	}
+	other, lead := -1, -1 // first lines of pending statements and comments
+	prev := 0             // last line of the previous child
+	flush := func(end int) {
+		if other >= 0 {
+			f.add(other, end, "block", "")
+		}
+		other = -1
+	}
+	for i := 0; i < int(n.ChildCount()); i++ {
+		child := n.Child(i)
+		if !child.IsNamed() || child.IsMissing() {
+			continue
+		}
+		if field := n.FieldNameForChild(i); field == "name" || field == "condition" {
+			continue
+		}
+		first, last := f.span(child)
+		kind := child.Type()
+		if first == prev && strings.Contains(kind, "comment") {
+			continue // a trailing comment, chunked with the line it ends
+		}
+		prev = last
+		if strings.Contains(kind, "comment") || f.g.leading[kind] {
+			if lead < 0 {
+				lead = first
+			}
+			continue
+		}
+		start := first
+		if lead >= 0 {
+			start, lead = lead, -1
+		}
+		switch {
+		case f.g.skip[kind]:
+			flush(start - 1)
+		case f.g.transparent[kind]:
+			flush(start - 1)
+			f.addChildren(child)
+		default:
+			def, chunkType := f.definition(child)
+			if def == nil {
+				if other < 0 {
+					other = start
+				}
+				continue
+			}
+			flush(start - 1)
+			f.addDefinition(def, start, last, chunkType, "")
+		}
+	}
+	if other >= 0 {
+		_, last := f.span(n)
+		flush(last)
+	}
+}
+
+// definition returns the definition n is or wraps, as a C++ template
+// does, and its chunk type; nil if n defines nothing.
+func (f *tsFile) definition(n *sitter.Node) (*sitter.Node, string) {
+	if chunkType, ok := f.g.defs[n.Type()]; ok {
+		if kind := n.ChildByFieldName("declaration_kind"); kind != nil {
+			switch k := kind.Content(f.src); k {
+			case "struct", "enum":
+				chunkType = k
+			}
+		}
+		return n, chunkType
+	}
+	if n.Type() == "template_declaration" && n.NamedChildCount() > 0 {
+		return f.definition(n.NamedChild(int(n.NamedChildCount()) - 1))
+	}
+	return nil, ""
+}
+
+// addDefinition adds def, spanning lines first to last, as a chunk named
+// after it, or a chunk per member if it is a container too large for one.
+// Functions inside containers are methods named "Container.method".
+func (f *tsFile) addDefinition(def *sitter.Node, first, last int, chunkType, container string) {
+	name := f.name(def)
+	if container != "" {
+		if chunkType == "function" {
+			chunkType = "method"
+		}
+		name = container + "." + name
+	} else if chunkType == "function" && strings.Contains(name, "::") {
+		chunkType = "method" // C++ out-of-line method
+	}
+	body := def.ChildByFieldName("body")
+	if body == nil {
+		body = f.bodyChild(def)
+	}
+	if !f.g.containers[def.Type()] || body == nil || len(strings.Join(f.lines[first-1:last], "\n")) <= f.cfg.MaxChunkSize {
+		f.add(first, last, chunkType, name)
+		return
+	}
+
+	fieldsStart := first // the declaration line goes with the first fields
+	fieldsEnd := -1
+	lead, prev := -1, 0
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		member := body.NamedChild(i)
+		mFirst, mLast := f.span(member)
+		if mFirst == prev && strings.Contains(member.Type(), "comment") {
+			continue
+		}
+		prev = mLast
+		if strings.Contains(member.Type(), "comment") || f.g.leading[member.Type()] {
+			if lead < 0 {
+				lead = mFirst
+			}
+			continue
+		}
+		memberDef, memberType := f.definition(member)
+		if memberDef == nil {
+			if fieldsStart < 0 {
+				fieldsStart = mFirst
+				if lead >= 0 {
+					fieldsStart = lead
+				}
+			}
+			fieldsEnd, lead = mLast, -1
+			continue
+		}
+		if fieldsStart >= 0 && fieldsEnd >= 0 {
+			f.add(fieldsStart, fieldsEnd, chunkType, name)
+		}
+		fieldsStart, fieldsEnd = -1, -1
+		if lead >= 0 {
+			mFirst, lead = lead, -1
+		}
+		f.addDefinition(memberDef, mFirst, mLast, memberType, name)
+	}
+	if fieldsStart >= 0 && fieldsEnd >= 0 {
+		f.add(fieldsStart, fieldsEnd, chunkType, name)
+	}
+}
+
+// bodyChild returns the body of a definition whose grammar does not label
+// it, such as a Kotlin class_body.
+func (f *tsFile) bodyChild(def *sitter.Node) *sitter.Node {
+	for i := int(def.NamedChildCount()) - 1; i >= 0; i-- {
+		child := def.NamedChild(i)
+		if strings.HasSuffix(child.Type(), "body") || strings.HasSuffix(child.Type(), "declaration_list") {
+			return child
+		}
+	}
+	return nil
+}
+
+// name returns the name a definition declares.
+func (f *tsFile) name(def *sitter.Node) string {
+	if n := def.ChildByFieldName("name"); n != nil {
+		return n.Content(f.src)
+	}
+	if n := def.ChildByFieldName("type"); n != nil && def.Type() == "impl_item" {
+		return n.Content(f.src)
+	}
+	// C declarators nest: a pointer to a function declarator around the
+	// identifier.
+	for d := def.ChildByFieldName("declarator"); d != nil; d = innerDeclarator(d) {
+		switch t := d.Type(); {
+		case strings.HasSuffix(t, "identifier") || strings.HasSuffix(t, "_name"):
+			return d.Content(f.src)
+		case t == "operator_cast":
+			if typ := d.ChildByFieldName("type"); typ != nil {
+				return "operator " + typ.Content(f.src)
+			}
+		}
+	}
+	for i := 0; i < int(def.NamedChildCount()); i++ {
+		child := def.NamedChild(i)
+		if t := child.Type(); strings.HasSuffix(t, "identifier") || t == "constant" {
+			return child.Content(f.src)
+		}
+	}
+	return ""
+}
+
+// innerDeclarator returns the declarator d wraps. C++ reference
+// declarators do not label theirs.
+func innerDeclarator(d *sitter.Node) *sitter.Node {
+	if inner := d.ChildByFieldName("declarator"); inner != nil {
+		return inner
+	}
+	if d.Type() == "reference_declarator" && d.NamedChildCount() > 0 {
+		return d.NamedChild(int(d.NamedChildCount()) - 1)
+	}
+	return nil
+}
+
+// span returns the 1-based first and last lines of n. A node ending at the
+// start of a line, as preprocessor directives do, ends on the line before.
+func (f *tsFile) span(n *sitter.Node) (int, int) {
+	start, end := n.StartPoint(), n.EndPoint()
+	last := int(end.Row) + 1
+	if end.Column == 0 && end.Row > start.Row {
+		last--
+	}
+	return int(start.Row) + 1, min(last, len(f.lines))
+}
+
+// add chunks lines first to last.
+func (f *tsFile) add(first, last int, chunkType, name string) {
+	if first < 1 || last < first {
+		return
+	}
+	text := strings.Join(f.lines[first-1:last], "\n")
+	if len(strings.TrimSpace(text)) < 20 {
+		return
+	}
+	f.chunks = append(f.chunks, f.cfg.splitLargeChunk(f.path, text, chunkType, name, f.g.language, first, last)...)
+}
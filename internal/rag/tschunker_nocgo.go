@@ -0,0 +1,9 @@
+//go:build !cgo
+
+package rag
+
+// NewTreeSitterChunker returns nil: the tree-sitter grammars need cgo, so
+// without it languages lacking a dedicated chunker use sliding windows.
+func NewTreeSitterChunker(filePath string, cfg ChunkerConfig) Chunker {
+	return nil
+}
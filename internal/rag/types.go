@@ -16,7 +16,79 @@ type Chunk struct {
 	Language   string `json:"language"`
 	Content    string `json:"content"`
 	TokenCount int    `json:"token_count"`
-	Hash       string `json:"hash"` // Content hash for caching
+	Hash       string `json:"hash"`              // Content hash for caching
+	Summary    string `json:"summary,omitempty"` // LLM summary from "rag enrich", if any
+	// Duplicates are the other places the same content appears, for
+	// stores that keep each distinct chunk once (see DedupStore).
+	Duplicates []ChunkLocation `json:"duplicates,omitempty"`
+	// EmbeddingModel is the model that embedded the chunk, for stores
+	// that record it (see ChunkModelStore).
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+	// Header locates the chunk (file, package, symbol, imports) for the
+	// embedder. It is prepended when embedding and not stored.
+	Header string `json:"-"`
+}
+
+// ChunkLocation is a place a chunk's content appears.
+type ChunkLocation struct {
+	FilePath   string `json:"file_path"`
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	SymbolName string `json:"symbol_name,omitempty"`
+}
+
+// Location returns where the chunk itself is.
+func (c *Chunk) Location() ChunkLocation {
+	return ChunkLocation{FilePath: c.FilePath, StartLine: c.StartLine, EndLine: c.EndLine, SymbolName: c.SymbolName}
+}
+
+// Locations returns every place the chunk's content appears, its own
+// location first.
+func (c *Chunk) Locations() []ChunkLocation {
+	return append([]ChunkLocation{c.Location()}, c.Duplicates...)
+}
+
+// sameLocation reports whether a and b start at the same line of the same
+// file.
+func sameLocation(a, b *Chunk) bool {
+	return a.FilePath == b.FilePath && a.StartLine == b.StartLine
+}
+
+// addDuplicate records loc as another place the chunk appears, unless it
+// is already known.
+func (c *Chunk) addDuplicate(loc ChunkLocation) {
+	if loc.FilePath == c.FilePath && loc.StartLine == c.StartLine {
+		return
+	}
+	for _, d := range c.Duplicates {
+		if d.FilePath == loc.FilePath && d.StartLine == loc.StartLine {
+			return
+		}
+	}
+	c.Duplicates = append(c.Duplicates, loc)
+}
+
+// dropFile removes the locations in filePath, moving the chunk to a
+// duplicate when it is itself in filePath. It reports whether any
+// location is left.
+func (c *Chunk) dropFile(filePath string) bool {
+	var kept []ChunkLocation
+	for _, d := range c.Duplicates {
+		if d.FilePath != filePath {
+			kept = append(kept, d)
+		}
+	}
+	c.Duplicates = kept
+	if c.FilePath != filePath {
+		return true
+	}
+	if len(c.Duplicates) == 0 {
+		return false
+	}
+	loc := c.Duplicates[0]
+	c.FilePath, c.StartLine, c.EndLine, c.SymbolName = loc.FilePath, loc.StartLine, loc.EndLine, loc.SymbolName
+	c.Duplicates = c.Duplicates[1:]
+	return true
 }
 
 // NewChunk creates a new chunk with auto-generated ID and hash
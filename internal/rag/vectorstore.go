@@ -1,17 +1,182 @@
 package rag
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
 
 // VectorStore stores and searches embeddings
 type VectorStore interface {
 	Insert(chunk *Chunk, embedding []float32) error
 	InsertBatch(chunks []*Chunk, embeddings [][]float32) error
-	Search(queryEmbedding []float32, topK int) ([]*SearchResult, error)
+	// Search returns the topK chunks passing filter that are nearest to
+	// queryEmbedding, best first.
+	Search(queryEmbedding []float32, topK int, filter SearchFilter) ([]*SearchResult, error)
 	Delete(filePath string) error
+	// DeleteWhere deletes every file whose path matches pattern, a glob
+	// as in SearchFilter.Path, and returns how many there were.
+	DeleteWhere(pattern string) (int, error)
 	Count() int
 	Clear() error
 }
 
+// DimensionMismatchError reports embeddings of another size than those a
+// store already holds, which means the embedding model changed since the
+// index was built.
+type DimensionMismatchError struct {
+	Index     int // dimensions of the stored vectors
+	Embedding int
+}
+
+func (e *DimensionMismatchError) Error() string {
+	return fmt.Sprintf("index holds %d-dimensional embeddings but got %d; re-embed the index with the new model or switch the embedder back",
+		e.Index, e.Embedding)
+}
+
+// SummaryStore is implemented by vector stores that can persist LLM
+// summaries of chunks, keyed by content hash so they survive re-indexing.
+type SummaryStore interface {
+	UnsummarizedChunks(limit int) ([]*Chunk, error)
+	SaveSummaries(summaries map[string]string) error
+}
+
+// EmbeddingMetadata identifies the embedding space of a store's vectors.
+// Vectors from different models cannot be compared, even at the same size.
+type EmbeddingMetadata struct {
+	Model      string // empty when the store predates recording it
+	Dimensions int
+}
+
+// MetadataStore is implemented by vector stores that record which
+// embedding model produced their vectors.
+type MetadataStore interface {
+	// EmbeddingMetadata returns the recorded model, or the zero value for
+	// an empty store.
+	EmbeddingMetadata() (EmbeddingMetadata, error)
+	SetEmbeddingMetadata(meta EmbeddingMetadata) error
+}
+
+// ChunkModelStore is implemented by vector stores that record which
+// embedding model produced each chunk, so an index left with vectors of
+// several models, whose scores cannot be compared, is caught.
+type ChunkModelStore interface {
+	// EmbeddingModels counts the stored chunks by embedding model. Chunks
+	// stored before models were recorded count under "".
+	EmbeddingModels() (map[string]int, error)
+}
+
+// StatsStore is implemented by vector stores that keep the indexer's
+// statistics, so a new process reports them too. Only the file count and
+// the time of the last update are kept; the rest comes from the store and
+// its embedding metadata. Clear drops them.
+type StatsStore interface {
+	// IndexStats returns the saved statistics, or the zero value if none
+	// were saved.
+	IndexStats() (IndexStats, error)
+	SaveIndexStats(stats IndexStats) error
+}
+
+// FileHashStore is implemented by vector stores that record the content
+// hash of each indexed file, so an update can skip unchanged files. Delete
+// and Clear drop the hashes along with the chunks.
+type FileHashStore interface {
+	FileHashes() (map[string]string, error)
+	SetFileHashes(hashes map[string]string) error
+}
+
+// ChunkSource is implemented by vector stores that can list their chunks,
+// with summaries, so they can be re-embedded without re-reading files.
+type ChunkSource interface {
+	Chunks() ([]*Chunk, error)
+}
+
+// DedupStore is implemented by vector stores that keep each distinct chunk,
+// identified by its content hash, once however many files it appears in.
+// Inserting a chunk whose content is stored records its location as a
+// duplicate instead, and the chunks they return list their duplicates.
+type DedupStore interface {
+	// AddDuplicates records the chunks whose content is already stored as
+	// duplicates and returns the others, which still need embedding.
+	AddDuplicates(chunks []*Chunk) ([]*Chunk, error)
+}
+
+// KeywordSearcher is implemented by vector stores that keep a BM25 index
+// of chunk contents alongside the vectors, for queries dense retrieval
+// misses: exact identifiers and error strings. It is kept up to date by
+// InsertBatch, Delete and Clear.
+type KeywordSearcher interface {
+	// KeywordSearch returns the topK chunks scoring highest for query,
+	// best first, with their BM25 scores and Source "keyword".
+	KeywordSearch(query string, topK int) ([]*SearchResult, error)
+}
+
+// StoreConfig selects where a project's embeddings are kept.
+type StoreConfig struct {
+	Provider string `json:"provider,omitempty"` // sqlite (default), pgvector or memory
+	// URL is the PostgreSQL connection string of a pgvector store. Leave
+	// the password out and set PGPASSWORD, or set PGVECTOR_URL instead.
+	URL string `json:"url,omitempty"`
+	// Index names the project's index in a shared database; it defaults to
+	// the project directory's name.
+	Index string `json:"index,omitempty"`
+	// Snapshot is the gob file a memory store loads and saves, relative to
+	// the project; it defaults to .index/rag_vectors.gob, and "none" keeps
+	// the index in memory only.
+	Snapshot string `json:"snapshot,omitempty"`
+	// Quantization shrinks the vectors of a SQLite store: float32
+	// (default), float16 (half the size) or int8 (a quarter).
+	Quantization string `json:"quantization,omitempty"`
+}
+
+// NewVectorStore opens the store cfg selects for the project at
+// projectPath, for vectors of dims dimensions.
+func NewVectorStore(cfg StoreConfig, projectPath string, dims int) (VectorStore, error) {
+	switch cfg.Provider {
+	case "", "sqlite":
+		q, err := ParseQuantization(cfg.Quantization)
+		if err != nil {
+			return nil, err
+		}
+		store, err := NewSQLiteVectorStore(filepath.Join(projectPath, ".index", "rag_vectors.db"), dims)
+		if err != nil {
+			return nil, err
+		}
+		if err := store.SetQuantization(q); err != nil {
+			store.Close()
+			return nil, err
+		}
+		return store, nil
+	case "pgvector":
+		url := cfg.URL
+		if url == "" {
+			url = os.Getenv("PGVECTOR_URL")
+		}
+		if url == "" {
+			return nil, fmt.Errorf("pgvector store needs rag.store.url or PGVECTOR_URL")
+		}
+		name := cfg.Index
+		if name == "" {
+			name = filepath.Base(projectPath)
+		}
+		return NewPgVectorStore(url, name, projectPath, dims)
+	case "memory":
+		path := cfg.Snapshot
+		switch {
+		case path == "":
+			path = filepath.Join(projectPath, ".index", "rag_vectors.gob")
+		case path == "none":
+			path = ""
+		case !filepath.IsAbs(path):
+			path = filepath.Join(projectPath, path)
+		}
+		return NewMemoryVectorStore(path, dims)
+	default:
+		return nil, fmt.Errorf("unknown vector store %q (want sqlite, pgvector or memory)", cfg.Provider)
+	}
+}
+
 // Helper functions
 
 func cosineSimilarity(a, b []float32) float32 {
@@ -0,0 +1,461 @@
+package rag
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// memorySnapshotVersion is bumped whenever the snapshot format changes.
+const memorySnapshotVersion = 1
+
+// MemoryVectorStore keeps embeddings in memory and searches them
+// exhaustively, for indexes that fit in RAM such as in CI runs. With a
+// snapshot path it loads the snapshot when created and writes it back on
+// Close if anything changed. Its keyword index is rebuilt on load rather
+// than saved.
+type MemoryVectorStore struct {
+	mu         sync.RWMutex
+	dims       int
+	path       string
+	chunks     map[string]*Chunk // by ID
+	vectors    map[string][]float32
+	meta       EmbeddingMetadata
+	stats      IndexStats // file count and last update only
+	fileHashes map[string]string
+	summaries  map[string]string // by chunk content hash
+	keywords   *bm25Index
+	dirty      bool
+}
+
+type memorySnapshot struct {
+	Version    int
+	Meta       EmbeddingMetadata
+	Stats      IndexStats
+	Dims       int
+	Chunks     []*Chunk
+	Vectors    []byte // little-endian float32s, Dims per chunk
+	FileHashes map[string]string
+	Summaries  map[string]string
+}
+
+// NewMemoryVectorStore creates an in-memory store for vectors of dims
+// dimensions, loading the snapshot at path if there is one. An empty path
+// keeps nothing on disk. As in the SQLite store, a snapshot keeps the
+// dimensions of its vectors whatever dims is: CheckEmbedder reports a
+// model change, and inserts of another size fail until the index is
+// cleared for a rebuild.
+func NewMemoryVectorStore(path string, dims int) (*MemoryVectorStore, error) {
+	s := &MemoryVectorStore{dims: dims, path: path}
+	s.reset()
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+	var snap memorySnapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snap.Version != memorySnapshotVersion {
+		return nil, fmt.Errorf("snapshot %s has an old format; rebuild the index", path)
+	}
+	vecs, err := decodeEmbedding(snap.Vectors, len(snap.Chunks)*snap.Dims)
+	if err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if len(snap.Chunks) > 0 {
+		s.dims = snap.Dims
+	}
+	for i, chunk := range snap.Chunks {
+		s.chunks[chunk.ID] = chunk
+		s.vectors[chunk.ID] = vecs[i*snap.Dims : (i+1)*snap.Dims]
+		s.keywords.add(chunk.ID, keywordText(chunk))
+	}
+	s.meta = snap.Meta
+	s.stats = snap.Stats
+	if s.meta.Dimensions == 0 {
+		s.meta.Dimensions = snap.Dims
+	}
+	if snap.FileHashes != nil {
+		s.fileHashes = snap.FileHashes
+	}
+	if snap.Summaries != nil {
+		s.summaries = snap.Summaries
+	}
+	return s, nil
+}
+
+func (s *MemoryVectorStore) reset() {
+	s.chunks = make(map[string]*Chunk)
+	s.vectors = make(map[string][]float32)
+	s.meta = EmbeddingMetadata{}
+	s.stats = IndexStats{}
+	s.fileHashes = make(map[string]string)
+	s.summaries = make(map[string]string)
+	s.keywords = newBM25Index()
+}
+
+func (s *MemoryVectorStore) Insert(chunk *Chunk, embedding []float32) error {
+	return s.InsertBatch([]*Chunk{chunk}, [][]float32{embedding})
+}
+
+func (s *MemoryVectorStore) InsertBatch(chunks []*Chunk, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("chunks and embeddings length mismatch: %d vs %d", len(chunks), len(embeddings))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, emb := range embeddings {
+		if s.dims == 0 && len(s.chunks) == 0 {
+			s.dims = len(emb)
+		}
+		if len(emb) != s.dims {
+			return &DimensionMismatchError{Index: s.dims, Embedding: len(emb)}
+		}
+		if existing, ok := s.chunks[chunks[i].ID]; ok && !sameLocation(existing, chunks[i]) {
+			existing.addDuplicate(chunks[i].Location())
+			for _, d := range chunks[i].Duplicates {
+				existing.addDuplicate(d)
+			}
+			continue
+		}
+		chunk := *chunks[i]
+		chunk.Summary, chunk.Header = "", ""
+		chunk.Duplicates = slices.Clone(chunk.Duplicates)
+		s.chunks[chunk.ID] = &chunk
+		s.vectors[chunk.ID] = append([]float32(nil), emb...)
+		s.keywords.add(chunk.ID, keywordText(&chunk))
+	}
+	s.dirty = true
+	return nil
+}
+
+func (s *MemoryVectorStore) Search(queryEmbedding []float32, topK int, filter SearchFilter) ([]*SearchResult, error) {
+	m, err := newChunkMatcher(filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path filter %q: %w", filter.Path, err)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	results := make([]*SearchResult, 0, len(s.chunks))
+	for id, chunk := range s.chunks {
+		if !m.match(chunk) {
+			continue
+		}
+		results = append(results, &SearchResult{
+			Chunk:  s.withSummary(chunk),
+			Score:  cosineSimilarity(queryEmbedding, s.vectors[id]),
+			Source: "rag",
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if topK > len(results) {
+		topK = len(results)
+	}
+	return results[:topK], nil
+}
+
+// withSummary returns a copy of chunk carrying its summary, if any.
+func (s *MemoryVectorStore) withSummary(chunk *Chunk) *Chunk {
+	c := *chunk
+	c.Summary = s.summaries[c.Hash]
+	c.Duplicates = slices.Clone(c.Duplicates)
+	return &c
+}
+
+// AddDuplicates implements DedupStore.
+func (s *MemoryVectorStore) AddDuplicates(chunks []*Chunk) ([]*Chunk, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var missing []*Chunk
+	for _, chunk := range chunks {
+		existing, ok := s.chunks[chunk.ID]
+		if !ok {
+			missing = append(missing, chunk)
+			continue
+		}
+		existing.addDuplicate(chunk.Location())
+		for _, d := range chunk.Duplicates {
+			existing.addDuplicate(d)
+		}
+		s.dirty = true
+	}
+	return missing, nil
+}
+
+// KeywordSearch implements KeywordSearcher.
+func (s *MemoryVectorStore) KeywordSearch(query string, topK int) ([]*SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var results []*SearchResult
+	for _, hit := range s.keywords.search(query, topK) {
+		results = append(results, &SearchResult{
+			Chunk:  s.withSummary(s.chunks[hit.id]),
+			Score:  float32(hit.score),
+			Source: "keyword",
+		})
+	}
+	return results, nil
+}
+
+func (s *MemoryVectorStore) Delete(filePath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delete(filePath)
+	return nil
+}
+
+// DeleteWhere implements VectorStore.
+func (s *MemoryVectorStore) DeleteWhere(pattern string) (int, error) {
+	re, err := regexp.Compile(globRegexp(pattern))
+	if err != nil {
+		return 0, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths := make(map[string]bool)
+	for _, chunk := range s.chunks {
+		for _, loc := range chunk.Locations() {
+			if re.MatchString(loc.FilePath) {
+				paths[loc.FilePath] = true
+			}
+		}
+	}
+	for path := range s.fileHashes {
+		if re.MatchString(path) {
+			paths[path] = true
+		}
+	}
+	for path := range paths {
+		s.delete(path)
+	}
+	return len(paths), nil
+}
+
+// delete removes filePath's chunks and hash. Callers hold s.mu.
+func (s *MemoryVectorStore) delete(filePath string) {
+	for id, chunk := range s.chunks {
+		moved := chunk.FilePath == filePath
+		if !chunk.dropFile(filePath) {
+			delete(s.chunks, id)
+			delete(s.vectors, id)
+			s.keywords.remove(id)
+		} else if moved {
+			s.keywords.add(id, keywordText(chunk))
+		}
+	}
+	delete(s.fileHashes, filePath)
+	s.dirty = true
+}
+
+func (s *MemoryVectorStore) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.chunks)
+}
+
+// Clear drops every chunk, the recorded model and the file hashes, so the
+// index can be rebuilt with a model of another size. Summaries are kept,
+// as in the SQLite store.
+func (s *MemoryVectorStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summaries := s.summaries
+	s.reset()
+	s.summaries = summaries
+	s.dims = 0
+	s.dirty = true
+	return nil
+}
+
+// EmbeddingMetadata implements MetadataStore.
+func (s *MemoryVectorStore) EmbeddingMetadata() (EmbeddingMetadata, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta, nil
+}
+
+// EmbeddingModels implements ChunkModelStore.
+func (s *MemoryVectorStore) EmbeddingModels() (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	models := make(map[string]int)
+	for _, chunk := range s.chunks {
+		models[chunk.EmbeddingModel]++
+	}
+	return models, nil
+}
+
+// IndexStats implements StatsStore.
+func (s *MemoryVectorStore) IndexStats() (IndexStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stats, nil
+}
+
+// SaveIndexStats implements StatsStore.
+func (s *MemoryVectorStore) SaveIndexStats(stats IndexStats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats = IndexStats{TotalFiles: stats.TotalFiles, LastUpdated: stats.LastUpdated}
+	s.dirty = true
+	return nil
+}
+
+// SetEmbeddingMetadata implements MetadataStore.
+func (s *MemoryVectorStore) SetEmbeddingMetadata(meta EmbeddingMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.meta = meta
+	if meta.Dimensions > 0 {
+		s.dims = meta.Dimensions
+	}
+	s.dirty = true
+	return nil
+}
+
+// FileHashes implements FileHashStore.
+func (s *MemoryVectorStore) FileHashes() (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hashes := make(map[string]string, len(s.fileHashes))
+	for path, hash := range s.fileHashes {
+		hashes[path] = hash
+	}
+	return hashes, nil
+}
+
+// SetFileHashes implements FileHashStore.
+func (s *MemoryVectorStore) SetFileHashes(hashes map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for path, hash := range hashes {
+		s.fileHashes[path] = hash
+	}
+	s.dirty = true
+	return nil
+}
+
+// Chunks implements ChunkSource.
+func (s *MemoryVectorStore) Chunks() ([]*Chunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedChunks(func(*Chunk) bool { return true }, 0), nil
+}
+
+// UnsummarizedChunks implements SummaryStore.
+func (s *MemoryVectorStore) UnsummarizedChunks(limit int) ([]*Chunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedChunks(func(c *Chunk) bool {
+		_, ok := s.summaries[c.Hash]
+		return !ok
+	}, limit), nil
+}
+
+// sortedChunks returns copies of the chunks keep selects, ordered by file
+// and line, up to limit when it is positive.
+func (s *MemoryVectorStore) sortedChunks(keep func(*Chunk) bool, limit int) []*Chunk {
+	var chunks []*Chunk
+	for _, chunk := range s.chunks {
+		if keep(chunk) {
+			chunks = append(chunks, s.withSummary(chunk))
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		if chunks[i].FilePath != chunks[j].FilePath {
+			return chunks[i].FilePath < chunks[j].FilePath
+		}
+		return chunks[i].StartLine < chunks[j].StartLine
+	})
+	if limit > 0 && len(chunks) > limit {
+		chunks = chunks[:limit]
+	}
+	return chunks
+}
+
+// SaveSummaries implements SummaryStore.
+func (s *MemoryVectorStore) SaveSummaries(summaries map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, summary := range summaries {
+		s.summaries[hash] = summary
+	}
+	s.dirty = true
+	return nil
+}
+
+// Save writes the snapshot to a temporary file and renames it into place,
+// so a concurrent load never reads a partial snapshot. It does nothing
+// without a snapshot path.
+func (s *MemoryVectorStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save()
+}
+
+func (s *MemoryVectorStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	snap := memorySnapshot{
+		Version:    memorySnapshotVersion,
+		Meta:       s.meta,
+		Stats:      s.stats,
+		Dims:       s.dims,
+		Chunks:     make([]*Chunk, 0, len(s.chunks)),
+		FileHashes: s.fileHashes,
+		Summaries:  s.summaries,
+	}
+	vecs := make([]float32, 0, len(s.chunks)*s.dims)
+	for id, chunk := range s.chunks {
+		if len(s.vectors[id]) != s.dims {
+			return fmt.Errorf("save snapshot: chunk %s has %d dimensions, store has %d", id, len(s.vectors[id]), s.dims)
+		}
+		snap.Chunks = append(snap.Chunks, chunk)
+		vecs = append(vecs, s.vectors[id]...)
+	}
+	snap.Vectors = encodeEmbedding(vecs)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create snapshot directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// Close saves the snapshot if the store changed since it was loaded.
+func (s *MemoryVectorStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	return s.save()
+}
@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openMemoryIndexer opens the snapshot at path for an embedder of dims.
+func openMemoryIndexer(t *testing.T, path string, dims int) (*RAGIndexer, *MemoryVectorStore) {
+	t.Helper()
+	store, err := NewMemoryVectorStore(path, dims)
+	if err != nil {
+		t.Fatalf("NewMemoryVectorStore(%d dims): %v", dims, err)
+	}
+	return NewRAGIndexer(NewMockEmbedder(dims), store), store
+}
+
+func TestMemoryStoreMovesToNewModel(t *testing.T) {
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, "main.go"), []byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := filepath.Join(t.TempDir(), "index.gob")
+
+	old, store := openMemoryIndexer(t, snapshot, 8)
+	if err := old.IndexProject(project); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A model of another size opens the snapshot, is told to rebuild, and
+	// can rebuild in place.
+	r, store := openMemoryIndexer(t, snapshot, 16)
+	var mismatch *EmbedderMismatchError
+	if _, err := r.Search("main", 1, SearchFilter{}); !errors.As(err, &mismatch) {
+		t.Fatalf("Search with a 16-dim embedder: err = %v, want an *EmbedderMismatchError", err)
+	}
+	if err := r.IndexProject(project); err != nil {
+		t.Fatalf("rebuild with a 16-dim embedder: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, store = openMemoryIndexer(t, snapshot, 16)
+	defer store.Close()
+	results, err := r.Search("main", 1, SearchFilter{})
+	if err != nil {
+		t.Fatalf("Search after the rebuild: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if meta, _ := store.EmbeddingMetadata(); meta.Dimensions != 16 {
+		t.Errorf("recorded dimensions = %d, want 16", meta.Dimensions)
+	}
+}
+
+func TestMemoryStoreRejectsOtherSizes(t *testing.T) {
+	store, err := NewMemoryVectorStore("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Insert(&Chunk{ID: "a", FilePath: "a.go"}, make([]float32, 4)); err != nil {
+		t.Fatal(err)
+	}
+	var mismatch *DimensionMismatchError
+	if err := store.Insert(&Chunk{ID: "b", FilePath: "b.go"}, make([]float32, 6)); !errors.As(err, &mismatch) {
+		t.Fatalf("insert of another size: err = %v, want a *DimensionMismatchError", err)
+	}
+	if err := store.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Insert(&Chunk{ID: "b", FilePath: "b.go"}, make([]float32, 6)); err != nil {
+		t.Fatalf("insert after Clear: %v", err)
+	}
+}
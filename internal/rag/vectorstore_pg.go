@@ -0,0 +1,543 @@
+package rag
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// pgvectorMaxIndexedDims is the largest vector pgvector builds an HNSW
+// index for; larger embeddings are searched exhaustively.
+const pgvectorMaxIndexedDims = 2000
+
+// PgVectorStore keeps embeddings in PostgreSQL with the pgvector extension,
+// so several developers and the MCP server can share one index. Each named
+// index has its own tables. File paths are stored relative to the project
+// root, so checkouts at different paths see the same files.
+type PgVectorStore struct {
+	db     *sql.DB
+	root   string
+	dims   int
+	prefix string // of the index's table names
+
+	chunks, meta, files string // quoted table names
+}
+
+var pgNameUnsafe = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// NewPgVectorStore connects to the database at connString (a postgres://
+// URL or key=value list; PG* environment variables fill in the rest) and
+// creates the tables of the index name if needed. root is the project the
+// index belongs to.
+func NewPgVectorStore(connString, name, root string, dims int) (*PgVectorStore, error) {
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to postgres: %w", err)
+	}
+
+	prefix := "rag_" + strings.Trim(pgNameUnsafe.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if len(prefix) > 48 {
+		prefix = prefix[:48] // leave room for suffixes within the 63 byte limit
+	}
+	s := &PgVectorStore{
+		db:     db,
+		root:   root,
+		dims:   dims,
+		prefix: prefix,
+	}
+	s.chunks, s.meta, s.files = s.name("chunks"), s.name("meta"), s.name("files")
+	if err := s.initSchema(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *PgVectorStore) initSchema() error {
+	schema := `
+CREATE EXTENSION IF NOT EXISTS vector;
+CREATE TABLE IF NOT EXISTS rag_chunk_summaries (
+  hash TEXT PRIMARY KEY,
+  summary TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS ` + s.meta + ` (
+  key TEXT PRIMARY KEY,
+  value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS ` + s.files + ` (
+  file_path TEXT PRIMARY KEY,
+  hash TEXT NOT NULL
+);`
+	if _, err := s.db.Exec(schema); err != nil {
+		return fmt.Errorf("init schema: %w", err)
+	}
+	if s.dims <= 0 {
+		// The embedder's size is unknown; an existing index knows its own.
+		meta, err := s.EmbeddingMetadata()
+		if err != nil {
+			return err
+		}
+		s.dims = meta.Dimensions
+	}
+	return s.createChunks()
+}
+
+// createChunks creates the chunk table for vectors of s.dims dimensions,
+// with an HNSW index for cosine distance when pgvector supports one.
+func (s *PgVectorStore) createChunks() error {
+	if s.dims <= 0 {
+		return fmt.Errorf("pgvector store needs the embedding dimensions")
+	}
+	schema := `
+CREATE TABLE IF NOT EXISTS ` + s.chunks + ` (
+  id TEXT PRIMARY KEY,
+  file_path TEXT NOT NULL,
+  start_line INTEGER,
+  end_line INTEGER,
+  chunk_type TEXT,
+  symbol_name TEXT,
+  language TEXT,
+  content TEXT,
+  token_count INTEGER,
+  hash TEXT,
+  embedding vector(` + strconv.Itoa(s.dims) + `) NOT NULL,
+  embedding_model TEXT NOT NULL DEFAULT ''
+);
+ALTER TABLE ` + s.chunks + ` ADD COLUMN IF NOT EXISTS embedding_model TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS ` + s.name("chunks_file") + ` ON ` + s.chunks + ` (file_path);`
+	if s.dims <= pgvectorMaxIndexedDims {
+		schema += `
+CREATE INDEX IF NOT EXISTS ` + s.name("chunks_embedding") + ` ON ` + s.chunks + ` USING hnsw (embedding vector_cosine_ops);`
+	}
+	if _, err := s.db.Exec(schema); err != nil {
+		return fmt.Errorf("init schema: %w", err)
+	}
+	return nil
+}
+
+// name returns the quoted name of one of the index's tables or indexes.
+func (s *PgVectorStore) name(suffix string) string {
+	return pq.QuoteIdentifier(s.prefix + "_" + suffix)
+}
+
+// relPath converts an indexed file path for storage.
+func (s *PgVectorStore) relPath(path string) string {
+	rel, err := filepath.Rel(s.root, path)
+	if err == nil && filepath.IsAbs(path) && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// absPath converts a stored file path back to one in this checkout.
+func (s *PgVectorStore) absPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.root, filepath.FromSlash(path))
+}
+
+// vectorLiteral formats vec as pgvector's text input, [1,2,3].
+func vectorLiteral(vec []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range vec {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func (s *PgVectorStore) Insert(chunk *Chunk, embedding []float32) error {
+	return s.InsertBatch([]*Chunk{chunk}, [][]float32{embedding})
+}
+
+func (s *PgVectorStore) InsertBatch(chunks []*Chunk, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("chunks and embeddings length mismatch: %d vs %d", len(chunks), len(embeddings))
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	stmt, err := tx.Prepare(`
+INSERT INTO ` + s.chunks + `
+  (id, file_path, start_line, end_line, chunk_type, symbol_name, language, content, token_count, hash, embedding, embedding_model)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11::vector, $12)
+ON CONFLICT (id) DO UPDATE SET
+  file_path = EXCLUDED.file_path, start_line = EXCLUDED.start_line, end_line = EXCLUDED.end_line,
+  chunk_type = EXCLUDED.chunk_type, symbol_name = EXCLUDED.symbol_name, language = EXCLUDED.language,
+  content = EXCLUDED.content, token_count = EXCLUDED.token_count, hash = EXCLUDED.hash, embedding = EXCLUDED.embedding,
+  embedding_model = EXCLUDED.embedding_model`)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, chunk := range chunks {
+		if len(embeddings[i]) != s.dims {
+			_ = tx.Rollback()
+			return &DimensionMismatchError{Index: s.dims, Embedding: len(embeddings[i])}
+		}
+		if _, err := stmt.Exec(
+			chunk.ID,
+			s.relPath(chunk.FilePath),
+			chunk.StartLine,
+			chunk.EndLine,
+			chunk.ChunkType,
+			chunk.SymbolName,
+			chunk.Language,
+			chunk.Content,
+			chunk.TokenCount,
+			chunk.Hash,
+			vectorLiteral(embeddings[i]),
+			chunk.EmbeddingModel,
+		); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("insert chunk %s: %w", chunk.FilePath, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// Search returns the topK chunks passing filter nearest to queryEmbedding
+// by cosine distance, through the HNSW index when there is one.
+func (s *PgVectorStore) Search(queryEmbedding []float32, topK int, filter SearchFilter) ([]*SearchResult, error) {
+	where, args := s.filter(filter, vectorLiteral(queryEmbedding), topK)
+	rows, err := s.db.Query(`
+SELECT c.id, c.file_path, c.start_line, c.end_line, c.chunk_type, c.symbol_name, c.language, c.content, c.token_count, c.hash, COALESCE(s.summary, ''),
+  1 - (c.embedding <=> $1::vector)
+FROM `+s.chunks+` c LEFT JOIN rag_chunk_summaries s ON s.hash = c.hash`+where+`
+ORDER BY c.embedding <=> $1::vector
+LIMIT $2`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*SearchResult
+	for rows.Next() {
+		c := &Chunk{}
+		var score float64
+		if err := rows.Scan(&c.ID, &c.FilePath, &c.StartLine, &c.EndLine, &c.ChunkType, &c.SymbolName, &c.Language, &c.Content, &c.TokenCount, &c.Hash, &c.Summary, &score); err != nil {
+			return nil, fmt.Errorf("scan chunk: %w", err)
+		}
+		c.FilePath = s.absPath(c.FilePath)
+		results = append(results, &SearchResult{Chunk: c, Score: float32(score), Source: "rag"})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+	return results, nil
+}
+
+// filter returns the WHERE clause, over chunks aliased c, that keeps the
+// chunks passing f, with args followed by its own arguments.
+func (s *PgVectorStore) filter(f SearchFilter, args ...any) (string, []any) {
+	var conds []string
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	in := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		params := make([]string, len(values))
+		for i, v := range values {
+			params[i] = arg(v)
+		}
+		conds = append(conds, column+` IN (`+strings.Join(params, `, `)+`)`)
+	}
+	in(`c.language`, f.Languages)
+	in(`c.chunk_type`, f.ChunkTypes)
+	if f.Path != "" {
+		conds = append(conds, `c.file_path ~ `+arg(globRegexp(s.relPath(f.Path))))
+	}
+	if f.SymbolPrefix != "" {
+		p := arg(f.SymbolPrefix)
+		conds = append(conds, `substr(c.symbol_name, 1, length(`+p+`)) = `+p)
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return `
+WHERE ` + strings.Join(conds, ` AND `), args
+}
+
+func (s *PgVectorStore) Delete(filePath string) error {
+	path := s.relPath(filePath)
+	for _, table := range []string{s.chunks, s.files} {
+		if _, err := s.db.Exec(`DELETE FROM `+table+` WHERE file_path = $1`, path); err != nil {
+			return fmt.Errorf("delete %s: %w", filePath, err)
+		}
+	}
+	return nil
+}
+
+// DeleteWhere implements VectorStore.
+func (s *PgVectorStore) DeleteWhere(pattern string) (int, error) {
+	re := globRegexp(s.relPath(pattern))
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	var count int
+	if err := tx.QueryRow(`
+SELECT COUNT(*) FROM (
+  SELECT file_path FROM `+s.chunks+` WHERE file_path ~ $1
+  UNION SELECT file_path FROM `+s.files+` WHERE file_path ~ $1
+) matched`, re).Scan(&count); err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("count files matching %s: %w", pattern, err)
+	}
+	for _, table := range []string{s.chunks, s.files} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE file_path ~ $1`, re); err != nil {
+			_ = tx.Rollback()
+			return 0, fmt.Errorf("delete files matching %s: %w", pattern, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return count, nil
+}
+
+func (s *PgVectorStore) Count() int {
+	var count int
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM ` + s.chunks).Scan(&count)
+	return count
+}
+
+// Clear empties the index. The chunk table is recreated, so a rebuild
+// with a model of another size can store its vectors.
+func (s *PgVectorStore) Clear() error {
+	if _, err := s.db.Exec(`DROP TABLE IF EXISTS ` + s.chunks + `; DELETE FROM ` + s.meta + `; DELETE FROM ` + s.files); err != nil {
+		return fmt.Errorf("clear chunks: %w", err)
+	}
+	return s.createChunks()
+}
+
+// EmbeddingMetadata implements MetadataStore.
+func (s *PgVectorStore) EmbeddingMetadata() (EmbeddingMetadata, error) {
+	var meta EmbeddingMetadata
+	rows, err := s.db.Query(`SELECT key, value FROM ` + s.meta + ` WHERE key IN ('embedding_model', 'dimensions')`)
+	if err != nil {
+		return meta, fmt.Errorf("select index metadata: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return meta, fmt.Errorf("scan index metadata: %w", err)
+		}
+		switch key {
+		case "embedding_model":
+			meta.Model = value
+		case "dimensions":
+			meta.Dimensions, _ = strconv.Atoi(value)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return meta, fmt.Errorf("iterate index metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// SetEmbeddingMetadata implements MetadataStore.
+func (s *PgVectorStore) SetEmbeddingMetadata(meta EmbeddingMetadata) error {
+	_, err := s.db.Exec(`INSERT INTO `+s.meta+` (key, value) VALUES ('embedding_model', $1), ('dimensions', $2)
+ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value`, meta.Model, strconv.Itoa(meta.Dimensions))
+	if err != nil {
+		return fmt.Errorf("save index metadata: %w", err)
+	}
+	return nil
+}
+
+// EmbeddingModels implements ChunkModelStore.
+func (s *PgVectorStore) EmbeddingModels() (map[string]int, error) {
+	rows, err := s.db.Query(`SELECT embedding_model, COUNT(*) FROM ` + s.chunks + ` GROUP BY embedding_model`)
+	if err != nil {
+		return nil, fmt.Errorf("count chunks by model: %w", err)
+	}
+	defer rows.Close()
+	models := make(map[string]int)
+	for rows.Next() {
+		var model string
+		var count int
+		if err := rows.Scan(&model, &count); err != nil {
+			return nil, fmt.Errorf("scan model count: %w", err)
+		}
+		models[model] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate model counts: %w", err)
+	}
+	return models, nil
+}
+
+// IndexStats implements StatsStore.
+func (s *PgVectorStore) IndexStats() (IndexStats, error) {
+	var stats IndexStats
+	rows, err := s.db.Query(`SELECT key, value FROM ` + s.meta + ` WHERE key IN ('total_files', 'last_updated')`)
+	if err != nil {
+		return stats, fmt.Errorf("select index stats: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return stats, fmt.Errorf("scan index stats: %w", err)
+		}
+		switch key {
+		case "total_files":
+			stats.TotalFiles, _ = strconv.Atoi(value)
+		case "last_updated":
+			stats.LastUpdated = value
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("iterate index stats: %w", err)
+	}
+	return stats, nil
+}
+
+// SaveIndexStats implements StatsStore.
+func (s *PgVectorStore) SaveIndexStats(stats IndexStats) error {
+	_, err := s.db.Exec(`INSERT INTO `+s.meta+` (key, value) VALUES ('total_files', $1), ('last_updated', $2)
+ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value`, strconv.Itoa(stats.TotalFiles), stats.LastUpdated)
+	if err != nil {
+		return fmt.Errorf("save index stats: %w", err)
+	}
+	return nil
+}
+
+// FileHashes implements FileHashStore.
+func (s *PgVectorStore) FileHashes() (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT file_path, hash FROM ` + s.files)
+	if err != nil {
+		return nil, fmt.Errorf("select file hashes: %w", err)
+	}
+	defer rows.Close()
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var path, hash string
+		if err := rows.Scan(&path, &hash); err != nil {
+			return nil, fmt.Errorf("scan file hash: %w", err)
+		}
+		hashes[s.absPath(path)] = hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate file hashes: %w", err)
+	}
+	return hashes, nil
+}
+
+// SetFileHashes implements FileHashStore.
+func (s *PgVectorStore) SetFileHashes(hashes map[string]string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	for path, hash := range hashes {
+		if _, err := tx.Exec(`INSERT INTO `+s.files+` (file_path, hash) VALUES ($1, $2)
+ON CONFLICT (file_path) DO UPDATE SET hash = EXCLUDED.hash`, s.relPath(path), hash); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("save file hash: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// Chunks implements ChunkSource.
+func (s *PgVectorStore) Chunks() ([]*Chunk, error) {
+	return s.queryChunks(`
+SELECT c.id, c.file_path, c.start_line, c.end_line, c.chunk_type, c.symbol_name, c.language, c.content, c.token_count, c.hash, COALESCE(s.summary, '')
+FROM ` + s.chunks + ` c LEFT JOIN rag_chunk_summaries s ON s.hash = c.hash
+ORDER BY c.file_path, c.start_line`)
+}
+
+// UnsummarizedChunks implements SummaryStore.
+func (s *PgVectorStore) UnsummarizedChunks(limit int) ([]*Chunk, error) {
+	query := `
+SELECT c.id, c.file_path, c.start_line, c.end_line, c.chunk_type, c.symbol_name, c.language, c.content, c.token_count, c.hash, ''
+FROM ` + s.chunks + ` c LEFT JOIN rag_chunk_summaries s ON s.hash = c.hash
+WHERE s.hash IS NULL
+ORDER BY c.file_path, c.start_line`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	return s.queryChunks(query)
+}
+
+func (s *PgVectorStore) queryChunks(query string) ([]*Chunk, error) {
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("select chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []*Chunk
+	for rows.Next() {
+		c := &Chunk{}
+		if err := rows.Scan(&c.ID, &c.FilePath, &c.StartLine, &c.EndLine, &c.ChunkType, &c.SymbolName, &c.Language, &c.Content, &c.TokenCount, &c.Hash, &c.Summary); err != nil {
+			return nil, fmt.Errorf("scan chunk: %w", err)
+		}
+		c.FilePath = s.absPath(c.FilePath)
+		chunks = append(chunks, c)
+	}
+	return chunks, rows.Err()
+}
+
+// SaveSummaries implements SummaryStore. Summaries are shared by every
+// index in the database, since they are keyed by content.
+func (s *PgVectorStore) SaveSummaries(summaries map[string]string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	stmt, err := tx.Prepare(`INSERT INTO rag_chunk_summaries (hash, summary) VALUES ($1, $2)
+ON CONFLICT (hash) DO UPDATE SET summary = EXCLUDED.summary`)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for hash, summary := range summaries {
+		if _, err := stmt.Exec(hash, summary); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("insert summary %s: %w", hash, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// Close releases the connection pool.
+func (s *PgVectorStore) Close() error {
+	return s.db.Close()
+}
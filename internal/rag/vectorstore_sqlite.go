@@ -8,18 +8,38 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 
 	_ "modernc.org/sqlite" // Pure Go SQLite driver
 )
 
-// SQLiteVectorStore persists embeddings in a SQLite database.
+// annMinChunks is the size from which searches go through an approximate
+// nearest neighbour index instead of scoring every stored vector.
+const annMinChunks = 10000
+
+// SQLiteVectorStore persists embeddings in a SQLite database. Once it holds
+// annMinChunks chunks, searches use an HNSW graph built on first use and
+// saved next to the database. Keyword search uses an FTS5 table of chunk
+// terms. Each distinct chunk is stored once, with the other places it
+// appears in chunk_duplicates.
 type SQLiteVectorStore struct {
-	db   *sql.DB
-	dims int
-	mu   sync.RWMutex
+	db    *sql.DB
+	path  string
+	dims  int          // of the stored vectors; 0 takes the size of the first insert
+	quant Quantization // of vectors written from now on
+	mu    sync.RWMutex
+
+	annMu    sync.Mutex // serialises searches using ann, which may update it
+	ann      *hnswIndex
+	annDirty bool // ann changed since it was saved
 }
 
+// NewSQLiteVectorStore opens the store at dbPath, creating it for vectors
+// of dims dimensions if needed. An existing store keeps the dimensions it
+// recorded, and refuses inserts of another size with a
+// *DimensionMismatchError.
 func NewSQLiteVectorStore(dbPath string, dims int) (*SQLiteVectorStore, error) {
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
 		return nil, fmt.Errorf("create sqlite directory: %w", err)
@@ -32,16 +52,40 @@ func NewSQLiteVectorStore(dbPath string, dims int) (*SQLiteVectorStore, error) {
 
 	store := &SQLiteVectorStore{
 		db:   db,
+		path: dbPath,
 		dims: dims,
 	}
 
 	if err := store.initSchema(); err != nil {
 		return nil, err
 	}
+	meta, err := store.embeddingMetadata()
+	if err != nil {
+		return nil, err
+	}
+	if meta.Dimensions > 0 {
+		store.dims = meta.Dimensions
+	}
+	if err := store.syncKeywords(); err != nil {
+		return nil, err
+	}
 
 	return store, nil
 }
 
+// SetQuantization sets how vectors inserted from now on are encoded.
+// Vectors already stored keep their encoding and are still read, so a
+// store changes over fully on its next rebuild.
+func (s *SQLiteVectorStore) SetQuantization(q Quantization) error {
+	if q != QuantizeNone && s.dims > 0 && s.dims <= 4 {
+		return fmt.Errorf("%s quantization needs more than 4 dimensions", q)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quant = q
+	return nil
+}
+
 func (s *SQLiteVectorStore) initSchema() error {
 	schema := `
 CREATE TABLE IF NOT EXISTS chunks (
@@ -58,11 +102,52 @@ CREATE TABLE IF NOT EXISTS chunks (
   embedding BLOB NOT NULL
 );
 CREATE INDEX IF NOT EXISTS idx_chunks_file ON chunks(file_path);
+CREATE TABLE IF NOT EXISTS chunk_summaries (
+  hash TEXT PRIMARY KEY,
+  summary TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS index_meta (
+  key TEXT PRIMARY KEY,
+  value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS file_hashes (
+  file_path TEXT PRIMARY KEY,
+  hash TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS chunk_duplicates (
+  chunk_id TEXT NOT NULL,
+  file_path TEXT NOT NULL,
+  start_line INTEGER NOT NULL,
+  end_line INTEGER,
+  symbol_name TEXT,
+  PRIMARY KEY (chunk_id, file_path, start_line)
+);
+CREATE INDEX IF NOT EXISTS idx_chunk_duplicates_file ON chunk_duplicates(file_path);
+CREATE TABLE IF NOT EXISTS keyword_docs (
+  rowid INTEGER PRIMARY KEY,
+  chunk_id TEXT UNIQUE NOT NULL
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS chunk_terms USING fts5(terms, tokenize="unicode61 remove_diacritics 0 tokenchars '_'");
 `
 	_, err := s.db.Exec(schema)
 	if err != nil {
 		return fmt.Errorf("init schema: %w", err)
 	}
+	return s.addColumn("chunks", "embedding_model", "TEXT NOT NULL DEFAULT ''")
+}
+
+// addColumn adds a column to a table created by an older version.
+func (s *SQLiteVectorStore) addColumn(table, column, decl string) error {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n); err != nil {
+		return fmt.Errorf("inspect %s: %w", table, err)
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := s.db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + decl); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
 	return nil
 }
 
@@ -84,8 +169,8 @@ func (s *SQLiteVectorStore) InsertBatch(chunks []*Chunk, embeddings [][]float32)
 	}
 	stmt, err := tx.Prepare(`
 INSERT OR REPLACE INTO chunks
-  (id, file_path, start_line, end_line, chunk_type, symbol_name, language, content, token_count, hash, embedding)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  (id, file_path, start_line, end_line, chunk_type, symbol_name, language, content, token_count, hash, embedding, embedding_model)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `)
 	if err != nil {
 		_ = tx.Rollback()
@@ -93,13 +178,34 @@ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	}
 	defer stmt.Close()
 
+	if s.dims == 0 && len(embeddings) > 0 {
+		s.dims = len(embeddings[0])
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO index_meta (key, value) VALUES ('dimensions', ?)`, strconv.Itoa(s.dims)); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("record dimensions: %w", err)
+	}
+
+	var inserted []int
 	for i, chunk := range chunks {
 		emb := embeddings[i]
 		if len(emb) != s.dims {
 			_ = tx.Rollback()
-			return fmt.Errorf("embedding dims mismatch: expected %d got %d", s.dims, len(emb))
+			return &DimensionMismatchError{Index: s.dims, Embedding: len(emb)}
 		}
-		blob := encodeEmbedding(emb)
+		primary, stored, err := storedLocation(tx, chunk.ID)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if stored && (primary.FilePath != chunk.FilePath || primary.StartLine != chunk.StartLine) {
+			if err := insertDuplicates(tx, chunk.ID, primary, chunk.Locations()); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+			continue
+		}
+		blob := encodeQuantized(emb, s.quant)
 		if _, err := stmt.Exec(
 			chunk.ID,
 			chunk.FilePath,
@@ -112,23 +218,73 @@ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 			chunk.TokenCount,
 			chunk.Hash,
 			blob,
+			chunk.EmbeddingModel,
 		); err != nil {
 			_ = tx.Rollback()
 			return fmt.Errorf("insert chunk %s: %w", chunk.FilePath, err)
 		}
+		if err := indexKeywords(tx, chunk); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := insertDuplicates(tx, chunk.ID, chunk.Location(), chunk.Duplicates); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		inserted = append(inserted, i)
 	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("commit: %w", err)
 	}
+	if s.ann != nil {
+		for _, i := range inserted {
+			if err := s.ann.Add(chunks[i].ID, embeddings[i]); err != nil {
+				return err
+			}
+		}
+		s.annDirty = true
+	}
 	return nil
 }
 
-func (s *SQLiteVectorStore) Search(queryEmbedding []float32, topK int) ([]*SearchResult, error) {
+// Search implements VectorStore. A filtered search scores every chunk
+// passing the filter rather than going through the nearest neighbour
+// index, whose candidates the filter could leave short of topK.
+func (s *SQLiteVectorStore) Search(queryEmbedding []float32, topK int, filter SearchFilter) ([]*SearchResult, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	rows, err := s.db.Query(`SELECT id, file_path, start_line, end_line, chunk_type, symbol_name, language, content, token_count, hash, embedding FROM chunks`)
+	var (
+		ids         []string
+		approximate bool
+		err         error
+	)
+	if filter.Empty() {
+		ids, approximate, err = s.searchIndex(queryEmbedding, topK)
+		if err != nil {
+			return nil, err
+		}
+	}
+	query := `
+SELECT c.id, c.file_path, c.start_line, c.end_line, c.chunk_type, c.symbol_name, c.language, c.content, c.token_count, c.hash, c.embedding, COALESCE(s.summary, '')
+FROM chunks c LEFT JOIN chunk_summaries s ON s.hash = c.hash`
+	var args []any
+	if !filter.Empty() {
+		var where string
+		where, args = sqliteFilter(filter)
+		query += ` WHERE ` + where
+	}
+	if approximate {
+		if len(ids) == 0 {
+			return nil, nil
+		}
+		query += ` WHERE c.id IN (?` + strings.Repeat(`, ?`, len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("select embeddings: %w", err)
 	}
@@ -149,11 +305,12 @@ func (s *SQLiteVectorStore) Search(queryEmbedding []float32, topK int) ([]*Searc
 			tokenCount int
 			hash       string
 			blob       []byte
+			summary    string
 		)
-		if err := rows.Scan(&id, &filePath, &startLine, &endLine, &chunkType, &symbolName, &language, &content, &tokenCount, &hash, &blob); err != nil {
+		if err := rows.Scan(&id, &filePath, &startLine, &endLine, &chunkType, &symbolName, &language, &content, &tokenCount, &hash, &blob, &summary); err != nil {
 			return nil, fmt.Errorf("scan chunk: %w", err)
 		}
-		vec, err := decodeEmbedding(blob, s.dims)
+		vec, err := decodeQuantized(blob, s.dims)
 		if err != nil {
 			return nil, fmt.Errorf("decode embedding: %w", err)
 		}
@@ -169,6 +326,7 @@ func (s *SQLiteVectorStore) Search(queryEmbedding []float32, topK int) ([]*Searc
 			Content:    content,
 			TokenCount: tokenCount,
 			Hash:       hash,
+			Summary:    summary,
 		}
 		results = append(results, &SearchResult{
 			Chunk:  chunk,
@@ -190,15 +348,183 @@ func (s *SQLiteVectorStore) Search(queryEmbedding []float32, topK int) ([]*Searc
 	if topK > len(results) {
 		topK = len(results)
 	}
-	return results[:topK], nil
+	results = results[:topK]
+	if err := s.attachDuplicates(resultChunks(results)); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// sqliteFilter returns the condition, over chunks aliased c, that keeps the
+// chunks passing f, and its arguments.
+func sqliteFilter(f SearchFilter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	in := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		conds = append(conds, column+` IN (?`+strings.Repeat(`, ?`, len(values)-1)+`)`)
+		for _, v := range values {
+			args = append(args, v)
+		}
+	}
+	in(`c.language`, f.Languages)
+	in(`c.chunk_type`, f.ChunkTypes)
+	if f.Path != "" {
+		conds = append(conds, `c.file_path GLOB ?`)
+		args = append(args, f.Path)
+	}
+	if f.SymbolPrefix != "" {
+		conds = append(conds, `substr(c.symbol_name, 1, length(?)) = ?`)
+		args = append(args, f.SymbolPrefix, f.SymbolPrefix)
+	}
+	return strings.Join(conds, ` AND `), args
+}
+
+// KeywordSearch implements KeywordSearcher.
+func (s *SQLiteVectorStore) KeywordSearch(query string, topK int) ([]*SearchResult, error) {
+	terms := keywordTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = `"` + t + `"`
+	}
+	if topK <= 0 {
+		topK = -1
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+SELECT c.id, c.file_path, c.start_line, c.end_line, c.chunk_type, c.symbol_name, c.language, c.content, c.token_count, c.hash, COALESCE(s.summary, ''), -bm25(chunk_terms)
+FROM chunk_terms
+JOIN keyword_docs k ON k.rowid = chunk_terms.rowid
+JOIN chunks c ON c.id = k.chunk_id
+LEFT JOIN chunk_summaries s ON s.hash = c.hash
+WHERE chunk_terms MATCH ?
+ORDER BY bm25(chunk_terms)
+LIMIT ?`, strings.Join(quoted, " OR "), topK)
+	if err != nil {
+		return nil, fmt.Errorf("select keyword matches: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*SearchResult
+	for rows.Next() {
+		var score float64
+		c := &Chunk{}
+		if err := rows.Scan(&c.ID, &c.FilePath, &c.StartLine, &c.EndLine, &c.ChunkType, &c.SymbolName, &c.Language, &c.Content, &c.TokenCount, &c.Hash, &c.Summary, &score); err != nil {
+			return nil, fmt.Errorf("scan chunk: %w", err)
+		}
+		results = append(results, &SearchResult{Chunk: c, Score: float32(score), Source: "keyword"})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+	if err := s.attachDuplicates(resultChunks(results)); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// AddDuplicates implements DedupStore.
+func (s *SQLiteVectorStore) AddDuplicates(chunks []*Chunk) ([]*Chunk, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	var missing []*Chunk
+	for _, chunk := range chunks {
+		primary, stored, err := storedLocation(tx, chunk.ID)
+		if err == nil && stored {
+			err = insertDuplicates(tx, chunk.ID, primary, chunk.Locations())
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		if !stored {
+			missing = append(missing, chunk)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return missing, nil
 }
 
 func (s *SQLiteVectorStore) Delete(filePath string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	_, err := s.db.Exec(`DELETE FROM chunks WHERE file_path = ?`, filePath)
+	return s.delete(filePath)
+}
+
+// DeleteWhere implements VectorStore. A file matches when it holds a
+// chunk, a duplicate or a recorded hash.
+func (s *SQLiteVectorStore) DeleteWhere(pattern string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows, err := s.db.Query(`
+SELECT file_path FROM chunks WHERE file_path GLOB ?1
+UNION SELECT file_path FROM chunk_duplicates WHERE file_path GLOB ?1
+UNION SELECT file_path FROM file_hashes WHERE file_path GLOB ?1`, pattern)
 	if err != nil {
-		return fmt.Errorf("delete %s: %w", filePath, err)
+		return 0, fmt.Errorf("select files matching %s: %w", pattern, err)
+	}
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan file path: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate rows: %w", err)
+	}
+	for i, path := range paths {
+		if err := s.delete(path); err != nil {
+			return i, err
+		}
+	}
+	return len(paths), nil
+}
+
+// delete removes filePath's chunks, duplicates and hash. Callers hold s.mu.
+func (s *SQLiteVectorStore) delete(filePath string) error {
+	if err := s.dropDuplicates(filePath); err != nil {
+		return err
+	}
+	if s.ann != nil {
+		ids, err := s.chunkIDs(`SELECT id FROM chunks WHERE file_path = ?`, filePath)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			s.ann.Remove(id)
+		}
+		s.annDirty = true
+	}
+	for _, query := range []string{
+		`DELETE FROM chunk_terms WHERE rowid IN (SELECT k.rowid FROM keyword_docs k JOIN chunks c ON c.id = k.chunk_id WHERE c.file_path = ?)`,
+		`DELETE FROM keyword_docs WHERE chunk_id IN (SELECT id FROM chunks WHERE file_path = ?)`,
+		`DELETE FROM chunks WHERE file_path = ?`,
+		`DELETE FROM file_hashes WHERE file_path = ?`,
+	} {
+		if _, err := s.db.Exec(query, filePath); err != nil {
+			return fmt.Errorf("delete %s: %w", filePath, err)
+		}
 	}
 	return nil
 }
@@ -214,12 +540,639 @@ func (s *SQLiteVectorStore) Count() int {
 func (s *SQLiteVectorStore) Clear() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if _, err := s.db.Exec(`DELETE FROM chunks`); err != nil {
+	if _, err := s.db.Exec(`DELETE FROM chunks; DELETE FROM chunk_duplicates; DELETE FROM index_meta; DELETE FROM file_hashes; DELETE FROM keyword_docs; DELETE FROM chunk_terms`); err != nil {
 		return fmt.Errorf("clear chunks: %w", err)
 	}
+	s.dims = 0
+	s.ann, s.annDirty = nil, false
+	if err := os.Remove(s.annPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove vector index: %w", err)
+	}
+	return nil
+}
+
+// EmbeddingMetadata implements MetadataStore. For an index built before the
+// model was recorded, only the dimensions are known, from a stored vector.
+func (s *SQLiteVectorStore) EmbeddingMetadata() (EmbeddingMetadata, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.embeddingMetadata()
+}
+
+func (s *SQLiteVectorStore) embeddingMetadata() (EmbeddingMetadata, error) {
+	var meta EmbeddingMetadata
+	rows, err := s.db.Query(`SELECT key, value FROM index_meta WHERE key IN ('embedding_model', 'dimensions')`)
+	if err != nil {
+		return meta, fmt.Errorf("select index metadata: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return meta, fmt.Errorf("scan index metadata: %w", err)
+		}
+		switch key {
+		case "embedding_model":
+			meta.Model = value
+		case "dimensions":
+			meta.Dimensions, _ = strconv.Atoi(value)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return meta, fmt.Errorf("iterate index metadata: %w", err)
+	}
+
+	if meta.Dimensions == 0 {
+		var size int
+		err := s.db.QueryRow(`SELECT length(embedding) FROM chunks LIMIT 1`).Scan(&size)
+		if err != nil && err != sql.ErrNoRows {
+			return meta, fmt.Errorf("select embedding: %w", err)
+		}
+		meta.Dimensions = size / 4
+	}
+	return meta, nil
+}
+
+// SetEmbeddingMetadata implements MetadataStore.
+func (s *SQLiteVectorStore) SetEmbeddingMetadata(meta EmbeddingMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO index_meta (key, value) VALUES ('embedding_model', ?), ('dimensions', ?)`,
+		meta.Model, strconv.Itoa(meta.Dimensions))
+	if err != nil {
+		return fmt.Errorf("save index metadata: %w", err)
+	}
+	if meta.Dimensions > 0 {
+		s.dims = meta.Dimensions
+	}
+	return nil
+}
+
+// EmbeddingModels implements ChunkModelStore.
+func (s *SQLiteVectorStore) EmbeddingModels() (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rows, err := s.db.Query(`SELECT embedding_model, COUNT(*) FROM chunks GROUP BY embedding_model`)
+	if err != nil {
+		return nil, fmt.Errorf("count chunks by model: %w", err)
+	}
+	defer rows.Close()
+	models := make(map[string]int)
+	for rows.Next() {
+		var model string
+		var count int
+		if err := rows.Scan(&model, &count); err != nil {
+			return nil, fmt.Errorf("scan model count: %w", err)
+		}
+		models[model] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate model counts: %w", err)
+	}
+	return models, nil
+}
+
+// IndexStats implements StatsStore.
+func (s *SQLiteVectorStore) IndexStats() (IndexStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var stats IndexStats
+	rows, err := s.db.Query(`SELECT key, value FROM index_meta WHERE key IN ('total_files', 'last_updated')`)
+	if err != nil {
+		return stats, fmt.Errorf("select index stats: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return stats, fmt.Errorf("scan index stats: %w", err)
+		}
+		switch key {
+		case "total_files":
+			stats.TotalFiles, _ = strconv.Atoi(value)
+		case "last_updated":
+			stats.LastUpdated = value
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("iterate index stats: %w", err)
+	}
+	return stats, nil
+}
+
+// SaveIndexStats implements StatsStore.
+func (s *SQLiteVectorStore) SaveIndexStats(stats IndexStats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO index_meta (key, value) VALUES ('total_files', ?), ('last_updated', ?)`,
+		strconv.Itoa(stats.TotalFiles), stats.LastUpdated)
+	if err != nil {
+		return fmt.Errorf("save index stats: %w", err)
+	}
+	return nil
+}
+
+// FileHashes implements FileHashStore.
+func (s *SQLiteVectorStore) FileHashes() (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rows, err := s.db.Query(`SELECT file_path, hash FROM file_hashes`)
+	if err != nil {
+		return nil, fmt.Errorf("select file hashes: %w", err)
+	}
+	defer rows.Close()
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var path, hash string
+		if err := rows.Scan(&path, &hash); err != nil {
+			return nil, fmt.Errorf("scan file hash: %w", err)
+		}
+		hashes[path] = hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate file hashes: %w", err)
+	}
+	return hashes, nil
+}
+
+// SetFileHashes implements FileHashStore.
+func (s *SQLiteVectorStore) SetFileHashes(hashes map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	for path, hash := range hashes {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO file_hashes (file_path, hash) VALUES (?, ?)`, path, hash); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("save file hash: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// Chunks implements ChunkSource.
+func (s *SQLiteVectorStore) Chunks() ([]*Chunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+SELECT c.id, c.file_path, c.start_line, c.end_line, c.chunk_type, c.symbol_name, c.language, c.content, c.token_count, c.hash, COALESCE(s.summary, '')
+FROM chunks c LEFT JOIN chunk_summaries s ON s.hash = c.hash
+ORDER BY c.file_path, c.start_line`)
+	if err != nil {
+		return nil, fmt.Errorf("select chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []*Chunk
+	for rows.Next() {
+		c := &Chunk{}
+		if err := rows.Scan(&c.ID, &c.FilePath, &c.StartLine, &c.EndLine, &c.ChunkType, &c.SymbolName, &c.Language, &c.Content, &c.TokenCount, &c.Hash, &c.Summary); err != nil {
+			return nil, fmt.Errorf("scan chunk: %w", err)
+		}
+		chunks = append(chunks, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+	if err := s.attachDuplicates(chunks); err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// UnsummarizedChunks implements SummaryStore.
+func (s *SQLiteVectorStore) UnsummarizedChunks(limit int) ([]*Chunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `
+SELECT c.id, c.file_path, c.start_line, c.end_line, c.chunk_type, c.symbol_name, c.language, c.content, c.token_count, c.hash
+FROM chunks c LEFT JOIN chunk_summaries s ON s.hash = c.hash
+WHERE s.hash IS NULL
+ORDER BY c.file_path, c.start_line`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("select unsummarized chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []*Chunk
+	for rows.Next() {
+		c := &Chunk{}
+		if err := rows.Scan(&c.ID, &c.FilePath, &c.StartLine, &c.EndLine, &c.ChunkType, &c.SymbolName, &c.Language, &c.Content, &c.TokenCount, &c.Hash); err != nil {
+			return nil, fmt.Errorf("scan chunk: %w", err)
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks, rows.Err()
+}
+
+// SaveSummaries implements SummaryStore.
+func (s *SQLiteVectorStore) SaveSummaries(summaries map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO chunk_summaries (hash, summary) VALUES (?, ?)`)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for hash, summary := range summaries {
+		if _, err := stmt.Exec(hash, summary); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("insert summary %s: %w", hash, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// Close saves the nearest neighbour index if it changed and releases the
+// underlying database handle.
+func (s *SQLiteVectorStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var saveErr error
+	if s.ann != nil && s.annDirty {
+		saveErr = s.saveIndex()
+	}
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	return saveErr
+}
+
+// annPath is where the nearest neighbour index of the store is saved.
+func (s *SQLiteVectorStore) annPath() string {
+	return s.path + ".hnsw"
+}
+
+// searchIndex returns the IDs of the chunks nearest to vec from the
+// nearest neighbour index, or false while the store is small enough to
+// search exhaustively. Callers hold s.mu.
+func (s *SQLiteVectorStore) searchIndex(vec []float32, topK int) ([]string, bool, error) {
+	s.annMu.Lock()
+	defer s.annMu.Unlock()
+	ann, err := s.nearestIndex()
+	if ann == nil || err != nil {
+		return nil, false, err
+	}
+	ids, _ := ann.Search(vec, topK)
+	return ids, true, nil
+}
+
+// nearestIndex returns the nearest neighbour index, loading or building
+// it on first use, or nil while the store is small. The index is brought
+// in line with the chunks in the database, which another process may have
+// changed. Callers hold s.mu and s.annMu.
+func (s *SQLiteVectorStore) nearestIndex() (*hnswIndex, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM chunks`).Scan(&count); err != nil {
+		return nil, fmt.Errorf("count chunks: %w", err)
+	}
+	if count < annMinChunks && s.ann == nil {
+		return nil, nil
+	}
+	if s.ann != nil && s.ann.Len() == count {
+		return s.ann, nil
+	}
+
+	if s.ann == nil {
+		meta, err := s.embeddingMetadata()
+		if err != nil {
+			return nil, err
+		}
+		if meta.Dimensions == 0 {
+			return nil, nil
+		}
+		s.ann, err = loadHNSW(s.annPath(), meta.Model, meta.Dimensions)
+		if err != nil {
+			s.ann = newHNSW(meta.Dimensions)
+		}
+	}
+	changed, err := s.syncIndex()
+	if err != nil {
+		s.ann = nil
+		return nil, err
+	}
+	if changed {
+		if err := s.saveIndex(); err != nil {
+			return nil, err
+		}
+	}
+	return s.ann, nil
+}
+
+// syncIndex adds the chunks missing from the nearest neighbour index and
+// removes the ones no longer stored, reporting whether anything changed.
+func (s *SQLiteVectorStore) syncIndex() (bool, error) {
+	ids, err := s.chunkIDs(`SELECT id FROM chunks`)
+	if err != nil {
+		return false, err
+	}
+	stored := make(map[string]bool, len(ids))
+	missing := 0
+	for _, id := range ids {
+		stored[id] = true
+		if !s.ann.Has(id) {
+			missing++
+		}
+	}
+	changed := false
+	for _, id := range s.ann.Keys() {
+		if !stored[id] {
+			s.ann.Remove(id)
+			changed = true
+		}
+	}
+	if missing == 0 {
+		return changed, nil
+	}
+
+	rows, err := s.db.Query(`SELECT id, embedding FROM chunks`)
+	if err != nil {
+		return false, fmt.Errorf("select embeddings: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id   string
+			blob []byte
+		)
+		if err := rows.Scan(&id, &blob); err != nil {
+			return false, fmt.Errorf("scan chunk: %w", err)
+		}
+		if s.ann.Has(id) {
+			continue
+		}
+		vec, err := decodeQuantized(blob, s.ann.dims)
+		if err != nil {
+			return false, fmt.Errorf("decode embedding: %w", err)
+		}
+		if err := s.ann.Add(id, vec); err != nil {
+			return false, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("iterate rows: %w", err)
+	}
+	return true, nil
+}
+
+// storedLocation returns where the chunk id is stored, if it is.
+func storedLocation(tx *sql.Tx, id string) (ChunkLocation, bool, error) {
+	var loc ChunkLocation
+	err := tx.QueryRow(`SELECT file_path, start_line, end_line, symbol_name FROM chunks WHERE id = ?`, id).
+		Scan(&loc.FilePath, &loc.StartLine, &loc.EndLine, &loc.SymbolName)
+	if err == sql.ErrNoRows {
+		return loc, false, nil
+	}
+	if err != nil {
+		return loc, false, fmt.Errorf("look up chunk %s: %w", id, err)
+	}
+	return loc, true, nil
+}
+
+// insertDuplicates records locs, other than primary, as duplicates of the
+// chunk id.
+func insertDuplicates(tx *sql.Tx, id string, primary ChunkLocation, locs []ChunkLocation) error {
+	for _, loc := range locs {
+		if loc.FilePath == primary.FilePath && loc.StartLine == primary.StartLine {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO chunk_duplicates (chunk_id, file_path, start_line, end_line, symbol_name) VALUES (?, ?, ?, ?, ?)`,
+			id, loc.FilePath, loc.StartLine, loc.EndLine, loc.SymbolName); err != nil {
+			return fmt.Errorf("record duplicate in %s: %w", loc.FilePath, err)
+		}
+	}
+	return nil
+}
+
+// dropDuplicates removes the duplicates in filePath and moves the chunks
+// stored at filePath that appear elsewhere to one of their duplicates, so
+// deleting the file's chunks leaves them. Callers hold s.mu.
+func (s *SQLiteVectorStore) dropDuplicates(filePath string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM chunk_duplicates WHERE file_path = ?`, filePath); err != nil {
+		return fmt.Errorf("delete duplicates in %s: %w", filePath, err)
+	}
+	rows, err := tx.Query(`
+SELECT c.id, d.file_path, d.start_line, d.end_line, d.symbol_name
+FROM chunks c JOIN chunk_duplicates d ON d.chunk_id = c.id
+WHERE c.file_path = ?
+ORDER BY c.id, d.file_path, d.start_line`, filePath)
+	if err != nil {
+		return fmt.Errorf("select duplicates: %w", err)
+	}
+	moves := make(map[string]ChunkLocation)
+	for rows.Next() {
+		var (
+			id  string
+			loc ChunkLocation
+		)
+		if err := rows.Scan(&id, &loc.FilePath, &loc.StartLine, &loc.EndLine, &loc.SymbolName); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan duplicate: %w", err)
+		}
+		if _, ok := moves[id]; !ok {
+			moves[id] = loc
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("select duplicates: %w", err)
+	}
+
+	for id, loc := range moves {
+		if _, err := tx.Exec(`UPDATE chunks SET file_path = ?, start_line = ?, end_line = ?, symbol_name = ? WHERE id = ?`,
+			loc.FilePath, loc.StartLine, loc.EndLine, loc.SymbolName, id); err != nil {
+			return fmt.Errorf("move chunk %s: %w", id, err)
+		}
+		if _, err := tx.Exec(`DELETE FROM chunk_duplicates WHERE chunk_id = ? AND file_path = ? AND start_line = ?`, id, loc.FilePath, loc.StartLine); err != nil {
+			return fmt.Errorf("move chunk %s: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// attachDuplicates fills in the duplicates of chunks. Callers hold s.mu.
+func (s *SQLiteVectorStore) attachDuplicates(chunks []*Chunk) error {
+	const maxParams = 500
+	byID := make(map[string]*Chunk, len(chunks))
+	ids := make([]any, 0, len(chunks))
+	for _, c := range chunks {
+		if _, ok := byID[c.ID]; !ok {
+			ids = append(ids, c.ID)
+		}
+		byID[c.ID] = c
+	}
+	for start := 0; start < len(ids); start += maxParams {
+		part := ids[start:min(start+maxParams, len(ids))]
+		rows, err := s.db.Query(`
+SELECT chunk_id, file_path, start_line, end_line, symbol_name FROM chunk_duplicates
+WHERE chunk_id IN (?`+strings.Repeat(`, ?`, len(part)-1)+`)
+ORDER BY file_path, start_line`, part...)
+		if err != nil {
+			return fmt.Errorf("select duplicates: %w", err)
+		}
+		for rows.Next() {
+			var (
+				id  string
+				loc ChunkLocation
+			)
+			if err := rows.Scan(&id, &loc.FilePath, &loc.StartLine, &loc.EndLine, &loc.SymbolName); err != nil {
+				rows.Close()
+				return fmt.Errorf("scan duplicate: %w", err)
+			}
+			byID[id].Duplicates = append(byID[id].Duplicates, loc)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("select duplicates: %w", err)
+		}
+	}
+	return nil
+}
+
+// resultChunks returns the chunks of results.
+func resultChunks(results []*SearchResult) []*Chunk {
+	chunks := make([]*Chunk, len(results))
+	for i, res := range results {
+		chunks[i] = res.Chunk
+	}
+	return chunks
+}
+
+// syncKeywords rebuilds the keyword index when it does not cover every
+// chunk, as in an index created before keyword search existed.
+func (s *SQLiteVectorStore) syncKeywords() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var chunks, docs int
+	if err := s.db.QueryRow(`SELECT (SELECT COUNT(*) FROM chunks), (SELECT COUNT(*) FROM keyword_docs)`).Scan(&chunks, &docs); err != nil {
+		return fmt.Errorf("count keyword index: %w", err)
+	}
+	if chunks == docs {
+		return nil
+	}
+
+	rows, err := s.db.Query(`SELECT id, symbol_name, content FROM chunks`)
+	if err != nil {
+		return fmt.Errorf("select chunks: %w", err)
+	}
+	var all []*Chunk
+	for rows.Next() {
+		c := &Chunk{}
+		if err := rows.Scan(&c.ID, &c.SymbolName, &c.Content); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan chunk: %w", err)
+		}
+		all = append(all, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate rows: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM keyword_docs; DELETE FROM chunk_terms`); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("clear keyword index: %w", err)
+	}
+	for _, c := range all {
+		if err := indexKeywords(tx, c); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
 	return nil
 }
 
+// indexKeywords replaces the keyword index entry of chunk. Its FTS row is
+// keyed by an explicit rowid in keyword_docs, which VACUUM leaves alone.
+func indexKeywords(tx *sql.Tx, chunk *Chunk) error {
+	if _, err := tx.Exec(`DELETE FROM chunk_terms WHERE rowid = (SELECT rowid FROM keyword_docs WHERE chunk_id = ?)`, chunk.ID); err != nil {
+		return fmt.Errorf("index keywords of %s: %w", chunk.FilePath, err)
+	}
+	res, err := tx.Exec(`INSERT OR REPLACE INTO keyword_docs (chunk_id) VALUES (?)`, chunk.ID)
+	if err != nil {
+		return fmt.Errorf("index keywords of %s: %w", chunk.FilePath, err)
+	}
+	rowid, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("index keywords of %s: %w", chunk.FilePath, err)
+	}
+	terms := strings.Join(keywordTerms(keywordText(chunk)), " ")
+	if _, err := tx.Exec(`INSERT INTO chunk_terms (rowid, terms) VALUES (?, ?)`, rowid, terms); err != nil {
+		return fmt.Errorf("index keywords of %s: %w", chunk.FilePath, err)
+	}
+	return nil
+}
+
+func (s *SQLiteVectorStore) saveIndex() error {
+	meta, err := s.embeddingMetadata()
+	if err != nil {
+		return err
+	}
+	if err := s.ann.save(s.annPath(), meta.Model); err != nil {
+		return err
+	}
+	s.annDirty = false
+	return nil
+}
+
+func (s *SQLiteVectorStore) chunkIDs(query string, args ...any) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select chunk ids: %w", err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan chunk id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select chunk ids: %w", err)
+	}
+	return ids, nil
+}
+
 func encodeEmbedding(vec []float32) []byte {
 	buf := make([]byte, len(vec)*4)
 	for i, v := range vec {
@@ -242,3 +1195,178 @@ func decodeEmbedding(data []byte, dims int) ([]float32, error) {
 // Tiny wrappers to avoid importing math in the hot path.
 func mathFloat32bits(f float32) uint32     { return math.Float32bits(f) }
 func mathFloat32frombits(b uint32) float32 { return math.Float32frombits(b) }
+
+// Relocate rewrites the paths of chunks indexed under oldRoot to point at
+// the same files under newRoot, for an index built in another checkout.
+// It returns the number of files moved.
+func (s *SQLiteVectorStore) Relocate(oldRoot, newRoot string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(`SELECT file_path FROM chunks UNION SELECT file_path FROM chunk_duplicates UNION SELECT file_path FROM file_hashes`)
+	if err != nil {
+		return 0, fmt.Errorf("list files: %w", err)
+	}
+	moves := make(map[string]string)
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan file: %w", err)
+		}
+		rel, err := filepath.Rel(oldRoot, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		moves[path] = filepath.Join(newRoot, rel)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("list files: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	for from, to := range moves {
+		for _, query := range []string{
+			`UPDATE chunks SET file_path = ? WHERE file_path = ?`,
+			`UPDATE chunk_duplicates SET file_path = ? WHERE file_path = ?`,
+			`UPDATE file_hashes SET file_path = ? WHERE file_path = ?`,
+		} {
+			if _, err := tx.Exec(query, to, from); err != nil {
+				_ = tx.Rollback()
+				return 0, fmt.Errorf("relocate %s: %w", from, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return len(moves), nil
+}
+
+// FileUsage is the space one indexed file's chunks take in a store.
+type FileUsage struct {
+	FilePath string
+	Language string
+	Chunks   int
+	Bytes    int64 // of chunk contents and vectors
+}
+
+// FileUsage reports the space taken by the chunks of each indexed file.
+// Files whose chunks are all duplicates of others take none.
+func (s *SQLiteVectorStore) FileUsage() ([]FileUsage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rows, err := s.db.Query(`
+SELECT file_path, COALESCE(language, ''), COUNT(*), SUM(length(content) + length(embedding))
+FROM chunks GROUP BY file_path, language`)
+	if err != nil {
+		return nil, fmt.Errorf("select file usage: %w", err)
+	}
+	defer rows.Close()
+	var usage []FileUsage
+	for rows.Next() {
+		var u FileUsage
+		if err := rows.Scan(&u.FilePath, &u.Language, &u.Chunks, &u.Bytes); err != nil {
+			return nil, fmt.Errorf("scan file usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate file usage: %w", err)
+	}
+	return usage, nil
+}
+
+// RemoveMissingFiles deletes the chunks, duplicates and hashes of indexed
+// files that no longer exist on disk, and returns how many files it
+// removed.
+func (s *SQLiteVectorStore) RemoveMissingFiles() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(`SELECT file_path FROM chunks UNION SELECT file_path FROM chunk_duplicates UNION SELECT file_path FROM file_hashes`)
+	if err != nil {
+		return 0, fmt.Errorf("list files: %w", err)
+	}
+	var missing []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan file: %w", err)
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			missing = append(missing, path)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("list files: %w", err)
+	}
+
+	for i, path := range missing {
+		if err := s.delete(path); err != nil {
+			return i, err
+		}
+	}
+	return len(missing), nil
+}
+
+// Compact drops the summaries, keyword terms and duplicate locations left
+// behind by chunks no longer indexed, merges the keyword index and
+// rebuilds the database file to release its free pages. It returns the
+// bytes saved on disk. Summaries are otherwise kept across rebuilds, so a
+// rebuild after compacting summarizes removed chunks again.
+func (s *SQLiteVectorStore) Compact() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, err := s.diskSize()
+	if err != nil {
+		return 0, err
+	}
+	for _, query := range []string{
+		`DELETE FROM chunk_summaries WHERE hash NOT IN (SELECT hash FROM chunks WHERE hash IS NOT NULL)`,
+		`DELETE FROM chunk_duplicates WHERE chunk_id NOT IN (SELECT id FROM chunks)`,
+		`DELETE FROM chunk_terms WHERE rowid IN (SELECT rowid FROM keyword_docs WHERE chunk_id NOT IN (SELECT id FROM chunks))`,
+		`DELETE FROM keyword_docs WHERE chunk_id NOT IN (SELECT id FROM chunks)`,
+		`INSERT INTO chunk_terms (chunk_terms) VALUES ('optimize')`,
+		`VACUUM`,
+	} {
+		if _, err := s.db.Exec(query); err != nil {
+			return 0, fmt.Errorf("compact: %w", err)
+		}
+	}
+	after, err := s.diskSize()
+	if err != nil {
+		return 0, err
+	}
+	return before - after, nil
+}
+
+// DiskSize returns the bytes the database takes on disk.
+func (s *SQLiteVectorStore) DiskSize() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.diskSize()
+}
+
+// diskSize sums the database file and its journal, if any.
+func (s *SQLiteVectorStore) diskSize() (int64, error) {
+	var size int64
+	for _, path := range []string{s.path, s.path + "-wal", s.path + "-journal"} {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("stat %s: %w", path, err)
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
@@ -0,0 +1,145 @@
+package rag
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// wordPiece is the BERT tokenizer: text is split on whitespace and
+// punctuation, then each word into the longest vocabulary pieces, with
+// "##" marking pieces that continue a word.
+type wordPiece struct {
+	vocab     map[string]int
+	lowercase bool
+	cls, sep  int
+	unk       int
+}
+
+// maxWordChars is the length above which a word is one unknown token.
+const maxWordChars = 100
+
+// loadWordPiece reads a vocab.txt, one token per line in ID order.
+func loadWordPiece(path string, lowercase bool) (*wordPiece, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	wp := &wordPiece{vocab: make(map[string]int), lowercase: lowercase}
+	scanner := bufio.NewScanner(f)
+	for id := 0; scanner.Scan(); id++ {
+		token := strings.TrimRight(scanner.Text(), "\r")
+		if _, dup := wp.vocab[token]; !dup {
+			wp.vocab[token] = id
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	for _, special := range []struct {
+		token string
+		id    *int
+	}{{"[CLS]", &wp.cls}, {"[SEP]", &wp.sep}, {"[UNK]", &wp.unk}} {
+		id, ok := wp.vocab[special.token]
+		if !ok {
+			return nil, fmt.Errorf("%s has no %s token", path, special.token)
+		}
+		*special.id = id
+	}
+	return wp, nil
+}
+
+// encode returns the token IDs of text framed by [CLS] and [SEP], at most
+// maxLen of them.
+func (wp *wordPiece) encode(text string, maxLen int) []int {
+	ids := []int{wp.cls}
+	for _, word := range wp.words(text) {
+		for _, id := range wp.pieces(word) {
+			if len(ids) == maxLen-1 {
+				return append(ids, wp.sep)
+			}
+			ids = append(ids, id)
+		}
+	}
+	return append(ids, wp.sep)
+}
+
+// words splits text on whitespace, and around punctuation and CJK
+// characters, which are words of their own.
+func (wp *wordPiece) words(text string) []string {
+	var (
+		words []string
+		cur   strings.Builder
+	)
+	flush := func() {
+		if cur.Len() > 0 {
+			words = append(words, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar || (unicode.IsControl(r) && !unicode.IsSpace(r)):
+			continue
+		case unicode.IsSpace(r):
+			flush()
+		case isBertPunct(r) || isCJK(r):
+			flush()
+			words = append(words, string(r))
+		default:
+			if wp.lowercase {
+				r = unicode.ToLower(r)
+			}
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
+
+// pieces splits a word into the longest vocabulary pieces from the left,
+// or returns [UNK] if some part of it has none.
+func (wp *wordPiece) pieces(word string) []int {
+	runes := []rune(word)
+	if len(runes) > maxWordChars {
+		return []int{wp.unk}
+	}
+	var ids []int
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		id := -1
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if v, ok := wp.vocab[piece]; ok {
+				id = v
+				break
+			}
+		}
+		if id < 0 {
+			return []int{wp.unk}
+		}
+		ids = append(ids, id)
+		start = end
+	}
+	return ids
+}
+
+// isBertPunct reports whether BERT splits on r: ASCII symbols as well as
+// Unicode punctuation.
+func isBertPunct(r rune) bool {
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
@@ -0,0 +1,92 @@
+// Package ratelimit provides a token bucket limiter for outbound API calls.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket. A nil *Limiter never blocks, so callers can
+// hold an optional limiter without nil checks.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// New creates a limiter refilling at ratePerSecond with the given burst size.
+func New(ratePerSecond float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:   ratePerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// PerMinute creates a limiter allowing n requests per minute, with bursts of
+// up to n. It returns nil (unlimited) when n <= 0.
+func PerMinute(n int) *Limiter {
+	if n <= 0 {
+		return nil
+	}
+	return New(float64(n)/60, n)
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		delay := l.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Allow takes a token if one is available without blocking.
+func (l *Limiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+	return l.reserve() == 0
+}
+
+// reserve takes a token and returns 0, or returns how long to wait for the next one.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	if l.rate <= 0 {
+		return time.Second
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
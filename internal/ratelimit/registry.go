@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+)
+
+// Limit describes the allowed request rate for one provider.
+type Limit struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	Burst             int `json:"burst,omitempty"` // defaults to RequestsPerMinute
+}
+
+// Registry hands out one shared limiter per provider so that every client
+// talking to the same provider draws from the same bucket.
+type Registry struct {
+	mu       sync.Mutex
+	limiters map[string]*Limiter
+}
+
+// NewRegistry creates an empty registry; unconfigured providers are unlimited.
+func NewRegistry() *Registry {
+	return &Registry{limiters: make(map[string]*Limiter)}
+}
+
+// Default is the process-wide registry used by LLM clients and embedders.
+var Default = NewRegistry()
+
+// Configure sets the limit for provider, replacing any previous limiter.
+// A non-positive RequestsPerMinute removes the limit.
+func (r *Registry) Configure(provider string, limit Limit) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit.RequestsPerMinute <= 0 {
+		delete(r.limiters, provider)
+		return
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.RequestsPerMinute
+	}
+	r.limiters[provider] = New(float64(limit.RequestsPerMinute)/60, burst)
+}
+
+// For returns the limiter for provider, or nil if it is unlimited.
+func (r *Registry) For(provider string) *Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.limiters[provider]
+}
+
+// Wait blocks until provider's bucket in the default registry has a token.
+func Wait(ctx context.Context, provider string) error {
+	return Default.For(provider).Wait(ctx)
+}
@@ -0,0 +1,156 @@
+// Package refs finds the places in a project where a symbol is used: call
+// sites, type references, method values, and so on. The structural index
+// records only definitions and caller names, not where each use is.
+//
+// Uses are matched by name, like the rest of the structural index. For
+// type-accurate Go references use gopls.
+package refs
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Reference is one use of a symbol.
+type Reference struct {
+	Name string `json:"name"` // the identifier used
+	// Package is the import path of the package the name is selected
+	// from, as in pkg.Name; Go only.
+	Package string `json:"package,omitempty"`
+	File    string `json:"file"` // slash-separated, relative to the project
+	Line    int    `json:"line"`
+	Column  int    `json:"column"` // byte offset in the line, from 1
+	Text    string `json:"text"`   // the source line, trimmed
+}
+
+// String renders the reference as "file:line:column: text".
+func (r Reference) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", r.File, r.Line, r.Column, r.Text)
+}
+
+// Find returns the uses of symbol in the Go and Python files under
+// projectPath, ordered by file and position. symbol may be qualified
+// ("pkg.Func", "Type.Method"); its last part is matched, and in Go a
+// package qualifier rules out selections from other imported packages.
+// Methods of other types with the same name are included. Definitions of
+// the name are not uses and are left out, as are Python comments.
+func Find(projectPath, symbol string) ([]Reference, error) {
+	qualifier, name := "", symbol
+	if i := strings.LastIndex(symbol, "."); i >= 0 {
+		qualifier, name = symbol[:i], symbol[i+1:]
+		qualifier = qualifier[strings.LastIndex(qualifier, ".")+1:]
+	}
+	if name == "" {
+		return nil, fmt.Errorf("empty symbol name")
+	}
+	return scan(projectPath, map[string]bool{name: true}, qualifier)
+}
+
+// FindAll returns the uses of each of names, unqualified identifiers, in
+// one pass over the project. Names without uses are absent from the map.
+func FindAll(projectPath string, names []string) (map[string][]Reference, error) {
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		want[n] = true
+	}
+	found, err := scan(projectPath, want, "")
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string][]Reference)
+	for _, r := range found {
+		byName[r.Name] = append(byName[r.Name], r)
+	}
+	return byName, nil
+}
+
+func scan(projectPath string, names map[string]bool, qualifier string) ([]Reference, error) {
+	// With a single name, files without it are skipped before parsing.
+	only := ""
+	if len(names) == 1 {
+		for n := range names {
+			only = n
+		}
+	}
+
+	refs := []Reference{}
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != projectPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		var scanFile func(file string, src []byte, names map[string]bool, qualifier string) ([]Reference, error)
+		switch filepath.Ext(p) {
+		case ".go":
+			scanFile = scanGo
+		case ".py":
+			scanFile = scanPython
+		default:
+			return nil
+		}
+		rel, err := filepath.Rel(projectPath, p)
+		if err != nil {
+			return err
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if only != "" && !strings.Contains(string(src), only) {
+			return nil
+		}
+		found, err := scanFile(filepath.ToSlash(rel), src, names, qualifier)
+		if err != nil {
+			// Unparseable files are skipped, as the indexer does.
+			return nil
+		}
+		refs = append(refs, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan project: %w", err)
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		a, b := refs[i], refs[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return refs, nil
+}
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"venv":         true,
+	"__pycache__":  true,
+	"testdata":     true,
+}
+
+// Format renders refs as a list of locations.
+func Format(symbol string, refs []Reference) string {
+	if len(refs) == 0 {
+		return fmt.Sprintf("No references to '%s' found.\n", symbol)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "References to '%s' (%d):\n\n", symbol, len(refs))
+	for _, r := range refs {
+		fmt.Fprintf(&b, "  %s\n", r)
+	}
+	return b.String()
+}
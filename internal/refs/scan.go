@@ -0,0 +1,105 @@
+package refs
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// scanGo returns the identifiers in names found in a Go file, other than
+// the names of function, method and type declarations. When qualifier is
+// set, selections from an imported package of another name ("other.Name")
+// are left out.
+func scanGo(file string, src []byte, names map[string]bool, qualifier string) ([]Reference, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := make(map[string]string) // local name to import path
+	for _, spec := range f.Imports {
+		path := strings.Trim(spec.Path.Value, `"`)
+		local := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			local = spec.Name.Name
+		}
+		imports[local] = path
+	}
+
+	skip := make(map[*ast.Ident]bool)
+	pkgs := make(map[*ast.Ident]string)
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			skip[n.Name] = true
+		case *ast.TypeSpec:
+			skip[n.Name] = true
+		case *ast.SelectorExpr:
+			x, ok := n.X.(*ast.Ident)
+			if !ok {
+				break
+			}
+			if path, imported := imports[x.Name]; imported {
+				pkgs[n.Sel] = path
+				if qualifier != "" && x.Name != qualifier {
+					skip[n.Sel] = true
+				}
+			}
+		}
+		return true
+	})
+
+	lines := strings.Split(string(src), "\n")
+	var refs []Reference
+	ast.Inspect(f, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || !names[id.Name] || skip[id] {
+			return true
+		}
+		pos := fset.Position(id.Pos())
+		refs = append(refs, Reference{Name: id.Name, Package: pkgs[id], File: file, Line: pos.Line, Column: pos.Column, Text: lineText(lines, pos.Line)})
+		return true
+	})
+	return refs, nil
+}
+
+var (
+	pythonIdent = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	pythonDecl  = regexp.MustCompile(`^\s*(?:async\s+)?(?:def|class)\s+([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// scanPython returns the whole-word occurrences of names in a Python file
+// outside comments, other than in the def or class statement declaring
+// them. Occurrences in strings count, which finds names used through
+// getattr or registries.
+func scanPython(file string, src []byte, names map[string]bool, _ string) ([]Reference, error) {
+	var refs []Reference
+	for i, line := range strings.Split(string(src), "\n") {
+		code := line
+		if j := strings.IndexByte(code, '#'); j >= 0 {
+			code = code[:j]
+		}
+		declared := -1
+		if m := pythonDecl.FindStringSubmatchIndex(code); m != nil {
+			declared = m[2]
+		}
+		for _, m := range pythonIdent.FindAllStringIndex(code, -1) {
+			name := code[m[0]:m[1]]
+			if m[0] == declared || !names[name] {
+				continue
+			}
+			refs = append(refs, Reference{Name: name, File: file, Line: i + 1, Column: m[0] + 1, Text: strings.TrimSpace(line)})
+		}
+	}
+	return refs, nil
+}
+
+func lineText(lines []string, line int) string {
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
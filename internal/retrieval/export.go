@@ -0,0 +1,125 @@
+package retrieval
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ExportFormat names a context-pack format understood by other coding agents.
+type ExportFormat string
+
+const (
+	// ExportRepoMap is an Aider-style repo map: each file followed by its
+	// top-level declarations, with elided code marked by "⋮...".
+	ExportRepoMap ExportFormat = "repomap"
+	// ExportFiles is a plain list of project-relative paths, one per line.
+	ExportFiles ExportFormat = "files"
+	// ExportMentions lists files as @path mentions for Claude Code prompts.
+	ExportMentions ExportFormat = "mentions"
+)
+
+// declarationPattern matches lines that start a top-level or class-level
+// declaration in the languages we commonly see (Go, Python, JS/TS, Java, Rust).
+var declarationPattern = regexp.MustCompile(`^\s{0,4}(?:` +
+	`func\s|type\s|const\s|var\s|` + // Go
+	`(?:async\s+)?def\s|class\s|` + // Python
+	`(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s|(?:export\s+)?(?:interface|enum)\s|` + // JS/TS
+	`(?:pub(?:\([^)]*\))?\s+)?(?:fn|struct|enum|trait|impl)\s|` + // Rust
+	`(?:public|protected|private)\s` + // Java/C#
+	`)`)
+
+// Export renders files (relative to projectRoot) in the given format.
+// maxDecls bounds how many declarations are listed per file in a repo map.
+func Export(projectRoot string, files []string, format ExportFormat, maxDecls int) (string, error) {
+	files = dedupeFiles(projectRoot, files)
+
+	var b strings.Builder
+	switch format {
+	case ExportFiles:
+		for _, f := range files {
+			b.WriteString(f)
+			b.WriteString("\n")
+		}
+	case ExportMentions:
+		for _, f := range files {
+			b.WriteString("@")
+			b.WriteString(f)
+			b.WriteString("\n")
+		}
+	case ExportRepoMap:
+		for _, f := range files {
+			decls, err := declarations(filepath.Join(projectRoot, f), maxDecls)
+			if err != nil {
+				return "", fmt.Errorf("failed to read %s: %w", f, err)
+			}
+			fmt.Fprintf(&b, "%s:\n", f)
+			if len(decls) == 0 {
+				b.WriteString("⋮...\n\n")
+				continue
+			}
+			for _, d := range decls {
+				b.WriteString("⋮...\n│")
+				b.WriteString(d)
+				b.WriteString("\n")
+			}
+			b.WriteString("⋮...\n\n")
+		}
+	default:
+		return "", fmt.Errorf("unknown export format %q (want repomap, files or mentions)", format)
+	}
+	return b.String(), nil
+}
+
+// dedupeFiles makes paths project-relative and drops duplicates and files
+// that no longer exist.
+func dedupeFiles(projectRoot string, files []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, f := range files {
+		if filepath.IsAbs(f) {
+			if rel, err := filepath.Rel(projectRoot, f); err == nil {
+				f = rel
+			}
+		}
+		f = filepath.ToSlash(filepath.Clean(f))
+		if seen[f] {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(projectRoot, f)); err != nil || info.IsDir() {
+			continue
+		}
+		seen[f] = true
+		out = append(out, f)
+	}
+	return out
+}
+
+func declarations(path string, limit int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var decls []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if !declarationPattern.MatchString(line) {
+			continue
+		}
+		if len(line) > 160 {
+			line = line[:157] + "..."
+		}
+		decls = append(decls, line)
+		if limit > 0 && len(decls) >= limit {
+			break
+		}
+	}
+	return decls, scanner.Err()
+}
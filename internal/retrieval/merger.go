@@ -26,28 +26,30 @@ func (m *ResultMerger) Merge(ragResults []*rag.SearchResult, indexerFiles []stri
 	// Track which files we've seen
 	fileMap := make(map[string]*rag.FileResult)
 
-	// Add RAG results
+	// Add RAG results, at every place their content appears
 	for _, res := range ragResults {
-		filePath := res.Chunk.FilePath
-
-		if existing, ok := fileMap[filePath]; ok {
-			// File already added, boost score and add chunk
-			existing.Relevance = max(existing.Relevance, res.Score) * 1.2 // Boost for multiple matches
-			existing.Chunks = append(existing.Chunks, res.Chunk)
-			existing.Source = "both" // Found by both sources
-		} else {
-			// New file
-			fileResult := rag.FileResult{
-				Path:      filePath,
-				Relevance: res.Score,
-				Source:    "rag",
-				Chunks:    []*rag.Chunk{res.Chunk},
-				Highlights: []rag.LineRange{
-					{Start: res.Chunk.StartLine, End: res.Chunk.EndLine},
-				},
+		for _, loc := range res.Chunk.Locations() {
+			filePath := loc.FilePath
+
+			if existing, ok := fileMap[filePath]; ok {
+				// File already added, boost score and add chunk
+				existing.Relevance = max(existing.Relevance, res.Score) * 1.2 // Boost for multiple matches
+				existing.Chunks = append(existing.Chunks, res.Chunk)
+				existing.Source = "both" // Found by both sources
+			} else {
+				// New file
+				fileResult := rag.FileResult{
+					Path:      filePath,
+					Relevance: res.Score,
+					Source:    "rag",
+					Chunks:    []*rag.Chunk{res.Chunk},
+					Highlights: []rag.LineRange{
+						{Start: loc.StartLine, End: loc.EndLine},
+					},
+				}
+				fileMap[filePath] = &fileResult
+				result.Sources.RAG++
 			}
-			fileMap[filePath] = &fileResult
-			result.Sources.RAG++
 		}
 	}
 
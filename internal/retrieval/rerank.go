@@ -0,0 +1,131 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yourorg/agent/internal/agent"
+	"github.com/yourorg/agent/internal/rag"
+)
+
+// DefaultRerankCandidates is how many of the top results are reranked when
+// no other number is given.
+const DefaultRerankCandidates = 20
+
+// maxRerankChunkChars caps how much of each candidate is shown to the model.
+const maxRerankChunkChars = 1500
+
+const rerankSystemPrompt = `You rank code search results by how useful they are for a query.
+Reply with the numbers of the excerpts, most relevant first, separated by commas. Leave out excerpts that are irrelevant. No other text.`
+
+// Reranker reorders search results by their relevance to a query.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, results []*rag.SearchResult) ([]*rag.SearchResult, error)
+}
+
+// LLMReranker asks a language model, ideally a small and cheap one, to
+// order the top candidates of a search. Results beyond the candidates, and
+// candidates the model leaves out, keep their order after the ranked ones.
+type LLMReranker struct {
+	client     agent.LLMClient
+	privacy    *rag.PrivacyFilter
+	candidates int
+}
+
+// NewLLMReranker reranks the top candidates results with client, cleaning
+// chunk contents with privacy first. A nil privacy filter sends chunks as
+// they are; candidates <= 0 means DefaultRerankCandidates.
+func NewLLMReranker(client agent.LLMClient, privacy *rag.PrivacyFilter, candidates int) *LLMReranker {
+	if candidates <= 0 {
+		candidates = DefaultRerankCandidates
+	}
+	return &LLMReranker{client: client, privacy: privacy, candidates: candidates}
+}
+
+// Rerank implements Reranker. The reordered results take over the scores
+// of the original order, best first, so they still merge with results
+// from other sources on the same scale.
+func (r *LLMReranker) Rerank(ctx context.Context, query string, results []*rag.SearchResult) ([]*rag.SearchResult, error) {
+	n := min(len(results), r.candidates)
+	if n < 2 {
+		return results, nil
+	}
+	candidates := results[:n]
+
+	resp, err := r.client.Chat(ctx, []agent.Message{
+		{Role: "system", Content: rerankSystemPrompt},
+		{Role: "user", Content: r.prompt(query, candidates)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rerank: %w", err)
+	}
+	order := parseRanking(resp.Content, n)
+	if len(order) == 0 {
+		return nil, fmt.Errorf("rerank: no ranking in reply %q", resp.Content)
+	}
+
+	scores := make([]float32, n)
+	for i, res := range candidates {
+		scores[i] = res.Score
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i] > scores[j] })
+
+	ranked := make([]*rag.SearchResult, 0, len(results))
+	placed := make([]bool, n)
+	for _, i := range order {
+		placed[i] = true
+		ranked = append(ranked, candidates[i])
+	}
+	for i, res := range candidates {
+		if !placed[i] {
+			ranked = append(ranked, res)
+		}
+	}
+	for i, res := range ranked {
+		moved := *res
+		moved.Score = scores[i]
+		ranked[i] = &moved
+	}
+	return append(ranked, results[n:]...), nil
+}
+
+// prompt lists the query and the numbered candidates.
+func (r *LLMReranker) prompt(query string, candidates []*rag.SearchResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Query: %s\n", query)
+	for i, res := range candidates {
+		c := res.Chunk
+		content := r.privacy.Clean(c.FilePath, c.Content)
+		if len(content) > maxRerankChunkChars {
+			content = content[:maxRerankChunkChars] + "\n..."
+		}
+		fmt.Fprintf(&b, "\n[%d] %s (lines %d-%d", i+1, c.FilePath, c.StartLine, c.EndLine)
+		if c.SymbolName != "" {
+			fmt.Fprintf(&b, ", %s %s", c.ChunkType, c.SymbolName)
+		}
+		fmt.Fprintf(&b, ")\n%s\n", content)
+	}
+	return b.String()
+}
+
+var rankNumber = regexp.MustCompile(`\d+`)
+
+// parseRanking reads the excerpt numbers in a reply as indexes into n
+// candidates, dropping repeats and numbers out of range.
+func parseRanking(reply string, n int) []int {
+	var order []int
+	seen := make(map[int]bool)
+	for _, m := range rankNumber.FindAllString(reply, -1) {
+		num, err := strconv.Atoi(m)
+		if err != nil || num < 1 || num > n || seen[num-1] {
+			continue
+		}
+		seen[num-1] = true
+		order = append(order, num-1)
+	}
+	return order
+}
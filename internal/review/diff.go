@@ -0,0 +1,101 @@
+package review
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DiffLine is a single added or removed line of a unified diff.
+type DiffLine struct {
+	Line int // line number in the new file (added) or old file (removed)
+	Text string
+}
+
+// FileDiff holds the changed lines of one file.
+type FileDiff struct {
+	Path    string
+	Added   []DiffLine
+	Removed []DiffLine
+	// Content is the file's new content. Callers fill it in when available;
+	// checks that need surrounding code are skipped without it.
+	Content string
+}
+
+// ParseDiff parses `git diff` output (any context size) into per-file changes.
+// Deleted files keep their old path; renamed files use the new one.
+func ParseDiff(diff string) []FileDiff {
+	var (
+		files   []FileDiff
+		current = -1
+		inHunk  bool
+		oldLine int
+		newLine int
+	)
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, FileDiff{})
+			current = len(files) - 1
+			inHunk = false
+		case current < 0:
+			continue
+		case strings.HasPrefix(line, "@@"):
+			oldLine, newLine = parseHunkHeader(line)
+			inHunk = true
+		case !inHunk:
+			// File header: only the ---/+++ lines matter.
+			if strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") {
+				if path := diffPath(line[4:]); path != "" {
+					files[current].Path = path
+				}
+			}
+		case strings.HasPrefix(line, "+"):
+			files[current].Added = append(files[current].Added, DiffLine{Line: newLine, Text: line[1:]})
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			files[current].Removed = append(files[current].Removed, DiffLine{Line: oldLine, Text: line[1:]})
+			oldLine++
+		case strings.HasPrefix(line, " "):
+			oldLine++
+			newLine++
+		}
+	}
+
+	out := files[:0]
+	for _, f := range files {
+		if f.Path != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func diffPath(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		return s[2:]
+	}
+	return s
+}
+
+// parseHunkHeader reads the starting line numbers from "@@ -a,b +c,d @@".
+func parseHunkHeader(line string) (int, int) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0
+	}
+	return hunkStart(fields[1]), hunkStart(fields[2])
+}
+
+func hunkStart(field string) int {
+	field = strings.TrimLeft(field, "-+")
+	if i := strings.IndexByte(field, ','); i >= 0 {
+		field = field[:i]
+	}
+	n, _ := strconv.Atoi(field)
+	return n
+}
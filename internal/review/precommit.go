@@ -0,0 +1,240 @@
+package review
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourorg/agent/internal/indexer"
+)
+
+// Finding is a problem the reviewer spotted in a change set.
+type Finding struct {
+	File    string   `json:"file"`
+	Line    int      `json:"line,omitempty"`
+	Symbol  string   `json:"symbol"`
+	Message string   `json:"message"`
+	Related []string `json:"related,omitempty"` // unchanged files that likely need updating
+}
+
+// String formats the finding as "file:line: message".
+func (f Finding) String() string {
+	var b strings.Builder
+	if f.Line > 0 {
+		fmt.Fprintf(&b, "%s:%d: %s", f.File, f.Line, f.Message)
+	} else {
+		fmt.Fprintf(&b, "%s: %s", f.File, f.Message)
+	}
+	for _, r := range f.Related {
+		fmt.Fprintf(&b, "\n    - %s", r)
+	}
+	return b.String()
+}
+
+var (
+	// func Name( / func (r *T) Name( / def name(
+	funcDeclPattern = regexp.MustCompile(`^\s*(?:func\s+(?:\([^)]*\)\s*)?|(?:async\s+)?def\s+)([A-Za-z_]\w*)\s*[\[(]`)
+	// Go interface method specs, checked only on lines inside an interface body.
+	interfaceMethodPattern = regexp.MustCompile(`^\s+([A-Za-z_]\w*)\(`)
+	interfaceStartPattern  = regexp.MustCompile(`^\s*(?:type\s+)?[A-Za-z_]\w*(?:\[[^\]]*\])?\s+interface\s*\{\s*$`)
+)
+
+// Reviewer checks a change set against the project index for changes that
+// are likely incomplete, such as an edited signature whose callers or
+// implementations were left untouched.
+type Reviewer struct {
+	projectRoot string
+	engine      *indexer.SearchEngine
+}
+
+// NewReviewer creates a reviewer for the indexed project at projectRoot.
+func NewReviewer(projectRoot string, idx *indexer.ProjectIndex) *Reviewer {
+	return &Reviewer{
+		projectRoot: projectRoot,
+		engine:      indexer.NewSearchEngine(idx),
+	}
+}
+
+// Review returns findings for the given diff, sorted by file and line.
+func (r *Reviewer) Review(files []FileDiff) []Finding {
+	changed := make(map[string]bool, len(files))
+	for _, f := range files {
+		changed[filepath.ToSlash(f.Path)] = true
+	}
+
+	var findings []Finding
+	for _, f := range files {
+		findings = append(findings, r.reviewSignatures(f, changed)...)
+		if strings.HasSuffix(f.Path, ".go") {
+			findings = append(findings, r.reviewInterfaceMethods(f, changed)...)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// reviewSignatures flags functions whose declaration changed or disappeared
+// while their callers, found via the call graph, were not staged.
+func (r *Reviewer) reviewSignatures(f FileDiff, changed map[string]bool) []Finding {
+	removed := declarations(f.Removed, funcDeclPattern)
+	added := declarations(f.Added, funcDeclPattern)
+
+	var findings []Finding
+	for name, old := range removed {
+		var msg string
+		line := old.Line
+		if cur, ok := added[name]; ok {
+			if normalize(cur.Text) == normalize(old.Text) {
+				continue
+			}
+			msg = fmt.Sprintf("signature of %s changed but %%d caller file(s) were not updated", name)
+			line = cur.Line
+		} else {
+			msg = fmt.Sprintf("%s was removed or renamed but %%d caller file(s) still reference it", name)
+		}
+
+		related := r.unchangedFiles(r.callerFiles(name), f.Path, changed)
+		if len(related) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			File:    f.Path,
+			Line:    line,
+			Symbol:  name,
+			Message: fmt.Sprintf(msg, len(related)),
+			Related: related,
+		})
+	}
+	return findings
+}
+
+// reviewInterfaceMethods flags interface methods whose signature changed
+// while their implementations elsewhere in the project were not staged. It
+// needs the file's new content to tell method specs from call statements.
+func (r *Reviewer) reviewInterfaceMethods(f FileDiff, changed map[string]bool) []Finding {
+	if f.Content == "" {
+		return nil
+	}
+	inInterface := interfaceLines(f.Content)
+
+	var specs []DiffLine
+	for _, l := range f.Added {
+		if inInterface[l.Line] {
+			specs = append(specs, l)
+		}
+	}
+	added := declarations(specs, interfaceMethodPattern)
+	removed := declarations(f.Removed, interfaceMethodPattern)
+
+	var findings []Finding
+	for name, cur := range added {
+		old, ok := removed[name]
+		if !ok || normalize(cur.Text) == normalize(old.Text) {
+			continue
+		}
+
+		var impls []string
+		for _, res := range r.engine.SearchSymbol(name) {
+			if res.Name == name && res.Type == "method" {
+				impls = append(impls, res.FilePath)
+			}
+		}
+		related := r.unchangedFiles(impls, f.Path, changed)
+		if len(related) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			File:    f.Path,
+			Line:    cur.Line,
+			Symbol:  name,
+			Message: fmt.Sprintf("interface method %s changed but %d implementation file(s) were not updated", name, len(related)),
+			Related: related,
+		})
+	}
+	return findings
+}
+
+// interfaceLines returns the 1-based line numbers inside Go interface bodies.
+func interfaceLines(content string) map[int]bool {
+	lines := make(map[int]bool)
+	depth := 0
+	for i, line := range strings.Split(content, "\n") {
+		if depth == 0 {
+			if interfaceStartPattern.MatchString(line) {
+				depth = 1
+			}
+			continue
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth > 0 {
+			lines[i+1] = true
+		} else {
+			depth = 0
+		}
+	}
+	return lines
+}
+
+func (r *Reviewer) callerFiles(name string) []string {
+	var files []string
+	for _, caller := range r.engine.SearchByCallGraph(name, "callers") {
+		if details := r.engine.GetSymbolDetails(caller); details != nil {
+			files = append(files, details.FilePath)
+		}
+	}
+	return files
+}
+
+// unchangedFiles returns the distinct project-relative paths in files that
+// are neither self nor part of the change set.
+func (r *Reviewer) unchangedFiles(files []string, self string, changed map[string]bool) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, file := range files {
+		rel := r.rel(file)
+		if rel == "" || rel == filepath.ToSlash(self) || changed[rel] || seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		out = append(out, rel)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (r *Reviewer) rel(path string) string {
+	if path == "" {
+		return ""
+	}
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(r.projectRoot, path); err == nil {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// declarations maps each declared name to the line declaring it.
+func declarations(lines []DiffLine, pattern *regexp.Regexp) map[string]DiffLine {
+	decls := make(map[string]DiffLine)
+	for _, l := range lines {
+		if m := pattern.FindStringSubmatch(l.Text); m != nil {
+			if _, ok := decls[m[1]]; !ok {
+				decls[m[1]] = l
+			}
+		}
+	}
+	return decls
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,196 @@
+package routes
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// goFrameworks are the Go routers recognized, by import path prefix; a
+// file's routes are attributed to the first one it imports.
+var goFrameworks = []struct{ prefix, name string }{
+	{"github.com/gin-gonic/gin", "gin"},
+	{"github.com/labstack/echo", "echo"},
+	{"github.com/go-chi/chi", "chi"},
+	{"github.com/gorilla/mux", "gorilla"},
+	{"net/http", "net/http"},
+}
+
+// goMethods maps router method names (Gin and Echo GET, chi Get) to HTTP
+// methods.
+var goMethods = map[string]string{
+	"GET": "GET", "POST": "POST", "PUT": "PUT", "PATCH": "PATCH", "DELETE": "DELETE",
+	"HEAD": "HEAD", "OPTIONS": "OPTIONS", "CONNECT": "CONNECT", "TRACE": "TRACE",
+	"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH", "Delete": "DELETE",
+	"Head": "HEAD", "Options": "OPTIONS", "Connect": "CONNECT", "Trace": "TRACE",
+	"Any": "ANY",
+}
+
+// goRoutes extracts the routes registered in a Go file. Group prefixes
+// (Gin/Echo Group, chi Route, gorilla PathPrefix().Subrouter()) are
+// tracked by variable name.
+func goRoutes(file string, src []byte) []Route {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	framework := ""
+	for _, fw := range goFrameworks {
+		for _, spec := range f.Imports {
+			if strings.HasPrefix(strings.Trim(spec.Path.Value, `"`), fw.prefix) && framework == "" {
+				framework = fw.name
+			}
+		}
+	}
+	if framework == "" {
+		return nil
+	}
+
+	prefixes := make(map[string]string)         // router variable to its path prefix
+	methods := make(map[*ast.CallExpr][]string) // gorilla routes to their .Methods(...)
+	var routes []Route
+	add := func(call *ast.CallExpr, method, path string, handler ast.Expr, recv string) {
+		name := types.ExprString(handler)
+		if _, ok := handler.(*ast.FuncLit); ok {
+			name = "func literal"
+		}
+		r := Route{
+			Method:    method,
+			Path:      joinPath(prefixes[recv], path),
+			Handler:   name,
+			Framework: framework,
+			File:      file,
+			Line:      fset.Position(call.Pos()).Line,
+		}
+		if ms := methods[call]; len(ms) > 0 && method == "ANY" {
+			for _, m := range ms {
+				r.Method = strings.ToUpper(m)
+				routes = append(routes, r)
+			}
+			return
+		}
+		routes = append(routes, r)
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) == 1 && len(n.Rhs) == 1 {
+				if id, ok := n.Lhs[0].(*ast.Ident); ok {
+					if p, ok := groupPrefix(n.Rhs[0], prefixes); ok {
+						prefixes[id.Name] = p
+					}
+				}
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			recv := identName(sel.X)
+			args := n.Args
+			switch name := sel.Sel.Name; {
+			case name == "Methods":
+				if inner, ok := sel.X.(*ast.CallExpr); ok {
+					for _, a := range args {
+						if m, ok := stringLit(a); ok {
+							methods[inner] = append(methods[inner], m)
+						}
+					}
+				}
+			case name == "Route" && len(args) == 2:
+				// chi: r.Route("/api", func(r chi.Router) { ... })
+				p, ok := stringLit(args[0])
+				fn, isFunc := args[1].(*ast.FuncLit)
+				if ok && isFunc && len(fn.Type.Params.List) > 0 && len(fn.Type.Params.List[0].Names) > 0 {
+					prefixes[fn.Type.Params.List[0].Names[0].Name] = joinPath(prefixes[recv], p)
+				}
+			case (name == "HandleFunc" || name == "Handle") && len(args) == 2:
+				// net/http, gorilla and chi; Go 1.22 patterns carry a method.
+				if p, ok := stringLit(args[0]); ok && isRoutePath(p) {
+					method := "ANY"
+					if m, rest, ok := strings.Cut(p, " "); ok && goMethods[m] != "" {
+						method, p = m, strings.TrimSpace(rest)
+						if host, path, ok := strings.Cut(p, "/"); ok && host != "" {
+							p = "/" + path // drop a host: "GET example.com/x"
+						}
+					}
+					add(n, method, p, args[1], recv)
+				}
+			case (name == "Handle" || name == "Method" || name == "MethodFunc") && len(args) >= 3:
+				// Gin r.Handle("GET", "/x", h), chi r.Method("GET", "/x", h).
+				m, ok1 := stringLit(args[0])
+				p, ok2 := stringLit(args[1])
+				if ok1 && ok2 && isRoutePath(p) {
+					add(n, strings.ToUpper(m), p, args[len(args)-1], recv)
+				}
+			case goMethods[name] != "" && len(args) >= 2:
+				if p, ok := stringLit(args[0]); ok && isRoutePath(p) {
+					add(n, goMethods[name], p, args[len(args)-1], recv)
+				}
+			}
+		}
+		return true
+	})
+	return routes
+}
+
+// groupPrefix returns the prefix of a router group expression: Gin and
+// Echo r.Group("/api"), gorilla r.PathPrefix("/api").Subrouter().
+func groupPrefix(expr ast.Expr, prefixes map[string]string) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	switch sel.Sel.Name {
+	case "Group":
+		if len(call.Args) > 0 {
+			if p, ok := stringLit(call.Args[0]); ok {
+				return joinPath(prefixes[identName(sel.X)], p), true
+			}
+		}
+	case "Subrouter":
+		if inner, ok := sel.X.(*ast.CallExpr); ok {
+			if isel, ok := inner.Fun.(*ast.SelectorExpr); ok && isel.Sel.Name == "PathPrefix" && len(inner.Args) == 1 {
+				if p, ok := stringLit(inner.Args[0]); ok {
+					return joinPath(prefixes[identName(isel.X)], p), true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// isRoutePath reports whether s looks like a route pattern rather than
+// any string: "/x", "" for a group's root, or "GET /x".
+func isRoutePath(s string) bool {
+	if s == "" || strings.HasPrefix(s, "/") {
+		return true
+	}
+	m, rest, ok := strings.Cut(s, " ")
+	return ok && goMethods[m] != "" && strings.Contains(rest, "/")
+}
+
+func identName(expr ast.Expr) string {
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
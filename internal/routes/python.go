@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	// decorator matches Flask and FastAPI route decorators:
+	// @app.route("/x", methods=["POST"]), @router.get("/x").
+	decorator = regexp.MustCompile(`^\s*@(\w+)\.(route|get|post|put|patch|delete|head|options|api_route|websocket)\(\s*[rRuU]?["']([^"']*)["'](.*)`)
+	// routerDef matches blueprints and routers with a prefix:
+	// bp = Blueprint("x", __name__, url_prefix="/api").
+	routerDef = regexp.MustCompile(`^\s*(\w+)\s*(?::\s*\w+\s*)?=\s*(?:\w+\.)?(?:Blueprint|APIRouter)\(.*\b(?:url_)?prefix\s*=\s*["']([^"']*)["']`)
+	// djangoPath matches entries of Django urlpatterns:
+	// path("patients/<int:pk>/", views.detail).
+	djangoPath = regexp.MustCompile(`^\s*(?:path|re_path|url)\(\s*[rR]?["']([^"']*)["']\s*,\s*([\w.]+)`)
+	pyDef      = regexp.MustCompile(`^\s*(?:async\s+)?def\s+(\w+)`)
+	quoted     = regexp.MustCompile(`["'](\w+)["']`)
+)
+
+// pythonRoutes extracts the routes of a Python file: Flask and FastAPI
+// decorators, with blueprint and router prefixes, and Django urlpatterns
+// in urls.py files.
+func pythonRoutes(file, src string) []Route {
+	lines := strings.Split(src, "\n")
+	if path.Base(file) == "urls.py" {
+		return djangoRoutes(file, lines)
+	}
+
+	framework := ""
+	switch {
+	case strings.Contains(src, "import flask") || strings.Contains(src, "from flask"):
+		framework = "flask"
+	case strings.Contains(src, "fastapi"):
+		framework = "fastapi"
+	}
+
+	prefixes := make(map[string]string)
+	var routes []Route
+	for i, line := range lines {
+		if m := routerDef.FindStringSubmatch(line); m != nil {
+			prefixes[m[1]] = m[2]
+			continue
+		}
+		m := decorator.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		router, kind, p, rest := m[1], m[2], m[3], m[4]
+
+		var methods []string
+		switch kind {
+		case "route", "api_route":
+			if _, list, ok := strings.Cut(rest, "methods"); ok {
+				for _, q := range quoted.FindAllStringSubmatch(list, -1) {
+					methods = append(methods, strings.ToUpper(q[1]))
+				}
+			}
+			if len(methods) == 0 {
+				methods = []string{"GET"} // Flask's default
+			}
+		case "websocket":
+			methods = []string{"WS"}
+		default:
+			methods = []string{strings.ToUpper(kind)}
+		}
+
+		fw := framework
+		if fw == "" {
+			fw = "fastapi"
+			if kind == "route" {
+				fw = "flask"
+			}
+		}
+		handler := ""
+		for j := i + 1; j < len(lines) && j <= i+20; j++ {
+			if d := pyDef.FindStringSubmatch(lines[j]); d != nil {
+				handler = d[1]
+				break
+			}
+		}
+		for _, method := range methods {
+			routes = append(routes, Route{
+				Method:    method,
+				Path:      joinPath(prefixes[router], p),
+				Handler:   handler,
+				Framework: fw,
+				File:      file,
+				Line:      i + 1,
+			})
+		}
+	}
+	return routes
+}
+
+func djangoRoutes(file string, lines []string) []Route {
+	var routes []Route
+	for i, line := range lines {
+		m := djangoPath.FindStringSubmatch(line)
+		if m == nil || m[2] == "include" {
+			continue
+		}
+		routes = append(routes, Route{
+			Method:    "ANY",
+			Path:      "/" + strings.TrimPrefix(m[1], "^"),
+			Handler:   m[2],
+			Framework: "django",
+			File:      file,
+			Line:      i + 1,
+		})
+	}
+	return routes
+}
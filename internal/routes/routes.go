@@ -0,0 +1,176 @@
+// Package routes extracts the HTTP routes of web services (net/http,
+// Gin, Echo, chi and gorilla/mux handlers in Go; Flask, FastAPI and
+// Django in Python), so a task about "the /patients endpoint" leads
+// straight to its handler.
+package routes
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Route is an HTTP route and the handler serving it.
+type Route struct {
+	Method    string `json:"method"` // GET, POST, ... or ANY
+	Path      string `json:"path"`   // with group and blueprint prefixes applied
+	Handler   string `json:"handler"`
+	Framework string `json:"framework"` // net/http, gin, echo, chi, gorilla, flask, fastapi or django
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+}
+
+func (r Route) String() string {
+	return fmt.Sprintf("%-7s %-40s %s  (%s:%d)", r.Method, r.Path, r.Handler, r.File, r.Line)
+}
+
+// Load extracts the routes of the project's Go and Python files. Route
+// files are slash-separated and relative to projectPath.
+func Load(projectPath string) ([]Route, error) {
+	var routes []Route
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != projectPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(p)
+		if ext != ".go" && ext != ".py" || strings.HasSuffix(p, "_test.go") {
+			return nil
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(projectPath, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if ext == ".go" {
+			routes = append(routes, goRoutes(rel, src)...)
+		} else {
+			routes = append(routes, pythonRoutes(rel, string(src))...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("extract routes: %w", err)
+	}
+	return routes, nil
+}
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"venv":         true,
+	"__pycache__":  true,
+	"testdata":     true,
+}
+
+// params matches path parameters in the syntaxes of the supported
+// frameworks: {id}, {id:[0-9]+}, :id, *path, <int:id> and regex groups.
+var params = regexp.MustCompile(`\{[^}]*\}|:[A-Za-z_]\w*|\*\w*|<[^>]*>|\(\?P<[^>]*>[^)]*\)`)
+
+// normalize puts a route or query path in a comparable form: parameters
+// as {}, without regex anchors, a leading slash and no trailing slash.
+func normalize(path string) string {
+	path = strings.TrimPrefix(strings.TrimSuffix(path, "$"), "^")
+	path = params.ReplaceAllString(path, "{}")
+	path = "/" + strings.Trim(path, "/")
+	return strings.ToLower(path)
+}
+
+// Match returns the routes serving path, best first: the exact route,
+// then routes under it (/patients/{id}) and routes ending with it under a
+// prefix (/api/v1/patients). An empty path or method matches all.
+func Match(routes []Route, path, method string) []Route {
+	method = strings.ToUpper(method)
+	q := normalize(path)
+	type scored struct {
+		r     Route
+		score int
+	}
+	var found []scored
+	for _, r := range routes {
+		if method != "" && r.Method != method && r.Method != "ANY" {
+			continue
+		}
+		score := 1
+		if path != "" {
+			p := normalize(r.Path)
+			switch {
+			case p == q:
+				score = 4
+			case strings.HasSuffix(p, q):
+				score = 3
+			case strings.HasPrefix(p, q+"/"):
+				score = 2
+			case strings.Contains(p, q+"/"):
+				score = 1
+			default:
+				continue
+			}
+		}
+		found = append(found, scored{r, score})
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].score != found[j].score {
+			return found[i].score > found[j].score
+		}
+		return found[i].r.Path < found[j].r.Path
+	})
+	matched := make([]Route, len(found))
+	for i, f := range found {
+		matched[i] = f.r
+	}
+	return matched
+}
+
+// taskPath matches the URL paths a task mentions: "/patients",
+// "/api/v1/patients/{id}".
+var taskPath = regexp.MustCompile("(?:^|[\\s\"'`(])(/[A-Za-z0-9_\\-{}:<>.]+(?:/[A-Za-z0-9_\\-{}:<>.]+)*/?)")
+
+// ForTask returns the routes serving the URL paths a task mentions.
+func ForTask(routes []Route, task string) []Route {
+	var found []Route
+	seen := make(map[Route]bool)
+	for _, m := range taskPath.FindAllStringSubmatch(task, -1) {
+		for _, r := range Match(routes, m[1], "") {
+			if !seen[r] {
+				seen[r] = true
+				found = append(found, r)
+			}
+		}
+	}
+	return found
+}
+
+// Format renders routes one per line.
+func Format(routes []Route) string {
+	var b strings.Builder
+	for _, r := range routes {
+		b.WriteString(r.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// joinPath appends a route path to a group prefix.
+func joinPath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	if path == "" || path == "/" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
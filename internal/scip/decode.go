@@ -0,0 +1,406 @@
+package scip
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Document is a file of a SCIP index read by Decode.
+type Document struct {
+	Path        string // slash-separated, relative to the project root
+	Language    string
+	Package     string // the package of its first definition, as SCIP names it
+	Definitions []Definition
+	References  []Reference
+}
+
+// Reference is an occurrence of a symbol that does not define it.
+type Reference struct {
+	Name    string // as Definition.Name
+	Kind    string // as Definition.Kind
+	Package string // the package of the symbol, as SCIP names it
+	Line    int    // 1-based
+	// Caller is the function or method whose body holds the reference,
+	// if any.
+	Caller string
+}
+
+// Decode reads a SCIP index, such as scip-go, scip-typescript or Export
+// write, into its documents. Local symbols, parameters and packages are
+// left out.
+func Decode(data []byte) ([]Document, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode SCIP index: %w", err)
+	}
+	var docs []Document
+	for _, f := range fields {
+		if f.num != 2 || f.typ != protowire.BytesType {
+			continue
+		}
+		d, err := decodeDocument(f.bytes)
+		if err != nil {
+			return nil, fmt.Errorf("decode SCIP index: %w", err)
+		}
+		docs = append(docs, d)
+	}
+	return docs, nil
+}
+
+// decoded is an occurrence with its symbol parsed.
+type decoded struct {
+	sym        parsedSymbol
+	line       int   // 1-based
+	enclosing  []int // 1-based first and last lines of the definition, if given
+	definition bool
+}
+
+func decodeDocument(b []byte) (Document, error) {
+	fields, err := readFields(b)
+	if err != nil {
+		return Document{}, err
+	}
+	var (
+		d    Document
+		occs []decoded
+		info = make(map[string][]string) // documentation, by symbol
+	)
+	for _, f := range fields {
+		switch {
+		case f.num == 1 && f.typ == protowire.BytesType:
+			d.Path = string(f.bytes)
+		case f.num == 4 && f.typ == protowire.BytesType:
+			d.Language = strings.ToLower(string(f.bytes))
+		case f.num == 2 && f.typ == protowire.BytesType:
+			o, ok, err := decodeOccurrence(f.bytes)
+			if err != nil {
+				return Document{}, err
+			}
+			if ok {
+				occs = append(occs, o)
+			}
+		case f.num == 3 && f.typ == protowire.BytesType:
+			symbol, docs, err := decodeSymbolInfo(f.bytes)
+			if err != nil {
+				return Document{}, err
+			}
+			info[symbol] = docs
+		}
+	}
+	if d.Language == "" {
+		d.Language = language(d.Path)
+	}
+
+	// Callers are the function definitions around a reference: by their
+	// enclosing ranges when the indexer gives them, otherwise the closest
+	// one above it.
+	var funcs []decoded
+	ranged := false
+	for _, o := range occs {
+		if !o.definition {
+			continue
+		}
+		if d.Package == "" {
+			d.Package = o.sym.pkg
+		}
+		signature, doc := splitDocumentation(info[o.sym.raw])
+		d.Definitions = append(d.Definitions, Definition{
+			Name:      o.sym.name,
+			Kind:      o.sym.kind,
+			File:      d.Path,
+			Line:      o.line,
+			Signature: signature,
+			Doc:       doc,
+		})
+		if o.sym.kind == "function" || o.sym.kind == "method" {
+			funcs = append(funcs, o)
+			ranged = ranged || o.enclosing != nil
+		}
+	}
+	sort.SliceStable(funcs, func(i, j int) bool { return funcs[i].line < funcs[j].line })
+	caller := func(line int) string {
+		name, span := "", 0
+		for _, f := range funcs {
+			switch {
+			case ranged && f.enclosing != nil && f.enclosing[0] <= line && line <= f.enclosing[1]:
+				if s := f.enclosing[1] - f.enclosing[0]; name == "" || s < span {
+					name, span = f.sym.name, s
+				}
+			case !ranged && f.line <= line:
+				name = f.sym.name
+			}
+		}
+		return name
+	}
+	for _, o := range occs {
+		if !o.definition {
+			d.References = append(d.References, Reference{
+				Name:    o.sym.name,
+				Kind:    o.sym.kind,
+				Package: o.sym.pkg,
+				Line:    o.line,
+				Caller:  caller(o.line),
+			})
+		}
+	}
+	return d, nil
+}
+
+// decodeOccurrence returns the occurrence, and false for a symbol that
+// is not kept.
+func decodeOccurrence(b []byte) (decoded, bool, error) {
+	fields, err := readFields(b)
+	if err != nil {
+		return decoded{}, false, err
+	}
+	var (
+		o          decoded
+		rng, outer []int32
+		symbol     string
+	)
+	for _, f := range fields {
+		switch f.num {
+		case 1:
+			rng = appendInt32s(rng, f)
+		case 2:
+			symbol = string(f.bytes)
+		case 3:
+			o.definition = f.varint&roleDefinition != 0
+		case 7:
+			outer = appendInt32s(outer, f)
+		}
+	}
+	sym, ok := parseSymbol(symbol)
+	if !ok || len(rng) < 3 {
+		return decoded{}, false, nil
+	}
+	o.sym, o.line = sym, int(rng[0])+1
+	if len(outer) >= 3 {
+		end := outer[0]
+		if len(outer) == 4 {
+			end = outer[2]
+		}
+		o.enclosing = []int{int(outer[0]) + 1, int(end) + 1}
+	}
+	return o, true, nil
+}
+
+// appendInt32s appends a repeated int32 field, packed or not.
+func appendInt32s(vs []int32, f field) []int32 {
+	if f.typ == protowire.VarintType {
+		return append(vs, int32(f.varint))
+	}
+	for b := f.bytes; len(b) > 0; {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			break
+		}
+		vs = append(vs, int32(v))
+		b = b[n:]
+	}
+	return vs
+}
+
+func decodeSymbolInfo(b []byte) (symbol string, docs []string, err error) {
+	fields, err := readFields(b)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, f := range fields {
+		switch f.num {
+		case 1:
+			symbol = string(f.bytes)
+		case 3:
+			docs = append(docs, string(f.bytes))
+		}
+	}
+	return symbol, docs, nil
+}
+
+// splitDocumentation returns the signature an indexer puts first in a
+// symbol's documentation as a code block, as Export does, and the rest.
+func splitDocumentation(docs []string) (signature, doc string) {
+	if len(docs) > 0 && strings.HasPrefix(docs[0], "```") {
+		block := strings.TrimSuffix(strings.TrimSpace(docs[0]), "```")
+		if _, code, ok := strings.Cut(block, "\n"); ok {
+			signature = strings.TrimSpace(code)
+		}
+		docs = docs[1:]
+	}
+	return signature, strings.TrimSpace(strings.Join(docs, "\n\n"))
+}
+
+// field is a field of a message: its number and, by its wire type, its
+// varint or its bytes.
+type field struct {
+	num    protowire.Number
+	typ    protowire.Type
+	varint uint64
+	bytes  []byte
+}
+
+func readFields(b []byte) ([]field, error) {
+	var fields []field
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		f := field{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// parsedSymbol is a global SCIP symbol, named the way Definition names
+// symbols.
+type parsedSymbol struct {
+	raw  string
+	pkg  string
+	name string // "Func", "Type", or "Type.Method"
+	kind string
+}
+
+// parseSymbol parses a symbol such as "scip-go gomod example.com/m v1
+// `example.com/m/store`/Store#Get().". Local symbols, and symbols that
+// name a package, a parameter or a type parameter, are not kept.
+func parseSymbol(s string) (parsedSymbol, bool) {
+	if s == "" || strings.HasPrefix(s, "local ") {
+		return parsedSymbol{}, false
+	}
+	// scheme, manager, package and version, where "  " is a space.
+	var parts []string
+	rest := s
+	for len(parts) < 4 {
+		var b strings.Builder
+		for {
+			i := strings.IndexByte(rest, ' ')
+			if i < 0 {
+				return parsedSymbol{}, false
+			}
+			b.WriteString(rest[:i])
+			rest = rest[i+1:]
+			if !strings.HasPrefix(rest, " ") {
+				break
+			}
+			b.WriteByte(' ')
+			rest = rest[1:]
+		}
+		parts = append(parts, b.String())
+	}
+
+	var names []string
+	var last byte
+	owned := false
+	for rest != "" {
+		// Type parameters and parameters: [T] and (x).
+		if rest[0] == '[' || rest[0] == '(' {
+			closing := byte(']')
+			if rest[0] == '(' {
+				closing = ')'
+			}
+			end := strings.IndexByte(rest, closing)
+			if end < 0 {
+				return parsedSymbol{}, false
+			}
+			rest, last = rest[end+1:], 0
+			continue
+		}
+		name, after, ok := descriptorName(rest)
+		if !ok || after == "" {
+			return parsedSymbol{}, false
+		}
+		last = after[0]
+		switch last {
+		case '(':
+			end := strings.IndexByte(after, ')')
+			if end < 0 || !strings.HasPrefix(after[end+1:], ".") {
+				return parsedSymbol{}, false
+			}
+			after = after[end+2:]
+		case '/', '#', '.', ':', '!':
+			after = after[1:]
+		default:
+			return parsedSymbol{}, false
+		}
+		if last != '/' && last != ':' {
+			owned = owned || len(names) > 0
+			names = append(names, name)
+		}
+		rest = after
+	}
+
+	sym := parsedSymbol{raw: s, pkg: parts[2], name: strings.Join(names, ".")}
+	if sym.pkg == "." {
+		sym.pkg = ""
+	}
+	switch last {
+	case '(':
+		sym.kind = "function"
+		if owned {
+			sym.kind = "method"
+		}
+	case '#':
+		sym.kind = "type"
+	case '.':
+		sym.kind = "variable"
+		if owned {
+			sym.kind = "field"
+		}
+	case '!':
+		sym.kind = "macro"
+	default:
+		return parsedSymbol{}, false
+	}
+	return sym, len(names) > 0
+}
+
+// descriptorName reads a name at the start of s, simple or backquoted,
+// and returns it with the rest of s.
+func descriptorName(s string) (name, rest string, ok bool) {
+	if strings.HasPrefix(s, "`") {
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '`' {
+				b.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '`' {
+				b.WriteByte('`')
+				i++
+				continue
+			}
+			return b.String(), s[i+1:], true
+		}
+		return "", "", false
+	}
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if c == '_' || c == '+' || c == '-' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			i++
+			continue
+		}
+		break
+	}
+	if i == 0 {
+		return "", "", false
+	}
+	return s[:i], s[i:], true
+}
@@ -0,0 +1,92 @@
+package scip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   parsedSymbol // without raw
+		ok     bool
+	}{
+		{"scip-go gomod example.com/m v1 `example.com/m/store`/Store#Get().", parsedSymbol{pkg: "example.com/m", name: "Store.Get", kind: "method"}, true},
+		{"scip-typescript npm app 1.0.0 src/`store.ts`/Store#get().", parsedSymbol{pkg: "app", name: "Store.get", kind: "method"}, true},
+		{"scip-indexer . . . internal/rag/lookup().", parsedSymbol{name: "lookup", kind: "function"}, true},
+		{"scip-go gomod example.com/m v1 `example.com/m/store`/Store#", parsedSymbol{pkg: "example.com/m", name: "Store", kind: "type"}, true},
+		{"scip-go gomod example.com/m v1 `example.com/m/store`/Store#items.", parsedSymbol{pkg: "example.com/m", name: "Store.items", kind: "field"}, true},
+		{"scip-go gomod example.com/m v1 `example.com/m/store`/limit.", parsedSymbol{pkg: "example.com/m", name: "limit", kind: "variable"}, true},
+		{"rust-analyzer cargo my  crate 0.1.0 parser/Token#", parsedSymbol{pkg: "my crate", name: "Token", kind: "type"}, true},
+		{"rust-analyzer cargo core 1.0 macros/vec!", parsedSymbol{pkg: "core", name: "vec", kind: "macro"}, true},
+		{"local 4", parsedSymbol{}, false},
+		{"scip-go gomod example.com/m v1 `example.com/m/store`/", parsedSymbol{}, false},
+		{"scip-go gomod example.com/m v1 `example.com/m/store`/Store#Get().(key)", parsedSymbol{}, false},
+		{"scip-go gomod example.com/m v1 `example.com/m/store`/Map#[K]", parsedSymbol{}, false},
+		{"scip-go gomod", parsedSymbol{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSymbol(tt.symbol)
+		got.raw = ""
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseSymbol(%q) = %+v, %v; want %+v, %v", tt.symbol, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDecodeExport(t *testing.T) {
+	dir := t.TempDir()
+	src := "package store\n\ntype Store struct{}\n\n// Get returns the value of key.\nfunc (s *Store) Get(key string) string {\n\treturn lookup(key)\n}\n\nfunc lookup(key string) string { return key }\n"
+	if err := os.WriteFile(filepath.Join(dir, "store.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defs := []Definition{
+		{Name: "Store", Kind: "type", File: "store.go", Line: 3},
+		{Name: "Store.Get", Kind: "method", File: "store.go", Line: 6, Signature: "func (s *Store) Get(key string) string", Doc: "Get returns the value of key."},
+		{Name: "lookup", Kind: "function", File: "store.go", Line: 10},
+	}
+	var buf bytes.Buffer
+	if _, err := Export(&buf, dir, defs, Options{Package: "store", ToolName: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := Decode(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	d := docs[0]
+	if d.Path != "store.go" || d.Language != "go" || d.Package != "store" {
+		t.Errorf("document = %q, %q, %q; want store.go, go, store", d.Path, d.Language, d.Package)
+	}
+	if len(d.Definitions) != len(defs) {
+		t.Fatalf("got %d definitions, want %d: %+v", len(d.Definitions), len(defs), d.Definitions)
+	}
+	for i, want := range defs {
+		if got := d.Definitions[i]; got != want {
+			t.Errorf("definition %d = %+v, want %+v", i, got, want)
+		}
+	}
+	found := false
+	for _, r := range d.References {
+		if r.Name == "lookup" {
+			found = true
+			if r.Kind != "function" || r.Line != 7 || r.Caller != "Store.Get" {
+				t.Errorf("reference to lookup = %+v, want a function at line 7 called from Store.Get", r)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no reference to lookup in %+v", d.References)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	if _, err := Decode([]byte{0x12, 0x05, 0x0a}); err == nil {
+		t.Error("Decode of a truncated index succeeded")
+	}
+}
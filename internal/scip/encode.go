@@ -0,0 +1,129 @@
+package scip
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// The messages below mirror the subset of scip.proto the export fills in,
+// with their field numbers, and are encoded by hand to avoid depending on
+// the generated bindings.
+
+// roleDefinition is SymbolRole.Definition.
+const roleDefinition = 1
+
+// Enum values of scip.proto.
+const (
+	textEncodingUTF8             = 1 // TextEncoding.UTF8
+	positionEncodingUTF8FromLine = 1 // PositionEncoding.UTF8CodeUnitOffsetFromLineStart
+)
+
+type index struct {
+	projectRoot string
+	toolName    string
+	toolVersion string
+	arguments   []string
+	documents   []*document
+}
+
+type document struct {
+	path        string
+	language    string
+	occurrences []occurrence
+	symbols     []symbolInfo
+}
+
+type occurrence struct {
+	// rng is [line, startChar, endChar], zero-based, on a single line.
+	rng    []int32
+	symbol string
+	roles  int32
+}
+
+type symbolInfo struct {
+	symbol        string
+	documentation []string
+	displayName   string
+}
+
+// encode returns the Index message.
+func (x *index) encode() []byte {
+	var tool []byte
+	tool = appendString(tool, 1, x.toolName)
+	tool = appendString(tool, 2, x.toolVersion)
+	for _, a := range x.arguments {
+		tool = appendString(tool, 3, a)
+	}
+
+	var meta []byte
+	meta = appendMessage(meta, 2, tool)
+	meta = appendString(meta, 3, x.projectRoot)
+	meta = appendVarint(meta, 4, textEncodingUTF8)
+
+	var b []byte
+	b = appendMessage(b, 1, meta)
+	for _, d := range x.documents {
+		b = appendMessage(b, 2, d.encode())
+	}
+	return b
+}
+
+// encode returns the Document message.
+func (d *document) encode() []byte {
+	var b []byte
+	b = appendString(b, 1, d.path)
+	for _, o := range d.occurrences {
+		b = appendMessage(b, 2, o.encode())
+	}
+	for _, s := range d.symbols {
+		b = appendMessage(b, 3, s.encode())
+	}
+	b = appendString(b, 4, d.language)
+	b = appendVarint(b, 6, positionEncodingUTF8FromLine)
+	return b
+}
+
+// encode returns the Occurrence message.
+func (o *occurrence) encode() []byte {
+	var packed []byte
+	for _, v := range o.rng {
+		packed = protowire.AppendVarint(packed, uint64(v))
+	}
+	var b []byte
+	b = appendMessage(b, 1, packed)
+	b = appendString(b, 2, o.symbol)
+	b = appendVarint(b, 3, uint64(o.roles))
+	return b
+}
+
+// encode returns the SymbolInformation message.
+func (s *symbolInfo) encode() []byte {
+	var b []byte
+	b = appendString(b, 1, s.symbol)
+	for _, d := range s.documentation {
+		b = appendString(b, 3, d)
+	}
+	b = appendString(b, 6, s.displayName)
+	return b
+}
+
+// appendString appends a string field, omitting it when empty as proto3
+// does.
+func appendString(b []byte, field protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, field, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// appendMessage appends an embedded message, or a packed repeated field.
+func appendMessage(b []byte, field protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, field, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendVarint(b []byte, field protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, field, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
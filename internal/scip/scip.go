@@ -0,0 +1,370 @@
+// Package scip exports a project's symbols and their references in the
+// SCIP code intelligence format (https://github.com/sourcegraph/scip), so
+// Sourcegraph and other code-intel tools can navigate the index, and
+// reads the indexes other SCIP indexers write (see Decode).
+//
+// Symbols come from the structural index and references are matched by
+// name (see package refs). In Go, a reference must also be able to see
+// the definition's package. A reference is attributed to a definition
+// only when one candidate remains, or when exactly one of them lives in
+// the same file or directory; other references are left out rather than
+// guessed.
+package scip
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourorg/agent/internal/refs"
+)
+
+// Definition is a symbol of the structural index.
+type Definition struct {
+	Name      string // "Func", "Type", or "Type.Method"
+	Kind      string // "function", "method", "type", "class", ...
+	File      string // slash-separated, relative to the project
+	Line      int    // 1-based
+	Signature string
+	Doc       string
+}
+
+// Options describes the export.
+type Options struct {
+	// Package names the project in symbols; defaults to the project
+	// directory's name.
+	Package     string
+	ToolName    string
+	ToolVersion string
+	Arguments   []string
+}
+
+// Stats counts what an export contained.
+type Stats struct {
+	Documents   int
+	Symbols     int
+	Occurrences int
+}
+
+// Export writes the SCIP index of the project at projectPath, built from
+// defs, to w.
+func Export(w io.Writer, projectPath string, defs []Definition, opts Options) (Stats, error) {
+	abs, err := filepath.Abs(projectPath)
+	if err != nil {
+		return Stats{}, fmt.Errorf("resolve project path: %w", err)
+	}
+	if opts.Package == "" {
+		opts.Package = filepath.Base(abs)
+	}
+
+	symbols := make(map[*Definition]string, len(defs))
+	byName := make(map[string][]*Definition)
+	for i := range defs {
+		d := &defs[i]
+		symbols[d] = symbol(opts.Package, d)
+		short := d.Name[strings.LastIndex(d.Name, ".")+1:]
+		byName[short] = append(byName[short], d)
+	}
+	names := make([]string, 0, len(byName))
+	for n := range byName {
+		names = append(names, n)
+	}
+	uses, err := refs.FindAll(projectPath, names)
+	if err != nil {
+		return Stats{}, fmt.Errorf("find references: %w", err)
+	}
+
+	docs := make(map[string]*document)
+	doc := func(file string) *document {
+		if d, ok := docs[file]; ok {
+			return d
+		}
+		d := &document{path: file}
+		docs[file] = d
+		return d
+	}
+	lines := newLineCache(projectPath)
+	goFiles := newGoImports(projectPath)
+
+	for i := range defs {
+		d := &defs[i]
+		short := d.Name[strings.LastIndex(d.Name, ".")+1:]
+		col := strings.Index(lines.line(d.File, d.Line), short)
+		rng := []int32{int32(d.Line - 1), 0, 0}
+		if col >= 0 {
+			rng = []int32{int32(d.Line - 1), int32(col), int32(col + len(short))}
+		}
+		dd := doc(d.File)
+		dd.occurrences = append(dd.occurrences, occurrence{rng: rng, symbol: symbols[d], roles: roleDefinition})
+		dd.symbols = append(dd.symbols, symbolInfo{symbol: symbols[d], displayName: d.Name, documentation: documentation(d)})
+	}
+	for name, found := range uses {
+		for _, r := range found {
+			var candidates []*Definition
+			for _, d := range byName[name] {
+				if goFiles.visible(d, r) {
+					candidates = append(candidates, d)
+				}
+			}
+			d := resolve(candidates, r.File)
+			if d == nil {
+				continue
+			}
+			rd := doc(r.File)
+			rd.occurrences = append(rd.occurrences, occurrence{
+				rng:    []int32{int32(r.Line - 1), int32(r.Column - 1), int32(r.Column - 1 + len(name))},
+				symbol: symbols[d],
+			})
+		}
+	}
+
+	idx := index{
+		projectRoot: "file://" + filepath.ToSlash(abs),
+		toolName:    opts.ToolName,
+		toolVersion: opts.ToolVersion,
+		arguments:   opts.Arguments,
+	}
+	var stats Stats
+	for _, d := range docs {
+		d.language = language(d.path)
+		sort.Slice(d.occurrences, func(i, j int) bool {
+			a, b := d.occurrences[i].rng, d.occurrences[j].rng
+			if a[0] != b[0] {
+				return a[0] < b[0]
+			}
+			return a[1] < b[1]
+		})
+		idx.documents = append(idx.documents, d)
+		stats.Symbols += len(d.symbols)
+		stats.Occurrences += len(d.occurrences)
+	}
+	sort.Slice(idx.documents, func(i, j int) bool { return idx.documents[i].path < idx.documents[j].path })
+	stats.Documents = len(idx.documents)
+
+	if _, err := w.Write(idx.encode()); err != nil {
+		return Stats{}, fmt.Errorf("write SCIP index: %w", err)
+	}
+	return stats, nil
+}
+
+// goImports decides whether a Go file can refer to a definition, from its
+// package and imports.
+type goImports struct {
+	root       string
+	modulePath string
+	imports    map[string][]string
+}
+
+func newGoImports(root string) *goImports {
+	g := &goImports{root: root, imports: make(map[string][]string)}
+	if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+				g.modulePath = strings.Trim(strings.TrimSpace(rest), `"`)
+				break
+			}
+		}
+	}
+	return g
+}
+
+// visible reports whether r can refer to d. A Go name refers to a
+// definition in its own package, one selected from its package (pkg.Name)
+// or, for a method called on a value, one in a package the file imports.
+// Go and other languages never refer to each other; references between
+// other files are not checked.
+func (g *goImports) visible(d *Definition, r refs.Reference) bool {
+	defGo, refGo := path.Ext(d.File) == ".go", path.Ext(r.File) == ".go"
+	if defGo != refGo {
+		return false
+	}
+	if !defGo {
+		return true
+	}
+	dir := path.Dir(d.File)
+	if r.Package != "" {
+		return g.isPackage(r.Package, dir)
+	}
+	if path.Dir(r.File) == dir {
+		return true
+	}
+	if !strings.Contains(d.Name, ".") {
+		return false
+	}
+	for _, imp := range g.fileImports(r.File) {
+		if g.isPackage(imp, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPackage reports whether importPath is the package in dir.
+func (g *goImports) isPackage(importPath, dir string) bool {
+	if dir == "." {
+		return g.modulePath != "" && importPath == g.modulePath
+	}
+	if g.modulePath != "" {
+		return importPath == g.modulePath+"/"+dir
+	}
+	return importPath == dir || strings.HasSuffix(importPath, "/"+dir)
+}
+
+func (g *goImports) fileImports(file string) []string {
+	if imports, ok := g.imports[file]; ok {
+		return imports
+	}
+	var imports []string
+	f, err := parser.ParseFile(token.NewFileSet(), filepath.Join(g.root, filepath.FromSlash(file)), nil, parser.ImportsOnly)
+	if err == nil {
+		for _, spec := range f.Imports {
+			imports = append(imports, strings.Trim(spec.Path.Value, `"`))
+		}
+	}
+	g.imports[file] = imports
+	return imports
+}
+
+// resolve picks the definition a use in file refers to: the only one, or
+// the only one in the same file or, failing that, the same directory.
+func resolve(candidates []*Definition, file string) *Definition {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	for _, same := range []func(*Definition) bool{
+		func(d *Definition) bool { return d.File == file },
+		func(d *Definition) bool { return path.Dir(d.File) == path.Dir(file) },
+	} {
+		var match *Definition
+		n := 0
+		for _, d := range candidates {
+			if same(d) {
+				match = d
+				n++
+			}
+		}
+		if n == 1 {
+			return match
+		}
+		if n > 1 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// symbol returns the SCIP symbol of d: "scip-indexer . <package> . "
+// followed by descriptors for the directory (and, outside Go, the module
+// file) and the name, e.g. "internal/rag/APIEmbedder#Embed()." for a Go
+// method.
+func symbol(pkg string, d *Definition) string {
+	var b strings.Builder
+	b.WriteString("scip-indexer . ")
+	b.WriteString(escape(pkg))
+	b.WriteString(" . ")
+
+	dir := path.Dir(d.File)
+	if dir != "." {
+		for _, seg := range strings.Split(dir, "/") {
+			b.WriteString(escape(seg) + "/")
+		}
+	}
+	if path.Ext(d.File) != ".go" {
+		// Outside Go, each file is its own module.
+		b.WriteString(escape(strings.TrimSuffix(path.Base(d.File), path.Ext(d.File))) + "/")
+	}
+
+	parts := strings.Split(d.Name, ".")
+	for _, owner := range parts[:len(parts)-1] {
+		b.WriteString(escape(owner) + "#")
+	}
+	name := escape(parts[len(parts)-1])
+	switch strings.ToLower(d.Kind) {
+	case "function", "method", "func":
+		b.WriteString(name + "().")
+	case "type", "struct", "interface", "class", "enum", "trait":
+		b.WriteString(name + "#")
+	default:
+		b.WriteString(name + ".")
+	}
+	return b.String()
+}
+
+// escape backquotes a name that is not a simple SCIP identifier.
+func escape(name string) string {
+	simple := name != ""
+	for _, r := range name {
+		if !(r == '_' || r == '+' || r == '-' || r == '$' ||
+			'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			simple = false
+			break
+		}
+	}
+	if simple {
+		return name
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func documentation(d *Definition) []string {
+	var out []string
+	if d.Signature != "" {
+		out = append(out, "```"+language(d.File)+"\n"+d.Signature+"\n```")
+	}
+	if d.Doc != "" {
+		out = append(out, d.Doc)
+	}
+	return out
+}
+
+func language(file string) string {
+	switch path.Ext(file) {
+	case ".go":
+		return "go"
+	case ".py":
+		return "python"
+	case ".ts", ".tsx":
+		return "typescript"
+	case ".js", ".jsx":
+		return "javascript"
+	case ".java":
+		return "java"
+	case ".rs":
+		return "rust"
+	case ".rb":
+		return "ruby"
+	case ".kt", ".kts":
+		return "kotlin"
+	}
+	return ""
+}
+
+// lineCache reads each file once to place definitions within their line.
+type lineCache struct {
+	root  string
+	files map[string][]string
+}
+
+func newLineCache(root string) *lineCache {
+	return &lineCache{root: root, files: make(map[string][]string)}
+}
+
+func (c *lineCache) line(file string, n int) string {
+	lines, ok := c.files[file]
+	if !ok {
+		if src, err := os.ReadFile(filepath.Join(c.root, filepath.FromSlash(file))); err == nil {
+			lines = strings.Split(string(src), "\n")
+		}
+		c.files[file] = lines
+	}
+	if n < 1 || n > len(lines) {
+		return ""
+	}
+	return lines[n-1]
+}
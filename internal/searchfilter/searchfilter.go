@@ -0,0 +1,202 @@
+// Package searchfilter restricts search results by symbol kind, source
+// language and location in the project.
+package searchfilter
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// kindAliases maps the kinds users ask for to the kinds the index
+// reports.
+var kindAliases = map[string][]string{
+	"func":      {"function", "method"},
+	"function":  {"function"},
+	"method":    {"method"},
+	"type":      {"type", "struct", "interface", "class", "enum", "trait"},
+	"struct":    {"struct"},
+	"interface": {"interface"},
+	"class":     {"class"},
+	"var":       {"variable", "var", "constant", "const"},
+	"const":     {"constant", "const"},
+	"section":   {"section"}, // documentation sections
+	"config":    {"config"},  // configuration file keys
+	"key":       {"config"},
+	"table":     {"table", "view"}, // SQL schema objects
+	"column":    {"column"},
+	"index":     {"index"},
+}
+
+// languages maps file extensions to language names.
+var languages = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".ts":   "typescript",
+	".tsx":  "typescript",
+	".js":   "javascript",
+	".jsx":  "javascript",
+	".java": "java",
+	".rs":   "rust",
+	".rb":   "ruby",
+	".kt":   "kotlin",
+	".kts":  "kotlin",
+
+	".md":       "markdown",
+	".markdown": "markdown",
+	".rst":      "rst",
+	".adoc":     "asciidoc",
+
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".json": "json",
+	".toml": "toml",
+	".env":  "dotenv",
+	".sql":  "sql",
+}
+
+var languageAliases = map[string]string{
+	"golang": "go",
+	"py":     "python",
+	"ts":     "typescript",
+	"js":     "javascript",
+	"rs":     "rust",
+	"rb":     "ruby",
+	"kt":     "kotlin",
+	"md":     "markdown",
+	"yml":    "yaml",
+	"env":    "dotenv",
+}
+
+// Filter keeps results matching any of its kinds, any of its languages
+// and any of its paths. An empty list does not filter.
+type Filter struct {
+	Kinds     []string
+	Languages []string
+	// Paths are slash-separated and relative to the project: a directory
+	// ("internal/rag"), a directory and everything below it
+	// ("internal/..."), or a glob ("cmd/*/main.go").
+	Paths []string
+}
+
+// Parse builds a filter from comma-separated lists, as given on the
+// command line, rejecting unknown kinds and languages.
+func Parse(kinds, langs, paths string) (Filter, error) {
+	var f Filter
+	for _, k := range split(strings.ToLower(kinds)) {
+		if _, ok := kindAliases[k]; !ok {
+			return Filter{}, fmt.Errorf("unknown kind %q (want func, function, method, type, struct, interface, class, var, const, section, config, table, column or index)", k)
+		}
+		f.Kinds = append(f.Kinds, k)
+	}
+	for _, l := range split(strings.ToLower(langs)) {
+		if alias, ok := languageAliases[l]; ok {
+			l = alias
+		}
+		if !knownLanguage(l) {
+			return Filter{}, fmt.Errorf("unknown language %q", l)
+		}
+		f.Languages = append(f.Languages, l)
+	}
+	for _, p := range split(paths) {
+		if _, err := path.Match(p, ""); err != nil {
+			return Filter{}, fmt.Errorf("invalid path pattern %q: %w", p, err)
+		}
+		f.Paths = append(f.Paths, strings.TrimPrefix(filepath.ToSlash(p), "./"))
+	}
+	return f, nil
+}
+
+// Empty reports whether the filter keeps everything.
+func (f Filter) Empty() bool {
+	return len(f.Kinds) == 0 && len(f.Languages) == 0 && len(f.Paths) == 0
+}
+
+// Match reports whether a result of the given kind, in the given file,
+// passes the filter. file may be absolute or relative to projectPath.
+func (f Filter) Match(projectPath, kind, file string) bool {
+	return f.matchKind(kind) && f.MatchFile(projectPath, file)
+}
+
+// MatchFile applies the language and path filters alone, for results
+// without a kind such as text matches.
+func (f Filter) MatchFile(projectPath, file string) bool {
+	if filepath.IsAbs(file) && projectPath != "" {
+		if rel, err := filepath.Rel(projectPath, file); err == nil {
+			file = rel
+		}
+	}
+	file = filepath.ToSlash(file)
+	return f.matchLanguage(file) && f.matchPath(file)
+}
+
+func (f Filter) matchKind(kind string) bool {
+	if len(f.Kinds) == 0 {
+		return true
+	}
+	kind = strings.ToLower(kind)
+	for _, k := range f.Kinds {
+		for _, want := range kindAliases[k] {
+			if kind == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f Filter) matchLanguage(file string) bool {
+	if len(f.Languages) == 0 {
+		return true
+	}
+	lang := languages[strings.ToLower(path.Ext(file))]
+	for _, l := range f.Languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) matchPath(file string) bool {
+	if len(f.Paths) == 0 {
+		return true
+	}
+	for _, p := range f.Paths {
+		if dir, ok := strings.CutSuffix(p, "..."); ok {
+			dir = strings.TrimSuffix(dir, "/")
+			if dir == "" || file == dir || strings.HasPrefix(file, dir+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(p, file); ok {
+			return true
+		}
+		// A plain directory matches the files directly in it.
+		if path.Dir(file) == strings.TrimSuffix(p, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+func knownLanguage(name string) bool {
+	for _, l := range languages {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+func split(list string) []string {
+	var out []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
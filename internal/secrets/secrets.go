@@ -0,0 +1,167 @@
+// Package secrets detects credentials in text (API keys, tokens, private
+// keys, passwords) with gitleaks-style rules and redacts them before file
+// contents, chunks or command output leave the machine.
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule detects one kind of secret. When Pattern has a capture group, only
+// the first group is the secret; otherwise the whole match is.
+type Rule struct {
+	ID      string
+	Pattern *regexp.Regexp
+	// MinEntropy, if set, skips candidates whose Shannon entropy (bits per
+	// character) is lower, filtering out placeholders like "changeme".
+	MinEntropy float64
+	// SkipIdentifiers ignores candidates that look like code identifiers
+	// (config.APIKey, os.Getenv) rather than literals. Used by the generic
+	// assignment rule, which matches any "password = ..." line.
+	SkipIdentifiers bool
+}
+
+// DefaultRules cover common provider tokens plus generic credential
+// assignments.
+var DefaultRules = []Rule{
+	{ID: "private-key", Pattern: regexp.MustCompile(`-----BEGIN[ A-Z0-9]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END[ A-Z0-9]*PRIVATE KEY( BLOCK)?-----`)},
+	{ID: "aws-access-key-id", Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`)},
+	{ID: "aws-secret-access-key", Pattern: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|private).{0,20}?['"\s:=]+([A-Za-z0-9/+=]{40})\b`), MinEntropy: 3.5},
+	{ID: "github-token", Pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{60,255})\b`)},
+	{ID: "gitlab-token", Pattern: regexp.MustCompile(`\b(glpat-[A-Za-z0-9_-]{20,})\b`)},
+	{ID: "slack-token", Pattern: regexp.MustCompile(`\b(xox[baprs]-[A-Za-z0-9-]{10,})\b`)},
+	{ID: "slack-webhook", Pattern: regexp.MustCompile(`https://hooks\.slack\.com/(?:services|workflows)/[A-Za-z0-9/_-]+`)},
+	{ID: "stripe-key", Pattern: regexp.MustCompile(`\b((?:sk|rk)_(?:live|test)_[A-Za-z0-9]{20,})\b`)},
+	{ID: "google-api-key", Pattern: regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})\b`)},
+	{ID: "anthropic-api-key", Pattern: regexp.MustCompile(`\b(sk-ant-[A-Za-z0-9_-]{20,})`)},
+	{ID: "openai-api-key", Pattern: regexp.MustCompile(`\b(sk-(?:proj-|svcacct-)?[A-Za-z0-9_-]{32,})`), MinEntropy: 3.5},
+	{ID: "jwt", Pattern: regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`)},
+	{ID: "url-password", Pattern: regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s:/@]+:([^\s:/@]{3,})@`), SkipIdentifiers: true},
+	{
+		ID:              "generic-secret",
+		Pattern:         regexp.MustCompile(`(?i)[\w.-]*(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|client[_-]?secret)[\w.-]*["']?\s*(?::=|=>|=|:)\s*["'\x60]?([A-Za-z0-9_\-+/=.~!@#$%^&*]{8,})`),
+		MinEntropy:      3.0,
+		SkipIdentifiers: true,
+	},
+}
+
+// Finding is one secret located in a text.
+type Finding struct {
+	RuleID string
+	Start  int // byte offsets of the secret itself
+	End    int
+}
+
+// Scanner finds and redacts secrets using a set of rules.
+type Scanner struct {
+	rules []Rule
+}
+
+// NewScanner creates a scanner; with no rules it uses DefaultRules.
+func NewScanner(rules ...Rule) *Scanner {
+	if len(rules) == 0 {
+		rules = DefaultRules
+	}
+	return &Scanner{rules: rules}
+}
+
+var defaultScanner = NewScanner()
+
+// Scan returns the non-overlapping secrets in text, in order. When two
+// rules match overlapping spans the earlier, longer one wins.
+func (s *Scanner) Scan(text string) []Finding {
+	var found []Finding
+	for _, rule := range s.rules {
+		for _, m := range rule.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			candidate := text[start:end]
+			if rule.MinEntropy > 0 && Entropy(candidate) < rule.MinEntropy {
+				continue
+			}
+			if rule.SkipIdentifiers && isIdentifier(candidate) {
+				continue
+			}
+			found = append(found, Finding{RuleID: rule.ID, Start: start, End: end})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Start != found[j].Start {
+			return found[i].Start < found[j].Start
+		}
+		return found[i].End > found[j].End
+	})
+	var out []Finding
+	for _, f := range found {
+		if len(out) > 0 && f.Start < out[len(out)-1].End {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// RedactedPrefix starts the placeholder Redact puts in place of a secret.
+const RedactedPrefix = "[REDACTED:"
+
+// Redact replaces every secret in text with "[REDACTED:<rule>]" and returns
+// the result and the number of secrets replaced.
+func (s *Scanner) Redact(text string) (string, int) {
+	found := s.Scan(text)
+	if len(found) == 0 {
+		return text, 0
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	last := 0
+	for _, f := range found {
+		b.WriteString(text[last:f.Start])
+		b.WriteString(RedactedPrefix + f.RuleID + "]")
+		last = f.End
+	}
+	b.WriteString(text[last:])
+	return b.String(), len(found)
+}
+
+// Redact redacts text with the default rules.
+func Redact(text string) string {
+	redacted, _ := defaultScanner.Redact(text)
+	return redacted
+}
+
+// Entropy returns the Shannon entropy of s in bits per character.
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// isIdentifier reports whether s looks like code rather than a literal
+// secret: dotted identifiers and calls such as cfg.Token or os.Getenv.
+func isIdentifier(s string) bool {
+	for _, r := range s {
+		if !(r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package sqlschema
+
+import (
+	"strings"
+)
+
+// token is a word, quoted name, literal or punctuation character of a
+// SQL statement.
+type token struct {
+	text   string // unquoted for quoted names
+	quoted bool   // a quoted name or a string literal
+	line   int
+}
+
+// upper is the token as a keyword; quoted tokens are never keywords.
+func (t token) upper() string {
+	if t.quoted {
+		return ""
+	}
+	return strings.ToUpper(t.text)
+}
+
+// statements splits src into statements of tokens, at semicolons outside
+// strings, comments and dollar-quoted bodies.
+func statements(src string) [][]token {
+	var (
+		all  [][]token
+		cur  []token
+		line = 1
+	)
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '-' && strings.HasPrefix(src[i:], "--"), c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src) - i - 4
+			}
+			line += strings.Count(src[i:i+end+4], "\n")
+			i += end + 4
+		case c == ';':
+			if len(cur) > 0 {
+				all = append(all, cur)
+			}
+			cur = nil
+			i++
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			j := i + 1
+			for j < len(src) {
+				if src[j] == closing {
+					if j+1 < len(src) && src[j+1] == closing && closing != ']' {
+						j += 2 // doubled quote
+						continue
+					}
+					break
+				}
+				j++
+			}
+			text := src[i+1 : min(j, len(src))]
+			cur = append(cur, token{text: text, quoted: true, line: line})
+			line += strings.Count(text, "\n")
+			i = j + 1
+		case c == '$':
+			// Dollar quoting: $$ ... $$ or $tag$ ... $tag$.
+			end := strings.IndexByte(src[i+1:], '$')
+			tag := ""
+			if end >= 0 {
+				tag = src[i : i+end+2]
+			}
+			if tag == "" || strings.ContainsAny(tag[1:len(tag)-1], " \t\n;") {
+				cur = append(cur, token{text: "$", line: line})
+				i++
+				continue
+			}
+			body := strings.Index(src[i+len(tag):], tag)
+			if body < 0 {
+				body = len(src) - i - len(tag)
+			}
+			text := src[i+len(tag) : i+len(tag)+body]
+			cur = append(cur, token{text: text, quoted: true, line: line})
+			line += strings.Count(text, "\n")
+			i += len(tag) + body + len(tag)
+		case isWordByte(c):
+			j := i
+			for j < len(src) && isWordByte(src[j]) {
+				j++
+			}
+			cur = append(cur, token{text: src[i:j], line: line})
+			i = j
+		default:
+			cur = append(cur, token{text: string(c), line: line})
+			i++
+		}
+	}
+	if len(cur) > 0 {
+		all = append(all, cur)
+	}
+	return all
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,318 @@
+package sqlschema
+
+import (
+	"strings"
+)
+
+// parser walks the tokens of one statement.
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return token{}
+}
+
+// accept consumes the keywords kws, in order, if the statement continues
+// with them.
+func (p *parser) accept(kws ...string) bool {
+	for i, kw := range kws {
+		if p.pos+i >= len(p.toks) || p.toks[p.pos+i].upper() != kw {
+			return false
+		}
+	}
+	p.pos += len(kws)
+	return true
+}
+
+// name consumes a possibly schema-qualified and quoted name.
+func (p *parser) name() (string, int, bool) {
+	t := p.peek()
+	if t.text == "" || !t.quoted && !isWordByte(t.text[0]) {
+		return "", 0, false
+	}
+	p.pos++
+	name := t.text
+	for {
+		next := p.peek()
+		switch {
+		case !next.quoted && next.text == ".":
+			p.pos++
+			if after := p.peek(); after.text != "" {
+				name += "." + after.text
+				p.pos++
+			}
+		case !next.quoted && strings.HasPrefix(next.text, ".") && len(next.text) > 1:
+			name += next.text
+			p.pos++
+		default:
+			return name, t.line, true
+		}
+	}
+}
+
+// group returns the tokens inside the parenthesized group starting at the
+// current token, split at top-level commas, and consumes it.
+func (p *parser) group() [][]token {
+	if p.peek().text != "(" || p.peek().quoted {
+		return nil
+	}
+	p.pos++
+	var (
+		items [][]token
+		cur   []token
+		depth = 0
+	)
+	for ; p.pos < len(p.toks); p.pos++ {
+		t := p.toks[p.pos]
+		if !t.quoted {
+			switch t.text {
+			case "(":
+				depth++
+			case ")":
+				if depth == 0 {
+					p.pos++
+					return append(items, cur)
+				}
+				depth--
+			case ",":
+				if depth == 0 {
+					items = append(items, cur)
+					cur = nil
+					continue
+				}
+			}
+		}
+		cur = append(cur, t)
+	}
+	return append(items, cur)
+}
+
+// constraintWords start table constraints rather than column definitions.
+var constraintWords = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "FOREIGN": true, "UNIQUE": true, "CHECK": true,
+	"KEY": true, "INDEX": true, "EXCLUDE": true, "FULLTEXT": true, "SPATIAL": true, "PERIOD": true,
+}
+
+// columnType renders the type of a column definition: the tokens after
+// its name up to the first constraint keyword.
+func columnType(toks []token) string {
+	var parts []string
+	for _, t := range toks {
+		switch t.upper() {
+		case "NOT", "NULL", "DEFAULT", "PRIMARY", "REFERENCES", "UNIQUE", "CHECK", "CONSTRAINT",
+			"GENERATED", "COLLATE", "AUTO_INCREMENT", "AUTOINCREMENT", "IDENTITY", "COMMENT":
+			return joinType(parts)
+		}
+		if t.quoted {
+			continue
+		}
+		parts = append(parts, t.text)
+	}
+	return joinType(parts)
+}
+
+func joinType(parts []string) string {
+	s := strings.Join(parts, " ")
+	for _, r := range []struct{ old, new string }{{"( ", "("}, {" (", "("}, {" )", ")"}, {" ,", ","}} {
+		s = strings.ReplaceAll(s, r.old, r.new)
+	}
+	return strings.ToLower(s)
+}
+
+// parseStatement returns the schema objects a statement creates, changes
+// or drops.
+func parseStatement(toks []token) []Object {
+	p := &parser{toks: toks}
+	switch {
+	case p.accept("CREATE"):
+		p.accept("OR", "REPLACE")
+		for p.accept("TEMP") || p.accept("TEMPORARY") || p.accept("UNLOGGED") || p.accept("GLOBAL") || p.accept("LOCAL") {
+		}
+		switch {
+		case p.accept("TABLE"):
+			return p.createTable()
+		case p.accept("VIEW"), p.accept("MATERIALIZED", "VIEW"):
+			p.accept("IF", "NOT", "EXISTS")
+			if name, line, ok := p.name(); ok {
+				return []Object{{Kind: "view", Name: name, Op: "create", Line: line}}
+			}
+		case p.accept("UNIQUE", "INDEX"), p.accept("INDEX"):
+			return p.createIndex()
+		}
+	case p.accept("ALTER", "TABLE"):
+		return p.alterTable()
+	case p.accept("DROP", "TABLE"):
+		return p.drop("table")
+	case p.accept("DROP", "VIEW"), p.accept("DROP", "MATERIALIZED", "VIEW"):
+		return p.drop("view")
+	case p.accept("DROP", "INDEX"):
+		p.accept("CONCURRENTLY")
+		p.accept("IF", "EXISTS")
+		if name, line, ok := p.name(); ok {
+			return []Object{{Kind: "index", Name: name, Op: "drop", Line: line}}
+		}
+	case p.accept("RENAME", "TABLE"):
+		from, line, ok := p.name()
+		if ok && p.accept("TO") {
+			if to, _, ok := p.name(); ok {
+				return []Object{{Kind: "table", Name: to, From: from, Op: "rename", Line: line}}
+			}
+		}
+	}
+	return nil
+}
+
+// drop parses the names of DROP TABLE or DROP VIEW.
+func (p *parser) drop(kind string) []Object {
+	p.accept("IF", "EXISTS")
+	var objs []Object
+	for {
+		name, line, ok := p.name()
+		if !ok {
+			return objs
+		}
+		objs = append(objs, Object{Kind: kind, Name: name, Op: "drop", Line: line})
+		if !p.accept(",") {
+			return objs
+		}
+	}
+}
+
+func (p *parser) createTable() []Object {
+	p.accept("IF", "NOT", "EXISTS")
+	table, line, ok := p.name()
+	if !ok {
+		return nil
+	}
+	objs := []Object{{Kind: "table", Name: table, Op: "create", Line: line}}
+	for _, def := range p.group() {
+		if len(def) == 0 || constraintWords[def[0].upper()] {
+			continue
+		}
+		if def[0].upper() == "LIKE" {
+			continue
+		}
+		objs = append(objs, Object{Kind: "column", Name: def[0].text, Table: table, Type: columnType(def[1:]), Op: "create", Line: def[0].line})
+	}
+	return objs
+}
+
+func (p *parser) createIndex() []Object {
+	p.accept("CONCURRENTLY")
+	p.accept("IF", "NOT", "EXISTS")
+	name, line, _ := p.name()
+	if strings.EqualFold(name, "ON") {
+		p.pos-- // an unnamed index
+		name = ""
+	}
+	if !p.accept("ON") {
+		return nil
+	}
+	p.accept("ONLY")
+	table, tline, ok := p.name()
+	if !ok {
+		return nil
+	}
+	if name == "" {
+		line = tline
+	}
+	var cols []string
+	p.accept("USING")
+	if t := p.peek(); !t.quoted && t.text != "(" {
+		p.pos++ // index method
+	}
+	for _, item := range p.group() {
+		if len(item) > 0 {
+			cols = append(cols, item[0].text)
+		}
+	}
+	return []Object{{Kind: "index", Name: name, Table: table, Type: strings.Join(cols, ", "), Op: "create", Line: line}}
+}
+
+func (p *parser) alterTable() []Object {
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	table, line, ok := p.name()
+	if !ok {
+		return nil
+	}
+	objs := []Object{{Kind: "table", Name: table, Op: "alter", Line: line}}
+	// Actions are comma-separated: ADD COLUMN a int, DROP COLUMN b.
+	var action []token
+	flush := func() {
+		if a := alterAction(table, action); a != nil {
+			objs = append(objs, *a)
+		}
+		action = nil
+	}
+	depth := 0
+	for _, t := range p.toks[p.pos:] {
+		if !t.quoted {
+			switch t.text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			case ",":
+				if depth == 0 {
+					flush()
+					continue
+				}
+			}
+		}
+		action = append(action, t)
+	}
+	flush()
+	return objs
+}
+
+// alterAction parses one action of ALTER TABLE table.
+func alterAction(table string, toks []token) *Object {
+	p := &parser{toks: toks}
+	switch {
+	case p.accept("ADD"):
+		if constraintWords[p.peek().upper()] {
+			return nil
+		}
+		p.accept("COLUMN")
+		p.accept("IF", "NOT", "EXISTS")
+		name, line, ok := p.name()
+		if !ok {
+			return nil
+		}
+		return &Object{Kind: "column", Name: name, Table: table, Type: columnType(p.toks[p.pos:]), Op: "add", Line: line}
+	case p.accept("DROP"):
+		if p.accept("CONSTRAINT") || constraintWords[p.peek().upper()] {
+			return nil
+		}
+		p.accept("COLUMN")
+		p.accept("IF", "EXISTS")
+		if name, line, ok := p.name(); ok {
+			return &Object{Kind: "column", Name: name, Table: table, Op: "drop", Line: line}
+		}
+	case p.accept("RENAME", "COLUMN"), p.accept("RENAME"):
+		if p.accept("TO") {
+			if to, line, ok := p.name(); ok {
+				return &Object{Kind: "table", Name: to, From: table, Op: "rename", Line: line}
+			}
+			return nil
+		}
+		from, line, ok := p.name()
+		if ok && p.accept("TO") {
+			if to, _, ok := p.name(); ok {
+				return &Object{Kind: "column", Name: to, From: from, Table: table, Op: "rename", Line: line}
+			}
+		}
+	case p.accept("ALTER"), p.accept("MODIFY"), p.accept("CHANGE"):
+		p.accept("COLUMN")
+		if name, line, ok := p.name(); ok {
+			return &Object{Kind: "column", Name: name, Table: table, Op: "alter", Line: line}
+		}
+	}
+	return nil
+}
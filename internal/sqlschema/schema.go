@@ -0,0 +1,216 @@
+package sqlschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yourorg/agent/internal/docs"
+)
+
+// Table is a table as the SQL files leave it, replayed in migration order
+// without down migrations, with the statements that shaped it.
+type Table struct {
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+	Indexes []string `json:"indexes,omitempty"`
+	Changes []Object `json:"changes"`
+	Dropped bool     `json:"dropped,omitempty"`
+}
+
+// Column is a column of a Table.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// Tables replays objs, as returned by Load, into the tables they define.
+func Tables(objs []Object) []*Table {
+	byName := make(map[string]*Table)
+	var order []*Table
+	get := func(name string) *Table {
+		key := tableKey(name)
+		t, ok := byName[key]
+		if !ok {
+			t = &Table{Name: name}
+			byName[key] = t
+			order = append(order, t)
+		}
+		return t
+	}
+
+	for _, o := range objs {
+		if o.Down {
+			continue
+		}
+		switch o.Kind {
+		case "table":
+			switch o.Op {
+			case "create":
+				t := get(o.Name)
+				t.Columns, t.Indexes, t.Dropped = nil, nil, false
+				t.Changes = append(t.Changes, o)
+			case "rename":
+				t := get(o.From)
+				delete(byName, tableKey(o.From))
+				t.Name = o.Name
+				byName[tableKey(o.Name)] = t
+				t.Changes = append(t.Changes, o)
+			case "drop":
+				t := get(o.Name)
+				t.Dropped = true
+				t.Changes = append(t.Changes, o)
+			}
+		case "column":
+			t := get(o.Table)
+			i := t.column(o.Name)
+			switch o.Op {
+			case "create", "add":
+				if i < 0 {
+					t.Columns = append(t.Columns, Column{Name: o.Name, Type: o.Type})
+				} else {
+					t.Columns[i].Type = o.Type
+				}
+			case "drop":
+				if i >= 0 {
+					t.Columns = append(t.Columns[:i], t.Columns[i+1:]...)
+				}
+			case "rename":
+				if j := t.column(o.From); j >= 0 {
+					t.Columns[j].Name = o.Name
+				}
+			}
+			if o.Op != "create" {
+				t.Changes = append(t.Changes, o)
+			}
+		case "index":
+			if o.Op == "create" && o.Table != "" {
+				t := get(o.Table)
+				t.Indexes = append(t.Indexes, strings.TrimSpace(o.Name+" ("+o.Type+")"))
+				t.Changes = append(t.Changes, o)
+			}
+		}
+	}
+	return order
+}
+
+func (t *Table) column(name string) int {
+	for i, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// tableKey identifies a table regardless of case and schema.
+func tableKey(name string) string {
+	name = strings.ToLower(name)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// ForTask returns the live tables a task names, best match first: by
+// full name, or by every word of a multi-word name, ignoring plurals.
+func ForTask(tables []*Table, task string) []*Table {
+	words := make(map[string]bool)
+	for _, t := range docs.Terms(task) {
+		words[singular(t)] = true
+	}
+	type scored struct {
+		t     *Table
+		score int
+	}
+	var found []scored
+	for _, t := range tables {
+		if t.Dropped {
+			continue
+		}
+		name := tableKey(t.Name)
+		parts := strings.Split(name, "_")
+		score := 0
+		if words[singular(name)] {
+			score = 2
+		} else if len(parts) > 1 {
+			all := true
+			for _, p := range parts {
+				all = all && words[singular(p)]
+			}
+			if all {
+				score = 1
+			}
+		}
+		if score > 0 {
+			found = append(found, scored{t, score})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].score > found[j].score })
+	tables = make([]*Table, len(found))
+	for i, f := range found {
+		tables[i] = f.t
+	}
+	return tables
+}
+
+// singular strips English plural endings: patients, statuses, entries.
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "uses"), strings.HasSuffix(word, "xes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 3:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// Latest returns the last up migration of objs, as returned by Load, for
+// naming the next one; ok is false without migrations.
+func Latest(objs []Object) (last Object, ok bool) {
+	for _, o := range objs {
+		if o.Migration != "" && !o.Down {
+			last, ok = o, true
+		}
+	}
+	return last, ok
+}
+
+// ByName returns the tables, columns, indexes and views created under a
+// name containing name, ignoring case, the way symbols are looked up.
+func ByName(objs []Object, name string) []Object {
+	name = strings.ToLower(name)
+	var found []Object
+	for _, o := range objs {
+		if (o.Op == "create" || o.Op == "add") && o.Name != "" && strings.Contains(strings.ToLower(o.Name), name) {
+			found = append(found, o)
+		}
+	}
+	return found
+}
+
+// Format renders tables with their columns and the statements that
+// shaped them, oldest first.
+func Format(tables []*Table) string {
+	var b strings.Builder
+	for _, t := range tables {
+		fmt.Fprintf(&b, "table %s\n", t.Name)
+		if len(t.Columns) > 0 {
+			cols := make([]string, len(t.Columns))
+			for i, c := range t.Columns {
+				cols[i] = strings.TrimSpace(c.Name + " " + c.Type)
+			}
+			fmt.Fprintf(&b, "  columns: %s\n", strings.Join(cols, ", "))
+		}
+		if len(t.Indexes) > 0 {
+			fmt.Fprintf(&b, "  indexes: %s\n", strings.Join(t.Indexes, ", "))
+		}
+		for _, c := range t.Changes {
+			if c.Migration != "" {
+				fmt.Fprintf(&b, "  [%s] %s\n", c.Migration, c)
+			} else {
+				fmt.Fprintf(&b, "  %s\n", c)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,193 @@
+// Package sqlschema indexes SQL files and migrations: the tables, columns,
+// indexes and views they create, change or drop, and the migration each
+// change belongs to. Schema tasks ("add a column to patients") then find
+// the migrations that shaped a table and the latest one to follow.
+package sqlschema
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Object is a schema object a statement creates, changes or drops.
+type Object struct {
+	Kind  string `json:"kind"` // table, column, index or view
+	Name  string `json:"name"`
+	Table string `json:"table,omitempty"` // of a column or index
+	// Type is a column's type, or an index's columns.
+	Type string `json:"type,omitempty"`
+	// From is the previous name of a renamed table or column.
+	From string `json:"from,omitempty"`
+	Op   string `json:"op"` // create, alter, add, drop or rename
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Migration is the ID of the migration file ("0007", "20240105120000",
+	// "V3"); empty for other SQL files.
+	Migration string `json:"migration,omitempty"`
+	// Down marks statements of down (rollback) migrations.
+	Down bool `json:"down,omitempty"`
+}
+
+func (o Object) String() string {
+	var what string
+	switch {
+	case o.Kind == "index" && o.Table != "":
+		what = strings.Join(strings.Fields(fmt.Sprintf("index %s on %s (%s)", o.Name, o.Table, o.Type)), " ")
+	case o.Table != "":
+		what = fmt.Sprintf("%s %s.%s %s", o.Kind, o.Table, o.Name, o.Type)
+	default:
+		what = o.Kind + " " + o.Name
+	}
+	what = strings.TrimSpace(what)
+	if o.From != "" {
+		what += " (was " + o.From + ")"
+	}
+	return fmt.Sprintf("%s:%d  %s %s", o.File, o.Line, o.Op, what)
+}
+
+// maxFileSize bounds the SQL files Load reads; larger ones are dumps.
+const maxFileSize = 1 << 20
+
+// Load parses the .sql files of the project. Object files are
+// slash-separated and relative to projectPath.
+func Load(projectPath string) ([]Object, error) {
+	var objs []Object
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != projectPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !strings.EqualFold(filepath.Ext(p), ".sql") {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(projectPath, p)
+		if err != nil {
+			return err
+		}
+		objs = append(objs, Parse(filepath.ToSlash(rel), string(src))...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load sql files: %w", err)
+	}
+	sort.SliceStable(objs, func(i, j int) bool {
+		return migrationLess(objs[i].Migration, objs[j].Migration)
+	})
+	return objs, nil
+}
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"venv":         true,
+	"__pycache__":  true,
+}
+
+// Parse returns the schema objects of the SQL file named file.
+func Parse(file, src string) []Object {
+	var objs []Object
+	for _, stmt := range Statements(file, src) {
+		objs = append(objs, stmt.Objects...)
+	}
+	return objs
+}
+
+// Statement is a statement of a SQL file and the schema objects it
+// touches, if any.
+type Statement struct {
+	Line    int // 1-based
+	EndLine int
+	Objects []Object
+}
+
+// Statements splits the SQL file named file into statements.
+func Statements(file, src string) []Statement {
+	id, down := MigrationID(file)
+	var stmts []Statement
+	for _, toks := range statements(src) {
+		last := toks[len(toks)-1]
+		stmt := Statement{Line: toks[0].line, EndLine: last.line + strings.Count(last.text, "\n")}
+		for _, o := range parseStatement(toks) {
+			o.File, o.Migration, o.Down = file, id, down
+			stmt.Objects = append(stmt.Objects, o)
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+var (
+	// flywayName matches Flyway versions: V1_2__add_email.sql.
+	flywayName = regexp.MustCompile(`^([VvUu]\d+(?:[._]\d+)*)__`)
+	// migrationName matches the numeric prefix of golang-migrate, goose,
+	// dbmate, Rails-style and numbered migration files.
+	migrationName = regexp.MustCompile(`^(\d+)(?:[_\-.]|$)`)
+	// downName matches rollback migrations: 0001_x.down.sql, down.sql.
+	downName = regexp.MustCompile(`(?i)(^|[._\-])(down|rollback)(\.|$)`)
+)
+
+// MigrationID returns the migration version of a SQL file from its name,
+// or from its directory for migrations stored as <version>_<name>/up.sql,
+// and whether it is a down migration.
+func MigrationID(file string) (id string, down bool) {
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	down = downName.MatchString(base)
+	if id := versionOf(base); id != "" {
+		return id, down
+	}
+	switch strings.ToLower(base) {
+	case "up", "down", "migration", "rollback":
+		return versionOf(filepath.Base(filepath.Dir(filepath.FromSlash(file)))), down
+	}
+	return "", down
+}
+
+func versionOf(name string) string {
+	if m := flywayName.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	if m := migrationName.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// migrationLess orders migration IDs numerically, after non-migrations.
+func migrationLess(a, b string) bool {
+	if a == "" || b == "" {
+		return a == "" && b != ""
+	}
+	na := strings.TrimLeft(strings.TrimLeft(a, "VvUu"), "0")
+	nb := strings.TrimLeft(strings.TrimLeft(b, "VvUu"), "0")
+	// Versions like 1.2.10 compare part by part.
+	pa, pb := strings.FieldsFunc(na, isSeparator), strings.FieldsFunc(nb, isSeparator)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, y := strings.TrimLeft(pa[i], "0"), strings.TrimLeft(pb[i], "0")
+		if len(x) != len(y) {
+			return len(x) < len(y)
+		}
+		if x != y {
+			return x < y
+		}
+	}
+	return len(pa) < len(pb)
+}
+
+func isSeparator(r rune) bool { return r == '.' || r == '_' }
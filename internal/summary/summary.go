@@ -0,0 +1,453 @@
+// Package summary maintains .index/PROJECT_SUMMARY.md: a compact description
+// of a project (overview, entry points, build and test commands,
+// dependencies, layout) built from the index and optionally enriched by an
+// LLM. The agent reads it as a preamble instead of re-deriving the
+// project's structure on every run.
+package summary
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourorg/agent/internal/deps"
+	"github.com/yourorg/agent/internal/indexer"
+)
+
+// Path returns the summary file of the project at projectPath.
+func Path(projectPath string) string {
+	return filepath.Join(projectPath, ".index", "PROJECT_SUMMARY.md")
+}
+
+const (
+	fingerprintPrefix = "<!-- fingerprint: "
+	respStart         = "<!-- responsibilities:start -->"
+	respEnd           = "<!-- responsibilities:end -->"
+
+	maxOverviewLines = 60
+	maxEntryPoints   = 20
+	maxDependencies  = 15
+	structureDepth   = 2
+)
+
+// Command is a detected build, test or lint command.
+type Command struct {
+	Purpose string `json:"purpose"` // "build", "test", "lint", ...
+	Command string `json:"command"`
+	Source  string `json:"source"` // file it was derived from
+}
+
+// Summary is the content of a project summary.
+type Summary struct {
+	Name        string
+	Fingerprint string
+	Overview    string
+	// Responsibilities is an LLM-written description of what the main
+	// modules do. It survives regeneration until replaced.
+	Responsibilities string
+	EntryPoints      []string
+	Commands         []Command
+	Dependencies     []string
+	Structure        string
+}
+
+// Build collects a summary of the project at projectPath from its index
+// and files.
+func Build(projectPath string, projIdx *indexer.ProjectIndex) (*Summary, error) {
+	fingerprint, err := Fingerprint(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	s := &Summary{
+		Name:        filepath.Base(projectPath),
+		Fingerprint: fingerprint,
+		Commands:    detectCommands(projectPath),
+	}
+	if projIdx != nil {
+		summ := indexer.NewSummarizer()
+		s.Overview = firstLines(strings.TrimSpace(summ.GenerateProjectOverview(projIdx)), maxOverviewLines)
+		s.Structure = strings.TrimRight(summ.GenerateStructureTree(projIdx, structureDepth), "\n")
+	}
+	if s.EntryPoints, err = detectEntryPoints(projectPath); err != nil {
+		return nil, err
+	}
+	if found, err := deps.Scan(projectPath); err == nil {
+		for _, d := range deps.Direct(found) {
+			if !d.Dev {
+				s.Dependencies = append(s.Dependencies, fmt.Sprintf("%s (%s)", d, d.Ecosystem))
+			}
+		}
+	}
+	return s, nil
+}
+
+// Markdown renders the summary file.
+func (s *Summary) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s -->\n", fingerprintPrefix, s.Fingerprint)
+	fmt.Fprintf(&b, "# Project summary: %s\n\n", s.Name)
+	b.WriteString("Generated by `indexer summary` and refreshed when files change. The responsibilities section is kept across refreshes.\n")
+
+	if s.Overview != "" {
+		fmt.Fprintf(&b, "\n## Overview\n\n%s\n", s.Overview)
+	}
+	if s.Responsibilities != "" {
+		fmt.Fprintf(&b, "\n## Responsibilities\n\n%s\n%s\n%s\n", respStart, strings.TrimSpace(s.Responsibilities), respEnd)
+	}
+	if len(s.EntryPoints) > 0 {
+		b.WriteString("\n## Entry points\n\n")
+		for _, e := range s.EntryPoints {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+	if len(s.Commands) > 0 {
+		b.WriteString("\n## Build and test\n\n")
+		for _, c := range s.Commands {
+			fmt.Fprintf(&b, "- %s: `%s` (%s)\n", c.Purpose, c.Command, c.Source)
+		}
+	}
+	if len(s.Dependencies) > 0 {
+		b.WriteString("\n## Key dependencies\n\n")
+		shown := s.Dependencies
+		if len(shown) > maxDependencies {
+			shown = shown[:maxDependencies]
+		}
+		for _, d := range shown {
+			fmt.Fprintf(&b, "- %s\n", d)
+		}
+		if more := len(s.Dependencies) - len(shown); more > 0 {
+			fmt.Fprintf(&b, "- ... and %d more\n", more)
+		}
+	}
+	if s.Structure != "" {
+		fmt.Fprintf(&b, "\n## Layout\n\n```\n%s\n```\n", s.Structure)
+	}
+	return b.String()
+}
+
+// Save writes the summary to the project's summary file.
+func (s *Summary) Save(projectPath string) error {
+	file := Path(projectPath)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return fmt.Errorf("save summary: %w", err)
+	}
+	if err := os.WriteFile(file, []byte(s.Markdown()), 0o644); err != nil {
+		return fmt.Errorf("save summary: %w", err)
+	}
+	return nil
+}
+
+// Load reads the summary file, returning "" when there is none.
+func Load(projectPath string) (string, error) {
+	data, err := os.ReadFile(Path(projectPath))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read summary: %w", err)
+	}
+	return string(data), nil
+}
+
+// Ensure returns the project's summary file content, rebuilding it from
+// projIdx when the project has changed since it was written. An existing
+// responsibilities section is kept. With a nil projIdx the file is only
+// read.
+func Ensure(projectPath string, projIdx *indexer.ProjectIndex) (string, error) {
+	existing, err := Load(projectPath)
+	if err != nil || projIdx == nil {
+		return existing, err
+	}
+	fingerprint, err := Fingerprint(projectPath)
+	if err != nil {
+		return existing, err
+	}
+	if existing != "" && fileFingerprint(existing) == fingerprint {
+		return existing, nil
+	}
+
+	s, err := Build(projectPath, projIdx)
+	if err != nil {
+		return existing, err
+	}
+	s.Responsibilities = Responsibilities(existing)
+	if err := s.Save(projectPath); err != nil {
+		return existing, err
+	}
+	return s.Markdown(), nil
+}
+
+// Preamble strips generator metadata from a summary file and truncates it
+// to about maxBytes, for use at the top of prompts.
+func Preamble(content string, maxBytes int) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "<!--") || strings.HasPrefix(line, "Generated by `indexer summary`") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	out := strings.TrimSpace(strings.Join(lines, "\n"))
+	if maxBytes > 0 && len(out) > maxBytes {
+		cut := strings.LastIndexByte(out[:maxBytes], '\n')
+		if cut < 0 {
+			cut = maxBytes
+		}
+		out = out[:cut] + "\n..."
+	}
+	return out
+}
+
+func fileFingerprint(content string) string {
+	first, _, _ := strings.Cut(content, "\n")
+	if !strings.HasPrefix(first, fingerprintPrefix) {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(first, fingerprintPrefix), " -->")
+}
+
+// Responsibilities returns the responsibilities section of a summary file.
+func Responsibilities(content string) string {
+	_, rest, ok := strings.Cut(content, respStart)
+	if !ok {
+		return ""
+	}
+	text, _, ok := strings.Cut(rest, respEnd)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(text)
+}
+
+// skipDirs are not part of the project's own code.
+var skipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "venv": true, "__pycache__": true,
+	"dist": true, "build": true, "target": true,
+}
+
+// walkSource calls fn for every file outside hidden and dependency
+// directories.
+func walkSource(root string, fn func(rel string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), info)
+	})
+}
+
+// Fingerprint identifies the state of the project's files (paths and
+// sizes), so summaries are rebuilt when files are added, removed or edited.
+func Fingerprint(projectPath string) (string, error) {
+	h := sha256.New()
+	err := walkSource(projectPath, func(rel string, info fs.FileInfo) error {
+		fmt.Fprintf(h, "%s\x00%d\n", rel, info.Size())
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("fingerprint project: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// detectEntryPoints finds Go main packages, Python scripts with a
+// __main__ guard, and npm bin/main entries.
+func detectEntryPoints(projectPath string) ([]string, error) {
+	seen := make(map[string]bool)
+	var entries []string
+	add := func(e string) {
+		if !seen[e] {
+			seen[e] = true
+			entries = append(entries, e)
+		}
+	}
+
+	err := walkSource(projectPath, func(rel string, info fs.FileInfo) error {
+		switch {
+		case strings.HasSuffix(rel, ".go") && !strings.HasSuffix(rel, "_test.go"):
+			if fileContains(filepath.Join(projectPath, rel), goMainPattern) {
+				dir := filepath.ToSlash(filepath.Dir(rel))
+				add(dir + " (Go main package)")
+			}
+		case strings.HasSuffix(rel, ".py"):
+			if fileContains(filepath.Join(projectPath, rel), pyMainPattern) {
+				add(rel + " (Python script)")
+			}
+		case filepath.Base(rel) == "package.json":
+			for _, e := range npmEntryPoints(projectPath, rel) {
+				add(e)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find entry points: %w", err)
+	}
+	sort.Strings(entries)
+	if len(entries) > maxEntryPoints {
+		entries = entries[:maxEntryPoints]
+	}
+	return entries, nil
+}
+
+var (
+	goMainPattern = regexp.MustCompile(`(?m)^package main\b[\s\S]*^func main\(\)`)
+	pyMainPattern = regexp.MustCompile(`(?m)^if __name__ == ["']__main__["']`)
+)
+
+func fileContains(path string, re *regexp.Regexp) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && re.Match(data)
+}
+
+// npmEntryPoints reads the main and bin entries of the package.json at
+// rel, relative to the project root.
+func npmEntryPoints(projectPath, rel string) []string {
+	var pkg struct {
+		Main string          `json:"main"`
+		Bin  json.RawMessage `json:"bin"`
+	}
+	data, err := os.ReadFile(filepath.Join(projectPath, rel))
+	if err != nil || json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+	dir := path.Dir(rel)
+	var entries []string
+	if pkg.Main != "" {
+		entries = append(entries, path.Join(dir, pkg.Main)+" (npm main)")
+	}
+	var bins map[string]string
+	var bin string
+	switch {
+	case len(pkg.Bin) == 0:
+	case json.Unmarshal(pkg.Bin, &bins) == nil:
+		for name, target := range bins {
+			entries = append(entries, fmt.Sprintf("%s (npm bin %s)", path.Join(dir, target), name))
+		}
+	case json.Unmarshal(pkg.Bin, &bin) == nil && bin != "":
+		entries = append(entries, path.Join(dir, bin)+" (npm bin)")
+	}
+	return entries
+}
+
+// detectCommands derives build, test and lint commands from the build
+// files at the project root.
+func detectCommands(projectPath string) []Command {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(projectPath, name))
+		return err == nil
+	}
+
+	var cmds []Command
+	if exists("go.mod") {
+		cmds = append(cmds,
+			Command{"build", "go build ./...", "go.mod"},
+			Command{"test", "go test ./...", "go.mod"},
+			Command{"lint", "go vet ./...", "go.mod"},
+		)
+	}
+	if exists("Cargo.toml") {
+		cmds = append(cmds,
+			Command{"build", "cargo build", "Cargo.toml"},
+			Command{"test", "cargo test", "Cargo.toml"},
+		)
+	}
+	if exists("package.json") {
+		cmds = append(cmds, npmScripts(filepath.Join(projectPath, "package.json"))...)
+	}
+	for _, name := range []string{"pytest.ini", "pyproject.toml", "setup.cfg", "tox.ini", "setup.py"} {
+		if exists(name) {
+			cmds = append(cmds, Command{"test", "pytest", name})
+			break
+		}
+	}
+	if exists("Makefile") {
+		cmds = append(cmds, makeTargets(filepath.Join(projectPath, "Makefile"))...)
+	}
+	return cmds
+}
+
+// commonScripts are the npm scripts and make targets worth listing, in
+// display order.
+var commonScripts = []string{"build", "test", "lint", "check", "fmt", "format", "typecheck"}
+
+func npmScripts(path string) []Command {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+	var cmds []Command
+	for _, name := range commonScripts {
+		if _, ok := pkg.Scripts[name]; !ok {
+			continue
+		}
+		cmd := "npm run " + name
+		if name == "test" {
+			cmd = "npm test"
+		}
+		cmds = append(cmds, Command{name, cmd, "package.json"})
+	}
+	return cmds
+}
+
+var makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9_.-]+)\s*:([^=]|$)`)
+
+func makeTargets(path string) []Command {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	targets := make(map[string]bool)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if m := makeTargetPattern.FindStringSubmatch(sc.Text()); m != nil {
+			targets[m[1]] = true
+		}
+	}
+	var cmds []Command
+	for _, name := range commonScripts {
+		if targets[name] {
+			cmds = append(cmds, Command{name, "make " + name, "Makefile"})
+		}
+	}
+	return cmds
+}
+
+func firstLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[:n], "\n") + "\n..."
+}
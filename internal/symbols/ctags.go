@@ -0,0 +1,46 @@
+package symbols
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/yourorg/agent/internal/ctags"
+)
+
+// importCtags reads a tags file, as universal-ctags writes it when run at
+// the root of the project. A tags file has no imports or calls, only
+// symbols, and their lines are found when it is read, so they are only
+// as current as the tags file.
+func importCtags(data []byte, root string) ([]*File, error) {
+	tags, err := ctags.Read(bytes.NewReader(data), root)
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]*File)
+	var files []*File
+	for _, t := range tags {
+		f := byPath[t.File]
+		if f == nil {
+			f = &File{Path: t.File, Language: strings.ToLower(t.Language)}
+			if f.Language == "" {
+				f.Language = strings.TrimPrefix(path.Ext(t.File), ".")
+			}
+			byPath[t.File] = f
+			files = append(files, f)
+		}
+		f.Symbols = append(f.Symbols, Symbol{
+			Name:      t.Name,
+			Kind:      t.Kind,
+			File:      t.File,
+			Line:      t.Line,
+			Signature: truncate(t.Signature, maxSignature),
+			Exported:  t.Exported,
+		})
+	}
+	for _, f := range files {
+		sort.SliceStable(f.Symbols, func(i, j int) bool { return f.Symbols[i].Line < f.Symbols[j].Line })
+	}
+	return files, nil
+}
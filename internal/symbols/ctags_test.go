@@ -0,0 +1,70 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestImportCtags(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"app/store.py": "class Store:\n    def get(self, key):\n        return key\n",
+		"src/main.c":   "static int helper(void) { return 0; }\n\nint main(void)\n{\n\treturn helper();\n}\n",
+		"web/app.ts":   "export function render() {}\n",
+		"tags": "!_TAG_FILE_FORMAT\t2\t/extended format/\n" +
+			"Store\tapp/store.py\t/^class Store:$/;\"\tc\tlanguage:Python\n" +
+			"get\tapp/store.py\t/^    def get(self, key):$/;\"\tm\tlanguage:Python\tclass:Store\n" +
+			"helper\tsrc/main.c\t/^static int helper(void) { return 0; }$/;\"\tf\tlanguage:C\tfile:\n" +
+			"main\tsrc/main.c\t/^int main(void)$/;\"\tf\tlanguage:C\n" +
+			"render\tweb/app.ts\t/^export function render() {}$/;\"\tf\tlanguage:TypeScript\n",
+	})
+	x := NewIndexer()
+	for _, p := range Parsers() {
+		x.RegisterParser(p)
+	}
+	if err := x.Import(filepath.Join(dir, "tags"), FormatCtags); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := x.IndexProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := idx.Languages(); !reflect.DeepEqual(got, []string{"c", "python", "typescript"}) {
+		t.Errorf("Languages() = %v, want [c python typescript]", got)
+	}
+	want := []Symbol{
+		{Name: "Store.get", Kind: "method", File: "app/store.py", Line: 2, Signature: "def get(self, key):", Exported: true},
+	}
+	if got := idx.Lookup("Store.get"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lookup(Store.get) = %+v, want %+v", got, want)
+	}
+	if got := idx.Lookup("main"); len(got) != 1 || got[0].Line != 3 || !got[0].Exported {
+		t.Errorf("Lookup(main) = %+v, want an exported symbol at line 3", got)
+	}
+	if got := idx.Lookup("helper"); len(got) != 1 || got[0].Exported {
+		t.Errorf("Lookup(helper) = %+v, want a file scoped symbol", got)
+	}
+	// app.ts is parsed, so its tag is left out.
+	if got := idx.Lookup("render"); len(got) != 1 || got[0].Kind != "function" || got[0].Signature != "export function render() {}" {
+		t.Errorf("Lookup(render) = %+v", got)
+	}
+
+	// A later run keeps the tags until the file is removed.
+	if idx, err = Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Lookup("Store.get"); !reflect.DeepEqual(got, want) {
+		t.Errorf("after reload, Lookup(Store.get) = %+v, want %+v", got, want)
+	}
+	if err := os.Remove(filepath.Join(dir, "tags")); err != nil {
+		t.Fatal(err)
+	}
+	if idx, err = Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Languages(); !reflect.DeepEqual(got, []string{"typescript"}) {
+		t.Errorf("after the tags file was removed, Languages() = %v, want [typescript]", got)
+	}
+}
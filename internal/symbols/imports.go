@@ -0,0 +1,129 @@
+package symbols
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Formats of the index files Import reads.
+const (
+	FormatSCIP  = "scip"
+	FormatCtags = "ctags"
+)
+
+// importers read the files of an index file of the project at root, by
+// its format.
+var importers = map[string]func(data []byte, root string) ([]*File, error){
+	FormatSCIP:  importSCIP,
+	FormatCtags: importCtags,
+}
+
+// importSource is an index file to merge in.
+type importSource struct {
+	path   string // absolute
+	format string
+}
+
+// Import has IndexProject merge in the files of the index file at path,
+// which another tool wrote in format, for languages no parser reads.
+// Files a parser reads take precedence over imported files at the same
+// path. With the cache enabled, imported files are saved with the rest,
+// and later runs keep them without Import until the index file changes,
+// when it is read again, or is removed.
+func (x *Indexer) Import(path, format string) error {
+	if importers[format] == nil {
+		return fmt.Errorf("unknown index format %q", format)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("resolve index file: %w", err)
+	}
+	x.imports = append(x.imports, importSource{path: abs, format: format})
+	return nil
+}
+
+// importAll returns the files of the index files of the project at root
+// imported now or, as before lists by index file, by an earlier run,
+// leaving out those at paths in parsed. changed reports whether any
+// differ from before.
+func (x *Indexer) importAll(root string, before map[string][]*entry, parsed map[string]bool) (imported []indexed, changed bool, err error) {
+	formats := make(map[string]string)
+	for source, entries := range before {
+		formats[source] = entries[0].Format
+	}
+	requested := make(map[string]bool)
+	for _, imp := range x.imports {
+		formats[imp.path] = imp.format
+		requested[imp.path] = true
+	}
+	sources := make([]string, 0, len(formats))
+	for source := range formats {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		format, old := formats[source], before[source]
+		info, err := os.Stat(source)
+		if err != nil {
+			if requested[source] {
+				return nil, false, fmt.Errorf("import %s: %w", source, err)
+			}
+			changed = true // removed since
+			continue
+		}
+		if len(old) > 0 && old[0].Format == format && old[0].Size == info.Size() && old[0].ModTime.Equal(info.ModTime()) {
+			for _, e := range old {
+				imported = append(imported, indexed{entry: e})
+			}
+			continue
+		}
+		changed = true
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, false, fmt.Errorf("import %s: %w", source, err)
+		}
+		sum := hash(data)
+		if len(old) > 0 && old[0].Format == format && old[0].Hash == sum {
+			for _, e := range old {
+				touched := *e
+				touched.Size, touched.ModTime = info.Size(), info.ModTime()
+				imported = append(imported, indexed{entry: &touched})
+			}
+			continue
+		}
+		files, err := importers[format](data, root)
+		if err != nil {
+			return nil, false, fmt.Errorf("import %s: %w", source, err)
+		}
+		for _, f := range files {
+			e, d := split(f)
+			e.Source, e.Format = source, format
+			e.Hash, e.Size, e.ModTime = sum, info.Size(), info.ModTime()
+			i := indexed{entry: e, file: f}
+			if x.cache {
+				i.file = nil
+				if i.blob, err = encodeDetails(d); err != nil {
+					return nil, false, err
+				}
+			}
+			imported = append(imported, i)
+		}
+	}
+
+	// Parsed files win over imported ones, and the first import of a path
+	// over later ones.
+	kept := imported[:0]
+	seen := make(map[string]bool)
+	for _, i := range imported {
+		if parsed[i.entry.Path] || seen[i.entry.Path] {
+			changed = true
+			continue
+		}
+		seen[i.entry.Path] = true
+		kept = append(kept, i)
+	}
+	return kept, changed, nil
+}
@@ -0,0 +1,127 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yourorg/agent/internal/scip"
+)
+
+// writeSCIP exports defs of the project at dir as a SCIP index to path.
+func writeSCIP(t *testing.T, dir, path string, defs []scip.Definition) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := scip.Export(f, dir, defs, scip.Options{Package: "store"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportSCIP(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"store/store.go": "package store\n\ntype Store struct{}\n\nfunc (s *Store) Get(key string) string {\n\treturn lookup(key)\n}\n\nfunc lookup(key string) string { return key }\n",
+		"web/app.ts":     "export function render() {}\n",
+	})
+	defs := []scip.Definition{
+		{Name: "Store", Kind: "type", File: "store/store.go", Line: 3},
+		{Name: "Store.Get", Kind: "method", File: "store/store.go", Line: 5, Signature: "func (s *Store) Get(key string) string"},
+		{Name: "lookup", Kind: "function", File: "store/store.go", Line: 9},
+	}
+	indexFile := filepath.Join(t.TempDir(), "index.scip")
+	writeSCIP(t, dir, indexFile, defs)
+
+	x := NewIndexer()
+	for _, p := range Parsers() {
+		x.RegisterParser(p)
+	}
+	if err := x.Import(indexFile, FormatSCIP); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := x.IndexProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Languages(); !reflect.DeepEqual(got, []string{"go", "typescript"}) {
+		t.Errorf("Languages() = %v, want [go typescript]", got)
+	}
+	if got := idx.Lookup("Store.Get"); len(got) != 1 || got[0].Signature != defs[1].Signature || got[0].Kind != "method" {
+		t.Errorf("Lookup(Store.Get) = %+v", got)
+	}
+	if got := idx.Neighbors("lookup", Callers); !reflect.DeepEqual(got, []string{"Store.Get"}) {
+		t.Errorf("callers of lookup = %v, want [Store.Get]", got)
+	}
+
+	// A later run keeps the imported files without Import.
+	idx, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Lookup("Store.Get"); len(got) != 1 || got[0].Signature != defs[1].Signature {
+		t.Errorf("after reload, Lookup(Store.Get) = %+v", got)
+	}
+
+	// A changed index file is read again.
+	writeSCIP(t, dir, indexFile, defs[:1])
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(indexFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if idx, err = Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Lookup("Store")) != 1 || len(idx.Lookup("Store.Get")) != 0 {
+		t.Errorf("after the index file changed, got %v", idx.Names())
+	}
+
+	// A removed one is dropped.
+	if err := os.Remove(indexFile); err != nil {
+		t.Fatal(err)
+	}
+	if idx, err = Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Names(); !reflect.DeepEqual(got, []string{"render"}) {
+		t.Errorf("after the index file was removed, Names() = %v, want [render]", got)
+	}
+}
+
+func TestImportParsedFileWins(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"web/app.ts": "export function render() {}\n",
+	})
+	indexFile := filepath.Join(t.TempDir(), "index.scip")
+	writeSCIP(t, dir, indexFile, []scip.Definition{{Name: "imported", Kind: "function", File: "web/app.ts", Line: 1}})
+
+	x := NewIndexer()
+	x.RegisterParser(NewTypeScriptParser())
+	x.SetCacheEnabled(false)
+	if err := x.Import(indexFile, FormatSCIP); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := x.IndexProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Names(); !reflect.DeepEqual(got, []string{"render"}) {
+		t.Errorf("Names() = %v, want [render]", got)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	x := NewIndexer()
+	if err := x.Import("index.bin", "unknown"); err == nil {
+		t.Error("Import with an unknown format succeeded")
+	}
+	if err := x.Import(filepath.Join(t.TempDir(), "missing.scip"), FormatSCIP); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.IndexProject(t.TempDir()); err == nil {
+		t.Error("IndexProject with a missing index file succeeded")
+	}
+}
@@ -0,0 +1,73 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NewJavaParser returns a parser for Java: classes, interfaces, enums,
+// records and annotation types, their constructors and methods with the
+// annotations on them, and imports.
+func NewJavaParser() Parser {
+	return javaParser{}
+}
+
+type javaParser struct{}
+
+func (javaParser) Extensions() []string {
+	return []string{".java"}
+}
+
+func (javaParser) Parse(file string, src []byte) *File {
+	return java.parse(file, src)
+}
+
+const javaModifiers = `(?:(?:public|protected|private|abstract|static|final|sealed|non-sealed|strictfp)\s+)*`
+
+// javaType declares a class-like type with the given keyword.
+func javaType(keyword, kind string) declaration {
+	return declaration{
+		pattern:   regexp.MustCompile(`^` + javaModifiers + keyword + `\s+(?P<name>[A-Za-z_$][\w$]*)`),
+		kind:      kind,
+		container: true,
+	}
+}
+
+var java = language{
+	name: "java",
+	style: style{
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+		tripleQuotes: true,
+	},
+	decls: []declaration{
+		javaType("class", "class"),
+		javaType("interface", "interface"),
+		javaType("enum", "enum"),
+		javaType("record", "record"),
+		javaType("@interface", "annotation"),
+		{
+			pattern:  regexp.MustCompile(`^(?:(?:public|protected|private)\s+)?(?:<[^>]*>\s*)?(?P<name>[A-Za-z_$][\w$]*)\s*\(`),
+			kind:     "constructor",
+			function: true,
+			member:   true,
+			sameName: true,
+		},
+		{
+			pattern:  regexp.MustCompile(`^(?:(?:public|protected|private|abstract|static|final|synchronized|native|default|strictfp)\s+)*(?:<[^>]*>\s+)?[\w$.]+(?:<[^()]*>)?(?:\[\])*\s+(?P<name>[A-Za-z_$][\w$]*)\s*\(`),
+			kind:     "function",
+			function: true,
+			member:   true,
+		},
+	},
+	imports: []*regexp.Regexp{
+		regexp.MustCompile(`^\s*import\s+(?:static\s+)?([\w.]+(?:\.\*)?)\s*;`),
+	},
+	annotation: regexp.MustCompile(`^@[A-Za-z_][\w.]*(?:\s*\([^)]*\))?`),
+	call:       defaultCall,
+	keywords:   words("if for while switch catch return new throw throws synchronized super this try else case assert instanceof do"),
+	exported: func(sig string) bool {
+		return strings.HasPrefix(sig, "public ") || strings.Contains(sig, " public ")
+	},
+}
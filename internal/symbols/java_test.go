@@ -0,0 +1,128 @@
+package symbols
+
+import (
+	"slices"
+	"testing"
+)
+
+const javaSource = `package com.acme.users;
+
+import java.util.List;
+import static org.junit.Assert.*;
+import com.acme.users.model.User;
+
+/**
+ * Serves the users API.
+ */
+@RestController
+@RequestMapping("/users")
+public class UserController {
+    private final UserService service;
+
+    public UserController(UserService service) {
+        this.service = service;
+    }
+
+    /** Returns one user. */
+    @GetMapping("/{id}")
+    public ResponseEntity<User> getUser(@PathVariable String id) {
+        String s = "call(me)";
+        return ResponseEntity.ok(service.find(id));
+    }
+
+    @Override
+    public String toString() { return describe(); }
+
+    private static Map<String, List<User>> group(List<User> users) {
+        return users.stream().collect(Collectors.groupingBy(User::getName));
+    }
+
+    private String describe() {
+        Runnable r = new Runnable() {
+            public void run() { helper(); }
+        };
+        return "controller";
+    }
+
+    enum Status { ACTIVE, DISABLED }
+}
+
+interface UserService {
+    User find(String id);
+}
+
+@interface Audited {
+    String value() default "";
+}
+
+record Point(int x, int y) {}
+`
+
+func TestJavaParser(t *testing.T) {
+	f := NewJavaParser().Parse("src/main/java/com/acme/users/UserController.java", []byte(javaSource))
+
+	want := []struct {
+		name, kind string
+		line       int
+		exported   bool
+	}{
+		{"UserController", "class", 12, true},
+		{"UserController.UserController", "constructor", 15, true},
+		{"UserController.getUser", "method", 21, true},
+		{"UserController.toString", "method", 27, true},
+		{"UserController.group", "method", 29, false},
+		{"UserController.describe", "method", 33, false},
+		{"UserController.Status", "enum", 40, false},
+		{"UserService", "interface", 43, false},
+		{"UserService.find", "method", 44, false},
+		{"Audited", "annotation", 47, false},
+		{"Audited.value", "method", 48, false},
+		{"Point", "record", 51, false},
+	}
+	if len(f.Symbols) != len(want) {
+		t.Errorf("got %d symbols, want %d: %+v", len(f.Symbols), len(want), f.Symbols)
+	}
+	for i, w := range want {
+		if i >= len(f.Symbols) {
+			break
+		}
+		s := f.Symbols[i]
+		if s.Name != w.name || s.Kind != w.kind || s.Line != w.line || s.Exported != w.exported {
+			t.Errorf("symbol %d = %s %s line %d exported %v, want %s %s line %d exported %v",
+				i, s.Kind, s.Name, s.Line, s.Exported, w.kind, w.name, w.line, w.exported)
+		}
+	}
+
+	class := f.Symbols[0]
+	if class.Doc != "Serves the users API." || !slices.Equal(class.Annotations, []string{"@RestController", `@RequestMapping("/users")`}) {
+		t.Errorf("class doc %q, annotations %q", class.Doc, class.Annotations)
+	}
+	get := f.Symbols[2]
+	if get.Doc != "Returns one user." || !slices.Equal(get.Annotations, []string{`@GetMapping("/{id}")`}) {
+		t.Errorf("getUser doc %q, annotations %q", get.Doc, get.Annotations)
+	}
+	if !slices.Equal(f.Symbols[3].Annotations, []string{"@Override"}) {
+		t.Errorf("toString annotations = %q", f.Symbols[3].Annotations)
+	}
+
+	wantImports := []string{"java.util.List", "org.junit.Assert.*", "com.acme.users.model.User"}
+	if !slices.Equal(f.Imports, wantImports) {
+		t.Errorf("imports = %q, want %q", f.Imports, wantImports)
+	}
+
+	calls := make(map[string][]string)
+	for _, c := range f.Calls {
+		calls[c.Caller] = append(calls[c.Caller], c.Callee)
+	}
+	for caller, want := range map[string][]string{
+		"UserController.getUser":  {"ok", "find"},
+		"UserController.toString": {"describe"},
+		"UserController.group":    {"stream", "collect", "groupingBy"},
+		// Calls in anonymous classes belong to the enclosing method.
+		"UserController.describe": {"Runnable", "run", "helper"},
+	} {
+		if !slices.Equal(calls[caller], want) {
+			t.Errorf("calls of %s = %q, want %q", caller, calls[caller], want)
+		}
+	}
+}
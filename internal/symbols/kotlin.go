@@ -0,0 +1,76 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NewKotlinParser returns a parser for Kotlin: classes, interfaces,
+// objects and companion objects, functions and extension functions, type
+// aliases, and imports.
+func NewKotlinParser() Parser {
+	return kotlinParser{}
+}
+
+type kotlinParser struct{}
+
+func (kotlinParser) Extensions() []string {
+	return []string{".kt", ".kts"}
+}
+
+func (kotlinParser) Parse(file string, src []byte) *File {
+	f := kotlin.parse(file, src)
+	// Extension functions are named after their receiver without its type
+	// arguments: "fun <T> List<T>?.second()" is List.second.
+	for i := range f.Symbols {
+		f.Symbols[i].Name = receiverName(f.Symbols[i].Name)
+	}
+	for i := range f.Calls {
+		f.Calls[i].Caller = receiverName(f.Calls[i].Caller)
+	}
+	return f
+}
+
+var kotlinTypeArgs = regexp.MustCompile(`<[^()]*?>|\?`)
+
+func receiverName(name string) string {
+	if !strings.ContainsAny(name, "<?") {
+		return name
+	}
+	return kotlinTypeArgs.ReplaceAllString(name, "")
+}
+
+const kotlinModifiers = `(?:(?:public|private|protected|internal|open|abstract|final|override|sealed|data|inner|inline|value|annotation|suspend|operator|infix|tailrec|external|expect|actual|const|lateinit)\s+)*`
+
+var kotlin = language{
+	name: "kotlin",
+	style: style{
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+		tripleQuotes: true,
+	},
+	decls: []declaration{
+		{pattern: regexp.MustCompile(`^` + kotlinModifiers + `enum\s+class\s+(?P<name>\w+)`), kind: "enum", container: true},
+		{pattern: regexp.MustCompile(`^` + kotlinModifiers + `class\s+(?P<name>\w+)`), kind: "class", container: true},
+		{pattern: regexp.MustCompile(`^` + kotlinModifiers + `(?:fun\s+)?interface\s+(?P<name>\w+)`), kind: "interface", container: true},
+		{pattern: regexp.MustCompile(`^` + kotlinModifiers + `companion\s+object\b(?:\s+(?P<name>\w+))?`), kind: "object", container: true, anonymous: "Companion"},
+		{pattern: regexp.MustCompile(`^` + kotlinModifiers + `object\s+(?P<name>\w+)`), kind: "object", container: true},
+		{pattern: regexp.MustCompile(`^` + kotlinModifiers + `fun\s+(?:<[^>]*>\s*)?(?P<name>[\w.]+(?:<[^()]*?>)?\??\.\w+)\s*\(`), kind: "extension", function: true},
+		{pattern: regexp.MustCompile(`^` + kotlinModifiers + `fun\s+(?:<[^>]*>\s*)?(?P<name>\w+)\s*\(`), kind: "function", function: true},
+		{pattern: regexp.MustCompile(`^` + kotlinModifiers + `typealias\s+(?P<name>\w+)`), kind: "type"},
+	},
+	imports: []*regexp.Regexp{
+		regexp.MustCompile(`^\s*import\s+(\w+(?:\.\w+)*(?:\.\*)?)`),
+	},
+	annotation: regexp.MustCompile(`^@[A-Za-z_][\w.:]*(?:\s*\([^)]*\))?`),
+	// A call may pass only a trailing lambda, "items.forEach { }", told
+	// apart from a return type before a body by its lowercase name.
+	call:        regexp.MustCompile(`(?:^|[^\w$])(?:([A-Za-z_]\w*)\s*(?:<[\w.,\s?*]*>)?\s*\(|([a-z_]\w*)\s*\{)`),
+	keywords:    words("if when for while catch return throw try else is in as fun object super this constructor init finally do get set"),
+	newlineEnds: true,
+	exported: func(sig string) bool {
+		return !strings.HasPrefix(sig, "private ") && !strings.HasPrefix(sig, "internal ") &&
+			!strings.Contains(sig, " private ") && !strings.Contains(sig, " internal ")
+	},
+}
@@ -0,0 +1,119 @@
+package symbols
+
+import (
+	"slices"
+	"testing"
+)
+
+const kotlinSource = `package com.example.shop
+
+import com.example.db.Repository
+import kotlinx.coroutines.*
+import com.example.util.Slugger as Slug
+
+/** A product in the catalogue. */
+@Entity
+data class Product(
+    val id: Long,
+    val name: String,
+) {
+    fun label(): String = Slug.slug(name)
+
+    companion object {
+        fun empty() = Product(0, "")
+    }
+}
+
+sealed interface Event
+
+enum class Status { ACTIVE, RETIRED }
+
+object Catalogue : Repository<Product>() {
+    private val items = mutableListOf<Product>()
+
+    suspend fun load(ids: List<Long>) {
+        ids.forEach { id ->
+            items.add(fetch(id))
+        }
+    }
+
+    internal fun fetch(id: Long): Product {
+        val s = "fun fake() {"
+        return Product.empty()
+    }
+}
+
+fun String.slugify(): String = lowercase().replace(' ', '-')
+
+fun <T> List<T>?.second(): T? = this?.getOrNull(1)
+
+private fun main() {
+    println(Catalogue.fetch(1).label())
+}
+
+typealias Products = List<Product>
+`
+
+func TestKotlinParser(t *testing.T) {
+	f := NewKotlinParser().Parse("src/shop/Catalogue.kt", []byte(kotlinSource))
+
+	want := []struct {
+		name, kind string
+		line       int
+		exported   bool
+	}{
+		{"Product", "class", 9, true},
+		{"Product.label", "method", 13, true},
+		{"Product.Companion", "object", 15, true},
+		{"Product.Companion.empty", "method", 16, true},
+		{"Event", "interface", 20, true},
+		{"Status", "enum", 22, true},
+		{"Catalogue", "object", 24, true},
+		{"Catalogue.load", "method", 27, true},
+		{"Catalogue.fetch", "method", 33, false},
+		{"String.slugify", "extension", 39, true},
+		{"List.second", "extension", 41, true},
+		{"main", "function", 43, false},
+		{"Products", "type", 47, true},
+	}
+	if len(f.Symbols) != len(want) {
+		t.Errorf("got %d symbols, want %d: %+v", len(f.Symbols), len(want), f.Symbols)
+	}
+	for i, w := range want {
+		if i >= len(f.Symbols) {
+			break
+		}
+		s := f.Symbols[i]
+		if s.Name != w.name || s.Kind != w.kind || s.Line != w.line || s.Exported != w.exported {
+			t.Errorf("symbol %d = %s %s line %d exported %v, want %s %s line %d exported %v",
+				i, s.Kind, s.Name, s.Line, s.Exported, w.kind, w.name, w.line, w.exported)
+		}
+	}
+	product := f.Symbols[0]
+	if product.Doc != "A product in the catalogue." || !slices.Equal(product.Annotations, []string{"@Entity"}) {
+		t.Errorf("Product doc %q, annotations %q", product.Doc, product.Annotations)
+	}
+
+	wantImports := []string{"com.example.db.Repository", "kotlinx.coroutines.*", "com.example.util.Slugger"}
+	if !slices.Equal(f.Imports, wantImports) {
+		t.Errorf("imports = %q, want %q", f.Imports, wantImports)
+	}
+
+	calls := make(map[string][]string)
+	for _, c := range f.Calls {
+		calls[c.Caller] = append(calls[c.Caller], c.Callee)
+	}
+	for caller, want := range map[string][]string{
+		"Product.label":           {"slug"},
+		"Product.Companion.empty": {"Product"},
+		"Catalogue.load":          {"forEach", "add", "fetch"},
+		"Catalogue.fetch":         {"empty"},
+		"String.slugify":          {"lowercase", "replace"},
+		"List.second":             {"getOrNull"},
+		"main":                    {"println", "fetch", "label"},
+	} {
+		if !slices.Equal(calls[caller], want) {
+			t.Errorf("calls of %s = %q, want %q", caller, calls[caller], want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package symbols
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// NewRubyParser returns a parser for Ruby: classes, modules and methods,
+// singleton methods included, and require and require_relative. Bodies
+// are followed by matching each end to the keyword that opened it.
+func NewRubyParser() Parser {
+	return rubyParser{}
+}
+
+type rubyParser struct{}
+
+func (rubyParser) Extensions() []string {
+	return []string{".rb", ".rake"}
+}
+
+var ruby = language{
+	name: "ruby",
+	style: style{
+		lineComment:  "#",
+		blockComment: [2]string{"=begin", "=end"},
+		quotes:       `"'`,
+	},
+}
+
+var (
+	rubyClass   = regexp.MustCompile(`^(class|module)\s+(<<\s*self\b|[A-Z]\w*(?:::[A-Z]\w*)*)`)
+	rubyDef     = regexp.MustCompile(`^(?:(private|protected|public)\s+)?def\s+(self\.)?([A-Za-z_]\w*[?!=]?)`)
+	rubyBlock   = regexp.MustCompile(`^(if|unless|while|until|case|begin|for)\b`)
+	rubyAssign  = regexp.MustCompile(`=\s*(if|unless|case|begin|while|until)\b`)
+	rubyDo      = regexp.MustCompile(`(?:^|[^.\w])(do)\b`)
+	rubyEnd     = regexp.MustCompile(`(?:^|[^.\w])(end)\b`)
+	rubyCall    = regexp.MustCompile(`(?:^|[^\w@$:])([a-z_]\w*[?!]?)`)
+	rubyRequire = regexp.MustCompile(`^\s*(?:require|require_relative|load)\s*\(?\s*['"]([^'"]+)['"]`)
+	rubyHeredoc = regexp.MustCompile(`<<[~-]?(?:['"]([A-Za-z_]\w*)['"]|([A-Z_][A-Z0-9_]*))`)
+)
+
+var rubyKeywords = words("def class module if unless while until case when then else elsif end do begin rescue ensure return yield self nil true false and or not in super alias undef defined? next break redo retry lambda proc")
+
+// rubyFrame is a body opened by a keyword and closed by end.
+type rubyFrame struct {
+	name      string
+	container bool
+	function  bool
+	private   bool // later methods of a container are private
+}
+
+// rubyEvent opens or closes a frame at a position on a line.
+type rubyEvent struct {
+	pos   int
+	open  *rubyFrame
+	close bool
+}
+
+func (rubyParser) Parse(file string, src []byte) *File {
+	f := &File{Path: file, Language: ruby.name}
+	orig := strings.Split(string(src), "\n")
+	code := strings.Split(ruby.style.blank(string(src)), "\n")
+
+	var (
+		stack   []rubyFrame
+		heredoc string
+	)
+	container := func() *rubyFrame {
+		for j := len(stack) - 1; j >= 0; j-- {
+			if stack[j].container {
+				return &stack[j]
+			}
+		}
+		return nil
+	}
+	qualify := func(name string) string {
+		if c := container(); c != nil {
+			return c.name + "." + name
+		}
+		return name
+	}
+
+	for i, line := range code {
+		if heredoc != "" {
+			if strings.TrimSpace(orig[i]) == heredoc {
+				heredoc = ""
+			}
+			continue
+		}
+		for _, m := range rubyRequire.FindAllStringSubmatchIndex(orig[i], -1) {
+			if inCode(line, orig[i], m[0], m[1]) {
+				f.Imports = append(f.Imports, orig[i][m[2]:m[3]])
+			}
+		}
+		if m := rubyHeredoc.FindStringSubmatchIndex(orig[i]); m != nil && inCode(line, orig[i], m[0], m[1]) {
+			if m[2] >= 0 {
+				heredoc = orig[i][m[2]:m[3]]
+			} else {
+				heredoc = orig[i][m[4]:m[5]]
+			}
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		text := strings.TrimSpace(line)
+		var events []rubyEvent
+		// Calls on a declaration's line are its parameters or superclass,
+		// except in the expression of an endless method.
+		callsFrom, endless := 0, ""
+
+		switch m := rubyClass.FindStringSubmatch(text); {
+		case m != nil && strings.HasPrefix(m[2], "<<"):
+			// class << self: its methods are the enclosing class's.
+			name := ""
+			if c := container(); c != nil {
+				name = c.name
+			}
+			events = append(events, rubyEvent{pos: indent, open: &rubyFrame{name: name, container: name != ""}})
+			callsFrom = len(line)
+		case m != nil:
+			name := qualify(strings.ReplaceAll(m[2], "::", "."))
+			f.Symbols = append(f.Symbols, Symbol{
+				Name:      name,
+				Kind:      m[1],
+				File:      file,
+				Line:      i + 1,
+				Signature: truncate(strings.TrimSpace(orig[i]), maxSignature),
+				Doc:       ruby.docAbove(orig, i),
+				Exported:  true,
+			})
+			events = append(events, rubyEvent{pos: indent, open: &rubyFrame{name: name, container: true}})
+			callsFrom = len(line)
+		default:
+			dm := rubyDef.FindStringSubmatchIndex(text)
+			if dm == nil {
+				break
+			}
+			name, kind := text[dm[6]:dm[7]], "function"
+			if c := container(); c != nil {
+				name, kind = c.name+"."+name, "method"
+			}
+			private := dm[2] >= 0 && text[dm[2]:dm[3]] != "public"
+			if c := container(); c != nil && dm[2] < 0 {
+				private = c.private
+			}
+			f.Symbols = append(f.Symbols, Symbol{
+				Name:      name,
+				Kind:      kind,
+				File:      file,
+				Line:      i + 1,
+				Signature: truncate(strings.TrimSpace(orig[i]), maxSignature),
+				Doc:       ruby.docAbove(orig, i),
+				Exported:  kind == "method" && !private,
+			})
+			// def name(args) = expression has no body and no end.
+			if body, ok := endlessBody(text[dm[1]:]); ok {
+				endless = name
+				callsFrom = indent + len(text) - len(body)
+			} else {
+				events = append(events, rubyEvent{pos: indent, open: &rubyFrame{name: name, function: true}})
+				callsFrom = len(line)
+			}
+		}
+		if len(events) == 0 && rubyBlock.MatchString(text) {
+			events = append(events, rubyEvent{pos: indent, open: &rubyFrame{}})
+		}
+
+		for _, m := range rubyAssign.FindAllStringIndex(line, -1) {
+			events = append(events, rubyEvent{pos: m[0], open: &rubyFrame{}})
+		}
+		// while, until and for take an optional do on the same line.
+		if first, _, _ := strings.Cut(text, " "); first != "while" && first != "until" && first != "for" {
+			for _, m := range rubyDo.FindAllStringSubmatchIndex(line, -1) {
+				events = append(events, rubyEvent{pos: m[2], open: &rubyFrame{}})
+			}
+		}
+		for _, m := range rubyEnd.FindAllStringSubmatchIndex(line, -1) {
+			events = append(events, rubyEvent{pos: m[2], close: true})
+		}
+		sort.SliceStable(events, func(a, b int) bool { return events[a].pos < events[b].pos })
+
+		// Calls belong to the innermost method open where they appear.
+		caller := func() string {
+			if endless != "" {
+				return endless
+			}
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].function {
+					return stack[j].name
+				}
+			}
+			return ""
+		}
+		next := 0
+		for _, m := range rubyCall.FindAllStringSubmatchIndex(line, -1) {
+			for next < len(events) && events[next].pos <= m[2] {
+				stack = applyRubyEvent(stack, events[next])
+				next++
+			}
+			name := line[m[2]:m[3]]
+			if m[2] < callsFrom || rubyKeywords[name] || assigned(line[m[3]:]) {
+				continue
+			}
+			if from := caller(); from != "" {
+				f.Calls = append(f.Calls, Call{Caller: from, Callee: name, Line: i + 1})
+			}
+		}
+		for ; next < len(events); next++ {
+			stack = applyRubyEvent(stack, events[next])
+		}
+
+		// A bare private or protected makes the methods after it private.
+		if n := len(stack); n > 0 && stack[n-1].container {
+			switch text {
+			case "private", "protected":
+				stack[n-1].private = true
+			case "public":
+				stack[n-1].private = false
+			}
+		}
+	}
+	return f
+}
+
+func applyRubyEvent(stack []rubyFrame, e rubyEvent) []rubyFrame {
+	if e.open != nil {
+		return append(stack, *e.open)
+	}
+	if len(stack) > 0 {
+		stack = stack[:len(stack)-1]
+	}
+	return stack
+}
+
+// endlessBody returns the expression of an endless method, given the
+// text after its name: "(x) = x * 2" gives "x * 2".
+func endlessBody(rest string) (string, bool) {
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "(") {
+		depth := 0
+		for i, c := range rest {
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+				if depth == 0 {
+					rest = strings.TrimSpace(rest[i+1:])
+					break
+				}
+			}
+		}
+	}
+	if strings.HasPrefix(rest, "=") && !strings.HasPrefix(rest, "==") {
+		return strings.TrimSpace(rest[1:]), true
+	}
+	return "", false
+}
+
+// assigned reports whether an identifier followed by rest is assigned to
+// or is a hash key rather than called.
+func assigned(rest string) bool {
+	rest = strings.TrimLeft(rest, " \t")
+	switch {
+	case strings.HasPrefix(rest, "=="), strings.HasPrefix(rest, "=~"), strings.HasPrefix(rest, "=>"):
+		return false
+	case strings.HasPrefix(rest, "="), strings.HasPrefix(rest, "+="), strings.HasPrefix(rest, "-="),
+		strings.HasPrefix(rest, "||="), strings.HasPrefix(rest, "&&="):
+		return true
+	case strings.HasPrefix(rest, ":") && !strings.HasPrefix(rest, "::"):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package symbols
+
+import (
+	"slices"
+	"testing"
+)
+
+const rubySource = `require "json"
+require_relative "../lib/store"
+
+=begin
+Accounts and their billing.
+end
+=end
+
+module Billing
+  # An account that can be charged.
+  class Account < Base
+    attr_reader :balance
+
+    def initialize(balance)
+      @balance = balance
+    end
+
+    # Charges the account.
+    def charge!(amount)
+      if amount > balance
+        raise InsufficientFunds
+      end
+      items.each do |item|
+        log(item) # end
+      end
+      total = amount + fee(amount)
+      record(total: total)
+    end
+
+    def self.open(name) = new(lookup(name))
+
+    class << self
+      def find(id)
+        Store.read(id)
+      end
+    end
+
+    private
+
+    def fee(amount)
+      sql = <<~SQL
+        select fee from plans where amount > ? end
+      SQL
+      query(sql, amount)
+    end
+  end
+
+  class Admin::Account < Account
+  end
+end
+
+def main
+  Billing::Account.open("x").charge!(1)
+end
+`
+
+func TestRubyParser(t *testing.T) {
+	f := NewRubyParser().Parse("lib/billing.rb", []byte(rubySource))
+
+	want := []struct {
+		name, kind string
+		line       int
+		exported   bool
+	}{
+		{"Billing", "module", 9, true},
+		{"Billing.Account", "class", 11, true},
+		{"Billing.Account.initialize", "method", 14, true},
+		{"Billing.Account.charge!", "method", 19, true},
+		{"Billing.Account.open", "method", 30, true},
+		{"Billing.Account.find", "method", 33, true},
+		{"Billing.Account.fee", "method", 40, false},
+		{"Billing.Admin.Account", "class", 48, true},
+		{"main", "function", 52, false},
+	}
+	if len(f.Symbols) != len(want) {
+		t.Errorf("got %d symbols, want %d: %+v", len(f.Symbols), len(want), f.Symbols)
+	}
+	for i, w := range want {
+		if i >= len(f.Symbols) {
+			break
+		}
+		s := f.Symbols[i]
+		if s.Name != w.name || s.Kind != w.kind || s.Line != w.line || s.Exported != w.exported {
+			t.Errorf("symbol %d = %s %s line %d exported %v, want %s %s line %d exported %v",
+				i, s.Kind, s.Name, s.Line, s.Exported, w.kind, w.name, w.line, w.exported)
+		}
+	}
+	if doc := f.Symbols[1].Doc; doc != "An account that can be charged." {
+		t.Errorf("Account doc = %q", doc)
+	}
+	if sig := f.Symbols[4].Signature; sig != "def self.open(name) = new(lookup(name))" {
+		t.Errorf("open signature = %q", sig)
+	}
+
+	if wantImports := []string{"json", "../lib/store"}; !slices.Equal(f.Imports, wantImports) {
+		t.Errorf("imports = %q, want %q", f.Imports, wantImports)
+	}
+
+	calls := make(map[string][]string)
+	for _, c := range f.Calls {
+		calls[c.Caller] = append(calls[c.Caller], c.Callee)
+	}
+	for caller, want := range map[string][]string{
+		"Billing.Account.initialize": {"balance"},
+		"Billing.Account.charge!":    {"amount", "balance", "raise", "items", "each", "item", "log", "item", "amount", "fee", "amount", "record", "total"},
+		"Billing.Account.open":       {"new", "lookup", "name"},
+		"Billing.Account.find":       {"read", "id"},
+		"Billing.Account.fee":        {"query", "sql", "amount"},
+		"main":                       {"open", "charge!"},
+	} {
+		if !slices.Equal(calls[caller], want) {
+			t.Errorf("calls of %s = %q, want %q", caller, calls[caller], want)
+		}
+	}
+}
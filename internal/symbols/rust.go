@@ -0,0 +1,102 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NewRustParser returns a parser for Rust: functions, structs, enums,
+// unions, traits, impl blocks with their methods, type aliases, modules,
+// constants and macros, and use declarations.
+func NewRustParser() Parser {
+	return rustParser{}
+}
+
+type rustParser struct{}
+
+func (rustParser) Extensions() []string {
+	return []string{".rs"}
+}
+
+func (rustParser) Parse(file string, src []byte) *File {
+	f := rust.parse(file, src)
+	// use declarations may span lines, so they are matched on the whole
+	// file rather than line by line.
+	code := rust.style.blank(string(src))
+	for _, m := range rustUse.FindAllStringSubmatch(code, -1) {
+		f.Imports = append(f.Imports, expandUse(strings.Join(strings.Fields(m[1]), ""))...)
+	}
+	for _, m := range rustExternCrate.FindAllStringSubmatch(code, -1) {
+		f.Imports = append(f.Imports, m[1])
+	}
+	return f
+}
+
+var (
+	rustUse         = regexp.MustCompile(`(?m)^\s*(?:pub(?:\([^)]*\))?\s+)?use\s+([^;]+);`)
+	rustExternCrate = regexp.MustCompile(`(?m)^\s*extern\s+crate\s+(\w+)`)
+)
+
+// expandUse splits a use tree into its paths: "std::{fs,io::{self,Read}}"
+// gives std::fs, std::io and std::io::Read.
+func expandUse(tree string) []string {
+	prefix, list, ok := strings.Cut(tree, "{")
+	if !ok || !strings.HasSuffix(list, "}") {
+		return []string{tree}
+	}
+	var paths []string
+	depth, from := 0, 0
+	list = list[:len(list)-1] + ","
+	for i, c := range list {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth > 0 {
+				continue
+			}
+			switch item := list[from:i]; item {
+			case "":
+			case "self":
+				paths = append(paths, strings.TrimSuffix(prefix, "::"))
+			default:
+				paths = append(paths, expandUse(prefix+item)...)
+			}
+			from = i + 1
+		}
+	}
+	return paths
+}
+
+const rustVisibility = `(?:pub(?:\([^)]*\))?\s+)?`
+
+var rust = language{
+	name: "rust",
+	style: style{
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"`,
+		charLiterals: true,
+	},
+	decls: []declaration{
+		{pattern: regexp.MustCompile(`^` + rustVisibility + `(?:default\s+)?(?:(?:const|async|unsafe|extern(?:\s+"[^"]*")?)\s+)*fn\s+(?P<name>\w+)`), kind: "function", function: true},
+		{pattern: regexp.MustCompile(`^` + rustVisibility + `struct\s+(?P<name>\w+)`), kind: "struct"},
+		{pattern: regexp.MustCompile(`^` + rustVisibility + `enum\s+(?P<name>\w+)`), kind: "enum"},
+		{pattern: regexp.MustCompile(`^` + rustVisibility + `union\s+(?P<name>\w+)`), kind: "union"},
+		{pattern: regexp.MustCompile(`^` + rustVisibility + `(?:unsafe\s+)?(?:auto\s+)?trait\s+(?P<name>\w+)`), kind: "trait", container: true},
+		// impl Type, impl Trait for Type, with generics; named by the type.
+		{pattern: regexp.MustCompile(`^(?:unsafe\s+)?impl\b(?:\s*<[^{]*?>)?\s+(?:!?[\w:]+(?:<[^{]*?>)?\s+for\s+)?(?:\w+::)*(?P<name>\w+)`), kind: "impl", container: true},
+		{pattern: regexp.MustCompile(`^` + rustVisibility + `type\s+(?P<name>\w+)`), kind: "type"},
+		{pattern: regexp.MustCompile(`^` + rustVisibility + `mod\s+(?P<name>\w+)`), kind: "module", container: true},
+		{pattern: regexp.MustCompile(`^` + rustVisibility + `(?:const|static)\s+(?:mut\s+)?(?P<name>\w+)\s*:`), kind: "constant"},
+		{pattern: regexp.MustCompile(`^macro_rules!\s*(?P<name>\w+)`), kind: "macro"},
+	},
+	annotation: regexp.MustCompile(`^#!?\[[^\]]*\]`),
+	call:       regexp.MustCompile(`(?:^|[^\w$])([A-Za-z_]\w*)\s*(?:::\s*<[^()]*>)?\s*\(`),
+	keywords:   words("if while for match loop return fn let in as move unsafe async await else impl where Self self super crate"),
+	exported: func(sig string) bool {
+		return strings.HasPrefix(sig, "pub ")
+	},
+}
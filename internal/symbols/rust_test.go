@@ -0,0 +1,152 @@
+package symbols
+
+import (
+	"slices"
+	"testing"
+)
+
+const rustSource = `//! Geometry.
+use std::collections::HashMap;
+use std::{
+    fmt,
+    io::{self, Read},
+};
+use crate::util::{self, clamp};
+extern crate serde;
+
+/// A point in the plane.
+#[derive(Debug, Clone)]
+pub struct Point {
+    x: f64,
+    y: f64,
+}
+
+pub struct Meters(f64);
+
+pub enum Shape<'a> {
+    Circle(&'a Point, f64),
+}
+
+pub trait Area {
+    fn area(&self) -> f64;
+    fn describe(&self) -> String {
+        format!("{}", self.area())
+    }
+}
+
+impl Point {
+    /// Makes a point.
+    pub fn new(x: f64, y: f64) -> Self {
+        let c = '{';
+        Point { x: clamp(x), y }
+    }
+
+    fn norm<T>(&self) -> f64
+    where
+        T: Into<f64>,
+    {
+        (self.x * self.x).sqrt()
+    }
+}
+
+impl<'a> fmt::Display for Shape<'a> {
+    fn fmt(&self, f: &mut fmt::Formatter) -> fmt::Result {
+        let v: Vec<_> = self.points().iter().collect::<Vec<_>>();
+        write!(f, "{:?}", v)
+    }
+}
+
+pub(crate) async fn load(path: &str) -> io::Result<String> {
+    let p = Point::new(1.0, 2.0);
+    std::fs::read_to_string(path)
+}
+
+type Map = HashMap<String, Point>;
+const LIMIT: usize = 10;
+
+macro_rules! square {
+    ($x:expr) => { $x * $x };
+}
+
+#[cfg(test)]
+mod tests {
+    #[test]
+    fn builds() {
+        super::load("x");
+    }
+}
+`
+
+func TestRustParser(t *testing.T) {
+	f := NewRustParser().Parse("src/geometry.rs", []byte(rustSource))
+
+	want := []struct {
+		name, kind string
+		line       int
+		exported   bool
+	}{
+		{"Point", "struct", 12, true},
+		{"Meters", "struct", 17, true},
+		{"Shape", "enum", 19, true},
+		{"Area", "trait", 23, true},
+		{"Area.area", "method", 24, false},
+		{"Area.describe", "method", 25, false},
+		{"Point", "impl", 30, false},
+		{"Point.new", "method", 32, true},
+		{"Point.norm", "method", 37, false},
+		{"Shape", "impl", 45, false},
+		{"Shape.fmt", "method", 46, false},
+		{"load", "function", 52, false},
+		{"Map", "type", 57, false},
+		{"LIMIT", "constant", 58, false},
+		{"square", "macro", 60, false},
+		{"tests", "module", 65, false},
+		{"tests.builds", "method", 67, false},
+	}
+	if len(f.Symbols) != len(want) {
+		t.Errorf("got %d symbols, want %d: %+v", len(f.Symbols), len(want), f.Symbols)
+	}
+	for i, w := range want {
+		if i >= len(f.Symbols) {
+			break
+		}
+		s := f.Symbols[i]
+		if s.Name != w.name || s.Kind != w.kind || s.Line != w.line || s.Exported != w.exported {
+			t.Errorf("symbol %d = %s %s line %d exported %v, want %s %s line %d exported %v",
+				i, s.Kind, s.Name, s.Line, s.Exported, w.kind, w.name, w.line, w.exported)
+		}
+	}
+
+	point := f.Symbols[0]
+	if point.Doc != "A point in the plane." || !slices.Equal(point.Annotations, []string{"#[derive(Debug, Clone)]"}) {
+		t.Errorf("Point doc %q, annotations %q", point.Doc, point.Annotations)
+	}
+	if sig := f.Symbols[9].Signature; sig != "impl<'a> fmt::Display for Shape<'a>" {
+		t.Errorf("impl signature = %q", sig)
+	}
+
+	wantImports := []string{
+		"std::collections::HashMap", "std::fmt", "std::io", "std::io::Read",
+		"crate::util", "crate::util::clamp", "serde",
+	}
+	if !slices.Equal(f.Imports, wantImports) {
+		t.Errorf("imports = %q, want %q", f.Imports, wantImports)
+	}
+
+	calls := make(map[string][]string)
+	for _, c := range f.Calls {
+		calls[c.Caller] = append(calls[c.Caller], c.Callee)
+	}
+	for caller, want := range map[string][]string{
+		"Area.describe": {"area"},
+		"Point.new":     {"clamp"},
+		"Point.norm":    {"sqrt"},
+		"Shape.fmt":     {"points", "iter", "collect"},
+		"load":          {"new", "read_to_string"},
+		"tests.builds":  {"load"},
+	} {
+		if !slices.Equal(calls[caller], want) {
+			t.Errorf("calls of %s = %q, want %q", caller, calls[caller], want)
+		}
+	}
+}
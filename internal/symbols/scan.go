@@ -0,0 +1,410 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// style describes a language's comments and string literals.
+type style struct {
+	lineComment  string    // "//" or "#"
+	blockComment [2]string // opening and closing delimiters, if any
+	quotes       string    // characters opening a string literal
+	tripleQuotes bool      // """ opens a string that may span lines
+	charLiterals bool      // ' opens a literal only around a single character, as in Rust
+}
+
+// blank returns src with comments and the contents of string literals
+// replaced by spaces. Every other byte, newlines and quotes included, is
+// kept at its offset, so positions in the result are positions in src.
+func (st style) blank(src string) string {
+	out := []byte(src)
+	clear := func(from, to int) {
+		for i := from; i < to && i < len(out); i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+	for i := 0; i < len(src); {
+		switch {
+		case st.lineComment != "" && strings.HasPrefix(src[i:], st.lineComment):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			clear(i, i+end)
+			i += end
+		case st.blockComment[0] != "" && strings.HasPrefix(src[i:], st.blockComment[0]):
+			end := len(src)
+			if j := strings.Index(src[i+len(st.blockComment[0]):], st.blockComment[1]); j >= 0 {
+				end = i + len(st.blockComment[0]) + j + len(st.blockComment[1])
+			}
+			clear(i, end)
+			i = end
+		case st.tripleQuotes && strings.HasPrefix(src[i:], `"""`):
+			end := len(src)
+			if j := strings.Index(src[i+3:], `"""`); j >= 0 {
+				end = i + 3 + j
+			}
+			clear(i+3, end)
+			i = min(end+3, len(src))
+		case src[i] == '\'' && st.charLiterals:
+			n := charLiteral(src[i:])
+			clear(i+1, i+n-1)
+			i += max(n, 1)
+		case strings.IndexByte(st.quotes, src[i]) >= 0:
+			q := src[i]
+			j := i + 1
+			for j < len(src) && src[j] != q && (src[j] != '\n' || q == '`') {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			clear(i+1, j)
+			i = j
+			if i < len(src) && src[i] == q {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return string(out)
+}
+
+// charLiteral returns the length of the character literal s starts with
+// ('a', '\n', '\u{1F600}'), or 0 if the quote starts something else, such
+// as a Rust lifetime.
+func charLiteral(s string) int {
+	if len(s) >= 3 && s[1] != '\\' && s[1] != '\'' && s[2] == '\'' {
+		return 3
+	}
+	if len(s) >= 4 && s[1] == '\\' {
+		if end := strings.IndexByte(s[3:], '\''); end >= 0 && end < 10 {
+			return end + 4
+		}
+	}
+	return 0
+}
+
+// inCode reports whether the match of a pattern against the original line
+// orig, from start to end, begins in code rather than in a comment or
+// string, given the same line with those blanked.
+func inCode(code, orig string, start, end int) bool {
+	for i := start; i < end && i < len(code); i++ {
+		if orig[i] != ' ' && orig[i] != '\t' {
+			return code[i] == orig[i]
+		}
+	}
+	return false
+}
+
+// declaration is a pattern matching one kind of declaration, with the
+// declared name in the group "name".
+type declaration struct {
+	pattern   *regexp.Regexp
+	kind      string
+	container bool   // the declarations in its body are named after it: "Class.method"
+	function  bool   // the calls in its body are its own; in a container it is a method
+	member    bool   // only directly in a container's body, like a method
+	topLevel  bool   // only outside any declaration's body
+	sameName  bool   // named like its container, like a constructor
+	anonymous string // the name when the name group does not match, as for Kotlin's companion objects
+}
+
+// language is a brace-delimited language parsed by patterns.
+type language struct {
+	name  string
+	style style
+	decls []declaration
+	// imports match imported modules, in group 1, on original lines.
+	imports []*regexp.Regexp
+	// annotation matches an annotation, decorator or attribute at the
+	// start of a line.
+	annotation *regexp.Regexp
+	// call matches a call, with the name called in the first group that
+	// matches.
+	call *regexp.Regexp
+	// keywords are words that look like calls or declarations before a
+	// parenthesis, such as if and for.
+	keywords map[string]bool
+	// newlineEnds has the end of a line end a declaration without a body,
+	// as in languages where semicolons are optional.
+	newlineEnds bool
+	// exported reports whether a declaration is visible outside its
+	// file or package, from its signature.
+	exported func(signature string) bool
+}
+
+// scope is a declaration whose body is open.
+type scope struct {
+	name      string
+	container bool
+	function  bool
+	depth     int // brace depth outside the body
+}
+
+// defaultCall matches a call in C-like languages, including generic ones
+// ("make<T>(").
+var defaultCall = regexp.MustCompile(`(?:^|[^\w$])([A-Za-z_$][\w$]*)\s*(?:<[\w$.,\s\[\]?]*>)?\s*\(`)
+
+// parse returns the symbols, imports and calls of a file.
+func (lang *language) parse(file string, src []byte) *File {
+	f := &File{Path: file, Language: lang.name}
+	orig := strings.Split(string(src), "\n")
+	code := strings.Split(lang.style.blank(string(src)), "\n")
+
+	var (
+		stack       []scope
+		pending     *scope // a declaration whose body has not opened yet
+		pendingAt   int    // the depth it was declared at
+		parens      int    // parenthesis depth since the pending declaration
+		depth       int
+		annotations []string
+	)
+	for i, line := range code {
+		for _, re := range lang.imports {
+			for _, m := range re.FindAllStringSubmatchIndex(orig[i], -1) {
+				if inCode(line, orig[i], m[0], m[1]) {
+					f.Imports = append(f.Imports, orig[i][m[2]:m[3]])
+				}
+			}
+		}
+
+		// Annotations before a declaration, on its line or the lines above.
+		start := len(line) - len(strings.TrimLeft(line, " \t"))
+		for lang.annotation != nil {
+			loc := lang.annotation.FindStringIndex(line[start:])
+			// Java's @interface declares an annotation type.
+			if loc == nil || loc[0] != 0 || line[start:start+loc[1]] == "@interface" {
+				break
+			}
+			annotations = append(annotations, strings.TrimSpace(orig[i][start:start+loc[1]]))
+			start += loc[1]
+			start += len(line[start:]) - len(strings.TrimLeft(line[start:], " \t"))
+		}
+		rest := line[start:]
+
+		declName, declEnd := -1, -1
+		if strings.TrimSpace(rest) != "" {
+			var top *scope
+			if len(stack) > 0 {
+				top = &stack[len(stack)-1]
+			}
+			if d, m := lang.match(rest, top, depth); m != nil {
+				name := d.anonymous
+				declName, declEnd = start+m[1], start+m[1]
+				if m[2] >= 0 {
+					name = rest[m[2]:m[3]]
+					declName, declEnd = start+m[2], start+m[3]
+				}
+				kind := d.kind
+				if top != nil && top.container {
+					name = top.name + "." + name
+					if d.function && kind == "function" {
+						kind = "method"
+					}
+				}
+				sig := strings.TrimSpace(orig[i][start:])
+				sig = strings.TrimSpace(strings.TrimSuffix(sig, "{"))
+				f.Symbols = append(f.Symbols, Symbol{
+					Name:        name,
+					Kind:        kind,
+					File:        file,
+					Line:        i + 1,
+					Signature:   truncate(sig, maxSignature),
+					Doc:         lang.docAbove(orig, i),
+					Exported:    lang.exported != nil && lang.exported(sig),
+					Annotations: annotations,
+				})
+				pending = &scope{name: name, container: d.container, function: d.function}
+				pendingAt, parens = depth, 0
+			}
+			annotations = nil
+		}
+
+		// Calls belong to the innermost function whose body is open, or to
+		// a function declared on this line, as in "fun f() = g()".
+		calls := lang.findCalls(line)
+		caller := func(pos int) string {
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].function {
+					return stack[j].name
+				}
+			}
+			if pending != nil && pending.function && pos > declEnd && declEnd >= 0 {
+				return pending.name
+			}
+			return ""
+		}
+		next := 0
+		for j := 0; j <= len(line); j++ {
+			for next < len(calls) && calls[next][0] <= j {
+				m := calls[next]
+				next++
+				name := line[m[0]:m[1]]
+				if m[0] == declName || lang.keywords[name] {
+					continue
+				}
+				if from := caller(m[0]); from != "" {
+					f.Calls = append(f.Calls, Call{Caller: from, Callee: name, Line: i + 1})
+				}
+			}
+			if j == len(line) {
+				break
+			}
+			switch line[j] {
+			case '{':
+				if pending != nil {
+					pending.depth = depth
+					stack = append(stack, *pending)
+					pending = nil
+				}
+				depth++
+			case '}':
+				if depth > 0 {
+					depth--
+				}
+				for len(stack) > 0 && stack[len(stack)-1].depth >= depth {
+					stack = stack[:len(stack)-1]
+				}
+				if pending != nil && depth < pendingAt {
+					pending = nil
+				}
+			case '(':
+				parens++
+			case ')':
+				parens--
+			case ';':
+				pending = nil
+			}
+		}
+		if pending != nil && lang.newlineEnds && parens <= 0 && !continues(line) {
+			pending = nil
+		}
+	}
+	return f
+}
+
+// findCalls returns the start and end of each name called in a line.
+// Matching resumes after each name, so "f(g(x))" gives both f and g.
+func (lang *language) findCalls(line string) [][2]int {
+	var calls [][2]int
+	for pos := 0; pos < len(line); {
+		m := lang.call.FindStringSubmatchIndex(line[pos:])
+		if m == nil {
+			break
+		}
+		g := 2
+		for g < len(m) && m[g] < 0 {
+			g += 2
+		}
+		if g == len(m) {
+			break
+		}
+		calls = append(calls, [2]int{pos + m[g], pos + m[g+1]})
+		pos += m[g+1]
+	}
+	return calls
+}
+
+// continues reports whether a line of code continues on the next, with
+// a parameter list, supertypes or a function body.
+func continues(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, suffix := range []string{",", "(", ":", "=>", "->", "=", "where"} {
+		if strings.HasSuffix(line, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the first declaration matching rest, the code of a line
+// after its annotations, with its submatch indexes. top is the innermost
+// open declaration, if any.
+func (lang *language) match(rest string, top *scope, depth int) (*declaration, []int) {
+	inContainer := top != nil && top.container && depth == top.depth+1
+	for i := range lang.decls {
+		d := &lang.decls[i]
+		if d.member && !inContainer || d.topLevel && depth > 0 {
+			continue
+		}
+		m := d.pattern.FindStringSubmatchIndex(rest)
+		if m == nil {
+			continue
+		}
+		g := d.pattern.SubexpIndex("name")
+		m = []int{m[0], m[1], m[2*g], m[2*g+1]}
+		if m[2] < 0 {
+			if d.anonymous == "" {
+				continue
+			}
+			return d, m
+		}
+		if lang.keywords[rest[m[2]:m[3]]] || d.sameName && shortName(top.name) != rest[m[2]:m[3]] {
+			continue
+		}
+		return d, m
+	}
+	return nil, nil
+}
+
+// maxSignature bounds Symbol.Signature.
+const maxSignature = 200
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// docAbove returns the comment directly above line i, skipping
+// annotations between the two, without comment markers.
+func (lang *language) docAbove(lines []string, i int) string {
+	var doc []string
+	for j := i - 1; j >= 0; j-- {
+		t := strings.TrimSpace(lines[j])
+		text, ok := commentText(t, lang.style)
+		if !ok {
+			if t != "" && lang.annotation != nil && lang.annotation.MatchString(t) {
+				continue
+			}
+			break
+		}
+		doc = append(doc, text)
+	}
+	for l, r := 0, len(doc)-1; l < r; l, r = l+1, r-1 {
+		doc[l], doc[r] = doc[r], doc[l]
+	}
+	return strings.TrimSpace(strings.Join(doc, "\n"))
+}
+
+// commentText returns the text of a line of comment, without its markers.
+func commentText(t string, st style) (string, bool) {
+	switch {
+	case st.lineComment != "" && strings.HasPrefix(t, st.lineComment):
+		t = strings.TrimLeft(t, st.lineComment[:1])
+		t = strings.TrimPrefix(t, "!")
+	case st.blockComment[0] == "/*" && (strings.HasPrefix(t, "/*") || strings.HasPrefix(t, "*")):
+		t = strings.TrimSuffix(t, "*/")
+		t = strings.TrimLeft(t, "/*")
+	case st.blockComment[1] == "*/" && strings.HasSuffix(t, "*/"):
+		t = strings.TrimSuffix(t, "*/")
+	default:
+		return "", false
+	}
+	return strings.TrimSpace(t), true
+}
+
+// words returns a set of words.
+func words(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(list) {
+		set[w] = true
+	}
+	return set
+}
@@ -0,0 +1,45 @@
+package symbols
+
+import (
+	"sort"
+
+	"github.com/yourorg/agent/internal/scip"
+)
+
+// importSCIP reads a SCIP index, as scip-go and scip-typescript write.
+// References to functions and methods from within one become its calls,
+// and the packages a file refers to besides its own become its imports.
+// Its paths are already relative to the project.
+func importSCIP(data []byte, root string) ([]*File, error) {
+	docs, err := scip.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*File, 0, len(docs))
+	for _, d := range docs {
+		f := &File{Path: d.Path, Language: d.Language}
+		for _, def := range d.Definitions {
+			f.Symbols = append(f.Symbols, Symbol{
+				Name:      def.Name,
+				Kind:      def.Kind,
+				File:      d.Path,
+				Line:      def.Line,
+				Signature: def.Signature,
+				Doc:       def.Doc,
+			})
+		}
+		sort.SliceStable(f.Symbols, func(i, j int) bool { return f.Symbols[i].Line < f.Symbols[j].Line })
+		seen := make(map[string]bool)
+		for _, r := range d.References {
+			if r.Package != "" && r.Package != d.Package && !seen[r.Package] {
+				seen[r.Package] = true
+				f.Imports = append(f.Imports, r.Package)
+			}
+			if r.Caller != "" && (r.Kind == "function" || r.Kind == "method") {
+				f.Calls = append(f.Calls, Call{Caller: r.Caller, Callee: shortName(r.Name), Line: r.Line})
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
@@ -0,0 +1,124 @@
+package symbols
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yourorg/agent/internal/docs"
+)
+
+// Result is a symbol matching a query.
+type Result struct {
+	Symbol
+	Score   float64 `json:"score"`
+	Matched int     `json:"matched"` // query words the symbol matched
+	Terms   int     `json:"terms"`   // query words searched for
+}
+
+// Search ranks symbols by how many query words their name contains, best
+// first, and returns at most limit of them (all with limit <= 0). A word
+// matching a whole word of the name ("user" in UserService) counts more
+// than a prefix; words found only in the file's path or the doc comment
+// count least.
+func Search(syms []Symbol, query string, limit int) []Result {
+	terms := docs.Terms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []Result
+	for _, s := range syms {
+		words := nameWords(s.Name)
+		file := strings.ToLower(s.File)
+		doc := strings.ToLower(s.Doc)
+		score, matched := 0.0, 0
+		for _, t := range terms {
+			best := 0.0
+			for _, w := range words {
+				switch {
+				case w == t:
+					best = max(best, 2)
+				case len(t) >= 3 && (strings.HasPrefix(w, t) || strings.HasPrefix(t, w) && len(w) >= 3):
+					best = max(best, 1)
+				}
+			}
+			if best == 0 && (strings.Contains(file, t) || strings.Contains(doc, t)) {
+				best = 0.5
+			}
+			if best > 0 {
+				score += best
+				matched++
+			}
+		}
+		if matched == 0 {
+			continue
+		}
+		score *= float64(matched) / float64(len(terms))
+		results = append(results, Result{Symbol: s, Score: score, Matched: matched, Terms: len(terms)})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Relevant is Search keeping only symbols whose name matches every query
+// word, or at least two, for adding to a task's context unasked.
+func Relevant(syms []Symbol, query string, limit int) []Result {
+	var relevant []Result
+	for _, r := range Search(syms, query, 0) {
+		if r.Matched == r.Terms || r.Matched >= 2 {
+			relevant = append(relevant, r)
+		}
+		if limit > 0 && len(relevant) == limit {
+			break
+		}
+	}
+	return relevant
+}
+
+// nameWords splits a symbol name into lowercase words at separators and
+// camelCase boundaries: "UserService.getByID" gives user, service, get,
+// by, id.
+func nameWords(name string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// Format renders results one per line, as "file:line  kind name".
+func Format(results []Result) string {
+	var b strings.Builder
+	for _, r := range results {
+		fmt.Fprintf(&b, "%s:%d  %s %s\n", r.File, r.Line, r.Kind, r.Name)
+	}
+	return b.String()
+}
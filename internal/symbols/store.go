@@ -0,0 +1,259 @@
+package symbols
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// storeVersion is bumped whenever the persisted format or what the
+// parsers extract changes.
+const storeVersion = 3
+
+// A store starts with a prefix. The header, the entries of every file,
+// follows, and then the details of each file in turn, so the entries are
+// read without the details.
+type prefix struct {
+	Magic   [4]byte
+	Version uint32
+	Stamp   int64 // time of the save
+	Size    int64 // of the header
+}
+
+var storeMagic = [4]byte{'s', 'y', 'm', 's'}
+
+const prefixSize = 4 + 4 + 8 + 8
+
+// Path returns the file the index of the project at projectPath is kept
+// in.
+func Path(projectPath string) string {
+	return filepath.Join(projectPath, ".index", "structure.db")
+}
+
+// entry is an indexed file as kept in memory: its symbols without their
+// signatures, docs and annotations, its imports, and the names it calls,
+// which narrow down the files whose calls a call graph query reads.
+type entry struct {
+	Path     string
+	Language string
+	Symbols  []Symbol
+	Imports  []string
+	Callees  []string // each name called, once
+
+	// Source is the index file an imported file was read from, in
+	// Format; Hash, Size and ModTime are then those of the index file.
+	Source string
+	Format string
+
+	Hash    string // hex SHA-256 of the contents
+	Size    int64
+	ModTime time.Time
+	Offset  int64 // of the details, from the end of the header
+	Length  int64
+}
+
+// details is the rest of a file, read only when needed.
+type details struct {
+	Symbols []symbolDetails // in the order of the entry's
+	Calls   []Call
+}
+
+type symbolDetails struct {
+	Signature   string
+	Doc         string
+	Annotations []string
+}
+
+// split returns the entry and the details of a parsed file.
+func split(f *File) (*entry, details) {
+	e := &entry{Path: f.Path, Language: f.Language, Imports: f.Imports}
+	d := details{Calls: f.Calls}
+	seen := make(map[string]bool)
+	for _, c := range f.Calls {
+		if !seen[c.Callee] {
+			seen[c.Callee] = true
+			e.Callees = append(e.Callees, c.Callee)
+		}
+	}
+	for _, s := range f.Symbols {
+		d.Symbols = append(d.Symbols, symbolDetails{Signature: s.Signature, Doc: s.Doc, Annotations: s.Annotations})
+		s.Signature, s.Doc, s.Annotations = "", "", nil
+		e.Symbols = append(e.Symbols, s)
+	}
+	return e, d
+}
+
+// file joins an entry and its details back into the parsed file.
+func (e *entry) file(d details) *File {
+	f := &File{Path: e.Path, Language: e.Language, Imports: e.Imports, Calls: d.Calls}
+	f.Symbols = make([]Symbol, len(e.Symbols))
+	copy(f.Symbols, e.Symbols)
+	for i := range f.Symbols {
+		if i < len(d.Symbols) {
+			f.Symbols[i].Signature = d.Symbols[i].Signature
+			f.Symbols[i].Doc = d.Symbols[i].Doc
+			f.Symbols[i].Annotations = d.Symbols[i].Annotations
+		}
+	}
+	return f
+}
+
+func hash(src []byte) string {
+	sum := sha256.Sum256(src)
+	return hex.EncodeToString(sum[:])
+}
+
+func encodeDetails(d details) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(d); err != nil {
+		return nil, fmt.Errorf("encode symbol details: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// store is a saved index, by its header.
+type store struct {
+	path    string
+	stamp   int64 // tells this save from a later one to the same path
+	data    int64 // offset of the first details
+	entries []*entry
+}
+
+// openStore reads the header of the store at path.
+func openStore(path string) (*store, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	stamp, size, err := readPrefix(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &store{path: path, stamp: stamp, data: prefixSize + size}
+	if err := gob.NewDecoder(io.LimitReader(r, size)).Decode(&s.entries); err != nil {
+		return nil, fmt.Errorf("decode structure index: %w", err)
+	}
+	return s, nil
+}
+
+// readPrefix returns the stamp and the header length of a store.
+func readPrefix(r io.Reader) (stamp, size int64, err error) {
+	var p prefix
+	if err := binary.Read(r, binary.LittleEndian, &p); err != nil {
+		return 0, 0, fmt.Errorf("read structure index: %w", err)
+	}
+	if p.Magic != storeMagic {
+		return 0, 0, errors.New("not a structure index")
+	}
+	if p.Version != storeVersion {
+		return 0, 0, errors.New("structure index has an old format")
+	}
+	return p.Stamp, p.Size, nil
+}
+
+// details reads the details of entries from the store. It fails if the
+// store was saved again since it was opened.
+func (s *store) details(entries []*entry) ([]details, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read symbol details: %w", err)
+	}
+	defer f.Close()
+	if stamp, _, err := readPrefix(f); err != nil {
+		return nil, err
+	} else if stamp != s.stamp {
+		return nil, errors.New("structure index was saved again since it was read")
+	}
+	out := make([]details, len(entries))
+	for i, e := range entries {
+		section := io.NewSectionReader(f, s.data+e.Offset, e.Length)
+		if err := gob.NewDecoder(section).Decode(&out[i]); err != nil {
+			return nil, fmt.Errorf("decode symbol details of %s: %w", e.Path, err)
+		}
+	}
+	return out, nil
+}
+
+// saveStore writes entries, by path, to a temporary file and renames it
+// into place, so a concurrent command never reads a partial index. The
+// details of entries[i] are blobs[i], or when that is nil, those saved
+// for it in old.
+func saveStore(path string, entries []*entry, blobs [][]byte, old *store) (*store, error) {
+	var oldFile *os.File
+	if old != nil {
+		f, err := os.Open(old.path)
+		if err != nil {
+			return nil, fmt.Errorf("read structure index: %w", err)
+		}
+		defer f.Close()
+		if stamp, _, err := readPrefix(f); err != nil {
+			return nil, err
+		} else if stamp != old.stamp {
+			return nil, errors.New("structure index was saved again while indexing")
+		}
+		oldFile = f
+	}
+
+	saved := make([]*entry, len(entries))
+	var offset int64
+	for i, e := range entries {
+		c := *e
+		if blobs[i] != nil {
+			c.Length = int64(len(blobs[i]))
+		}
+		c.Offset = offset
+		offset += c.Length
+		saved[i] = &c
+	}
+	var header bytes.Buffer
+	if err := gob.NewEncoder(&header).Encode(saved); err != nil {
+		return nil, fmt.Errorf("encode structure index: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create index directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("write structure index: %w", err)
+	}
+	defer os.Remove(tmp)
+	s := &store{path: path, stamp: time.Now().UnixNano(), data: prefixSize + int64(header.Len()), entries: saved}
+	w := bufio.NewWriter(out)
+	err = binary.Write(w, binary.LittleEndian, prefix{storeMagic, storeVersion, s.stamp, int64(header.Len())})
+	if err == nil {
+		_, err = w.Write(header.Bytes())
+	}
+	for i := 0; err == nil && i < len(entries); i++ {
+		if blobs[i] != nil {
+			_, err = w.Write(blobs[i])
+		} else {
+			_, err = io.Copy(w, io.NewSectionReader(oldFile, old.data+entries[i].Offset, entries[i].Length))
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("write structure index: %w", err)
+	}
+	return s, nil
+}
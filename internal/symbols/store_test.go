@@ -0,0 +1,148 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingParser counts the files it parses.
+type countingParser struct {
+	Parser
+	parsed atomic.Int32
+}
+
+func (p *countingParser) Parse(file string, src []byte) *File {
+	p.parsed.Add(1)
+	return p.Parser.Parse(file, src)
+}
+
+func TestIndexProjectCache(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"a.ts": "export function alpha() {}\n",
+		"b.ts": "export function beta() {}\n",
+		"c.ts": "export function gamma() {}\n",
+	})
+	parser := &countingParser{Parser: NewTypeScriptParser()}
+	index := func(cache bool) *Index {
+		t.Helper()
+		parser.parsed.Store(0)
+		x := NewIndexer()
+		x.RegisterParser(parser)
+		x.SetCacheEnabled(cache)
+		idx, err := x.IndexProject(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return idx
+	}
+	names := func(idx *Index) []string {
+		var names []string
+		for _, s := range idx.Symbols() {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+
+	if idx := index(true); idx.Len() != 3 || parser.parsed.Load() != 3 {
+		t.Fatalf("first run: %d symbols, %d parsed", idx.Len(), parser.parsed.Load())
+	}
+	if _, err := os.Stat(Path(dir)); err != nil {
+		t.Fatalf("index not saved: %v", err)
+	}
+	if idx := index(true); idx.Len() != 3 || parser.parsed.Load() != 0 {
+		t.Errorf("unchanged project: %d symbols, %d parsed, want 3 and 0", idx.Len(), parser.parsed.Load())
+	}
+
+	// a.ts changes, b.ts is only touched and c.ts is deleted.
+	later := time.Now().Add(time.Hour)
+	if err := os.WriteFile(filepath.Join(dir, "a.ts"), []byte("export function delta() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, "b.ts"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "c.ts")); err != nil {
+		t.Fatal(err)
+	}
+	idx := index(true)
+	if got := names(idx); len(got) != 2 || got[0] != "delta" || got[1] != "beta" {
+		t.Errorf("symbols after changes = %q, want [delta beta]", got)
+	}
+	if parser.parsed.Load() != 1 {
+		t.Errorf("parsed %d files after changes, want only a.ts", parser.parsed.Load())
+	}
+	// The touched file's new time is saved, so it is not even read again.
+	if index(true); parser.parsed.Load() != 0 {
+		t.Errorf("parsed %d files on the next run, want 0", parser.parsed.Load())
+	}
+
+	if idx := index(false); idx.Len() != 2 || parser.parsed.Load() != 2 {
+		t.Errorf("cache disabled: %d symbols, %d parsed, want 2 and 2", idx.Len(), parser.parsed.Load())
+	}
+
+	// A corrupt index is rebuilt.
+	if err := os.WriteFile(Path(dir), []byte("not gob"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if idx := index(true); idx.Len() != 2 || parser.parsed.Load() != 2 {
+		t.Errorf("corrupt index: %d symbols, %d parsed, want 2 and 2", idx.Len(), parser.parsed.Load())
+	}
+}
+
+func TestIndexReadsDetailsOnDemand(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"a.ts": "/** Starts. */\nexport function alpha() {\n  beta();\n}\n",
+		"b.ts": "export function beta(x: number) {}\n",
+		"c.ts": "export function gamma() {}\n",
+	})
+	if _, err := Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := Load(dir) // from the saved index
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.files) != 0 {
+		t.Fatalf("details of %d files read on load, want none", len(idx.files))
+	}
+	if got := idx.Names(); !slices.Equal(got, []string{"alpha", "beta", "gamma"}) || len(idx.files) != 0 {
+		t.Errorf("Names() = %q, reading %d files", got, len(idx.files))
+	}
+
+	got := idx.Lookup("beta")
+	if len(got) != 1 || got[0].Signature != "export function beta(x: number) {}" {
+		t.Errorf("Lookup(beta) = %+v", got)
+	}
+	if len(idx.files) != 1 {
+		t.Errorf("Lookup read the details of %d files, want 1", len(idx.files))
+	}
+	if got := idx.Neighbors("beta", Callers); !slices.Equal(got, []string{"alpha"}) {
+		t.Errorf("callers of beta = %q", got)
+	}
+	if len(idx.files) != 2 {
+		t.Errorf("Neighbors read the details of %d files in all, want 2", len(idx.files))
+	}
+	if got := idx.SymbolsIn(func(file string) bool { return file == "a.ts" }); len(got) != 1 || got[0].Doc != "Starts." {
+		t.Errorf("SymbolsIn(a.ts) = %+v", got)
+	}
+
+	// Once the index is saved again, the details it has not read yet are
+	// left empty rather than read from the wrong place.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "c.ts"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Lookup("gamma"); len(got) != 1 || got[0].Name != "gamma" || got[0].Signature != "" {
+		t.Errorf("Lookup(gamma) after a save = %+v", got)
+	}
+	if got := idx.Lookup("beta"); len(got) != 1 || got[0].Signature == "" {
+		t.Errorf("Lookup(beta) lost the details already read: %+v", got)
+	}
+}
@@ -0,0 +1,605 @@
+// Package symbols indexes the languages the structural indexer has no
+// parser for: their functions, classes and other declarations, the
+// modules each file imports and the calls in each function, so search,
+// callgraph and fetch_context also work on the rest of a polyglot
+// project.
+//
+// Parsing is line based. Comments and the contents of string literals are
+// blanked first, declarations are then matched by pattern and nesting is
+// followed by braces, or by end in Ruby. Calls are matched by name, like the Python call
+// graph of package testmap.
+package symbols
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Symbol is a declaration.
+type Symbol struct {
+	Name        string   `json:"name"` // "func", "Class", or "Class.method"
+	Kind        string   `json:"kind"` // "function", "method", "class", "interface", ...
+	File        string   `json:"file"` // slash-separated, relative to the project
+	Line        int      `json:"line"` // 1-based
+	Signature   string   `json:"signature,omitempty"`
+	Doc         string   `json:"doc,omitempty"`
+	Exported    bool     `json:"exported,omitempty"`
+	Annotations []string `json:"annotations,omitempty"` // annotations, decorators and attributes
+}
+
+// ShortName returns the name without its container: "method" for
+// "Class.method".
+func (s Symbol) ShortName() string {
+	return shortName(s.Name)
+}
+
+func shortName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// Call is a call from a function to another, by the name called.
+type Call struct {
+	Caller string `json:"caller"` // qualified name of the calling function
+	Callee string `json:"callee"` // the name called, without receiver or package
+	Line   int    `json:"line"`
+}
+
+// File is what a parser found in a source file.
+type File struct {
+	Path     string   `json:"path"` // slash-separated, relative to the project
+	Language string   `json:"language"`
+	Symbols  []Symbol `json:"symbols"`
+	Imports  []string `json:"imports,omitempty"` // as written: a module, package or relative path
+	Calls    []Call   `json:"calls,omitempty"`
+}
+
+// Parser extracts the symbols of one language's source files.
+type Parser interface {
+	// Extensions returns the file extensions the parser reads, with the
+	// dot.
+	Extensions() []string
+	// Parse returns the symbols, imports and calls of a file. file is
+	// slash-separated and relative to the project.
+	Parse(file string, src []byte) *File
+}
+
+// Parsers returns a parser for each supported language.
+func Parsers() []Parser {
+	return []Parser{
+		NewTypeScriptParser(),
+		NewJavaParser(),
+		NewRustParser(),
+		NewRubyParser(),
+		NewKotlinParser(),
+	}
+}
+
+// maxFileSize bounds the files indexed; larger ones are generated or
+// bundled code.
+const maxFileSize = 1 << 20
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"venv":         true,
+	"__pycache__":  true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+}
+
+// Indexer parses a project's files with the parsers registered for their
+// extensions.
+type Indexer struct {
+	parsers map[string]Parser
+	workers int
+	cache   bool
+	imports []importSource
+}
+
+// NewIndexer returns an indexer without parsers, which keeps what it
+// parses in the project's .index directory.
+func NewIndexer() *Indexer {
+	return &Indexer{parsers: make(map[string]Parser), cache: true}
+}
+
+// RegisterParser has p parse the files with its extensions, replacing an
+// earlier parser for the same extension.
+func (x *Indexer) RegisterParser(p Parser) {
+	for _, ext := range p.Extensions() {
+		x.parsers[ext] = p
+	}
+}
+
+// SetConcurrency sets the number of files parsed at once. n <= 0, the
+// default, parses one per CPU.
+func (x *Indexer) SetConcurrency(n int) {
+	x.workers = n
+}
+
+// SetCacheEnabled has IndexProject reuse the files saved by an earlier
+// run, reparsing only those whose contents changed, and save the result.
+// Disabled, every file is parsed again and nothing is saved.
+func (x *Indexer) SetCacheEnabled(enabled bool) {
+	x.cache = enabled
+}
+
+// Load indexes the project at projectPath with all parsers.
+func Load(projectPath string) (*Index, error) {
+	x := NewIndexer()
+	for _, p := range Parsers() {
+		x.RegisterParser(p)
+	}
+	return x.IndexProject(projectPath)
+}
+
+// IndexProject parses the files of the project at projectPath.
+func (x *Indexer) IndexProject(projectPath string) (*Index, error) {
+	var old *store
+	previous := make(map[string]*entry)   // parsed files, by path
+	imported := make(map[string][]*entry) // imported files, by index file
+	if x.cache {
+		if s, err := openStore(Path(projectPath)); err == nil {
+			old = s
+			for _, e := range s.entries {
+				if e.Source != "" {
+					imported[e.Source] = append(imported[e.Source], e)
+				} else {
+					previous[e.Path] = e
+				}
+			}
+		}
+	}
+
+	type source struct {
+		path, rel string
+		parser    Parser
+		info      fs.FileInfo
+	}
+	var (
+		sources []source
+		kept    []indexed
+	)
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != projectPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parser := x.parser(d.Name())
+		if parser == nil || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+		rel, err := filepath.Rel(projectPath, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if e, ok := previous[rel]; ok && e.Size == info.Size() && e.ModTime.Equal(info.ModTime()) {
+			kept = append(kept, indexed{entry: e})
+			delete(previous, rel)
+			return nil
+		}
+		sources = append(sources, source{path: p, rel: rel, parser: parser, info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("index symbols: %w", err)
+	}
+
+	// The rest are read again, and parsed again only if their contents
+	// changed. Each worker writes only the slots of the files it takes, so
+	// the results need no lock.
+	parsed := make([]indexed, len(sources))
+	errs := make([]error, len(sources))
+	workers := x.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(sources)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				s := sources[i]
+				src, err := os.ReadFile(s.path)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				sum := hash(src)
+				if e, ok := previous[s.rel]; ok && e.Hash == sum {
+					touched := *e
+					touched.Size, touched.ModTime = s.info.Size(), s.info.ModTime()
+					parsed[i] = indexed{entry: &touched}
+					continue
+				}
+				f := s.parser.Parse(s.rel, src)
+				e, d := split(f)
+				e.Hash, e.Size, e.ModTime = sum, s.info.Size(), s.info.ModTime()
+				parsed[i] = indexed{entry: e, file: f}
+				if x.cache {
+					parsed[i].file = nil
+					parsed[i].blob, errs[i] = encodeDetails(d)
+				}
+			}
+		}()
+	}
+	for i := range sources {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("index symbols: %w", err)
+	}
+
+	all := append(kept, parsed...)
+	paths := make(map[string]bool, len(all))
+	for _, f := range all {
+		paths[f.entry.Path] = true
+	}
+	imports, importsChanged, err := x.importAll(projectPath, imported, paths)
+	if err != nil {
+		return nil, fmt.Errorf("index symbols: %w", err)
+	}
+	all = append(all, imports...)
+	sort.Slice(all, func(i, j int) bool { return all[i].entry.Path < all[j].entry.Path })
+	entries := make([]*entry, len(all))
+	for i, f := range all {
+		entries[i] = f.entry
+	}
+	if !x.cache {
+		idx := newIndex(entries, nil)
+		for i, f := range all {
+			idx.files[i] = f.file
+		}
+		return idx, nil
+	}
+	// What is left of previous was read again or deleted; either changes
+	// what is saved.
+	if len(sources) == 0 && len(previous) == 0 && !importsChanged {
+		return newIndex(entries, old), nil
+	}
+	blobs := make([][]byte, len(all))
+	for i, f := range all {
+		blobs[i] = f.blob
+	}
+	saved, err := saveStore(Path(projectPath), entries, blobs, old)
+	if err != nil {
+		return nil, err
+	}
+	return newIndex(saved.entries, saved), nil
+}
+
+// indexed is a file's entry with its details: encoded, or nil when they
+// are those saved in the store, or parsed when nothing is saved.
+type indexed struct {
+	entry *entry
+	blob  []byte
+	file  *File
+}
+
+// parser returns the parser of a file by its name, or nil.
+func (x *Indexer) parser(name string) Parser {
+	if strings.HasSuffix(name, ".min.js") {
+		return nil
+	}
+	return x.parsers[filepath.Ext(name)]
+}
+
+// Index is the symbols, imports and calls of a project's files. Only
+// the names, kinds and places of the symbols are kept in memory with the
+// imports; signatures, docs, annotations and calls are read from the
+// saved index as queries need them, and kept from then on.
+type Index struct {
+	entries  []*entry               // by path
+	byName   map[string][]symbolRef // by short name
+	calledIn map[string][]int       // the entries calling a name, by the name
+	store    *store                 // where details are read from, if saved
+
+	mu    sync.Mutex
+	files map[int]*File // entries with their details, by position
+}
+
+// symbolRef is the position of a symbol in an index's entries.
+type symbolRef struct {
+	entry, symbol int
+}
+
+func newIndex(entries []*entry, st *store) *Index {
+	idx := &Index{
+		entries:  entries,
+		byName:   make(map[string][]symbolRef),
+		calledIn: make(map[string][]int),
+		store:    st,
+		files:    make(map[int]*File),
+	}
+	for i, e := range entries {
+		for j, s := range e.Symbols {
+			idx.byName[s.ShortName()] = append(idx.byName[s.ShortName()], symbolRef{i, j})
+		}
+		for _, callee := range e.Callees {
+			idx.calledIn[callee] = append(idx.calledIn[callee], i)
+		}
+	}
+	return idx
+}
+
+// details returns the entries at positions ids with their details.
+// Details that cannot be read, because the saved index was replaced or
+// removed since, are left empty.
+func (idx *Index) details(ids []int) []*File {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.files == nil {
+		idx.files = make(map[int]*File)
+	}
+	var missing []int
+	for _, id := range ids {
+		if idx.files[id] == nil {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 && idx.store != nil {
+		entries := make([]*entry, len(missing))
+		for i, id := range missing {
+			entries[i] = idx.entries[id]
+		}
+		if d, err := idx.store.details(entries); err == nil {
+			for i, id := range missing {
+				idx.files[id] = idx.entries[id].file(d[i])
+			}
+		}
+	}
+	files := make([]*File, len(ids))
+	for i, id := range ids {
+		if files[i] = idx.files[id]; files[i] == nil {
+			files[i] = idx.entries[id].file(details{})
+		}
+	}
+	return files
+}
+
+// resolve returns the symbols at refs with their details.
+func (idx *Index) resolve(refs []symbolRef) []Symbol {
+	ids := make([]int, len(refs))
+	for i, r := range refs {
+		ids[i] = r.entry
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	files := make(map[int]*File, len(ids))
+	for i, f := range idx.details(ids) {
+		files[ids[i]] = f
+	}
+	found := make([]Symbol, len(refs))
+	for i, r := range refs {
+		found[i] = files[r.entry].Symbols[r.symbol]
+	}
+	return found
+}
+
+// Files returns the indexed files, by path, reading all their details.
+func (idx *Index) Files() []*File {
+	return idx.details(idx.positions(nil))
+}
+
+// positions returns the positions of the entries whose path keep
+// accepts, or of all of them for a nil keep.
+func (idx *Index) positions(keep func(file string) bool) []int {
+	var ids []int
+	for i, e := range idx.entries {
+		if keep == nil || keep(e.Path) {
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+// NumFiles returns the number of indexed files.
+func (idx *Index) NumFiles() int {
+	return len(idx.entries)
+}
+
+// Len returns the number of symbols.
+func (idx *Index) Len() int {
+	n := 0
+	for _, e := range idx.entries {
+		n += len(e.Symbols)
+	}
+	return n
+}
+
+// Languages returns the languages of the indexed files, sorted.
+func (idx *Index) Languages() []string {
+	seen := make(map[string]bool)
+	var langs []string
+	for _, e := range idx.entries {
+		if !seen[e.Language] {
+			seen[e.Language] = true
+			langs = append(langs, e.Language)
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Names returns the name of every symbol, by file and line, without
+// reading any details.
+func (idx *Index) Names() []string {
+	var names []string
+	for _, e := range idx.entries {
+		for _, s := range e.Symbols {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// Symbols returns every symbol, by file and line.
+func (idx *Index) Symbols() []Symbol {
+	return idx.SymbolsIn(nil)
+}
+
+// SymbolsIn returns the symbols of the files whose path keep accepts,
+// reading the details of those files only.
+func (idx *Index) SymbolsIn(keep func(file string) bool) []Symbol {
+	var all []Symbol
+	for _, f := range idx.details(idx.positions(keep)) {
+		all = append(all, f.Symbols...)
+	}
+	return all
+}
+
+// Lookup returns the symbols named name: by their qualified name when it
+// has a container ("Class.method"), otherwise by their short name.
+func (idx *Index) Lookup(name string) []Symbol {
+	var refs []symbolRef
+	for _, r := range idx.byName[shortName(name)] {
+		if !strings.Contains(name, ".") || idx.entries[r.entry].Symbols[r.symbol].Name == name {
+			refs = append(refs, r)
+		}
+	}
+	return idx.resolve(refs)
+}
+
+// Find returns the symbols whose name contains query, ignoring case, the
+// way the structural index searches symbols.
+func (idx *Index) Find(query string) []Symbol {
+	query = strings.ToLower(query)
+	var refs []symbolRef
+	for i, e := range idx.entries {
+		for j, s := range e.Symbols {
+			if strings.Contains(strings.ToLower(s.Name), query) {
+				refs = append(refs, symbolRef{i, j})
+			}
+		}
+	}
+	return idx.resolve(refs)
+}
+
+// Directions accepted by Neighbors, as for callgraph.Build.
+const (
+	Callers = "callers"
+	Callees = "callees"
+	Both    = "both"
+)
+
+// Neighbors returns the functions calling (direction "callers") or called
+// by (direction "callees") the named function, or both. Calls match by
+// short name, and callees the index has no declaration for, such as
+// library functions, are left out. Only the calls of the files calling
+// the name or declaring it are read.
+func (idx *Index) Neighbors(name, direction string) []string {
+	short := shortName(name)
+	var ids []int
+	if direction != Callees {
+		ids = append(ids, idx.calledIn[short]...)
+	}
+	if direction != Callers {
+		for _, r := range idx.byName[short] {
+			ids = append(ids, r.entry)
+		}
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	seen := make(map[string]bool)
+	var names []string
+	add := func(n string) {
+		if !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	for _, f := range idx.details(ids) {
+		for _, c := range f.Calls {
+			if direction != Callees && c.Callee == short {
+				add(c.Caller)
+			}
+			if direction != Callers && (c.Caller == name || !strings.Contains(name, ".") && shortName(c.Caller) == name) && len(idx.byName[c.Callee]) > 0 {
+				add(c.Callee)
+			}
+		}
+	}
+	return names
+}
+
+// Imports returns the modules the file or module name imports (direction
+// "imports"), the files importing it (direction "imported_by"), or both.
+// Relative imports are resolved against the importing file, so
+// "./api/client" imported from src/app.ts is src/api/client; a file is
+// named with or without its extension.
+func (idx *Index) Imports(name, direction string) []string {
+	name = trimSourceExt(path.Clean(name))
+	seen := make(map[string]bool)
+	var names []string
+	add := func(n string) {
+		if !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	for _, e := range idx.entries {
+		file := trimSourceExt(e.Path)
+		for _, imp := range e.Imports {
+			target := resolveImport(e.Path, imp)
+			if direction != "imported_by" && file == name {
+				add(target)
+			}
+			if direction != "imports" && (target == name || imp == name) {
+				add(e.Path)
+			}
+		}
+	}
+	return names
+}
+
+// resolveImport returns a relative import as a project path without
+// extension, and other imports as written.
+func resolveImport(file, imp string) string {
+	if !strings.HasPrefix(imp, "./") && !strings.HasPrefix(imp, "../") {
+		return imp
+	}
+	return trimSourceExt(path.Join(path.Dir(file), imp))
+}
+
+// sourceExts are the extensions of the files parsed.
+var sourceExts = func() map[string]bool {
+	exts := make(map[string]bool)
+	for _, p := range Parsers() {
+		for _, ext := range p.Extensions() {
+			exts[ext] = true
+		}
+	}
+	return exts
+}()
+
+// trimSourceExt removes a source file extension from p, leaving others
+// such as the ".service" of "./user.service".
+func trimSourceExt(p string) string {
+	if ext := path.Ext(p); sourceExts[ext] {
+		return strings.TrimSuffix(p, ext)
+	}
+	return p
+}
@@ -0,0 +1,69 @@
+package symbols
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeProject writes files, by slash-separated path, under a temporary
+// directory and returns it.
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestIndexProjectConcurrency(t *testing.T) {
+	files := map[string]string{
+		"node_modules/lib/index.js": "export function vendored() {}\n",
+		".git/hooks/pre-commit.rb":  "def hook\nend\n",
+		"web/app.min.js":            "function minified(){}\n",
+		"README.md":                 "# Project\n",
+	}
+	for i := range 40 {
+		files[fmt.Sprintf("src/mod%02d.ts", i)] = fmt.Sprintf("export function f%d() { return g%d() }\n", i, i)
+		files[fmt.Sprintf("lib/mod%02d.rb", i)] = fmt.Sprintf("class M%d\n  def run\n    call%d\n  end\nend\n", i, i)
+	}
+	dir := writeProject(t, files)
+
+	index := func(workers int) *Index {
+		x := NewIndexer()
+		for _, p := range Parsers() {
+			x.RegisterParser(p)
+		}
+		x.SetConcurrency(workers)
+		x.SetCacheEnabled(false)
+		idx, err := x.IndexProject(dir)
+		if err != nil {
+			t.Fatalf("IndexProject with %d workers: %v", workers, err)
+		}
+		return idx
+	}
+
+	serial := index(1)
+	if len(serial.Files()) != 80 || serial.Len() != 120 {
+		t.Fatalf("got %d files and %d symbols, want 80 and 120", len(serial.Files()), serial.Len())
+	}
+	for _, f := range serial.Files() {
+		if f.Path == "node_modules/lib/index.js" || f.Path == ".git/hooks/pre-commit.rb" || f.Path == "web/app.min.js" {
+			t.Errorf("indexed %s", f.Path)
+		}
+	}
+	for _, workers := range []int{0, 4, 100} {
+		if got := index(workers); !reflect.DeepEqual(got.Files(), serial.Files()) {
+			t.Errorf("%d workers indexed differently from one", workers)
+		}
+	}
+}
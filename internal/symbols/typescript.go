@@ -0,0 +1,88 @@
+package symbols
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// NewTypeScriptParser returns a parser for TypeScript and JavaScript,
+// including JSX and TSX: functions, arrow functions assigned to
+// variables, classes and their methods, interfaces, type aliases, enums
+// and exported variables, with ES module imports and require calls.
+func NewTypeScriptParser() Parser {
+	return typeScriptParser{}
+}
+
+type typeScriptParser struct{}
+
+func (typeScriptParser) Extensions() []string {
+	return []string{".ts", ".tsx", ".mts", ".cts", ".js", ".jsx", ".mjs", ".cjs"}
+}
+
+func (typeScriptParser) Parse(file string, src []byte) *File {
+	lang := typeScript
+	switch path.Ext(file) {
+	case ".js", ".jsx", ".mjs", ".cjs":
+		lang.name = "javascript"
+	}
+	f := lang.parse(file, src)
+
+	// export { a, b as c } and export default a mark declarations above.
+	exported := make(map[string]bool)
+	for _, m := range tsExportList.FindAllStringSubmatch(typeScript.style.blank(string(src)), -1) {
+		for _, name := range strings.Split(m[1]+m[2], ",") {
+			name, _, _ = strings.Cut(strings.TrimSpace(name), " ")
+			exported[name] = true
+		}
+	}
+	for i := range f.Symbols {
+		if exported[f.Symbols[i].Name] {
+			f.Symbols[i].Exported = true
+		}
+	}
+	return f
+}
+
+const tsIdent = `[A-Za-z_$][\w$]*`
+
+// tsArrow matches what follows "=" when a function is assigned: a
+// function expression or an arrow function, possibly async or generic.
+const tsArrow = `=\s*(?:async\s+)?(?:function\b|(?:<[^=]*>\s*)?(?:\([^)]*\)|` + tsIdent + `)\s*(?::[^=]+)?=>|(?:<[^=]*>\s*)?\($)`
+
+var tsExportList = regexp.MustCompile(`(?m)^\s*export\s*(?:\{([^}]*)\}\s*;?\s*$|default\s+(` + tsIdent + `)\s*;?\s*$)`)
+
+var typeScript = language{
+	name: "typescript",
+	style: style{
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+	},
+	decls: []declaration{
+		{pattern: regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?class\s+(?P<name>` + tsIdent + `)`), kind: "class", container: true},
+		{pattern: regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?interface\s+(?P<name>` + tsIdent + `)`), kind: "interface", container: true},
+		{pattern: regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+(?P<name>` + tsIdent + `)`), kind: "enum"},
+		{pattern: regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?type\s+(?P<name>` + tsIdent + `)\s*(?:<[^=]*>)?\s*=`), kind: "type"},
+		{pattern: regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>` + tsIdent + `)`), kind: "function", function: true},
+		{pattern: regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+(?P<name>` + tsIdent + `)\s*(?::[^=]+)?` + tsArrow), kind: "function", function: true},
+		{pattern: regexp.MustCompile(`^(?:module\.)?exports\.(?P<name>` + tsIdent + `)\s*` + tsArrow), kind: "function", function: true},
+		{pattern: regexp.MustCompile(`^export\s+(?:const|let|var)\s+(?P<name>` + tsIdent + `)`), kind: "variable"},
+		// Class members: methods, and arrow functions assigned to fields.
+		{pattern: regexp.MustCompile(`^(?:(?:public|private|protected|static|readonly|override|abstract|declare|async|get|set)\s+)*\*?\s*(?P<name>#?` + tsIdent + `)\s*\??\s*(?:<[^>]*>)?\s*\(`), kind: "function", function: true, member: true},
+		{pattern: regexp.MustCompile(`^(?:(?:public|private|protected|static|readonly|override)\s+)*(?P<name>#?` + tsIdent + `)\s*(?::[^=]+)?` + tsArrow), kind: "function", function: true, member: true},
+	},
+	imports: []*regexp.Regexp{
+		regexp.MustCompile(`^\s*import\s+(?:type\s+)?(?:[^'"]*?\sfrom\s+)?['"]([^'"]+)['"]`),
+		regexp.MustCompile(`^\s*export\s+[^'"]*?\sfrom\s+['"]([^'"]+)['"]`),
+		regexp.MustCompile(`^\s*}\s*from\s+['"]([^'"]+)['"]`),
+		regexp.MustCompile(`\b(?:require|import)\(\s*['"]([^'"]+)['"]\s*\)`),
+	},
+	annotation:  regexp.MustCompile(`^@[\w$.]+(?:\([^)]*\))?`),
+	call:        defaultCall,
+	keywords:    words("if for while switch catch return function typeof instanceof new delete void async await yield super import require with do else case in of"),
+	newlineEnds: true,
+	exported: func(sig string) bool {
+		return strings.HasPrefix(sig, "export ") || strings.HasPrefix(sig, "exports.") || strings.HasPrefix(sig, "module.exports.")
+	},
+}
@@ -0,0 +1,219 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const tsSource = `import { Router } from "express";
+import type { User } from './models/user';
+import {
+  hash,
+  compare,
+} from "../lib/crypto";
+const fs = require('fs');
+
+/**
+ * Creates users.
+ */
+@Injectable()
+export class UserService {
+  private cache = new Map<string, User>();
+
+  constructor(private db: Database) {}
+
+  // Looks a user up, from the cache first.
+  async findUser(id: string): Promise<User> {
+    const label = "not a call(";
+    return this.cache.get(id) ?? this.load(id);
+  }
+
+  private load(id: string) {
+    return this.db.query(id);
+  }
+
+  handleClick = (event: Event) => {
+    this.findUser(event.id);
+  };
+}
+
+export interface Store {
+  read(key: string): string;
+}
+
+export type ID = string;
+
+export enum Color { Red, Green }
+
+export function createRouter(service: UserService): Router {
+  const router = Router();
+  router.get("/users/:id", (req, res) => service.findUser(req.params.id));
+  return router;
+}
+
+export const formatUser = (user: User) => user.name.trim();
+
+const helper = async (x) => {
+  formatUser(x);
+};
+
+function internal() {
+  if (true) {
+    helper(1);
+  }
+}
+
+export { internal };
+`
+
+func TestTypeScriptParser(t *testing.T) {
+	f := NewTypeScriptParser().Parse("src/users.ts", []byte(tsSource))
+	if f.Language != "typescript" {
+		t.Errorf("language = %q", f.Language)
+	}
+
+	want := []struct {
+		name, kind string
+		line       int
+		exported   bool
+	}{
+		{"UserService", "class", 13, true},
+		{"UserService.constructor", "method", 16, false},
+		{"UserService.findUser", "method", 19, false},
+		{"UserService.load", "method", 24, false},
+		{"UserService.handleClick", "method", 28, false},
+		{"Store", "interface", 33, true},
+		{"Store.read", "method", 34, false},
+		{"ID", "type", 37, true},
+		{"Color", "enum", 39, true},
+		{"createRouter", "function", 41, true},
+		{"formatUser", "function", 47, true},
+		{"helper", "function", 49, false},
+		{"internal", "function", 53, true},
+	}
+	if len(f.Symbols) != len(want) {
+		t.Errorf("got %d symbols, want %d: %+v", len(f.Symbols), len(want), f.Symbols)
+	}
+	for i, w := range want {
+		if i >= len(f.Symbols) {
+			break
+		}
+		s := f.Symbols[i]
+		if s.Name != w.name || s.Kind != w.kind || s.Line != w.line || s.Exported != w.exported {
+			t.Errorf("symbol %d = %s %s line %d exported %v, want %s %s line %d exported %v",
+				i, s.Kind, s.Name, s.Line, s.Exported, w.kind, w.name, w.line, w.exported)
+		}
+	}
+
+	class := f.Symbols[0]
+	if class.Doc != "Creates users." || !slices.Equal(class.Annotations, []string{"@Injectable()"}) {
+		t.Errorf("class doc %q, annotations %q", class.Doc, class.Annotations)
+	}
+	if f.Symbols[2].Doc != "Looks a user up, from the cache first." {
+		t.Errorf("method doc = %q", f.Symbols[2].Doc)
+	}
+	if sig := f.Symbols[2].Signature; sig != "async findUser(id: string): Promise<User>" {
+		t.Errorf("method signature = %q", sig)
+	}
+
+	wantImports := []string{"express", "./models/user", "../lib/crypto", "fs"}
+	if !slices.Equal(f.Imports, wantImports) {
+		t.Errorf("imports = %q, want %q", f.Imports, wantImports)
+	}
+
+	calls := make(map[string][]string)
+	for _, c := range f.Calls {
+		calls[c.Caller] = append(calls[c.Caller], c.Callee)
+	}
+	for caller, want := range map[string][]string{
+		"UserService.findUser":    {"get", "load"},
+		"UserService.load":        {"query"},
+		"UserService.handleClick": {"findUser"},
+		"createRouter":            {"Router", "get", "findUser"},
+		"formatUser":              {"trim"},
+		"helper":                  {"formatUser"},
+		"internal":                {"helper"},
+	} {
+		if !slices.Equal(calls[caller], want) {
+			t.Errorf("calls of %s = %q, want %q", caller, calls[caller], want)
+		}
+	}
+}
+
+func TestJavaScriptLanguage(t *testing.T) {
+	f := NewTypeScriptParser().Parse("web/app.jsx", []byte("export default function App() {\n  return <div/>;\n}\n"))
+	if f.Language != "javascript" || len(f.Symbols) != 1 || f.Symbols[0].Name != "App" {
+		t.Errorf("got %s %+v", f.Language, f.Symbols)
+	}
+}
+
+func TestIndexNeighborsAndImports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/users.ts":       tsSource,
+		"src/models/user.ts": "export interface User { id: string }\n",
+		"src/main.js":        "import { createRouter } from './users';\nfunction start() {\n  createRouter(null);\n}\n",
+		"node_modules/x.js":  "function ignored() {}\n",
+		"bundle.min.js":      "function ignored() {}\n",
+	}
+	for name, src := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Lookup("ignored"); len(got) != 0 {
+		t.Errorf("indexed skipped files: %+v", got)
+	}
+	if got := idx.Lookup("UserService.findUser"); len(got) != 1 || got[0].File != "src/users.ts" {
+		t.Errorf("Lookup(UserService.findUser) = %+v", got)
+	}
+	if got := idx.Lookup("findUser"); len(got) != 1 {
+		t.Errorf("Lookup(findUser) = %+v", got)
+	}
+
+	tests := []struct {
+		name, direction string
+		want            []string
+	}{
+		{"createRouter", Callers, []string{"start"}},
+		{"findUser", Callers, []string{"UserService.handleClick", "createRouter"}},
+		// Library calls (Router, get) are left out.
+		{"createRouter", Callees, []string{"findUser"}},
+		{"UserService.findUser", Callees, []string{"load"}},
+		{"internal", Both, []string{"helper"}},
+	}
+	for _, tt := range tests {
+		if got := idx.Neighbors(tt.name, tt.direction); !slices.Equal(got, tt.want) {
+			t.Errorf("Neighbors(%s, %s) = %q, want %q", tt.name, tt.direction, got, tt.want)
+		}
+	}
+
+	if got := idx.Imports("src/users.ts", "imported_by"); !slices.Equal(got, []string{"src/main.js"}) {
+		t.Errorf("importers of src/users.ts = %q", got)
+	}
+	if got := idx.Imports("src/models/user", "imported_by"); !slices.Equal(got, []string{"src/users.ts"}) {
+		t.Errorf("importers of src/models/user = %q", got)
+	}
+	if got := idx.Imports("src/main.js", "imports"); !slices.Equal(got, []string{"src/users"}) {
+		t.Errorf("imports of src/main.js = %q", got)
+	}
+}
+
+func TestRelevant(t *testing.T) {
+	f := NewTypeScriptParser().Parse("src/users.ts", []byte(tsSource))
+	got := Relevant(f.Symbols, "fix the find user lookup in the user service", 3)
+	if len(got) == 0 || got[0].Name != "UserService.findUser" {
+		t.Errorf("Relevant = %+v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package testmap
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// goUnits returns the functions, methods and types of a Go file with the
+// calls in their bodies. Calls into other packages of module resolve to
+// those packages; method calls resolve to the file's own package and the
+// module packages it imports, since receivers are not typed.
+func goUnits(file string, src []byte, module string) []*unit {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	dir := path.Dir(file)
+	isTest := strings.HasSuffix(file, "_test.go")
+
+	imports := make(map[string]string) // local name to package directory
+	dirs := []string{dir}
+	for _, spec := range f.Imports {
+		p := strings.Trim(spec.Path.Value, `"`)
+		local := p[strings.LastIndex(p, "/")+1:]
+		if spec.Name != nil {
+			local = spec.Name.Name
+		}
+		rel, ok := strings.CutPrefix(p, module+"/")
+		if module == "" || !ok {
+			imports[local] = "" // outside the module: never resolved
+			continue
+		}
+		imports[local] = rel
+		dirs = append(dirs, rel)
+	}
+
+	var units []*unit
+	add := func(name string, pos token.Pos, body ast.Node) *unit {
+		u := &unit{
+			sym:    Symbol{Name: name, File: file, Line: fset.Position(pos).Line, Language: "go"},
+			dir:    dir,
+			helper: isTest,
+		}
+		if body != nil {
+			u.calls = goCalls(body, dir, dirs, imports)
+		}
+		units = append(units, u)
+		return u
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverType(d.Recv.List[0].Type) + "." + name
+			}
+			u := add(name, d.Pos(), d.Body)
+			if isTest && d.Recv == nil && goTestName(name) {
+				u.test, u.helper, u.id = true, false, name
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				add(ts.Name.Name, ts.Pos(), nil)
+			}
+		}
+	}
+	return units
+}
+
+// goCalls returns the calls made in body, and composite literals of
+// named types, which exercise the type.
+func goCalls(body ast.Node, dir string, dirs []string, imports map[string]string) []call {
+	var calls []call
+	ast.Inspect(body, func(n ast.Node) bool {
+		var fun ast.Expr
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			fun = n.Fun
+		case *ast.CompositeLit:
+			fun = n.Type
+		default:
+			return true
+		}
+		if idx, ok := fun.(*ast.IndexExpr); ok { // generic instantiation
+			fun = idx.X
+		}
+		switch fn := fun.(type) {
+		case *ast.Ident:
+			calls = append(calls, call{name: fn.Name, dirs: []string{dir}})
+		case *ast.SelectorExpr:
+			if x, ok := fn.X.(*ast.Ident); ok {
+				if pkg, imported := imports[x.Name]; imported {
+					if pkg != "" {
+						calls = append(calls, call{name: fn.Sel.Name, dirs: []string{pkg}})
+					}
+					return true
+				}
+			}
+			calls = append(calls, call{name: fn.Sel.Name, dirs: dirs, method: true})
+		}
+		return true
+	})
+	return calls
+}
+
+// goTestName reports whether name is a test function name as go test
+// sees it: "Test" followed by nothing or a non-lowercase letter.
+// TestMain is not a test.
+func goTestName(name string) bool {
+	rest, ok := strings.CutPrefix(name, "Test")
+	if !ok || name == "TestMain" {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return !unicode.IsLower(r)
+}
+
+// receiverType returns the type name of a method receiver.
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package testmap
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	pythonDecl = regexp.MustCompile(`^(\s*)(?:async\s+)?(def|class)\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	pythonCall = regexp.MustCompile(`(\.)?\b([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+)
+
+// pythonUnits returns the functions, classes and methods of a Python file
+// with the calls in their bodies. Bodies end at the next line indented no
+// deeper than the def or class; functions nested in functions belong to
+// their parent. Calls resolve by name across the project.
+func pythonUnits(file, src string) []*unit {
+	base := path.Base(file)
+	testFile := strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py") || base == "conftest.py"
+
+	type open struct {
+		u      *unit
+		indent int
+		class  bool
+	}
+	var (
+		units []*unit
+		stack []open
+	)
+	for i, line := range strings.Split(src, "\n") {
+		code := line
+		if j := strings.IndexByte(code, '#'); j >= 0 {
+			code = code[:j]
+		}
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		indent := len(code) - len(strings.TrimLeft(code, " \t"))
+		for len(stack) > 0 && indent <= stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		m := pythonDecl.FindStringSubmatch(code)
+		if m == nil || (len(stack) > 0 && !stack[len(stack)-1].class) {
+			if len(stack) > 0 {
+				u := stack[len(stack)-1].u
+				u.calls = append(u.calls, pythonCalls(code)...)
+			}
+			continue
+		}
+
+		name, class := m[3], m[2] == "class"
+		inTestClass := false
+		if len(stack) > 0 {
+			outer := stack[len(stack)-1].u
+			inTestClass = outer.test
+			name = outer.sym.Name + "." + name
+		}
+		u := &unit{
+			sym:    Symbol{Name: name, File: file, Line: i + 1, Language: "python"},
+			helper: testFile,
+		}
+		if testFile && base != "conftest.py" {
+			short := m[3]
+			switch {
+			case class:
+				u.test = strings.HasPrefix(short, "Test") && len(stack) == 0
+			case len(stack) == 0 || inTestClass:
+				u.test = strings.HasPrefix(short, "test")
+			}
+			if u.test {
+				u.helper = false
+				u.id = file + "::" + strings.ReplaceAll(name, ".", "::")
+			}
+		}
+		units = append(units, u)
+		stack = append(stack, open{u: u, indent: indent, class: class})
+	}
+
+	// A test class collects tests; run those rather than the class.
+	var out []*unit
+	for _, u := range units {
+		if u.test && !strings.Contains(u.sym.Name, ".") && hasTests(units, u.sym.Name) {
+			u.test, u.helper = false, true
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+func hasTests(units []*unit, class string) bool {
+	for _, u := range units {
+		if u.test && strings.HasPrefix(u.sym.Name, class+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// pythonCalls returns the calls on a line of code; method calls
+// ("obj.name(") are told apart from plain ones.
+func pythonCalls(code string) []call {
+	var calls []call
+	for _, m := range pythonCall.FindAllStringSubmatch(code, -1) {
+		calls = append(calls, call{name: m[2], method: m[1] != ""})
+	}
+	return calls
+}
@@ -0,0 +1,421 @@
+// Package testmap links tests (Go TestXxx functions, pytest tests) to the
+// production functions and types they exercise, so after editing a
+// function the agent knows which tests to run.
+//
+// Links come from a syntactic call graph: functions a test calls, and
+// what those call in turn up to MaxDepth, plus naming conventions
+// (TestParse and test_parse cover parse; TestT_M covers T.M) and file
+// pairs (foo_test.go covers foo.go, test_foo.py covers foo.py).
+package testmap
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MaxDepth is how many calls away from a test a function still counts as
+// covered by it.
+const MaxDepth = 3
+
+// Reasons a test covers a symbol, strongest first.
+const (
+	ReasonName  = "name"  // the test is named after the symbol
+	ReasonCalls = "calls" // the test calls the symbol, directly or not
+	ReasonFile  = "file"  // the test file is named after the symbol's file
+)
+
+// Symbol is a production function, method or type.
+type Symbol struct {
+	Name     string `json:"name"` // "Parse", "Type.Method", "Class.method"
+	File     string `json:"file"` // slash-separated, relative to the project
+	Line     int    `json:"line"`
+	Language string `json:"language"`
+}
+
+// Test is a test function.
+type Test struct {
+	Name     string `json:"name"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Language string `json:"language"`
+	// ID selects the test for its runner: the Go function name, or the
+	// pytest node ID (tests/test_x.py::TestY::test_z).
+	ID string `json:"id"`
+}
+
+// Coverage is a test covering a symbol.
+type Coverage struct {
+	Test   Test   `json:"test"`
+	Reason string `json:"reason"`
+	// Depth is the number of calls from the test to the symbol: 1 for a
+	// direct call, 0 for links by name or file.
+	Depth int `json:"depth"`
+}
+
+// unit is a function, method or type found by a language front end, and
+// the calls made in its body.
+type unit struct {
+	sym    Symbol
+	dir    string // Go package directory; "" for Python
+	test   bool   // a test function
+	helper bool   // a non-test function of a test file
+	id     string // for tests
+	calls  []call
+}
+
+// call is a call made by a unit. A call resolves to the units named name
+// (the last part of their name for methods) in one of dirs, or anywhere
+// when dirs is nil.
+type call struct {
+	name   string
+	dirs   []string
+	method bool
+}
+
+// Map holds the tests of a project and the symbols they cover.
+type Map struct {
+	Tests   []Test
+	Symbols []Symbol
+	covers  map[Symbol][]Coverage
+}
+
+// Build parses the Go and Python files of the project and links its
+// tests to the symbols they cover.
+func Build(projectPath string) (*Map, error) {
+	module := modulePath(projectPath)
+	var units []*unit
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != projectPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(p)
+		if ext != ".go" && ext != ".py" {
+			return nil
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(projectPath, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if ext == ".go" {
+			units = append(units, goUnits(rel, src, module)...)
+		} else {
+			units = append(units, pythonUnits(rel, string(src))...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("map tests: %w", err)
+	}
+	return link(units), nil
+}
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"venv":         true,
+	"__pycache__":  true,
+	"testdata":     true,
+}
+
+// link resolves the calls of units and walks them from every test.
+func link(units []*unit) *Map {
+	m := &Map{covers: make(map[Symbol][]Coverage)}
+	byName := make(map[string][]*unit)
+	for _, u := range units {
+		last := u.sym.Name[strings.LastIndex(u.sym.Name, ".")+1:]
+		byName[last] = append(byName[last], u)
+		if !u.test && !u.helper {
+			m.Symbols = append(m.Symbols, u.sym)
+		}
+	}
+	resolve := func(from *unit, c call) []*unit {
+		var out []*unit
+		for _, u := range byName[c.name] {
+			if u == from || u.test || u.sym.Language != from.sym.Language {
+				continue
+			}
+			// Plain calls reach functions and types. Method calls reach
+			// methods, and in Python also module functions ("mod.f()").
+			qualified := strings.Contains(u.sym.Name, ".")
+			if !c.method && qualified || c.method && !qualified && u.sym.Language == "go" {
+				continue
+			}
+			if c.dirs != nil && !contains(c.dirs, u.dir) {
+				continue
+			}
+			out = append(out, u)
+		}
+		return out
+	}
+
+	for _, t := range units {
+		if !t.test {
+			continue
+		}
+		test := Test{Name: t.sym.Name, File: t.sym.File, Line: t.sym.Line, Language: t.sym.Language, ID: t.id}
+		m.Tests = append(m.Tests, test)
+		covered := make(map[Symbol]Coverage)
+		cover := func(s Symbol, reason string, depth int) {
+			if prev, ok := covered[s]; ok && !stronger(reason, depth, prev) {
+				return
+			}
+			covered[s] = Coverage{Test: test, Reason: reason, Depth: depth}
+		}
+
+		// Breadth-first through the calls, up to MaxDepth.
+		seen := map[*unit]bool{t: true}
+		frontier := []*unit{t}
+		for depth := 1; depth <= MaxDepth && len(frontier) > 0; depth++ {
+			var next []*unit
+			for _, u := range frontier {
+				for _, c := range u.calls {
+					for _, callee := range resolve(u, c) {
+						if seen[callee] {
+							continue
+						}
+						seen[callee] = true
+						next = append(next, callee)
+						if !callee.helper {
+							cover(callee.sym, ReasonCalls, depth)
+						}
+					}
+				}
+			}
+			frontier = next
+		}
+
+		for _, u := range units {
+			if u.test || u.helper || u.sym.Language != t.sym.Language {
+				continue
+			}
+			if namedAfter(t, u) {
+				cover(u.sym, ReasonName, 0)
+			} else if pairedFiles(t.sym.File, u.sym.File) {
+				cover(u.sym, ReasonFile, 0)
+			}
+		}
+		for s, c := range covered {
+			m.covers[s] = append(m.covers[s], c)
+		}
+	}
+	for s := range m.covers {
+		sortCoverage(m.covers[s])
+	}
+	return m
+}
+
+// stronger reports whether a link by reason at depth beats prev.
+func stronger(reason string, depth int, prev Coverage) bool {
+	rank := map[string]int{ReasonName: 0, ReasonCalls: 1, ReasonFile: 2}
+	if rank[reason] != rank[prev.Reason] {
+		return rank[reason] < rank[prev.Reason]
+	}
+	return depth < prev.Depth
+}
+
+func sortCoverage(covs []Coverage) {
+	sort.SliceStable(covs, func(i, j int) bool {
+		a, b := covs[i], covs[j]
+		if a.Reason != b.Reason || a.Depth != b.Depth {
+			return stronger(a.Reason, a.Depth, b)
+		}
+		if a.Test.File != b.Test.File {
+			return a.Test.File < b.Test.File
+		}
+		return a.Test.Line < b.Test.Line
+	})
+}
+
+// namedAfter reports whether test t is named after symbol u: TestParse or
+// test_parse for Parse/parse, TestT_M for T.M, test_m in class TestT for
+// T.m. Go tests must be in u's package.
+func namedAfter(t, u *unit) bool {
+	if t.sym.Language == "go" {
+		if t.dir != u.dir {
+			return false
+		}
+		name := strings.TrimPrefix(t.sym.Name, "Test")
+		return name == u.sym.Name || strings.ReplaceAll(name, "_", ".") == u.sym.Name
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(t.sym.Name, "test_"), "test")
+	if class, method, ok := strings.Cut(t.sym.Name, "."); ok {
+		name = strings.TrimPrefix(class, "Test") + "." + strings.TrimPrefix(strings.TrimPrefix(method, "test_"), "test")
+	}
+	return squash(name) == squash(u.sym.Name)
+}
+
+// squash compares Python names across snake and camel case.
+func squash(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// pairedFiles reports whether testFile is the test file of file:
+// foo_test.go for foo.go, test_foo.py or foo_test.py for foo.py.
+func pairedFiles(testFile, file string) bool {
+	base := path.Base(testFile)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	want := strings.TrimSuffix(stem, "_test")
+	if ext == ".py" {
+		want = strings.TrimPrefix(want, "test_")
+	}
+	if path.Base(file) != want+ext {
+		return false
+	}
+	// Go tests sit with their package; Python tests often in tests/.
+	return ext != ".go" || path.Dir(testFile) == path.Dir(file)
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// TestsFor returns the tests covering the symbols named name, strongest
+// link first. name may be a bare function or method name or qualified
+// ("Type.Method").
+func (m *Map) TestsFor(name string) []Coverage {
+	var syms []Symbol
+	for _, s := range m.Symbols {
+		if s.Name == name || strings.HasSuffix(s.Name, "."+name) {
+			syms = append(syms, s)
+		}
+	}
+	return m.merge(syms)
+}
+
+// TestsForFiles returns the tests covering the symbols declared in files,
+// and the tests declared in them, for checking a change to those files.
+func (m *Map) TestsForFiles(files []string) []Coverage {
+	set := make(map[string]bool, len(files))
+	for _, f := range files {
+		set[filepath.ToSlash(f)] = true
+	}
+	var syms []Symbol
+	for _, s := range m.Symbols {
+		if set[s.File] {
+			syms = append(syms, s)
+		}
+	}
+	covs := m.merge(syms)
+	seen := make(map[Test]bool)
+	for _, c := range covs {
+		seen[c.Test] = true
+	}
+	for _, t := range m.Tests {
+		if set[t.File] && !seen[t] {
+			covs = append(covs, Coverage{Test: t, Reason: ReasonFile})
+		}
+	}
+	return covs
+}
+
+// merge collects the tests covering any of syms, once each with its
+// strongest link.
+func (m *Map) merge(syms []Symbol) []Coverage {
+	best := make(map[Test]Coverage)
+	var order []Test
+	for _, s := range syms {
+		for _, c := range m.covers[s] {
+			prev, ok := best[c.Test]
+			if !ok {
+				order = append(order, c.Test)
+			}
+			if !ok || stronger(c.Reason, c.Depth, prev) {
+				best[c.Test] = c
+			}
+		}
+	}
+	covs := make([]Coverage, len(order))
+	for i, t := range order {
+		covs[i] = best[t]
+	}
+	sortCoverage(covs)
+	return covs
+}
+
+// Commands returns the commands running exactly the given tests: one
+// `go test` per package and one pytest invocation.
+func Commands(covs []Coverage) []string {
+	goTests := make(map[string][]string)
+	var dirs, pytest []string
+	for _, c := range covs {
+		switch c.Test.Language {
+		case "go":
+			dir := path.Dir(c.Test.File)
+			if _, ok := goTests[dir]; !ok {
+				dirs = append(dirs, dir)
+			}
+			if !contains(goTests[dir], c.Test.ID) {
+				goTests[dir] = append(goTests[dir], c.Test.ID)
+			}
+		case "python":
+			if !contains(pytest, c.Test.ID) {
+				pytest = append(pytest, c.Test.ID)
+			}
+		}
+	}
+	var cmds []string
+	for _, dir := range dirs {
+		cmds = append(cmds, fmt.Sprintf("go test ./%s -run '^(%s)$'", strings.TrimPrefix(dir, "./"), strings.Join(goTests[dir], "|")))
+	}
+	if len(pytest) > 0 {
+		cmds = append(cmds, "pytest "+strings.Join(pytest, " "))
+	}
+	return cmds
+}
+
+// Format renders coverage one test per line, with how it was linked.
+func Format(covs []Coverage) string {
+	var b strings.Builder
+	for _, c := range covs {
+		how := c.Reason
+		switch {
+		case c.Reason == ReasonCalls && c.Depth == 1:
+			how = "calls it"
+		case c.Reason == ReasonCalls:
+			how = fmt.Sprintf("calls it %d levels down", c.Depth)
+		case c.Reason == ReasonName:
+			how = "named after it"
+		case c.Reason == ReasonFile:
+			how = "test file of its file"
+		}
+		fmt.Fprintf(&b, "%s:%d  %s  (%s)\n", c.Test.File, c.Test.Line, c.Test.Name, how)
+	}
+	return b.String()
+}
+
+// modulePath reads the module path from the project's go.mod, or returns
+// "" without one.
+func modulePath(projectPath string) string {
+	data, err := os.ReadFile(filepath.Join(projectPath, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,204 @@
+package textsearch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// Options controls a search.
+type Options struct {
+	// Regex treats the pattern as a Go regular expression instead of a
+	// literal string.
+	Regex bool
+	// IgnoreCase matches regardless of case.
+	IgnoreCase bool
+	// MaxResults stops the search after this many matches; 0 means no
+	// limit.
+	MaxResults int
+	// Dirs limits the search to files under these slash-separated
+	// directories, relative to the project. Empty searches every file.
+	Dirs []string
+	// Include, when set, limits the search to the files it accepts, given
+	// their slash-separated path relative to the project.
+	Include func(file string) bool
+}
+
+// Match is a line containing a match.
+type Match struct {
+	File   string `json:"file"` // slash-separated, relative to the project
+	Line   int    `json:"line"`
+	Column int    `json:"column"` // byte offset of the match in the line, from 1
+	Text   string `json:"text"`
+}
+
+// String renders the match as "file:line:column: text".
+func (m Match) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", m.File, m.Line, m.Column, m.Text)
+}
+
+// Search returns the lines matching pattern, ordered by file and line.
+// Patterns are matched against single lines, so a regular expression
+// cannot match across a line break.
+func (idx *Index) Search(pattern string, opts Options) ([]Match, error) {
+	expr := pattern
+	if !opts.Regex {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	parsed, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	matches := []Match{}
+	for _, i := range idx.candidates(required(parsed.Simplify())) {
+		f := idx.files[i]
+		if !inDirs(f.Path, opts.Dirs) || (opts.Include != nil && !opts.Include(f.Path)) {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(idx.root, filepath.FromSlash(f.Path)))
+		if err != nil {
+			// Deleted since the index was opened.
+			continue
+		}
+		for n, line := range strings.Split(string(src), "\n") {
+			loc := re.FindStringIndex(line)
+			if loc == nil {
+				continue
+			}
+			matches = append(matches, Match{File: f.Path, Line: n + 1, Column: loc[0] + 1, Text: strings.TrimRight(line, "\r")})
+			if opts.MaxResults > 0 && len(matches) >= opts.MaxResults {
+				return matches, nil
+			}
+		}
+	}
+	return matches, nil
+}
+
+func inDirs(path string, dirs []string) bool {
+	if len(dirs) == 0 {
+		return true
+	}
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// candidates returns the files containing every trigram of every literal,
+// or all files when no literal is long enough to narrow the search.
+func (idx *Index) candidates(literals []string) []int {
+	var (
+		result []int
+		narrow bool
+	)
+	for _, lit := range literals {
+		for i := 0; i+3 <= len(lit); i++ {
+			t, _ := trigram([]byte(lit[i : i+3]))
+			posting := idx.postings[t]
+			if !narrow {
+				result, narrow = posting, true
+			} else {
+				result = intersect(result, posting)
+			}
+			if len(result) == 0 {
+				return nil
+			}
+		}
+	}
+	if !narrow {
+		result = make([]int, len(idx.files))
+		for i := range result {
+			result[i] = i
+		}
+	}
+	return result
+}
+
+func intersect(a, b []int) []int {
+	var out []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// required returns literal strings any match of re must contain. It is
+// conservative: alternations and optional parts contribute nothing.
+// Case-insensitive literals are kept only when ASCII, since the index
+// folds ASCII case alone.
+func required(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if lit, ok := literal(re); ok {
+			return []string{lit}
+		}
+	case syntax.OpCapture, syntax.OpPlus:
+		return required(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return required(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var (
+			out []string
+			run strings.Builder
+		)
+		flush := func() {
+			if run.Len() > 0 {
+				out = append(out, run.String())
+				run.Reset()
+			}
+		}
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				if lit, ok := literal(sub); ok {
+					run.WriteString(lit)
+					continue
+				}
+			}
+			flush()
+			out = append(out, required(sub)...)
+		}
+		flush()
+		return out
+	}
+	return nil
+}
+
+// literal returns the text of a literal node, lowercased when it is
+// case-insensitive.
+func literal(re *syntax.Regexp) (string, bool) {
+	s := string(re.Rune)
+	if re.Flags&syntax.FoldCase == 0 {
+		return s, true
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return "", false
+		}
+	}
+	return strings.ToLower(s), true
+}
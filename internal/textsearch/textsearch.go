@@ -0,0 +1,231 @@
+// Package textsearch answers literal and regular expression searches over
+// the text files of a project from a trigram index kept in .index, so a
+// search reads only the files that can contain a match.
+//
+// Trigrams are recorded case-folded; the index narrows the candidate files
+// and each candidate is then matched line by line, so results are exact.
+package textsearch
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// indexVersion is bumped whenever the persisted format changes.
+const indexVersion = 1
+
+// maxFileSize is the size above which files are left out of the index.
+const maxFileSize = 1 << 20
+
+// Path returns the text index file of the project at projectPath.
+func Path(projectPath string) string {
+	return filepath.Join(projectPath, ".index", "text_index.gob")
+}
+
+// file is an indexed file and the trigrams it contains.
+type file struct {
+	Path     string // slash-separated, relative to the project
+	Size     int64
+	ModTime  time.Time
+	Trigrams []uint32 // sorted
+}
+
+// Index is the trigram index of a project.
+type Index struct {
+	root  string
+	files []file
+	// postings maps each trigram to the positions in files of the files
+	// containing it, in increasing order.
+	postings map[uint32][]int
+}
+
+type persisted struct {
+	Version int
+	Files   []file
+}
+
+// Open loads the project's index, brings it up to date with the files on
+// disk and saves it if anything changed. A missing or unreadable index is
+// rebuilt from scratch.
+func Open(projectPath string) (*Index, error) {
+	return open(projectPath, false)
+}
+
+// Rebuild indexes every file of the project again, ignoring the saved
+// index.
+func Rebuild(projectPath string) (*Index, error) {
+	return open(projectPath, true)
+}
+
+func open(projectPath string, rebuild bool) (*Index, error) {
+	previous := make(map[string]file)
+	if !rebuild {
+		if saved, err := load(Path(projectPath)); err == nil {
+			for _, f := range saved {
+				previous[f.Path] = f
+			}
+		}
+	}
+
+	var (
+		files   []file
+		changed = rebuild
+	)
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != projectPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxFileSize {
+			return nil
+		}
+		rel, err := filepath.Rel(projectPath, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if f, ok := previous[rel]; ok && f.Size == info.Size() && f.ModTime.Equal(info.ModTime()) {
+			files = append(files, f)
+			delete(previous, rel)
+			return nil
+		}
+		changed = true
+		delete(previous, rel)
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if binary(src) {
+			return nil
+		}
+		files = append(files, file{Path: rel, Size: info.Size(), ModTime: info.ModTime(), Trigrams: trigrams(src)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan project: %w", err)
+	}
+	if len(previous) > 0 {
+		changed = true // files were deleted
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	idx := &Index{root: projectPath, files: files, postings: make(map[uint32][]int)}
+	for i, f := range files {
+		for _, t := range f.Trigrams {
+			idx.postings[t] = append(idx.postings[t], i)
+		}
+	}
+	if changed {
+		if err := idx.save(); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// Files returns the number of indexed files.
+func (idx *Index) Files() int {
+	return len(idx.files)
+}
+
+func load(path string) ([]file, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p persisted
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&p); err != nil {
+		return nil, fmt.Errorf("decode text index: %w", err)
+	}
+	if p.Version != indexVersion {
+		return nil, errors.New("text index has an old format")
+	}
+	return p.Files, nil
+}
+
+// save writes the index to a temporary file and renames it into place, so
+// a concurrent search never reads a partial index.
+func (idx *Index) save() error {
+	path := Path(idx.root)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create index directory: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(persisted{Version: indexVersion, Files: idx.files}); err != nil {
+		return fmt.Errorf("encode text index: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write text index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write text index: %w", err)
+	}
+	return nil
+}
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"venv":         true,
+	"__pycache__":  true,
+}
+
+// binary reports whether src looks like a binary file: a NUL byte in its
+// first 8KB, as git decides.
+func binary(src []byte) bool {
+	return bytes.IndexByte(src[:min(len(src), 8192)], 0) >= 0
+}
+
+// trigrams returns the distinct case-folded trigrams of src, sorted.
+// Trigrams spanning a line break are left out, since matches never span
+// lines.
+func trigrams(src []byte) []uint32 {
+	seen := make(map[uint32]bool)
+	for i := 0; i+3 <= len(src); i++ {
+		if t, ok := trigram(src[i : i+3]); ok {
+			seen[t] = true
+		}
+	}
+	out := make([]uint32, 0, len(seen))
+	for t := range seen {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func trigram(b []byte) (uint32, bool) {
+	var t uint32
+	for _, c := range b[:3] {
+		if c == '\n' {
+			return 0, false
+		}
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		t = t<<8 | uint32(c)
+	}
+	return t, true
+}
@@ -0,0 +1,84 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yourorg/agent/internal/proxy"
+)
+
+// GitHubIssues reads issues through the GitHub REST API.
+type GitHubIssues struct {
+	token   string
+	repo    string
+	baseURL string
+	client  *http.Client
+}
+
+// NewGitHubIssues creates a GitHub issues client for repo ("owner/name").
+// baseURL defaults to https://api.github.com; the token may be empty for
+// public repositories.
+func NewGitHubIssues(token, repo, baseURL string) (*GitHubIssues, error) {
+	if strings.Count(repo, "/") != 1 {
+		return nil, fmt.Errorf("GitHub repository must be owner/name, got %q", repo)
+	}
+	if baseURL == "" {
+		baseURL = "https://api.github.com"
+	}
+	return &GitHubIssues{
+		token:   token,
+		repo:    repo,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  proxy.Client("github", 0),
+	}, nil
+}
+
+type githubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+type githubComment struct {
+	Body string `json:"body"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// Fetch loads the issue with the given number.
+func (c *GitHubIssues) Fetch(ctx context.Context, key string) (*Issue, error) {
+	headers := map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	if c.token != "" {
+		headers["Authorization"] = "Bearer " + c.token
+	}
+
+	base := fmt.Sprintf("%s/repos/%s/issues/%s", c.baseURL, c.repo, key)
+
+	var gi githubIssue
+	if err := doJSON(ctx, c.client, "GET", base, headers, nil, &gi); err != nil {
+		return nil, fmt.Errorf("github: %w", err)
+	}
+
+	var comments []githubComment
+	if err := doJSON(ctx, c.client, "GET", base+"/comments?per_page=100", headers, nil, &comments); err != nil {
+		return nil, fmt.Errorf("github: %w", err)
+	}
+
+	issue := &Issue{
+		Key:         fmt.Sprintf("%s#%d", c.repo, gi.Number),
+		Title:       gi.Title,
+		Description: gi.Body,
+		URL:         gi.HTMLURL,
+	}
+	for _, cm := range comments {
+		issue.Comments = append(issue.Comments, Comment{Author: cm.User.Login, Body: cm.Body})
+	}
+	return issue, nil
+}
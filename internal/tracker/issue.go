@@ -0,0 +1,155 @@
+// Package tracker fetches issues from Jira, Linear and GitHub so they can be
+// used as agent task descriptions.
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Issue is a tracker-agnostic view of an issue.
+type Issue struct {
+	Key         string
+	Title       string
+	Description string
+	Comments    []Comment
+	URL         string
+}
+
+// Comment is a single issue comment.
+type Comment struct {
+	Author string
+	Body   string
+}
+
+// Fetcher loads an issue by its tracker-specific key.
+type Fetcher interface {
+	Fetch(ctx context.Context, key string) (*Issue, error)
+}
+
+// Ref identifies an issue in a particular tracker.
+type Ref struct {
+	Tracker string // "jira", "linear" or "github"
+	Key     string // "ABC-123" for Jira/Linear, the issue number for GitHub
+	Repo    string // "owner/repo" for GitHub, when given explicitly
+}
+
+var (
+	keyPattern         = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*-\d+$`)
+	githubRefPattern   = regexp.MustCompile(`^(?:([\w.-]+/[\w.-]+))?#(\d+)$`)
+	githubURLPattern   = regexp.MustCompile(`^https?://[^/]+/([\w.-]+/[\w.-]+)/issues/(\d+)`)
+	jiraURLPattern     = regexp.MustCompile(`^https?://[^/]+/browse/([A-Za-z][A-Za-z0-9]*-\d+)`)
+	linearURLPattern   = regexp.MustCompile(`^https?://linear\.app/[^/]+/issue/([A-Za-z][A-Za-z0-9]*-\d+)`)
+	codeSpanPattern    = regexp.MustCompile("`([^`\n]{3,80})`")
+	trackerPrefixNames = []string{"jira", "linear", "github", "gh"}
+)
+
+// ParseRef parses an issue reference. Accepted forms:
+//
+//	jira:ABC-123, linear:ENG-42, github:owner/repo#7, gh:7, #7, owner/repo#7,
+//	issue URLs from any of the three trackers, or a bare ABC-123 key, which
+//	is resolved to defaultTracker.
+func ParseRef(s, defaultTracker string) (Ref, error) {
+	s = strings.TrimSpace(s)
+
+	for _, prefix := range trackerPrefixNames {
+		if rest, ok := strings.CutPrefix(s, prefix+":"); ok {
+			name := prefix
+			if name == "gh" {
+				name = "github"
+			}
+			if name == "github" && !strings.Contains(rest, "#") {
+				rest = "#" + rest
+			}
+			ref, err := ParseRef(rest, name)
+			if err != nil {
+				return Ref{}, err
+			}
+			if ref.Tracker != name {
+				return Ref{}, fmt.Errorf("invalid %s issue reference %q", name, s)
+			}
+			return ref, nil
+		}
+	}
+
+	if m := githubURLPattern.FindStringSubmatch(s); m != nil {
+		return Ref{Tracker: "github", Repo: m[1], Key: m[2]}, nil
+	}
+	if m := linearURLPattern.FindStringSubmatch(s); m != nil {
+		return Ref{Tracker: "linear", Key: strings.ToUpper(m[1])}, nil
+	}
+	if m := jiraURLPattern.FindStringSubmatch(s); m != nil {
+		return Ref{Tracker: "jira", Key: strings.ToUpper(m[1])}, nil
+	}
+	if m := githubRefPattern.FindStringSubmatch(s); m != nil {
+		return Ref{Tracker: "github", Repo: m[1], Key: m[2]}, nil
+	}
+	if keyPattern.MatchString(s) {
+		switch defaultTracker {
+		case "jira", "linear":
+			return Ref{Tracker: defaultTracker, Key: strings.ToUpper(s)}, nil
+		case "":
+			return Ref{}, fmt.Errorf("cannot tell whether %q is a Jira or Linear issue; prefix it with jira: or linear:", s)
+		}
+	}
+	if _, err := strconv.Atoi(s); err == nil && defaultTracker == "github" {
+		return Ref{Tracker: "github", Key: s}, nil
+	}
+	return Ref{}, fmt.Errorf("unrecognized issue reference %q", s)
+}
+
+// Prompt renders the issue as an agent task description.
+func (i *Issue) Prompt() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n", i.Key, i.Title)
+	if i.URL != "" {
+		fmt.Fprintf(&b, "%s\n", i.URL)
+	}
+	if desc := strings.TrimSpace(i.Description); desc != "" {
+		fmt.Fprintf(&b, "\n%s\n", desc)
+	}
+	if len(i.Comments) > 0 {
+		b.WriteString("\nComments:\n")
+		for _, c := range i.Comments {
+			body := strings.TrimSpace(c.Body)
+			if body == "" {
+				continue
+			}
+			if c.Author != "" {
+				fmt.Fprintf(&b, "\n[%s]\n%s\n", c.Author, body)
+			} else {
+				fmt.Fprintf(&b, "\n%s\n", body)
+			}
+		}
+	}
+	return b.String()
+}
+
+// Queries returns retrieval queries derived from the issue: its title plus
+// any `code spans` mentioned in the description or comments.
+func (i *Issue) Queries(max int) []string {
+	queries := []string{i.Title}
+	seen := map[string]bool{i.Title: true}
+
+	texts := []string{i.Description}
+	for _, c := range i.Comments {
+		texts = append(texts, c.Body)
+	}
+	for _, text := range texts {
+		for _, m := range codeSpanPattern.FindAllStringSubmatch(text, -1) {
+			q := strings.TrimSpace(m[1])
+			if q == "" || seen[q] {
+				continue
+			}
+			seen[q] = true
+			queries = append(queries, q)
+			if len(queries) >= max {
+				return queries
+			}
+		}
+	}
+	return queries
+}
@@ -0,0 +1,86 @@
+package tracker
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/yourorg/agent/internal/proxy"
+)
+
+// JiraClient reads issues through the Jira REST API (v2, which returns
+// descriptions as plain wiki text rather than ADF documents).
+type JiraClient struct {
+	baseURL string
+	auth    string
+	client  *http.Client
+}
+
+// JiraConfig configures a JiraClient. Jira Cloud uses Email plus an API
+// token; Jira Server/Data Center uses a personal access token without Email.
+type JiraConfig struct {
+	BaseURL string
+	Email   string
+	Token   string
+}
+
+// NewJiraClient creates a Jira API client.
+func NewJiraClient(cfg JiraConfig) (*JiraClient, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("Jira URL is required (set JIRA_URL)")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("Jira token is required (set JIRA_API_TOKEN)")
+	}
+
+	auth := "Bearer " + cfg.Token
+	if cfg.Email != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(cfg.Email+":"+cfg.Token))
+	}
+
+	return &JiraClient{
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		auth:    auth,
+		client:  proxy.Client("jira", 0),
+	}, nil
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		Comment     struct {
+			Comments []struct {
+				Author struct {
+					DisplayName string `json:"displayName"`
+				} `json:"author"`
+				Body string `json:"body"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+// Fetch loads the issue with the given key, e.g. "ABC-123".
+func (c *JiraClient) Fetch(ctx context.Context, key string) (*Issue, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=summary,description,comment", c.baseURL, url.PathEscape(key))
+
+	var ji jiraIssue
+	if err := doJSON(ctx, c.client, "GET", endpoint, map[string]string{"Authorization": c.auth}, nil, &ji); err != nil {
+		return nil, fmt.Errorf("jira: %w", err)
+	}
+
+	issue := &Issue{
+		Key:         ji.Key,
+		Title:       ji.Fields.Summary,
+		Description: ji.Fields.Description,
+		URL:         c.baseURL + "/browse/" + ji.Key,
+	}
+	for _, cm := range ji.Fields.Comment.Comments {
+		issue.Comments = append(issue.Comments, Comment{Author: cm.Author.DisplayName, Body: cm.Body})
+	}
+	return issue, nil
+}
@@ -0,0 +1,97 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/yourorg/agent/internal/proxy"
+)
+
+const linearAPIURL = "https://api.linear.app/graphql"
+
+// LinearClient reads issues through Linear's GraphQL API.
+type LinearClient struct {
+	apiKey string
+	client *http.Client
+}
+
+// NewLinearClient creates a Linear API client.
+func NewLinearClient(apiKey string) (*LinearClient, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("Linear API key is required (set LINEAR_API_KEY)")
+	}
+	return &LinearClient{apiKey: apiKey, client: proxy.Client("linear", 0)}, nil
+}
+
+const linearIssueQuery = `query Issue($id: String!) {
+  issue(id: $id) {
+    identifier
+    title
+    description
+    url
+    comments { nodes { body user { name } } }
+  }
+}`
+
+type linearRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type linearResponse struct {
+	Data struct {
+		Issue *struct {
+			Identifier  string `json:"identifier"`
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			URL         string `json:"url"`
+			Comments    struct {
+				Nodes []struct {
+					Body string `json:"body"`
+					User *struct {
+						Name string `json:"name"`
+					} `json:"user"`
+				} `json:"nodes"`
+			} `json:"comments"`
+		} `json:"issue"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Fetch loads the issue with the given identifier, e.g. "ENG-42".
+func (c *LinearClient) Fetch(ctx context.Context, key string) (*Issue, error) {
+	reqBody := linearRequest{
+		Query:     linearIssueQuery,
+		Variables: map[string]interface{}{"id": key},
+	}
+
+	var resp linearResponse
+	if err := doJSON(ctx, c.client, "POST", linearAPIURL, map[string]string{"Authorization": c.apiKey}, reqBody, &resp); err != nil {
+		return nil, fmt.Errorf("linear: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("linear: %s", resp.Errors[0].Message)
+	}
+	li := resp.Data.Issue
+	if li == nil {
+		return nil, fmt.Errorf("linear: issue %s not found", key)
+	}
+
+	issue := &Issue{
+		Key:         li.Identifier,
+		Title:       li.Title,
+		Description: li.Description,
+		URL:         li.URL,
+	}
+	for _, n := range li.Comments.Nodes {
+		cm := Comment{Body: n.Body}
+		if n.User != nil {
+			cm.Author = n.User.Name
+		}
+		issue.Comments = append(issue.Comments, cm)
+	}
+	return issue, nil
+}
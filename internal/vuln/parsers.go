@@ -0,0 +1,229 @@
+package vuln
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type govulnMessage struct {
+	OSV *struct {
+		ID       string   `json:"id"`
+		Aliases  []string `json:"aliases"`
+		Summary  string   `json:"summary"`
+		Details  string   `json:"details"`
+		Affected []struct {
+			Package struct {
+				Name string `json:"name"`
+			} `json:"package"`
+		} `json:"affected"`
+	} `json:"osv"`
+	Finding *struct {
+		OSV          string `json:"osv"`
+		FixedVersion string `json:"fixed_version"`
+		Trace        []struct {
+			Module   string `json:"module"`
+			Version  string `json:"version"`
+			Package  string `json:"package"`
+			Function string `json:"function"`
+			Receiver string `json:"receiver"`
+			Position *struct {
+				Filename string `json:"filename"`
+				Line     int    `json:"line"`
+			} `json:"position"`
+		} `json:"trace"`
+	} `json:"finding"`
+}
+
+// parseGovulncheck reads the govulncheck -json message stream. Only
+// findings whose trace reaches a vulnerable function (i.e. the vulnerable
+// code is actually called) are reported; each trace is reordered to run
+// from project code to the vulnerable symbol.
+func parseGovulncheck(out []byte, root string) ([]Finding, error) {
+	type osvInfo struct {
+		aliases []string
+		summary string
+	}
+	osvs := make(map[string]osvInfo)
+	var findings []Finding
+	seen := make(map[string]bool)
+
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var msg govulnMessage
+		if err := dec.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if msg.OSV != nil {
+			summary := msg.OSV.Summary
+			if summary == "" {
+				summary = firstLine(msg.OSV.Details)
+			}
+			osvs[msg.OSV.ID] = osvInfo{aliases: msg.OSV.Aliases, summary: summary}
+			continue
+		}
+		if msg.Finding == nil || len(msg.Finding.Trace) == 0 || msg.Finding.Trace[0].Function == "" {
+			continue
+		}
+
+		vulnerable := msg.Finding.Trace[0]
+		f := Finding{
+			ID:      msg.Finding.OSV,
+			Package: vulnerable.Package,
+			Version: vulnerable.Version,
+			FixedIn: msg.Finding.FixedVersion,
+		}
+		for i := len(msg.Finding.Trace) - 1; i >= 0; i-- {
+			fr := msg.Finding.Trace[i]
+			name := fr.Function
+			if fr.Receiver != "" {
+				name = strings.TrimPrefix(fr.Receiver, "*") + "." + name
+			}
+			frame := Frame{Function: fr.Package + "." + name}
+			if fr.Position != nil {
+				frame.File, frame.Line = projectFile(root, fr.Position.Filename), fr.Position.Line
+			}
+			f.Trace = append(f.Trace, frame)
+		}
+
+		key := f.ID + "|" + f.Trace[0].Function
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		findings = append(findings, f)
+	}
+
+	for i := range findings {
+		info := osvs[findings[i].ID]
+		findings[i].Aliases, findings[i].Summary = info.aliases, info.summary
+	}
+	return findings, nil
+}
+
+type npmAuditReport struct {
+	Vulnerabilities map[string]struct {
+		Name         string            `json:"name"`
+		Severity     string            `json:"severity"`
+		Via          []json.RawMessage `json:"via"`
+		FixAvailable json.RawMessage   `json:"fixAvailable"`
+	} `json:"vulnerabilities"`
+}
+
+type npmAdvisory struct {
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Severity string `json:"severity"`
+	Range    string `json:"range"`
+}
+
+// parseNpmAudit reads `npm audit --json` (npm 7+). Entries that are only
+// vulnerable through another package are skipped; the advisory is
+// reported once against the package that has it.
+func parseNpmAudit(out []byte, _ string) ([]Finding, error) {
+	var report npmAuditReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, v := range report.Vulnerabilities {
+		fix := ""
+		var fixObj struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(v.FixAvailable, &fixObj) == nil && fixObj.Version != "" {
+			fix = fmt.Sprintf("%s@%s", fixObj.Name, fixObj.Version)
+		}
+
+		for _, raw := range v.Via {
+			var adv npmAdvisory
+			if json.Unmarshal(raw, &adv) != nil || adv.URL == "" {
+				continue // a string naming the dependency it comes through
+			}
+			findings = append(findings, Finding{
+				ID:       adv.URL[strings.LastIndex(adv.URL, "/")+1:],
+				Package:  adv.Name,
+				Version:  adv.Range,
+				FixedIn:  fix,
+				Severity: adv.Severity,
+				Summary:  adv.Title,
+			})
+		}
+	}
+	return findings, nil
+}
+
+type pipAuditDependency struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Vulns   []struct {
+		ID          string   `json:"id"`
+		FixVersions []string `json:"fix_versions"`
+		Aliases     []string `json:"aliases"`
+		Description string   `json:"description"`
+	} `json:"vulns"`
+}
+
+// parsePipAudit reads `pip-audit -f json`, accepting both the current
+// {"dependencies": [...]} layout and the older bare list.
+func parsePipAudit(out []byte, _ string) ([]Finding, error) {
+	var deps []pipAuditDependency
+	var report struct {
+		Dependencies []pipAuditDependency `json:"dependencies"`
+	}
+	if err := json.Unmarshal(out, &report); err == nil {
+		deps = report.Dependencies
+	} else if err := json.Unmarshal(out, &deps); err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, d := range deps {
+		for _, v := range d.Vulns {
+			findings = append(findings, Finding{
+				ID:      v.ID,
+				Aliases: v.Aliases,
+				Package: d.Name,
+				Version: d.Version,
+				FixedIn: strings.Join(v.FixVersions, ", "),
+				Summary: firstLine(v.Description),
+			})
+		}
+	}
+	return findings, nil
+}
+
+// projectFile returns filename relative to root when it lies inside the
+// project, or "" for files in dependencies and the standard library.
+func projectFile(root, filename string) string {
+	path := filename
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
@@ -0,0 +1,59 @@
+package vuln
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yourorg/agent/internal/indexer"
+)
+
+// MapSymbols fills in the project symbols and modules each finding touches.
+// For findings with a call path, the project functions on the path and
+// their callers in the index are reported; otherwise the modules importing
+// the vulnerable package are.
+func MapSymbols(findings []Finding, engine *indexer.SearchEngine) {
+	for i := range findings {
+		f := &findings[i]
+
+		symbols := make(map[string]bool)
+		for _, fr := range f.Trace {
+			if fr.File == "" {
+				continue
+			}
+			name := shortName(fr.Function)
+			symbols[name] = true
+			for _, caller := range engine.SearchByCallGraph(name, "callers") {
+				symbols[caller] = true
+			}
+		}
+		f.Symbols = sortedKeys(symbols)
+
+		if len(f.Trace) == 0 {
+			f.Modules = engine.SearchImports(f.Package, "imported_by")
+		}
+	}
+}
+
+// shortName strips the import path from "example.com/pkg.Type.Method",
+// leaving "Type.Method" as the index names it.
+func shortName(function string) string {
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		function = function[i+1:]
+	}
+	if i := strings.Index(function, "."); i >= 0 {
+		function = function[i+1:]
+	}
+	return function
+}
+
+func sortedKeys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,299 @@
+// Package vuln runs dependency vulnerability scanners (govulncheck, npm
+// audit, pip-audit) and ties their findings to the project's own symbols so
+// "fix vulnerability X" tasks start from the affected code.
+package vuln
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Frame is one step of a call path from project code to a vulnerable symbol.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file,omitempty"` // project-relative for project frames
+	Line     int    `json:"line,omitempty"`
+}
+
+func (f Frame) String() string {
+	if f.File == "" {
+		return f.Function
+	}
+	return fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line)
+}
+
+// Finding is one vulnerability reported against a dependency.
+type Finding struct {
+	Tool     string   `json:"tool"`
+	ID       string   `json:"id"` // GO-…, GHSA-…, PYSEC-…
+	Aliases  []string `json:"aliases,omitempty"`
+	Package  string   `json:"package"`
+	Version  string   `json:"version,omitempty"`  // installed version or affected range
+	FixedIn  string   `json:"fixed_in,omitempty"` // first fixed version, if known
+	Severity string   `json:"severity,omitempty"`
+	Summary  string   `json:"summary,omitempty"`
+	// Trace is the call path from project code to the vulnerable symbol,
+	// when the scanner computes reachability (govulncheck).
+	Trace []Frame `json:"trace,omitempty"`
+	// Symbols are indexed project symbols that reach the vulnerable code,
+	// filled in by MapSymbols.
+	Symbols []string `json:"symbols,omitempty"`
+	// Modules are project modules that import the vulnerable package.
+	Modules []string `json:"modules,omitempty"`
+}
+
+// Matches reports whether the finding is known by id (case-insensitive).
+func (f Finding) Matches(id string) bool {
+	if strings.EqualFold(f.ID, id) {
+		return true
+	}
+	for _, a := range f.Aliases {
+		if strings.EqualFold(a, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tool describes a scanner command and the project files that enable it.
+type Tool struct {
+	Name    string
+	Command []string
+	Markers []string
+	parse   func(out []byte, root string) ([]Finding, error)
+}
+
+// DefaultTools are the built-in scanners.
+var DefaultTools = []Tool{
+	{Name: "govulncheck", Command: []string{"govulncheck", "-json", "./..."}, Markers: []string{"go.mod"}, parse: parseGovulncheck},
+	{Name: "npm audit", Command: []string{"npm", "audit", "--json"}, Markers: []string{"package-lock.json"}, parse: parseNpmAudit},
+	{Name: "pip-audit", Command: []string{"pip-audit", "-f", "json", "-r", "requirements.txt"}, Markers: []string{"requirements.txt"}, parse: parsePipAudit},
+}
+
+// Scanner runs the applicable tools for a project and caches the results.
+type Scanner struct {
+	projectRoot string
+	tools       []Tool
+	timeout     time.Duration
+
+	mu      sync.Mutex
+	cached  []Finding
+	errs    []error
+	scanned bool
+}
+
+// ScannerConfig configures a Scanner.
+type ScannerConfig struct {
+	ProjectRoot string
+	// Tools restricts scanning to these tool names (default: all that apply).
+	Tools []string
+	// Timeout bounds each tool run (default 5 minutes).
+	Timeout time.Duration
+}
+
+// NewScanner creates a scanner for the project.
+func NewScanner(cfg ScannerConfig) *Scanner {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	var tools []Tool
+	for _, t := range DefaultTools {
+		if len(cfg.Tools) > 0 && !contains(cfg.Tools, t.Name) {
+			continue
+		}
+		if applies(cfg.ProjectRoot, t) {
+			tools = append(tools, t)
+		}
+	}
+
+	return &Scanner{
+		projectRoot: cfg.ProjectRoot,
+		tools:       tools,
+		timeout:     timeout,
+	}
+}
+
+// Tools returns the names of the tools the scanner will run.
+func (s *Scanner) Tools() []string {
+	names := make([]string, len(s.tools))
+	for i, t := range s.tools {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// Scan runs every tool once and returns all findings, sorted by package
+// then ID, along with any per-tool errors. Later calls return the cached
+// results.
+func (s *Scanner) Scan(ctx context.Context) ([]Finding, []error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scanned {
+		return s.cached, s.errs
+	}
+
+	var findings []Finding
+	var errs []error
+	for _, t := range s.tools {
+		found, err := s.run(ctx, t)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.Name, err))
+			continue
+		}
+		findings = append(findings, found...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Package != findings[j].Package {
+			return findings[i].Package < findings[j].Package
+		}
+		return findings[i].ID < findings[j].ID
+	})
+
+	s.cached, s.errs, s.scanned = findings, errs, true
+	return findings, errs
+}
+
+func (s *Scanner) run(ctx context.Context, t Tool) ([]Finding, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, t.Command[0], t.Command[1:]...)
+	cmd.Dir = s.projectRoot
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Scanners exit non-zero when they find vulnerabilities; judge by output.
+	runErr := cmd.Run()
+	if stdout.Len() == 0 {
+		if runErr != nil {
+			return nil, fmt.Errorf("%v: %s", runErr, strings.TrimSpace(stderr.String()))
+		}
+		return nil, nil
+	}
+
+	findings, err := t.parse(stdout.Bytes(), s.projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("parse output: %w", err)
+	}
+	for i := range findings {
+		findings[i].Tool = t.Name
+	}
+	return findings, nil
+}
+
+// idPattern matches advisory identifiers as they appear in task text.
+var idPattern = regexp.MustCompile(`(?i)\b(CVE-\d{4}-\d{4,}|GHSA(?:-[0-9a-z]{4}){3}|GO-\d{4}-\d{4,}|PYSEC-\d{4}-\d+)\b`)
+
+// MentionedIDs extracts advisory IDs from text.
+func MentionedIDs(text string) []string {
+	return idPattern.FindAllString(text, -1)
+}
+
+// IsVulnerabilityTask reports whether a task is about fixing vulnerabilities.
+func IsVulnerabilityTask(text string) bool {
+	lower := strings.ToLower(text)
+	return len(MentionedIDs(text)) > 0 ||
+		strings.Contains(lower, "vulnerab") ||
+		strings.Contains(lower, "security advisory") ||
+		strings.Contains(lower, "cve")
+}
+
+// Filter keeps the findings known by any of ids. With no ids it returns
+// findings unchanged.
+func Filter(findings []Finding, ids []string) []Finding {
+	if len(ids) == 0 {
+		return findings
+	}
+	var out []Finding
+	for _, f := range findings {
+		for _, id := range ids {
+			if f.Matches(id) {
+				out = append(out, f)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Format renders findings for a report or an LLM prompt, at most limit of
+// them, noting how many were omitted.
+func Format(findings []Finding, limit int) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	shown := findings
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+
+	var b strings.Builder
+	for _, f := range shown {
+		fmt.Fprintf(&b, "%s in %s", f.ID, f.Package)
+		if f.Version != "" {
+			fmt.Fprintf(&b, " %s", f.Version)
+		}
+		if f.Severity != "" {
+			fmt.Fprintf(&b, " [%s]", f.Severity)
+		}
+		fmt.Fprintf(&b, " (%s)\n", f.Tool)
+		if f.Summary != "" {
+			fmt.Fprintf(&b, "  %s\n", f.Summary)
+		}
+		if f.FixedIn != "" {
+			fmt.Fprintf(&b, "  Fixed in: %s\n", f.FixedIn)
+		}
+		if len(f.Aliases) > 0 {
+			fmt.Fprintf(&b, "  Aliases: %s\n", strings.Join(f.Aliases, ", "))
+		}
+		if len(f.Trace) > 0 {
+			frames := make([]string, len(f.Trace))
+			for i, fr := range f.Trace {
+				frames[i] = fr.String()
+			}
+			fmt.Fprintf(&b, "  Call path: %s\n", strings.Join(frames, " -> "))
+		}
+		if len(f.Symbols) > 0 {
+			fmt.Fprintf(&b, "  Affected symbols: %s\n", strings.Join(f.Symbols, ", "))
+		}
+		if len(f.Modules) > 0 {
+			fmt.Fprintf(&b, "  Importing modules: %s\n", strings.Join(f.Modules, ", "))
+		}
+	}
+	if omitted := len(findings) - len(shown); omitted > 0 {
+		fmt.Fprintf(&b, "(%d more finding(s) not shown)\n", omitted)
+	}
+	return b.String()
+}
+
+func applies(root string, t Tool) bool {
+	if _, err := exec.LookPath(t.Command[0]); err != nil {
+		return false
+	}
+	for _, marker := range t.Markers {
+		if _, err := os.Stat(filepath.Join(root, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
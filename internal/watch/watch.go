@@ -0,0 +1,163 @@
+// Package watch reports changes to a project's files in debounced batches,
+// so indexes can be refreshed incrementally while files are edited instead
+// of being rebuilt on every run.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long a burst of events must be quiet before it is
+// reported, so a save that touches several files, or a checkout, is
+// refreshed once.
+const DefaultDebounce = 500 * time.Millisecond
+
+// skipDirs are never watched: metadata, the indexes themselves (which a
+// refresh writes to), and dependencies and build output.
+var skipDirs = map[string]bool{
+	".git": true, ".index": true, "node_modules": true, "vendor": true,
+	"venv": true, ".venv": true, "__pycache__": true, "dist": true, "build": true,
+}
+
+// Config configures a Watcher.
+type Config struct {
+	// Root is the project directory, watched recursively.
+	Root string
+	// Debounce overrides DefaultDebounce.
+	Debounce time.Duration
+}
+
+// Watcher watches a project tree for file changes.
+type Watcher struct {
+	root     string
+	debounce time.Duration
+	fsw      *fsnotify.Watcher
+}
+
+// New starts watching every directory under cfg.Root.
+func New(cfg Config) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create watcher: %w", err)
+	}
+	w := &Watcher{root: cfg.Root, debounce: cfg.Debounce, fsw: fsw}
+	if w.debounce <= 0 {
+		w.debounce = DefaultDebounce
+	}
+	if _, err := w.addTree(cfg.Root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+// Run calls fn with the absolute paths of the files created, modified,
+// removed or renamed in each burst of changes, sorted, until ctx is done.
+// Files in a newly created directory are reported too. fn runs on Run's
+// goroutine; changes made meanwhile are reported in the next batch.
+func (w *Watcher) Run(ctx context.Context, fn func(changed []string)) error {
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			if w.skipped(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					files, err := w.addTree(event.Name)
+					if err != nil {
+						return err
+					}
+					for _, f := range files {
+						pending[f] = true
+					}
+					timer.Reset(w.debounce)
+					continue
+				}
+			}
+			pending[event.Name] = true
+			timer.Reset(w.debounce)
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("watch %s: %w", w.root, err)
+
+		case <-timer.C:
+			changed := make([]string, 0, len(pending))
+			for path := range pending {
+				changed = append(changed, path)
+			}
+			sort.Strings(changed)
+			clear(pending)
+			fn(changed)
+		}
+	}
+}
+
+// addTree watches dir and its subdirectories and returns the files in
+// them.
+func (w *Watcher) addTree(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Removed while walking, or unreadable: nothing to watch.
+			if path != dir && d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+			return nil
+		}
+		if path != w.root && skipDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(path); err != nil {
+			return fmt.Errorf("watch %s: %w", path, err)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// skipped reports whether path is inside a directory that is not watched.
+// Events for such a directory itself arrive from its watched parent.
+func (w *Watcher) skipped(path string) bool {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return true
+	}
+	for dir := rel; dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if skipDirs[filepath.Base(dir)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+// Package workspace splits a monorepo into named roots (go/, python/,
+// web/, ...), each indexed on its own with the languages it contains, so
+// context for a task can be fetched from one root or across all of them.
+//
+// Roots are configured under "workspace" in .indexer.json:
+//
+//	"workspace": {"roots": [
+//	  {"name": "api", "path": "go", "languages": ["go"]},
+//	  {"name": "ml", "path": "python", "languages": ["python"]}
+//	]}
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yourorg/agent/internal/indexer"
+)
+
+// Config lists the roots of a workspace. Without roots the project is a
+// single root.
+type Config struct {
+	Roots []Root `json:"roots,omitempty"`
+}
+
+// Root is a logical project within the workspace.
+type Root struct {
+	// Name identifies the root in -roots and results; defaults to the
+	// last element of Path.
+	Name string `json:"name,omitempty"`
+	// Path is the root's directory, relative to the project.
+	Path string `json:"path"`
+	// Languages restricts the parsers used for the root: "go", "python".
+	// Empty uses every parser.
+	Languages []string `json:"languages,omitempty"`
+
+	// Dir is the absolute directory, set by Resolve.
+	Dir string `json:"-"`
+}
+
+// languages are the languages with a structural parser.
+var languages = []string{"go", "python"}
+
+// Resolve checks the configured roots against the project at projectPath
+// and returns them with Dir set. Without configured roots it returns the
+// project itself as the only root.
+func (c Config) Resolve(projectPath string) ([]Root, error) {
+	if len(c.Roots) == 0 {
+		return []Root{{Name: filepath.Base(projectPath), Path: ".", Dir: projectPath}}, nil
+	}
+
+	seen := make(map[string]bool)
+	roots := make([]Root, 0, len(c.Roots))
+	for _, r := range c.Roots {
+		clean := filepath.Clean(filepath.FromSlash(r.Path))
+		if r.Path == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("workspace root %q: path must be inside the project", r.Path)
+		}
+		if r.Name == "" {
+			r.Name = filepath.Base(clean)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("workspace root %q is configured twice", r.Name)
+		}
+		seen[r.Name] = true
+		r.Languages = slices.Clone(r.Languages)
+		for i, lang := range r.Languages {
+			lang = strings.ToLower(lang)
+			if !slices.Contains(languages, lang) {
+				return nil, fmt.Errorf("workspace root %q: no parser for language %q (have go, python)", r.Name, lang)
+			}
+			r.Languages[i] = lang
+		}
+		r.Dir = filepath.Join(projectPath, clean)
+		if info, err := os.Stat(r.Dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("workspace root %q: %s is not a directory", r.Name, r.Dir)
+		}
+		roots = append(roots, r)
+	}
+	return roots, nil
+}
+
+// Select returns the roots named in a comma-separated list, in the list's
+// order. An empty list or "all" selects every root.
+func Select(roots []Root, names string) ([]Root, error) {
+	if names == "" || names == "all" {
+		return roots, nil
+	}
+	byName := make(map[string]Root, len(roots))
+	for _, r := range roots {
+		byName[r.Name] = r
+	}
+	var selected []Root
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		r, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown workspace root %q (have %s)", name, rootNames(roots))
+		}
+		selected = append(selected, r)
+	}
+	return selected, nil
+}
+
+// Contains reports whether file, slash-separated and relative to the
+// project, lies in one of roots.
+func Contains(roots []Root, file string) bool {
+	for _, r := range roots {
+		dir := path.Clean(filepath.ToSlash(r.Path))
+		if dir == "." || file == dir || strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NewIndexer returns an indexer with the parsers of the root's languages.
+func (r Root) NewIndexer() *indexer.Indexer {
+	idx := indexer.NewIndexer()
+	all := len(r.Languages) == 0
+	if all || slices.Contains(r.Languages, "go") {
+		idx.RegisterParser(indexer.NewGoParser())
+	}
+	if all || slices.Contains(r.Languages, "python") {
+		idx.RegisterParser(indexer.NewPythonParser())
+	}
+	return idx
+}
+
+// Context is the context fetched from one root.
+type Context struct {
+	Root    string                  `json:"root"`
+	Path    string                  `json:"path"`
+	Context *indexer.ProjectContext `json:"context"`
+}
+
+// FetchContext fetches context for task from each root, indexing them
+// with index, and returns them in the roots' order. maxResults applies
+// to each root.
+func FetchContext(roots []Root, task string, maxResults int, index func(Root) (*indexer.ProjectIndex, error)) ([]Context, error) {
+	contexts := make([]Context, 0, len(roots))
+	for _, r := range roots {
+		projIdx, err := index(r)
+		if err != nil {
+			return nil, fmt.Errorf("index workspace root %s: %w", r.Name, err)
+		}
+		ctx := indexer.NewContextFetcher(projIdx).FetchContext(task, maxResults)
+		contexts = append(contexts, Context{Root: r.Name, Path: r.Path, Context: ctx})
+	}
+	return contexts, nil
+}
+
+// Format renders contexts one after another. A single context is
+// rendered as is, so a project without roots looks as before.
+func Format(contexts []Context) string {
+	if len(contexts) == 1 {
+		return indexer.FormatContext(contexts[0].Context)
+	}
+	var b strings.Builder
+	for i, c := range contexts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "=== Workspace root: %s (%s) ===\n\n", c.Root, c.Path)
+		b.WriteString(indexer.FormatContext(c.Context))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func rootNames(roots []Root) string {
+	names := make([]string, len(roots))
+	for i, r := range roots {
+		names[i] = r.Name
+	}
+	return strings.Join(names, ", ")
+}